@@ -0,0 +1,212 @@
+//go:build lite
+
+// Command jivefire-lite renders a WAV or AIFF file to a numbered image
+// sequence without FFmpeg: the audio is decoded by internal/wav and the FFT
+// is pure Go, so the binary builds with go build -tags lite and none of the
+// static FFmpeg libraries. The frames are encoded, with the original audio,
+// by an encoder installed separately.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/alecthomas/kong"
+	"github.com/linuxmatters/jivefire/internal/audio"
+	"github.com/linuxmatters/jivefire/internal/cli"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/failure"
+	"github.com/linuxmatters/jivefire/internal/frames"
+	"github.com/linuxmatters/jivefire/internal/renderer"
+)
+
+var version = "dev"
+
+var CLI struct {
+	Input           string   `arg:"" help:"Input WAV or AIFF file" type:"path"`
+	FramesDir       string   `arg:"" help:"Directory to write every frame into as a numbered image (frame-000001.png, ...)" type:"path"`
+	Title           string   `help:"Podcast title" default:"Podcast Title"`
+	Episode         *int     `help:"Episode number (omitted from output when not set)"`
+	Font            string   `help:"Path to a TrueType font for the title, badge and thumbnail text (for scripts the built-in font lacks)"`
+	FontFallback    []string `help:"Path to a TrueType font tried for characters the title font lacks, such as another script or emoji; repeat to try several in order"`
+	BackgroundImage string   `help:"Path to custom background image (PNG, JPEG or WebP; scaled to 1280x720)"`
+	FramesFormat    string   `help:"Image format for the frames: png or jpeg" default:"png"`
+	Thumbnail       string   `help:"Also write the thumbnail PNG to this path" type:"path"`
+	Version         bool     `help:"Show version information"`
+}
+
+func main() {
+	kong.Parse(
+		&CLI,
+		kong.Name("jivefire-lite"),
+		kong.Description("Spin your podcast .wav into visualiser frames, without FFmpeg."),
+		kong.UsageOnError(),
+		kong.Help(cli.StyledHelpPrinter(kong.HelpOptions{Compact: true})),
+	)
+
+	if CLI.Version {
+		cli.PrintVersion(version)
+		os.Exit(0)
+	}
+
+	if _, err := os.Stat(CLI.Input); os.IsNotExist(err) {
+		cli.PrintError(fmt.Sprintf("input file does not exist: %s", CLI.Input))
+		os.Exit(failure.ExitInputNotFound)
+	}
+	format, err := frames.ParseFormat(CLI.FramesFormat)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --frames-format: %v", err))
+		os.Exit(1)
+	}
+
+	runtimeConfig := &config.RuntimeConfig{}
+	if CLI.Font != "" {
+		if _, err := os.Stat(CLI.Font); os.IsNotExist(err) {
+			cli.PrintError(fmt.Sprintf("font does not exist: %s", CLI.Font))
+			os.Exit(failure.ExitInputNotFound)
+		}
+		runtimeConfig.FontPath = CLI.Font
+	}
+	for _, path := range CLI.FontFallback {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			cli.PrintError(fmt.Sprintf("font does not exist: %s", path))
+			os.Exit(failure.ExitInputNotFound)
+		}
+	}
+	runtimeConfig.FontFallbacks = CLI.FontFallback
+	if CLI.BackgroundImage != "" {
+		if _, err := os.Stat(CLI.BackgroundImage); os.IsNotExist(err) {
+			cli.PrintError(fmt.Sprintf("background image does not exist: %s", CLI.BackgroundImage))
+			os.Exit(failure.ExitInputNotFound)
+		}
+		if err := renderer.ValidateImage(CLI.BackgroundImage); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --background-image: %v", err))
+			os.Exit(failure.ExitCode(err))
+		}
+		runtimeConfig.BackgroundImagePath = CLI.BackgroundImage
+	}
+
+	meta := renderer.PodcastMeta{Title: CLI.Title, Episode: CLI.Episode}
+	missing, err := renderer.MissingGlyphs(meta.Title, runtimeConfig)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --font or --font-fallback: %v", err))
+		os.Exit(1)
+	}
+	if len(missing) > 0 {
+		cli.PrintWarning(fmt.Sprintf("title font has no glyphs for %q; use --font or --font-fallback with a font that covers them", string(missing)))
+	}
+
+	writer, err := frames.New(CLI.FramesDir, format)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("creating frames directory: %v", err))
+		os.Exit(1)
+	}
+	numFrames, err := render(CLI.Input, writer, meta, runtimeConfig)
+	if err != nil {
+		cli.PrintError(err.Error())
+		os.Exit(failure.ExitCode(err))
+	}
+
+	if CLI.Thumbnail != "" {
+		if err := renderer.GenerateThumbnail(CLI.Thumbnail, meta, runtimeConfig); err != nil {
+			cli.PrintError(fmt.Sprintf("generating thumbnail: %v", err))
+			os.Exit(failure.ExitCode(err))
+		}
+	}
+
+	fmt.Printf("Wrote %d frames to %s at %d fps; encode them with the audio, e.g.\n", numFrames, writer.Pattern(), config.FPS)
+	fmt.Printf("  ffmpeg -framerate %d -i %s -i %s -c:v libx264 -pix_fmt yuv420p -c:a aac -shortest output.mp4\n", config.FPS, writer.Pattern(), CLI.Input)
+}
+
+// render runs both passes over inputFile, as jivefire does: Pass 1 analyses
+// the audio for the bar scale, Pass 2 draws a frame per 1/FPS of audio into
+// writer. It returns the number of frames written.
+func render(inputFile string, writer *frames.Writer, meta renderer.PodcastMeta, runtimeConfig *config.RuntimeConfig) (int, error) {
+	reader, err := audio.NewStreamingReader(inputFile)
+	if err != nil {
+		return 0, fmt.Errorf("opening audio: %w", err)
+	}
+	defer reader.Close()
+
+	profile, err := audio.AnalyzeReader(reader, audio.Span{}, audio.FreqRange{}, audio.VisFilter{}, nil, nil)
+	if err != nil {
+		return 0, fmt.Errorf("analysing audio: %w", err)
+	}
+	if err := reader.Seek(0); err != nil {
+		return 0, fmt.Errorf("rewinding audio: %w", err)
+	}
+
+	// Missing artwork and fonts degrade the frames rather than fail them, as
+	// in jivefire.
+	bgImage, err := renderer.LoadBackgroundImage(runtimeConfig)
+	if err != nil {
+		bgImage = nil
+		cli.PrintWarning(fmt.Sprintf("could not load background image, rendering without it: %v", err))
+	}
+	fontFace, err := renderer.LoadTitleFont(meta.Title, runtimeConfig)
+	if err != nil {
+		fontFace = nil
+		cli.PrintWarning(fmt.Sprintf("could not load font, rendering without centre text: %v", err))
+	}
+
+	processor, err := audio.NewProcessor()
+	if err != nil {
+		return 0, fmt.Errorf("creating FFT processor: %w", err)
+	}
+	defer processor.Close()
+	frame := renderer.NewFrame(bgImage, fontFace, meta, runtimeConfig)
+	vis, err := renderer.NewVisualizer(runtimeConfig.Visualizer, runtimeConfig)
+	if err != nil {
+		return 0, fmt.Errorf("creating visualizer: %w", err)
+	}
+	frame.SetVisualizer(vis)
+	badgeFont, err := renderer.LoadBadgeFont(runtimeConfig)
+	if err != nil && meta.Episode != nil {
+		cli.PrintWarning(fmt.Sprintf("could not load the badge font, rendering without the episode number: %v", err))
+	}
+	frame.SetBadgeFont(badgeFont)
+	frame.SetTotalFrames(profile.NumFrames)
+
+	rate := reader.SampleRate()
+	bands, err := audio.NewBands(rate, audio.FreqRange{})
+	if err != nil {
+		return 0, err
+	}
+	smoother := audio.NewSmoother(config.NumBars, config.Framerate, float64(config.Height/2-config.CenterGap/2)*config.MaxBarHeight)
+	gate := audio.Gate{Threshold: runtimeConfig.GetNoiseGate(), Floor: runtimeConfig.MinBar}
+	dcBlock := audio.NewDCBlocker(rate)
+
+	// Each frame reads 1/FPS of audio and slides it into the FFT window, DC
+	// offset removed as in Pass 1.
+	samples := make([]float64, rate/config.FPS)
+	fftBuffer := make([]float64, config.FFTSize)
+	barHeights := make([]float64, config.NumBars)
+	rearrangedHeights := make([]float64, config.NumBars)
+	frameNum := 0
+	for ; ; frameNum++ {
+		n, err := audio.ReadNextFrame(reader, samples)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return frameNum, fmt.Errorf("reading audio: %w", err)
+		}
+		dcBlock.Apply(samples[:n], samples[:n])
+		audio.ShiftIn(fftBuffer, samples[:n])
+
+		coeffs := processor.ProcessChunk(fftBuffer)
+		audio.BinFFT(coeffs, bands, smoother.Sensitivity(), profile.OptimalBaseScale, gate, barHeights)
+		smoother.Step(barHeights)
+		audio.RearrangeFrequenciesCenterOut(barHeights, rearrangedHeights)
+		frame.Draw(rearrangedHeights)
+		if err := writer.Write(frameNum, frame.GetImage()); err != nil {
+			return frameNum, fmt.Errorf("error writing frame %d: %w", frameNum, err)
+		}
+	}
+	if frameNum == 0 {
+		return 0, errors.New("no audio data available")
+	}
+	return frameNum, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/alecthomas/kong"
+	"github.com/linuxmatters/jivefire/internal/analysis"
+	"github.com/linuxmatters/jivefire/internal/audio"
+	"github.com/linuxmatters/jivefire/internal/cli"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/encoder"
+)
+
+// analyzeCmd runs Pass 1 alone and saves it, for `jivefire render
+// --analysis` to render from on another machine: the analysis where the
+// audio lives, the encode on a box with a GPU. It takes every render flag so
+// one command line serves both; those Pass 1 does not use are ignored.
+type analyzeCmd struct {
+	Input string `arg:"" name:"input" help:"Input audio file" optional:""`
+	Out   string `help:"Analysis file to write (e.g. profile.json)"`
+	renderFlags
+}
+
+// analysisIO is how a render uses a saved analysis: write stops after Pass 1
+// and saves it to the destination, from skips Pass 1 for a loaded one.
+type analysisIO struct {
+	write bool
+	from  *analysis.File
+}
+
+// runAnalyze validates the analyze flags and runs Pass 1 through the usual
+// render path.
+func runAnalyze(ctx *kong.Context, cmd *analyzeCmd) {
+	if cmd.Input == "" && cmd.Out == "" {
+		_ = ctx.PrintUsage(true)
+		os.Exit(0)
+	}
+	if cmd.Input == "" || cmd.Out == "" {
+		cli.PrintError("<input> and --out are required")
+		os.Exit(1)
+	}
+	if cmd.Out == encoder.StdoutPath {
+		cli.PrintError("--out needs a file, not stdout")
+		os.Exit(1)
+	}
+	for _, unsupported := range []struct {
+		set  bool
+		flag string
+	}{
+		{cmd.Analysis != "", "--analysis"},
+		{cmd.FramesDir != "" || cmd.FramesOnly, "--frames-dir"},
+		{cmd.AudioOut != "", "--audio-out"},
+		{cmd.Report != "", "--report"},
+		{cmd.NotifyURL != "" || cmd.NotifyCmd != "", "--notify-url and --notify-cmd"},
+		{cmd.PreviewWindow, "--preview-window"},
+		{cmd.Play, "--play"},
+		{cmd.Archive, "--archive"},
+		{cmd.Segments != 0 || cmd.Segment != "" || len(cmd.Join) > 0, "--segments, --segment and --join"},
+	} {
+		if unsupported.set {
+			cli.PrintError(fmt.Sprintf("%s is not available for analyze", unsupported.flag))
+			os.Exit(1)
+		}
+	}
+
+	// Nothing is rendered, so there is no thumbnail to draw.
+	cmd.NoThumbnail = true
+	cmd.WriteDescription = false
+	cmd.Thumbnails = 0
+	runRender(ctx, &renderCmd{Input: cmd.Input, renderFlags: cmd.renderFlags, analysisOut: cmd.Out})
+}
+
+// analysisInput identifies inputFile for the analysis file.
+func analysisInput(inputFile string, metadata *audio.Metadata) (analysis.Input, error) {
+	info, err := os.Stat(inputFile)
+	if err != nil {
+		return analysis.Input{}, err
+	}
+	return analysis.Input{
+		Name:       filepath.Base(inputFile),
+		Size:       info.Size(),
+		SampleRate: metadata.SampleRate,
+		Channels:   metadata.Channels,
+		Duration:   metadata.Duration.Seconds(),
+	}, nil
+}
+
+// analysisSettings returns the render settings Pass 1 depends on.
+func analysisSettings(span audio.Span, length float64, runtimeConfig *config.RuntimeConfig) analysis.Settings {
+	base := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		return filepath.Base(path)
+	}
+	return analysis.Settings{
+		Start:       span.Start.Seconds(),
+		Duration:    length,
+		Speed:       span.Speed,
+		Channels:    span.Channels,
+		FreqMin:     runtimeConfig.FreqMin,
+		FreqMax:     runtimeConfig.FreqMax,
+		VisHighpass: runtimeConfig.VisHighPass,
+		VisLowpass:  runtimeConfig.VisLowPass,
+		Declip:      runtimeConfig.Declip,
+		Music:       base(runtimeConfig.MusicPath),
+		MusicGain:   runtimeConfig.MusicGainDB,
+		MusicDuck:   runtimeConfig.MusicDuckDB,
+		Intro:       base(runtimeConfig.IntroPath),
+		Outro:       base(runtimeConfig.OutroPath),
+		Crossfade:   runtimeConfig.Crossfade.Seconds(),
+	}
+}
+
+// saveProfile converts a Pass 1 profile for the analysis file.
+func saveProfile(p *audio.Profile) analysis.Profile {
+	saved := analysis.Profile{
+		Frames:       p.NumFrames,
+		GlobalPeak:   p.GlobalPeak,
+		GlobalRMS:    p.GlobalRMS,
+		DynamicRange: p.DynamicRange,
+		TruePeak:     p.TruePeak,
+		OutputPeak:   p.OutputPeak,
+		DCOffset:     p.DCOffset,
+		Clipped:      p.ClippedFraction,
+		OptimalScale: p.OptimalBaseScale,
+		SampleRate:   p.SampleRate,
+		Duration:     p.Duration,
+	}
+	if !math.IsInf(p.Loudness, -1) {
+		saved.Loudness = &p.Loudness
+	}
+	return saved
+}
+
+// loadProfile converts a saved profile back for Pass 2.
+func loadProfile(p analysis.Profile) *audio.Profile {
+	loudness := math.Inf(-1)
+	if p.Loudness != nil {
+		loudness = *p.Loudness
+	}
+	return &audio.Profile{
+		NumFrames:        p.Frames,
+		GlobalPeak:       p.GlobalPeak,
+		GlobalRMS:        p.GlobalRMS,
+		DynamicRange:     p.DynamicRange,
+		TruePeak:         p.TruePeak,
+		Loudness:         loudness,
+		OutputPeak:       p.OutputPeak,
+		DCOffset:         p.DCOffset,
+		ClippedFraction:  p.Clipped,
+		OptimalBaseScale: p.OptimalScale,
+		SampleRate:       p.SampleRate,
+		Duration:         p.Duration,
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/linuxmatters/jivefire/internal/analysis"
+	"github.com/linuxmatters/jivefire/internal/encoder"
+)
+
+// maxArchiveInput is the largest source --archive attaches. FFmpeg holds an
+// attachment in memory, and its size in an int, until the muxer writes it.
+const maxArchiveInput = 1 << 30
+
+// audioMimeTypes maps a source's extension to the MIME type Matroska records
+// for its attachment; anything else is application/octet-stream.
+var audioMimeTypes = map[string]string{
+	".aac":  "audio/aac",
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+	".mp3":  "audio/mpeg",
+	".oga":  "audio/ogg",
+	".ogg":  "audio/ogg",
+	".opus": "audio/ogg",
+	".wav":  "audio/wav",
+}
+
+// checkArchive validates --archive against the output and the source it
+// will attach. The bundle needs an .mkv file: Matroska is the one muxer with
+// attachments, and its cues are written by seeking back, which stdout can't.
+func checkArchive(outputFile, format, input string) error {
+	if outputFile == encoder.StdoutPath {
+		return fmt.Errorf("needs an .mkv output file, not stdout")
+	}
+	if !strings.EqualFold(filepath.Ext(outputFile), ".mkv") {
+		return fmt.Errorf("%s: must end in .mkv", outputFile)
+	}
+	if format != "" {
+		return fmt.Errorf("cannot be combined with --format, as it always writes Matroska")
+	}
+	info, err := os.Stat(input)
+	if err != nil {
+		return err
+	}
+	if info.Size() > maxArchiveInput {
+		return fmt.Errorf("%s is %d MiB, over the %d MiB that can be attached", input, info.Size()>>20, maxArchiveInput>>20)
+	}
+	return nil
+}
+
+// archiveAttachments returns the source audio and its analysis, as
+// jivefire analyze would save it, for an --archive render to carry. The
+// analysis is named after the source, so the two extract side by side.
+func archiveAttachments(input string, saved analysis.File) ([]encoder.Attachment, error) {
+	source, err := os.ReadFile(input)
+	if err != nil {
+		return nil, fmt.Errorf("reading the source to attach: %w", err)
+	}
+	profile, err := analysis.Marshal(saved)
+	if err != nil {
+		return nil, fmt.Errorf("encoding the analysis to attach: %w", err)
+	}
+
+	name := filepath.Base(input)
+	mime, ok := audioMimeTypes[strings.ToLower(filepath.Ext(name))]
+	if !ok {
+		mime = "application/octet-stream"
+	}
+	return []encoder.Attachment{
+		{Name: name, MimeType: mime, Data: source},
+		{Name: strings.TrimSuffix(name, filepath.Ext(name)) + ".analysis.json", MimeType: "application/json", Data: profile},
+	}, nil
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/audio"
+	"github.com/linuxmatters/jivefire/internal/cli"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/encoder"
+	"github.com/linuxmatters/jivefire/internal/frames"
+	"github.com/linuxmatters/jivefire/internal/renderer"
+)
+
+// benchNames are the benchmarks jivefire bench runs, in pipeline order.
+var benchNames = []string{"fft", "draw", "yuv", "encode", "end-to-end"}
+
+// benchSampleRate and benchSignal shape the audio for the end-to-end
+// benchmark: the calibration tones, a sweep and pink noise, about 30s.
+const (
+	benchSampleRate = 48000
+	benchSignal     = 5 * time.Second
+)
+
+type benchCmd struct {
+	Benchmarks []string `arg:"" optional:"" help:"Benchmarks to run: fft, draw, yuv, encode, end-to-end (default: all)"`
+	Encoder    string   `help:"Video encoder for encode and end-to-end: auto, nvenc, qsv, vaapi, vulkan, amf, mf (Windows), software" default:"auto"`
+	Runs       int      `help:"How many times the end-to-end benchmark renders; it reports the median" default:"3"`
+	JSON       string   `help:"Also write the results to this file as JSON" type:"path"`
+}
+
+// benchResult is one benchmark's figures, per video frame so the stages
+// compare directly.
+type benchResult struct {
+	Name           string  `json:"name"`
+	Description    string  `json:"description"`
+	Iterations     int     `json:"iterations"`
+	NsPerFrame     int64   `json:"ns_per_frame"`
+	FPS            float64 `json:"fps"`
+	AllocsPerFrame int64   `json:"allocs_per_frame"`
+	BytesPerFrame  int64   `json:"bytes_per_frame"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// benchReport is the --json export.
+type benchReport struct {
+	Version   string        `json:"version"`
+	Generated time.Time     `json:"generated"`
+	GOOS      string        `json:"goos"`
+	GOARCH    string        `json:"goarch"`
+	CPUs      int           `json:"cpus"`
+	Results   []benchResult `json:"results"`
+}
+
+// runBench runs the chosen benchmarks, prints each as it finishes and
+// optionally exports them. It exits non-zero when any benchmark fails.
+func runBench(cmd *benchCmd) {
+	names := cmd.Benchmarks
+	if len(names) == 0 {
+		names = benchNames
+	}
+	for _, name := range names {
+		if !slices.Contains(benchNames, name) {
+			cli.PrintError(fmt.Sprintf("unknown benchmark %q (must be fft, draw, yuv, encode or end-to-end)", name))
+			os.Exit(1)
+		}
+	}
+	hwAccel, ok := validEncoders[cmd.Encoder]
+	if !ok {
+		cli.PrintError(fmt.Sprintf("invalid --encoder value: %s (must be auto, nvenc, qsv, vaapi, vulkan, amf, mf, or software)", cmd.Encoder))
+		os.Exit(1)
+	}
+	if cmd.Runs < 1 {
+		cli.PrintError(fmt.Sprintf("invalid --runs: %d (must be at least 1)", cmd.Runs))
+		os.Exit(1)
+	}
+
+	dir, err := os.MkdirTemp("", "jivefire-bench-")
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("creating a temporary directory: %v", err))
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	cli.PrintBenchHeader(config.Width, config.Height)
+	report := benchReport{
+		Version:   version,
+		Generated: time.Now().UTC(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+	}
+	failed := false
+	for _, name := range names {
+		r := runBenchmark(name, cmd, hwAccel, dir)
+		cli.PrintBenchResult(cli.BenchResult{
+			Name:        r.Name,
+			Description: r.Description,
+			PerFrame:    time.Duration(r.NsPerFrame),
+			FPS:         r.FPS,
+			Allocs:      r.AllocsPerFrame,
+			Bytes:       r.BytesPerFrame,
+			Err:         r.Error,
+		})
+		report.Results = append(report.Results, r)
+		failed = failed || r.Error != ""
+	}
+	fmt.Println()
+
+	if cmd.JSON != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(cmd.JSON, append(data, '\n'), 0o644)
+		}
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("writing --json: %v", err))
+			os.Exit(1)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// runBenchmark runs one benchmark by name.
+func runBenchmark(name string, cmd *benchCmd, hwAccel encoder.HWAccelType, dir string) benchResult {
+	switch name {
+	case "fft":
+		return benchFunc(name, "FFT and binning of one frame's audio", benchFFT)
+	case "draw":
+		return benchFunc(name, "Drawing one frame: background, bars and title", benchDraw)
+	case "yuv":
+		return benchFunc(name, fmt.Sprintf("RGBA to YUV420P conversion, %d×%d", config.Width, config.Height), benchYUV)
+	case "encode":
+		return benchEncode(hwAccel, dir)
+	default:
+		return benchEndToEnd(cmd, dir)
+	}
+}
+
+// benchFunc times fn, one b.Loop iteration per frame, with
+// testing.Benchmark. An error from fn fails the benchmark.
+func benchFunc(name, desc string, fn func(b *testing.B) error) benchResult {
+	var failure string
+	res := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		if err := fn(b); err != nil {
+			failure = err.Error()
+			b.FailNow()
+		}
+	})
+	r := benchResult{Name: name, Description: desc, Error: failure}
+	if res.N == 0 && r.Error == "" {
+		r.Error = "did not run"
+	}
+	if r.Error != "" {
+		return r
+	}
+	r.Iterations = res.N
+	r.NsPerFrame = res.NsPerOp()
+	r.FPS = perSecond(r.NsPerFrame)
+	r.AllocsPerFrame = res.AllocsPerOp()
+	r.BytesPerFrame = res.AllocedBytesPerOp()
+	return r
+}
+
+func perSecond(ns int64) float64 {
+	if ns <= 0 {
+		return 0
+	}
+	return float64(time.Second) / float64(ns)
+}
+
+// benchFFT analyses one frame's step of a two-tone signal per iteration, as
+// Pass 2 does: the FFT, then binning into bars.
+func benchFFT(b *testing.B) error {
+	processor, err := audio.NewProcessor()
+	if err != nil {
+		return err
+	}
+	defer processor.Close()
+	bands, err := audio.NewBands(benchSampleRate, audio.FreqRange{})
+	if err != nil {
+		return err
+	}
+	samples := make([]float64, config.FFTSize)
+	for i := range samples {
+		t := float64(i) / benchSampleRate
+		samples[i] = 0.4*math.Sin(2*math.Pi*110*t) + 0.2*math.Sin(2*math.Pi*2500*t)
+	}
+	result := make([]float64, config.NumBars)
+	for b.Loop() {
+		audio.BinFFT(processor.ProcessChunk(samples), bands, 1, 1, audio.Gate{}, result)
+	}
+	return nil
+}
+
+// benchFrame returns a frame with the embedded background and a title, as
+// an ordinary render draws.
+func benchFrame() *renderer.Frame {
+	runtimeConfig := &config.RuntimeConfig{}
+	episode := 1
+	meta := renderer.PodcastMeta{Title: "Jivefire Benchmark", Episode: &episode}
+	bgImage, _ := renderer.LoadBackgroundImage(runtimeConfig)
+	fontFace, _ := renderer.LoadTitleFont(meta.Title, runtimeConfig)
+	badgeFace, _ := renderer.LoadBadgeFont(runtimeConfig)
+	frame := renderer.NewFrame(bgImage, fontFace, meta, runtimeConfig)
+	frame.SetBadgeFont(badgeFace)
+	return frame
+}
+
+// benchDraw draws a frame of the self-test's rolling bars per iteration.
+func benchDraw(b *testing.B) error {
+	frame := benchFrame()
+	heights := make([]float64, config.NumBars)
+	n := 0
+	for b.Loop() {
+		selftestBars(heights, n)
+		frame.Draw(heights)
+		n++
+	}
+	return nil
+}
+
+// benchYUV converts a drawn frame per iteration with the software encoder's
+// Go path.
+func benchYUV(b *testing.B) error {
+	converter, err := encoder.NewConverter(config.Width, config.Height)
+	if err != nil {
+		return err
+	}
+	defer converter.Close()
+	frame := benchFrame()
+	heights := make([]float64, config.NumBars)
+	selftestBars(heights, 0)
+	frame.Draw(heights)
+	rgba := frame.GetImage().Pix
+	for b.Loop() {
+		converter.Convert(rgba)
+	}
+	return nil
+}
+
+// benchEncode encodes pre-drawn frames per iteration, so only the encoder's
+// time counts; the output is finished and timed too.
+func benchEncode(hwAccel encoder.HWAccelType, dir string) benchResult {
+	frame := benchFrame()
+	heights := make([]float64, config.NumBars)
+	rendered := make([][]byte, config.FPS)
+	for i := range rendered {
+		selftestBars(heights, i)
+		frame.Draw(heights)
+		rendered[i] = slices.Clone(frame.GetImage().Pix)
+	}
+
+	encoderName := "no encoder"
+	r := benchFunc("encode", "", func(b *testing.B) error {
+		enc, err := encoder.New(encoder.Config{
+			OutputPath: filepath.Join(dir, "encode.mp4"),
+			Width:      config.Width,
+			Height:     config.Height,
+			Framerate:  config.FPS,
+			HWAccel:    hwAccel,
+		})
+		if err != nil {
+			return err
+		}
+		if err := enc.Initialize(); err != nil {
+			return err
+		}
+		encoderName = enc.EncoderName()
+		i := 0
+		for b.Loop() {
+			if err := enc.WriteFrameRGBA(rendered[i%len(rendered)]); err != nil {
+				_ = enc.Close()
+				return err
+			}
+			i++
+		}
+		return enc.Close()
+	})
+	r.Description = "Video encoding with " + encoderName
+	return r
+}
+
+// benchEndToEnd renders the calibration signal with this binary cmd.Runs
+// times and reports the median per frame, everything included: decoding,
+// both passes, drawing and encoding.
+func benchEndToEnd(cmd *benchCmd, dir string) benchResult {
+	r := benchResult{Name: "end-to-end", Description: "Rendering a calibration signal, both passes"}
+	signal := audio.NewTestSignal(benchSampleRate, 20, 20000, benchSignal, benchSignal)
+	input := filepath.Join(dir, "signal.wav")
+	wav, err := frames.CreateWAV(input, benchSampleRate, 1)
+	if err == nil {
+		err = wav.Write(signal.Samples())
+		if closeErr := wav.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		r.Error = fmt.Sprintf("writing the signal: %v", err)
+		return r
+	}
+	self, err := os.Executable()
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	totalFrames := int(signal.Duration().Seconds() * config.FPS)
+	r.Description = fmt.Sprintf("Rendering %s of calibration signal, both passes", signal.Duration().Round(time.Second))
+	times := make([]time.Duration, 0, cmd.Runs)
+	for range cmd.Runs {
+		render := exec.Command(self, "--no-preview", "--no-thumbnail", "--encoder="+cmd.Encoder, input, filepath.Join(dir, "render.mp4"))
+		t0 := time.Now()
+		if out, err := render.CombinedOutput(); err != nil {
+			r.Error = fmt.Sprintf("render failed: %v", err)
+			if len(out) > 0 {
+				r.Error += "\n" + string(out)
+			}
+			return r
+		}
+		times = append(times, time.Since(t0))
+	}
+	slices.Sort(times)
+	r.Iterations = cmd.Runs
+	r.NsPerFrame = times[len(times)/2].Nanoseconds() / int64(totalFrames)
+	r.FPS = perSecond(r.NsPerFrame)
+	return r
+}
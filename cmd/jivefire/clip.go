@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/linuxmatters/jivefire/internal/chapters"
+	"github.com/linuxmatters/jivefire/internal/cli"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/renderer"
+	"github.com/linuxmatters/jivefire/internal/subtitles"
+)
+
+// clipCmd renders a short section of an episode as a square or vertical
+// audiogram for social media. It takes every render flag, with the section
+// given by --start and --end (or --duration).
+type clipCmd struct {
+	renderCmd
+	Quote     string `help:"Quote drawn large above the visualiser (default: what --subtitles has for the clip, otherwise the title)"`
+	Subtitles string `help:"SRT or WebVTT transcript of the episode to take the quote from: the cues spoken between --start and --end" type:"path"`
+	Shape     string `help:"Clip shape: square (1080x1080) or vertical (1080x1920)" default:"square"`
+}
+
+// runClip validates the clip flags, finds the quote and renders the clip
+// through the usual render path.
+func runClip(ctx *kong.Context, cmd *clipCmd) {
+	if cmd.Input == "" && cmd.Output == "" && cmd.OutputTemplate == "" {
+		_ = ctx.PrintUsage(true)
+		os.Exit(0)
+	}
+	if cmd.End == "" && cmd.Duration == "" {
+		cli.PrintError("a clip needs --end or --duration to say where it stops")
+		os.Exit(1)
+	}
+	for _, unsupported := range []struct {
+		set  bool
+		flag string
+	}{
+		{cmd.FramesDir != "" || cmd.FramesOnly, "--frames-dir"},
+		{cmd.Chapters != "", "--chapters"},
+		{cmd.WriteDescription, "--write-description"},
+		{cmd.Thumbnails > 0, "--thumbnails"},
+	} {
+		if unsupported.set {
+			cli.PrintError(fmt.Sprintf("%s is not available for clips", unsupported.flag))
+			os.Exit(1)
+		}
+	}
+
+	shape, err := config.ParseClipShape(cmd.Shape)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --shape: %v", err))
+		os.Exit(1)
+	}
+
+	quote := cmd.Quote
+	if quote == "" && cmd.Subtitles != "" {
+		start, length, err := parseSection(cmd.Start, cmd.End, cmd.Duration)
+		if err != nil {
+			cli.PrintError(err.Error())
+			os.Exit(1)
+		}
+		cues, err := subtitles.Load(cmd.Subtitles)
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --subtitles: %v", err))
+			os.Exit(1)
+		}
+		var end time.Duration
+		if length > 0 {
+			end = start + length
+		}
+		quote = subtitles.Text(cues, start, end)
+		if quote == "" {
+			cli.PrintWarning(fmt.Sprintf("--subtitles has nothing said between %s and %s; showing the title instead", chapters.FormatTimestamp(start), chapters.FormatTimestamp(end)))
+		}
+	}
+	if quote != "" {
+		missing, err := renderer.MissingGlyphs(quote, &config.RuntimeConfig{FontPath: cmd.Font, FontFallbacks: cmd.FontFallback})
+		if err == nil && len(missing) > 0 {
+			cli.PrintWarning(fmt.Sprintf("quote font has no glyphs for %q; use --font or --font-fallback with a font that covers them", string(missing)))
+		}
+	}
+
+	// The episode thumbnail has no place in a clip.
+	cmd.NoThumbnail = true
+	cmd.clipShape, cmd.clipQuote = shape, quote
+	runRender(ctx, &cmd.renderCmd)
+}
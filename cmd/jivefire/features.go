@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// featuresWriter writes --export-features: a CSV of every frame's audio
+// features, a row per frame after a header of frame, time (seconds into the
+// video) and the feature names, for editing tools to key effects from.
+type featuresWriter struct {
+	file  *os.File
+	csv   *csv.Writer
+	names []string
+	row   []string
+}
+
+// createFeatures creates the file at path for the features names, writing
+// its header.
+func createFeatures(path string, names []string) (*featuresWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &featuresWriter{file: f, csv: csv.NewWriter(f), names: names, row: make([]string, 2+len(names))}
+	if err := w.csv.Write(append([]string{"frame", "time"}, names...)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// write adds the row of frame, whose features are values.
+func (w *featuresWriter) write(frame int, values map[string]float64) error {
+	w.row[0] = strconv.Itoa(frame)
+	w.row[1] = strconv.FormatFloat(float64(frame)/config.FPS, 'f', 3, 64)
+	for i, name := range w.names {
+		w.row[2+i] = strconv.FormatFloat(values[name], 'g', 6, 64)
+	}
+	return w.csv.Write(w.row)
+}
+
+// Close flushes the rows and closes the file.
+func (w *featuresWriter) Close() error {
+	w.csv.Flush()
+	err := w.csv.Error()
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/cli"
+	"github.com/linuxmatters/jivefire/internal/encoder"
+	"github.com/linuxmatters/jivefire/internal/feed"
+)
+
+// defaultTitle is --title's default, which --rss replaces as though no title
+// had been given.
+const defaultTitle = "Podcast Title"
+
+// applyFeed fills in the title and episode number from --rss, leaving any
+// given explicitly as they are, and keeps the episode for its artwork, date
+// and metadata. Errors exit.
+func applyFeed(flags *textFlags) {
+	if flags.RSS == "" {
+		if flags.EpisodeGUID != "" {
+			cli.PrintError("--episode-guid needs --rss")
+			os.Exit(1)
+		}
+		return
+	}
+
+	f, err := feed.Fetch(flags.RSS)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --rss: %v", err))
+		os.Exit(1)
+	}
+	ep, err := f.Episode(flags.EpisodeGUID)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --episode-guid: %v", err))
+		os.Exit(1)
+	}
+
+	if flags.Title == defaultTitle && ep.Title != "" {
+		flags.Title = ep.Title
+	}
+	if flags.Episode == nil {
+		flags.Episode = ep.Number
+	}
+	flags.feedEpisode = &ep
+}
+
+// feedArtwork downloads the --rss episode's artwork into the user cache, so
+// re-rendering an episode does not fetch it again, and returns its path.
+func feedArtwork(ep *feed.Episode) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return feed.FetchArtwork(ep.Artwork, filepath.Join(dir, "jivefire", "artwork"))
+}
+
+// containerTags returns the MP4 metadata for the episode: the title and
+// episode number, and with --rss the show, author and publish date. The
+// placeholder title is left out.
+func containerTags(flags *textFlags) []encoder.Tag {
+	var tags []encoder.Tag
+	if flags.Title != defaultTitle {
+		tags = append(tags, encoder.Tag{Key: "title", Value: flags.Title})
+	}
+	if flags.Episode != nil {
+		tags = append(tags, encoder.Tag{Key: "episode_sort", Value: strconv.Itoa(*flags.Episode)})
+	}
+	if ep := flags.feedEpisode; ep != nil {
+		tags = append(tags,
+			encoder.Tag{Key: "show", Value: ep.Show},
+			encoder.Tag{Key: "album", Value: ep.Show},
+			encoder.Tag{Key: "artist", Value: ep.Author},
+		)
+		if !ep.Published.IsZero() {
+			tags = append(tags, encoder.Tag{Key: "date", Value: ep.Published.Format(time.DateOnly)})
+		}
+	}
+	return tags
+}
+
+// templateDate returns the {date} for --output-template: the episode's
+// publish date with --rss, otherwise today.
+func templateDate(flags *textFlags) time.Time {
+	if ep := flags.feedEpisode; ep != nil && !ep.Published.IsZero() {
+		return ep.Published
+	}
+	return time.Now()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/audio"
+	"github.com/linuxmatters/jivefire/internal/cli"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/encoder"
+	"github.com/linuxmatters/jivefire/internal/failure"
+	"github.com/linuxmatters/jivefire/internal/output"
+	"github.com/linuxmatters/jivefire/internal/renderer"
+)
+
+// maxIdleSeconds caps jivefire idle --duration; a stream loops the video,
+// so an hour is far more than it needs.
+const maxIdleSeconds = 3600
+
+type idleCmd struct {
+	Output    string `arg:"" name:"output" help:"Output MP4 file"`
+	Duration  int    `help:"Loop length in seconds; the last frame leads seamlessly back into the first" default:"60"`
+	Title     string `help:"Title drawn above the bars" default:"Starting Soon"`
+	Seed      uint64 `help:"Pick a different motion; the same seed always draws the same loop" default:"1"`
+	BarColor  string `help:"Bar color in hex format (e.g., #A40000 or A40000)"`
+	TextColor string `help:"Text color in hex format (e.g., #F8B31D or F8B31D)"`
+	Font      string `help:"Path to a TrueType font for the title"`
+	backgroundFlags
+	Encoder string `help:"Video encoder: auto, nvenc, qsv, vaapi, vulkan, amf, mf (Windows), software" default:"auto"`
+	Profile string `help:"Rate control: fast, youtube, archive or small" default:"fast"`
+}
+
+// runIdle renders a looping visualiser driven by audio.IdleBars instead of
+// an input file, through the same frame and encoder as a render. The video
+// has no audio track.
+func runIdle(cmd *idleCmd) {
+	if cmd.Duration < 1 || cmd.Duration > maxIdleSeconds {
+		cli.PrintError(fmt.Sprintf("invalid --duration: %d (must be between 1 and %d seconds)", cmd.Duration, maxIdleSeconds))
+		os.Exit(1)
+	}
+	hwAccelType, ok := validEncoders[cmd.Encoder]
+	if !ok {
+		cli.PrintError(fmt.Sprintf("invalid --encoder value: %s (must be auto, nvenc, qsv, vaapi, vulkan, amf, mf, or software)", cmd.Encoder))
+		os.Exit(1)
+	}
+	encodeProfile, err := encoder.ParseProfile(cmd.Profile)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --profile: %v", err))
+		os.Exit(1)
+	}
+
+	runtimeConfig := &config.RuntimeConfig{}
+	for _, c := range []struct {
+		flag, value string
+		dst         *config.OptionalColor
+	}{
+		{"--bar-color", cmd.BarColor, &runtimeConfig.BarColor},
+		{"--text-color", cmd.TextColor, &runtimeConfig.TextColor},
+	} {
+		if c.value == "" {
+			continue
+		}
+		r, g, b, err := config.ParseHexColor(c.value)
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid %s: %v", c.flag, err))
+			os.Exit(1)
+		}
+		*c.dst = config.OptionalColor{R: r, G: g, B: b, Set: true}
+	}
+	if cmd.Font != "" {
+		if _, err := os.Stat(cmd.Font); os.IsNotExist(err) {
+			cli.PrintError(fmt.Sprintf("font does not exist: %s", cmd.Font))
+			os.Exit(failure.ExitInputNotFound)
+		}
+		runtimeConfig.FontPath = cmd.Font
+	}
+	applyBackgroundFlags(&cmd.backgroundFlags, runtimeConfig)
+
+	meta := renderer.PodcastMeta{Title: cmd.Title}
+	bgImage, err := renderer.LoadBackgroundImage(runtimeConfig)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("loading background image: %v", err))
+		os.Exit(1)
+	}
+	fontFace, err := renderer.LoadTitleFont(meta.Title, runtimeConfig)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("loading font: %v", err))
+		os.Exit(1)
+	}
+	frame := renderer.NewFrame(bgImage, fontFace, meta, runtimeConfig)
+
+	dest, err := output.Open(cmd.Output)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid output: %v", err))
+		os.Exit(1)
+	}
+	started := time.Now()
+	if err := encodeIdle(cmd, frame, dest.Path(), hwAccelType, encodeProfile); err != nil {
+		_ = dest.Discard()
+		cli.PrintError(err.Error())
+		os.Exit(failure.ExitCode(err))
+	}
+	if err := dest.Publish(); err != nil {
+		cli.PrintError(fmt.Sprintf("uploading %s: %v", dest, err))
+		os.Exit(1)
+	}
+	fmt.Printf("%s %s\n", cli.KeyStyle.Render("Output:"), cli.ValueStyle.Render(dest.String()))
+	fmt.Printf("%s %s\n", cli.KeyStyle.Render("Loop:"), cli.ValueStyle.Render(fmt.Sprintf("%ds, rendered in %s", cmd.Duration, time.Since(started).Round(100*time.Millisecond))))
+}
+
+// encodeIdle encodes cmd.Duration seconds of idle bars drawn on frame into
+// path.
+func encodeIdle(cmd *idleCmd, frame *renderer.Frame, path string, hwAccel encoder.HWAccelType, profile encoder.Profile) error {
+	enc, err := encoder.New(encoder.Config{
+		OutputPath: path,
+		Width:      config.Width,
+		Height:     config.Height,
+		Framerate:  config.FPS,
+		HWAccel:    hwAccel,
+		Profile:    profile,
+		Metadata:   []encoder.Tag{{Key: "title", Value: cmd.Title}},
+	})
+	if err != nil {
+		return fmt.Errorf("creating encoder: %w", err)
+	}
+	if err := enc.Initialize(); err != nil {
+		return fmt.Errorf("initializing encoder: %w", err)
+	}
+	closed := false
+	defer func() {
+		if !closed {
+			_ = enc.Close()
+		}
+	}()
+
+	total := cmd.Duration * config.FPS
+	bars := audio.NewIdleBars(total, config.FPS, cmd.Seed)
+	maxHeight := float64(config.Height/2-config.CenterGap/2) * config.MaxBarHeight
+	levels := make([]float64, config.NumBars)
+	heights := make([]float64, config.NumBars)
+	for n := range total {
+		bars.Heights(n, levels)
+		for i := range levels {
+			levels[i] *= maxHeight
+		}
+		// Bass in the middle, as a render lays out its bars.
+		audio.RearrangeFrequenciesCenterOut(levels, heights)
+		frame.Draw(heights)
+		startY, endY := frame.DirtyRows()
+		if err := enc.WriteFrameRGBARows(frame.GetImage().Pix, startY, endY); err != nil {
+			return fmt.Errorf("encoding frame %d: %w", n, err)
+		}
+	}
+	if err := enc.Fallback(); err != nil {
+		return err
+	}
+	closed = true
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("finishing the output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,712 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+
+	tea "charm.land/bubbletea/v2"
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/audio"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/encoder"
+	"github.com/linuxmatters/jivefire/internal/frames"
+	"github.com/linuxmatters/jivefire/internal/renderer"
+	"github.com/linuxmatters/jivefire/internal/ui"
+	"github.com/linuxmatters/jivefire/internal/yuv"
+)
+
+// The integration tests render synthetic audio through both passes and the
+// software encoder, decode the MP4, and check what comes out. Every fixture
+// starts with a second of silence, so the first decoded frame is the bare
+// background and bar activity is measured as the difference from it.
+
+const (
+	fixtureRate = 44100
+	leadIn      = time.Second
+	// Mean luma difference from the silent frame above which a bar counts as
+	// drawn; white bars on black sit well over 100.
+	activeLuma = 24
+	// Allowed offset between the audio and the bars that show it: the FFT
+	// window looks 46ms ahead, and AAC priming delays the audio by 23ms.
+	syncTolerance = 100 * time.Millisecond
+)
+
+// signal is a generator for a mono fixture.
+type signal func(t float64) float64
+
+func silence(float64) float64 { return 0 }
+
+func tone(freq float64) signal {
+	return func(t float64) float64 { return 0.5 * math.Sin(2*math.Pi*freq*t) }
+}
+
+// sweep rises linearly from f0 to f1 Hz over length.
+func sweep(f0, f1 float64, length time.Duration) signal {
+	rate := (f1 - f0) / length.Seconds()
+	return func(t float64) float64 {
+		return 0.5 * math.Sin(2*math.Pi*(f0*t+rate*t*t/2))
+	}
+}
+
+func noise() signal {
+	r := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // deterministic test noise
+	return func(float64) float64 { return 0.5 * (2*r.Float64() - 1) }
+}
+
+// segment is one part of a fixture.
+type segment struct {
+	length time.Duration
+	signal signal
+}
+
+// writeFixture writes the lead-in silence followed by segs as a mono WAV and
+// returns its path.
+func writeFixture(t *testing.T, segs ...segment) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixture.wav")
+	w, err := frames.CreateWAV(path, fixtureRate, 1)
+	if err != nil {
+		t.Fatalf("creating fixture: %v", err)
+	}
+	segs = append([]segment{{leadIn, silence}}, segs...)
+	for _, seg := range segs {
+		samples := make([]float32, int(seg.length.Seconds()*fixtureRate))
+		for i := range samples {
+			samples[i] = float32(seg.signal(float64(i) / fixtureRate))
+		}
+		if err := w.Write(samples); err != nil {
+			t.Fatalf("writing fixture: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("closing fixture: %v", err)
+	}
+	return path
+}
+
+// blackBackground writes a plain black background so drawn bars stand out
+// from it.
+func blackBackground(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "black.png")
+	img := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("creating background: %v", err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("encoding background: %v", err)
+	}
+	return path
+}
+
+// renderHeadless runs both passes on input into output with the UI running
+// against no terminal, as generateVideo does, and returns the completion
+// message. span selects the section rendered, as --start and --end do.
+func renderHeadless(t *testing.T, input, output string, span audio.Span) *ui.RenderComplete {
+	t.Helper()
+	return renderHeadlessProfile(t, input, output, span, nil)
+}
+
+// renderHeadlessProfile is renderHeadless with Pass 1's profile passed
+// through adjust, when set, before Pass 2 sees it.
+func renderHeadlessProfile(t *testing.T, input, output string, span audio.Span, adjust func(*audio.Profile)) *ui.RenderComplete {
+	t.Helper()
+	return renderHeadlessPart(t, input, output, span, adjust, renderPart{})
+}
+
+// renderHeadlessPart is renderHeadlessProfile rendering part of a split
+// render.
+func renderHeadlessPart(t *testing.T, input, output string, span audio.Span, adjust func(*audio.Profile), part renderPart) *ui.RenderComplete {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("renders and decodes video")
+	}
+
+	runtimeConfig := &config.RuntimeConfig{
+		BarColor:            config.OptionalColor{R: 0xff, G: 0xff, B: 0xff, Set: true},
+		BackgroundImagePath: blackBackground(t),
+	}
+	model := ui.NewModel(true)
+	p := tea.NewProgram(model,
+		tea.WithInput(nil),
+		tea.WithOutput(io.Discard),
+		tea.WithoutRenderer(),
+		tea.WithoutSignalHandler())
+
+	var analysisErr error
+	go func() {
+		// Both passes share one reader, rewound between them, as a render does.
+		openInput := func() (audio.Source, error) {
+			return openAudio(input, span.Start, 0, runtimeConfig)
+		}
+		reader, err := openInput()
+		if err != nil {
+			analysisErr = err
+			p.Quit()
+			return
+		}
+		defer func() {
+			if reader != nil {
+				_ = reader.Close()
+			}
+		}()
+		profile, err := audio.AnalyzeReader(reader, span, audio.FreqRange{}, audio.VisFilter{}, nil, nil)
+		if err == nil {
+			reader, err = rewindReader(reader, span.Start, openInput)
+		}
+		if err != nil {
+			analysisErr = err
+			p.Quit()
+			return
+		}
+		if adjust != nil {
+			adjust(profile)
+		}
+		p.Send(ui.AnalysisComplete{
+			PeakMagnitude: profile.GlobalPeak,
+			RMSLevel:      profile.GlobalRMS,
+			DynamicRange:  profile.DynamicRange,
+			TruePeak:      profile.TruePeak,
+			Duration:      time.Duration(float64(time.Second) * profile.Duration),
+			OptimalScale:  profile.OptimalBaseScale,
+		})
+		runPass2(p, profile, pass2Config{
+			renderOptions: renderOptions{
+				channels:      1,
+				noPreview:     true,
+				hwAccel:       encoder.HWAccelNone,
+				colorSpace:    yuv.BT709,
+				colorRange:    yuv.RangeLimited,
+				profile:       encoder.ProfileFast,
+				runtimeConfig: runtimeConfig,
+				meta:          renderer.PodcastMeta{Title: "Integration"},
+			},
+			reader:           reader,
+			outputFile:       output,
+			controls:         model.Controls(),
+			span:             span,
+			overallStartTime: time.Now(),
+			part:             part,
+		})
+	}()
+
+	final, err := p.Run()
+	if err != nil {
+		t.Fatalf("running UI: %v", err)
+	}
+	if analysisErr != nil {
+		t.Fatalf("analysing audio: %v", analysisErr)
+	}
+	m, ok := final.(*ui.Model)
+	if !ok {
+		t.Fatalf("final model is %T, want *ui.Model", final)
+	}
+	complete, _ := m.Result()
+	if complete == nil {
+		t.Fatal("render did not complete (see the error printed above)")
+	}
+	return complete
+}
+
+// decodedVideo is what the tests check of a rendered MP4.
+type decodedVideo struct {
+	frames   int
+	duration time.Duration // from the video stream
+	// activity[frame][column] is the mean luma difference from the first
+	// frame over each drawn bar column's upper half, in display (left to
+	// right) order.
+	activity [][]float64
+}
+
+// barColumns returns the left edge of each display column.
+func barColumns() []int {
+	startX := (config.Width - (config.NumBars*config.BarWidth + (config.NumBars-1)*config.BarGap)) / 2
+	cols := make([]int, config.NumBars)
+	for i := range cols {
+		cols[i] = startX + i*(config.BarWidth+config.BarGap)
+	}
+	return cols
+}
+
+// decodeVideo decodes every video frame of path and measures bar activity.
+func decodeVideo(t *testing.T, path string) decodedVideo {
+	t.Helper()
+
+	var formatCtx *ffmpeg.AVFormatContext
+	cPath := ffmpeg.ToCStr(path)
+	defer cPath.Free()
+	if _, err := ffmpeg.AVFormatOpenInput(&formatCtx, cPath, nil, nil); err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	defer ffmpeg.AVFormatCloseInput(&formatCtx)
+	if _, err := ffmpeg.AVFormatFindStreamInfo(formatCtx, nil); err != nil {
+		t.Fatalf("reading stream info: %v", err)
+	}
+
+	streamIdx := -1
+	var stream *ffmpeg.AVStream
+	for i := uintptr(0); i < uintptr(formatCtx.NbStreams()); i++ {
+		s := formatCtx.Streams().Get(i)
+		if s.Codecpar().CodecType() == ffmpeg.AVMediaTypeVideo {
+			streamIdx, stream = int(i), s
+			break
+		}
+	}
+	if stream == nil {
+		t.Fatal("no video stream in output")
+	}
+
+	decoder := ffmpeg.AVCodecFindDecoder(stream.Codecpar().CodecId())
+	if decoder == nil {
+		t.Fatal("no decoder for the video stream")
+	}
+	codecCtx := ffmpeg.AVCodecAllocContext3(decoder)
+	defer ffmpeg.AVCodecFreeContext(&codecCtx)
+	if _, err := ffmpeg.AVCodecParametersToContext(codecCtx, stream.Codecpar()); err != nil {
+		t.Fatalf("copying codec parameters: %v", err)
+	}
+	if _, err := ffmpeg.AVCodecOpen2(codecCtx, decoder, nil); err != nil {
+		t.Fatalf("opening decoder: %v", err)
+	}
+
+	packet := ffmpeg.AVPacketAlloc()
+	defer ffmpeg.AVPacketFree(&packet)
+	frame := ffmpeg.AVFrameAlloc()
+	defer ffmpeg.AVFrameFree(&frame)
+
+	var out decodedVideo
+	var reference []byte
+	cols := barColumns()
+	top := config.Height/2 - config.CenterGap/2 - int(float64(config.Height/2-config.CenterGap/2)*config.MaxBarHeight)
+	bottom := config.Height/2 - config.CenterGap/2 - config.FramingLineHeight
+
+	measure := func() {
+		stride := frame.Linesize().Get(0)
+		luma := unsafe.Slice((*byte)(frame.Data().Get(0)), stride*frame.Height())
+		if reference == nil {
+			reference = make([]byte, config.Width*config.Height)
+			for y := range config.Height {
+				copy(reference[y*config.Width:(y+1)*config.Width], luma[y*stride:])
+			}
+		}
+		row := make([]float64, len(cols))
+		for c, x0 := range cols {
+			var sum int
+			for y := top; y < bottom; y++ {
+				for x := x0; x < x0+config.BarWidth; x++ {
+					d := int(luma[y*stride+x]) - int(reference[y*config.Width+x])
+					sum += max(d, -d)
+				}
+			}
+			row[c] = float64(sum) / float64((bottom-top)*config.BarWidth)
+		}
+		out.activity = append(out.activity, row)
+		out.frames++
+	}
+	receive := func() {
+		for {
+			if _, err := ffmpeg.AVCodecReceiveFrame(codecCtx, frame); err != nil {
+				if errors.Is(err, ffmpeg.AVErrorEOF) || errors.Is(err, ffmpeg.EAgain) {
+					return
+				}
+				t.Fatalf("decoding frame: %v", err)
+			}
+			measure()
+			ffmpeg.AVFrameUnref(frame)
+		}
+	}
+
+	for {
+		if _, err := ffmpeg.AVReadFrame(formatCtx, packet); err != nil {
+			if errors.Is(err, ffmpeg.AVErrorEOF) {
+				break
+			}
+			t.Fatalf("reading packet: %v", err)
+		}
+		if packet.StreamIndex() == streamIdx {
+			if _, err := ffmpeg.AVCodecSendPacket(codecCtx, packet); err != nil {
+				t.Fatalf("sending packet: %v", err)
+			}
+			receive()
+		}
+		ffmpeg.AVPacketUnref(packet)
+	}
+	if _, err := ffmpeg.AVCodecSendPacket(codecCtx, nil); err != nil {
+		t.Fatalf("flushing decoder: %v", err)
+	}
+	receive()
+
+	tb := stream.TimeBase()
+	out.duration = time.Duration(float64(stream.Duration()) * float64(tb.Num()) / float64(tb.Den()) * float64(time.Second))
+	return out
+}
+
+// decodedAudio is the output's audio track, decoded to mono.
+type decodedAudio struct {
+	duration time.Duration
+	onset    time.Duration // first sample above half the fixture level, -1 if none
+}
+
+func decodeAudio(t *testing.T, path string) decodedAudio {
+	t.Helper()
+	reader, err := audio.NewStreamingReader(path)
+	if err != nil {
+		t.Fatalf("opening output audio: %v", err)
+	}
+	defer reader.Close()
+
+	out := decodedAudio{onset: -1}
+	buf := make([]float64, 4096)
+	var total int
+	for {
+		n, err := reader.ReadInto(buf)
+		for i, s := range buf[:n] {
+			if out.onset < 0 && math.Abs(s) > 0.25 {
+				out.onset = samplesToDuration(total+i, reader.SampleRate())
+			}
+		}
+		total += n
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("decoding output audio: %v", err)
+		}
+	}
+	out.duration = samplesToDuration(total, reader.SampleRate())
+	return out
+}
+
+func samplesToDuration(n, rate int) time.Duration {
+	return time.Duration(n) * time.Second / time.Duration(rate)
+}
+
+// renderFixture renders segs and decodes the result.
+func renderFixture(t *testing.T, segs ...segment) (*ui.RenderComplete, decodedVideo, decodedAudio) {
+	t.Helper()
+	input := writeFixture(t, segs...)
+	output := filepath.Join(t.TempDir(), "out.mp4")
+	complete := renderHeadless(t, input, output, audio.Span{})
+	return complete, decodeVideo(t, output), decodeAudio(t, output)
+}
+
+// barCentre returns the centre frequency of bar (0 is the lowest).
+func barCentre(bar int) float64 {
+	low, high := audio.BarFrequencies(fixtureRate)
+	return (low[bar] + high[bar]) / 2
+}
+
+// loudestBar returns the bar (0 is the lowest) whose columns are most active
+// in row. Bars are mirrored about the centre, so the left half is enough.
+func loudestBar(row []float64) (bar int, level float64) {
+	centre := config.NumBars / 2
+	for col := range centre {
+		if row[col] > level {
+			bar, level = centre-1-col, row[col]
+		}
+	}
+	return bar, level
+}
+
+// frameAt returns the index of the frame shown at d.
+func frameAt(d time.Duration) int {
+	return int(d.Seconds() * config.FPS)
+}
+
+func TestIntegrationFrameCountAndDuration(t *testing.T) {
+	const length = 3 * time.Second
+	complete, video, sound := renderFixture(t, segment{length, tone(barCentre(4))})
+
+	total := leadIn + length
+	wantFrames := frameAt(total)
+	if video.frames != complete.TotalFrames {
+		t.Errorf("decoded %d frames, render reported %d", video.frames, complete.TotalFrames)
+	}
+	if d := video.frames - wantFrames; d < -1 || d > 1 {
+		t.Errorf("decoded %d frames, want %d±1", video.frames, wantFrames)
+	}
+	if d := video.duration - total; d.Abs() > time.Second/config.FPS {
+		t.Errorf("video duration %v, want %v", video.duration, total)
+	}
+	if d := sound.duration - total; d.Abs() > syncTolerance {
+		t.Errorf("audio duration %v, want %v", sound.duration, total)
+	}
+}
+
+func TestIntegrationDurationStopsRender(t *testing.T) {
+	input := writeFixture(t, segment{3 * time.Second, tone(barCentre(4))})
+	output := filepath.Join(t.TempDir(), "out.mp4")
+	const maxFrames = 2 * config.FPS
+	complete := renderHeadless(t, input, output, audio.Span{Frames: maxFrames})
+
+	if complete.TotalFrames != maxFrames {
+		t.Errorf("render reported %d frames, want %d", complete.TotalFrames, maxFrames)
+	}
+	if video := decodeVideo(t, output); video.frames != maxFrames {
+		t.Errorf("decoded %d frames, want %d", video.frames, maxFrames)
+	}
+	sound := decodeAudio(t, output)
+	if want := time.Duration(maxFrames) * time.Second / config.FPS; (sound.duration - want).Abs() > syncTolerance {
+		t.Errorf("audio duration %v, want %v", sound.duration, want)
+	}
+	for _, w := range complete.AssetWarnings {
+		t.Errorf("unexpected warning: %s", w)
+	}
+}
+
+// A second decode that finds less audio than Pass 1 did still gets every
+// frame Pass 1 counted, in silence, with the audio padded to match.
+func TestIntegrationShortDecodeRendersEveryFrame(t *testing.T) {
+	input := writeFixture(t, segment{time.Second, tone(barCentre(4))})
+	output := filepath.Join(t.TempDir(), "out.mp4")
+	var analysed int
+	const extra = config.FPS / 2
+	complete := renderHeadlessProfile(t, input, output, audio.Span{}, func(profile *audio.Profile) {
+		profile.NumFrames += extra
+		analysed = profile.NumFrames
+	})
+
+	if complete.TotalFrames != analysed {
+		t.Errorf("render reported %d frames, want Pass 1's %d", complete.TotalFrames, analysed)
+	}
+	video := decodeVideo(t, output)
+	if video.frames != analysed {
+		t.Errorf("decoded %d frames, want %d", video.frames, analysed)
+	}
+	if sound := decodeAudio(t, output); (sound.duration - video.duration).Abs() > syncTolerance {
+		t.Errorf("audio lasts %v, video %v", sound.duration, video.duration)
+	}
+	want := fmt.Sprintf("audio ran out %d frames", extra)
+	if !slices.ContainsFunc(complete.AssetWarnings, func(w string) bool { return strings.Contains(w, want) }) {
+		t.Errorf("warnings %q, want one saying %q", complete.AssetWarnings, want)
+	}
+}
+
+func TestIntegrationStartSkipsLeadIn(t *testing.T) {
+	// Start mid-tone, past the lead-in silence, and render one second.
+	input := writeFixture(t, segment{3 * time.Second, tone(barCentre(4))})
+	output := filepath.Join(t.TempDir(), "out.mp4")
+	span := audio.Span{Start: leadIn + 1500*time.Millisecond, Frames: config.FPS}
+	complete := renderHeadless(t, input, output, span)
+
+	if complete.TotalFrames != span.Frames {
+		t.Errorf("render reported %d frames, want %d", complete.TotalFrames, span.Frames)
+	}
+	sound := decodeAudio(t, output)
+	if sound.onset < 0 || sound.onset > syncTolerance {
+		t.Errorf("tone starts at %v in the clip, want at the start", sound.onset)
+	}
+	if (sound.duration - time.Second).Abs() > syncTolerance {
+		t.Errorf("audio duration %v, want 1s", sound.duration)
+	}
+}
+
+func TestIntegrationSpeedShortensRender(t *testing.T) {
+	// Lead-in plus 3s of tone at double speed plays in 2s, tone from 0.5s.
+	input := writeFixture(t, segment{3 * time.Second, tone(barCentre(4))})
+	output := filepath.Join(t.TempDir(), "out.mp4")
+	complete := renderHeadless(t, input, output, audio.Span{Speed: 2})
+
+	want := 2 * config.FPS
+	if frames := decodeVideo(t, output).frames; frames < want-1 || frames > want+1 {
+		t.Errorf("decoded %d frames, want %d", frames, want)
+	}
+	sound := decodeAudio(t, output)
+	if (sound.duration - 2*time.Second).Abs() > syncTolerance {
+		t.Errorf("audio duration %v, want 2s", sound.duration)
+	}
+	if (sound.onset - leadIn/2).Abs() > syncTolerance {
+		t.Errorf("tone starts at %v, want %v", sound.onset, leadIn/2)
+	}
+	for _, w := range complete.AssetWarnings {
+		t.Errorf("unexpected warning: %s", w)
+	}
+}
+
+func TestIntegrationSilenceDrawsNoBars(t *testing.T) {
+	_, video, _ := renderFixture(t, segment{2 * time.Second, silence})
+	for f, row := range video.activity {
+		if bar, level := loudestBar(row); level > activeLuma {
+			t.Fatalf("frame %d: bar %d drawn (luma %.1f) in silence", f, bar, level)
+		}
+	}
+}
+
+func TestIntegrationToneLightsItsBar(t *testing.T) {
+	const length = 2 * time.Second
+	for _, bar := range []int{2, 14, 28} {
+		_, video, _ := renderFixture(t, segment{length, tone(barCentre(bar))})
+
+		// Skip the attack and stop before the end, away from the transitions.
+		from, to := frameAt(leadIn+length/4), frameAt(leadIn+length*3/4)
+		for f := from; f < to && f < video.frames; f++ {
+			got, level := loudestBar(video.activity[f])
+			if level < activeLuma {
+				t.Errorf("%.0f Hz, frame %d: no bar drawn", barCentre(bar), f)
+				break
+			}
+			if got != bar {
+				t.Errorf("%.0f Hz, frame %d: loudest bar %d, want %d", barCentre(bar), f, got, bar)
+				break
+			}
+		}
+	}
+}
+
+func TestIntegrationSweepClimbsBars(t *testing.T) {
+	const length = 4 * time.Second
+	lowBar, highBar := 1, 24
+	_, video, _ := renderFixture(t, segment{length, sweep(barCentre(lowBar), barCentre(highBar), length)})
+
+	// Sample the loudest bar every quarter second; the spring dynamics may
+	// hold a bar for a frame or two, so allow it to trail by one.
+	prev := -1
+	for at := leadIn + length/8; at < leadIn+length; at += time.Second / 4 {
+		f := frameAt(at)
+		if f >= video.frames {
+			break
+		}
+		got, level := loudestBar(video.activity[f])
+		if level < activeLuma {
+			t.Fatalf("frame %d: no bar drawn during the sweep", f)
+		}
+		if got < prev-1 {
+			t.Errorf("frame %d: loudest bar fell from %d to %d as the sweep rose", f, prev, got)
+		}
+		prev = max(prev, got)
+	}
+	if prev < highBar-2 {
+		t.Errorf("sweep peaked at bar %d, want about %d", prev, highBar)
+	}
+}
+
+func TestIntegrationNoiseFillsSpectrum(t *testing.T) {
+	const length = 2 * time.Second
+	_, video, _ := renderFixture(t, segment{length, noise()})
+
+	f := frameAt(leadIn + length/2)
+	if f >= video.frames {
+		t.Fatalf("only %d frames decoded", video.frames)
+	}
+	var active int
+	for _, level := range video.activity[f][:config.NumBars/2] {
+		if level > activeLuma {
+			active++
+		}
+	}
+	if want := config.NumBars / 2 * 3 / 4; active < want {
+		t.Errorf("%d of %d bars drawn for white noise, want at least %d", active, config.NumBars/2, want)
+	}
+}
+
+func TestIntegrationAudioVideoSync(t *testing.T) {
+	const bar = 6
+	_, video, sound := renderFixture(t,
+		segment{time.Second, silence},
+		segment{time.Second, tone(barCentre(bar))})
+
+	if sound.onset < 0 {
+		t.Fatal("tone not found in the output audio")
+	}
+	onsetFrame := -1
+	centre := config.NumBars / 2
+	for f, row := range video.activity {
+		if row[centre-1-bar] > activeLuma {
+			onsetFrame = f
+			break
+		}
+	}
+	if onsetFrame < 0 {
+		t.Fatal("tone never drawn")
+	}
+
+	videoOnset := time.Duration(onsetFrame) * time.Second / config.FPS
+	if d := videoOnset - sound.onset; d.Abs() > syncTolerance {
+		t.Errorf("bars respond at %v, audio starts at %v (%v apart, tolerance %v)", videoOnset, sound.onset, d, syncTolerance)
+	}
+	if want := leadIn + time.Second; (sound.onset - want).Abs() > syncTolerance {
+		t.Errorf("audio tone starts at %v, want %v", sound.onset, want)
+	}
+}
+
+// The self-test's software run encodes, decodes and times five seconds of
+// bars.
+func TestIntegrationSelftestSoftware(t *testing.T) {
+	if testing.Short() {
+		t.Skip("encodes and decodes video")
+	}
+	candidate := selftestCandidates(nil)[0]
+	frame := renderer.NewFrame(nil, nil, renderer.PodcastMeta{}, &config.RuntimeConfig{})
+	fps, err := selftestEncoder(candidate, frame, filepath.Join(t.TempDir(), "selftest.mp4"))
+	if err != nil {
+		t.Fatalf("software self-test: %v", err)
+	}
+	if fps <= 0 || math.IsInf(fps, 0) {
+		t.Errorf("software self-test speed %v fps", fps)
+	}
+}
+
+// Segments rendered apart, each from a warm-up before it, join with the
+// audio into one video of every frame, the bars still in step with the
+// sound. Every part renders at one scale, as from a shared analysis.
+func TestIntegrationJoinedSegments(t *testing.T) {
+	const bar = 6
+	input := writeFixture(t,
+		segment{time.Second, silence},
+		segment{time.Second, tone(barCentre(bar))})
+	dir := t.TempDir()
+	scale := func(p *audio.Profile) { p.OptimalBaseScale = 0.5 }
+	whole := renderHeadlessProfile(t, input, filepath.Join(dir, "whole.mp4"), audio.Span{}, scale)
+
+	const count = 3
+	var segments []string
+	for index := 1; index <= count; index++ {
+		first, end := segmentBounds(whole.TotalFrames, index, count)
+		warmup := min(first, config.FPS/2)
+		span := audio.Span{Start: time.Duration(first-warmup) * time.Second / config.FPS, Frames: warmup + end - first}
+		path := filepath.Join(dir, fmt.Sprintf("segment-%d.mp4", index))
+		renderHeadlessPart(t, input, path, span, func(p *audio.Profile) {
+			scale(p)
+			p.NumFrames = span.Frames
+		}, renderPart{first: first - warmup, warmup: warmup, videoOnly: true})
+		segments = append(segments, path)
+	}
+	joined := filepath.Join(dir, "joined.mp4")
+	renderHeadlessPart(t, input, joined, audio.Span{}, scale, renderPart{join: segments})
+
+	video, sound := decodeVideo(t, joined), decodeAudio(t, joined)
+	if video.frames != whole.TotalFrames {
+		t.Errorf("joined video has %d frames, the whole render %d", video.frames, whole.TotalFrames)
+	}
+	if want := time.Duration(whole.TotalFrames) * time.Second / config.FPS; (sound.duration - want).Abs() > syncTolerance {
+		t.Errorf("joined audio lasts %v, want %v", sound.duration, want)
+	}
+	onsetFrame := -1
+	for f, row := range video.activity {
+		if row[config.NumBars/2-1-bar] > activeLuma {
+			onsetFrame = f
+			break
+		}
+	}
+	videoOnset := time.Duration(onsetFrame) * time.Second / config.FPS
+	if onsetFrame < 0 || (videoOnset-sound.onset).Abs() > syncTolerance {
+		t.Errorf("bars respond at frame %d, audio starts at %v", onsetFrame, sound.onset)
+	}
+}
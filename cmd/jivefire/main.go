@@ -1,44 +1,241 @@
 package main
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"image"
 	"io"
 	"math"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"github.com/alecthomas/kong"
-	"github.com/charmbracelet/harmonica"
+	"github.com/linuxmatters/jivefire/internal/analysis"
 	"github.com/linuxmatters/jivefire/internal/audio"
+	"github.com/linuxmatters/jivefire/internal/chapters"
 	"github.com/linuxmatters/jivefire/internal/cli"
 	"github.com/linuxmatters/jivefire/internal/config"
 	"github.com/linuxmatters/jivefire/internal/encoder"
+	"github.com/linuxmatters/jivefire/internal/failure"
+	"github.com/linuxmatters/jivefire/internal/feed"
+	"github.com/linuxmatters/jivefire/internal/frames"
+	"github.com/linuxmatters/jivefire/internal/locale"
+	"github.com/linuxmatters/jivefire/internal/memlimit"
+	"github.com/linuxmatters/jivefire/internal/metrics"
+	"github.com/linuxmatters/jivefire/internal/naming"
+	"github.com/linuxmatters/jivefire/internal/notify"
+	"github.com/linuxmatters/jivefire/internal/output"
+	"github.com/linuxmatters/jivefire/internal/playback"
+	"github.com/linuxmatters/jivefire/internal/preflight"
 	"github.com/linuxmatters/jivefire/internal/renderer"
+	"github.com/linuxmatters/jivefire/internal/report"
+	"github.com/linuxmatters/jivefire/internal/script"
+	"github.com/linuxmatters/jivefire/internal/timing"
 	"github.com/linuxmatters/jivefire/internal/ui"
+	"github.com/linuxmatters/jivefire/internal/wav"
+	"github.com/linuxmatters/jivefire/internal/window"
+	"github.com/linuxmatters/jivefire/internal/yuv"
 )
 
+// validFormats are the --format containers; other muxers are reachable by
+// output file extension. hls and dash write a playlist at the output path
+// with numbered segments beside it.
+var validFormats = []string{"mp4", "mpegts", "hls", "dash"}
+
+// maxBackgroundBlur caps --background-blur; beyond this the artwork is a
+// flat wash of colour anyway.
+const maxBackgroundBlur = 100
+
+// validEncoders maps the --encoder values to the encoders they select.
+var validEncoders = map[string]encoder.HWAccelType{
+	"auto":     encoder.HWAccelAuto,
+	"nvenc":    encoder.HWAccelNVENC,
+	"qsv":      encoder.HWAccelQSV,
+	"vaapi":    encoder.HWAccelVAAPI,
+	"vulkan":   encoder.HWAccelVulkan,
+	"amf":      encoder.HWAccelAMF,
+	"mf":       encoder.HWAccelMF,
+	"software": encoder.HWAccelNone,
+}
+
+// maxThumbnailVariants caps --thumbnails; each variant pauses rendering while
+// it is drawn and saved.
+const maxThumbnailVariants = 10
+
 // version is set via ldflags at build time: "dev" for local builds, the git tag
 // (e.g. "v0.1.0") for releases.
 var version = "dev"
 
+// textFlags are the title and thumbnail options shared by the render and
+// thumbnail commands.
+type textFlags struct {
+	Episode            *int     `help:"Episode number (omitted from output when not set)"`
+	Title              string   `help:"Podcast title" default:"Podcast Title"`
+	RSS                string   `name:"rss" help:"Podcast feed URL (or file) to take the title, episode number, artwork and publish date from; --title and --episode override it"`
+	EpisodeGUID        string   `name:"episode-guid" help:"GUID of the --rss episode to use (default: the newest)"`
+	TextColor          string   `help:"Text color in hex format (e.g., #F8B31D or F8B31D)"`
+	Font               string   `help:"Path to a TrueType font for the title, badge and thumbnail text (for scripts the built-in font lacks)"`
+	FontFallback       []string `help:"Path to a font for characters --font lacks, such as another script or emoji; repeat for more, tried in order"`
+	ThumbnailImage     string   `help:"Path to custom thumbnail image (PNG, JPEG or WebP; cropped to fill the thumbnail)"`
+	ThumbnailTextColor string   `help:"Thumbnail text color in hex format (defaults to --text-color)"`
+	ThumbnailRotation  *float64 `help:"Thumbnail text rotation in degrees clockwise, 0 to disable (default 3)"`
+	ThumbnailAlign     string   `help:"Thumbnail text alignment: left, centre, right" default:"centre"`
+	ThumbnailMaxLines  int      `help:"Number of lines the thumbnail title is split across" default:"2"`
+	ThumbnailSize      string   `help:"Thumbnail resolution as WIDTHxHEIGHT (e.g., 1920x1080; defaults to the video resolution)"`
+
+	feedEpisode *feed.Episode // Set by applyFeed from --rss
+}
+
+// backgroundFlags are the background image options shared by the render and
+// idle commands.
+type backgroundFlags struct {
+	BackgroundImage string  `help:"Path to custom background image (PNG, JPEG or WebP; scaled to 1280x720 per --background-fit)"`
+	BackgroundFit   string  `help:"Fit a background of another aspect ratio: cover (crop to fill), contain (letterbox) or stretch" default:"cover"`
+	BackgroundDim   float64 `help:"Darken the background by this fraction, 0 to 1 (e.g. 0.4), to keep bars and text readable" default:"0"`
+	BackgroundBlur  int     `help:"Blur the background with this radius in pixels" default:"0"`
+}
+
+type renderCmd struct {
+	Input  string `arg:"" name:"input" help:"Input WAV file" optional:""`
+	Output string `arg:"" name:"output" help:"Output MP4 file, or - to stream to stdout (not needed with --frames-only)" optional:""`
+	renderFlags
+
+	// Set by runClip for `jivefire clip`
+	clipShape config.ClipShape
+	clipQuote string
+
+	analysisOut string // Set by runAnalyze: where to save Pass 1
+}
+
+// renderFlags are the render options, apart from the input and output, so
+// jivefire test can take them all while making its own input.
+type renderFlags struct {
+	textFlags
+	OutputTemplate string  `help:"Build the output name from a template instead of <output>, e.g. \"{slug}-e{episode:03d}-{date}.mp4\" (variables: {title}, {slug}, {episode}, {input}, {date})"`
+	Channels       int     `help:"Audio channels in MP4: 1 (mono), 2 (stereo) or 6 (5.1); 0 matches the source" default:"0"`
+	Gain           float64 `help:"Raise (or, negative, lower) the level of the encoded audio by this many dB" default:"0"`
+	Normalize      float64 `help:"Normalise the encoded audio to this integrated loudness in LUFS (e.g. -16 for podcasts); 0 leaves the level as it is" default:"0"`
+	Surround       string  `help:"When matching a source with more than two channels: downmix to stereo or passthrough as 5.1" default:"downmix"`
+	Decoder        string  `help:"Decode the input with ffmpeg (any format) or native (uncompressed WAV and AIFF, mono or stereo, in pure Go)" default:"ffmpeg"`
+	AudioOut       string  `help:"Also write the encoded audio alone to this .m4a or .aac file, after --gain or --normalize and any downmix" type:"path"`
+	Music          string  `help:"Mix this audio file under the input as a music bed, looped to the end, before analysis and encoding"`
+	MusicGain      string  `help:"Level of the --music bed in dB (e.g. --music-gain=-18dB)" default:"-18dB"`
+	MusicDuck      float64 `help:"Lower the --music bed by this many dB more while the voice is speaking (e.g. 10); 0 keeps it level" default:"0"`
+	Intro          string  `help:"Play this audio file (e.g. the show's jingle) before the episode"`
+	Outro          string  `help:"Play this audio file after the episode"`
+	Crossfade      string  `help:"Overlap --intro and --outro with the episode by this long, fading one into the other (e.g. 2s)" default:"0s"`
+	BarColor       string  `help:"Bar color in hex format (e.g., #A40000 or A40000)"`
+	Bands          string  `help:"Colour the bars by band, split at these crossover frequencies in Hz (e.g. 250,4000 for bass, voice and treble)"`
+	BandColors     string  `help:"Comma-separated hex colours for --bands, bass first; unset bands keep the bar colour for the bass, then amber and blue"`
+	BarColorMode   string  `help:"Bar colouring: fixed (--bar-color, or --bands), or reactive (blended by the sound from the first --reactive-colors colour for bass to the second for treble)" default:"fixed"`
+	ReactiveColors string  `help:"Two comma-separated hex colours bounding --bar-color-mode=reactive, bass-heavy first" default:"#E0301E,#F8D41D"`
+	CentroidGlow   string  `help:"Brighten with the spectral centroid for treble-heavy moments, dimming a little for bass-heavy ones: none, bars, or background (which also warms as it dims)" default:"none"`
+	NoiseGate      float64 `help:"Treat bar levels below this as noise, 0 to 1 (0 disables the gate)" default:"0.01"`
+	MinBar         float64 `help:"Keep quiet bars moving during soft speech at up to this fraction of full height, 0 to 0.5 (e.g. 0.05)" default:"0"`
+	FreqMin        float64 `help:"Lowest frequency in Hz shown across the bars (e.g. 40 for voice)" default:"0"`
+	FreqMax        float64 `help:"Highest frequency in Hz shown across the bars (e.g. 12000 for voice); 0 runs to half the sample rate" default:"0"`
+	VisHighpass    float64 `help:"Filter out frequencies below this many Hz before the bars analyse the audio (e.g. 80 for room rumble); the encoded audio is untouched" default:"0"`
+	VisLowpass     float64 `help:"Filter out frequencies above this many Hz before the bars analyse the audio (e.g. 12000); the encoded audio is untouched" default:"0"`
+	Declip         bool    `help:"Rebuild clipped peaks, and high-pass at 40 Hz unless --vis-highpass is set, before the bars analyse the audio; the encoded audio is untouched"`
+	AVOffset       int     `name:"av-offset" help:"Draw the bars this many milliseconds after the audio, or before it when negative (e.g. -40 if they seem to lag); the encoded audio is untouched" default:"0"`
+	Scale          float64 `help:"Bar scale to use instead of the one derived in analysis (its Optimal Scale in the summary); 0 keeps the derived scale" default:"0"`
+	Analysis       string  `help:"Skip the analysis pass and render from this file saved by jivefire analyze, made with the same input and settings" type:"path"`
+
+	// Split renders (segments.go)
+	Segments      int      `help:"Render the video in this many processes at once, a segment of the episode each, and join them (not with --music, --intro or --outro)" default:"0"`
+	Segment       string   `help:"Render only segment K of N (e.g. 2/4) of the video, without audio, for --join; needs --analysis"`
+	Join          []string `help:"Mux these --segment renders, listed in order, with the audio of <input> into <output>; needs --analysis"`
+	SegmentWorker string   `hidden:"" help:"Segment file a --segments worker writes"`
+
+	backgroundFlags
+	BackgroundMotion string  `help:"Move the background over the episode: none, or kenburns (a slow zoom and pan across the artwork)" default:"none"`
+	NoThumbnail      bool    `help:"Skip generating the thumbnail PNG"`
+	Thumbnails       int     `help:"Also write N thumbnail variants over video frames spread through the episode (output-1.png, ...)" default:"0"`
+	Chapters         string  `help:"Path to chapters file (one \"MM:SS Title\" per line) to embed as MP4 chapters"`
+	WriteDescription bool    `help:"Write a YouTube description (title, duration, chapters) alongside the video"`
+	TitleAlign       string  `help:"Video title alignment: left, centre, right" default:"centre"`
+	TitleMaxLines    int     `help:"Maximum lines the video title wraps onto" default:"2"`
+	BadgeImage       string  `help:"Path to a PNG logo drawn in the corner in place of the episode number"`
+	BadgePosition    string  `help:"Badge corner: top-left, top-right, bottom-left, bottom-right" default:"top-right"`
+	BadgePadding     *int    `help:"Badge inset in pixels from the frame edges (default 30)"`
+	BadgePulse       bool    `help:"Pulse the badge opacity with the audio loudness"`
+	Meter            string  `help:"Draw a stereo level meter in dBFS in a corner: top-left, top-right, bottom-left, bottom-right"`
+	Timecode         string  `help:"Burn an HH:MM:SS:FF timecode into a corner, for reviewing edits: top-left, top-right, bottom-left, bottom-right"`
+	TimecodeSize     float64 `help:"Timecode font size in points, 12 to 72" default:"24"`
+	TimecodePill     float64 `help:"Opacity of the rounded panel behind the timecode, 0 (none) to 1" default:"0.6"`
+	Particles        bool    `help:"Draw embers rising from the bars, more and faster as the audio gets louder"`
+	Visualizer       string  `aliases:"style" help:"Visualiser drawn over the background: bars, waveform and spectrogram are built in, custom builds can register more" default:"bars"`
+	WaveformLayout   string  `help:"Waveform visualiser layout: scroll (newest at the right) or centre (newest in the middle, spreading out)" default:"scroll"`
+	Colormap         string  `help:"Spectrogram visualiser colours: magma, viridis, fire or grey" default:"magma"`
+	Script           string  `help:"Lua script called every frame to draw an overlay (rects, lines, text) from the bar heights and time"`
+	Layout           string  `help:"JSON file listing the widgets drawn over the visualiser (framing lines, title, badge, meter, timecode, progress, script) with their anchors and z-order" type:"path"`
+	NoPreview        bool    `help:"Disable video preview during encoding"`
+	Lang             string  `help:"Language of the progress display and summary: de, en, es or fr (default from LC_ALL, LC_MESSAGES or LANG)"`
+	Report           string  `help:"Write a JSON run report (timings, encoder, sizes, audio profile) to this path on completion" type:"path"`
+	ExportFeatures   string  `help:"Write every frame's audio features (spectral centroid, flux, zero-crossing rate) to this CSV file" type:"path"`
+	NotifyURL        string  `help:"POST the JSON run report to this URL when the render finishes, fails or is cancelled"`
+	NotifyCmd        string  `help:"Run this shell command when the render finishes, fails or is cancelled, with JIVEFIRE_STATUS, JIVEFIRE_OUTPUT, JIVEFIRE_DURATION and more set"`
+	MetricsAddr      string  `help:"Serve the render's progress at /metrics on this address (e.g. :9100) in the Prometheus text format, while it runs"`
+	PreviewWindow    bool    `help:"Also show the frames being encoded in a video window at full colour (needs ffplay on PATH)"`
+	Play             bool    `help:"Play the audio through the system's sound output as it is encoded, holding the render to real time so the preview keeps pace with the sound (needs pw-play or ffplay on PATH)"`
+	Guides           bool    `help:"Draw YouTube's safe areas and the strips its player covers on the previews, to check text placement; never encoded"`
+	FramesDir        string  `help:"Also write every frame as a numbered image into this directory (frame-000001.png, ...)" type:"path"`
+	FramesFormat     string  `help:"Image format for --frames-dir: png or jpeg" default:"png"`
+	FramesOnly       bool    `help:"Write only the --frames-dir images, without encoding a video or thumbnail"`
+	FramesAudio      bool    `help:"Also write the matching audio to audio.wav in --frames-dir"`
+	FrequencyAxis    bool    `help:"Label the terminal spectrum with frequency markers"`
+	PreviewProtocol  string  `help:"Preview graphics: auto, blocks, kitty, iterm2 or sixel (auto detects kitty, Ghostty, iTerm2, WezTerm, foot and mlterm)" default:"auto"`
+	PreviewFPS       float64 `name:"preview-fps" help:"Preview refresh rate in frames per second, rounded to a + and - step (default: live for blocks, 2 for image protocols)"`
+	PreviewSize      string  `help:"Preview size in terminal cells, WIDTHxHEIGHT or a width alone for 16:9 (default 72x20; shrinks to fit the terminal)"`
+	Encoder          string  `help:"Video encoder: auto, nvenc, qsv, vaapi, vulkan, amf, mf (Windows), software" default:"auto"`
+	VideoCodec       string  `help:"Video codec: h264, or av1 (needs an NVENC, QSV, VA-API or AMF AV1 encoder; not for mpegts)" default:"h264"`
+	AudioCodec       string  `help:"Audio codec: aac, opus (resampled to 48 kHz) for .mkv and .webm outputs, or lossless flac for .mkv" default:"aac"`
+	HWDevice         string  `help:"Hardware device to encode on, for systems with more than one GPU: a render node (e.g. /dev/dri/renderD129) for qsv and vaapi, or a GPU index for nvenc, vulkan and qsv on Windows"`
+	ColorSpace       string  `help:"RGB to YUV matrix tagged on the video: auto (bt601 on NVENC at limited range, which it converts on the GPU; bt709 otherwise), bt709 (HD, what YouTube expects) or bt601" default:"auto"`
+	ColorRange       string  `help:"YUV code range tagged on the video: limited (TV, standard for H.264) or full" default:"limited"`
+	Profile          string  `help:"Rate control: fast (quick CRF 24), youtube (capped at YouTube's 720p bitrate), archive (high quality) or small (smallest files)" default:"fast"`
+	EncoderOpts      string  `help:"Extra FFmpeg options for the video encoder as comma-separated key=value pairs (e.g. \"g=60,x264-params=aq-mode=3\"), applied over Jivefire's own"`
+	EncodeRetries    int     `help:"Times a transient encoder failure (a busy device, an interrupted or timed-out call) is retried, with a growing pause, before the render fails; 0 to 10" default:"3"`
+	EncodeQueue      int     `help:"Frames the render may draw ahead of the video encoder, 0 to 64, to ride out disk or GPU stalls; 0 encodes each frame as it is drawn" default:"8"`
+	GOP              int     `name:"gop" help:"Frames from one keyframe to the next (e.g. 30 for streaming, 300 for an archive); 0 for two seconds" default:"0"`
+	BFrames          string  `name:"bframes" help:"Most B-frames in a row, 0 to 16 (NVENC takes 4, AMF 3); auto keeps each encoder's own choice for the --profile" default:"auto"`
+	KeyframeInterval int     `help:"Also force a keyframe every this many seconds, as well as the one at each --chapters start; 0 for none" default:"0"`
+	Start            string  `help:"Render from this point in the audio, as [HH:]MM:SS (e.g. 05:00 to skip pre-roll)"`
+	End              string  `help:"Stop rendering at this point in the audio, as [HH:]MM:SS"`
+	Duration         string  `help:"Render only this much audio (e.g. 45m or 1h2m30s), also used for the size estimate and progress when a file reports the wrong length"`
+	Speed            float64 `help:"Play the audio this many times faster, 1 to 16 (e.g. 2 for a recap at double speed); the pitch is kept" default:"1"`
+	MaxMemory        string  `help:"Stop the render if the Go heap outgrows this size (e.g. 512M or 2G; also the garbage collector's soft limit)"`
+	Format           string  `help:"Container format: mp4, mpegts, hls or dash (guessed from the output name, e.g. .m3u8 or .mpd; mp4 when streaming to stdout)"`
+	SegmentLength    int     `help:"HLS/DASH segment length in seconds" default:"6"`
+	Archive          bool    `help:"Write a Matroska archive to an .mkv output, carrying the source audio and its analysis as attachments"`
+}
+
+type thumbnailCmd struct {
+	Output string `arg:"" name:"output" help:"Output PNG file" optional:""`
+	textFlags
+	OutputTemplate string `help:"Build the output name from a template instead of <output>; the extension is replaced with .png"`
+}
+
 var CLI struct {
-	Input           string `arg:"" name:"input" help:"Input WAV file" optional:""`
-	Output          string `arg:"" name:"output" help:"Output MP4 file" optional:""`
-	Episode         *int   `help:"Episode number (omitted from output when not set)"`
-	Title           string `help:"Podcast title" default:"Podcast Title"`
-	Channels        int    `help:"Audio channels in MP4: 1 (mono) or 2 (stereo)" default:"1"`
-	BarColor        string `help:"Bar color in hex format (e.g., #A40000 or A40000)"`
-	TextColor       string `help:"Text color in hex format (e.g., #F8B31D or F8B31D)"`
-	BackgroundImage string `help:"Path to custom background image (PNG, 1280x720)"`
-	ThumbnailImage  string `help:"Path to custom thumbnail image (PNG, 1280x720)"`
-	NoPreview       bool   `help:"Disable video preview during encoding"`
-	Encoder         string `help:"Video encoder: auto, nvenc, qsv, vaapi, vulkan, software" default:"auto"`
-	Version         bool   `help:"Show version information"`
-	Probe           bool   `help:"Probe and display available hardware encoders"`
+	Render       renderCmd    `cmd:"" default:"withargs" help:"Render a podcast audio file to an MP4 visualiser (default)"`
+	Thumbnail    thumbnailCmd `cmd:"" help:"Generate only the thumbnail PNG from the title and episode, without reading audio"`
+	Clip         clipCmd      `cmd:"" help:"Render a short section (--start, --end) as a square or vertical audiogram with a large quote, for social media"`
+	Analyze      analyzeCmd   `cmd:"" aliases:"analyse" help:"Run only the analysis pass and save it with --out, for render --analysis to encode from on another machine"`
+	Idle         idleCmd      `cmd:"" help:"Render a seamlessly looping visualiser without audio, for a live stream's starting-soon screen"`
+	Test         testCmd      `cmd:"" help:"Render octave tones, a frequency sweep and pink noise, labelled, to see which bars respond to which frequencies"`
+	Selftest     selftestCmd  `cmd:"" help:"Encode a few seconds through every available encoder, check the output decodes, and compare speeds"`
+	Bench        benchCmd     `cmd:"" help:"Time each stage of the pipeline (fft, draw, yuv, encode) and a whole render (end-to-end), per frame"`
+	Studio       studioCmd    `cmd:"" help:"Serve a local web page to try colours, fonts and background settings on a live preview, and copy the render flags for them"`
+	VersionCmd   versionCmd   `cmd:"" name:"version" help:"Show version information; --json adds the commit, build date, FFmpeg versions and encoders"`
+	Version      bool         `help:"Show version information"`
+	Probe        bool         `help:"Probe and display available hardware encoders"`
+	NoProbeCache bool         `help:"Probe hardware encoders afresh instead of using the results cached by an earlier run"`
 }
 
 func main() {
@@ -56,142 +253,1342 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Probe flag: display hardware encoder status, then exit
-	if CLI.Probe {
-		encoders := encoder.DetectHWEncoders()
-		var infos []cli.EncoderInfo
-		for _, enc := range encoders {
-			infos = append(infos, cli.EncoderInfo{
-				Name:        enc.Name,
-				Description: enc.Description,
-				Available:   enc.Available,
-			})
+	encoder.ProbeCacheRefresh = CLI.NoProbeCache
+
+	// Probe flag: display hardware encoder status, then exit
+	if CLI.Probe {
+		encoders := encoder.DetectHWEncoders()
+		var infos []cli.EncoderInfo
+		for _, enc := range encoders {
+			infos = append(infos, cli.EncoderInfo{
+				Name:        enc.Name,
+				Description: enc.Description,
+				Available:   enc.Available,
+			})
+		}
+		cli.PrintHardwareProbe(infos)
+		os.Exit(0)
+	}
+
+	if strings.HasPrefix(ctx.Command(), "thumbnail") {
+		runThumbnail(&CLI.Thumbnail)
+		return
+	}
+	if strings.HasPrefix(ctx.Command(), "clip") {
+		runClip(ctx, &CLI.Clip)
+		return
+	}
+	if strings.HasPrefix(ctx.Command(), "analyze") {
+		runAnalyze(ctx, &CLI.Analyze)
+		return
+	}
+	if strings.HasPrefix(ctx.Command(), "idle") {
+		runIdle(&CLI.Idle)
+		return
+	}
+	if strings.HasPrefix(ctx.Command(), "test") {
+		runTest(ctx, &CLI.Test)
+		return
+	}
+	if ctx.Command() == "selftest" {
+		runSelftest(&CLI.Selftest)
+		return
+	}
+	if strings.HasPrefix(ctx.Command(), "bench") {
+		runBench(&CLI.Bench)
+		return
+	}
+	if ctx.Command() == "studio" {
+		runStudio(&CLI.Studio)
+		return
+	}
+	if ctx.Command() == "version" {
+		runVersion(&CLI.VersionCmd)
+		return
+	}
+	runRender(ctx, &CLI.Render)
+}
+
+// applyTextFlags validates the shared title and thumbnail flags into
+// runtimeConfig, exiting on invalid values and warning about titles the font
+// cannot render faithfully.
+func applyTextFlags(flags *textFlags, runtimeConfig *config.RuntimeConfig) {
+	if flags.TextColor != "" {
+		r, g, b, err := config.ParseHexColor(flags.TextColor)
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --text-color: %v", err))
+			os.Exit(1)
+		}
+		runtimeConfig.TextColor = config.OptionalColor{R: r, G: g, B: b, Set: true}
+	}
+
+	if flags.ThumbnailImage != "" {
+		if _, err := os.Stat(flags.ThumbnailImage); os.IsNotExist(err) {
+			cli.PrintError(fmt.Sprintf("thumbnail image does not exist: %s", flags.ThumbnailImage))
+			os.Exit(failure.ExitInputNotFound)
+		}
+		if err := renderer.ValidateImage(flags.ThumbnailImage); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --thumbnail-image: %v", err))
+			os.Exit(failure.ExitCode(err))
+		}
+		runtimeConfig.ThumbnailImagePath = flags.ThumbnailImage
+	}
+
+	if flags.Font != "" {
+		if _, err := os.Stat(flags.Font); os.IsNotExist(err) {
+			cli.PrintError(fmt.Sprintf("font does not exist: %s", flags.Font))
+			os.Exit(failure.ExitInputNotFound)
+		}
+		runtimeConfig.FontPath = flags.Font
+	}
+	for _, path := range flags.FontFallback {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			cli.PrintError(fmt.Sprintf("font does not exist: %s", path))
+			os.Exit(failure.ExitInputNotFound)
+		}
+	}
+	runtimeConfig.FontFallbacks = flags.FontFallback
+
+	// Flag titles the fonts cannot represent before spending time on the
+	// render.
+	missing, err := renderer.MissingGlyphs(flags.Title, runtimeConfig)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --font or --font-fallback: %v", err))
+		os.Exit(1)
+	}
+	if len(missing) > 0 {
+		cli.PrintWarning(fmt.Sprintf("title font has no glyphs for %q; use --font or --font-fallback with a font that covers them", string(missing)))
+	}
+
+	if flags.ThumbnailTextColor != "" {
+		r, g, b, err := config.ParseHexColor(flags.ThumbnailTextColor)
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --thumbnail-text-color: %v", err))
+			os.Exit(1)
+		}
+		runtimeConfig.ThumbnailTextColor = config.OptionalColor{R: r, G: g, B: b, Set: true}
+	}
+
+	runtimeConfig.ThumbnailRotation = flags.ThumbnailRotation
+
+	thumbnailAlign, err := config.ParseTextAlign(flags.ThumbnailAlign)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --thumbnail-align: %v", err))
+		os.Exit(1)
+	}
+	runtimeConfig.ThumbnailAlign = thumbnailAlign
+
+	if flags.ThumbnailMaxLines < 1 {
+		cli.PrintError(fmt.Sprintf("invalid --thumbnail-max-lines: %d (must be at least 1)", flags.ThumbnailMaxLines))
+		os.Exit(1)
+	}
+	runtimeConfig.ThumbnailMaxLines = flags.ThumbnailMaxLines
+
+	if flags.ThumbnailSize != "" {
+		w, h, err := config.ParseSize(flags.ThumbnailSize)
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --thumbnail-size: %v", err))
+			os.Exit(1)
+		}
+		runtimeConfig.ThumbnailWidth = w
+		runtimeConfig.ThumbnailHeight = h
+	}
+}
+
+// applyBackgroundFlags validates the shared background flags into
+// runtimeConfig, exiting on invalid values.
+func applyBackgroundFlags(flags *backgroundFlags, runtimeConfig *config.RuntimeConfig) {
+	if flags.BackgroundImage != "" {
+		if _, err := os.Stat(flags.BackgroundImage); os.IsNotExist(err) {
+			cli.PrintError(fmt.Sprintf("background image does not exist: %s", flags.BackgroundImage))
+			os.Exit(failure.ExitInputNotFound)
+		}
+		if err := renderer.ValidateImage(flags.BackgroundImage); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --background-image: %v", err))
+			os.Exit(failure.ExitCode(err))
+		}
+		runtimeConfig.BackgroundImagePath = flags.BackgroundImage
+	}
+
+	backgroundFit, err := config.ParseBackgroundFit(flags.BackgroundFit)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --background-fit: %v", err))
+		os.Exit(1)
+	}
+	runtimeConfig.BackgroundFit = backgroundFit
+
+	if flags.BackgroundDim < 0 || flags.BackgroundDim > 1 {
+		cli.PrintError(fmt.Sprintf("invalid --background-dim: %g (must be between 0 and 1)", flags.BackgroundDim))
+		os.Exit(1)
+	}
+	if flags.BackgroundBlur < 0 || flags.BackgroundBlur > maxBackgroundBlur {
+		cli.PrintError(fmt.Sprintf("invalid --background-blur: %d (must be between 0 and %d)", flags.BackgroundBlur, maxBackgroundBlur))
+		os.Exit(1)
+	}
+	runtimeConfig.BackgroundDim = flags.BackgroundDim
+	runtimeConfig.BackgroundBlur = flags.BackgroundBlur
+}
+
+// resolveOutput returns the output path, expanding tmpl when set. The
+// template and an explicit output are mutually exclusive; errors exit.
+func resolveOutput(output, tmpl, input string, flags *textFlags) string {
+	if tmpl == "" {
+		return output
+	}
+	if output != "" {
+		cli.PrintError("give either <output> or --output-template, not both")
+		os.Exit(1)
+	}
+	expanded, err := naming.Expand(tmpl, naming.Vars{
+		Title:   flags.Title,
+		Episode: flags.Episode,
+		Input:   input,
+		Date:    templateDate(flags),
+	})
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --output-template: %v", err))
+		os.Exit(1)
+	}
+	return expanded
+}
+
+// runThumbnail writes only the thumbnail PNG, for episodes whose video has
+// already been rendered.
+func runThumbnail(cmd *thumbnailCmd) {
+	applyFeed(&cmd.textFlags)
+	name := resolveOutput(cmd.Output, cmd.OutputTemplate, "", &cmd.textFlags)
+	if name == "" {
+		cli.PrintError("<output> or --output-template is required")
+		os.Exit(1)
+	}
+	if cmd.OutputTemplate != "" {
+		name = sidecarPath(name, ".png")
+	}
+	cmd.Output = name
+
+	runtimeConfig := &config.RuntimeConfig{}
+	applyTextFlags(&cmd.textFlags, runtimeConfig)
+
+	dest, err := output.Open(cmd.Output)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid <output>: %v", err))
+		os.Exit(1)
+	}
+
+	meta := renderer.PodcastMeta{Title: cmd.Title, Episode: cmd.Episode}
+	if err := renderer.GenerateThumbnail(dest.Path(), meta, runtimeConfig); err != nil {
+		_ = dest.Discard()
+		cli.PrintError(fmt.Sprintf("failed to generate thumbnail: %v", err))
+		os.Exit(1)
+	}
+	if err := dest.Publish(); err != nil {
+		cli.PrintError(err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("%s %s\n", cli.KeyStyle.Render("Thumbnail:"), cli.ValueStyle.Render(dest.String()))
+}
+
+// runRender validates the render flags and encodes the video.
+func runRender(ctx *kong.Context, cmd *renderCmd) {
+	// No arguments: show usage instead of erroring
+	if cmd.Input == "" && cmd.Output == "" && cmd.OutputTemplate == "" {
+		_ = ctx.PrintUsage(true)
+		os.Exit(0)
+	}
+
+	applyFeed(&cmd.textFlags)
+	analysing := cmd.analysisOut != ""
+	if !analysing {
+		cmd.Output = resolveOutput(cmd.Output, cmd.OutputTemplate, cmd.Input, &cmd.textFlags)
+	}
+	// A --segments worker writes where its coordinator says, and reports to
+	// it alone.
+	if cmd.SegmentWorker != "" {
+		cmd.Output = cmd.SegmentWorker
+		cmd.Report, cmd.NotifyURL, cmd.NotifyCmd, cmd.MetricsAddr = "", "", "", ""
+		cmd.NoPreview, cmd.PreviewWindow, cmd.Guides, cmd.Play = true, false, false, false
+	}
+
+	frameSeq := parseFramesFlags(cmd)
+	if err := checkAudioOut(cmd.AudioOut, cmd.Output); err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --audio-out: %v", err))
+		os.Exit(1)
+	}
+	if cmd.FramesOnly || analysing {
+		if cmd.Input == "" {
+			cli.PrintError("<input> is required")
+			os.Exit(1)
+		}
+	} else if cmd.Input == "" || cmd.Output == "" {
+		cli.PrintError("<input> and <output> are required")
+		os.Exit(1)
+	}
+
+	if _, err := os.Stat(cmd.Input); os.IsNotExist(err) {
+		cli.PrintError(fmt.Sprintf("input file does not exist: %s", cmd.Input))
+		os.Exit(failure.ExitInputNotFound)
+	}
+
+	if cmd.Format != "" && !slices.Contains(validFormats, cmd.Format) {
+		cli.PrintError(fmt.Sprintf("invalid --format: %s (must be one of %s)", cmd.Format, strings.Join(validFormats, ", ")))
+		os.Exit(1)
+	}
+
+	if cmd.Archive && !analysing {
+		if cmd.FramesOnly {
+			cli.PrintError("--archive cannot be combined with --frames-only, which writes no video")
+			os.Exit(1)
+		}
+		if err := checkArchive(cmd.Output, cmd.Format, cmd.Input); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --archive: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	// Streaming has no file name to derive sidecar paths from.
+	streaming := cmd.Output == encoder.StdoutPath
+	if streaming && (cmd.WriteDescription || cmd.Thumbnails > 0) {
+		cli.PrintError("--write-description and --thumbnails need an output file, not stdout")
+		os.Exit(1)
+	}
+	if streaming && (cmd.Format == "hls" || cmd.Format == "dash") {
+		cli.PrintError(fmt.Sprintf("--format=%s writes segment files and cannot stream to stdout", cmd.Format))
+		os.Exit(1)
+	}
+
+	if cmd.SegmentLength < 1 {
+		cli.PrintError(fmt.Sprintf("invalid --segment-length: %d (must be at least 1)", cmd.SegmentLength))
+		os.Exit(1)
+	}
+	split, err := parseSplit(cmd)
+	if err != nil {
+		cli.PrintError(err.Error())
+		os.Exit(1)
+	}
+	// A segment is only video, for the --join render's thumbnail.
+	if split.count > 0 {
+		cmd.NoThumbnail = true
+	}
+
+	if cmd.Channels != 0 && cmd.Channels != 1 && cmd.Channels != 2 && cmd.Channels != 6 {
+		cli.PrintError(fmt.Sprintf("invalid channels value: %d (must be 0, 1, 2 or 6)", cmd.Channels))
+		os.Exit(1)
+	}
+	if cmd.Surround != "downmix" && cmd.Surround != "passthrough" {
+		cli.PrintError(fmt.Sprintf("invalid --surround: %q (must be downmix or passthrough)", cmd.Surround))
+		os.Exit(1)
+	}
+	if cmd.Decoder != "ffmpeg" && cmd.Decoder != "native" {
+		cli.PrintError(fmt.Sprintf("invalid --decoder: %q (must be ffmpeg or native)", cmd.Decoder))
+		os.Exit(1)
+	}
+	if cmd.Decoder == "native" && cmd.Channels == 6 {
+		cli.PrintError("--decoder=native cannot be combined with --channels=6, as it reads mono and stereo")
+		os.Exit(1)
+	}
+	if cmd.Channels == 6 && cmd.Speed != 1 {
+		cli.PrintError("--channels=6 cannot be combined with --speed, whose time-stretched audio is mono")
+		os.Exit(1)
+	}
+
+	hwAccelType, ok := validEncoders[cmd.Encoder]
+	if !ok {
+		cli.PrintError(fmt.Sprintf("invalid --encoder value: %s (must be auto, nvenc, qsv, vaapi, vulkan, amf, mf, or software)", cmd.Encoder))
+		os.Exit(1)
+	}
+
+	videoCodec, err := encoder.ParseVideoCodec(cmd.VideoCodec)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --video-codec: %v", err))
+		os.Exit(1)
+	}
+	if videoCodec == encoder.CodecAV1 {
+		if hwAccelType == encoder.HWAccelNone || hwAccelType == encoder.HWAccelVulkan || hwAccelType == encoder.HWAccelMF {
+			cli.PrintError(fmt.Sprintf("--video-codec=av1 needs --encoder auto, nvenc, qsv, vaapi or amf, not %s", cmd.Encoder))
+			os.Exit(1)
+		}
+		if cmd.Format == "mpegts" || (cmd.Format == "" && strings.EqualFold(filepath.Ext(cmd.Output), ".ts")) {
+			cli.PrintError("--video-codec=av1 cannot be muxed into MPEG-TS; use mp4, hls or dash")
+			os.Exit(1)
+		}
+	}
+
+	audioCodec, err := encoder.ParseAudioCodec(cmd.AudioCodec)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --audio-codec: %v", err))
+		os.Exit(1)
+	}
+	// A --segment render is video alone; its audio comes with --join.
+	if !analysing && !cmd.FramesOnly && split.count == 0 {
+		if err := checkAudioCodec(audioCodec, cmd.Output, cmd.Format, videoCodec); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --audio-codec: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	colorSpace, err := yuv.ParseColorSpace(cmd.ColorSpace)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --color-space: %v", err))
+		os.Exit(1)
+	}
+	colorRange, err := yuv.ParseColorRange(cmd.ColorRange)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --color-range: %v", err))
+		os.Exit(1)
+	}
+	encodeProfile, err := encoder.ParseProfile(cmd.Profile)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --profile: %v", err))
+		os.Exit(1)
+	}
+	hooks := notify.Hooks{URL: cmd.NotifyURL, Command: cmd.NotifyCmd, ReportPath: cmd.Report}
+	if cmd.NotifyURL != "" {
+		if err := notify.ValidateURL(cmd.NotifyURL); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --notify-url: %v", err))
+			os.Exit(1)
+		}
+	}
+	encoderOpts, err := encoder.ParseOptions(cmd.EncoderOpts)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --encoder-opts: %v", err))
+		os.Exit(1)
+	}
+	if cmd.EncodeRetries < 0 || cmd.EncodeRetries > maxEncodeRetries {
+		cli.PrintError(fmt.Sprintf("invalid --encode-retries: %d (must be 0 to %d)", cmd.EncodeRetries, maxEncodeRetries))
+		os.Exit(1)
+	}
+	if cmd.EncodeQueue < 0 || cmd.EncodeQueue > encoder.MaxQueue {
+		cli.PrintError(fmt.Sprintf("invalid --encode-queue: %d (must be 0 to %d)", cmd.EncodeQueue, encoder.MaxQueue))
+		os.Exit(1)
+	}
+	bFrames, err := parseGOP(cmd.GOP, cmd.BFrames, cmd.Output, cmd.Format, cmd.SegmentLength)
+	if err != nil {
+		cli.PrintError(err.Error())
+		os.Exit(1)
+	}
+	if cmd.KeyframeInterval < 0 {
+		cli.PrintError(fmt.Sprintf("invalid --keyframe-interval: %d (must be 0 or more)", cmd.KeyframeInterval))
+		os.Exit(1)
+	}
+	start, length, err := parseSection(cmd.Start, cmd.End, cmd.Duration)
+	if err != nil {
+		cli.PrintError(err.Error())
+		os.Exit(1)
+	}
+	if cmd.Speed < 1 || cmd.Speed > 16 {
+		cli.PrintError(fmt.Sprintf("invalid --speed: %g (must be between 1 and 16)", cmd.Speed))
+		os.Exit(1)
+	}
+	maxMemory, err := memlimit.ParseSize(cmd.MaxMemory)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --max-memory: %v", err))
+		os.Exit(1)
+	}
+
+	if cmd.HWDevice != "" && hwAccelType == encoder.HWAccelNone {
+		cli.PrintError("--hw-device needs a hardware encoder, not --encoder=software")
+		os.Exit(1)
+	}
+
+	// If user explicitly requested a specific hardware encoder, a device or
+	// AV1 (which has no software fallback), verify it's available. An
+	// analysis encodes nothing, so the GPU it names may be on another machine.
+	if !analysing && ((hwAccelType != encoder.HWAccelAuto && hwAccelType != encoder.HWAccelNone) || cmd.HWDevice != "" || videoCodec == encoder.CodecAV1) {
+		encoders := encoder.DetectHWEncodersOn(cmd.HWDevice)
+		selectedEncoder := encoder.SelectBestEncoderFrom(encoders, hwAccelType, videoCodec)
+		if selectedEncoder == nil && hwAccelType == encoder.HWAccelAuto && cmd.HWDevice == "" && videoCodec == encoder.CodecAV1 {
+			cli.PrintError("no AV1 hardware encoder is available (AV1 needs NVENC, QSV, VA-API or AMF); use --video-codec=h264")
+			os.Exit(failure.ExitHWUnavailable)
+		}
+		if selectedEncoder == nil && cmd.HWDevice != "" {
+			if hwAccelType == encoder.HWAccelAuto {
+				cli.PrintError(fmt.Sprintf("no hardware encoder works on --hw-device %s; check the device path or index", cmd.HWDevice))
+			} else {
+				cli.PrintError(fmt.Sprintf("requested encoder '%s' does not work on --hw-device %s; check the device path or index", cmd.Encoder, cmd.HWDevice))
+			}
+			os.Exit(failure.ExitHWUnavailable)
+		}
+		if selectedEncoder == nil {
+			// Requested encoder not available - list what IS available
+			var available []string
+			for _, enc := range encoders {
+				if enc.Available && enc.Codec == videoCodec {
+					available = append(available, string(enc.Type))
+				}
+			}
+			if len(available) > 0 {
+				cli.PrintError(fmt.Sprintf("requested encoder '%s' is not available. Available hardware encoders: %s",
+					cmd.Encoder, strings.Join(available, ", ")))
+			} else {
+				cli.PrintError(fmt.Sprintf("requested encoder '%s' is not available. No hardware encoders detected; use --encoder=software",
+					cmd.Encoder))
+			}
+			os.Exit(failure.ExitHWUnavailable)
+		}
+	}
+
+	runtimeConfig := &config.RuntimeConfig{ClipShape: cmd.clipShape, ClipQuote: cmd.clipQuote}
+
+	if cmd.BarColor != "" {
+		r, g, b, err := config.ParseHexColor(cmd.BarColor)
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --bar-color: %v", err))
+			os.Exit(1)
+		}
+		runtimeConfig.BarColor = config.OptionalColor{R: r, G: g, B: b, Set: true}
+	}
+	applyBandFlags(cmd, runtimeConfig)
+	applyColorModeFlags(cmd, runtimeConfig)
+
+	// The feed's artwork stands in for --background-image; a show's square
+	// cover is letterboxed or cropped per --background-fit like any other.
+	if ep := cmd.feedEpisode; ep != nil && ep.Artwork != "" && cmd.BackgroundImage == "" {
+		artwork, err := feedArtwork(ep)
+		if err != nil {
+			cli.PrintWarning(fmt.Sprintf("--rss: could not download the artwork, using the default background: %v", err))
+		} else {
+			cmd.BackgroundImage = artwork
+		}
+	}
+
+	applyBackgroundFlags(&cmd.backgroundFlags, runtimeConfig)
+	backgroundMotion, err := config.ParseBackgroundMotion(cmd.BackgroundMotion)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --background-motion: %v", err))
+		os.Exit(1)
+	}
+	runtimeConfig.BackgroundMotion = backgroundMotion
+
+	if cmd.NoiseGate < 0 || cmd.NoiseGate >= 1 {
+		cli.PrintError(fmt.Sprintf("invalid --noise-gate: %g (must be at least 0 and below 1)", cmd.NoiseGate))
+		os.Exit(1)
+	}
+	if cmd.MinBar < 0 || cmd.MinBar > 0.5 {
+		cli.PrintError(fmt.Sprintf("invalid --min-bar: %g (must be between 0 and 0.5)", cmd.MinBar))
+		os.Exit(1)
+	}
+	if err := checkScale(cmd.Scale); err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --scale: %v", err))
+		os.Exit(1)
+	}
+	runtimeConfig.NoiseGate = &cmd.NoiseGate
+	runtimeConfig.MinBar = cmd.MinBar
+	runtimeConfig.BaseScale = cmd.Scale
+
+	if cmd.FreqMin < 0 || cmd.FreqMax < 0 || (cmd.FreqMax > 0 && cmd.FreqMax <= cmd.FreqMin) {
+		cli.PrintError(fmt.Sprintf("invalid --freq-min/--freq-max: %g-%g Hz (the maximum must be above the minimum)", cmd.FreqMin, cmd.FreqMax))
+		os.Exit(1)
+	}
+	runtimeConfig.FreqMin = cmd.FreqMin
+	runtimeConfig.FreqMax = cmd.FreqMax
+
+	if cmd.VisHighpass < 0 || cmd.VisLowpass < 0 || (cmd.VisLowpass > 0 && cmd.VisLowpass <= cmd.VisHighpass) {
+		cli.PrintError(fmt.Sprintf("invalid --vis-highpass/--vis-lowpass: %g/%g Hz (the low-pass must be above the high-pass)", cmd.VisHighpass, cmd.VisLowpass))
+		os.Exit(1)
+	}
+	runtimeConfig.VisHighPass = cmd.VisHighpass
+	runtimeConfig.VisLowPass = cmd.VisLowpass
+	runtimeConfig.Declip = cmd.Declip
+	if cmd.AVOffset < -maxAVOffset || cmd.AVOffset > maxAVOffset {
+		cli.PrintError(fmt.Sprintf("invalid --av-offset: %d (must be between -%d and %d ms)", cmd.AVOffset, maxAVOffset, maxAVOffset))
+		os.Exit(1)
+	}
+	runtimeConfig.AVOffset = time.Duration(cmd.AVOffset) * time.Millisecond
+
+	if cmd.Gain < -40 || cmd.Gain > 40 {
+		cli.PrintError(fmt.Sprintf("invalid --gain: %g (must be between -40 and 40 dB)", cmd.Gain))
+		os.Exit(1)
+	}
+	if cmd.Normalize != 0 && (cmd.Normalize < -40 || cmd.Normalize > -5) {
+		cli.PrintError(fmt.Sprintf("invalid --normalize: %g (must be between -40 and -5 LUFS)", cmd.Normalize))
+		os.Exit(1)
+	}
+	if cmd.Gain != 0 && cmd.Normalize != 0 {
+		cli.PrintError("--gain and --normalize cannot be combined")
+		os.Exit(1)
+	}
+	runtimeConfig.GainDB = cmd.Gain
+	runtimeConfig.Normalize = cmd.Normalize
+
+	musicGain, err := parseDecibels(cmd.MusicGain)
+	if err != nil || musicGain < -60 || musicGain > 12 {
+		cli.PrintError(fmt.Sprintf("invalid --music-gain: %s (must be between -60 and 12 dB)", cmd.MusicGain))
+		os.Exit(1)
+	}
+	if cmd.MusicDuck < 0 || cmd.MusicDuck > 40 {
+		cli.PrintError(fmt.Sprintf("invalid --music-duck: %g (must be between 0 and 40 dB)", cmd.MusicDuck))
+		os.Exit(1)
+	}
+	if cmd.Music != "" {
+		if _, err := os.Stat(cmd.Music); os.IsNotExist(err) {
+			cli.PrintError(fmt.Sprintf("music file does not exist: %s", cmd.Music))
+			os.Exit(failure.ExitInputNotFound)
+		}
+	}
+	runtimeConfig.NativeDecoder = cmd.Decoder == "native"
+	runtimeConfig.MusicPath = cmd.Music
+	runtimeConfig.MusicGainDB = musicGain
+	runtimeConfig.MusicDuckDB = cmd.MusicDuck
+
+	for _, bumper := range []struct{ flag, path string }{{"--intro", cmd.Intro}, {"--outro", cmd.Outro}} {
+		if bumper.path == "" {
+			continue
+		}
+		if _, err := os.Stat(bumper.path); os.IsNotExist(err) {
+			cli.PrintError(fmt.Sprintf("%s file does not exist: %s", bumper.flag, bumper.path))
+			os.Exit(failure.ExitInputNotFound)
+		}
+	}
+	crossfade, err := time.ParseDuration(cmd.Crossfade)
+	if err != nil || crossfade < 0 || crossfade > 10*time.Second {
+		cli.PrintError(fmt.Sprintf("invalid --crossfade: %q (use a length such as 2s or 500ms, up to 10s)", cmd.Crossfade))
+		os.Exit(1)
+	}
+	runtimeConfig.IntroPath = cmd.Intro
+	runtimeConfig.OutroPath = cmd.Outro
+	runtimeConfig.Crossfade = crossfade
+
+	applyTextFlags(&cmd.textFlags, runtimeConfig)
+
+	titleAlign, err := config.ParseTextAlign(cmd.TitleAlign)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --title-align: %v", err))
+		os.Exit(1)
+	}
+	runtimeConfig.TitleAlign = titleAlign
+
+	if cmd.TitleMaxLines < 1 {
+		cli.PrintError(fmt.Sprintf("invalid --title-max-lines: %d (must be at least 1)", cmd.TitleMaxLines))
+		os.Exit(1)
+	}
+	runtimeConfig.TitleMaxLines = cmd.TitleMaxLines
+
+	if cmd.BadgeImage != "" {
+		if _, err := os.Stat(cmd.BadgeImage); os.IsNotExist(err) {
+			cli.PrintError(fmt.Sprintf("badge image does not exist: %s", cmd.BadgeImage))
+			os.Exit(failure.ExitInputNotFound)
+		}
+		runtimeConfig.BadgeImagePath = cmd.BadgeImage
+	}
+
+	badgePosition, err := config.ParseBadgePosition(cmd.BadgePosition)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --badge-position: %v", err))
+		os.Exit(1)
+	}
+	runtimeConfig.BadgePosition = badgePosition
+
+	if cmd.BadgePadding != nil && *cmd.BadgePadding < 0 {
+		cli.PrintError(fmt.Sprintf("invalid --badge-padding: %d (must not be negative)", *cmd.BadgePadding))
+		os.Exit(1)
+	}
+	runtimeConfig.BadgePadding = cmd.BadgePadding
+	runtimeConfig.BadgePulse = cmd.BadgePulse
+
+	loc := locale.FromEnv()
+	if cmd.Lang != "" {
+		var err error
+		if loc, err = locale.Parse(cmd.Lang); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --lang: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	// A layout lists what is drawn, so it can move the badge and captions,
+	// turn the meter, timecode and particles on or leave them off.
+	meterCorner, timecodeCorner := cmd.Meter, cmd.Timecode
+	runtimeConfig.Particles = cmd.Particles
+	if cmd.Layout != "" {
+		layout, err := config.LoadLayout(cmd.Layout)
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --layout: %v", err))
+			os.Exit(1)
+		}
+		if badge, ok := config.LayoutEntry(layout, config.WidgetBadge); ok && badge.Anchor != "" {
+			badgePosition = config.BadgePosition(badge.Anchor)
+			runtimeConfig.BadgePosition = badgePosition
+		}
+		meter, ok := config.LayoutEntry(layout, config.WidgetMeter)
+		switch {
+		case !ok && cmd.Meter != "":
+			cli.PrintError("invalid --meter: the --layout has no meter widget")
+			os.Exit(1)
+		case ok && meter.Anchor != "":
+			meterCorner = meter.Anchor
+		case ok && meterCorner == "":
+			meterCorner = string(config.BadgeBottomLeft)
+		}
+		timecode, ok := config.LayoutEntry(layout, config.WidgetTimecode)
+		switch {
+		case !ok && cmd.Timecode != "":
+			cli.PrintError("invalid --timecode: the --layout has no timecode widget")
+			os.Exit(1)
+		case ok && timecode.Anchor != "":
+			timecodeCorner = timecode.Anchor
+		case ok && timecodeCorner == "":
+			timecodeCorner = string(config.BadgeBottomRight)
+		}
+		_, particles := config.LayoutEntry(layout, config.WidgetParticles)
+		if !particles && cmd.Particles {
+			cli.PrintError("invalid --particles: the --layout has no particles widget")
+			os.Exit(1)
+		}
+		runtimeConfig.Particles = particles
+		if _, ok := config.LayoutEntry(layout, config.WidgetScript); !ok && cmd.Script != "" {
+			cli.PrintError("invalid --script: the --layout has no script widget")
+			os.Exit(1)
+		}
+		if _, ok := config.LayoutEntry(layout, config.WidgetCaptions); !ok && cmd.clipQuote != "" {
+			cli.PrintError("invalid --quote or --subtitles: the --layout has no captions widget")
+			os.Exit(1)
+		}
+		runtimeConfig.Layout = layout
+	}
+
+	if meterCorner != "" {
+		meter, err := config.ParseBadgePosition(meterCorner)
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --meter: %v", err))
+			os.Exit(1)
+		}
+		if _, badgeShown := config.LayoutEntry(runtimeConfig.GetLayout(), config.WidgetBadge); badgeShown && meter == badgePosition {
+			cli.PrintError(fmt.Sprintf("invalid --meter: %s is the badge's corner (move the badge with --badge-position)", meter))
+			os.Exit(1)
+		}
+		runtimeConfig.Meter = meter
+	}
+
+	if timecodeCorner != "" {
+		timecode, err := config.ParseBadgePosition(timecodeCorner)
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --timecode: %v", err))
+			os.Exit(1)
+		}
+		if _, badgeShown := config.LayoutEntry(runtimeConfig.GetLayout(), config.WidgetBadge); badgeShown && timecode == badgePosition {
+			cli.PrintError(fmt.Sprintf("invalid --timecode: %s is the badge's corner (move the badge with --badge-position)", timecode))
+			os.Exit(1)
+		}
+		if timecode == runtimeConfig.Meter {
+			cli.PrintError(fmt.Sprintf("invalid --timecode: %s is the meter's corner", timecode))
+			os.Exit(1)
+		}
+		if cmd.TimecodeSize < config.TimecodeMinFontSize || cmd.TimecodeSize > config.TimecodeMaxFontSize {
+			cli.PrintError(fmt.Sprintf("invalid --timecode-size: %g (must be between %g and %g)", cmd.TimecodeSize, config.TimecodeMinFontSize, config.TimecodeMaxFontSize))
+			os.Exit(1)
+		}
+		if cmd.TimecodePill < 0 || cmd.TimecodePill > 1 {
+			cli.PrintError(fmt.Sprintf("invalid --timecode-pill: %g (must be between 0 and 1)", cmd.TimecodePill))
+			os.Exit(1)
+		}
+		runtimeConfig.Timecode = timecode
+		runtimeConfig.TimecodeSize = cmd.TimecodeSize
+		runtimeConfig.TimecodePill = &cmd.TimecodePill
+	}
+
+	if !slices.Contains(renderer.Visualizers(), cmd.Visualizer) {
+		cli.PrintError(fmt.Sprintf("invalid --visualizer: %s (must be one of %s)", cmd.Visualizer, strings.Join(renderer.Visualizers(), ", ")))
+		os.Exit(1)
+	}
+	runtimeConfig.Visualizer = cmd.Visualizer
+
+	waveformLayout, err := config.ParseWaveformLayout(cmd.WaveformLayout)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --waveform-layout: %v", err))
+		os.Exit(1)
+	}
+	runtimeConfig.WaveformLayout = waveformLayout
+
+	colormap, err := config.ParseColormap(cmd.Colormap)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --colormap: %v", err))
+		os.Exit(1)
+	}
+	runtimeConfig.Colormap = colormap
+
+	// Load the script once up front so syntax errors surface before Pass 1
+	// rather than after it; the render loads its own copy.
+	if cmd.Script != "" {
+		s, err := script.Load(cmd.Script, renderer.LoadFont)
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --script: %v", err))
+			os.Exit(1)
+		}
+		s.Close()
+		runtimeConfig.ScriptPath = cmd.Script
+	}
+
+	if cmd.Thumbnails < 0 || cmd.Thumbnails > maxThumbnailVariants {
+		cli.PrintError(fmt.Sprintf("invalid --thumbnails: %d (must be between 0 and %d)", cmd.Thumbnails, maxThumbnailVariants))
+		os.Exit(1)
+	}
+
+	previewProtocol, err := ui.ParseGraphicsProtocol(cmd.PreviewProtocol)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --preview-protocol: %v", err))
+		os.Exit(1)
+	}
+	if previewProtocol == ui.GraphicsAuto {
+		previewProtocol = ui.DetectGraphicsProtocol(os.Getenv)
+	}
+	if cmd.PreviewFPS < 0 {
+		cli.PrintError(fmt.Sprintf("invalid --preview-fps: %g (must not be negative)", cmd.PreviewFPS))
+		os.Exit(1)
+	}
+	var previewSize ui.PreviewConfig
+	if cmd.PreviewSize != "" {
+		if previewSize, err = ui.ParsePreviewSize(cmd.PreviewSize); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --preview-size: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	if cmd.PreviewWindow {
+		if err := window.Available(); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --preview-window: %v", err))
+			os.Exit(1)
+		}
+	}
+	if cmd.Play {
+		if err := playback.Available(); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --play: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	// The guides are drawn on the previews alone, for a 16:9 frame.
+	if cmd.Guides {
+		switch {
+		case runtimeConfig.ClipShape != "":
+			cli.PrintError("invalid --guides: the safe areas are for 16:9 video, not a clip")
+			os.Exit(1)
+		case cmd.NoPreview && !cmd.PreviewWindow:
+			cli.PrintError("invalid --guides: there is no preview to draw them on; drop --no-preview or add --preview-window")
+			os.Exit(1)
+		}
+		runtimeConfig.Guides = true
+	}
+
+	var chapterList []chapters.Chapter
+	if cmd.Chapters != "" {
+		chapterList, err = chapters.Load(cmd.Chapters)
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --chapters: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	if start > 0 || length > 0 {
+		var end time.Duration
+		if length > 0 {
+			end = start + length
+		}
+		chapterList = chapters.Clip(chapterList, start, end)
+	}
+	if cmd.Speed != 1 {
+		chapterList = chapters.Scale(chapterList, cmd.Speed)
+	}
+
+	meta := renderer.PodcastMeta{Title: cmd.Title, Episode: cmd.Episode}
+
+	analysisUse := analysisIO{write: analysing}
+	if cmd.Analysis != "" {
+		if analysisUse.from, err = analysis.Read(cmd.Analysis); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --analysis: %v", err))
+			os.Exit(1)
+		}
+	}
+	if len(split.join) > 0 {
+		if err := checkJoin(split.join); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --join: %v", err))
+			os.Exit(failure.ExitCode(err))
+		}
+	}
+
+	var progress *metrics.Render
+	if cmd.MetricsAddr != "" {
+		progress = metrics.New(version, config.FPS)
+		if err := metrics.Serve(cmd.MetricsAddr, progress); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --metrics-addr: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	// Generate video using 2-pass streaming approach
+	destName, destFlag := cmd.Output, "<output>"
+	if analysing {
+		destName, destFlag = cmd.analysisOut, "--out"
+	}
+	dest, err := output.Open(destName)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid %s: %v", destFlag, err))
+		os.Exit(1)
+	}
+
+	generateVideo(cmd.Input, dest, renderOptions{
+		analysisUse:       analysisUse,
+		split:             split,
+		format:            cmd.Format,
+		segmentLength:     cmd.SegmentLength,
+		channels:          cmd.Channels,
+		surround:          cmd.Surround,
+		noPreview:         cmd.NoPreview,
+		loc:               loc,
+		previewProtocol:   previewProtocol,
+		previewSize:       previewSize,
+		previewFPS:        cmd.PreviewFPS,
+		previewWindow:     cmd.PreviewWindow,
+		play:              cmd.Play,
+		frequencyAxis:     cmd.FrequencyAxis,
+		reportPath:        cmd.Report,
+		hooks:             hooks,
+		progress:          progress,
+		frames:            frameSeq,
+		audioOut:          cmd.AudioOut,
+		featuresOut:       cmd.ExportFeatures,
+		hwAccel:           hwAccelType,
+		hwDevice:          cmd.HWDevice,
+		videoCodec:        videoCodec,
+		audioCodec:        audioCodec,
+		colorSpace:        colorSpace,
+		colorRange:        colorRange,
+		profile:           encodeProfile,
+		encoderOpts:       encoderOpts,
+		gop:               cmd.GOP,
+		bFrames:           bFrames,
+		encodeRetries:     cmd.EncodeRetries,
+		encodeQueue:       cmd.EncodeQueue,
+		keyframeInterval:  cmd.KeyframeInterval,
+		start:             start,
+		length:            length,
+		speed:             cmd.Speed,
+		memGuard:          memlimit.New(maxMemory),
+		runtimeConfig:     runtimeConfig,
+		meta:              meta,
+		chapters:          chapterList,
+		tags:              containerTags(&cmd.textFlags),
+		archive:           cmd.Archive,
+		writeDescription:  cmd.WriteDescription,
+		writeThumbnail:    !cmd.NoThumbnail && !streaming && !cmd.FramesOnly,
+		thumbnailVariants: cmd.Thumbnails,
+	})
+}
+
+// framesConfig is the --frames-dir image sequence requested for a render;
+// dir is empty when none was.
+type framesConfig struct {
+	dir    string
+	format frames.Format
+	only   bool // skip the video encoder entirely
+	audio  bool // also write the audio as WAV
+}
+
+// parseFramesFlags validates the --frames-* flags, exiting on flags that
+// need --frames-dir or conflict with --frames-only.
+func parseFramesFlags(cmd *renderCmd) framesConfig {
+	format, err := frames.ParseFormat(cmd.FramesFormat)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --frames-format: %v", err))
+		os.Exit(1)
+	}
+	if cmd.FramesDir == "" {
+		if cmd.FramesOnly || cmd.FramesAudio {
+			cli.PrintError("--frames-only and --frames-audio need --frames-dir")
+			os.Exit(1)
+		}
+		return framesConfig{}
+	}
+
+	// Without a video there is nothing for the output name, sidecars or
+	// container options to apply to.
+	if cmd.FramesOnly && (cmd.Output != "" || cmd.WriteDescription || cmd.Thumbnails > 0 || cmd.Format != "" || cmd.Chapters != "") {
+		cli.PrintError("--frames-only writes no video; drop <output>, --output-template, --format, --chapters, --write-description and --thumbnails")
+		os.Exit(1)
+	}
+	return framesConfig{dir: cmd.FramesDir, format: format, only: cmd.FramesOnly, audio: cmd.FramesAudio}
+}
+
+// applyBandFlags validates --bands and --band-colors into runtimeConfig.
+// The bars are assigned to bands once the sample rate is known.
+func applyBandFlags(cmd *renderCmd, runtimeConfig *config.RuntimeConfig) {
+	if cmd.Bands == "" {
+		if cmd.BandColors != "" {
+			cli.PrintError("--band-colors needs --bands")
+			os.Exit(1)
+		}
+		return
+	}
+	crossovers, err := config.ParseCrossovers(cmd.Bands)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --bands: %v", err))
+		os.Exit(1)
+	}
+	runtimeConfig.Crossovers = crossovers
+	if cmd.BandColors == "" {
+		return
+	}
+	colours := strings.Split(cmd.BandColors, ",")
+	if len(colours) > len(crossovers)+1 {
+		cli.PrintError(fmt.Sprintf("invalid --band-colors: %d colours for %d bands", len(colours), len(crossovers)+1))
+		os.Exit(1)
+	}
+	for _, colour := range colours {
+		r, g, b, err := config.ParseHexColor(strings.TrimSpace(colour))
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --band-colors: %v", err))
+			os.Exit(1)
+		}
+		runtimeConfig.BandColors = append(runtimeConfig.BandColors, config.OptionalColor{R: r, G: g, B: b, Set: true})
+	}
+}
+
+// applyColorModeFlags validates --bar-color-mode, --reactive-colors and
+// --centroid-glow into runtimeConfig.
+func applyColorModeFlags(cmd *renderCmd, runtimeConfig *config.RuntimeConfig) {
+	glow, err := config.ParseCentroidGlow(cmd.CentroidGlow)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --centroid-glow: %v", err))
+		os.Exit(1)
+	}
+	if glow == config.GlowBars && cmd.Visualizer != renderer.DefaultVisualizer {
+		cli.PrintError(fmt.Sprintf("--centroid-glow=bars dims the bars; --visualizer=%s has none, so try --centroid-glow=background", cmd.Visualizer))
+		os.Exit(1)
+	}
+	runtimeConfig.CentroidGlow = glow
+
+	mode, err := config.ParseBarColorMode(cmd.BarColorMode)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --bar-color-mode: %v", err))
+		os.Exit(1)
+	}
+	runtimeConfig.BarColorMode = mode
+	if mode != config.ColorReactive {
+		return
+	}
+	if len(runtimeConfig.Crossovers) > 0 {
+		cli.PrintError("--bar-color-mode=reactive colours every bar alike; drop --bands")
+		os.Exit(1)
+	}
+	colours := strings.Split(cmd.ReactiveColors, ",")
+	if len(colours) != 2 {
+		cli.PrintError(fmt.Sprintf("invalid --reactive-colors: %q (give two colours, bass-heavy first)", cmd.ReactiveColors))
+		os.Exit(1)
+	}
+	bounds := make([]config.OptionalColor, 2)
+	for i, colour := range colours {
+		r, g, b, err := config.ParseHexColor(strings.TrimSpace(colour))
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --reactive-colors: %v", err))
+			os.Exit(1)
+		}
+		bounds[i] = config.OptionalColor{R: r, G: g, B: b, Set: true}
+	}
+	runtimeConfig.ReactiveLow, runtimeConfig.ReactiveHigh = bounds[0], bounds[1]
+}
+
+// checkAudioCodec validates --audio-codec against the video output. Opus
+// goes in Matroska, or in WebM with AV1, its one video codec of Jivefire's;
+// FLAC goes in Matroska alone. The --format containers and stdout stay AAC.
+func checkAudioCodec(codec encoder.AudioCodec, outputFile, format string, videoCodec encoder.VideoCodec) error {
+	if codec == encoder.AudioAAC {
+		return nil
+	}
+	outputs := ".mkv"
+	if codec == encoder.AudioOpus {
+		outputs = ".mkv or .webm"
+	}
+	if format != "" {
+		return fmt.Errorf("%s cannot be combined with --format; write an %s file", codec, outputs)
+	}
+	if outputFile == encoder.StdoutPath {
+		return fmt.Errorf("%s needs an %s output file, not stdout", codec, outputs)
+	}
+	switch ext := strings.ToLower(filepath.Ext(outputFile)); {
+	case ext == ".mkv":
+	case ext == ".webm" && codec == encoder.AudioOpus:
+		if videoCodec != encoder.CodecAV1 {
+			return fmt.Errorf("%s: WebM needs --video-codec=av1, as it does not take H.264", outputFile)
 		}
-		cli.PrintHardwareProbe(infos)
-		os.Exit(0)
+	default:
+		return fmt.Errorf("%s: %s needs an %s output", outputFile, codec, outputs)
 	}
+	return nil
+}
 
-	// No arguments: show usage instead of erroring
-	if CLI.Input == "" && CLI.Output == "" {
-		_ = ctx.PrintUsage(true)
-		os.Exit(0)
+// checkAudioOut validates an --audio-out path against the video output.
+// The audio-only encode is AAC, so the path must name an M4A or ADTS file.
+func checkAudioOut(path, videoOutput string) error {
+	if path == "" {
+		return nil
 	}
+	if output.IsRemote(path) {
+		return fmt.Errorf("%s: must be a local file", path)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m4a", ".aac":
+	default:
+		return fmt.Errorf("%s: must end in .m4a or .aac", path)
+	}
+	if filepath.Clean(path) == filepath.Clean(videoOutput) {
+		return fmt.Errorf("%s is also the video output", path)
+	}
+	return nil
+}
 
-	if CLI.Input == "" || CLI.Output == "" {
-		cli.PrintError("<input> and <output> are required")
-		os.Exit(1)
+// checkScale validates --scale, a bar scale to use in place of Pass 1's; 0
+// keeps Pass 1's.
+func checkScale(scale float64) error {
+	if scale < 0 || math.IsNaN(scale) || math.IsInf(scale, 0) {
+		return fmt.Errorf("%g (must be a number, not negative)", scale)
 	}
+	return nil
+}
 
-	if _, err := os.Stat(CLI.Input); os.IsNotExist(err) {
-		cli.PrintError(fmt.Sprintf("input file does not exist: %s", CLI.Input))
-		os.Exit(1)
+// barScale returns the scale the bars are binned with: --scale when given,
+// for manual control, or else the calibration Pass 1 derived.
+func barScale(profile *audio.Profile, scale float64) float64 {
+	if scale > 0 {
+		return scale
 	}
+	return profile.OptimalBaseScale
+}
 
-	if CLI.Channels != 1 && CLI.Channels != 2 {
-		cli.PrintError(fmt.Sprintf("invalid channels value: %d (must be 1 or 2)", CLI.Channels))
-		os.Exit(1)
+// segmentedFormat reports whether the output is an HLS or DASH manifest, which
+// writes its segments to further files beside it.
+func segmentedFormat(outputFile, format string) bool {
+	switch format {
+	case "hls", "dash":
+		return true
+	case "":
+		ext := strings.ToLower(filepath.Ext(outputFile))
+		return ext == ".m3u8" || ext == ".mpd"
 	}
+	return false
+}
 
-	validEncoders := map[string]encoder.HWAccelType{
-		"auto":     encoder.HWAccelAuto,
-		"nvenc":    encoder.HWAccelNVENC,
-		"qsv":      encoder.HWAccelQSV,
-		"vaapi":    encoder.HWAccelVAAPI,
-		"vulkan":   encoder.HWAccelVulkan,
-		"software": encoder.HWAccelNone,
+// parseGOP checks --gop against the HLS or DASH segment length, which
+// needs a keyframe to start each segment on, and reads --bframes: nil for
+// auto, or a count that fits in the GOP. The encoder checks the count
+// against its own limit once it is chosen.
+func parseGOP(gop int, bFrames, outputFile, format string, segmentLength int) (*int, error) {
+	if gop < 0 {
+		return nil, fmt.Errorf("invalid --gop: %d (must be 0 or more)", gop)
 	}
-	hwAccelType, ok := validEncoders[CLI.Encoder]
-	if !ok {
-		cli.PrintError(fmt.Sprintf("invalid --encoder value: %s (must be auto, nvenc, qsv, vaapi, vulkan, or software)", CLI.Encoder))
-		os.Exit(1)
+	if segmentedFormat(outputFile, format) && gop > segmentLength*config.FPS {
+		return nil, fmt.Errorf("invalid --gop: %d frames is longer than the %d-second --segment-length, and each segment starts on a keyframe", gop, segmentLength)
 	}
-
-	// If user explicitly requested a specific hardware encoder, verify it's available
-	if hwAccelType != encoder.HWAccelAuto && hwAccelType != encoder.HWAccelNone {
-		encoders := encoder.DetectHWEncoders()
-		selectedEncoder := encoder.SelectBestEncoderFrom(encoders, hwAccelType)
-		if selectedEncoder == nil {
-			// Requested encoder not available - list what IS available
-			var available []string
-			for _, enc := range encoders {
-				if enc.Available {
-					available = append(available, string(enc.Type))
-				}
-			}
-			if len(available) > 0 {
-				cli.PrintError(fmt.Sprintf("requested encoder '%s' is not available. Available hardware encoders: %s",
-					CLI.Encoder, strings.Join(available, ", ")))
-			} else {
-				cli.PrintError(fmt.Sprintf("requested encoder '%s' is not available. No hardware encoders detected; use --encoder=software",
-					CLI.Encoder))
-			}
-			os.Exit(1)
-		}
+	if bFrames == "auto" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(bFrames)
+	if err != nil || n < 0 || n > encoder.MaxBFrames {
+		return nil, fmt.Errorf("invalid --bframes: %s (must be auto or 0 to %d)", bFrames, encoder.MaxBFrames)
+	}
+	frames := gop
+	if frames == 0 {
+		frames = 2 * config.FPS
+	}
+	if n >= frames {
+		return nil, fmt.Errorf("invalid --bframes: %d B-frames in a row do not fit in a GOP of %d frames", n, frames)
 	}
+	return &n, nil
+}
 
-	runtimeConfig := &config.RuntimeConfig{}
+// parseDecibels reads a level in dB, with or without the unit: -18dB or -18.
+func parseDecibels(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 2 && strings.EqualFold(s[len(s)-2:], "db") {
+		s = strings.TrimSpace(s[:len(s)-2])
+	}
+	return strconv.ParseFloat(s, 64)
+}
 
-	if CLI.BarColor != "" {
-		r, g, b, err := config.ParseHexColor(CLI.BarColor)
-		if err != nil {
-			cli.PrintError(fmt.Sprintf("invalid --bar-color: %v", err))
-			os.Exit(1)
+// parseSection reads --start, --end and --duration into the render's start
+// and length within the audio; a zero length runs to the end.
+func parseSection(startFlag, endFlag, durationFlag string) (start, length time.Duration, err error) {
+	if startFlag != "" {
+		if start, err = chapters.ParseTimestamp(startFlag); err != nil {
+			return 0, 0, fmt.Errorf("invalid --start: %w", err)
 		}
-		runtimeConfig.BarColor = config.OptionalColor{R: r, G: g, B: b, Set: true}
 	}
-
-	if CLI.TextColor != "" {
-		r, g, b, err := config.ParseHexColor(CLI.TextColor)
+	if endFlag != "" && durationFlag != "" {
+		return 0, 0, fmt.Errorf("--end and --duration both set the length; use one")
+	}
+	if endFlag != "" {
+		end, err := chapters.ParseTimestamp(endFlag)
 		if err != nil {
-			cli.PrintError(fmt.Sprintf("invalid --text-color: %v", err))
-			os.Exit(1)
+			return 0, 0, fmt.Errorf("invalid --end: %w", err)
 		}
-		runtimeConfig.TextColor = config.OptionalColor{R: r, G: g, B: b, Set: true}
-	}
-
-	if CLI.BackgroundImage != "" {
-		if _, err := os.Stat(CLI.BackgroundImage); os.IsNotExist(err) {
-			cli.PrintError(fmt.Sprintf("background image does not exist: %s", CLI.BackgroundImage))
-			os.Exit(1)
+		if end <= start {
+			return 0, 0, fmt.Errorf("invalid --end: %s is not after --start %s", chapters.FormatTimestamp(end), chapters.FormatTimestamp(start))
 		}
-		runtimeConfig.BackgroundImagePath = CLI.BackgroundImage
+		length = end - start
 	}
-
-	if CLI.ThumbnailImage != "" {
-		if _, err := os.Stat(CLI.ThumbnailImage); os.IsNotExist(err) {
-			cli.PrintError(fmt.Sprintf("thumbnail image does not exist: %s", CLI.ThumbnailImage))
-			os.Exit(1)
+	if durationFlag != "" {
+		length, err = time.ParseDuration(durationFlag)
+		if err != nil || length <= 0 {
+			return 0, 0, fmt.Errorf("invalid --duration: %q (use a positive length such as 45m or 1h2m30s)", durationFlag)
 		}
-		runtimeConfig.ThumbnailImagePath = CLI.ThumbnailImage
 	}
+	return start, length, nil
+}
 
-	inputFile := CLI.Input
-	outputFile := CLI.Output
-	channels := CLI.Channels
-	noPreview := CLI.NoPreview
+// outputChannels resolves --channels against the source: an explicit count
+// is used as given; 0 matches the source, downmixing anything beyond stereo
+// unless surround is "passthrough", which keeps it as 5.1. The
+// time-stretched audio of --speed is mono, so the match stops at stereo.
+func outputChannels(flag, source int, surround string, speed float64) int {
+	if flag != 0 {
+		return flag
+	}
+	switch {
+	case source <= 1:
+		return 1
+	case source == 2 || speed != 1 || surround != "passthrough":
+		return 2
+	}
+	return 6
+}
 
-	meta := renderer.PodcastMeta{Title: CLI.Title, Episode: CLI.Episode}
+// sidecarPath returns the path for a file written alongside the video, such
+// as the thumbnail, by swapping the output extension for ext.
+func sidecarPath(outputFile, ext string) string {
+	return strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + ext
+}
 
-	// Generate video using 2-pass streaming approach
-	generateVideo(inputFile, outputFile, channels, noPreview, hwAccelType, runtimeConfig, meta)
+// chapterKeyframes returns the encoder's frames at which the chapters
+// start, for encoder.Config.Keyframes, so a chapter seek lands on a
+// keyframe. Frame first of the episode is the encoder's first, as a
+// --segments worker's is; the chapters before it are dropped.
+func chapterKeyframes(list []chapters.Chapter, first int) []int64 {
+	var frames []int64
+	for _, c := range list {
+		frame := int64(math.Round(c.Start.Seconds()*config.FPS)) - int64(first)
+		if frame >= 0 && (len(frames) == 0 || frame > frames[len(frames)-1]) {
+			frames = append(frames, frame)
+		}
+	}
+	return frames
 }
 
-func generateVideo(inputFile string, outputFile string, channels int, noPreview bool, hwAccel encoder.HWAccelType, runtimeConfig *config.RuntimeConfig, meta renderer.PodcastMeta) {
+// generateVideo renders inputFile to dest in two passes, or runs only Pass 1
+// for jivefire analyze, exiting the process on failure.
+func generateVideo(inputFile string, dest output.Destination, opts renderOptions) {
 	overallStartTime := time.Now()
+	outputFile := dest.Path()
+
+	// endReport is the run report of a render that did not complete, for the
+	// --notify-url and --notify-cmd hooks.
+	endReport := func(status, message string) report.Report {
+		return report.Report{
+			Version:   version,
+			Generated: time.Now().UTC(),
+			Status:    status,
+			Error:     message,
+			Host:      report.CurrentHost(),
+			Input:     inputFile,
+			Output:    dest.String(),
+			Timings:   report.Timings{Total: time.Since(overallStartTime).Seconds()},
+		}
+	}
+	// A failing hook is only a warning: the render's own outcome stands.
+	notifyEnd := func(r report.Report) {
+		if err := opts.hooks.Fire(r); err != nil {
+			cli.PrintWarning(err.Error())
+		}
+	}
+	// A render that does not finish leaves nothing worth uploading. The
+	// exit code follows any failure kind the error wraps.
+	fail := func(format string, args ...any) {
+		err := fmt.Errorf(format, args...)
+		cli.PrintError(err.Error())
+		notifyEnd(endReport(report.StatusFailed, err.Error()))
+		_ = dest.Discard()
+		os.Exit(failure.ExitCode(err))
+	}
 
-	thumbnailPath := strings.Replace(outputFile, ".mp4", ".png", 1)
-	thumbnailStartTime := time.Now()
-	if err := renderer.GenerateThumbnail(thumbnailPath, meta, runtimeConfig); err != nil {
-		cli.PrintError(fmt.Sprintf("failed to generate thumbnail: %v", err))
-		os.Exit(1)
+	// When the video streams to stdout the report, UI and summary move to
+	// stderr so they do not corrupt it.
+	streaming := outputFile == encoder.StdoutPath
+	uiOutput := io.Writer(os.Stdout)
+	if streaming {
+		uiOutput = os.Stderr
+	}
+	// A --segments worker's stdout carries its progress to the coordinator,
+	// and a render that joins segments draws no frames to preview.
+	if opts.split.worker {
+		uiOutput = os.Stderr
+	}
+	if opts.split.workers > 0 || len(opts.split.join) > 0 {
+		opts.noPreview = true
 	}
-	thumbnailDuration := time.Since(thumbnailStartTime)
 
-	// Get audio metadata upfront for Pass 1 progress estimation
-	metadata, err := audio.GetMetadata(inputFile)
+	// Get audio metadata upfront for the pre-flight report and Pass 1 progress estimation
+	metadata, err := inputMetadata(inputFile, opts.runtimeConfig)
 	if err != nil {
-		cli.PrintError(fmt.Sprintf("reading audio metadata: %v", err))
-		os.Exit(1)
+		fail("reading audio metadata: %w", err)
+	}
+
+	// The render covers length from start (--start, --end, --duration); a
+	// length stands in for the reported one, which container headers (VBR
+	// MP3s without a Xing header especially) can get wrong.
+	if opts.start > 0 && metadata.Duration > 0 && opts.start >= metadata.Duration {
+		fail("--start %s is beyond the end of the audio (%s)", chapters.FormatTimestamp(opts.start), chapters.FormatTimestamp(metadata.Duration))
+	}
+	duration := max(metadata.Duration-opts.start, 0)
+	if opts.length > 0 {
+		duration = opts.length
+	}
+	// --intro and --outro lengthen the render, and the intro moves the
+	// chapters later.
+	introLength, err := bumperLength(opts.runtimeConfig.IntroPath, opts.runtimeConfig.Crossfade)
+	if err != nil {
+		fail("reading --intro: %w", err)
+	}
+	outroLength, err := bumperLength(opts.runtimeConfig.OutroPath, opts.runtimeConfig.Crossfade)
+	if err != nil {
+		fail("reading --outro: %w", err)
+	}
+	duration += introLength + outroLength
+	if introLength > 0 {
+		opts.chapters = chapters.Shift(opts.chapters, time.Duration(float64(introLength)/opts.speed))
+	}
+	// At --speed the video, and the audio in it, plays the section faster.
+	videoDuration := time.Duration(float64(duration) / opts.speed)
+
+	opts.channels = outputChannels(opts.channels, metadata.Channels, opts.surround, opts.speed)
+
+	// Pre-flight: report the input and refuse to start a render that cannot
+	// fit on the destination filesystem.
+	// An image sequence has no useful size estimate, so a frames-only render
+	// skips the space check.
+	estimatedSize := preflight.EstimateOutputSize(videoDuration)
+	inputReport := cli.InputReport{
+		Path:       inputFile,
+		Codec:      metadata.Codec,
+		SampleRate: metadata.SampleRate,
+		Channels:   metadata.Channels,
+		BitDepth:   metadata.BitDepth,
+		Duration:   metadata.Duration,
+	}
+	if !opts.frames.only && !opts.analysisUse.write {
+		inputReport.EstimatedSize = preflight.FormatBytes(estimatedSize)
+	}
+	var spaceErr error
+	if !streaming && !opts.frames.only && !opts.analysisUse.write {
+		// With --segments the segments sit beside the output until joined.
+		needed := estimatedSize
+		if opts.split.workers > 0 {
+			needed *= 2
+		}
+		var free uint64
+		free, spaceErr = preflight.CheckSpace(outputFile, needed)
+		inputReport.FreeSpace = preflight.FormatBytes(int64(free)) //nolint:gosec // free space is far below MaxInt64
+	}
+	if !opts.split.worker {
+		cli.PrintInputReport(uiOutput, inputReport)
+	}
+	if spaceErr != nil {
+		fail("%w", spaceErr)
 	}
 
 	// Calculate estimated total frames for Pass 1 progress.
@@ -199,35 +1596,146 @@ func generateVideo(inputFile string, outputFile string, channels int, noPreview
 	// of audio regardless of input rate.
 	samplesPerFrame := metadata.SampleRate / config.FPS
 	if samplesPerFrame <= 0 {
-		cli.PrintError(fmt.Sprintf("input sample rate too low for %d FPS: %d Hz", config.FPS, metadata.SampleRate))
-		os.Exit(1)
+		fail("input sample rate too low for %d FPS: %d Hz", config.FPS, metadata.SampleRate)
+	}
+	span := audio.Span{Start: opts.start, Speed: opts.speed, Channels: opts.channels}
+	// The bar frequencies depend on the sample rate, so the range is checked
+	// here rather than with the other flags.
+	freq := audio.FreqRange{Min: opts.runtimeConfig.FreqMin, Max: opts.runtimeConfig.FreqMax}
+	bands, err := audio.NewBands(metadata.SampleRate, freq)
+	if err != nil {
+		fail("invalid --freq-min/--freq-max: %w", err)
+	}
+	if len(opts.runtimeConfig.Crossovers) > 0 {
+		opts.runtimeConfig.BarBands = bands.Split(opts.runtimeConfig.Crossovers)
+	}
+	vis := audio.VisFilter{HighPass: opts.runtimeConfig.VisHighPass, LowPass: opts.runtimeConfig.VisLowPass, Declip: opts.runtimeConfig.Declip}
+	if _, err := audio.NewFilter(metadata.SampleRate, vis); err != nil {
+		fail("invalid --vis-highpass/--vis-lowpass: %w", err)
+	}
+	estimatedTotalFrames := max(int(metadata.NumSamples)-int(opts.start.Seconds()*float64(metadata.SampleRate)), 0) / span.Step(samplesPerFrame)
+	estimatedTotalFrames += int((introLength + outroLength).Seconds() / opts.speed * config.FPS)
+	if opts.length > 0 {
+		estimatedTotalFrames = int(math.Ceil(videoDuration.Seconds() * config.FPS))
+		// With bumpers the Stitcher ends the episode at length instead, so
+		// the outro still plays.
+		if !hasBumpers(opts.runtimeConfig) {
+			span.Frames = estimatedTotalFrames
+		}
+	}
+
+	// A saved analysis only holds for the audio and settings it was made
+	// with; rendering from another would scale the bars and level the audio
+	// for a different episode.
+	analysedInput, err := analysisInput(inputFile, metadata)
+	if err != nil {
+		fail("reading input: %w", err)
+	}
+	analysedSettings := analysisSettings(span, opts.length.Seconds(), opts.runtimeConfig)
+	if from := opts.analysisUse.from; from != nil {
+		if err := from.Check(analysedInput, analysedSettings); err != nil {
+			fail("--analysis: %w", err)
+		}
+		if from.Version != version {
+			cli.PrintWarning(fmt.Sprintf("--analysis was made by jivefire %s, this is %s; the bars may scale differently", from.Version, version))
+		}
+	}
+
+	// A segment renders its share of the analysed frames, video alone and
+	// tagged for --join. It starts drawing a warm-up earlier, unencoded, so
+	// the bar smoothing carries into it much as it would in a whole render.
+	part := renderPart{join: opts.split.join}
+	if opts.split.count > 0 {
+		first, end := segmentBounds(opts.analysisUse.from.Profile.Frames, opts.split.index, opts.split.count)
+		warmup := min(first, int(segmentWarmup.Seconds()*config.FPS))
+		step := float64(span.Step(samplesPerFrame)) / float64(metadata.SampleRate)
+		span.Start += time.Duration(float64(first-warmup) * step * float64(time.Second))
+		span.Frames = warmup + end - first
+		part = renderPart{first: first - warmup, warmup: warmup, total: opts.analysisUse.from.Profile.Frames, videoOnly: true}
+		opts.tags = []encoder.Tag{{Key: segmentTagKey, Value: segmentTag(opts.split.index, opts.split.count)}}
+		opts.chapters = nil
+	}
+
+	var thumbnailDuration time.Duration
+	if opts.writeThumbnail {
+		thumbnailPath := sidecarPath(outputFile, ".png")
+		thumbnailStartTime := time.Now()
+		if err := renderer.GenerateThumbnail(thumbnailPath, opts.meta, opts.runtimeConfig); err != nil {
+			fail("failed to generate thumbnail: %w", err)
+		}
+		thumbnailDuration = time.Since(thumbnailStartTime)
 	}
-	estimatedTotalFrames := int(metadata.NumSamples) / samplesPerFrame
 
 	// The alternate screen buffer (set via View().AltScreen) prevents ghost box
 	// edges when the view height changes between passes.
-	model := ui.NewModel(noPreview)
-	p := tea.NewProgram(model)
+	model := ui.NewModel(opts.noPreview)
+	model.SetLocale(opts.loc)
+	model.SetPreviewProtocol(opts.previewProtocol)
+	model.SetPreviewRate(opts.previewFPS)
+	model.SetPreviewSize(opts.previewSize)
+	if opts.frequencyAxis {
+		// Label the bars in the centre-out order the spectrum displays them.
+		low, high := bands.Frequencies()
+		displayLow := make([]float64, config.NumBars)
+		displayHigh := make([]float64, config.NumBars)
+		audio.RearrangeFrequenciesCenterOut(low, displayLow)
+		audio.RearrangeFrequenciesCenterOut(high, displayHigh)
+		model.SetFrequencyAxis(displayLow, displayHigh)
+	}
+	p := tea.NewProgram(model, tea.WithOutput(uiOutput), tea.WithFilter(metricsFilter(opts.progress)))
+	if opts.split.worker {
+		// The model still provides the controls the coordinator drives.
+		p = tea.NewProgram(newWorkerModel(os.Stdout, part.warmup), tea.WithInput(nil), tea.WithOutput(io.Discard))
+		go followCoordinator(os.Stdin, model.Controls())
+	}
 
 	// Shared state between goroutines
 	var profile *audio.Profile
-	var analysisErr error
+	var analysisErr, renderErr error
+	var analysisSaved bool
 
 	// Run both passes in a single goroutine
 	go func() {
 		// === PASS 1: Analysis ===
 		pass1StartTime := time.Now()
 
-		profile, analysisErr = audio.AnalyzeAudio(inputFile, func(frame int, currentRMS, currentPeak float64, barHeights []float64, duration time.Duration) {
-			p.Send(ui.AnalysisProgress{
-				Frame:       frame,
-				TotalFrames: estimatedTotalFrames,
-				CurrentRMS:  currentRMS,
-				CurrentPeak: currentPeak,
-				BarHeights:  barHeights,
-				Duration:    duration,
+		// One decoder serves both passes: Pass 1 reads the audio through,
+		// then it is rewound for Pass 2 rather than opened and probed again.
+		openInput := func() (audio.Source, error) {
+			return openAudio(inputFile, span.Start, opts.length, opts.runtimeConfig)
+		}
+		reader, err := openInput()
+		if err != nil {
+			analysisErr = fmt.Errorf("failed to open audio: %w", err)
+			p.Quit()
+			return
+		}
+		defer func() {
+			if reader != nil {
+				_ = reader.Close()
+			}
+		}()
+
+		if opts.analysisUse.from != nil {
+			profile = loadProfile(opts.analysisUse.from.Profile)
+			if opts.split.count > 0 {
+				profile.NumFrames = span.Frames
+			}
+		} else {
+			profile, analysisErr = audio.AnalyzeReader(reader, span, freq, vis, opts.memGuard, func(frame int, levels audio.FrameAnalysis, barHeights []float64, duration time.Duration) {
+				// The estimate comes from the reported length, which a stream
+				// can outrun; never let progress pass 100%.
+				p.Send(ui.AnalysisProgress{
+					Frame:           frame,
+					TotalFrames:     max(estimatedTotalFrames, frame),
+					CurrentRMS:      levels.RMSLevel,
+					CurrentPeak:     levels.SamplePeak,
+					CurrentTruePeak: levels.TruePeak,
+					BarHeights:      barHeights,
+					Duration:        duration,
+				})
 			})
-		})
+		}
 
 		pass1Duration := time.Since(pass1StartTime)
 
@@ -236,34 +1744,101 @@ func generateVideo(inputFile string, outputFile string, channels int, noPreview
 			return
 		}
 
+		savedAnalysis := func() analysis.File {
+			return analysis.File{
+				Version:   version,
+				Generated: time.Now().UTC(),
+				Input:     analysedInput,
+				Settings:  analysedSettings,
+				Profile:   saveProfile(profile),
+			}
+		}
+
+		// jivefire analyze stops here. analysisSaved is read once p.Run()
+		// returns, which p.Quit() synchronises.
+		if opts.analysisUse.write {
+			analysisErr = analysis.Write(outputFile, savedAnalysis())
+			analysisSaved = analysisErr == nil
+			p.Quit()
+			return
+		}
+
 		// Signal Pass 1 complete - this transitions the UI to Pass 2
 		p.Send(ui.AnalysisComplete{
 			PeakMagnitude: profile.GlobalPeak,
 			RMSLevel:      profile.GlobalRMS,
 			DynamicRange:  profile.DynamicRange,
+			TruePeak:      profile.TruePeak,
+			Loudness:      profile.Loudness,
+			DCOffset:      profile.DCOffset,
+			Clipped:       profile.ClippedFraction,
 			Duration:      time.Duration(float64(time.Second) * profile.Duration),
 			OptimalScale:  profile.OptimalBaseScale,
 			AnalysisTime:  pass1Duration,
 		})
 
 		// === PASS 2: Rendering & Encoding ===
-		runPass2(p, profile, pass2Config{
-			inputFile:         inputFile,
+		if opts.analysisUse.from == nil {
+			// On failure rewindReader has already closed reader and returns
+			// nil, which the deferred Close skips.
+			reader, err = rewindReader(reader, span.Start, openInput)
+		}
+		if err != nil {
+			cli.PrintError(fmt.Sprintf("opening audio stream: %v", err))
+			renderErr = err
+			p.Quit()
+			return
+		}
+		var attachments []encoder.Attachment
+		if opts.archive {
+			attachments, err = archiveAttachments(inputFile, savedAnalysis())
+			if err != nil {
+				cli.PrintError(err.Error())
+				renderErr = err
+				p.Quit()
+				return
+			}
+		}
+		cfg := pass2Config{
+			renderOptions:     opts,
+			reader:            reader,
 			outputFile:        outputFile,
-			channels:          channels,
-			noPreview:         noPreview,
-			hwAccel:           hwAccel,
-			runtimeConfig:     runtimeConfig,
-			meta:              meta,
+			controls:          model.Controls(),
+			span:              span,
+			attachments:       attachments,
 			thumbnailDuration: thumbnailDuration,
+			analysisDuration:  pass1Duration,
 			overallStartTime:  overallStartTime,
-		})
+			part:              part,
+		}
+		if opts.split.workers > 0 {
+			renderErr = runSegments(p, profile, savedAnalysis(), cfg, opts.split.workers)
+			return
+		}
+		renderErr = runPass2(p, profile, cfg)
 	}()
 
 	finalModel, err := p.Run()
 	if err != nil {
-		cli.PrintError(fmt.Sprintf("running UI: %v", err))
-		os.Exit(1)
+		fail("running UI: %w", err)
+	}
+
+	if opts.analysisUse.write {
+		if analysisErr != nil {
+			fail("analysing audio: %w", analysisErr)
+		}
+		if !analysisSaved {
+			cli.PrintWarning("analysis cancelled; nothing was saved")
+			_ = dest.Discard()
+			os.Exit(failure.ExitCancelled)
+		}
+		if err := dest.Publish(); err != nil {
+			cli.PrintError(err.Error())
+			os.Exit(1)
+		}
+		fmt.Fprintf(uiOutput, "%s %s\n", cli.KeyStyle.Render("Analysis:"), cli.ValueStyle.Render(dest.String()))
+		fmt.Fprintf(uiOutput, "%s %s\n", cli.KeyStyle.Render("Optimal Scale:"), cli.ValueStyle.Render(fmt.Sprintf("%.3f", profile.OptimalBaseScale)))
+		return
 	}
 
 	// Surface results from the final model now the alt screen is gone. The
@@ -274,29 +1849,333 @@ func generateVideo(inputFile string, outputFile string, channels int, noPreview
 			cli.PrintWarning(w)
 		}
 		if summary := m.CompletionSummary(); summary != "" {
-			fmt.Println(summary)
+			fmt.Fprintln(uiOutput, summary)
+		}
+		if summary := m.CancelSummary(); summary != "" {
+			cli.PrintWarning(summary)
+			notifyEnd(endReport(report.StatusCancelled, summary))
+			_ = dest.Discard()
+			os.Exit(failure.ExitCancelled)
+		}
+		if complete, profile := m.Result(); complete != nil {
+			if opts.audioOut != "" {
+				fmt.Fprintf(uiOutput, "%s %s\n", cli.KeyStyle.Render("Audio:"), cli.ValueStyle.Render(opts.audioOut))
+			}
+			// Upload before reporting, so hooks only hear of outputs they
+			// can fetch. A failed upload keeps the staged files, which the
+			// error names.
+			if output.IsRemote(dest.String()) {
+				fmt.Fprintf(uiOutput, "%s %s\n", cli.KeyStyle.Render("Uploading:"), cli.ValueStyle.Render(dest.String()))
+				if err := dest.Publish(); err != nil {
+					cli.PrintError(err.Error())
+					notifyEnd(endReport(report.StatusFailed, err.Error()))
+					os.Exit(1)
+				}
+			}
+			r := buildReport(inputFile, metadata, estimatedTotalFrames, estimatedSize, complete, profile)
+			r.Output = dest.String()
+			r.Video.Width, r.Video.Height = opts.runtimeConfig.GetVideoSize()
+			if !opts.frames.only {
+				r.Encoder.Profile = string(opts.profile)
+			}
+			if opts.reportPath != "" {
+				if err := report.Write(opts.reportPath, r); err != nil {
+					fail("writing report: %w", err)
+				}
+			}
+			notifyEnd(r)
+			return
 		}
 	}
 
-	// Check for analysis errors (encoding errors handled within runPass2)
+	// Check for analysis errors; runPass2 has already printed its own
 	if analysisErr != nil {
-		cli.PrintError(fmt.Sprintf("analysing audio: %v", analysisErr))
-		os.Exit(1)
+		fail("analysing audio: %w", analysisErr)
+	}
+	if renderErr != nil {
+		notifyEnd(endReport(report.StatusFailed, renderErr.Error()))
+		os.Exit(failure.ExitCode(renderErr))
 	}
 }
 
-// pass2Config groups the encoding and timing parameters for runPass2 so the
-// call site uses named fields and transposed arguments can't compile silently.
-type pass2Config struct {
-	inputFile         string
-	outputFile        string
-	channels          int
+// metricsFilter passes the progress sent to the display on to the
+// --metrics-addr endpoint, when there is one.
+func metricsFilter(progress *metrics.Render) func(tea.Model, tea.Msg) tea.Msg {
+	return func(_ tea.Model, msg tea.Msg) tea.Msg {
+		if progress == nil {
+			return msg
+		}
+		switch msg := msg.(type) {
+		case ui.AnalysisProgress:
+			progress.Analysing(msg.Frame, msg.TotalFrames)
+		case ui.RenderProgress:
+			progress.Rendering(msg.Frame, msg.TotalFrames, msg.Elapsed, msg.FileSize)
+		case ui.RenderComplete:
+			progress.Finished(msg.TotalFrames, msg.FileSize)
+		}
+		return msg
+	}
+}
+
+// buildReport assembles the run report from the pre-flight estimates and the
+// figures the completion summary shows.
+func buildReport(inputFile string, metadata *audio.Metadata, estimatedFrames int, estimatedSize int64, complete *ui.RenderComplete, profile *ui.AudioProfile) report.Report {
+	r := report.Report{
+		Version:   version,
+		Generated: time.Now().UTC(),
+		Status:    report.StatusCompleted,
+		Host:      report.CurrentHost(),
+		Input:     inputFile,
+		Output:    complete.OutputFile,
+		Encoder:   report.Encoder{Name: complete.EncoderName, Hardware: complete.EncoderIsHW, Retries: complete.EncoderRetries},
+		Video: report.Video{
+			Width:           config.Width,
+			Height:          config.Height,
+			FPS:             config.FPS,
+			Frames:          complete.TotalFrames,
+			EstimatedFrames: estimatedFrames,
+		},
+		Audio: report.Audio{
+			SampleRate:       metadata.SampleRate,
+			Channels:         metadata.Channels,
+			Duration:         metadata.Duration.Seconds(),
+			SamplesProcessed: complete.SamplesProcessed,
+		},
+		Size: report.Size{Estimated: estimatedSize, Actual: complete.FileSize},
+		Timings: report.Timings{
+			Thumbnail:     complete.ThumbnailTime.Seconds(),
+			Visualisation: complete.VisTime.Seconds(),
+			VideoEncoding: complete.EncodeTime.Seconds(),
+			AudioEncoding: complete.AudioTime.Seconds(),
+			Total:         complete.TotalTime.Seconds(),
+		},
+		Speed:        report.NewSpeed(complete.TotalFrames, config.FPS, complete.TotalTime),
+		FrameTimings: report.NewFrameTimings(complete.FrameTimings),
+	}
+
+	var analysisTime time.Duration
+	if profile != nil {
+		analysisTime = profile.AnalysisTime
+		r.Audio.Duration = profile.Duration.Seconds()
+		r.Timings.Analysis = analysisTime.Seconds()
+		r.Profile = report.Profile{
+			PeakLevel:    profile.PeakLevel,
+			RMSLevel:     profile.RMSLevel,
+			DynamicRange: profile.DynamicRange,
+			DCOffset:     profile.DCOffset,
+			Clipped:      profile.Clipped,
+			OptimalScale: profile.OptimalScale,
+		}
+	}
+
+	// Unlike the summary's Runtime row, analysis has its own field here.
+	accounted := analysisTime + complete.ThumbnailTime + complete.VisTime + complete.EncodeTime + complete.AudioTime
+	r.Timings.Other = max(complete.TotalTime-accounted, 0).Seconds()
+	return r
+}
+
+// provenanceTagKey is the tag holding a render's provenance. MP4 keeps only
+// the tags it has iTunes atoms for, so it is the comment, as a segment's is;
+// a segment is never the published file.
+const provenanceTagKey = "comment"
+
+// renderSettings are the options that shape a render's output, hashed for
+// its provenance.
+type renderSettings struct {
+	Runtime    config.RuntimeConfig
+	Span       audio.Span
+	Channels   int
+	Codec      encoder.VideoCodec
+	AudioCodec encoder.AudioCodec
+	Profile    encoder.Profile
+	ColorSpace yuv.ColorSpace
+	ColorRange yuv.ColorRange
+	Options    []encoder.Option
+}
+
+// provenanceTag returns the tag tracing the output of cfg back to this
+// render: the version, a hash of its settings, the encoder and the timings.
+func provenanceTag(cfg *pass2Config, enc report.Encoder, timings report.Timings) (encoder.Tag, error) {
+	settings := renderSettings{
+		Runtime:    *cfg.runtimeConfig,
+		Span:       cfg.span,
+		Channels:   cfg.channels,
+		Codec:      cfg.videoCodec,
+		AudioCodec: cfg.audioCodec,
+		Profile:    cfg.profile,
+		ColorSpace: cfg.colorSpace,
+		ColorRange: cfg.colorRange,
+		Options:    cfg.encoderOpts,
+	}
+	settings.Runtime.Guides = false // Drawn on the previews only
+	hash, err := report.SettingsHash(settings)
+	if err != nil {
+		return encoder.Tag{}, err
+	}
+	value, err := report.Provenance{Version: version, Settings: hash, Encoder: enc, Timings: timings}.Value()
+	if err != nil {
+		return encoder.Tag{}, err
+	}
+	return encoder.Tag{Key: provenanceTagKey, Value: value}, nil
+}
+
+// renderOptions are the settings of a render, gathered from the flags once
+// in runRender and carried into pass2Config, so the call sites use named
+// fields and transposed arguments can't compile silently.
+type renderOptions struct {
+	analysisUse       analysisIO
+	split             splitRender
+	format            string
+	segmentLength     int
+	channels          int    // As asked for; generateVideo resolves it against the input
+	surround          string // --surround: downmix or passthrough
 	noPreview         bool
+	loc               *locale.Locale
+	previewProtocol   ui.GraphicsProtocol
+	previewSize       ui.PreviewConfig
+	previewFPS        float64
+	previewWindow     bool
+	play              bool // --play: sound the audio, paced to real time
+	frequencyAxis     bool
+	reportPath        string // --report path; empty for none
+	hooks             notify.Hooks
+	progress          *metrics.Render // --metrics-addr; nil for none
+	frames            framesConfig
+	audioOut          string // --audio-out path; empty for none
+	featuresOut       string // --export-features path; empty for none
 	hwAccel           encoder.HWAccelType
+	hwDevice          string
+	videoCodec        encoder.VideoCodec
+	audioCodec        encoder.AudioCodec // The video's audio track; --audio-out is always AAC
+	colorSpace        yuv.ColorSpace
+	colorRange        yuv.ColorRange
+	profile           encoder.Profile
+	encoderOpts       []encoder.Option
+	gop               int  // --gop in frames; 0 for two seconds
+	bFrames           *int // --bframes; nil for auto
+	encodeRetries     int  // --encode-retries: transient failures retried per send
+	encodeQueue       int  // --encode-queue: frames drawn ahead of the encoder
+	keyframeInterval  int  // --keyframe-interval in seconds; 0 for none
+	start, length     time.Duration
+	speed             float64
+	memGuard          *memlimit.Guard
 	runtimeConfig     *config.RuntimeConfig
 	meta              renderer.PodcastMeta
+	chapters          []chapters.Chapter
+	tags              []encoder.Tag
+	archive           bool
+	writeDescription  bool
+	writeThumbnail    bool
+	thumbnailVariants int
+}
+
+// pass2Config adds what Pass 1 leaves for runPass2 to the render's options.
+type pass2Config struct {
+	renderOptions
+	reader            audio.Source // Rewound to span.Start after Pass 1; the caller closes it
+	outputFile        string
+	controls          *ui.Controls
+	span              audio.Span
+	attachments       []encoder.Attachment // --archive: the source audio and its analysis
 	thumbnailDuration time.Duration
+	analysisDuration  time.Duration
 	overallStartTime  time.Time
+	part              renderPart // A split render's segment or join
+}
+
+// outputGain returns the linear gain applied to the encoded audio for
+// --gain (gainDB) or --normalize (target LUFS, 0 when not normalising),
+// given that audio's measured loudness and true peak. Normalising stops
+// short of raising the true peak past config.NormalizeCeiling; the warning
+// says when the target could not be met, or when --gain will clip.
+func outputGain(gainDB, target, loudness, peak float64) (float64, string) {
+	peakDB := math.Inf(-1)
+	if peak > 0 {
+		peakDB = 20 * math.Log10(peak)
+	}
+	if target == 0 {
+		if gainDB > 0 && peakDB+gainDB > 0 {
+			return dbToGain(gainDB), fmt.Sprintf("--gain %+.1f dB pushes peaks %.1f dB past full scale; they will clip", gainDB, peakDB+gainDB)
+		}
+		return dbToGain(gainDB), ""
+	}
+
+	if math.IsInf(loudness, -1) {
+		return 1, "--normalize: the audio is silent, so its level was left as it is"
+	}
+	db := target - loudness
+	if headroom := config.NormalizeCeiling - peakDB; db > headroom {
+		return dbToGain(headroom), fmt.Sprintf("--normalize: gain held at %+.1f dB to keep peaks below %.0f dBTP, so the audio is %.1f LUFS rather than %.1f", headroom, config.NormalizeCeiling, loudness+headroom, target)
+	}
+	return dbToGain(db), ""
+}
+
+// Source faults worth a warning: a DC offset and a share of clipped samples
+// beyond these.
+const (
+	dcOffsetWarning = 0.01
+	clippedWarning  = 0.001
+)
+
+// faultWarnings returns the warnings for the DC offset and clipping Pass 1
+// found in the audio; declip is whether --declip is rebuilding the peaks.
+func faultWarnings(profile *audio.Profile, declip bool) []string {
+	var warnings []string
+	if math.Abs(profile.DCOffset) > dcOffsetWarning {
+		warnings = append(warnings, fmt.Sprintf("the audio has a DC offset of %+.1f%% of full scale; the bars ignore it, but it stays in the encoded audio", profile.DCOffset*100))
+	}
+	if profile.ClippedFraction > clippedWarning && !declip {
+		warnings = append(warnings, fmt.Sprintf("%.2f%% of the audio's samples are clipped; --declip rebuilds the peaks the bars see", profile.ClippedFraction*100))
+	}
+	return warnings
+}
+
+// encodeStallWarning is the longest the render may wait on a full encode
+// queue before it is worth a warning; an encoder that is merely slower than
+// the render waits a frame's encode at a time.
+const encodeStallWarning = time.Second
+
+// maxEncodeRetries bounds --encode-retries: with the pause doubling to a
+// second, ten retries hold a send up for about seven seconds.
+const maxEncodeRetries = 10
+
+// shownRetries is how many retries the warning lists before counting the
+// rest.
+const shownRetries = 3
+
+// retryWarning describes the transient encoder failures that were retried.
+func retryWarning(retries []encoder.Retry) string {
+	shown := make([]string, 0, shownRetries)
+	for _, r := range retries[:min(len(retries), shownRetries)] {
+		shown = append(shown, r.String())
+	}
+	list := strings.Join(shown, "; ")
+	if more := len(retries) - len(shown); more > 0 {
+		list += fmt.Sprintf("; and %d more", more)
+	}
+	if len(retries) == 1 {
+		return fmt.Sprintf("the encoder retried a transient failure (%s)", list)
+	}
+	return fmt.Sprintf("the encoder retried %d transient failures (%s)", len(retries), list)
+}
+
+// maxAVOffset bounds --av-offset in milliseconds: a second either way is
+// far beyond any player's or encoder's delay.
+const maxAVOffset = 1000
+
+// avOffsetSamples converts --av-offset to samples of the input at step
+// samples a frame: the lead the bars read ahead for a negative offset, or
+// the delay for a positive one.
+func avOffsetSamples(offset time.Duration, step int) (lead, delay int) {
+	n := int(math.Round(offset.Seconds() * config.FPS * float64(step)))
+	if n < 0 {
+		return -n, 0
+	}
+	return 0, n
+}
+
+func dbToGain(db float64) float64 {
+	return math.Pow(10, db/20)
 }
 
 // expandMonoToStereo writes n mono samples from src into dst as interleaved
@@ -310,41 +2189,308 @@ func expandMonoToStereo(dst []float32, src []float64, n int) {
 	}
 }
 
+// openAudio opens length of inputFile from start (all of it for a zero
+// length), with the --music bed mixed under it and the --intro and --outro
+// around it when they are set.
+func openAudio(inputFile string, start, length time.Duration, runtimeConfig *config.RuntimeConfig) (audio.Source, error) {
+	var source audio.Source
+	var err error
+	if runtimeConfig.NativeDecoder {
+		source, err = wav.OpenAt(inputFile, start)
+	} else {
+		source, err = audio.NewStreamingReaderAt(inputFile, start)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if runtimeConfig.MusicPath != "" {
+		mixer, err := audio.NewMixer(source, audio.MusicBed{
+			Path:   runtimeConfig.MusicPath,
+			GainDB: runtimeConfig.MusicGainDB,
+			DuckDB: runtimeConfig.MusicDuckDB,
+		})
+		if err != nil {
+			_ = source.Close()
+			return nil, err
+		}
+		source = mixer
+	}
+	if hasBumpers(runtimeConfig) {
+		stitcher, err := audio.NewStitcher(source, audio.Bumpers{
+			Intro:     runtimeConfig.IntroPath,
+			Outro:     runtimeConfig.OutroPath,
+			Crossfade: runtimeConfig.Crossfade,
+			Length:    length,
+		})
+		if err != nil {
+			_ = source.Close()
+			return nil, err
+		}
+		source = stitcher
+	}
+	return source, nil
+}
+
+// inputMetadata reads inputFile's metadata with the decoder that will read
+// its samples, so --decoder=native reports, and fails, as its render will.
+func inputMetadata(inputFile string, runtimeConfig *config.RuntimeConfig) (*audio.Metadata, error) {
+	if runtimeConfig.NativeDecoder {
+		return audio.WAVMetadata(inputFile)
+	}
+	return audio.GetMetadata(inputFile)
+}
+
+func hasBumpers(runtimeConfig *config.RuntimeConfig) bool {
+	return runtimeConfig.IntroPath != "" || runtimeConfig.OutroPath != ""
+}
+
+// bumperLength returns how much a bumper adds to the audio: its length less
+// the crossfade overlapping it with the episode. An empty path adds nothing.
+func bumperLength(path string, crossfade time.Duration) (time.Duration, error) {
+	if path == "" {
+		return 0, nil
+	}
+	metadata, err := audio.GetMetadata(path)
+	if err != nil {
+		return 0, err
+	}
+	return metadata.Duration - min(crossfade, metadata.Duration), nil
+}
+
+// rewindReader seeks reader back to start for Pass 2. A source the demuxer
+// cannot seek in is closed and opened afresh with reopen instead. On error
+// reader has been closed and the Source returned is nil.
+func rewindReader(reader audio.Source, start time.Duration, reopen func() (audio.Source, error)) (audio.Source, error) {
+	if err := reader.Seek(start); err == nil {
+		return reader, nil
+	}
+	_ = reader.Close()
+	reopened, err := reopen()
+	if err != nil {
+		return nil, err
+	}
+	return reopened, nil
+}
+
 // runPass2 collects any non-fatal warnings during rendering (e.g. an asset that
 // failed to load and was dropped) and delivers them on the RenderComplete
 // message so the caller can print them after the Bubbletea alt screen exits.
-func runPass2(p *tea.Program, profile *audio.Profile, cfg pass2Config) {
-	var warnings []string
-	reader, err := audio.NewStreamingReader(cfg.inputFile)
-	if err != nil {
-		cli.PrintError(fmt.Sprintf("opening audio stream: %v", err))
+// It returns the error that stopped the render, if any, already printed.
+func runPass2(p *tea.Program, profile *audio.Profile, cfg pass2Config) error {
+	warnings := slices.Clone(cfg.part.warnings)
+
+	// fail reports an error that stops the render, and returns it for the
+	// --notify-url and --notify-cmd hooks and the exit code.
+	fail := func(format string, args ...any) error {
+		err := fmt.Errorf(format, args...)
+		cli.PrintError(err.Error())
 		p.Quit()
-		return
+		return err
+	}
+	reader := cfg.reader
+	var err error
+
+	// Chapter ends depend on the audio duration, only known after Pass 1. At
+	// --speed the chapters were already moved onto the faster timeline.
+	audioDuration := time.Duration(profile.Duration * float64(time.Second))
+	if cfg.span.Speed > 1 {
+		audioDuration = time.Duration(float64(audioDuration) / cfg.span.Speed)
+	}
+	if err := chapters.SetEnds(cfg.chapters, audioDuration); err != nil {
+		return fail("invalid --chapters: %w", err)
 	}
-	defer reader.Close()
 
-	enc, err := encoder.New(encoder.Config{
-		OutputPath:    cfg.outputFile,
-		Width:         config.Width,
-		Height:        config.Height,
-		Framerate:     config.FPS,
-		SampleRate:    reader.SampleRate(),
-		AudioChannels: cfg.channels,
-		HWAccel:       cfg.hwAccel,
-	})
-	if err != nil {
-		cli.PrintError(fmt.Sprintf("creating encoder: %v", err))
-		p.Quit()
-		return
+	if cfg.writeDescription {
+		descriptionPath := sidecarPath(cfg.outputFile, ".txt")
+		description := chapters.Description(cfg.meta.Title, cfg.meta.Episode, audioDuration, cfg.chapters)
+		if err := os.WriteFile(descriptionPath, []byte(description), 0o644); err != nil {
+			return fail("writing description: %w", err)
+		}
+		warnings = append(warnings, chapters.YouTubeWarnings(cfg.chapters)...)
 	}
 
-	if err = enc.Initialize(); err != nil {
-		cli.PrintError(fmt.Sprintf("initialising encoder: %v", err))
-		p.Quit()
-		return
+	// Joining segments encodes only the audio here, to a file beside the
+	// output that is muxed with them at the end. A segment has no audio.
+	joining := len(cfg.part.join) > 0
+	encPath := cfg.outputFile
+	if joining {
+		// Matroska for Opus and FLAC, which the output takes alike
+		ext := ".m4a"
+		if cfg.audioCodec == encoder.AudioOpus || cfg.audioCodec == encoder.AudioFLAC {
+			ext = ".mka"
+		}
+		tmp, err := os.CreateTemp(filepath.Dir(cfg.outputFile), ".jivefire-audio-*"+ext)
+		if err != nil {
+			return fail("creating the audio for --join: %w", err)
+		}
+		_ = tmp.Close()
+		encPath = tmp.Name()
+		defer os.Remove(encPath)
+	}
+	sampleRate := reader.SampleRate()
+	if cfg.part.videoOnly {
+		sampleRate = 0
+	}
+
+	// A frames-only render has no encoder; every use of enc below is guarded.
+	// Frames and audio reach it through the queue, which is closed before
+	// the encoder's frame count and flush.
+	var enc *encoder.Encoder
+	var queue *encoder.Queue
+	if !cfg.frames.only {
+		width, height := cfg.runtimeConfig.GetVideoSize()
+		enc, err = encoder.New(encoder.Config{
+			OutputPath:    encPath,
+			Width:         width,
+			Height:        height,
+			Framerate:     config.FPS,
+			SampleRate:    sampleRate,
+			AudioChannels: cfg.channels,
+			HWAccel:       cfg.hwAccel,
+			HWDevice:      cfg.hwDevice,
+			VideoCodec:    cfg.videoCodec,
+			AudioCodec:    cfg.audioCodec,
+			ColorSpace:    cfg.colorSpace,
+			ColorRange:    cfg.colorRange,
+			Profile:       cfg.profile,
+			Options:       cfg.encoderOpts,
+			Chapters:      cfg.chapters,
+			Keyframes:     chapterKeyframes(cfg.chapters, cfg.part.first+cfg.part.warmup),
+			KeyframeEvery: cfg.keyframeInterval * config.FPS,
+			GOP:           cfg.gop,
+			BFrames:       cfg.bFrames,
+			Metadata:      cfg.tags,
+			Attachments:   cfg.attachments,
+			Format:        cfg.format,
+			SegmentLength: cfg.segmentLength,
+			AudioOnly:     joining,
+			Retries:       cfg.encodeRetries,
+		})
+		if err != nil {
+			return fail("creating encoder: %w", err)
+		}
+
+		if err = enc.Initialize(); err != nil {
+			return fail("initialising encoder: %w", err)
+		}
+
+		defer enc.Close()
+
+		queue = encoder.NewQueue(enc, cfg.encodeQueue)
+		defer queue.Close()
 	}
 
-	defer enc.Close()
+	// The image sequence and its WAV are written alongside (or instead of)
+	// the encoder, from the same frames and samples.
+	var frameWriter *frames.Writer
+	var wavWriter *frames.WAVWriter
+	if cfg.frames.dir != "" {
+		frameWriter, err = frames.New(cfg.frames.dir, cfg.frames.format)
+		if err != nil {
+			return fail("creating --frames-dir: %w", err)
+		}
+		if cfg.frames.audio {
+			wavWriter, err = frames.CreateWAV(filepath.Join(cfg.frames.dir, frames.AudioFile), reader.SampleRate(), cfg.channels)
+			if err != nil {
+				return fail("creating %s: %w", frames.AudioFile, err)
+			}
+			// On an early return; the render's end closes it and checks.
+			defer func() {
+				if wavWriter != nil {
+					_ = wavWriter.Close()
+				}
+			}()
+		}
+	}
+
+	// --audio-out encodes the same samples as the video's audio track, to a
+	// file of its own.
+	var audioEnc *encoder.Encoder
+	if cfg.audioOut != "" {
+		audioEnc, err = encoder.New(encoder.Config{
+			OutputPath:    cfg.audioOut,
+			SampleRate:    reader.SampleRate(),
+			AudioChannels: cfg.channels,
+			Chapters:      cfg.chapters,
+			Metadata:      cfg.tags,
+			AudioOnly:     true,
+			Retries:       cfg.encodeRetries,
+		})
+		if err != nil {
+			return fail("creating --audio-out: %w", err)
+		}
+		if err = audioEnc.Initialize(); err != nil {
+			return fail("initialising --audio-out: %w", err)
+		}
+		defer audioEnc.Close()
+	}
+
+	// --gain and --normalize scale the samples in place; every buffer
+	// passed here is scratch the caller refills for the next write.
+	gain, gainWarning := outputGain(cfg.runtimeConfig.GainDB, cfg.runtimeConfig.Normalize, profile.Loudness, profile.OutputPeak)
+	if gainWarning != "" {
+		warnings = append(warnings, gainWarning)
+	}
+	warnings = append(warnings, faultWarnings(profile, cfg.runtimeConfig.Declip)...)
+	// audioWritten counts the samples written per channel, to line the end
+	// of the audio up with the last video frame.
+	// The level meter reads the last samples written, after the gain, so it
+	// shows the published levels of the frame about to be drawn.
+	// With a limit, set by a lead reading past --duration, the audio stops
+	// there.
+	var audioWritten, audioLimit int64
+	var player *playback.Player
+	meterOn := cfg.runtimeConfig.Meter != ""
+	meterRMS, meterPeak := make([]float64, 2), make([]float64, 2)
+	writeAudio := func(samples []float32) error {
+		channels := max(cfg.channels, 1)
+		if audioLimit > 0 {
+			keep := max(min(int64(len(samples)/channels), audioLimit-audioWritten), 0)
+			samples = samples[:keep*int64(channels)]
+		}
+		audioWritten += int64(len(samples) / channels)
+		if gain != 1 {
+			for i := range samples {
+				samples[i] *= float32(gain)
+			}
+		}
+		if meterOn {
+			audio.ChannelLevels(samples, cfg.channels, meterRMS, meterPeak)
+		}
+		if player != nil {
+			player.Write(samples)
+		}
+		if queue != nil {
+			queue.WriteAudioSamples(samples)
+		}
+		if audioEnc != nil {
+			if err := audioEnc.WriteAudioSamples(samples); err != nil {
+				return err
+			}
+		}
+		if wavWriter != nil {
+			return wavWriter.Write(samples)
+		}
+		return nil
+	}
+
+	// The codec and encoder shown in the UI; the encoder name can change if a
+	// hardware encoder falls back mid-run.
+	videoCodec := fmt.Sprintf("%s %d×%d", cfg.videoCodec.DisplayName(), config.Width, config.Height)
+	if enc == nil {
+		videoCodec = fmt.Sprintf("%s %d×%d", strings.ToUpper(string(cfg.frames.format)), config.Width, config.Height)
+	}
+	encoderName := func() string {
+		switch {
+		case enc == nil:
+			return "image sequence"
+		case joining && cfg.part.encoderName != "":
+			return cfg.part.encoderName
+		case joining:
+			return fmt.Sprintf("%d joined segments", len(cfg.part.join))
+		}
+		return queue.EncoderName()
+	}
 
 	// Load background image (custom or embedded). A load failure is non-fatal:
 	// the renderer tolerates a nil background, but warn so the dropped asset is
@@ -360,10 +2506,10 @@ func runPass2(p *tea.Program, profile *audio.Profile, cfg pass2Config) {
 		}
 	}
 
-	// Load font for centre text (embedded). A nil face degrades gracefully, but
-	// a failed load of the embedded font signals an internal problem worth
-	// surfacing.
-	fontFace, err := renderer.LoadFont(48)
+	// Load font for centre text (embedded), sized so the wrapped title fits the
+	// centre gap. A nil face degrades gracefully, but a failed load of the
+	// embedded font signals an internal problem worth surfacing.
+	fontFace, err := renderer.LoadTitleFont(cfg.meta.Title, cfg.runtimeConfig)
 	if err != nil {
 		fontFace = nil
 		warnings = append(warnings, fmt.Sprintf("could not load embedded font, rendering without centre text: %v", err))
@@ -371,51 +2517,157 @@ func runPass2(p *tea.Program, profile *audio.Profile, cfg pass2Config) {
 
 	processor, err := audio.NewProcessor()
 	if err != nil {
-		cli.PrintError(fmt.Sprintf("creating FFT processor: %v", err))
-		p.Quit()
-		return
+		return fail("creating FFT processor: %w", err)
 	}
 	defer processor.Close()
 	frame := renderer.NewFrame(bgImage, fontFace, cfg.meta, cfg.runtimeConfig)
+	frame.SetFrameIndex(cfg.part.first)
+
+	// The moving background is fitted larger than the frame; without it the
+	// still one stays.
+	if bgImage != nil && cfg.runtimeConfig.BackgroundMotion == config.MotionKenBurns {
+		motionBg, err := renderer.LoadMotionBackground(cfg.runtimeConfig)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("could not load the moving background, keeping it still: %v", err))
+		}
+		frame.SetBackgroundMotion(motionBg)
+	}
+
+	// A clip lays each frame out on its audiogram canvas for the encoder; the
+	// previews show the frame as drawn.
+	var clip *renderer.Clip
+	if cfg.runtimeConfig.ClipShape != "" {
+		clip, err = renderer.NewClip(bgImage, cfg.meta, cfg.runtimeConfig)
+		if err != nil {
+			return fail("laying out clip: %w", err)
+		}
+	}
+
+	vis, err := renderer.NewVisualizer(cfg.runtimeConfig.Visualizer, cfg.runtimeConfig)
+	if err != nil {
+		return fail("creating visualizer: %w", err)
+	}
+	frame.SetVisualizer(vis)
+	// A level-following visualizer (the waveform) is scaled so Pass 1's
+	// loudest peak fills it.
+	levelVis, _ := vis.(renderer.LevelVisualizer)
+	levelScale := 1.0
+	if profile.TruePeak > 0 {
+		levelScale = 1 / profile.TruePeak
+	}
+	// A spectrum visualizer (the spectrogram) gets a column of the whole
+	// binned range, a row per pixel.
+	specVis, _ := vis.(renderer.SpectrumVisualizer)
+	var spectrumColumn []float64
+	if specVis != nil {
+		spectrumColumn = make([]float64, config.Height)
+	}
+
+	var overlay *script.Script
+	if cfg.runtimeConfig.ScriptPath != "" {
+		overlay, err = script.Load(cfg.runtimeConfig.ScriptPath, renderer.LoadFont)
+		if err != nil {
+			return fail("loading --script: %w", err)
+		}
+		defer overlay.Close()
+		frame.SetOverlay(overlay)
+	}
+
+	// The audio features are extracted only for a visualizer or script
+	// that reads them, the background's centroid glow, or
+	// --export-features.
+	var featureSinks []renderer.FeatureVisualizer
+	if fv, ok := vis.(renderer.FeatureVisualizer); ok {
+		featureSinks = append(featureSinks, fv)
+	}
+	if overlay != nil {
+		featureSinks = append(featureSinks, overlay)
+	}
+	var featureSet *audio.FeatureSet
+	var featuresOut *featuresWriter
+	backgroundGlow := cfg.runtimeConfig.CentroidGlow == config.GlowBackground
+	if len(featureSinks) > 0 || backgroundGlow || cfg.featuresOut != "" {
+		featureSet = audio.NewFeatureSet(reader.SampleRate())
+	}
+	if cfg.featuresOut != "" {
+		featuresOut, err = createFeatures(cfg.featuresOut, featureSet.Names())
+		if err != nil {
+			return fail("creating --export-features: %w", err)
+		}
+		defer featuresOut.Close()
+	}
+
+	// Load the optional logo badge. A failure falls back to the episode number
+	// badge with a warning, matching the background image handling.
+	badgeImage, err := renderer.LoadBadgeImage(cfg.runtimeConfig)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("could not load badge image, using episode number: %v", err))
+	}
+	frame.SetBadgeImage(badgeImage)
+
+	// The episode number and timecode are drawn in the title font at sizes
+	// of their own, so they fail with the title.
+	badgeFont, err := renderer.LoadBadgeFont(cfg.runtimeConfig)
+	if err != nil && cfg.meta.Episode != nil {
+		warnings = append(warnings, fmt.Sprintf("could not load the badge font, rendering without the episode number: %v", err))
+	}
+	frame.SetBadgeFont(badgeFont)
+	timecodeFont, err := renderer.LoadTimecodeFont(cfg.runtimeConfig)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("could not load the timecode font, rendering without the timecode: %v", err))
+	}
+	frame.SetTimecode(timecodeFont)
+
+	// The preview window is a nicety: if the player fails to start, encode
+	// without it.
+	var previewWin *window.Window
+	if cfg.previewWindow {
+		previewWin, err = window.Open(config.Width, config.Height, config.FPS)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("could not open preview window: %v", err))
+		} else {
+			defer previewWin.Close()
+		}
+	}
+	// So is --play: without a player the render runs at full speed.
+	if cfg.play {
+		player, err = playback.Open(reader.SampleRate(), cfg.channels)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("could not play the audio: %v", err))
+		} else {
+			defer player.Close()
+		}
+	}
+	// The window is shown the guides on a copy; the frame itself is encoded.
+	var guideImg *image.RGBA
+	if previewWin != nil && cfg.runtimeConfig.Guides {
+		guideImg = image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	}
 
+	// Pass 1's frame count sets the progress total and the variant spacing,
+	// but the loop below runs on to the end of the audio (or --duration) in
+	// case this decode finds more or less than Pass 1 did.
 	numFrames := profile.NumFrames
+	frame.SetTotalFrames(cmp.Or(cfg.part.total, numFrames))
 
 	var totalVis, totalEncode, totalAudio time.Duration
 	renderStartTime := time.Now()
 	lastProgressUpdate := renderStartTime
 	const progressUpdateInterval = 30 * time.Millisecond
 
-	// Codec display uses the output channel count (from CLI), not the input's.
+	// Codec display uses the output channel count, not the input's.
 	audioSampleRate := reader.SampleRate()
-	audioChannelStr := "mono"
-	if cfg.channels == 2 {
-		audioChannelStr = "stereo"
-	}
-	audioCodecInfo := fmt.Sprintf("AAC %.1f㎑ %s", float64(audioSampleRate)/1000.0, audioChannelStr)
-
-	// Harmonica spring peak-hold state. Each bar rises INSTANTLY to a new high,
-	// then springs DOWN toward the raw level over subsequent frames. The spring
-	// delta is locked to the video frame interval (1/FPS) so the fall rate is
-	// framerate-independent.
-	prevBarHeights := make([]float64, config.NumBars)
-	const (
-		harmonicaSpringFreq    = 6.0
-		harmonicaSpringDamping = 1.0
-		// harmonicaGain lifts the spring bars to a fuller amplitude. The peak-hold
-		// path has no integrator, so bars otherwise peak at the raw scaled height
-		// and look short (the old leaky-integrator path had a steady-state gain of
-		// roughly 4.3x for free). The existing soft-knee compression caps the loud
-		// bars, so this keeps dynamic spread rather than flattening everything to
-		// full height. Tune for taste.
-		harmonicaGain = 2.0
-	)
-	harmonicaDelta := 1.0 / config.Framerate
-	harmonicaSprings := make([]harmonica.Spring, config.NumBars)
-	for i := range harmonicaSprings {
-		harmonicaSprings[i] = harmonica.NewSpring(harmonicaDelta, harmonicaSpringFreq, harmonicaSpringDamping)
+	audioChannelStr := encoder.ChannelLayoutName(cfg.channels)
+	audioCodecInfo := fmt.Sprintf("%s %.1f㎑ %s", cfg.audioCodec.DisplayName(), float64(cfg.audioCodec.SampleRate(audioSampleRate))/1000.0, audioChannelStr)
+	if enc == nil || cfg.part.videoOnly {
+		audioCodecInfo = "none"
+		if wavWriter != nil {
+			audioCodecInfo = fmt.Sprintf("WAV %.1f㎑ %s", float64(audioSampleRate)/1000.0, audioChannelStr)
+		}
 	}
-	harmonicaPos := make([]float64, config.NumBars)
-	harmonicaVel := make([]float64, config.NumBars)
+
+	// Auto-sensitivity, soft knee and spring peak-hold, carried frame to frame.
+	smoother := audio.NewSmoother(config.NumBars, config.Framerate, float64(config.Height/2-config.CenterGap/2)*config.MaxBarHeight)
 
 	// Reusable buffers to avoid per-frame allocations in the render loop.
 	barHeights := make([]float64, config.NumBars)
@@ -437,275 +2689,609 @@ func runPass2(p *tea.Program, profile *audio.Profile, cfg pass2Config) {
 		previewImgs[1] = image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
 	}
 
-	sensitivity := 1.0
+	gate := audio.Gate{Threshold: cfg.runtimeConfig.GetNoiseGate(), Floor: cfg.runtimeConfig.MinBar}
+	bands, err := audio.NewBands(reader.SampleRate(), audio.FreqRange{Min: cfg.runtimeConfig.FreqMin, Max: cfg.runtimeConfig.FreqMax})
+	if err != nil {
+		return fail("invalid --freq-min/--freq-max: %w", err)
+	}
+	visFilter, err := audio.NewFilter(reader.SampleRate(), audio.VisFilter{HighPass: cfg.runtimeConfig.VisHighPass, LowPass: cfg.runtimeConfig.VisLowPass, Declip: cfg.runtimeConfig.Declip})
+	if err != nil {
+		return fail("invalid --vis-highpass/--vis-lowpass: %w", err)
+	}
+	dcBlock := audio.NewDCBlocker(reader.SampleRate())
+	baseScale := barScale(profile, cfg.runtimeConfig.BaseScale)
 
-	// Sliding buffer for FFT: we read samplesPerFrame but need FFTSize for FFT.
-	// Derive from the file's actual sample rate so encoded audio and video
-	// durations stay aligned for any input rate.
+	// Sliding buffer for FFT: we read samplesPerFrame (step at --speed) but
+	// need FFTSize for FFT. Derive from the file's actual sample rate so
+	// encoded audio and video durations stay aligned for any input rate.
 	samplesPerFrame := reader.SampleRate() / config.FPS
+	step := cfg.span.Step(samplesPerFrame)
 	fftBuffer := make([]float64, config.FFTSize)
 
+	// --av-offset shifts the bars against the audio. A delay holds back the
+	// samples they analyse. A lead makes the first read that much longer,
+	// so the bars see ahead of the frames; the audio is still encoded as it
+	// is read, and the frames after the end of the stream draw silence
+	// without adding any.
+	lead, visDelay := avOffsetSamples(cfg.runtimeConfig.AVOffset, step)
+	delay := audio.NewDelay(visDelay)
+	if lead > 0 && cfg.span.Frames > 0 {
+		audioLimit = int64(cfg.span.Frames) * int64(samplesPerFrame)
+	}
+
+	// At --speed the audio is time-stretched back to samplesPerFrame per
+	// frame, keeping its pitch, so it stays in step with the video.
+	var stretcher *audio.Stretcher
+	if step != samplesPerFrame {
+		stretcher = audio.NewStretcher(reader.SampleRate(), cfg.span.Speed)
+	}
+
+	// The reader downmixes to mono for the FFT. For more than one output
+	// channel it also converts each frame to the output layout, so a stereo
+	// or 5.1 source keeps its own channels in the encoded audio.
+	multi := cfg.channels > 1 && stretcher == nil
+	if multi {
+		if err := reader.EnableOutput(cfg.channels); err != nil {
+			return fail("opening audio stream: %w", err)
+		}
+	}
+
 	// Pre-allocate reusable buffers for audio processing (avoid per-frame allocations)
-	newSamples := make([]float64, samplesPerFrame)
-	audioSamples := make([]float32, samplesPerFrame)
-	// The reader downmixes to mono. For stereo output the encoder expects
-	// interleaved L,R pairs, so duplicate each mono sample into both channels via
-	// this pre-allocated buffer (no per-frame allocation).
-	stereo := cfg.channels == 2
+	newSamples := make([]float64, step)
+	audioSamples := make([]float32, step)
+	// The stretcher works on the mono signal. For stereo output the encoder
+	// expects interleaved L,R pairs, so duplicate each mono sample into both
+	// channels via this pre-allocated buffer (no per-frame allocation).
+	stereo := cfg.channels == 2 && !multi
 	var stereoSamples []float32
 	if stereo {
-		stereoSamples = make([]float32, samplesPerFrame*2)
+		stereoSamples = make([]float32, step*2)
 	}
-
-	// Pre-fill buffer with first chunk
-	n, err := audio.FillFFTBuffer(reader, fftBuffer)
-	if err != nil {
-		cli.PrintError(fmt.Sprintf("error reading initial audio chunk: %v", err))
-		p.Quit()
-		return
+	// writeMono converts float64 mono samples to float32 for the AAC encoder
+	// via the pre-allocated buffers, sliced to the actual length. For stereo
+	// the mono signal is duplicated into both interleaved channels.
+	// WriteAudioSamples copies into the FIFO and retains no reference, so the
+	// buffers are reused on every call. The stretcher can hand back a little
+	// more than a frame at a time, so they grow when needed.
+	writeMono := func(samples []float64) error {
+		if stereo {
+			stereoSamples = slices.Grow(stereoSamples[:0], len(samples)*2)[:len(samples)*2]
+			expandMonoToStereo(stereoSamples, samples, len(samples))
+			return writeAudio(stereoSamples)
+		}
+		audioSamples = slices.Grow(audioSamples[:0], len(samples))[:len(samples)]
+		for i, v := range samples {
+			audioSamples[i] = float32(v)
+		}
+		return writeAudio(audioSamples)
 	}
-	if n == 0 {
-		cli.PrintError("no audio data available")
-		p.Quit()
-		return
+
+	// Decode ahead on another goroutine, so drawing and encoding a frame
+	// never wait on the disk or the decoder. The prefetcher owns the reader
+	// until it is closed.
+	prefetch := audio.NewPrefetcher(reader, step+lead, step, multi)
+	defer prefetch.Close()
+
+	// The FFT buffer only feeds the bars, so --vis-highpass/--vis-lowpass
+	// filter each read in place once its audio has been written, any DC
+	// offset is taken out, as in Pass 1, and --av-offset delays it, before
+	// it is shifted into the end of the window.
+	feedBars := func(samples []float64) {
+		if visFilter != nil {
+			visFilter.Apply(samples, samples)
+		}
+		dcBlock.Apply(samples, samples)
+		delay.Apply(samples, samples)
+		audio.ShiftIn(fftBuffer, samples)
 	}
 
-	// Write initial audio samples to encoder (first samplesPerFrame worth).
-	// This corresponds to the audio for frame 0. Reuse the audioSamples buffer:
-	// WriteAudioSamples copies into the FIFO and retains no reference, and the
-	// buffer is overwritten before each later use in the render loop.
-	initialCount := min(samplesPerFrame, n)
+	// The first read is frame 0's audio, and the bars' lead beyond it; all
+	// of it is encoded, so no sample is skipped.
+	block, err := prefetch.Next()
+	if errors.Is(err, io.EOF) {
+		return fail("no audio data available")
+	}
+	if err != nil {
+		return fail("error reading initial audio chunk: %w", err)
+	}
+	initialCount := len(block.Samples)
 	var initialErr error
-	if stereo {
-		expandMonoToStereo(stereoSamples, fftBuffer, initialCount)
-		initialErr = enc.WriteAudioSamples(stereoSamples[:initialCount*2])
+	if multi {
+		initialErr = writeAudio(block.Output)
+	} else if stretcher != nil {
+		initialErr = writeMono(stretcher.Process(block.Samples))
 	} else {
-		for i := range initialCount {
-			audioSamples[i] = float32(fftBuffer[i])
-		}
-		initialErr = enc.WriteAudioSamples(audioSamples[:initialCount])
+		initialErr = writeMono(block.Samples)
 	}
 	if initialErr != nil {
-		cli.PrintError(fmt.Sprintf("error writing initial audio: %v", initialErr))
-		p.Quit()
-		return
+		prefetch.Release(block)
+		return fail("error writing initial audio: %w", initialErr)
+	}
+	feedBars(block.Samples)
+	prefetch.Release(block)
+
+	// Frames captured as thumbnail variant backgrounds, evenly spaced through
+	// the episode (25/50/75% for three variants).
+	variantFrames := make(map[int]int, cfg.thumbnailVariants)
+	for i := 1; i <= cfg.thumbnailVariants; i++ {
+		variantFrames[numFrames*i/(cfg.thumbnailVariants+1)] = i
 	}
 
-	// Process frames until we run out of audio
+	// Process frames until we run out of audio or the user stops the encode.
+	// Pausing blocks here between frames; the paused time is left out of the
+	// stage timings and the total.
+	var pausedTotal time.Duration
+	var frameTimes timing.Recorder
 	frameNum := 0
-	for frameNum < numFrames {
-		// Use current buffer for FFT
-		chunk := fftBuffer[:config.FFTSize]
-
-		// === VISUALISATION TIMING START ===
-		t0 := time.Now()
-
-		coeffs := processor.ProcessChunk(chunk)
-
-		// Bin magnitudes into bars using the optimal baseScale from Pass 1.
-		audio.BinFFT(coeffs, sensitivity, profile.OptimalBaseScale, barHeights)
-
-		// Auto-sensitivity: detect overshoot, applying soft-knee compression to any
-		// bar above the threshold.
-		overshootDetected := false
-
-		for i, h := range barHeights {
-			if h > config.OvershootThreshold {
-				overshootDetected = true
-				overshoot := h - config.OvershootThreshold
-				barHeights[i] = config.OvershootThreshold + overshoot*math.Exp(-overshoot/config.OvershootThreshold)
-			}
+	samplesRead := int64(initialCount)
+	silentFrames := 0          // Frames rendered after the audio ran out early
+	leadLeft := lead           // Audio the lead encoded ahead of the frames
+	var silentOutput []float32 // One frame of multi-channel silence for them
+	for {
+		if paused := cfg.controls.WaitWhilePaused(); paused > 0 {
+			pausedTotal += paused
+			renderStartTime = renderStartTime.Add(paused)
 		}
-
-		if overshootDetected {
-			sensitivity *= config.SensitivityDecay
-		} else {
-			sensitivity *= config.SensitivityGrowth
+		if cfg.controls.Cancelled() != ui.CancelNone {
+			break
 		}
 
-		if sensitivity < config.SensitivityMin {
-			sensitivity = config.SensitivityMin
-		}
-		if sensitivity > config.SensitivityMax {
-			sensitivity = config.SensitivityMax
-		}
+		// Joining segments only encodes the audio; the segments hold the
+		// frames.
+		if !joining {
+			// Use current buffer for FFT
+			chunk := fftBuffer[:config.FFTSize]
 
-		// Scale normalised bar heights into pixel space.
-		actualAvailableSpace := float64(config.Height/2 - config.CenterGap/2)
-		availableHeight := actualAvailableSpace * config.MaxBarHeight
-		for i := range barHeights {
-			barHeights[i] *= availableHeight
-		}
+			// === VISUALISATION TIMING START ===
+			t0 := time.Now()
 
-		// Harmonica peak-hold dynamic. Each bar rises instantly to a new peak, then
-		// springs down toward the raw level. Writes into prevBarHeights so the
-		// downstream rearrange/draw pipeline stays unchanged.
-		for i := range barHeights {
-			// Apply the spring-path gain so bars reach a fuller amplitude; the
-			// soft-knee below caps the loud ones, preserving dynamic spread.
-			currentHeight := barHeights[i] * harmonicaGain
+			coeffs := processor.ProcessChunk(chunk)
+			tBin := time.Now()
+			frameTimes.Add(timing.StageFFT, tBin.Sub(t0))
 
-			if currentHeight >= harmonicaPos[i] {
-				// Instant rise to the new peak; reset velocity so the fall starts
-				// from rest.
-				harmonicaPos[i] = currentHeight
-				harmonicaVel[i] = 0
-			} else {
-				harmonicaPos[i], harmonicaVel[i] = harmonicaSprings[i].Update(
-					harmonicaPos[i], harmonicaVel[i], currentHeight)
-				if harmonicaPos[i] < 0 {
-					harmonicaPos[i] = 0
-					harmonicaVel[i] = 0
+			// Bin magnitudes into bars using the baseScale from Pass 1 or --scale,
+			// then smooth them into pixel heights.
+			audio.BinFFT(coeffs, bands, smoother.Sensitivity(), baseScale, gate, barHeights)
+			smoother.Step(barHeights)
+
+			audio.RearrangeFrequenciesCenterOut(barHeights, rearrangedHeights)
+			if levelVis != nil {
+				levelVis.Levels(audio.RMS(chunk)*levelScale, audio.SamplePeak(chunk)*levelScale)
+			}
+			if specVis != nil {
+				audio.SpectrumColumn(coeffs, bands, baseScale, spectrumColumn)
+				specVis.Spectrum(spectrumColumn)
+			}
+			if featureSet != nil {
+				features := featureSet.Extract(chunk, coeffs)
+				for _, sink := range featureSinks {
+					sink.Features(features)
+				}
+				if backgroundGlow {
+					frame.SetCentroid(features[audio.FeatureCentroid])
+				}
+				if featuresOut != nil {
+					if err := featuresOut.write(frameNum, features); err != nil {
+						return fail("writing --export-features: %w", err)
+					}
 				}
 			}
 
-			heldHeight := harmonicaPos[i]
-
-			// Soft knee compression
-			if heldHeight > availableHeight {
-				overshoot := heldHeight - availableHeight
-				heldHeight = availableHeight + overshoot*math.Exp(-overshoot/availableHeight)
+			// Mean held bar height is the loudness proxy for the badge pulse
+			// and the particles.
+			if cfg.runtimeConfig.BadgePulse || cfg.runtimeConfig.Particles {
+				var sum float64
+				for _, h := range barHeights {
+					sum += h
+				}
+				frame.SetLevel(sum / float64(len(barHeights)) / smoother.MaxHeight())
 			}
 
-			prevBarHeights[i] = heldHeight
-		}
-
-		audio.RearrangeFrequenciesCenterOut(prevBarHeights, rearrangedHeights)
+			tDraw := time.Now()
+			frameTimes.Add(timing.StageBin, tDraw.Sub(tBin))
 
-		frame.Draw(rearrangedHeights)
-		totalVis += time.Since(t0)
-		// === VISUALISATION TIMING END ===
+			if meterOn {
+				frame.SetMeterLevels(meterRMS, meterPeak)
+			}
+			frame.Draw(rearrangedHeights)
+			frameTimes.Add(timing.StageDraw, time.Since(tDraw))
+			totalVis += time.Since(t0)
+			// === VISUALISATION TIMING END ===
+
+			// === VIDEO ENCODING TIMING START ===
+			t0 = time.Now()
+			img := frame.GetImage()
+			if queue != nil && frameNum >= cfg.part.warmup {
+				// Only the rows the frame changed need converting; a clip is
+				// scaled onto its own canvas, so it goes whole.
+				if clip != nil {
+					queue.WriteFrameRGBA(clip.Compose(img).Pix)
+				} else if frameNum == cfg.part.warmup && frameNum > 0 {
+					// The encoder saw none of the warm-up, so it takes every row.
+					queue.WriteFrameRGBA(img.Pix)
+				} else {
+					startY, endY := frame.DirtyRows()
+					queue.WriteFrameRGBARows(img.Pix, startY, endY)
+				}
+				// A failure surfaces once the encoder reaches the frame, up to
+				// --encode-queue frames later, and names the frame itself.
+				if err := queue.Err(); err != nil {
+					return fail("error encoding %w", err)
+				}
+			}
+			if frameWriter != nil {
+				if err := frameWriter.Write(frameNum, img); err != nil {
+					return fail("error writing frame %d: %w", frameNum, err)
+				}
+			}
+			encodeTime := time.Since(t0)
+			totalEncode += encodeTime
+			frameTimes.Add(timing.StageEncode, encodeTime)
+			frameTimes.EndFrame(frameNum + 1)
+			// === VIDEO ENCODING TIMING END ===
+
+			// --play holds each frame back until its audio is heard.
+			if player != nil {
+				player.Pace(time.Duration(frameNum) * time.Second / config.FPS)
+			}
+			if previewWin != nil {
+				if guideImg != nil {
+					copy(guideImg.Pix, img.Pix)
+					renderer.DrawGuides(guideImg)
+					previewWin.WriteFrame(guideImg.Pix)
+				} else {
+					previewWin.WriteFrame(img.Pix)
+				}
+			}
 
-		// === VIDEO ENCODING TIMING START ===
-		t0 = time.Now()
-		img := frame.GetImage()
-		if err := enc.WriteFrameRGBA(img.Pix); err != nil {
-			cli.PrintError(fmt.Sprintf("error encoding frame %d: %v", frameNum, err))
-			p.Quit()
-			return
+			// Thumbnail variants are drawn straight from the frame buffer, outside
+			// the timed sections; a failure drops that variant with a warning.
+			if variant, ok := variantFrames[frameNum]; ok {
+				variantPath := sidecarPath(cfg.outputFile, fmt.Sprintf("-%d.png", variant))
+				if err := renderer.GenerateThumbnailWithBackground(variantPath, img, cfg.meta, cfg.runtimeConfig); err != nil {
+					warnings = append(warnings, fmt.Sprintf("could not write thumbnail variant %s: %v", variantPath, err))
+				}
+			}
 		}
-		totalEncode += time.Since(t0)
-		// === VIDEO ENCODING TIMING END ===
 
-		// Throttled UI updates, outside the timed sections.
+		// Throttled UI updates and memory checks, outside the timed sections.
 		if time.Since(lastProgressUpdate) >= progressUpdateInterval {
 			lastProgressUpdate = time.Now()
+			if err := cfg.memGuard.Check(); err != nil {
+				return fail("render stopped at frame %d: %w", frameNum, err)
+			}
 			elapsed := time.Since(renderStartTime)
 
 			copy(barHeightsCopy, rearrangedHeights)
 
 			// Actual on-disk file size, not an estimate.
 			var currentFileSize int64
-			if fileInfo, err := os.Stat(cfg.outputFile); err == nil {
+			if enc == nil {
+				currentFileSize = frameWriter.Bytes()
+			} else if fileInfo, err := os.Stat(encPath); err == nil {
 				currentFileSize = fileInfo.Size()
 			}
 
 			var frameData *image.RGBA
-			if !cfg.noPreview {
+			if !cfg.noPreview && cfg.controls.PreviewEnabled() {
 				// Copy into the buffer the UI is not reading; the next frame.Draw
 				// mutates img and the next send reuses the other buffer.
 				previewImg := previewImgs[previewIdx]
-				copy(previewImg.Pix, img.Pix)
+				copy(previewImg.Pix, frame.GetImage().Pix)
+				if cfg.runtimeConfig.Guides {
+					renderer.DrawGuides(previewImg)
+				}
 				frameData = previewImg
 				previewIdx ^= 1
 			}
 
-			p.Send(ui.RenderProgress{
-				Frame:       frameNum + 1,
-				TotalFrames: numFrames,
-				Elapsed:     elapsed,
-				BarHeights:  barHeightsCopy,
-				FileSize:    currentFileSize,
-				Sensitivity: sensitivity,
-				FrameData:   frameData,
-				VideoCodec:  fmt.Sprintf("H.264 %d×%d", config.Width, config.Height),
-				AudioCodec:  audioCodecInfo,
-				EncoderName: enc.EncoderName(),
-			})
+			// --segments showed the workers' progress, and keeps it while
+			// joining.
+			if !cfg.part.quiet {
+				var queueDepth, queueCapacity int
+				var encoderStats ui.EncoderStats
+				if queue != nil {
+					queueDepth, queueCapacity = queue.Depth(), queue.Capacity()
+					encoderStats = ui.EncoderStats(queue.Stats())
+				}
+				p.Send(ui.RenderProgress{
+					Frame:         frameNum + 1,
+					TotalFrames:   numFrames,
+					Elapsed:       elapsed,
+					BarHeights:    barHeightsCopy,
+					FileSize:      currentFileSize,
+					Sensitivity:   smoother.Sensitivity(),
+					FrameData:     frameData,
+					VideoCodec:    videoCodec,
+					AudioCodec:    audioCodecInfo,
+					EncoderName:   encoderName(),
+					QueueDepth:    queueDepth,
+					QueueCapacity: queueCapacity,
+					Encoder:       encoderStats,
+				})
+			}
 		}
 
 		frameNum++
+		if cfg.span.Frames > 0 && frameNum >= cfg.span.Frames {
+			break
+		}
+		if frameNum >= numFrames {
+			numFrames = frameNum + 1
+		}
 
 		// === AUDIO TIMING START ===
-		// Read audio, encode, and shift the FFT buffer ready for the next frame.
-		t0 = time.Now()
-		nRead, readErr := audio.ReadNextFrame(reader, newSamples)
+		// Take the prefetched audio, encode it, and shift the FFT buffer ready
+		// for the next frame.
+		t0 := time.Now()
+		block, readErr := prefetch.Next()
+		if errors.Is(readErr, io.EOF) && frameNum < profile.NumFrames {
+			// The audio ran out before the frame count Pass 1 found. Carry on
+			// in silence, so the video still gets every frame and the audio
+			// keeps pace with it. The frames covering a lead's audio are
+			// expected.
+			if leadLeft == 0 {
+				silentFrames++
+			}
+			readErr = nil
+		}
 		if readErr != nil {
 			if errors.Is(readErr, io.EOF) {
 				totalAudio += time.Since(t0)
 				break
 			}
-			cli.PrintError(fmt.Sprintf("error reading audio: %v", readErr))
-			p.Quit()
-			return
+			return fail("error reading audio: %w", readErr)
 		}
-
-		// Convert this frame's float64 samples to float32 for the AAC encoder via
-		// the pre-allocated buffers, sliced to the actual length. For stereo the
-		// mono signal is duplicated into both interleaved channels.
-		var writeErr error
-		if stereo {
-			expandMonoToStereo(stereoSamples, newSamples, nRead)
-			writeErr = enc.WriteAudioSamples(stereoSamples[:nRead*2])
-		} else {
-			for i := range nRead {
-				audioSamples[i] = float32(newSamples[i])
+		var nRead int
+		var output []float32
+		if block != nil {
+			nRead = copy(newSamples, block.Samples)
+			output = block.Output
+		} else if multi {
+			if silentOutput == nil {
+				silentOutput = make([]float32, step*cfg.channels)
 			}
-			writeErr = enc.WriteAudioSamples(audioSamples[:nRead])
+			output = silentOutput
 		}
-		if writeErr != nil {
-			cli.PrintError(fmt.Sprintf("error writing audio at frame %d: %v", frameNum, writeErr))
-			p.Quit()
-			return
+		samplesRead += int64(nRead)
+		// Zero-pad a short final read (or a silent frame) so stale samples
+		// never feed the FFT or the audio.
+		clear(newSamples[nRead:])
+
+		// A silent frame writes a whole frame of silence, less any audio a
+		// lead has already written for it; a short final read writes what it
+		// has, and the end is padded after the loop.
+		audioLen := nRead
+		if block == nil {
+			ahead := min(leadLeft, step)
+			leadLeft -= ahead
+			audioLen = step - ahead
+			if multi {
+				output = output[:audioLen*cfg.channels]
+			}
 		}
-		// Shift the buffer left by samplesPerFrame and append the new samples,
-		// zero-padding a short final read so stale samples never feed the FFT.
-		copy(fftBuffer, fftBuffer[samplesPerFrame:])
-		if nRead < samplesPerFrame {
-			copy(fftBuffer[config.FFTSize-samplesPerFrame:], newSamples[:nRead])
-			clear(fftBuffer[config.FFTSize-samplesPerFrame+nRead:])
+		var writeErr error
+		if multi {
+			writeErr = writeAudio(output)
+		} else if stretcher != nil {
+			writeErr = writeMono(stretcher.Process(newSamples[:audioLen]))
 		} else {
-			copy(fftBuffer[config.FFTSize-samplesPerFrame:], newSamples[:nRead])
+			writeErr = writeMono(newSamples[:audioLen])
+		}
+		if block != nil {
+			prefetch.Release(block)
+		}
+		if writeErr != nil {
+			return fail("error writing audio at frame %d: %w", frameNum, writeErr)
 		}
+		feedBars(newSamples)
 		totalAudio += time.Since(t0)
 		// === AUDIO TIMING END ===
 	}
 
-	// Flush samples still in the FIFO after the last video frame is written.
-	if err := enc.FlushAudioEncoder(); err != nil {
-		cli.PrintError(fmt.Sprintf("error flushing audio: %v", err))
-		p.Quit()
-		return
+	// A stream that decodes differently each time (typically a damaged MP3)
+	// is rendered in full, but say so: Pass 1's levels did not see all of it.
+	// One that comes up short is rendered to Pass 1's length in silence.
+	if cfg.controls.Cancelled() == ui.CancelNone {
+		if silentFrames > 0 {
+			warnings = append(warnings, fmt.Sprintf("audio ran out %d frames before the %d analysed; they were rendered in silence, and the file may be damaged", silentFrames, profile.NumFrames))
+		} else if frameNum != profile.NumFrames {
+			warnings = append(warnings, fmt.Sprintf("audio length differs between passes (%d frames analysed, %d rendered); the file may be damaged", profile.NumFrames, frameNum))
+		}
+		numFrames = frameNum
 	}
 
-	if err := enc.Close(); err != nil {
-		cli.PrintError(fmt.Sprintf("error closing encoder: %v", err))
-		p.Quit()
-		return
+	// A failing script only loses its overlay; the render carries on.
+	if overlay != nil && overlay.Err() != nil {
+		warnings = append(warnings, fmt.Sprintf("--script stopped drawing at %v", overlay.Err()))
+	}
+
+	// The stretcher holds back the end of its last segment.
+	if stretcher != nil {
+		if err := writeMono(stretcher.Flush()); err != nil {
+			return fail("error writing audio: %w", err)
+		}
+	}
+
+	// The last frame is usually only partly covered by audio; pad the audio
+	// with silence to its end, so both streams finish together.
+	if short := int64(frameNum)*int64(samplesPerFrame) - audioWritten; short > 0 && cfg.controls.Cancelled() == ui.CancelNone {
+		if err := writeAudio(make([]float32, short*int64(cfg.channels))); err != nil {
+			return fail("error writing audio: %w", err)
+		}
+	}
+
+	// The encoder takes the last of the queue before it is counted and
+	// flushed. One that stalled for long held the render up even so.
+	if queue != nil {
+		if err := queue.Close(); err != nil {
+			return fail("error encoding %w", err)
+		}
+		if _, longest := queue.Waited(); longest > encodeStallWarning {
+			warnings = append(warnings, fmt.Sprintf("the video encoder stalled for up to %.1f s with --encode-queue full, holding up the render; the disk or GPU may be struggling", longest.Seconds()))
+		}
+	}
+
+	// A finished output carries its provenance; a segment's tag marks it for
+	// --join instead.
+	var provenance encoder.Tag
+	if enc != nil && !cfg.part.videoOnly && cfg.controls.Cancelled() == ui.CancelNone {
+		total := time.Since(cfg.overallStartTime) - pausedTotal
+		timings := report.Timings{
+			Analysis:      cfg.analysisDuration.Seconds(),
+			Thumbnail:     cfg.thumbnailDuration.Seconds(),
+			Visualisation: (totalVis + cfg.part.visTime).Seconds(),
+			VideoEncoding: (totalEncode + cfg.part.encodeTime).Seconds(),
+			AudioEncoding: totalAudio.Seconds(),
+			Total:         total.Seconds(),
+		}
+		accounted := cfg.analysisDuration + cfg.thumbnailDuration + totalVis + cfg.part.visTime + totalEncode + cfg.part.encodeTime + totalAudio
+		timings.Other = max(total-accounted, 0).Seconds()
+		encoded := report.Encoder{Name: encoderName(), Hardware: enc.IsHardware() || cfg.part.hardware, Profile: string(cfg.profile)}
+		if provenance, err = provenanceTag(&cfg, encoded, timings); err != nil {
+			warnings = append(warnings, fmt.Sprintf("could not record the render's provenance: %v", err))
+		}
+	}
+
+	var encoderRetries int
+	if enc != nil {
+		// Every frame drawn must have reached the encoder, or the video runs
+		// short of the audio. A segment's warm-up is drawn only.
+		if got, want := enc.VideoFrames(), int64(max(frameNum-cfg.part.warmup, 0)); !joining && got != want {
+			warnings = append(warnings, fmt.Sprintf("the encoder received %d video frames of the %d rendered", got, want))
+		}
+
+		// Flush samples still in the FIFO after the last video frame is written.
+		if err := enc.FlushAudioEncoder(); err != nil {
+			return fail("error flushing audio: %w", err)
+		}
+
+		if err := enc.Fallback(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("hardware encoder failed mid-run: %v", err))
+		}
+		if retries := enc.Retries(); len(retries) > 0 {
+			encoderRetries = len(retries)
+			warnings = append(warnings, retryWarning(retries))
+		}
+		if unused := enc.UnusedOptions(); len(unused) > 0 {
+			warnings = append(warnings, fmt.Sprintf("--encoder-opts: %s ignored %s, which it does not recognise", enc.EncoderName(), strings.Join(unused, ", ")))
+		}
+
+		if !joining {
+			if err := enc.AddMetadata(provenance); err != nil {
+				warnings = append(warnings, fmt.Sprintf("could not record the render's provenance: %v", err))
+			}
+		}
+		if err := enc.Close(); err != nil {
+			return fail("error closing encoder: %w", err)
+		}
+	}
+
+	// The segments are muxed with the audio once it is all encoded.
+	if joining && cfg.controls.Cancelled() == ui.CancelNone {
+		t0 := time.Now()
+		err := encoder.Join(encoder.Config{
+			OutputPath: cfg.outputFile,
+			Chapters:   cfg.chapters,
+			Metadata:   append(slices.Clip(cfg.tags), provenance),
+			Format:     cfg.format,
+		}, cfg.part.join, encPath)
+		if err != nil {
+			return fail("joining the segments: %w", err)
+		}
+		totalEncode += time.Since(t0)
+	}
+
+	if wavWriter != nil {
+		err := wavWriter.Close()
+		wavWriter = nil
+		if err != nil {
+			return fail("error closing %s: %w", frames.AudioFile, err)
+		}
+	}
+
+	if featuresOut != nil {
+		if err := featuresOut.Close(); err != nil {
+			return fail("error closing --export-features: %w", err)
+		}
+	}
+
+	if audioEnc != nil {
+		if err := audioEnc.FlushAudioEncoder(); err != nil {
+			return fail("error flushing --audio-out: %w", err)
+		}
+		if retries := audioEnc.Retries(); len(retries) > 0 {
+			warnings = append(warnings, "--audio-out: "+retryWarning(retries))
+		}
+		if err := audioEnc.Close(); err != nil {
+			return fail("error closing --audio-out: %w", err)
+		}
+	}
+
+	// A stopped encode is finalised above like a finished one, so the partial
+	// video plays; an aborted one is then removed. Streams and segmented
+	// outputs cannot be taken back, so they are always kept, as are frames
+	// already written to --frames-dir.
+	outputFile := cfg.outputFile
+	if enc == nil {
+		outputFile = cfg.frames.dir
+	}
+	if mode := cfg.controls.Cancelled(); mode != ui.CancelNone {
+		// Joining writes the output only at the end, so a stopped join has
+		// none to finalise.
+		discard := (mode == ui.CancelDiscard || joining) && enc != nil && cfg.outputFile != encoder.StdoutPath && !segmentedFormat(cfg.outputFile, cfg.format)
+		if discard && !joining {
+			if err := os.Remove(cfg.outputFile); err != nil {
+				warnings = append(warnings, fmt.Sprintf("could not remove %s: %v", cfg.outputFile, err))
+				discard = false
+			}
+		}
+		if mode == ui.CancelDiscard && audioEnc != nil {
+			if err := os.Remove(cfg.audioOut); err != nil {
+				warnings = append(warnings, fmt.Sprintf("could not remove %s: %v", cfg.audioOut, err))
+			}
+		}
+		if mode == ui.CancelDiscard && featuresOut != nil {
+			if err := os.Remove(cfg.featuresOut); err != nil {
+				warnings = append(warnings, fmt.Sprintf("could not remove %s: %v", cfg.featuresOut, err))
+			}
+		}
+		p.Send(ui.RenderCancelled{
+			OutputFile:    outputFile,
+			Frames:        frameNum,
+			TotalFrames:   numFrames,
+			Discarded:     discard,
+			AssetWarnings: warnings,
+		})
+		return nil
 	}
 
-	fileInfo, err := os.Stat(cfg.outputFile)
 	var actualFileSize int64
-	if err == nil {
+	if enc == nil {
+		actualFileSize = frameWriter.Bytes()
+	} else if fileInfo, err := os.Stat(cfg.outputFile); err == nil {
 		actualFileSize = fileInfo.Size()
 	}
 
-	samplesProcessed := int64(profile.SampleRate) * int64(profile.Duration)
+	samplesProcessed := samplesRead
 
-	overallTotalTime := time.Since(cfg.overallStartTime)
+	overallTotalTime := time.Since(cfg.overallStartTime) - pausedTotal
 
 	p.Send(ui.RenderComplete{
-		OutputFile:       cfg.outputFile,
+		OutputFile:       outputFile,
 		FileSize:         actualFileSize,
 		TotalFrames:      numFrames,
-		VisTime:          totalVis,
-		EncodeTime:       totalEncode,
+		VisTime:          totalVis + cfg.part.visTime,
+		EncodeTime:       totalEncode + cfg.part.encodeTime,
 		AudioTime:        totalAudio,
 		TotalTime:        overallTotalTime,
 		ThumbnailTime:    cfg.thumbnailDuration,
 		SamplesProcessed: samplesProcessed,
-		EncoderName:      enc.EncoderName(),
-		EncoderIsHW:      enc.IsHardware(),
+		EncoderName:      encoderName(),
+		EncoderIsHW:      enc != nil && enc.IsHardware() || cfg.part.hardware,
+		EncoderRetries:   encoderRetries,
+		FrameTimings:     frameTimes.Summary(),
 		AssetWarnings:    warnings,
 	})
+	return nil
 }
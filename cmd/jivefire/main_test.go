@@ -0,0 +1,671 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/analysis"
+	"github.com/linuxmatters/jivefire/internal/audio"
+	"github.com/linuxmatters/jivefire/internal/chapters"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/encoder"
+	"github.com/linuxmatters/jivefire/internal/failure"
+	"github.com/linuxmatters/jivefire/internal/feed"
+	"github.com/linuxmatters/jivefire/internal/frames"
+	"github.com/linuxmatters/jivefire/internal/renderer"
+	"github.com/linuxmatters/jivefire/internal/report"
+	"github.com/linuxmatters/jivefire/internal/script"
+)
+
+func TestParseSection(t *testing.T) {
+	tests := []struct {
+		start, end, duration string
+		wantStart, wantLen   time.Duration
+	}{
+		{"", "", "", 0, 0},
+		{"05:00", "", "", 5 * time.Minute, 0},
+		{"05:00", "45:00", "", 5 * time.Minute, 40 * time.Minute},
+		{"", "1:00:00", "", 0, time.Hour},
+		{"00:05:00", "", "10m", 5 * time.Minute, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		start, length, err := parseSection(tt.start, tt.end, tt.duration)
+		if err != nil {
+			t.Errorf("parseSection(%q, %q, %q) error: %v", tt.start, tt.end, tt.duration, err)
+			continue
+		}
+		if start != tt.wantStart || length != tt.wantLen {
+			t.Errorf("parseSection(%q, %q, %q) = %v, %v; want %v, %v",
+				tt.start, tt.end, tt.duration, start, length, tt.wantStart, tt.wantLen)
+		}
+	}
+}
+
+func TestParseSectionErrors(t *testing.T) {
+	tests := []struct{ start, end, duration string }{
+		{"5 minutes", "", ""},
+		{"", "later", ""},
+		{"10:00", "05:00", ""},
+		{"10:00", "10:00", ""},
+		{"", "45:00", "10m"},
+		{"", "", "-5m"},
+		{"", "", "soon"},
+	}
+	for _, tt := range tests {
+		if _, _, err := parseSection(tt.start, tt.end, tt.duration); err == nil {
+			t.Errorf("parseSection(%q, %q, %q) succeeded, want error", tt.start, tt.end, tt.duration)
+		}
+	}
+}
+
+func TestParseDecibels(t *testing.T) {
+	for in, want := range map[string]float64{"-18dB": -18, "-18": -18, "-6.5 dB": -6.5, "3db": 3, "0": 0} {
+		if got, err := parseDecibels(in); err != nil || got != want {
+			t.Errorf("parseDecibels(%q) = %g, %v; want %g", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "dB", "loud", "-18dBFS"} {
+		if _, err := parseDecibels(in); err == nil {
+			t.Errorf("parseDecibels(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestOutputChannels(t *testing.T) {
+	tests := []struct {
+		flag, source int
+		surround     string
+		speed        float64
+		want         int
+	}{
+		{0, 1, "downmix", 1, 1},
+		{0, 2, "downmix", 1, 2},
+		{0, 6, "downmix", 1, 2},
+		{0, 6, "passthrough", 1, 6},
+		{0, 6, "passthrough", 2, 2},
+		{0, 2, "passthrough", 1, 2},
+		{1, 6, "passthrough", 1, 1},
+		{2, 1, "downmix", 1, 2},
+		{6, 2, "downmix", 1, 6},
+	}
+	for _, tt := range tests {
+		if got := outputChannels(tt.flag, tt.source, tt.surround, tt.speed); got != tt.want {
+			t.Errorf("outputChannels(%d, %d, %q, %g) = %d, want %d", tt.flag, tt.source, tt.surround, tt.speed, got, tt.want)
+		}
+	}
+}
+
+func TestOutputGain(t *testing.T) {
+	tests := []struct {
+		name                           string
+		gainDB, target, loudness, peak float64
+		wantDB                         float64
+		warns                          bool
+	}{
+		{"unchanged", 0, 0, -20, 0.5, 0, false},
+		{"gain", 6, 0, -20, 0.25, 6, false},
+		{"gain clips", 12, 0, -20, 0.5, 12, true},
+		{"cut", -6, 0, -20, 1, -6, false},
+		{"normalise up", 0, -16, -22, 0.25, 6, false},
+		{"normalise down", 0, -16, -12, 0.9, -4, false},
+		{"held at ceiling", 0, -16, -30, 0.5, -1 + 20*math.Log10(2), true},
+		{"silence", 0, -16, math.Inf(-1), 0, 0, true},
+	}
+	for _, tt := range tests {
+		gain, warning := outputGain(tt.gainDB, tt.target, tt.loudness, tt.peak)
+		if got := 20 * math.Log10(gain); math.Abs(got-tt.wantDB) > 1e-9 {
+			t.Errorf("%s: gain %.3f dB, want %.3f", tt.name, got, tt.wantDB)
+		}
+		if (warning != "") != tt.warns {
+			t.Errorf("%s: warning %q, want one: %v", tt.name, warning, tt.warns)
+		}
+	}
+}
+
+func TestAVOffsetSamples(t *testing.T) {
+	tests := []struct {
+		offset      time.Duration
+		step        int
+		lead, delay int
+	}{
+		{0, 1600, 0, 0},
+		{40 * time.Millisecond, 1600, 0, 1920},
+		{-40 * time.Millisecond, 1600, 1920, 0},
+		{-40 * time.Millisecond, 3200, 3840, 0}, // --speed=2 reads twice the input
+	}
+	for _, tt := range tests {
+		lead, delay := avOffsetSamples(tt.offset, tt.step)
+		if lead != tt.lead || delay != tt.delay {
+			t.Errorf("avOffsetSamples(%v, %d) = %d, %d; want %d, %d", tt.offset, tt.step, lead, delay, tt.lead, tt.delay)
+		}
+	}
+}
+
+func TestFaultWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		dc, clip float64
+		declip   bool
+		want     int
+	}{
+		{"clean", 0.001, 0, false, 0},
+		{"offset", -0.03, 0, false, 1},
+		{"clipped", 0, 0.01, false, 1},
+		{"clipped, declipped", 0, 0.01, true, 0},
+		{"both", 0.05, 0.01, false, 2},
+	}
+	for _, tt := range tests {
+		got := faultWarnings(&audio.Profile{DCOffset: tt.dc, ClippedFraction: tt.clip}, tt.declip)
+		if len(got) != tt.want {
+			t.Errorf("%s: warnings %q, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestRetryWarning verifies the warning lists the first retries and counts
+// the rest, and names an audio retry's stream.
+func TestRetryWarning(t *testing.T) {
+	busy := errors.New("send frame to encoder: Device or resource busy")
+	var retries []encoder.Retry
+	for i := range 5 {
+		retries = append(retries, encoder.Retry{Pts: int64(100 + i), Attempt: 1, Err: busy})
+	}
+	got := retryWarning(retries[:1])
+	if want := "the encoder retried a transient failure (frame 100, retry 1: send frame to encoder: Device or resource busy)"; got != want {
+		t.Errorf("one retry: %q, want %q", got, want)
+	}
+	got = retryWarning(retries)
+	if !strings.Contains(got, "retried 5 ") || !strings.Contains(got, "frame 102") || strings.Contains(got, "frame 103") || !strings.HasSuffix(got, "; and 2 more)") {
+		t.Errorf("five retries: %q, want three listed and two counted", got)
+	}
+	got = retryWarning([]encoder.Retry{{Audio: true, Pts: 48000, Attempt: 2, Err: busy}})
+	if want := "the encoder retried a transient failure (audio at sample 48000, retry 2: send frame to encoder: Device or resource busy)"; got != want {
+		t.Errorf("audio retry: %q, want %q", got, want)
+	}
+}
+
+// TestSavedProfile verifies a profile survives the analysis file, silence's
+// -Inf loudness included.
+func TestSavedProfile(t *testing.T) {
+	for _, loudness := range []float64{-16.5, math.Inf(-1)} {
+		p := &audio.Profile{NumFrames: 900, GlobalPeak: 0.8, TruePeak: 0.9, Loudness: loudness, DCOffset: -0.02, ClippedFraction: 0.003, OptimalBaseScale: 0.5, SampleRate: 48000, Duration: 30}
+		if got := loadProfile(saveProfile(p)); *got != *p {
+			t.Errorf("loadProfile(saveProfile(%+v)) = %+v", p, got)
+		}
+	}
+}
+
+// The self-test always tries libx264 first, then only the hardware encoders
+// that probed as working, each with the flags that select it for a render.
+func TestCheckAudioOut(t *testing.T) {
+	tests := []struct {
+		path, output string
+		ok           bool
+	}{
+		{"", "out.mp4", true},
+		{"episode.m4a", "episode.mp4", true},
+		{"Episode.AAC", "episode.mp4", true},
+		{"episode.opus", "episode.mp4", false},
+		{"episode", "episode.mp4", false},
+		{"s3://bucket/episode.m4a", "episode.mp4", false},
+		{"./episode.m4a", "episode.m4a", false},
+	}
+	for _, tt := range tests {
+		if err := checkAudioOut(tt.path, tt.output); (err == nil) != tt.ok {
+			t.Errorf("checkAudioOut(%q, %q) = %v, want ok %v", tt.path, tt.output, err, tt.ok)
+		}
+	}
+}
+
+func TestCheckAudioCodec(t *testing.T) {
+	tests := []struct {
+		codec          encoder.AudioCodec
+		output, format string
+		video          encoder.VideoCodec
+		ok             bool
+	}{
+		{encoder.AudioAAC, "episode.mp4", "", encoder.CodecH264, true},
+		{encoder.AudioOpus, "episode.mkv", "", encoder.CodecH264, true},
+		{encoder.AudioOpus, "Episode.WEBM", "", encoder.CodecAV1, true},
+		{encoder.AudioOpus, "episode.webm", "", encoder.CodecH264, false},
+		{encoder.AudioOpus, "episode.mp4", "", encoder.CodecH264, false},
+		{encoder.AudioOpus, "episode.mkv", "mpegts", encoder.CodecH264, false},
+		{encoder.AudioOpus, encoder.StdoutPath, "", encoder.CodecH264, false},
+		{encoder.AudioFLAC, "episode.mkv", "", encoder.CodecH264, true},
+		{encoder.AudioFLAC, "episode.webm", "", encoder.CodecAV1, false},
+		{encoder.AudioFLAC, "episode.mp4", "", encoder.CodecH264, false},
+	}
+	for _, tt := range tests {
+		if err := checkAudioCodec(tt.codec, tt.output, tt.format, tt.video); (err == nil) != tt.ok {
+			t.Errorf("checkAudioCodec(%s, %q, %q, %s) = %v, want ok %v", tt.codec, tt.output, tt.format, tt.video, err, tt.ok)
+		}
+	}
+}
+
+// TestCheckScale verifies --scale takes 0 and positive scales and refuses
+// negative and non-finite ones, and that a scale given replaces Pass 1's.
+func TestCheckScale(t *testing.T) {
+	for _, tt := range []struct {
+		scale float64
+		ok    bool
+	}{
+		{0, true},
+		{0.25, true},
+		{-0.5, false},
+		{math.NaN(), false},
+		{math.Inf(1), false},
+	} {
+		if err := checkScale(tt.scale); (err == nil) != tt.ok {
+			t.Errorf("checkScale(%g) = %v, want ok %v", tt.scale, err, tt.ok)
+		}
+	}
+
+	profile := &audio.Profile{OptimalBaseScale: 0.8}
+	if got := barScale(profile, 0); got != 0.8 {
+		t.Errorf("barScale() without --scale = %g, want Pass 1's 0.8", got)
+	}
+	if got := barScale(profile, 0.25); got != 0.25 {
+		t.Errorf("barScale() with --scale=0.25 = %g, want 0.25", got)
+	}
+}
+
+func TestCheckArchive(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "episode.flac")
+	if err := os.WriteFile(input, []byte("fLaC"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		output, format, input string
+		ok                    bool
+	}{
+		{"episode.mkv", "", input, true},
+		{"Episode.MKV", "", input, true},
+		{"episode.mp4", "", input, false},
+		{"episode.mkv", "mp4", input, false},
+		{encoder.StdoutPath, "", input, false},
+		{"episode.mkv", "", input + ".missing", false},
+	}
+	for _, tt := range tests {
+		if err := checkArchive(tt.output, tt.format, tt.input); (err == nil) != tt.ok {
+			t.Errorf("checkArchive(%q, %q, %q) = %v, want ok %v", tt.output, tt.format, tt.input, err, tt.ok)
+		}
+	}
+}
+
+// TestArchiveAttachments verifies an archive carries the source as it is,
+// typed by its extension, and the analysis beside it as JSON.
+func TestArchiveAttachments(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "Episode 42.MP3")
+	if err := os.WriteFile(input, []byte("ID3"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := archiveAttachments(input, analysis.File{Version: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("archiveAttachments() = %d attachments, want 2", len(got))
+	}
+	if a := got[0]; a.Name != "Episode 42.MP3" || a.MimeType != "audio/mpeg" || string(a.Data) != "ID3" {
+		t.Errorf("source attachment = %q, %q, %q", a.Name, a.MimeType, a.Data)
+	}
+	var saved analysis.File
+	if a := got[1]; a.Name != "Episode 42.analysis.json" || a.MimeType != "application/json" || json.Unmarshal(a.Data, &saved) != nil || saved.Format != analysis.FormatVersion {
+		t.Errorf("analysis attachment = %q, %q, %s", a.Name, a.MimeType, a.Data)
+	}
+}
+
+func TestSelftestCandidates(t *testing.T) {
+	got := selftestCandidates([]encoder.HWEncoder{
+		{Name: "h264_nvenc", Type: encoder.HWAccelNVENC, Codec: encoder.CodecH264, Available: true},
+		{Name: "h264_qsv", Type: encoder.HWAccelQSV, Codec: encoder.CodecH264},
+		{Name: "av1_nvenc", Type: encoder.HWAccelNVENC, Codec: encoder.CodecAV1, Available: true},
+	})
+	want := []struct{ name, flags string }{
+		{"libx264", "--encoder=software"},
+		{"h264_nvenc", "--encoder=nvenc"},
+		{"av1_nvenc", "--encoder=nvenc --video-codec=av1"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%d candidates, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].name != w.name || got[i].flags() != w.flags {
+			t.Errorf("candidate %d = %s (%s), want %s (%s)", i, got[i].name, got[i].flags(), w.name, w.flags)
+		}
+	}
+}
+
+// TestProvenanceTag verifies the provenance is a comment whose settings hash
+// follows what is encoded, not what is only previewed.
+func TestProvenanceTag(t *testing.T) {
+	settings := func(tag encoder.Tag) string {
+		t.Helper()
+		var doc map[string]report.Provenance
+		if err := json.Unmarshal([]byte(tag.Value), &doc); err != nil {
+			t.Fatalf("provenance %q: %v", tag.Value, err)
+		}
+		return doc[report.ProvenanceKey].Settings
+	}
+	cfg := &pass2Config{renderOptions: renderOptions{runtimeConfig: &config.RuntimeConfig{}, videoCodec: encoder.CodecH264, profile: encoder.ProfileFast}}
+	tag, err := provenanceTag(cfg, report.Encoder{Name: "libx264"}, report.Timings{Total: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Key != "comment" {
+		t.Errorf("key = %q, want comment", tag.Key)
+	}
+	base := settings(tag)
+
+	cfg.runtimeConfig.Guides = true
+	guides, _ := provenanceTag(cfg, report.Encoder{Name: "libx264"}, report.Timings{Total: 2})
+	if settings(guides) != base {
+		t.Error("--guides or the timings changed the settings hash")
+	}
+	cfg.profile = encoder.ProfileArchive
+	archive, _ := provenanceTag(cfg, report.Encoder{Name: "libx264"}, report.Timings{Total: 1})
+	if settings(archive) == base {
+		t.Error("--profile did not change the settings hash")
+	}
+}
+
+// TestParseGOP verifies --bframes reads auto as the encoder's own choice
+// and a count only if it fits in the GOP, and that an HLS or DASH GOP may
+// not outlast a segment.
+func TestParseGOP(t *testing.T) {
+	tests := []struct {
+		gop     int
+		bFrames string
+		output  string
+		want    int // -1 for nil
+		wantErr bool
+	}{
+		{0, "auto", "out.mp4", -1, false},
+		{0, "0", "out.mp4", 0, false},
+		{300, "3", "out.mkv", 3, false},
+		{0, "16", "out.mp4", 16, false},
+		{-1, "auto", "out.mp4", 0, true},
+		{0, "17", "out.mp4", 0, true},
+		{0, "some", "out.mp4", 0, true},
+		{4, "4", "out.mp4", 0, true},
+		{180, "auto", "out.m3u8", -1, false},
+		{181, "auto", "out.m3u8", 0, true},
+		{300, "auto", "out.mpd", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseGOP(tt.gop, tt.bFrames, tt.output, "", 6)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseGOP(%d, %q, %s) error = %v, want error %v", tt.gop, tt.bFrames, tt.output, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (tt.want < 0) != (got == nil) || got != nil && *got != tt.want {
+			t.Errorf("parseGOP(%d, %q, %s) = %v, want %d", tt.gop, tt.bFrames, tt.output, got, tt.want)
+		}
+	}
+}
+
+// TestChapterKeyframes verifies each chapter start maps to its frame,
+// counted from a segment's first frame, without the chapters before it or
+// two on one frame.
+func TestChapterKeyframes(t *testing.T) {
+	list := []chapters.Chapter{
+		{Title: "Intro", Start: 0},
+		{Title: "News", Start: 2*time.Minute + 15*time.Second},
+		{Title: "Close by", Start: 2*time.Minute + 15*time.Second + 10*time.Millisecond},
+		{Title: "Feedback", Start: time.Hour + 4*time.Minute + 30*time.Second + 500*time.Millisecond},
+	}
+	news, feedback := int64(135*config.FPS), int64(3870.5*config.FPS)
+	if got, want := chapterKeyframes(list, 0), []int64{0, news, feedback}; !slices.Equal(got, want) {
+		t.Errorf("chapterKeyframes() = %v, want %v", got, want)
+	}
+	if got, want := chapterKeyframes(list, 1000), []int64{news - 1000, feedback - 1000}; !slices.Equal(got, want) {
+		t.Errorf("chapterKeyframes() from frame 1000 = %v, want %v", got, want)
+	}
+	if got := chapterKeyframes(nil, 0); got != nil {
+		t.Errorf("chapterKeyframes(nil) = %v, want none", got)
+	}
+}
+
+func TestContainerTags(t *testing.T) {
+	tagMap := func(tags []encoder.Tag) map[string]string {
+		m := make(map[string]string)
+		for _, tag := range tags {
+			m[tag.Key] = tag.Value
+		}
+		return m
+	}
+
+	if tags := containerTags(&textFlags{Title: defaultTitle}); len(tags) != 0 {
+		t.Errorf("placeholder title gave tags %v", tags)
+	}
+
+	episode := 67
+	got := tagMap(containerTags(&textFlags{
+		Title:   "Terminal Velocity",
+		Episode: &episode,
+		feedEpisode: &feed.Episode{
+			Show:      "Linux Matters",
+			Author:    "Linux Matters",
+			Published: time.Date(2025, 10, 7, 7, 0, 0, 0, time.UTC),
+		},
+	}))
+	want := map[string]string{
+		"title":        "Terminal Velocity",
+		"episode_sort": "67",
+		"show":         "Linux Matters",
+		"album":        "Linux Matters",
+		"artist":       "Linux Matters",
+		"date":         "2025-10-07",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+// The generated label overlay must load and run through every segment
+// without a script error, which would silently drop the labels.
+func TestTestLabelScript(t *testing.T) {
+	signal := audio.NewTestSignal(testSampleRate, 20, 20000, 4*time.Second, 2*time.Second)
+	path := filepath.Join(t.TempDir(), "labels.lua")
+	if err := os.WriteFile(path, []byte(testLabelScript(signal)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := script.Load(path, renderer.LoadFont)
+	if err != nil {
+		t.Fatalf("loading the label script: %v", err)
+	}
+	defer s.Close()
+	img := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	bars := make([]float64, config.NumBars)
+	for at := time.Duration(0); at < signal.Duration(); at += 500 * time.Millisecond {
+		s.Process(bars, at)
+		s.Draw(img)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("label script failed: %v", err)
+	}
+}
+
+func TestParseSegment(t *testing.T) {
+	if index, count, err := parseSegment("2/4"); err != nil || index != 2 || count != 4 {
+		t.Errorf("parseSegment(2/4) = %d, %d, %v; want 2, 4", index, count, err)
+	}
+	for _, s := range []string{"", "2", "0/4", "5/4", "1/1", "a/4", "2/4x", "1/65"} {
+		if _, _, err := parseSegment(s); err == nil {
+			t.Errorf("parseSegment(%q) succeeded, want error", s)
+		}
+	}
+}
+
+// TestSegmentBounds verifies the segments cover every frame once, in order.
+func TestSegmentBounds(t *testing.T) {
+	for _, tt := range []struct{ frames, count int }{{108000, 4}, {1001, 3}, {5, 8}} {
+		next := 0
+		for index := 1; index <= tt.count; index++ {
+			first, end := segmentBounds(tt.frames, index, tt.count)
+			if first != next || end < first {
+				t.Errorf("segmentBounds(%d, %d, %d) = %d, %d; want a segment from %d", tt.frames, index, tt.count, first, end, next)
+			}
+			next = end
+		}
+		if next != tt.frames {
+			t.Errorf("%d segments of %d frames end at %d", tt.count, tt.frames, next)
+		}
+	}
+}
+
+// TestWorkerArgs verifies a worker renders the coordinator's command line
+// with its own segment and analysis in place of --segments and --analysis.
+func TestWorkerArgs(t *testing.T) {
+	got := workerArgs([]string{"render", "--segments", "4", "--analysis=old.json", "--title=Show", "in.wav", "out.mp4"}, 2, 4, "tmp/analysis.json", "tmp/segment-2.mp4")
+	want := []string{"render", "--title=Show", "in.wav", "out.mp4", "--segment=2/4", "--analysis=tmp/analysis.json", "--segment-worker=tmp/segment-2.mp4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("workerArgs() = %q, want %q", got, want)
+	}
+
+	got = workerArgs([]string{"--segments=2", "--", "-in.wav", "out.mp4"}, 1, 2, "a.json", "s.mp4")
+	want = []string{"--segment=1/2", "--analysis=a.json", "--segment-worker=s.mp4", "--", "-in.wav", "out.mp4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("workerArgs() with -- = %q, want %q", got, want)
+	}
+}
+
+// TestCurrentBuild verifies jivefire version --json lists libx264 first,
+// then the hardware encoders with their backends and availability.
+func TestCurrentBuild(t *testing.T) {
+	info := currentBuild([]encoder.HWEncoder{
+		{Name: "h264_nvenc", Type: encoder.HWAccelNVENC, Codec: encoder.CodecH264, Available: true},
+		{Name: "av1_vaapi", Type: encoder.HWAccelVAAPI, Codec: encoder.CodecAV1},
+	})
+	if info.Version != version || info.GoVersion == "" || info.OS == "" {
+		t.Errorf("currentBuild() = %+v, want the version and platform", info)
+	}
+	want := []encoderInfo{
+		{Name: "libx264", Backend: "software", Codec: "h264", Available: true},
+		{Name: "h264_nvenc", Backend: "nvenc", Codec: "h264", Hardware: true, Available: true},
+		{Name: "av1_vaapi", Backend: "vaapi", Codec: "av1", Hardware: true},
+	}
+	if !slices.Equal(info.Encoders, want) {
+		t.Errorf("encoders = %+v, want %+v", info.Encoders, want)
+	}
+}
+
+// TestStudioSettings verifies the studio's form values become the render
+// flags that reproduce them, defaults left out, and fonts it was not given
+// are refused.
+func TestStudioSettings(t *testing.T) {
+	q := url.Values{
+		"title":           {"Terminal Velocity"},
+		"episode":         {"67"},
+		"bar-color":       {"#A40000"},
+		"text-color":      {"#ffffff"},
+		"bar-color-mode":  {"reactive"},
+		"title-align":     {"centre"},
+		"particles":       {"on"},
+		"background-dim":  {"0.4"},
+		"background-blur": {"0"},
+		"font":            {"/fonts/Inter.ttf"},
+	}
+	s, err := parseStudioSettings(q, []string{"/fonts/Inter.ttf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(s.flags(backgroundFlags{}), " ")
+	want := "--title='Terminal Velocity' --episode=67 --text-color=#ffffff --bar-color-mode=reactive --particles --font=/fonts/Inter.ttf --background-dim=0.4"
+	if got != want {
+		t.Errorf("flags = %s\nwant %s", got, want)
+	}
+	if rc := s.runtimeConfig(backgroundFlags{}); !rc.Particles || rc.TextColor != (config.OptionalColor{R: 255, G: 255, B: 255, Set: true}) {
+		t.Errorf("runtimeConfig() = %+v, want particles and white text", rc)
+	}
+
+	if _, err := parseStudioSettings(q, nil); err == nil {
+		t.Error("a font not given to the studio was accepted")
+	}
+	q.Set("background-blur", "1000")
+	if _, err := parseStudioSettings(q, []string{"/fonts/Inter.ttf"}); err == nil {
+		t.Error("a blur beyond --background-blur's range was accepted")
+	}
+}
+
+// TestNativeDecoder verifies --decoder=native reads the input's metadata and
+// samples without FFmpeg, and refuses a file it cannot decode.
+func TestNativeDecoder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in.wav")
+	w, err := frames.CreateWAV(path, 48000, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(make([]float32, 2*4800)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rc := &config.RuntimeConfig{NativeDecoder: true}
+	metadata, err := inputMetadata(path, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := audio.Metadata{NumSamples: 4800, SampleRate: 48000, Codec: "pcm_s16le", Channels: 2, BitDepth: 16, Duration: 100 * time.Millisecond}
+	if *metadata != want {
+		t.Errorf("inputMetadata() = %+v, want %+v", *metadata, want)
+	}
+
+	source, err := openAudio(path, 50*time.Millisecond, 0, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	if n, _ := source.ReadInto(make([]float64, 4800)); n != 2400 {
+		t.Errorf("read %d samples from 50ms, want 2400", n)
+	}
+
+	text := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(text, []byte("show notes"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inputMetadata(text, rc); !errors.Is(err, failure.ErrUnsupportedFormat) {
+		t.Errorf("inputMetadata() of a text file = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+// stuckSource is an audio.Source that cannot seek, counting its Closes.
+type stuckSource struct {
+	audio.Source
+	closes int
+}
+
+func (s *stuckSource) Seek(time.Duration) error { return errors.New("not seekable") }
+func (s *stuckSource) Close() error             { s.closes++; return nil }
+
+// TestRewindReader verifies that a reader which cannot seek is closed once
+// and, when reopening it fails too, that nil is returned in its place.
+func TestRewindReader(t *testing.T) {
+	stuck := &stuckSource{}
+	reopenErr := errors.New("gone")
+	got, err := rewindReader(stuck, 0, func() (audio.Source, error) { return stuck, reopenErr })
+	if !errors.Is(err, reopenErr) || got != nil {
+		t.Errorf("rewindReader() = %v, %v; want nil, %v", got, err, reopenErr)
+	}
+	if stuck.closes != 1 {
+		t.Errorf("reader closed %d times, want 1", stuck.closes)
+	}
+
+	fresh := &stuckSource{}
+	if got, err := rewindReader(&stuckSource{}, 0, func() (audio.Source, error) { return fresh, nil }); err != nil || got != fresh {
+		t.Errorf("rewindReader() = %v, %v; want the reopened source", got, err)
+	}
+}
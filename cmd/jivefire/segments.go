@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/linuxmatters/jivefire/internal/analysis"
+	"github.com/linuxmatters/jivefire/internal/audio"
+	"github.com/linuxmatters/jivefire/internal/cli"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/encoder"
+	"github.com/linuxmatters/jivefire/internal/ui"
+)
+
+// A split render draws and encodes the video in segments, each in its own
+// process and perhaps on its own machine, and encodes the audio once to mux
+// with them. Every segment renders from the same saved analysis, so the bars
+// scale alike throughout, and starts drawing a warm-up before its first frame
+// so the bar smoothing has settled as it would have in a whole render.
+const (
+	maxSegments   = 64
+	segmentWarmup = 20 * time.Second // Longer than the sensitivity takes to adapt to a level
+	segmentTagKey = "comment"
+)
+
+// splitRender is a render's part in a split render: --segments runs the
+// workers and joins their segments, --segment renders one segment and --join
+// muxes segments rendered elsewhere.
+type splitRender struct {
+	workers int      // --segments
+	index   int      // --segment K/N, K from 1
+	count   int      // --segment K/N, N; 0 for a whole render
+	join    []string // --join
+	worker  bool     // Run by --segments; reports progress on stdout
+}
+
+// renderPart is what runPass2 renders of a split render.
+type renderPart struct {
+	first     int  // Index in the episode of the first frame drawn
+	warmup    int  // Frames drawn, and not encoded, before the segment
+	total     int  // Frames in the whole episode; 0 for a whole render
+	videoOnly bool // A segment carries no audio
+
+	// join lists the segments to mux with the audio, in place of drawing.
+	// The rest describe how the segments were rendered, for the summary.
+	join        []string
+	quiet       bool // The workers reported the progress
+	encoderName string
+	hardware    bool
+	visTime     time.Duration
+	encodeTime  time.Duration
+	warnings    []string
+}
+
+// parseSplit validates the --segments, --segment and --join flags.
+func parseSplit(cmd *renderCmd) (splitRender, error) {
+	split := splitRender{workers: cmd.Segments, join: cmd.Join, worker: cmd.SegmentWorker != ""}
+	modes := 0
+	for _, set := range []bool{cmd.Segments != 0, cmd.Segment != "", len(cmd.Join) > 0} {
+		if set {
+			modes++
+		}
+	}
+	if modes == 0 {
+		return split, nil
+	}
+	if modes > 1 {
+		return split, errors.New("--segments, --segment and --join cannot be combined")
+	}
+
+	flag := "--segments"
+	switch {
+	case cmd.Segment != "":
+		flag = "--segment"
+		var err error
+		if split.index, split.count, err = parseSegment(cmd.Segment); err != nil {
+			return split, fmt.Errorf("invalid --segment: %w", err)
+		}
+	case len(cmd.Join) > 0:
+		flag = "--join"
+	case cmd.Segments < 2 || cmd.Segments > maxSegments:
+		return split, fmt.Errorf("invalid --segments: %d (must be between 2 and %d)", cmd.Segments, maxSegments)
+	}
+	if flag != "--segments" && cmd.Analysis == "" {
+		return split, fmt.Errorf("%s needs --analysis, saved once by jivefire analyze, so every segment scales its bars alike", flag)
+	}
+	if cmd.Output == encoder.StdoutPath || segmentedFormat(cmd.Output, cmd.Format) || cmd.Format == "mpegts" ||
+		(cmd.Format == "" && strings.EqualFold(filepath.Ext(cmd.Output), ".ts")) {
+		return split, fmt.Errorf("%s writes an MP4 file, not a stream, HLS, DASH or MPEG-TS", flag)
+	}
+
+	for _, unsupported := range []struct {
+		set   bool
+		flags string
+		modes string // The modes that refuse it
+	}{
+		{cmd.FramesDir != "" || cmd.FramesOnly, "--frames-dir", "--segments --segment --join"},
+		{cmd.Thumbnails > 0, "--thumbnails", "--segments --segment --join"},
+		{cmd.ExportFeatures != "", "--export-features", "--segments --segment --join"},
+		{cmd.Play, "--play", "--segments --segment --join"},
+		{cmd.Archive, "--archive", "--segments --segment --join"},
+		// Seeking to a segment would start the bed and bumpers again.
+		{cmd.Music != "" || cmd.Intro != "" || cmd.Outro != "", "--music, --intro and --outro", "--segments --segment"},
+		{cmd.WriteDescription || cmd.AudioOut != "", "--write-description and --audio-out", "--segment"},
+	} {
+		if unsupported.set && slices.Contains(strings.Fields(unsupported.modes), flag) {
+			return split, fmt.Errorf("%s cannot be combined with %s", unsupported.flags, flag)
+		}
+	}
+	return split, nil
+}
+
+// parseSegment parses --segment K/N.
+func parseSegment(s string) (index, count int, err error) {
+	k, n, ok := strings.Cut(s, "/")
+	index, kErr := strconv.Atoi(k)
+	count, nErr := strconv.Atoi(n)
+	if !ok || kErr != nil || nErr != nil {
+		return 0, 0, fmt.Errorf("%q (use K/N, e.g. 2/4 for the second of four)", s)
+	}
+	if count < 2 || count > maxSegments || index < 1 || index > count {
+		return 0, 0, fmt.Errorf("%q (N must be between 2 and %d, and K between 1 and N)", s, maxSegments)
+	}
+	return index, count, nil
+}
+
+// segmentBounds returns the frames [first, end) of segment index of count
+// in a render of frames.
+func segmentBounds(frames, index, count int) (first, end int) {
+	return frames * (index - 1) / count, frames * index / count
+}
+
+// segmentTag is the tag marking a segment file, for --join to check.
+func segmentTag(index, count int) string {
+	return fmt.Sprintf("jivefire segment %d/%d", index, count)
+}
+
+// checkJoin verifies paths are every segment of one split render, in order.
+func checkJoin(paths []string) error {
+	for i, path := range paths {
+		tag, err := encoder.ReadTag(path, segmentTagKey)
+		if err != nil {
+			return err
+		}
+		spec, ok := strings.CutPrefix(tag, "jivefire segment ")
+		index, count, err := parseSegment(spec)
+		if !ok || err != nil {
+			return fmt.Errorf("%s was not rendered with --segment", path)
+		}
+		if count != len(paths) {
+			return fmt.Errorf("%s is segment %d of %d, but %d segments were given", path, index, count, len(paths))
+		}
+		if index != i+1 {
+			return fmt.Errorf("%s is segment %d of %d, given as segment %d; list the segments in order", path, index, count, i+1)
+		}
+	}
+	return nil
+}
+
+// workerArgs returns the command line of a --segments worker: the
+// coordinator's own, rendering segment index of count from analysisPath into
+// out. The flags go before any "--", which ends them.
+func workerArgs(args []string, index, count int, analysisPath, out string) []string {
+	var kept []string
+	rest := len(args)
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			rest = i
+			break
+		}
+		name, _, hasValue := strings.Cut(args[i], "=")
+		if name == "--segments" || name == "--analysis" {
+			if !hasValue {
+				i++
+			}
+			continue
+		}
+		kept = append(kept, args[i])
+	}
+	kept = append(kept,
+		fmt.Sprintf("--segment=%d/%d", index, count),
+		"--analysis="+analysisPath,
+		"--segment-worker="+out,
+	)
+	return append(kept, args[rest:]...)
+}
+
+// workerStatus is a line of a worker's progress on stdout; the last, with
+// Done, also describes how the segment was rendered.
+type workerStatus struct {
+	Frames     int      `json:"frames"`
+	Bytes      int64    `json:"bytes"`
+	Done       bool     `json:"done,omitempty"`
+	Encoder    string   `json:"encoder,omitempty"`
+	Hardware   bool     `json:"hardware,omitempty"`
+	VisTime    float64  `json:"vis_seconds,omitempty"`
+	EncodeTime float64  `json:"encode_seconds,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// workerModel stands in for the progress display in a worker, writing each
+// update as a line of JSON for the coordinator.
+type workerModel struct {
+	out    *json.Encoder
+	warmup int // Frames drawn before the segment, left out of the counts
+}
+
+func newWorkerModel(out io.Writer, warmup int) *workerModel {
+	return &workerModel{out: json.NewEncoder(out), warmup: warmup}
+}
+
+func (m *workerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *workerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ui.RenderProgress:
+		_ = m.out.Encode(workerStatus{Frames: max(msg.Frame-m.warmup, 0), Bytes: msg.FileSize})
+	case ui.RenderComplete:
+		_ = m.out.Encode(workerStatus{
+			Frames:     max(msg.TotalFrames-m.warmup, 0),
+			Bytes:      msg.FileSize,
+			Done:       true,
+			Encoder:    msg.EncoderName,
+			Hardware:   msg.EncoderIsHW,
+			VisTime:    msg.VisTime.Seconds(),
+			EncodeTime: msg.EncodeTime.Seconds(),
+			Warnings:   msg.AssetWarnings,
+		})
+		return m, tea.Quit
+	case ui.RenderCancelled:
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m *workerModel) View() tea.View {
+	return tea.NewView("")
+}
+
+// followCoordinator applies the pause and resume a worker's coordinator
+// sends on stdin, and stops the render, discarding it, once the coordinator
+// has gone.
+func followCoordinator(r io.Reader, controls *ui.Controls) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		switch scanner.Text() {
+		case "pause":
+			controls.SetPaused(true)
+		case "resume":
+			controls.SetPaused(false)
+		}
+	}
+	controls.Cancel(ui.CancelDiscard)
+}
+
+// segmentWorker is a running worker process and the progress it last sent.
+type segmentWorker struct {
+	path   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+
+	mu     sync.Mutex
+	status workerStatus
+}
+
+func (w *segmentWorker) last() workerStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+// segmentResult is how a worker exited.
+type segmentResult struct {
+	index int
+	err   error
+}
+
+// runSegments renders the video in workers processes, each this program run
+// with --segment on saved, then joins their segments with the audio in a
+// join pass. Every worker is stopped when one fails or the user cancels; a
+// split render cannot be finalised part-way, so a stopped one is discarded.
+func runSegments(p *tea.Program, profile *audio.Profile, saved analysis.File, cfg pass2Config, workers int) error {
+	fail := func(format string, args ...any) error {
+		err := fmt.Errorf(format, args...)
+		cli.PrintError(err.Error())
+		p.Quit()
+		return err
+	}
+
+	// The segments are written beside the output, on the disk it will take.
+	dir, err := os.MkdirTemp(filepath.Dir(cfg.outputFile), ".jivefire-segments-")
+	if err != nil {
+		return fail("creating the segment directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	analysisPath := filepath.Join(dir, "analysis.json")
+	if err := analysis.Write(analysisPath, saved); err != nil {
+		return fail("saving the analysis for the segments: %w", err)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fail("finding jivefire to run the segments: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := make(chan segmentResult, workers)
+	running := make([]*segmentWorker, 0, workers)
+	for index := range workers {
+		w := &segmentWorker{path: filepath.Join(dir, fmt.Sprintf("segment-%d.mp4", index+1))}
+		w.cmd = exec.CommandContext(ctx, self, workerArgs(os.Args[1:], index+1, workers, analysisPath, w.path)...) //nolint:gosec // this program, with the user's own arguments
+		w.cmd.Stderr = &w.stderr
+		stdout, err := w.cmd.StdoutPipe()
+		if err == nil {
+			w.stdin, err = w.cmd.StdinPipe()
+		}
+		if err == nil {
+			err = w.cmd.Start()
+		}
+		if err != nil {
+			cancel()
+			for range running {
+				<-results
+			}
+			return fail("starting segment %d of %d: %w", index+1, workers, err)
+		}
+		running = append(running, w)
+		go func() {
+			scanner := bufio.NewScanner(stdout)
+			for scanner.Scan() {
+				var status workerStatus
+				if json.Unmarshal(scanner.Bytes(), &status) == nil {
+					w.mu.Lock()
+					w.status = status
+					w.mu.Unlock()
+				}
+			}
+			err := w.cmd.Wait()
+			if err == nil && !w.last().Done {
+				err = errors.New("stopped before the end of the segment")
+			}
+			results <- segmentResult{index: index, err: err}
+		}()
+	}
+
+	numFrames := profile.NumFrames
+	videoCodec := fmt.Sprintf("%s %d×%d", cfg.videoCodec.DisplayName(), config.Width, config.Height)
+	audioCodec := fmt.Sprintf("%s %.1f㎑ %s", cfg.audioCodec.DisplayName(), float64(cfg.audioCodec.SampleRate(cfg.reader.SampleRate()))/1000.0, encoder.ChannelLayoutName(cfg.channels))
+	barHeights := make([]float64, config.NumBars)
+	started := time.Now()
+	var pausedTotal time.Duration
+	var pausedAt time.Time
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for finished := 0; finished < workers; {
+		select {
+		case r := <-results:
+			finished++
+			if r.err != nil {
+				cancel()
+				for ; finished < workers; finished++ {
+					<-results
+				}
+				message := strings.TrimSpace(running[r.index].stderr.String())
+				if message != "" {
+					message = ": " + message
+				}
+				return fail("segment %d of %d failed (%v)%s", r.index+1, workers, r.err, message)
+			}
+		case <-ticker.C:
+			if cfg.controls.Cancelled() != ui.CancelNone {
+				cancel()
+				for ; finished < workers; finished++ {
+					<-results
+				}
+				frames := 0
+				for _, w := range running {
+					frames += w.last().Frames
+				}
+				p.Send(ui.RenderCancelled{OutputFile: cfg.outputFile, Frames: frames, TotalFrames: numFrames, Discarded: true})
+				return nil
+			}
+			// Pausing is passed on to the workers, which pause between frames.
+			if paused := cfg.controls.Paused(); paused != !pausedAt.IsZero() {
+				line := "resume\n"
+				if paused {
+					line = "pause\n"
+					pausedAt = time.Now()
+				} else {
+					pausedTotal += time.Since(pausedAt)
+					pausedAt = time.Time{}
+				}
+				for _, w := range running {
+					_, _ = io.WriteString(w.stdin, line)
+				}
+			}
+			if !pausedAt.IsZero() {
+				continue
+			}
+			var frames int
+			var size int64
+			for _, w := range running {
+				status := w.last()
+				frames += status.Frames
+				size += status.Bytes
+			}
+			p.Send(ui.RenderProgress{
+				Frame:       frames,
+				TotalFrames: numFrames,
+				Elapsed:     time.Since(started) - pausedTotal,
+				BarHeights:  barHeights,
+				FileSize:    size,
+				VideoCodec:  videoCodec,
+				AudioCodec:  audioCodec,
+				EncoderName: fmt.Sprintf("%d segments", workers),
+			})
+		}
+	}
+	for _, w := range running {
+		_ = w.stdin.Close()
+	}
+
+	// The segments' wall time is shared between drawing and encoding as the
+	// workers spent theirs.
+	part := renderPart{quiet: true}
+	var vis, enc float64
+	for _, w := range running {
+		status := w.last()
+		part.join = append(part.join, w.path)
+		vis += status.VisTime
+		enc += status.EncodeTime
+		for _, warning := range status.Warnings {
+			if !slices.Contains(part.warnings, warning) {
+				part.warnings = append(part.warnings, warning)
+			}
+		}
+	}
+	first := running[0].last()
+	part.encoderName = fmt.Sprintf("%s ×%d", first.Encoder, workers)
+	part.hardware = first.Hardware
+	if wall := time.Since(started) - pausedTotal; vis+enc > 0 {
+		part.visTime = time.Duration(float64(wall) * vis / (vis + enc))
+		part.encodeTime = wall - part.visTime
+	}
+	cfg.part = part
+	return runPass2(p, profile, cfg)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/cli"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/encoder"
+	"github.com/linuxmatters/jivefire/internal/renderer"
+)
+
+// selftestSeconds is how much video jivefire selftest encodes per encoder:
+// long enough for a hardware encoder's start-up cost not to dominate its
+// speed, short enough to try every encoder in well under a minute.
+const selftestSeconds = 5
+
+type selftestCmd struct{}
+
+// selftestCandidate is one encoder for jivefire selftest to try.
+type selftestCandidate struct {
+	name  string
+	desc  string
+	accel encoder.HWAccelType
+	codec encoder.VideoCodec
+}
+
+// flags returns the render flags that select the candidate's encoder.
+func (c selftestCandidate) flags() string {
+	if c.accel == encoder.HWAccelNone {
+		return "--encoder=software"
+	}
+	f := "--encoder=" + string(c.accel)
+	if c.codec == encoder.CodecAV1 {
+		f += " --video-codec=av1"
+	}
+	return f
+}
+
+// selftestCandidates returns libx264 followed by every available hardware
+// encoder in priority order.
+func selftestCandidates(hw []encoder.HWEncoder) []selftestCandidate {
+	candidates := []selftestCandidate{{
+		name:  "libx264",
+		desc:  "Software",
+		accel: encoder.HWAccelNone,
+		codec: encoder.CodecH264,
+	}}
+	for _, enc := range hw {
+		if enc.Available {
+			candidates = append(candidates, selftestCandidate{
+				name:  enc.Name,
+				desc:  enc.Description,
+				accel: enc.Type,
+				codec: enc.Codec,
+			})
+		}
+	}
+	return candidates
+}
+
+// runSelftest encodes synthetic bars through every available encoder,
+// checks each output decodes to the frames sent, and reports the speeds.
+// It exits non-zero when any encoder fails.
+func runSelftest(_ *selftestCmd) {
+	if !selftest() {
+		os.Exit(1)
+	}
+}
+
+func selftest() bool {
+	candidates := selftestCandidates(encoder.DetectHWEncoders())
+
+	dir, err := os.MkdirTemp("", "jivefire-selftest-")
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("creating a temporary directory: %v", err))
+		return false
+	}
+	defer os.RemoveAll(dir)
+
+	// The embedded background and font are always present; without them the
+	// test still exercises the encoders, on plainer frames.
+	runtimeConfig := &config.RuntimeConfig{}
+	meta := renderer.PodcastMeta{Title: "Jivefire Self-Test"}
+	bgImage, _ := renderer.LoadBackgroundImage(runtimeConfig)
+	fontFace, _ := renderer.LoadTitleFont(meta.Title, runtimeConfig)
+	frame := renderer.NewFrame(bgImage, fontFace, meta, runtimeConfig)
+
+	cli.PrintSelftestHeader(selftestSeconds)
+	results := make([]cli.SelftestResult, 0, len(candidates))
+	passed := true
+	for i, c := range candidates {
+		path := filepath.Join(dir, fmt.Sprintf("%d-%s.mp4", i, c.name))
+		fps, err := selftestEncoder(c, frame, path)
+		r := cli.SelftestResult{
+			Name:        c.name,
+			Description: c.desc,
+			Flags:       c.flags(),
+			FPS:         fps,
+			Realtime:    fps / config.FPS,
+			Err:         err,
+		}
+		cli.PrintSelftestResult(r)
+		results = append(results, r)
+		passed = passed && err == nil
+		_ = os.Remove(path)
+	}
+	cli.PrintSelftestSummary(results)
+	return passed
+}
+
+// selftestEncoder encodes selftestSeconds of synthetic bars through c into
+// path, decodes the result, and returns the frames encoded per second. Only
+// time spent in the encoder counts, not drawing the frames.
+func selftestEncoder(c selftestCandidate, frame *renderer.Frame, path string) (float64, error) {
+	enc, err := encoder.New(encoder.Config{
+		OutputPath: path,
+		Width:      config.Width,
+		Height:     config.Height,
+		Framerate:  config.FPS,
+		HWAccel:    c.accel,
+		VideoCodec: c.codec,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := enc.Initialize(); err != nil {
+		return 0, err
+	}
+	closed := false
+	defer func() {
+		if !closed {
+			_ = enc.Close()
+		}
+	}()
+	// A hardware device that fails to open falls back to libx264 silently,
+	// which would pass off the software speed as the hardware's.
+	if got := enc.EncoderName(); got != c.name {
+		return 0, fmt.Errorf("could not start, %s ran instead", got)
+	}
+
+	total := selftestSeconds * config.FPS
+	heights := make([]float64, config.NumBars)
+	var encodeTime time.Duration
+	for n := range total {
+		selftestBars(heights, n)
+		frame.Draw(heights)
+		t0 := time.Now()
+		if err := enc.WriteFrameRGBA(frame.GetImage().Pix); err != nil {
+			return 0, fmt.Errorf("encoding frame %d: %w", n, err)
+		}
+		encodeTime += time.Since(t0)
+	}
+	if err := enc.Fallback(); err != nil {
+		return 0, err
+	}
+	t0 := time.Now()
+	closed = true
+	if err := enc.Close(); err != nil {
+		return 0, fmt.Errorf("finishing the output: %w", err)
+	}
+	encodeTime += time.Since(t0)
+
+	frames, err := encoder.CountVideoFrames(path)
+	if err != nil {
+		return 0, fmt.Errorf("output does not decode: %w", err)
+	}
+	if frames != total {
+		return 0, fmt.Errorf("decoded %d of %d frames", frames, total)
+	}
+	return float64(total) / encodeTime.Seconds(), nil
+}
+
+// selftestBars fills heights, in pixels, with frame n of a wave rolling
+// across the bars, so every frame differs as it would in a real render.
+func selftestBars(heights []float64, n int) {
+	maxHeight := float64(config.Height/2-config.CenterGap/2) * config.MaxBarHeight
+	t := float64(n) / config.FPS
+	for i := range heights {
+		phase := 2 * math.Pi * (0.8*t + 2*float64(i)/float64(len(heights)))
+		heights[i] = maxHeight * (0.55 + 0.45*math.Sin(phase))
+	}
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"image/jpeg"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/linuxmatters/jivefire/internal/audio"
+	"github.com/linuxmatters/jivefire/internal/cli"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/failure"
+	"github.com/linuxmatters/jivefire/internal/renderer"
+)
+
+// jivefire studio serves a page for trying a look before rendering with
+// it: the settings are form fields, each change redraws the frame here with
+// idle bars (as jivefire idle draws them), and the page shows the render
+// flags that reproduce it. Jivefire has no config file; the flags are the
+// export.
+
+// studioLoop is the length in seconds of the idle motion the studio
+// previews, looped.
+const studioLoop = 10
+
+// studioCatchUp is the most frames drawn, unshown, to bring the preview up to
+// the one asked for, so the embers move as they would in a render; further
+// behind, the preview jumps.
+const studioCatchUp = 2 * config.FPS
+
+//go:embed studio.html
+var studioPage string
+
+var studioTemplate = template.Must(template.New("studio").Parse(studioPage))
+
+type studioCmd struct {
+	Addr  string   `help:"Address to serve the studio on" default:"localhost:8420"`
+	Title string   `help:"Title to preview" default:"Podcast Title"`
+	Font  []string `help:"TrueType fonts to offer besides the built-in one" type:"existingfile"`
+	backgroundFlags
+}
+
+// studioSettings are the options the studio's form sets, each the value of
+// the render flag it stands for.
+type studioSettings struct {
+	Title          string
+	Episode        int // 0 for none
+	BarColor       string
+	TextColor      string
+	BarColorMode   config.BarColorMode
+	TitleAlign     config.TextAlign
+	Particles      bool
+	BackgroundDim  float64
+	BackgroundBlur int
+	Font           string // "" for the built-in font, or one of the --font paths
+}
+
+// defaultStudioSettings are the render defaults, with the title and
+// background options jivefire studio was started with.
+func defaultStudioSettings(cmd *studioCmd) studioSettings {
+	defaults := &config.RuntimeConfig{}
+	return studioSettings{
+		Title:          cmd.Title,
+		BarColor:       hexColor(defaults.GetBarColor()),
+		TextColor:      hexColor(defaults.GetTextColor()),
+		BarColorMode:   config.ColorFixed,
+		TitleAlign:     config.AlignCentre,
+		BackgroundDim:  cmd.BackgroundDim,
+		BackgroundBlur: cmd.BackgroundBlur,
+	}
+}
+
+// hexColor formats an RGB colour as the form's colour fields take it.
+func hexColor(r, g, b uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// parseStudioSettings validates the form's query; fonts are the ones it may
+// choose from.
+func parseStudioSettings(q url.Values, fonts []string) (studioSettings, error) {
+	s := studioSettings{
+		Title:     q.Get("title"),
+		BarColor:  q.Get("bar-color"),
+		TextColor: q.Get("text-color"),
+		Particles: q.Get("particles") != "",
+		Font:      q.Get("font"),
+	}
+	var err error
+	if v := q.Get("episode"); v != "" {
+		if s.Episode, err = strconv.Atoi(v); err != nil || s.Episode < 0 {
+			return s, fmt.Errorf("invalid episode %q", v)
+		}
+	}
+	for _, c := range []string{s.BarColor, s.TextColor} {
+		if _, _, _, err := config.ParseHexColor(c); err != nil {
+			return s, err
+		}
+	}
+	if s.BarColorMode, err = config.ParseBarColorMode(q.Get("bar-color-mode")); err != nil {
+		return s, err
+	}
+	if s.TitleAlign, err = config.ParseTextAlign(q.Get("title-align")); err != nil {
+		return s, err
+	}
+	if s.BackgroundDim, err = strconv.ParseFloat(q.Get("background-dim"), 64); err != nil || s.BackgroundDim < 0 || s.BackgroundDim > 1 {
+		return s, fmt.Errorf("invalid background dim %q", q.Get("background-dim"))
+	}
+	if s.BackgroundBlur, err = strconv.Atoi(q.Get("background-blur")); err != nil || s.BackgroundBlur < 0 || s.BackgroundBlur > maxBackgroundBlur {
+		return s, fmt.Errorf("invalid background blur %q", q.Get("background-blur"))
+	}
+	if s.Font != "" && !slices.Contains(fonts, s.Font) {
+		return s, fmt.Errorf("font %q was not given to jivefire studio", s.Font)
+	}
+	return s, nil
+}
+
+// runtimeConfig returns the settings as a render would take them, over the
+// background image bg.
+func (s studioSettings) runtimeConfig(bg backgroundFlags) *config.RuntimeConfig {
+	rc := &config.RuntimeConfig{
+		BackgroundImagePath: bg.BackgroundImage,
+		BackgroundDim:       s.BackgroundDim,
+		BackgroundBlur:      s.BackgroundBlur,
+		BarColorMode:        s.BarColorMode,
+		TitleAlign:          s.TitleAlign,
+		Particles:           s.Particles,
+		FontPath:            s.Font,
+	}
+	rc.BackgroundFit, _ = config.ParseBackgroundFit(bg.BackgroundFit)
+	for _, c := range []struct {
+		value string
+		dst   *config.OptionalColor
+	}{
+		{s.BarColor, &rc.BarColor},
+		{s.TextColor, &rc.TextColor},
+	} {
+		r, g, b, _ := config.ParseHexColor(c.value)
+		*c.dst = config.OptionalColor{R: r, G: g, B: b, Set: true}
+	}
+	return rc
+}
+
+// flags returns the render flags that reproduce the settings, leaving out
+// those at their defaults; bg is the background jivefire studio was given.
+func (s studioSettings) flags(bg backgroundFlags) []string {
+	defaults := studioSettings{
+		BarColor:     hexColor(config.BarColorR, config.BarColorG, config.BarColorB),
+		TextColor:    hexColor(config.TextColorR, config.TextColorG, config.TextColorB),
+		BarColorMode: config.ColorFixed,
+		TitleAlign:   config.AlignCentre,
+	}
+	var flags []string
+	add := func(name, value string) {
+		flags = append(flags, "--"+name+"="+shellQuote(value))
+	}
+	add("title", s.Title)
+	if s.Episode > 0 {
+		add("episode", strconv.Itoa(s.Episode))
+	}
+	if !strings.EqualFold(s.BarColor, defaults.BarColor) {
+		add("bar-color", s.BarColor)
+	}
+	if !strings.EqualFold(s.TextColor, defaults.TextColor) {
+		add("text-color", s.TextColor)
+	}
+	if s.BarColorMode != defaults.BarColorMode {
+		add("bar-color-mode", string(s.BarColorMode))
+	}
+	if s.TitleAlign != defaults.TitleAlign {
+		add("title-align", string(s.TitleAlign))
+	}
+	if s.Particles {
+		flags = append(flags, "--particles")
+	}
+	if s.Font != "" {
+		add("font", s.Font)
+	}
+	if bg.BackgroundImage != "" {
+		add("background-image", bg.BackgroundImage)
+		if bg.BackgroundFit != "cover" {
+			add("background-fit", bg.BackgroundFit)
+		}
+	}
+	if s.BackgroundDim != 0 {
+		add("background-dim", strconv.FormatFloat(s.BackgroundDim, 'g', -1, 64))
+	}
+	if s.BackgroundBlur != 0 {
+		add("background-blur", strconv.Itoa(s.BackgroundBlur))
+	}
+	return flags
+}
+
+// shellQuote quotes s for a POSIX shell when it needs it.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("#-_./,:", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// studio draws the preview frames. A frame is built afresh when the
+// settings change, and otherwise drawn on from the last one shown.
+type studio struct {
+	cmd *studioCmd
+
+	mu       sync.Mutex
+	settings studioSettings
+	frame    *renderer.Frame
+	last     int // Index of the last frame drawn
+	bars     *audio.IdleBars
+	levels   []float64
+	heights  []float64
+}
+
+func newStudio(cmd *studioCmd) *studio {
+	return &studio{
+		cmd:     cmd,
+		bars:    audio.NewIdleBars(studioLoop*config.FPS, config.FPS, 1),
+		levels:  make([]float64, config.NumBars),
+		heights: make([]float64, config.NumBars),
+	}
+}
+
+// draw draws frame n of the loop with settings s.
+func (st *studio) draw(s studioSettings, n int) (*renderer.Frame, error) {
+	if st.frame == nil || st.settings != s {
+		rc := s.runtimeConfig(st.cmd.backgroundFlags)
+		meta := renderer.PodcastMeta{Title: s.Title}
+		if s.Episode > 0 {
+			meta.Episode = &s.Episode
+		}
+		bg, err := renderer.LoadBackgroundImage(rc)
+		if err != nil {
+			return nil, fmt.Errorf("loading background image: %w", err)
+		}
+		face, err := renderer.LoadTitleFont(meta.Title, rc)
+		if err != nil {
+			return nil, fmt.Errorf("loading font: %w", err)
+		}
+		badgeFace, err := renderer.LoadBadgeFont(rc)
+		if err != nil {
+			return nil, fmt.Errorf("loading font: %w", err)
+		}
+		st.frame = renderer.NewFrame(bg, face, meta, rc)
+		st.frame.SetBadgeFont(badgeFace)
+		st.settings, st.last = s, n-1
+	}
+	first := n
+	if behind := n - st.last; behind > 0 && behind <= studioCatchUp {
+		first = st.last + 1
+	}
+	maxHeight := float64(config.Height/2-config.CenterGap/2) * config.MaxBarHeight
+	for i := first; i <= n; i++ {
+		st.bars.Heights(i, st.levels)
+		var level float64
+		for j := range st.levels {
+			level += st.levels[j] / config.NumBars
+			st.levels[j] *= maxHeight
+		}
+		audio.RearrangeFrequenciesCenterOut(st.levels, st.heights)
+		st.frame.SetLevel(level)
+		st.frame.SetFrameIndex(i)
+		st.frame.Draw(st.heights)
+	}
+	st.last = n
+	return st.frame, nil
+}
+
+// handler serves the page, the preview frames and the flags.
+func (st *studio) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = studioTemplate.Execute(w, struct {
+			studioSettings
+			Fonts []string
+		}{defaultStudioSettings(st.cmd), st.cmd.Font})
+	})
+	mux.HandleFunc("GET /frame.jpg", func(w http.ResponseWriter, r *http.Request) {
+		s, err := parseStudioSettings(r.URL.Query(), st.cmd.Font)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		frame, err := st.draw(s, max(n, 0)%(studioLoop*config.FPS))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = jpeg.Encode(w, frame.GetImage(), &jpeg.Options{Quality: 85})
+	})
+	mux.HandleFunc("GET /flags", func(w http.ResponseWriter, r *http.Request) {
+		s, err := parseStudioSettings(r.URL.Query(), st.cmd.Font)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, strings.Join(s.flags(st.cmd.backgroundFlags), " "))
+	})
+	return mux
+}
+
+// runStudio serves the studio until interrupted.
+func runStudio(cmd *studioCmd) {
+	// The background flags are checked as a render checks them.
+	applyBackgroundFlags(&cmd.backgroundFlags, &config.RuntimeConfig{})
+	for _, font := range cmd.Font {
+		if _, err := renderer.LoadTitleFont(cmd.Title, &config.RuntimeConfig{FontPath: font}); err != nil {
+			cli.PrintError(fmt.Sprintf("invalid --font %s: %v", font, err))
+			os.Exit(failure.ExitCode(err))
+		}
+	}
+
+	ln, err := net.Listen("tcp", cmd.Addr)
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("invalid --addr: %v", err))
+		os.Exit(1)
+	}
+	fmt.Printf("%s %s\n", cli.KeyStyle.Render("Studio:"), cli.ValueStyle.Render("http://"+ln.Addr().String()+"/"))
+	fmt.Println(cli.KeyStyle.Render("Press Ctrl+C to stop."))
+	if err := http.Serve(ln, newStudio(cmd).handler()); err != nil { //nolint:gosec // a local preview, not a public server
+		cli.PrintError(err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/linuxmatters/jivefire/internal/audio"
+	"github.com/linuxmatters/jivefire/internal/chapters"
+	"github.com/linuxmatters/jivefire/internal/cli"
+	"github.com/linuxmatters/jivefire/internal/frames"
+)
+
+// testSampleRate is the rate of the calibration audio, high enough for the
+// sweep to reach 20kHz.
+const testSampleRate = 48000
+
+// testTitle replaces the placeholder title on a calibration video.
+const testTitle = "Jivefire Calibration"
+
+// testCmd renders a calibration video: octave tones, a sweep and pink noise
+// through the normal render path, labelled with the frequency sounding, so
+// the effect of --freq-min, --freq-max, --noise-gate and the other bar
+// flags can be seen bar by bar.
+type testCmd struct {
+	Output string `arg:"" name:"output" help:"Output MP4 file" optional:""`
+	renderFlags
+	Sweep int `help:"Length of the frequency sweep in seconds" default:"20"`
+	Noise int `help:"Length of the pink noise in seconds" default:"10"`
+}
+
+// runTest writes the calibration audio, its chapters and the label overlay
+// to the cache directory, then renders them as a normal episode would be.
+func runTest(ctx *kong.Context, cmd *testCmd) {
+	if cmd.Output == "" && cmd.OutputTemplate == "" {
+		_ = ctx.PrintUsage(true)
+		os.Exit(0)
+	}
+	for _, unsupported := range []struct {
+		set  bool
+		flag string
+	}{
+		{cmd.Script != "", "--script"},
+		{cmd.Chapters != "", "--chapters"},
+	} {
+		if unsupported.set {
+			cli.PrintError(fmt.Sprintf("%s is not available for jivefire test, which draws its own labels and chapters", unsupported.flag))
+			os.Exit(1)
+		}
+	}
+	if cmd.Sweep < 0 || cmd.Noise < 0 {
+		cli.PrintError("--sweep and --noise cannot be negative")
+		os.Exit(1)
+	}
+
+	minHz, maxHz := cmd.FreqMin, cmd.FreqMax
+	if minHz <= 0 {
+		minHz = 20
+	}
+	if maxHz <= 0 {
+		maxHz = 20000
+	}
+	if minHz >= maxHz {
+		cli.PrintError(fmt.Sprintf("--freq-min (%g) must be below --freq-max (%g)", minHz, maxHz))
+		os.Exit(1)
+	}
+	signal := audio.NewTestSignal(testSampleRate, minHz, maxHz, time.Duration(cmd.Sweep)*time.Second, time.Duration(cmd.Noise)*time.Second)
+	if len(signal.Segments) == 0 {
+		cli.PrintError("nothing to render: no octave tone falls within the frequency range and --sweep and --noise are 0")
+		os.Exit(1)
+	}
+
+	dir, err := testDir()
+	if err != nil {
+		cli.PrintError(fmt.Sprintf("preparing the calibration audio: %v", err))
+		os.Exit(1)
+	}
+	input := filepath.Join(dir, "calibration.wav")
+	if err := writeTestFiles(signal, input); err != nil {
+		cli.PrintError(fmt.Sprintf("preparing the calibration audio: %v", err))
+		os.Exit(1)
+	}
+	cmd.Script = filepath.Join(dir, "labels.lua")
+	cmd.Chapters = filepath.Join(dir, "chapters.txt")
+	if cmd.Title == defaultTitle {
+		cmd.Title = testTitle
+	}
+	runRender(ctx, &renderCmd{Input: input, Output: cmd.Output, renderFlags: cmd.renderFlags})
+}
+
+// testDir returns the directory the calibration files are written to. They
+// are small and rewritten on every run, so they live in the cache rather
+// than a temporary directory a failed render would leave behind.
+func testDir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cache, "jivefire", "test")
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+// writeTestFiles writes the signal to wav, with chapters.txt marking each
+// segment and labels.lua, a --script overlay naming the frequency sounding,
+// beside it.
+func writeTestFiles(signal *audio.TestSignal, wav string) error {
+	w, err := frames.CreateWAV(wav, signal.SampleRate, 1)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(signal.Samples()); err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(wav)
+	var list strings.Builder
+	for _, seg := range signal.Segments {
+		fmt.Fprintf(&list, "%s %s\n", chapters.FormatTimestamp(seg.Start), seg.Label)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "chapters.txt"), []byte(list.String()), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "labels.lua"), []byte(testLabelScript(signal)), 0o644)
+}
+
+// testLabelScript returns a Lua overlay that writes the frequency sounding,
+// or the segment's label for noise, in the bottom-left corner.
+func testLabelScript(signal *audio.TestSignal) string {
+	var b strings.Builder
+	b.WriteString("local segments = {\n")
+	for _, seg := range signal.Segments {
+		fmt.Fprintf(&b, "  {%g, %g, %q, %g, %g},\n", seg.Start.Seconds(), seg.End.Seconds(), seg.Label, seg.FromHz, seg.ToHz)
+	}
+	b.WriteString(`}
+
+local function hz(f)
+  if f >= 1000 then
+    return string.format("%.1f kHz", f / 1000)
+  end
+  return string.format("%d Hz", math.floor(f + 0.5))
+end
+
+function frame(bars, t)
+  for _, s in ipairs(segments) do
+    if t >= s[1] and t < s[2] then
+      local label = s[3]
+      if s[4] > 0 and s[4] ~= s[5] then
+        label = "Sweep " .. hz(s[4] * (s[5] / s[4]) ^ ((t - s[1]) / (s[2] - s[1])))
+      end
+      draw.rect(20, height - 76, 360, 56, "#000000", 0.5)
+      draw.text(36, height - 68, label, "#FFFFFF", 32)
+      return
+    end
+  end
+end
+`)
+	return b.String()
+}
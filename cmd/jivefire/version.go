@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/linuxmatters/jivefire/internal/cli"
+	"github.com/linuxmatters/jivefire/internal/encoder"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
+)
+
+// These are set via ldflags at build time alongside version, and left empty
+// by a plain go build: the commit and its date then come from the VCS stamp
+// Go embeds when building from a checkout.
+var (
+	commit         = ""
+	buildDate      = ""
+	statigoVersion = "" // The ffmpeg-statigo tag of third_party/ffmpeg-statigo
+)
+
+type versionCmd struct {
+	JSON bool `help:"Print the version, build and FFmpeg details, and the encoders available, as JSON"`
+}
+
+// buildInfo is the jivefire version --json document, for bug reports and
+// automation to capture the environment a render ran in.
+type buildInfo struct {
+	Version       string        `json:"version"`
+	Commit        string        `json:"commit,omitempty"`
+	BuildDate     string        `json:"build_date,omitempty"`
+	GoVersion     string        `json:"go_version"`
+	OS            string        `json:"os"`
+	Arch          string        `json:"arch"`
+	FFmpegStatigo string        `json:"ffmpeg_statigo,omitempty"`
+	FFmpeg        string        `json:"ffmpeg"`
+	Encoders      []encoderInfo `json:"encoders"`
+}
+
+// encoderInfo is a video encoder jivefire can use, the --encoder backend
+// selecting it, and whether it works on this machine.
+type encoderInfo struct {
+	Name      string `json:"name"`
+	Backend   string `json:"backend"`
+	Codec     string `json:"codec"`
+	Hardware  bool   `json:"hardware"`
+	Available bool   `json:"available"`
+}
+
+// runVersion prints the version, or with --json the full build details.
+func runVersion(cmd *versionCmd) {
+	if !cmd.JSON {
+		cli.PrintVersion(version)
+		return
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(currentBuild(encoder.DetectHWEncoders())); err != nil {
+		cli.PrintError(err.Error())
+		os.Exit(1)
+	}
+}
+
+// currentBuild describes this binary, with libx264 and the hardware
+// encoders hw.
+func currentBuild(hw []encoder.HWEncoder) buildInfo {
+	info := buildInfo{
+		Version:       version,
+		Commit:        commit,
+		BuildDate:     buildDate,
+		GoVersion:     runtime.Version(),
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		FFmpegStatigo: statigoVersion,
+		FFmpeg:        ffmpegutil.Version(),
+		Encoders:      []encoderInfo{{Name: "libx264", Backend: "software", Codec: string(encoder.CodecH264), Available: true}},
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		vcs := make(map[string]string)
+		for _, s := range bi.Settings {
+			vcs[s.Key] = s.Value
+		}
+		if info.Commit == "" {
+			info.Commit = vcs["vcs.revision"]
+			if info.Commit != "" && vcs["vcs.modified"] == "true" {
+				info.Commit += "-dirty"
+			}
+		}
+		if info.BuildDate == "" {
+			info.BuildDate = vcs["vcs.time"]
+		}
+	}
+	for _, h := range hw {
+		info.Encoders = append(info.Encoders, encoderInfo{Name: h.Name, Backend: string(h.Type), Codec: string(h.Codec), Hardware: true, Available: h.Available})
+	}
+	return info
+}
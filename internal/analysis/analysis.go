@@ -0,0 +1,162 @@
+// Package analysis reads and writes the Pass 1 results `jivefire analyze`
+// saves, so the render can run on another machine without analysing the
+// audio again. The file records what the results depend on, the input and
+// the settings that change what Pass 1 measures, and Check refuses a render
+// that differs from either.
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FormatVersion is the file layout Write produces and Read accepts.
+const FormatVersion = 1
+
+// File is the top-level JSON document. Durations are in seconds.
+type File struct {
+	Format    int       `json:"format"`
+	Version   string    `json:"version"` // jivefire version that ran the analysis
+	Generated time.Time `json:"generated"`
+	Input     Input     `json:"input"`
+	Settings  Settings  `json:"settings"`
+	Profile   Profile   `json:"profile"`
+}
+
+// Input identifies the analysed audio by its name and what its decoder
+// reports, enough to catch a render pointed at another episode or an edited
+// file without hashing hours of audio.
+type Input struct {
+	Name       string  `json:"name"` // Base name; the path differs between machines
+	Size       int64   `json:"size_bytes"`
+	SampleRate int     `json:"sample_rate"`
+	Channels   int     `json:"channels"`
+	Duration   float64 `json:"duration_seconds"`
+}
+
+// Settings are the render options Pass 1 depends on. The JSON names are the
+// flags' with underscores, so a mismatch can name the flag to change. Files
+// are recorded by base name.
+type Settings struct {
+	Start       float64 `json:"start"`
+	Duration    float64 `json:"duration"` // Of the section rendered; 0 runs to the end
+	Speed       float64 `json:"speed"`
+	Channels    int     `json:"channels"` // As encoded, after --surround and --speed
+	FreqMin     float64 `json:"freq_min"`
+	FreqMax     float64 `json:"freq_max"`
+	VisHighpass float64 `json:"vis_highpass"`
+	VisLowpass  float64 `json:"vis_lowpass"`
+	Declip      bool    `json:"declip"`
+	Music       string  `json:"music"`
+	MusicGain   float64 `json:"music_gain"`
+	MusicDuck   float64 `json:"music_duck"`
+	Intro       string  `json:"intro"`
+	Outro       string  `json:"outro"`
+	Crossfade   float64 `json:"crossfade"`
+}
+
+// Profile is the Pass 1 analysis Pass 2 renders from. Levels are linear full
+// scale; Loudness is in LUFS and nil for silence, whose -Inf JSON cannot
+// hold.
+type Profile struct {
+	Frames       int      `json:"frames"`
+	GlobalPeak   float64  `json:"global_peak"`
+	GlobalRMS    float64  `json:"global_rms"`
+	DynamicRange float64  `json:"dynamic_range"`
+	TruePeak     float64  `json:"true_peak"`
+	Loudness     *float64 `json:"loudness_lufs"`
+	OutputPeak   float64  `json:"output_peak"`
+	DCOffset     float64  `json:"dc_offset"`
+	Clipped      float64  `json:"clipped_fraction"`
+	OptimalScale float64  `json:"optimal_scale"`
+	SampleRate   int      `json:"sample_rate"`
+	Duration     float64  `json:"duration_seconds"`
+}
+
+// Write saves f as indented JSON.
+func Write(path string, f File) error {
+	data, err := Marshal(f)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Marshal returns f as the indented JSON Write saves, for an analysis
+// carried somewhere other than its own file.
+func Marshal(f File) ([]byte, error) {
+	f.Format = FormatVersion
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Read loads a file Write saved.
+func Read(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if f.Format != FormatVersion {
+		return nil, fmt.Errorf("%s: analysis format %d, this jivefire reads %d; run jivefire analyze again", path, f.Format, FormatVersion)
+	}
+	if f.Profile.Frames <= 0 {
+		return nil, fmt.Errorf("%s: the analysis has no frames", path)
+	}
+	return &f, nil
+}
+
+// Check returns an error naming what differs when input or settings are not
+// those f was analysed with.
+func (f *File) Check(input Input, settings Settings) error {
+	if input != f.Input {
+		return fmt.Errorf("the input is not the audio that was analysed (%s, %d bytes, %s); run jivefire analyze on it",
+			f.Input.Name, f.Input.Size, time.Duration(f.Input.Duration*float64(time.Second)).Round(time.Second))
+	}
+	differ := diff(f.Settings, settings)
+	if len(differ) == 0 {
+		return nil
+	}
+	return errors.New("the analysis was made with other settings: " + strings.Join(differ, ", ") + "; match them or run jivefire analyze again")
+}
+
+// diff lists the settings that differ between analysed and now, as flags
+// with both values, in name order.
+func diff(analysed, now Settings) []string {
+	a, b := fields(analysed), fields(now)
+	var out []string
+	for _, name := range slices.Sorted(maps.Keys(a)) {
+		if a[name] != b[name] {
+			out = append(out, fmt.Sprintf("--%s %v (analysed) vs %v", strings.ReplaceAll(name, "_", "-"), show(a[name]), show(b[name])))
+		}
+	}
+	return out
+}
+
+// fields returns s by JSON name.
+func fields(s Settings) map[string]any {
+	data, _ := json.Marshal(s) // Plain numbers and strings cannot fail
+	var m map[string]any
+	_ = json.Unmarshal(data, &m)
+	return m
+}
+
+// show formats a setting, marking an unset file.
+func show(v any) any {
+	if v == "" {
+		return "(none)"
+	}
+	return v
+}
@@ -0,0 +1,93 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testFile() File {
+	return File{
+		Version:  "dev",
+		Input:    Input{Name: "episode.wav", Size: 1000, SampleRate: 48000, Channels: 2, Duration: 3600},
+		Settings: Settings{Speed: 1, Channels: 2, FreqMax: 12000},
+		Profile:  Profile{Frames: 108000, GlobalPeak: 0.9, OptimalScale: 0.4, SampleRate: 48000, Duration: 3600},
+	}
+}
+
+// TestWriteRoundTrip verifies a file reads back as written, silence's
+// missing loudness included.
+func TestWriteRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.json")
+	f := testFile()
+	if err := Write(path, f); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if got.Format != FormatVersion || got.Input != f.Input || got.Settings != f.Settings || got.Profile.Loudness != nil {
+		t.Errorf("Read() = %+v, want %+v", got, f)
+	}
+
+	loudness := -16.5
+	f.Profile.Loudness = &loudness
+	if err := Write(path, f); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if got, err := Read(path); err != nil || got.Profile.Loudness == nil || *got.Profile.Loudness != loudness {
+		t.Errorf("Read() loudness = %v, %v, want %g", got.Profile.Loudness, err, loudness)
+	}
+}
+
+// TestReadRejects verifies files from another format, or with no frames, are
+// refused rather than rendered from.
+func TestReadRejects(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"format.json": `{"format": 2, "profile": {"frames": 10}}`,
+		"empty.json":  `{"format": 1}`,
+		"bad.json":    `{"format":`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Read(path); err == nil {
+			t.Errorf("Read(%s) succeeded", name)
+		}
+	}
+}
+
+// TestCheck verifies a matching render passes and a mismatch names the
+// flags that differ.
+func TestCheck(t *testing.T) {
+	f := testFile()
+	if err := f.Check(f.Input, f.Settings); err != nil {
+		t.Errorf("Check() on matching render error = %v", err)
+	}
+
+	other := f.Input
+	other.Size++
+	if err := f.Check(other, f.Settings); err == nil || !strings.Contains(err.Error(), "episode.wav") {
+		t.Errorf("Check() on edited input error = %v, want it to name the analysed file", err)
+	}
+
+	settings := f.Settings
+	settings.Start = 300
+	settings.Music = "bed.mp3"
+	err := f.Check(f.Input, settings)
+	if err == nil {
+		t.Fatal("Check() on other settings succeeded")
+	}
+	for _, want := range []string{"--start 0 (analysed) vs 300", "--music (none) (analysed) vs bed.mp3"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Check() error = %q, want it to contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "--speed") {
+		t.Errorf("Check() error = %q names a setting that matches", err)
+	}
+}
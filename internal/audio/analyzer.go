@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"slices"
 	"time"
 
 	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/memlimit"
 )
 
 // FrameAnalysis holds statistics for a single frame.
@@ -15,8 +17,14 @@ type FrameAnalysis struct {
 	// Peak FFT magnitude across all bars
 	PeakMagnitude float64
 
-	// RMS level of audio chunk
+	// RMS level of audio chunk, about its mean so a DC offset does not add
+	// to it
 	RMSLevel float64
+
+	// Largest absolute sample and the estimated inter-sample (true) peak of
+	// the audio chunk, linear full scale
+	SamplePeak float64
+	TruePeak   float64
 }
 
 // Profile holds complete audio analysis results.
@@ -28,6 +36,19 @@ type Profile struct {
 	GlobalPeak   float64 // Highest peak magnitude across all frames
 	GlobalRMS    float64 // Average RMS across all frames
 	DynamicRange float64 // Ratio of GlobalPeak to GlobalRMS
+	TruePeak     float64 // Highest true-peak sample level, linear full scale
+
+	// Levels of the audio as encoded (see Span.Channels): integrated
+	// loudness in LUFS, -Inf for silence, and the true peak across its
+	// channels, linear full scale.
+	Loudness   float64
+	OutputPeak float64
+
+	// Faults of the mono signal analysed: its DC offset, the mean sample
+	// value in linear full scale, and the share of its samples in
+	// flat-topped runs at full scale (0-1).
+	DCOffset        float64
+	ClippedFraction float64
 
 	// Bar-scaling factor derived from GlobalPeak (see AnalyzeAudio).
 	OptimalBaseScale float64
@@ -37,17 +58,54 @@ type Profile struct {
 	Duration   float64 // Seconds
 }
 
-// ProgressCallback is called with progress updates during analysis.
-type ProgressCallback func(frame int, currentRMS, currentPeak float64, barHeights []float64, duration time.Duration)
+// ProgressCallback is called with progress updates during analysis, carrying
+// the levels of the latest frame.
+type ProgressCallback func(frame int, levels FrameAnalysis, barHeights []float64, duration time.Duration)
+
+// Span selects the part of the audio both passes read: Frames video frames
+// from Start, running to the end of the audio when Frames is 0. Speed above 1
+// (--speed) makes each frame cover that many frames' worth of audio, for a
+// time-lapse of a long recording; 0 means real time. Channels is the channel
+// count the audio is encoded with, which Pass 1 measures loudness in; 0 is
+// mono.
+type Span struct {
+	Start    time.Duration
+	Frames   int
+	Speed    float64
+	Channels int
+}
+
+// Step returns the input samples each video frame advances by, given the
+// samplesPerFrame of real-time playback.
+func (s Span) Step(samplesPerFrame int) int {
+	if s.Speed <= 0 || s.Speed == 1 {
+		return samplesPerFrame
+	}
+	return int(math.Round(float64(samplesPerFrame) * s.Speed))
+}
 
 // AnalyzeAudio performs Pass 1: stream through audio and collect statistics.
-func AnalyzeAudio(filename string, progressCb ProgressCallback) (*Profile, error) {
-	reader, err := NewStreamingReader(filename)
+// Only one FFT window and one frame of samples are held at a time, so memory
+// does not depend on the length of the file. span limits analysis to the
+// section being rendered and freq to the part of the spectrum the bars show,
+// so the scale is calibrated on what is drawn. vis filters the FFT feed as
+// Pass 2 does, and both take the DC offset out of the FFT feed; the levels
+// are measured on the unfiltered audio. A non-nil
+// guard stops analysis with an error if the heap outgrows --max-memory
+// regardless.
+func AnalyzeAudio(filename string, span Span, freq FreqRange, vis VisFilter, guard *memlimit.Guard, progressCb ProgressCallback) (*Profile, error) {
+	reader, err := NewStreamingReaderAt(filename, span.Start)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open audio: %w", err)
 	}
 	defer reader.Close()
+	return AnalyzeReader(reader, span, freq, vis, guard, progressCb)
+}
 
+// AnalyzeReader is AnalyzeAudio on a reader already positioned at
+// span.Start. It leaves the reader where analysis stopped; Seek back to
+// span.Start to read the same audio again.
+func AnalyzeReader(reader Source, span Span, freq FreqRange, vis VisFilter, guard *memlimit.Guard, progressCb ProgressCallback) (*Profile, error) {
 	// NumFrames and Duration are derived from the actual sample count below.
 	profile := &Profile{
 		SampleRate: reader.SampleRate(),
@@ -60,6 +118,15 @@ func AnalyzeAudio(filename string, progressCb ProgressCallback) (*Profile, error
 		return nil, fmt.Errorf("input sample rate too low for %d FPS: %d Hz", config.FPS, reader.SampleRate())
 	}
 
+	bands, err := NewBands(reader.SampleRate(), freq)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := NewFilter(reader.SampleRate(), vis)
+	if err != nil {
+		return nil, err
+	}
+
 	processor, err := NewProcessor()
 	if err != nil {
 		return nil, fmt.Errorf("creating FFT processor: %w", err)
@@ -69,17 +136,49 @@ func AnalyzeAudio(filename string, progressCb ProgressCallback) (*Profile, error
 	var sumRMS float64
 	var maxPeak float64
 
-	// Sliding buffer for FFT: we advance by samplesPerFrame but need FFTSize for FFT.
+	// Sliding buffer for FFT: we advance by step but need FFTSize for FFT.
+	step := span.Step(samplesPerFrame)
 	fftBuffer := make([]float64, config.FFTSize)
-	frameBuf := make([]float64, samplesPerFrame)
+	frameBuf := make([]float64, step)
+
+	// Loudness is measured on what Pass 2 encodes: the source's channels in
+	// the output layout, or at --speed the time-stretched mono, which a
+	// stereo output duplicates into both channels.
+	out := newOutputLevels(reader, span, step != samplesPerFrame)
+	if err := out.enable(); err != nil {
+		return nil, err
+	}
 
-	n, err := FillFFTBuffer(reader, fftBuffer)
+	// Every frame reads its own step samples, the first included, into the
+	// end of the window, so each sample is measured once, as Pass 2 encodes
+	// it, and the window ends with the frame.
+	n, err := FillFFTBuffer(reader, frameBuf)
 	if err != nil {
 		return nil, fmt.Errorf("error reading initial chunk: %w", err)
 	}
 	if n == 0 {
 		return nil, fmt.Errorf("no audio data in file")
 	}
+	out.add(frameBuf[:n])
+	var faults clipStats
+	faults.add(frameBuf[:n])
+	clear(frameBuf[n:])
+	ShiftIn(fftBuffer, frameBuf)
+
+	// The FFT reads a filtered copy of the window, kept in step with
+	// fftBuffer, with the DC offset taken out as Pass 2 does.
+	visBuffer := make([]float64, config.FFTSize)
+	visFrame := make([]float64, step)
+	dc := NewDCBlocker(reader.SampleRate())
+	feed := func(dst, src []float64) {
+		if filter != nil {
+			filter.Apply(dst, src)
+			src = dst
+		}
+		dc.Apply(dst, src)
+	}
+	feed(visFrame, frameBuf)
+	ShiftIn(visBuffer, visFrame)
 
 	// Pre-allocate bar magnitudes buffer for progress callbacks
 	barHeights := make([]float64, config.NumBars)
@@ -90,50 +189,63 @@ func AnalyzeAudio(filename string, progressCb ProgressCallback) (*Profile, error
 	for {
 		// ProcessChunk reads fftBuffer in place (applying the pre-computed Hanning
 		// window), so no intermediate copy is needed.
-		coeffs := processor.ProcessChunk(fftBuffer)
+		coeffs := processor.ProcessChunk(visBuffer)
 
-		analysis := analyzeFrame(coeffs, fftBuffer, barHeights)
+		analysis := analyzeFrame(coeffs, bands, fftBuffer, barHeights)
 
 		if analysis.PeakMagnitude > maxPeak {
 			maxPeak = analysis.PeakMagnitude
 		}
+		profile.TruePeak = max(profile.TruePeak, analysis.TruePeak)
 		sumRMS += analysis.RMSLevel
 
 		frameNum++
 
-		// Throttle progress callbacks to every third frame.
-		if progressCb != nil && frameNum%3 == 0 {
-			elapsed := time.Since(startTime)
-			progressCb(frameNum, analysis.RMSLevel, analysis.PeakMagnitude, barHeights, elapsed)
+		// Throttle progress callbacks and memory checks to every third frame.
+		if frameNum%3 == 0 {
+			if err := guard.Check(); err != nil {
+				return nil, fmt.Errorf("analysis stopped at frame %d: %w", frameNum, err)
+			}
+			if progressCb != nil {
+				elapsed := time.Since(startTime)
+				progressCb(frameNum, analysis, barHeights, elapsed)
+			}
 		}
 
-		nRead, err := ReadNextFrame(reader, frameBuf)
+		var nRead int
+		if span.Frames <= 0 || frameNum < span.Frames {
+			nRead, err = ReadNextFrame(reader, frameBuf)
+		} else {
+			err = io.EOF
+		}
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				if progressCb != nil {
 					elapsed := time.Since(startTime)
-					progressCb(frameNum, analysis.RMSLevel, analysis.PeakMagnitude, barHeights, elapsed)
+					progressCb(frameNum, analysis, barHeights, elapsed)
 				}
 				break
 			}
 			return nil, fmt.Errorf("error reading audio at frame %d: %w", frameNum, err)
 		}
 
-		// Shift buffer left by samplesPerFrame, append new samples.
-		copy(fftBuffer, fftBuffer[samplesPerFrame:])
-		copy(fftBuffer[config.FFTSize-samplesPerFrame:], frameBuf[:nRead])
-		if nRead < samplesPerFrame {
-			clear(fftBuffer[config.FFTSize-samplesPerFrame+nRead:])
-		}
+		out.add(frameBuf[:nRead])
+		faults.add(frameBuf[:nRead])
+		clear(frameBuf[nRead:])
+		ShiftIn(fftBuffer, frameBuf)
+		feed(visFrame, frameBuf)
+		ShiftIn(visBuffer, visFrame)
 	}
 
 	// Duration tracks the number of frames advanced, not total samples read; each
-	// frame represents samplesPerFrame of audio.
+	// frame represents step samples of audio.
 	profile.NumFrames = frameNum
-	profile.Duration = float64(frameNum*samplesPerFrame) / float64(reader.SampleRate())
+	profile.Duration = float64(frameNum*step) / float64(reader.SampleRate())
 
 	profile.GlobalPeak = maxPeak
+	profile.Loudness, profile.OutputPeak = out.levels(profile.TruePeak)
 	profile.GlobalRMS = sumRMS / float64(profile.NumFrames)
+	profile.DCOffset, profile.ClippedFraction = faults.offset(), faults.fraction()
 
 	if profile.GlobalRMS > 0 {
 		profile.DynamicRange = profile.GlobalPeak / profile.GlobalRMS
@@ -156,15 +268,12 @@ func AnalyzeAudio(filename string, progressCb ProgressCallback) (*Profile, error
 // analyzeFrame extracts statistics from FFT coefficients and audio chunk.
 // barMagnitudes is an optional buffer that receives per-bar average magnitudes
 // for progress display; pass nil when bar magnitudes are not needed.
-func analyzeFrame(spectrum Spectrum, audioChunk []float64, barMagnitudes []float64) FrameAnalysis {
+func analyzeFrame(spectrum Spectrum, bands *Bands, audioChunk []float64, barMagnitudes []float64) FrameAnalysis {
 	analysis := FrameAnalysis{}
 
-	// Calculate RMS of audio chunk
-	var sumSquares float64
-	for _, sample := range audioChunk {
-		sumSquares += sample * sample
-	}
-	analysis.RMSLevel = math.Sqrt(sumSquares / float64(len(audioChunk)))
+	analysis.RMSLevel = acRMS(audioChunk)
+	analysis.SamplePeak = SamplePeak(audioChunk)
+	analysis.TruePeak = TruePeak(audioChunk)
 
 	// Bin frequencies into per-bar raw average magnitudes (shared with BinFFT).
 	// Write into the caller's buffer when supplied; otherwise use a local scratch.
@@ -172,7 +281,7 @@ func analyzeFrame(spectrum Spectrum, audioChunk []float64, barMagnitudes []float
 	if bins == nil {
 		bins = make([]float64, config.NumBars)
 	}
-	binRawMagnitudes(spectrum, bins)
+	binRawMagnitudes(spectrum, bands, bins)
 
 	// Track peak across raw bar magnitudes
 	for _, avgMagnitude := range bins {
@@ -183,3 +292,89 @@ func analyzeFrame(spectrum Spectrum, audioChunk []float64, barMagnitudes []float
 
 	return analysis
 }
+
+// acRMS returns the RMS of the samples about their mean, 0 for none.
+func acRMS(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum, sumSquares float64
+	for _, s := range samples {
+		sum += s
+		sumSquares += s * s
+	}
+	mean := sum / float64(len(samples))
+	return math.Sqrt(max(sumSquares/float64(len(samples))-mean*mean, 0))
+}
+
+// outputLevels meters the encoded audio during Pass 1.
+type outputLevels struct {
+	reader   Source
+	channels int
+	multi    bool // metering the reader's Output rather than the mono signal
+	meter    *LoudnessMeter
+	scratch  []float64
+	channel  []float64
+	peak     float64
+}
+
+func newOutputLevels(reader Source, span Span, stretched bool) *outputLevels {
+	channels := max(span.Channels, 1)
+	o := &outputLevels{
+		reader:   reader,
+		channels: channels,
+		multi:    channels > 1 && !stretched,
+	}
+	metered := 1
+	if o.multi {
+		metered = channels
+	}
+	o.meter = NewLoudnessMeter(reader.SampleRate(), metered)
+	return o
+}
+
+func (o *outputLevels) enable() error {
+	if !o.multi {
+		return nil
+	}
+	if err := o.reader.EnableOutput(o.channels); err != nil {
+		return fmt.Errorf("converting to %d channels: %w", o.channels, err)
+	}
+	return nil
+}
+
+// add meters the mono samples just read, or the output frames the reader
+// converted alongside them.
+func (o *outputLevels) add(mono []float64) {
+	if !o.multi {
+		o.meter.Add(mono)
+		return
+	}
+	frames := o.reader.Output()
+	frames = frames[:min(len(frames), len(mono)*o.channels)]
+	o.scratch = slices.Grow(o.scratch[:0], len(frames))[:len(frames)]
+	for i, v := range frames {
+		o.scratch[i] = float64(v)
+	}
+	o.meter.Add(o.scratch)
+
+	n := len(frames) / o.channels
+	o.channel = slices.Grow(o.channel[:0], n)[:n]
+	for c := range o.channels {
+		for i := range o.channel {
+			o.channel[i] = o.scratch[i*o.channels+c]
+		}
+		o.peak = max(o.peak, TruePeak(o.channel))
+	}
+}
+
+// levels returns the integrated loudness and true peak of the output. The
+// mono signal's true peak is monoPeak; a stereo output duplicating it adds
+// 3 LU of loudness but no peak.
+func (o *outputLevels) levels(monoPeak float64) (loudness, peak float64) {
+	loudness = o.meter.Integrated()
+	if o.multi {
+		return loudness, o.peak
+	}
+	return loudness + 10*math.Log10(float64(o.channels)), monoPeak
+}
@@ -1,15 +1,27 @@
+//go:build !lite
+
 package audio
 
 import (
+	"bufio"
+	"encoding/binary"
 	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/memlimit"
 )
 
 func mustAnalyze(t *testing.T) *Profile {
 	t.Helper()
-	profile, err := AnalyzeAudio("../../testdata/LMP0.mp3", nil)
+	profile, err := AnalyzeAudio("../../testdata/LMP0.mp3", Span{}, FreqRange{}, VisFilter{}, nil, nil)
 	if err != nil {
 		t.Fatalf("Failed to analyse audio: %v", err)
 	}
@@ -57,12 +69,169 @@ func TestAnalyzeAudio(t *testing.T) {
 }
 
 func TestAnalyzeAudioInvalidFile(t *testing.T) {
-	_, err := AnalyzeAudio("nonexistent.mp3", nil)
+	_, err := AnalyzeAudio("nonexistent.mp3", Span{}, FreqRange{}, VisFilter{}, nil, nil)
 	if err == nil {
 		t.Error("Expected error for nonexistent file, got nil")
 	}
 }
 
+func TestAnalyzeAudioMaxFrames(t *testing.T) {
+	const maxFrames = 3 * config.FPS
+	profile, err := AnalyzeAudio("../../testdata/LMP0.mp3", Span{Frames: maxFrames}, FreqRange{}, VisFilter{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to analyse audio: %v", err)
+	}
+	if profile.NumFrames != maxFrames {
+		t.Errorf("NumFrames = %d, want %d", profile.NumFrames, maxFrames)
+	}
+	if math.Abs(profile.Duration-3) > 0.01 {
+		t.Errorf("Duration = %.3fs, want 3s", profile.Duration)
+	}
+}
+
+// The loudness is of the audio as encoded: a stereo pair of identical
+// channels is 3 LU louder than the mono downmix, whether it comes from the
+// source's channels or from duplicating the time-stretched mono.
+func TestAnalyzeAudioLoudness(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stereo.wav")
+	if err := os.WriteFile(path, wavBytes(3, 32, 2, 8000*5), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	analyse := func(span Span) *Profile {
+		t.Helper()
+		profile, err := AnalyzeAudio(path, span, FreqRange{}, VisFilter{}, nil, nil)
+		if err != nil {
+			t.Fatalf("analysing %+v: %v", span, err)
+		}
+		return profile
+	}
+
+	mono := analyse(Span{})
+	if mono.Loudness > -8 || mono.Loudness < -10 {
+		t.Errorf("mono loudness %.2f LUFS, want about -9 for a half-scale sine", mono.Loudness)
+	}
+	for _, span := range []Span{{Channels: 2}, {Channels: 2, Speed: 2}} {
+		stereo := analyse(span)
+		if d := stereo.Loudness - mono.Loudness; math.Abs(d-3.01) > 0.2 {
+			t.Errorf("%+v: %.2f LU louder than mono, want 3.01", span, d)
+		}
+		if math.Abs(stereo.OutputPeak-0.5) > 0.02 {
+			t.Errorf("%+v: output peak %.3f, want 0.5", span, stereo.OutputPeak)
+		}
+	}
+}
+
+// writeSilentWAV creates a 16-bit mono WAV of the given length. The data is
+// left as a sparse hole, so hours of silence take no disk space.
+func writeSilentWAV(t *testing.T, path string, sampleRate int, length time.Duration) {
+	t.Helper()
+	dataBytes := uint32(length.Seconds() * float64(sampleRate) * 2)
+
+	le := binary.LittleEndian
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	le.PutUint32(header[4:], 36+dataBytes)
+	copy(header[8:], "WAVEfmt ")
+	le.PutUint32(header[16:], 16)
+	le.PutUint16(header[20:], 1) // PCM
+	le.PutUint16(header[22:], 1) // mono
+	le.PutUint32(header[24:], uint32(sampleRate))
+	le.PutUint32(header[28:], uint32(sampleRate*2))
+	le.PutUint16(header[32:], 2)
+	le.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	le.PutUint32(header[40:], dataBytes)
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("creating WAV: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(header); err != nil {
+		t.Fatalf("writing WAV header: %v", err)
+	}
+	if err := f.Truncate(int64(len(header)) + int64(dataBytes)); err != nil {
+		t.Fatalf("extending WAV: %v", err)
+	}
+}
+
+// residentBytes returns the process's resident set size from /proc.
+func residentBytes(t *testing.T) int64 {
+	t.Helper()
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		t.Fatalf("reading /proc/self/status: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(scanner.Text(), "VmRSS:"); ok {
+			kb, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(rest), " kB"), 10, 64)
+			if err != nil {
+				t.Fatalf("parsing VmRSS %q: %v", rest, err)
+			}
+			return kb << 10
+		}
+	}
+	t.Fatal("VmRSS not found in /proc/self/status")
+	return 0
+}
+
+// TestAnalyzeAudioLongFileStableMemory analyses four hours of audio and checks
+// resident memory stops growing once analysis is under way, so Pass 1 never
+// holds more than a window of the file.
+func TestAnalyzeAudioLongFileStableMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("analyses four hours of audio")
+	}
+	if runtime.GOOS != "linux" {
+		t.Skip("reads resident memory from /proc")
+	}
+
+	const (
+		sampleRate = 8000
+		length     = 4 * time.Hour
+		// Allowance above the early baseline for allocator and GC noise.
+		maxGrowth = 32 << 20
+	)
+	path := filepath.Join(t.TempDir(), "long.wav")
+	writeSilentWAV(t, path, sampleRate, length)
+
+	// The baseline is taken a minute in, after the decoder and buffers are
+	// allocated; a leak proportional to the file would add hundreds of MiB.
+	baselineFrame := 60 * config.FPS
+	var baseline, peak int64
+	guard := memlimit.New(1 << 30)
+	defer debug.SetMemoryLimit(math.MaxInt64)
+
+	profile, err := AnalyzeAudio(path, Span{}, FreqRange{}, VisFilter{}, guard, func(frame int, _ FrameAnalysis, _ []float64, _ time.Duration) {
+		if frame%(config.FPS*300) != 0 && frame != baselineFrame {
+			return
+		}
+		rss := residentBytes(t)
+		if frame == baselineFrame {
+			baseline = rss
+		}
+		peak = max(peak, rss)
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeAudio failed: %v", err)
+	}
+
+	wantFrames := int(length.Seconds()) * sampleRate / (sampleRate / config.FPS)
+	if diff := profile.NumFrames - wantFrames; diff < -1 || diff > 1 {
+		t.Errorf("NumFrames = %d, want about %d", profile.NumFrames, wantFrames)
+	}
+	if baseline == 0 {
+		t.Fatal("baseline resident memory was never sampled")
+	}
+	if growth := peak - baseline; growth > maxGrowth {
+		t.Errorf("resident memory grew by %dMiB over %v of audio (baseline %dMiB, peak %dMiB)",
+			growth>>20, length, baseline>>20, peak>>20)
+	}
+	t.Logf("Resident memory: baseline %dMiB, peak %dMiB", baseline>>20, peak>>20)
+}
+
 func TestOptimalBaseScaleCalculation(t *testing.T) {
 	profile := mustAnalyze(t)
 
@@ -114,7 +283,7 @@ func TestAnalyzeFrameDirectly(t *testing.T) {
 	}
 	coeffs := processor.ProcessChunk(testSamples)
 
-	analysis := analyzeFrame(coeffs, testSamples, nil)
+	analysis := analyzeFrame(coeffs, fullBands, testSamples, nil)
 
 	if analysis.PeakMagnitude <= 0 {
 		t.Errorf("Expected positive PeakMagnitude, got %.6f", analysis.PeakMagnitude)
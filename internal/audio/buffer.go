@@ -6,10 +6,15 @@ import (
 	"io"
 )
 
+// sampleReader is the part of StreamingReader the read helpers use.
+type sampleReader interface {
+	ReadInto(dst []float64) (int, error)
+}
+
 // readIntoBuffer fills buf from reader via repeated ReadInto calls. Returns the
 // number of samples read and the raw error from the underlying reader, including
 // io.EOF, so callers can apply their own end-of-file convention.
-func readIntoBuffer(reader *StreamingReader, buf []float64) (int, error) {
+func readIntoBuffer(reader sampleReader, buf []float64) (int, error) {
 	var total int
 	for total < len(buf) {
 		n, err := reader.ReadInto(buf[total:])
@@ -27,7 +32,7 @@ func readIntoBuffer(reader *StreamingReader, buf []float64) (int, error) {
 // FillFFTBuffer reads up to len(buf) samples from reader via repeated ReadChunk
 // calls. Returns the number of samples read. Returns (0, nil) on immediate EOF,
 // allowing callers to decide whether that is an error.
-func FillFFTBuffer(reader *StreamingReader, buf []float64) (int, error) {
+func FillFFTBuffer(reader Source, buf []float64) (int, error) {
 	total, err := readIntoBuffer(reader, buf)
 	if err != nil && !errors.Is(err, io.EOF) {
 		return total, err
@@ -38,7 +43,7 @@ func FillFFTBuffer(reader *StreamingReader, buf []float64) (int, error) {
 // ReadNextFrame reads up to len(buf) samples from reader into the provided
 // buffer. Returns the number of samples read. Returns (0, io.EOF) when no
 // samples are available. Returns (n, nil) for partial frames at end of file.
-func ReadNextFrame(reader *StreamingReader, buf []float64) (int, error) {
+func ReadNextFrame(reader Source, buf []float64) (int, error) {
 	total, err := readIntoBuffer(reader, buf)
 	if err != nil {
 		if errors.Is(err, io.EOF) {
@@ -51,3 +56,41 @@ func ReadNextFrame(reader *StreamingReader, buf []float64) (int, error) {
 	}
 	return total, nil
 }
+
+// ShiftIn slides samples into the end of an FFT window, dropping the oldest.
+// A frame longer than the window (--speed) leaves only its last len(buf)
+// samples. Callers zero-pad a short final read so stale samples never feed
+// the FFT.
+func ShiftIn(buf, samples []float64) {
+	if len(samples) >= len(buf) {
+		copy(buf, samples[len(samples)-len(buf):])
+		return
+	}
+	copy(buf, buf[len(samples):])
+	copy(buf[len(buf)-len(samples):], samples)
+}
+
+// Delay holds a signal back by a fixed number of samples, starting in
+// silence and carrying its state from call to call.
+type Delay struct {
+	line []float64
+	pos  int
+}
+
+// NewDelay returns a delay of n samples; 0 passes the signal straight
+// through.
+func NewDelay(n int) *Delay {
+	return &Delay{line: make([]float64, max(n, 0))}
+}
+
+// Apply delays src into dst, which may be the same slice.
+func (d *Delay) Apply(dst, src []float64) {
+	if len(d.line) == 0 {
+		copy(dst, src)
+		return
+	}
+	for i, x := range src {
+		dst[i], d.line[d.pos] = d.line[d.pos], x
+		d.pos = (d.pos + 1) % len(d.line)
+	}
+}
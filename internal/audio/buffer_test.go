@@ -1,8 +1,11 @@
+//go:build !lite
+
 package audio
 
 import (
 	"errors"
 	"io"
+	"slices"
 	"testing"
 )
 
@@ -164,3 +167,44 @@ func TestReadNextFrameImmediateEOF(t *testing.T) {
 		t.Errorf("Expected 0 samples on EOF, got %d", n)
 	}
 }
+
+func TestShiftIn(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []float64
+		want    []float64
+	}{
+		{"shorter than the window", []float64{5, 6}, []float64{3, 4, 5, 6}},
+		{"as long as the window", []float64{5, 6, 7, 8}, []float64{5, 6, 7, 8}},
+		{"longer than the window", []float64{5, 6, 7, 8, 9, 10}, []float64{7, 8, 9, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := []float64{1, 2, 3, 4}
+			ShiftIn(buf, tt.samples)
+			for i := range buf {
+				if buf[i] != tt.want[i] {
+					t.Fatalf("buffer = %v, want %v", buf, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDelay(t *testing.T) {
+	d := NewDelay(3)
+	got := []float64{1, 2}
+	d.Apply(got, got)
+	rest := []float64{3, 4, 5, 6}
+	d.Apply(rest, rest)
+	got = append(got, rest...)
+	if want := []float64{0, 0, 0, 1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("delayed by 3 across calls: %v, want %v", got, want)
+	}
+
+	through := []float64{1, 2, 3}
+	NewDelay(0).Apply(through, through)
+	if want := []float64{1, 2, 3}; !slices.Equal(through, want) {
+		t.Errorf("no delay: %v, want %v", through, want)
+	}
+}
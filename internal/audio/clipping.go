@@ -0,0 +1,149 @@
+package audio
+
+import "math"
+
+// Recordings with a DC offset or digital clipping skew what the bars and
+// Pass 1 see: a constant offset lands in the lowest FFT bins, holding the
+// bass bars up past the noise gate and inflating the RMS, and flat-topped
+// peaks splatter harmonics up the spectrum. Pass 1 measures both, the FFT
+// feed of both passes goes through a DCBlocker, and VisFilter.Declip can
+// rebuild clipped peaks before analysis.
+
+// Clipping detection and repair
+const (
+	clipLevel  = 0.999 // Samples at or beyond this, in a run, are clipped
+	clipRun    = 2     // Consecutive samples it takes to count as clipped
+	dcBlockHz  = 5.0   // DCBlocker cutoff, below anything a voice or instrument makes
+	declipTaps = 2     // Unclipped samples each side of a run the cubic repair fits
+)
+
+// clipStats measures the DC offset and clipping of the mono signal Pass 1
+// reads, a block at a time.
+type clipStats struct {
+	samples int64
+	clipped int64
+	sum     float64
+
+	// The clipped run in progress: its length and sign.
+	run      int
+	negative bool
+}
+
+func (c *clipStats) add(samples []float64) {
+	for _, v := range samples {
+		c.samples++
+		c.sum += v
+		if math.Abs(v) < clipLevel {
+			c.run = 0
+			continue
+		}
+		if math.Signbit(v) != c.negative {
+			c.run = 0
+		}
+		c.run++
+		c.negative = math.Signbit(v)
+		switch {
+		case c.run == clipRun:
+			c.clipped += clipRun
+		case c.run > clipRun:
+			c.clipped++
+		}
+	}
+}
+
+// offset returns the mean sample value, linear full scale.
+func (c *clipStats) offset() float64 {
+	if c.samples == 0 {
+		return 0
+	}
+	return c.sum / float64(c.samples)
+}
+
+// fraction returns the share of samples clipped.
+func (c *clipStats) fraction() float64 {
+	if c.samples == 0 {
+		return 0
+	}
+	return float64(c.clipped) / float64(c.samples)
+}
+
+// DCBlocker removes any DC offset from the signal the bars analyse with a
+// one-pole high-pass far below the audio, carrying its state from call to
+// call. A signal without an offset passes through it all but unchanged.
+type DCBlocker struct {
+	r      float64
+	x1, y1 float64
+}
+
+// NewDCBlocker returns a DC blocker for audio at sampleRate.
+func NewDCBlocker(sampleRate int) *DCBlocker {
+	return &DCBlocker{r: 1 - 2*math.Pi*dcBlockHz/float64(max(sampleRate, 1))}
+}
+
+// Apply filters src into dst, which may be the same slice.
+func (d *DCBlocker) Apply(dst, src []float64) {
+	for i, x := range src {
+		y := x - d.x1 + d.r*d.y1
+		d.x1, d.y1 = x, y
+		dst[i] = y
+	}
+}
+
+// declipper rebuilds clipped peaks: each run of clipped samples is replaced
+// by the cubic through the two samples either side of it, where that rises
+// past the clip. It sees a block at a time, keeping the last samples of the
+// one before as context; a run reaching the end of a block is left as it is.
+type declipper struct {
+	context []float64 // The last declipTaps raw samples of the previous block
+	ext     []float64 // context then the block, reused
+}
+
+func newDeclipper() *declipper {
+	return &declipper{context: make([]float64, declipTaps)}
+}
+
+// apply repairs src into dst, which may be the same slice.
+func (d *declipper) apply(dst, src []float64) {
+	d.ext = append(append(d.ext[:0], d.context...), src...)
+	copy(dst, src)
+	for s := declipTaps; s < len(d.ext); {
+		if math.Abs(d.ext[s]) < clipLevel {
+			s++
+			continue
+		}
+		e := s + 1
+		for e < len(d.ext) && math.Abs(d.ext[e]) >= clipLevel && math.Signbit(d.ext[e]) == math.Signbit(d.ext[s]) {
+			e++
+		}
+		if e-s >= clipRun && math.Abs(d.ext[s-1]) < clipLevel && e+declipTaps <= len(d.ext) {
+			d.repair(dst, s, e)
+		}
+		s = e
+	}
+	if n := len(d.ext); n >= declipTaps {
+		copy(d.context, d.ext[n-declipTaps:])
+	}
+}
+
+// repair fills the run ext[s:e] in dst, which is ext offset by the context.
+func (d *declipper) repair(dst []float64, s, e int) {
+	xs := [4]float64{float64(s - 2), float64(s - 1), float64(e), float64(e + 1)}
+	ys := [4]float64{d.ext[s-2], d.ext[s-1], d.ext[e], d.ext[e+1]}
+	for i := s; i < e; i++ {
+		x := float64(i)
+		var v float64
+		for j := range xs {
+			term := ys[j]
+			for k := range xs {
+				if k != j {
+					term *= (x - xs[k]) / (xs[j] - xs[k])
+				}
+			}
+			v += term
+		}
+		clipped := d.ext[i]
+		if math.Abs(v) > math.Abs(clipped) && math.Signbit(v) == math.Signbit(clipped) {
+			dst[i-declipTaps] = v
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClipStats(t *testing.T) {
+	var c clipStats
+	// A lone full-scale sample is a peak, not clipping; the runs of three
+	// and two are, the second split across blocks.
+	c.add([]float64{0.5, 1, 0.2, 1, 1, 1, -0.2, -1})
+	c.add([]float64{-1, 0.3})
+	if got, want := c.fraction(), 5.0/10; got != want {
+		t.Errorf("clipped fraction %.2f, want %.2f", got, want)
+	}
+	if got, want := c.offset(), (0.5+1+0.2+3-0.2-2+0.3)/10; math.Abs(got-want) > 1e-12 {
+		t.Errorf("offset %.4f, want %.4f", got, want)
+	}
+
+	// A run that flips sign at full scale is two runs of one.
+	var flip clipStats
+	flip.add([]float64{1, -1, 1, -1})
+	if got := flip.fraction(); got != 0 {
+		t.Errorf("alternating full scale: clipped fraction %.2f, want 0", got)
+	}
+}
+
+func TestDCBlocker(t *testing.T) {
+	const rate = 48000
+	in := interleavedSine(rate, 1, 1000, 0.5, 2)
+	for i := range in {
+		in[i] += 0.2
+	}
+	out := make([]float64, len(in))
+	d := NewDCBlocker(rate)
+	d.Apply(out, in[:1000])
+	d.Apply(out[1000:], in[1000:])
+
+	tail := out[len(out)/2:]
+	var sum float64
+	for _, v := range tail {
+		sum += v
+	}
+	if mean := sum / float64(len(tail)); math.Abs(mean) > 1e-3 {
+		t.Errorf("offset left after the blocker: %.4f", mean)
+	}
+	if got := acRMS(tail) * math.Sqrt2; math.Abs(got-0.5) > 0.005 {
+		t.Errorf("1 kHz amplitude %.4f after the blocker, want 0.5", got)
+	}
+}
+
+// TestDeclipper verifies a sine clipped at full scale has its peaks rebuilt
+// above the clip, close to the original, and that unclipped audio and runs
+// at a block's end are left alone.
+func TestDeclipper(t *testing.T) {
+	const rate = 48000
+	sine := interleavedSine(rate, 1, 200, 1.2, 0.05)
+	clipped := make([]float64, len(sine))
+	for i, v := range sine {
+		clipped[i] = max(-1, min(v, 1))
+	}
+	out := make([]float64, len(clipped))
+	d := newDeclipper()
+	// The first peak's run starts just after the first block, whose last
+	// samples the repair needs.
+	d.apply(out[:36], clipped[:36])
+	d.apply(out[36:], clipped[36:])
+
+	var peak, worst float64
+	for i := range out[:len(out)-declipTaps-1] {
+		peak = max(peak, math.Abs(out[i]))
+		if clipped[i] != sine[i] {
+			worst = max(worst, math.Abs(out[i]-sine[i])/math.Abs(sine[i]-clipped[i]))
+		}
+	}
+	if peak < 1.1 {
+		t.Errorf("rebuilt peak %.3f, want near 1.2", peak)
+	}
+	if worst > 0.5 {
+		t.Errorf("rebuilt samples off by %.0f%% of the clipping", worst*100)
+	}
+
+	quiet := interleavedSine(rate, 1, 200, 0.5, 0.05)
+	got := make([]float64, len(quiet))
+	newDeclipper().apply(got, quiet)
+	for i := range quiet {
+		if got[i] != quiet[i] {
+			t.Fatalf("unclipped sample %d changed: %g, want %g", i, got[i], quiet[i])
+		}
+	}
+}
+
+func TestFilterDeclip(t *testing.T) {
+	f, err := NewFilter(48000, VisFilter{Declip: true})
+	if err != nil || f == nil {
+		t.Fatalf("NewFilter with Declip = %v, %v; want a filter", f, err)
+	}
+	if got := 20 * math.Log10(sineGain(f, 48000, 20)); got > -6 {
+		t.Errorf("declip high-pass at 20 Hz: %.2f dB, want well below the passband", got)
+	}
+	if got := 20 * math.Log10(sineGain(f, 48000, 1000)); math.Abs(got) > 0.3 {
+		t.Errorf("declip high-pass at 1 kHz: %.2f dB, want 0", got)
+	}
+}
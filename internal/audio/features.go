@@ -0,0 +1,166 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// FeatureExtractor computes one feature of the audio per video frame, beside
+// the bar heights, for visualizers, scripts and the --export-features file.
+// Extract is called once a frame, in order, with the frame's FFT window of
+// mono samples and its spectrum from Processor.ProcessChunk. An extractor
+// may keep state between frames, and must not retain either slice.
+type FeatureExtractor interface {
+	Extract(samples []float64, spectrum Spectrum) float64
+}
+
+// FeatureFactory creates an extractor for one render of audio at sampleRate.
+type FeatureFactory func(sampleRate int) FeatureExtractor
+
+// Built-in features
+const (
+	FeatureCentroid = "centroid" // Spectral centroid in Hz: where the spectrum's weight sits
+	FeatureFlux     = "flux"     // Spectral flux (0-1): how much louder the spectrum got since the last frame
+	FeatureZCR      = "zcr"      // Zero-crossing rate (0-1): the fraction of samples changing sign, high for noise and sibilance
+)
+
+var (
+	featuresMu sync.RWMutex
+	features   = map[string]FeatureFactory{
+		FeatureCentroid: func(sampleRate int) FeatureExtractor { return centroid{sampleRate: sampleRate} },
+		FeatureFlux:     func(int) FeatureExtractor { return &flux{} },
+		FeatureZCR:      func(int) FeatureExtractor { return zeroCrossings{} },
+	}
+)
+
+// RegisterFeature makes a feature available under name to every render.
+// Custom builds call it from an init function, as they register visualizers.
+// It panics if name is empty or already registered, as that is a programming
+// error.
+func RegisterFeature(name string, factory FeatureFactory) {
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+	if name == "" || factory == nil {
+		panic("audio: RegisterFeature needs a name and a factory")
+	}
+	if _, dup := features[name]; dup {
+		panic(fmt.Sprintf("audio: feature %q registered twice", name))
+	}
+	features[name] = factory
+}
+
+// FeatureNames returns the registered feature names, sorted.
+func FeatureNames() []string {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	names := make([]string, 0, len(features))
+	for name := range features {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// FeatureSet extracts every registered feature each frame.
+type FeatureSet struct {
+	names      []string
+	extractors []FeatureExtractor
+	values     map[string]float64
+}
+
+// NewFeatureSet creates the extractors of every registered feature for audio
+// at sampleRate.
+func NewFeatureSet(sampleRate int) *FeatureSet {
+	s := &FeatureSet{names: FeatureNames(), values: make(map[string]float64)}
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	for _, name := range s.names {
+		s.extractors = append(s.extractors, features[name](sampleRate))
+	}
+	return s
+}
+
+// Extract computes the features of the next frame from its samples and
+// spectrum, and returns them by name. The map is reused by the next call.
+func (s *FeatureSet) Extract(samples []float64, spectrum Spectrum) map[string]float64 {
+	for i, name := range s.names {
+		s.values[name] = s.extractors[i].Extract(samples, spectrum)
+	}
+	return s.values
+}
+
+// Names returns the features extracted, sorted.
+func (s *FeatureSet) Names() []string {
+	return s.names
+}
+
+// magnitude returns the magnitude of bin k of spectrum.
+func magnitude(spectrum Spectrum, k int) float64 {
+	re, im := float64(spectrum[2*k]), float64(spectrum[2*k+1])
+	return math.Sqrt(re*re + im*im)
+}
+
+// centroid is the magnitude-weighted mean frequency of the spectrum; 0 for
+// silence.
+type centroid struct {
+	sampleRate int
+}
+
+func (c centroid) Extract(_ []float64, spectrum Spectrum) float64 {
+	binHz := float64(c.sampleRate) / config.FFTSize
+	var weighted, total float64
+	for k := range len(spectrum) / 2 {
+		m := magnitude(spectrum, k)
+		weighted += m * float64(k) * binHz
+		total += m
+	}
+	if total == 0 {
+		return 0
+	}
+	return weighted / total
+}
+
+// flux is the rise in magnitude of every bin since the last frame, as a
+// fraction of this frame's total magnitude, so a sudden onset reads near 1
+// and steady or fading sound near 0.
+type flux struct {
+	last []float64
+}
+
+func (f *flux) Extract(_ []float64, spectrum Spectrum) float64 {
+	bins := len(spectrum) / 2
+	if len(f.last) != bins {
+		f.last = make([]float64, bins)
+	}
+	var rise, total float64
+	for k := range bins {
+		m := magnitude(spectrum, k)
+		rise += max(m-f.last[k], 0)
+		total += m
+		f.last[k] = m
+	}
+	if total == 0 {
+		return 0
+	}
+	return rise / total
+}
+
+// zeroCrossings is the fraction of adjacent sample pairs that change sign.
+type zeroCrossings struct{}
+
+func (zeroCrossings) Extract(samples []float64, _ Spectrum) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	crossings := 0
+	for i := 1; i < len(samples); i++ {
+		if (samples[i-1] < 0) != (samples[i] < 0) {
+			crossings++
+		}
+	}
+	return float64(crossings) / float64(len(samples)-1)
+}
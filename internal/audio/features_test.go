@@ -0,0 +1,87 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// spectrumWith returns a spectrum whose only energy is magnitude m in bin k.
+func spectrumWith(k int, m float32) Spectrum {
+	s := make(Spectrum, 2*(config.FFTSize/2+1))
+	s[2*k] = m
+	return s
+}
+
+func TestCentroid(t *testing.T) {
+	c := centroid{sampleRate: 48000}
+	binHz := 48000.0 / config.FFTSize
+	if got := c.Extract(nil, spectrumWith(40, 1)); math.Abs(got-40*binHz) > 1e-9 {
+		t.Errorf("one bin: centroid %.2f Hz, want %.2f", got, 40*binHz)
+	}
+
+	// Equal energy in two bins sits midway between them.
+	s := spectrumWith(10, 2)
+	s[2*30+1] = 2
+	if got := c.Extract(nil, s); math.Abs(got-20*binHz) > 1e-9 {
+		t.Errorf("two bins: centroid %.2f Hz, want %.2f", got, 20*binHz)
+	}
+
+	if got := c.Extract(nil, make(Spectrum, 2*(config.FFTSize/2+1))); got != 0 {
+		t.Errorf("silence: centroid %.2f, want 0", got)
+	}
+}
+
+func TestFlux(t *testing.T) {
+	f := &flux{}
+	if got := f.Extract(nil, spectrumWith(5, 1)); got != 1 {
+		t.Errorf("onset: flux %.3f, want 1", got)
+	}
+	if got := f.Extract(nil, spectrumWith(5, 1)); got != 0 {
+		t.Errorf("steady: flux %.3f, want 0", got)
+	}
+	if got := f.Extract(nil, spectrumWith(5, 0.5)); got != 0 {
+		t.Errorf("fading: flux %.3f, want 0", got)
+	}
+	// The bin doubling adds half of the new total.
+	if got := f.Extract(nil, spectrumWith(5, 1)); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("rising: flux %.3f, want 0.5", got)
+	}
+}
+
+func TestZeroCrossings(t *testing.T) {
+	var z zeroCrossings
+	for _, tt := range []struct {
+		name    string
+		samples []float64
+		want    float64
+	}{
+		{"alternating", []float64{1, -1, 1, -1, 1}, 1},
+		{"constant", []float64{0.5, 0.5, 0.5}, 0},
+		{"one crossing", []float64{1, 1, -1, -1, -1}, 0.25},
+		{"too short", []float64{1}, 0},
+	} {
+		if got := z.Extract(tt.samples, nil); got != tt.want {
+			t.Errorf("%s: zcr %.3f, want %.3f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFeatureSet(t *testing.T) {
+	set := NewFeatureSet(48000)
+	for _, name := range []string{FeatureCentroid, FeatureFlux, FeatureZCR} {
+		if _, ok := set.Extract([]float64{1, -1}, spectrumWith(1, 1))[name]; !ok {
+			t.Errorf("feature %q missing from the set", name)
+		}
+	}
+}
+
+func TestRegisterFeatureDuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a built-in feature again did not panic")
+		}
+	}()
+	RegisterFeature(FeatureCentroid, func(int) FeatureExtractor { return zeroCrossings{} })
+}
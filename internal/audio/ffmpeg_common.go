@@ -1,36 +1,39 @@
+//go:build !lite
+
 package audio
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 
 	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/failure"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
 )
 
 // openAudioFormatCtx opens an audio file, finds stream info, and locates the
 // first audio stream. The caller is responsible for closing the returned
-// format context via AVFormatCloseInput.
+// format context via AVFormatCloseInput. Errors are marked
+// failure.ErrInputNotFound or failure.ErrUnsupportedFormat where the cause
+// is clear.
 func openAudioFormatCtx(filename string) (*ffmpeg.AVFormatContext, int, error) {
+	ffmpegutil.Init()
 	var formatCtx *ffmpeg.AVFormatContext
 
-	path := ffmpeg.ToCStr(filename)
-	defer path.Free()
+	var cs ffmpegutil.CStrings
+	defer cs.Free()
 
-	ret, err := ffmpeg.AVFormatOpenInput(&formatCtx, path, nil, nil)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to open audio file: %w", err)
-	}
-	if ret < 0 {
-		return nil, 0, fmt.Errorf("failed to open audio file: error code %d", ret)
+	ret, err := ffmpeg.AVFormatOpenInput(&formatCtx, cs.New(filename), nil, nil)
+	if err := ffmpegutil.Check(ret, err, "failed to open audio file"); err != nil {
+		return nil, 0, failure.Mark(openFailure(filename), err)
 	}
 
 	ret, err = ffmpeg.AVFormatFindStreamInfo(formatCtx, nil)
-	if err != nil {
-		ffmpeg.AVFormatCloseInput(&formatCtx)
-		return nil, 0, fmt.Errorf("failed to find stream info: %w", err)
-	}
-	if ret < 0 {
+	if err := ffmpegutil.Check(ret, err, "failed to find stream info"); err != nil {
 		ffmpeg.AVFormatCloseInput(&formatCtx)
-		return nil, 0, fmt.Errorf("failed to find stream info: error code %d", ret)
+		return nil, 0, failure.Mark(failure.ErrUnsupportedFormat, err)
 	}
 
 	audioStreamIdx := -1
@@ -44,8 +47,23 @@ func openAudioFormatCtx(filename string) (*ffmpeg.AVFormatContext, int, error) {
 	}
 	if audioStreamIdx == -1 {
 		ffmpeg.AVFormatCloseInput(&formatCtx)
-		return nil, 0, fmt.Errorf("no audio stream found in file")
+		return nil, 0, failure.Mark(failure.ErrUnsupportedFormat, fmt.Errorf("no audio stream found in file"))
 	}
 
 	return formatCtx, audioStreamIdx, nil
 }
+
+// openFailure classifies a file FFmpeg could not open: missing, or readable
+// but not a format FFmpeg knows. A file that cannot be read at all, such as
+// for want of permission, is neither.
+func openFailure(filename string) error {
+	f, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return failure.ErrInputNotFound
+	}
+	if err != nil {
+		return nil
+	}
+	f.Close()
+	return failure.ErrUnsupportedFormat
+}
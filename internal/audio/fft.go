@@ -3,42 +3,114 @@ package audio
 import (
 	"fmt"
 	"math"
-	"unsafe"
 
-	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
 	"github.com/linuxmatters/jivefire/internal/config"
 )
 
-// avComplexFloat mirrors C's AVComplexFloat: two contiguous 32-bit floats with
-// no padding (8 bytes). The generated ffmpeg.AVComplexFloat is an opaque pointer
-// wrapper with no value fields, so reading the RDFT output buffer through this
-// layout matches the on-wire struct av_tx writes, as tx_test.go does.
-type avComplexFloat struct {
-	re, im float32
-}
-
 // Spectrum is a flat float32 view of an RDFT forward (R2C) output: interleaved
 // re/im pairs for the N/2+1 complex bins (length 2*(N/2+1)). Bin i lives at
 // indices [2*i] (re) and [2*i+1] (im). ProcessChunk returns a Spectrum backed by
 // a reused buffer; callers must consume it before the next ProcessChunk call.
 type Spectrum []float32
 
-// binRawMagnitudes bins FFT coefficients into per-bar raw average magnitudes.
-// It writes config.NumBars values into result. The spectrum runs up to the
-// Nyquist frequency (~22kHz at 44.1kHz) to capture cymbals, hi-hats, and the
-// musical "air" in stings and bumpers. Each bar averages the per-bin magnitude
-// (hypot of the re/im pair) over its frequency range, dividing by binsPerBar.
-// Callers apply any normalisation on top of these raw values.
-func binRawMagnitudes(spectrum Spectrum, result []float64) {
-	// Bin only the positive-frequency half (bins 0 .. N/2-1); the Nyquist bin
-	// (index N/2) is discarded, matching the pre-swap []complex128 behaviour.
-	maxFreqBin := config.FFTSize / 2
-	binsPerBar := maxFreqBin / config.NumBars
+// FreqRange is the part of the spectrum spread across the bars, in Hz
+// (--freq-min, --freq-max). A zero Max runs to the Nyquist frequency, so the
+// zero value is the whole spectrum.
+type FreqRange struct {
+	Min, Max float64
+}
+
+// Bands maps FFT bins onto bars: bar i averages the bins from edges[i] up to,
+// but not including, edges[i+1]. Only the positive-frequency half (bins
+// 0 .. N/2-1) is binned; the Nyquist bin (index N/2) is discarded, matching
+// the pre-swap []complex128 behaviour.
+type Bands struct {
+	edges []int
+	binHz float64
+}
+
+// NewBands divides freq evenly across the bars at sampleRate. The whole
+// spectrum runs up to the Nyquist frequency (~22kHz at 44.1kHz) to capture
+// cymbals, hi-hats, and the musical "air" in stings and bumpers; a narrower
+// range such as 40-12000 Hz for voice gives each bar a finer slice. Each bar
+// needs at least one FFT bin, so a range narrower than that is an error.
+func NewBands(sampleRate int, freq FreqRange) (*Bands, error) {
+	binHz := float64(sampleRate) / config.FFTSize
+	nyquist := float64(sampleRate) / 2
+	maxBin := config.FFTSize / 2
+
+	hiHz := freq.Max
+	if hiHz == 0 {
+		hiHz = nyquist
+	}
+	if freq.Min < 0 || hiHz <= freq.Min {
+		return nil, fmt.Errorf("frequency range %g-%g Hz is empty", freq.Min, hiHz)
+	}
+	if hiHz > nyquist {
+		return nil, fmt.Errorf("%g Hz is above the %g Hz Nyquist frequency of a %d Hz input", hiHz, nyquist, sampleRate)
+	}
+
+	lo := int(freq.Min / binHz)
+	hi := min(int(math.Ceil(hiHz/binHz)), maxBin)
+	if hi-lo < config.NumBars {
+		return nil, fmt.Errorf("frequency range %g-%g Hz is too narrow for %d bars at %d Hz (needs at least %.0f Hz)", freq.Min, hiHz, config.NumBars, sampleRate, config.NumBars*binHz)
+	}
+
+	edges := make([]int, config.NumBars+1)
+	for i := range edges {
+		edges[i] = lo + int(math.Round(float64(i*(hi-lo))/config.NumBars))
+	}
+	return &Bands{edges: edges, binHz: binHz}, nil
+}
 
+// Frequencies returns the lower and upper edge in Hz of each bar's
+// frequency range, in bar (low to high) order before any rearrangement.
+func (b *Bands) Frequencies() (low, high []float64) {
+	low = make([]float64, config.NumBars)
+	high = make([]float64, config.NumBars)
 	for bar := range config.NumBars {
-		start := bar * binsPerBar
-		end := start + binsPerBar
-		end = min(end, maxFreqBin)
+		low[bar] = float64(b.edges[bar]) * b.binHz
+		high[bar] = float64(b.edges[bar+1]) * b.binHz
+	}
+	return low, high
+}
+
+// Split assigns each bar to a band by the frequency at its centre: band 0
+// below crossovers[0], band 1 from there to crossovers[1], and so on. The
+// bands follow bar (low to high) order, before any rearrangement. Bars are
+// FFT slices already, so splitting them stands in for crossover filters.
+func (b *Bands) Split(crossovers []float64) []int {
+	split := make([]int, config.NumBars)
+	for bar := range config.NumBars {
+		centre := float64(b.edges[bar]+b.edges[bar+1]) / 2 * b.binHz
+		for _, hz := range crossovers {
+			if centre >= hz {
+				split[bar]++
+			}
+		}
+	}
+	return split
+}
+
+// BarFrequencies returns the lower and upper edge in Hz of each bar's
+// frequency range at sampleRate across the whole spectrum.
+func BarFrequencies(sampleRate int) (low, high []float64) {
+	bands, err := NewBands(sampleRate, FreqRange{})
+	if err != nil {
+		// The whole spectrum always has a bin per bar; only a sample rate
+		// too low to decode gets here.
+		return make([]float64, config.NumBars), make([]float64, config.NumBars)
+	}
+	return bands.Frequencies()
+}
+
+// binRawMagnitudes bins FFT coefficients into per-bar raw average magnitudes.
+// It writes config.NumBars values into result. Each bar averages the per-bin
+// magnitude (hypot of the re/im pair) over its bins in bands. Callers apply
+// any normalisation on top of these raw values.
+func binRawMagnitudes(spectrum Spectrum, bands *Bands, result []float64) {
+	for bar := range config.NumBars {
+		start, end := bands.edges[bar], bands.edges[bar+1]
 
 		var sum float64
 		for i := start; i < end; i++ {
@@ -47,32 +119,69 @@ func binRawMagnitudes(spectrum Spectrum, result []float64) {
 			sum += math.Hypot(re, im)
 		}
 
-		result[bar] = sum / float64(binsPerBar)
+		result[bar] = sum / float64(end-start)
 	}
 }
 
+// Gate shapes quiet bars in BinFFT. Scaled magnitudes below Threshold are
+// treated as noise; Floor is the normalised height (0-1) a bar has at the
+// threshold. With a zero Floor gated bars drop to nothing. Otherwise they
+// shrink in proportion to their level, and every bar above the threshold is
+// at least Floor tall, so soft speech keeps the bars moving while true
+// silence still stays flat.
+type Gate struct {
+	Threshold float64
+	Floor     float64
+}
+
 // BinFFT bins FFT coefficients into bars and writes normalised values (0.0-1.0)
 // into the caller-provided result buffer. It works in normalised space (the
 // maxBarHeight pixel scaling is applied later); baseScale comes from Pass 1
 // analysis (OptimalBaseScale = 0.85 / GlobalPeak).
-func BinFFT(spectrum Spectrum, sensitivity float64, baseScale float64, result []float64) {
-	binRawMagnitudes(spectrum, result)
+func BinFFT(spectrum Spectrum, bands *Bands, sensitivity float64, baseScale float64, gate Gate, result []float64) {
+	binRawMagnitudes(spectrum, bands, result)
 
 	for i := range result {
 		scaled := result[i] * baseScale * sensitivity
 
 		// Noise gate on the raw value, before the log scale.
-		if scaled < 0.01 {
-			result[i] = 0
+		if scaled < gate.Threshold {
+			result[i] = gate.Floor * scaled / gate.Threshold
 		} else {
 			// Log10(1 + scaled*9) maps scaled in [0,1] to ~[0,1] for better visual
 			// dynamic range. Deliberately unclipped so the main loop's overshoot
 			// detection can drive sensitivity.
-			result[i] = math.Log10(1 + scaled*9)
+			result[i] = max(math.Log10(1+scaled*9), gate.Floor)
 		}
 	}
 }
 
+// spectrogramRange is the dynamic range in dB a SpectrumColumn spans: levels
+// that far below full scale are 0.
+const spectrogramRange = 60.0
+
+// SpectrumColumn resamples the bins across the bands' frequency range onto
+// len(result) rows, low frequency first, for a spectrogram. Each row takes
+// the loudest of its bins, scaled by baseScale from Pass 1 and mapped on a
+// dB scale to 0-1 over the spectrogramRange below full scale.
+func SpectrumColumn(spectrum Spectrum, bands *Bands, baseScale float64, result []float64) {
+	lo, hi := bands.edges[0], bands.edges[config.NumBars]
+	n := len(result)
+	for row := range n {
+		start := lo + row*(hi-lo)/n
+		end := max(lo+(row+1)*(hi-lo)/n, start+1)
+		var peak float64
+		for i := start; i < end; i++ {
+			peak = max(peak, math.Hypot(float64(spectrum[2*i]), float64(spectrum[2*i+1])))
+		}
+		level := 0.0
+		if scaled := peak * baseScale; scaled > 0 {
+			level = 1 + 20*math.Log10(scaled)/spectrogramRange
+		}
+		result[row] = min(max(level, 0), 1)
+	}
+}
+
 // RearrangeFrequenciesCenterOut mirrors the bars symmetrically about the centre,
 // writing into the caller-provided result buffer. Low frequencies (bass) land at
 // the centre and high frequencies fan out to the edges, so a quieter input still
@@ -86,129 +195,3 @@ func RearrangeFrequenciesCenterOut(barHeights []float64, result []float64) {
 		result[center+i] = barHeights[i]   // centre → right edge (mirror)
 	}
 }
-
-// Processor handles FFT analysis for visualisation.
-type Processor struct {
-	// Pre-computed Hanning window coefficients (avoids trig per sample)
-	hanningWindow []float64
-	// Reusable float32 spectrum buffer (interleaved re/im for N/2+1 bins),
-	// returned by ProcessChunk to avoid allocation per call.
-	spectrum Spectrum
-
-	// av_tx RDFT lifecycle. All raw C state stays inside Processor so the
-	// unsafe.Pointer/context boundary never leaks to consumers.
-	ctx    *ffmpeg.AVTXContext // transform context from AVTxInit
-	fn     ffmpeg.AVTxFn       // forward transform function pointer
-	inBuf  unsafe.Pointer      // C buffer: config.FFTSize real float32 samples
-	outBuf unsafe.Pointer      // C buffer: config.FFTSize/2+1 AVComplexFloat bins
-}
-
-// rdftRealStride is the byte stride of one real input sample (float32).
-const rdftRealStride = int(unsafe.Sizeof(float32(0)))
-
-// rdftComplexStride is the byte stride of one AVComplexFloat output bin: two
-// contiguous 32-bit floats with no padding, matching C's AVComplexFloat (8 bytes).
-const rdftComplexStride = 2 * int(unsafe.Sizeof(float32(0)))
-
-// NewProcessor creates a new audio processor with a pre-computed Hanning window
-// and an av_tx RDFT lifecycle. It returns an error if the C transform context or
-// its buffers cannot be allocated.
-func NewProcessor() (*Processor, error) {
-	window := make([]float64, config.FFTSize)
-	n := float64(config.FFTSize - 1)
-	for i := range config.FFTSize {
-		window[i] = 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/n))
-	}
-	p := &Processor{
-		hanningWindow: window,
-		spectrum:      make(Spectrum, 2*(config.FFTSize/2+1)),
-	}
-
-	// scale, ctx, and fn are locals, not Processor fields, then copied into p.
-	// cgo rejects a Go pointer into a struct that itself holds Go pointers (the
-	// slices above): av_tx_init takes the addresses of ctx/fn (it writes the
-	// transform function pointer through &fn) and reads &scale. scale needs no
-	// lifetime beyond this call; ctx/fn are stored for the transform's lifetime.
-	scale := float32(1.0)
-	var ctx *ffmpeg.AVTXContext
-	var fn ffmpeg.AVTxFn
-	if _, err := ffmpeg.AVTxInit(&ctx, &fn, ffmpeg.AVTxFloatRdft, 0 /*forward*/, config.FFTSize, unsafe.Pointer(&scale), 0); err != nil {
-		return nil, fmt.Errorf("av_tx_init (RDFT): %w", err)
-	}
-	p.ctx = ctx
-	p.fn = fn
-
-	// AVMalloc returns memory aligned to FFmpeg's max SIMD alignment (>= 32 bytes),
-	// satisfying av_tx_fn's default requirement so AV_TX_UNALIGNED is not needed.
-	// RDFT forward (R2C) input is a flat real array of config.FFTSize float32;
-	// output is config.FFTSize/2+1 AVComplexFloat bins.
-	inBytes := uint64(rdftRealStride * config.FFTSize)             //nolint:gosec // positive constants
-	outBytes := uint64(rdftComplexStride * (config.FFTSize/2 + 1)) //nolint:gosec // positive constants
-	p.inBuf = ffmpeg.AVMalloc(inBytes)
-	if p.inBuf == nil {
-		p.Close()
-		return nil, fmt.Errorf("av_malloc RDFT input buffer (%d bytes) failed", inBytes)
-	}
-	p.outBuf = ffmpeg.AVMalloc(outBytes)
-	if p.outBuf == nil {
-		p.Close()
-		return nil, fmt.Errorf("av_malloc RDFT output buffer (%d bytes) failed", outBytes)
-	}
-
-	return p, nil
-}
-
-// Close releases the av_tx context and C buffers. It is nil-guarded and
-// idempotent, so a partially constructed Processor and repeated calls are safe.
-func (p *Processor) Close() {
-	if p.ctx != nil {
-		// Pass a local to AVTxUninit: cgo rejects &p.ctx because Processor holds
-		// Go pointers (the slices). The uninit nils the local; we mirror that on p.
-		ctx := p.ctx
-		ffmpeg.AVTxUninit(&ctx)
-		p.ctx = nil
-	}
-	if p.inBuf != nil {
-		ffmpeg.AVFree(p.inBuf)
-		p.inBuf = nil
-	}
-	if p.outBuf != nil {
-		ffmpeg.AVFree(p.outBuf)
-		p.outBuf = nil
-	}
-}
-
-// ProcessChunk performs FFT on a chunk of audio samples.
-// Uses pre-computed Hanning window coefficients for better performance.
-// The returned slice is a buffer reused across calls; callers must fully
-// consume it before the next ProcessChunk call.
-func (p *Processor) ProcessChunk(samples []float64) Spectrum {
-	// Clamp to the window size; short final chunks are zero-padded by the loop below.
-	n := min(len(samples), config.FFTSize)
-
-	// Apply the Hanning window and write the windowed real samples directly into
-	// the C input buffer as float32. RDFT forward (R2C) takes a flat real array.
-	in := unsafe.Slice((*float32)(p.inBuf), config.FFTSize)
-	for i := range n {
-		in[i] = float32(samples[i] * p.hanningWindow[i])
-	}
-	// Zero-pad any remainder so samples beyond the input are treated as silence.
-	for i := n; i < config.FFTSize; i++ {
-		in[i] = 0
-	}
-
-	// Forward R2C transform: stride is one real sample in bytes.
-	ffmpeg.AVTxCall(p.fn, p.ctx, p.outBuf, p.inBuf, rdftRealStride)
-
-	// RDFT forward emits N/2+1 complex bins with DC at index 0, ascending in
-	// frequency. Copy the re/im pairs straight into the interleaved float32
-	// spectrum the consumers read; binRawMagnitudes bins indices 0 .. N/2-1 and
-	// discards the Nyquist bin (index N/2).
-	out := unsafe.Slice((*avComplexFloat)(p.outBuf), config.FFTSize/2+1)
-	for i := range out {
-		p.spectrum[2*i] = out[i].re
-		p.spectrum[2*i+1] = out[i].im
-	}
-
-	return p.spectrum
-}
@@ -0,0 +1,95 @@
+//go:build lite
+
+package audio
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// Processor handles FFT analysis for visualisation. The FFmpeg-free build
+// has no av_tx, so this is an iterative radix-2 FFT in Go; its bins match
+// the RDFT's (unnormalised, DC first, N/2+1 of them) to float32 precision.
+type Processor struct {
+	// Pre-computed Hanning window coefficients (avoids trig per sample)
+	hanningWindow []float64
+	// Reusable float32 spectrum buffer (interleaved re/im for N/2+1 bins),
+	// returned by ProcessChunk to avoid allocation per call.
+	spectrum Spectrum
+
+	buf      []complex128 // The transform, in place
+	twiddle  []complex128 // e^(-2πik/N) for k < N/2
+	reversed []int        // Bit-reversed position of each input sample
+}
+
+// NewProcessor creates a new audio processor with a pre-computed Hanning
+// window and twiddle factors. It returns an error if config.FFTSize is not a
+// power of two.
+func NewProcessor() (*Processor, error) {
+	n := config.FFTSize
+	if n < 2 || n&(n-1) != 0 {
+		return nil, fmt.Errorf("FFT size %d is not a power of two", n)
+	}
+	window := make([]float64, n)
+	for i := range n {
+		window[i] = 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(n-1)))
+	}
+	p := &Processor{
+		hanningWindow: window,
+		spectrum:      make(Spectrum, 2*(n/2+1)),
+		buf:           make([]complex128, n),
+		twiddle:       make([]complex128, n/2),
+		reversed:      make([]int, n),
+	}
+	for k := range p.twiddle {
+		sin, cos := math.Sincos(-2 * math.Pi * float64(k) / float64(n))
+		p.twiddle[k] = complex(cos, sin)
+	}
+	shift := bits.UintSize - bits.Len(uint(n-1))
+	for i := range p.reversed {
+		p.reversed[i] = int(bits.Reverse(uint(i)) >> shift) //nolint:gosec // below n
+	}
+	return p, nil
+}
+
+// Close is a no-op: the Go transform holds no C resources.
+func (p *Processor) Close() {}
+
+// ProcessChunk performs FFT on a chunk of audio samples.
+// Uses pre-computed Hanning window coefficients for better performance.
+// The returned slice is a buffer reused across calls; callers must fully
+// consume it before the next ProcessChunk call.
+func (p *Processor) ProcessChunk(samples []float64) Spectrum {
+	n := config.FFTSize
+
+	// Window the samples, as float32 like the RDFT's input, into bit-reversed
+	// order; a short final chunk is zero-padded.
+	for i := range n {
+		var v float64
+		if i < len(samples) {
+			v = float64(float32(samples[i] * p.hanningWindow[i]))
+		}
+		p.buf[p.reversed[i]] = complex(v, 0)
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		half, stride := size/2, n/size
+		for start := 0; start < n; start += size {
+			for k := range half {
+				t := p.twiddle[k*stride] * p.buf[start+k+half]
+				u := p.buf[start+k]
+				p.buf[start+k] = u + t
+				p.buf[start+k+half] = u - t
+			}
+		}
+	}
+
+	for i := range n/2 + 1 {
+		p.spectrum[2*i] = float32(real(p.buf[i]))
+		p.spectrum[2*i+1] = float32(imag(p.buf[i]))
+	}
+	return p.spectrum
+}
@@ -0,0 +1,47 @@
+//go:build lite
+
+package audio
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// TestLiteProcessorMatchesDFT verifies the Go FFT of the lite build gives
+// the same bins as a direct DFT of the windowed samples, as av_tx's RDFT
+// does, so bars are scaled alike in both builds.
+func TestLiteProcessorMatchesDFT(t *testing.T) {
+	p, err := NewProcessor()
+	if err != nil {
+		t.Fatalf("NewProcessor() error = %v", err)
+	}
+	defer p.Close()
+
+	n := config.FFTSize
+	rng := rand.New(rand.NewPCG(1, 2))
+	samples := make([]float64, n)
+	for i := range samples {
+		samples[i] = rng.Float64()*2 - 1
+	}
+	spectrum := p.ProcessChunk(samples)
+	if len(spectrum) != 2*(n/2+1) {
+		t.Fatalf("len(spectrum) = %d, want %d", len(spectrum), 2*(n/2+1))
+	}
+
+	for _, k := range []int{0, 1, 20, n / 4, n/2 - 1, n / 2} {
+		var re, im float64
+		for i, s := range samples {
+			v := float64(float32(s * p.hanningWindow[i]))
+			sin, cos := math.Sincos(-2 * math.Pi * float64(k*i) / float64(n))
+			re += v * cos
+			im += v * sin
+		}
+		gotRe, gotIm := float64(spectrum[2*k]), float64(spectrum[2*k+1])
+		if math.Abs(gotRe-re) > 1e-3 || math.Abs(gotIm-im) > 1e-3 {
+			t.Errorf("bin %d = (%g, %g), want (%g, %g)", k, gotRe, gotIm, re, im)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package audio
 import (
 	"math"
 	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
 )
 
 // TestBinFFT_KnownSineWave verifies that BinFFT correctly identifies a known
@@ -46,7 +48,7 @@ func TestBinFFT_KnownSineWave(t *testing.T) {
 
 	// Bin the FFT results into 64 bars
 	result := make([]float64, numBars)
-	BinFFT(fftInput, sensitivity, baseScale, result)
+	BinFFT(fftInput, fullBands, sensitivity, baseScale, defaultGate, result)
 
 	// Find the bar with maximum magnitude
 	maxVal := 0.0
@@ -118,7 +120,7 @@ func TestBinFFT_Silence(t *testing.T) {
 	silence := make(Spectrum, 2*(fftSize/2+1))
 
 	result := make([]float64, numBars)
-	BinFFT(silence, sensitivity, baseScale, result)
+	BinFFT(silence, fullBands, sensitivity, baseScale, defaultGate, result)
 
 	// All bars should be zero (or very close due to log scaling of near-zero)
 	for bar, val := range result {
@@ -169,7 +171,7 @@ func TestBinFFT_NoiseGate(t *testing.T) {
 	fftInput := processor.ProcessChunk(quietSignal)
 
 	result := make([]float64, numBars)
-	BinFFT(fftInput, sensitivity, baseScale, result)
+	BinFFT(fftInput, fullBands, sensitivity, baseScale, defaultGate, result)
 
 	// Most bars should be zero due to noise gate
 	zeroCount := 0
@@ -212,7 +214,7 @@ func TestBinFFT_EnergyDistribution(t *testing.T) {
 	fftInput := processor.ProcessChunk(signal)
 
 	result := make([]float64, numBars)
-	BinFFT(fftInput, sensitivity, baseScale, result)
+	BinFFT(fftInput, fullBands, sensitivity, baseScale, defaultGate, result)
 
 	// Sum all bar energies
 	totalEnergy := 0.0
@@ -544,3 +546,175 @@ func BenchmarkHanningPrecomputed(b *testing.B) {
 		}
 	}
 }
+
+// TestBarFrequencies verifies the bar edges tile 0 Hz up to just below
+// Nyquist without gaps, matching the bins BinFFT averages.
+func TestBarFrequencies(t *testing.T) {
+	low, high := BarFrequencies(44100)
+	if len(low) != config.NumBars || len(high) != config.NumBars {
+		t.Fatalf("got %d/%d edges, want %d", len(low), len(high), config.NumBars)
+	}
+	if low[0] != 0 {
+		t.Errorf("first bar starts at %v Hz, want 0", low[0])
+	}
+	for bar := 1; bar < config.NumBars; bar++ {
+		if low[bar] != high[bar-1] {
+			t.Errorf("bar %d starts at %v Hz, previous ends at %v Hz", bar, low[bar], high[bar-1])
+		}
+	}
+	// 1024 positive bins of 44100/2048 Hz; the Nyquist bin is not binned.
+	if want := 1024 * 44100.0 / 2048; high[config.NumBars-1] != want {
+		t.Errorf("last bar ends at %v Hz, want %v", high[config.NumBars-1], want)
+	}
+}
+
+// fullBands spreads the whole spectrum across the bars, as a render does
+// without --freq-min or --freq-max.
+var fullBands, _ = NewBands(config.SampleRate, FreqRange{})
+
+// defaultGate is the gate a render uses without --noise-gate or --min-bar.
+var defaultGate = Gate{Threshold: config.NoiseGate}
+
+// levelSpectrum returns a spectrum whose bins all have magnitude level, so
+// every bar's raw average is level.
+func levelSpectrum(level float32) Spectrum {
+	spectrum := make(Spectrum, 2*(config.FFTSize/2+1))
+	for i := 0; i < len(spectrum); i += 2 {
+		spectrum[i] = level
+	}
+	return spectrum
+}
+
+func TestBinFFTGateFloor(t *testing.T) {
+	result := make([]float64, config.NumBars)
+	gate := Gate{Threshold: 0.01, Floor: 0.1}
+
+	tests := []struct {
+		name  string
+		level float32
+		want  float64
+	}{
+		{"silence stays flat", 0, 0},
+		{"half the threshold gets half the floor", 0.005, 0.05},
+		{"just above the threshold is held at the floor", 0.011, 0.1},
+		{"loud bars are unaffected", 0.5, math.Log10(1 + 0.5*9)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			BinFFT(levelSpectrum(tt.level), fullBands, 1, 1, gate, result)
+			if math.Abs(result[0]-tt.want) > 1e-6 {
+				t.Errorf("bar = %.6f, want %.6f", result[0], tt.want)
+			}
+		})
+	}
+
+	// Without a floor the gate zeroes quiet bars, as it always has.
+	BinFFT(levelSpectrum(0.005), fullBands, 1, 1, defaultGate, result)
+	if result[0] != 0 {
+		t.Errorf("gated bar without a floor = %.6f, want 0", result[0])
+	}
+}
+
+// TestNewBandsRange verifies a voice range is spread across the bars from
+// the bin holding --freq-min to the one holding --freq-max.
+func TestNewBandsRange(t *testing.T) {
+	bands, err := NewBands(44100, FreqRange{Min: 40, Max: 12000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	low, high := bands.Frequencies()
+	binHz := 44100.0 / config.FFTSize
+	if low[0] > 40 || low[0] < 40-binHz {
+		t.Errorf("first bar starts at %v Hz, want the bin holding 40 Hz", low[0])
+	}
+	if top := high[config.NumBars-1]; top < 12000 || top > 12000+binHz {
+		t.Errorf("last bar ends at %v Hz, want the bin holding 12000 Hz", top)
+	}
+	for bar := range config.NumBars {
+		if high[bar] <= low[bar] {
+			t.Errorf("bar %d covers %v-%v Hz, want at least one bin", bar, low[bar], high[bar])
+		}
+		if bar > 0 && low[bar] != high[bar-1] {
+			t.Errorf("bar %d starts at %v Hz, previous ends at %v Hz", bar, low[bar], high[bar-1])
+		}
+	}
+}
+
+func TestBandsSplit(t *testing.T) {
+	bands, err := NewBands(48000, FreqRange{Max: 12000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	low, high := bands.Frequencies()
+	crossovers := []float64{250, 4000}
+	split := bands.Split(crossovers)
+	for bar, band := range split {
+		centre := (low[bar] + high[bar]) / 2
+		want := 0
+		switch {
+		case centre >= 4000:
+			want = 2
+		case centre >= 250:
+			want = 1
+		}
+		if band != want {
+			t.Errorf("bar %d (%.0f Hz) in band %d, want %d", bar, centre, band, want)
+		}
+		if bar > 0 && band < split[bar-1] {
+			t.Errorf("bar %d in band %d, below bar %d's band %d", bar, band, bar-1, split[bar-1])
+		}
+	}
+	if split[0] != 0 || split[config.NumBars-1] != 2 {
+		t.Errorf("bands run %d to %d, want 0 to 2", split[0], split[config.NumBars-1])
+	}
+	for bar, band := range bands.Split(nil) {
+		if band != 0 {
+			t.Fatalf("bar %d in band %d with no crossovers", bar, band)
+		}
+	}
+}
+
+func TestNewBandsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rate int
+		freq FreqRange
+	}{
+		{"min above max", 44100, FreqRange{Min: 5000, Max: 4000}},
+		{"negative min", 44100, FreqRange{Min: -10}},
+		{"above nyquist", 16000, FreqRange{Max: 12000}},
+		{"fewer bins than bars", 44100, FreqRange{Min: 100, Max: 600}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewBands(tt.rate, tt.freq); err == nil {
+				t.Errorf("NewBands(%d, %+v) succeeded, want an error", tt.rate, tt.freq)
+			}
+		})
+	}
+}
+
+// TestSpectrumColumn verifies the dB mapping: full scale is 1, the bottom of
+// the range and silence are 0, and a single loud bin lights only its row.
+func TestSpectrumColumn(t *testing.T) {
+	result := make([]float64, 100)
+	SpectrumColumn(levelSpectrum(1), fullBands, 1, result)
+	if result[0] != 1 || result[99] != 1 {
+		t.Errorf("full scale rows = %g, %g; want 1", result[0], result[99])
+	}
+	SpectrumColumn(levelSpectrum(0.01), fullBands, 1, result)
+	if want := 1 - 40/spectrogramRange; math.Abs(result[50]-want) > 1e-6 {
+		t.Errorf("-40 dB row = %g, want %g", result[50], want)
+	}
+	SpectrumColumn(levelSpectrum(0), fullBands, 1, result)
+	if result[50] != 0 {
+		t.Errorf("silent row = %g, want 0", result[50])
+	}
+
+	spectrum := levelSpectrum(0)
+	spectrum[2*512] = 1 // Half way up the spectrum
+	SpectrumColumn(spectrum, fullBands, 1, result)
+	if result[50] != 1 || result[49] != 0 || result[51] != 0 {
+		t.Errorf("rows around the loud bin = %g, %g, %g; want 0, 1, 0", result[49], result[50], result[51])
+	}
+}
@@ -0,0 +1,113 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+)
+
+// VisFilter shapes the signal the FFT sees, without touching the encoded
+// audio: HighPass removes room rumble below it, which otherwise dominates
+// the centre bars, and LowPass hiss above it. Cutoffs are in Hz; 0 disables
+// that side. Declip rebuilds clipped peaks first, and high-passes at
+// declipHighPass when HighPass is 0, as the rumble that often drives a
+// recording into clipping would hold up the bass bars.
+type VisFilter struct {
+	HighPass float64
+	LowPass  float64
+	Declip   bool
+}
+
+// declipHighPass is the gentle high-pass of VisFilter.Declip, in Hz.
+const declipHighPass = 40
+
+// Filter applies a VisFilter as second-order Butterworth sections, 12 dB
+// per octave beyond each cutoff, after any declipping.
+type Filter struct {
+	declip *declipper
+	stages []biquad
+}
+
+// butterworthQ gives a second-order section a maximally flat passband.
+const butterworthQ = math.Sqrt2 / 2
+
+// NewFilter returns the filter for vis at sampleRate, or nil when vis
+// filters nothing. Cutoffs must lie below the Nyquist frequency, with the
+// low-pass above the high-pass.
+func NewFilter(sampleRate int, vis VisFilter) (*Filter, error) {
+	nyquist := float64(sampleRate) / 2
+	switch {
+	case vis.HighPass < 0 || vis.LowPass < 0:
+		return nil, fmt.Errorf("cutoffs must not be negative")
+	case vis.HighPass >= nyquist || vis.LowPass >= nyquist:
+		return nil, fmt.Errorf("cutoffs must be below %g Hz, half the %d Hz sample rate", nyquist, sampleRate)
+	case vis.LowPass > 0 && vis.LowPass <= vis.HighPass:
+		return nil, fmt.Errorf("the low-pass cutoff (%g Hz) must be above the high-pass (%g Hz)", vis.LowPass, vis.HighPass)
+	}
+
+	var f Filter
+	if vis.Declip {
+		f.declip = newDeclipper()
+		if vis.HighPass == 0 && (vis.LowPass == 0 || vis.LowPass > declipHighPass) {
+			vis.HighPass = declipHighPass
+		}
+	}
+	if vis.HighPass > 0 {
+		f.stages = append(f.stages, newPassFilter(sampleRate, vis.HighPass, true))
+	}
+	if vis.LowPass > 0 {
+		f.stages = append(f.stages, newPassFilter(sampleRate, vis.LowPass, false))
+	}
+	if len(f.stages) == 0 && f.declip == nil {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+// Apply filters src into dst, which may be the same slice, carrying the
+// filter state on from the previous call.
+func (f *Filter) Apply(dst, src []float64) {
+	if f.declip != nil {
+		f.declip.apply(dst, src)
+		src = dst
+	}
+	for i, v := range src {
+		for s := range f.stages {
+			v = f.stages[s].process(v)
+		}
+		dst[i] = v
+	}
+}
+
+// newPassFilter returns a Butterworth high- or low-pass section at cutoff,
+// from the Audio EQ Cookbook's bilinear-transform designs.
+func newPassFilter(sampleRate int, cutoff float64, high bool) biquad {
+	w0 := 2 * math.Pi * cutoff / float64(sampleRate)
+	cos := math.Cos(w0)
+	alpha := math.Sin(w0) / (2 * butterworthQ)
+	a0 := 1 + alpha
+
+	b0, b1 := (1-cos)/2, 1-cos
+	if high {
+		b0, b1 = (1+cos)/2, -(1 + cos)
+	}
+	return biquad{
+		b0: b0 / a0,
+		b1: b1 / a0,
+		b2: b0 / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+// biquad is a second-order IIR filter in transposed direct form II.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
@@ -0,0 +1,74 @@
+package audio
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// IdleBars generates bar heights without any audio, for `jivefire idle`.
+// Each bar is a sum of sinusoids at a whole number of cycles per loop, so
+// the heights at frame n and frame n+frames match exactly and the video
+// loops without a seam. Every harmonic's phase drifts smoothly from bar to
+// bar, so the motion ripples across the spectrum like speech rather than
+// flickering bar by bar, and higher bars are quieter, as they are for real
+// audio.
+type IdleBars struct {
+	frames    int
+	harmonics []idleHarmonic
+}
+
+// idleHarmonic is one sinusoid of the motion; phase is its offset on bar 0
+// and spread how far the offset turns across all the bars.
+type idleHarmonic struct {
+	cycles int
+	amp    float64
+	phase  float64
+	spread float64
+}
+
+// idleMotion is the character of the motion: a slow swell, a phrase-length
+// rise and fall, and faster syllable-rate movement, in Hz and as a fraction
+// of full height.
+var idleMotion = []struct{ hz, amp float64 }{
+	{0.07, 0.14},
+	{0.3, 0.12},
+	{0.9, 0.10},
+	{1.9, 0.07},
+	{3.3, 0.04},
+}
+
+// idleBase is the height the motion swings around.
+const idleBase = 0.42
+
+// NewIdleBars returns a generator looping every frames frames at fps. The
+// seed picks the phases; the same seed draws the same loop.
+func NewIdleBars(frames, fps int, seed uint64) *IdleBars {
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	seconds := float64(frames) / float64(fps)
+	b := &IdleBars{frames: max(frames, 1)}
+	for _, m := range idleMotion {
+		b.harmonics = append(b.harmonics, idleHarmonic{
+			// Round to whole cycles per loop; a loop shorter than one
+			// cycle still moves through one.
+			cycles: max(int(math.Round(m.hz*seconds)), 1),
+			amp:    m.amp,
+			phase:  rng.Float64() * 2 * math.Pi,
+			spread: (0.5 + rng.Float64()) * 2 * math.Pi,
+		})
+	}
+	return b
+}
+
+// Heights fills heights, bass first as Pass 2's analysis orders them, with
+// frame n's heights as fractions of full height between 0 and 1.
+func (b *IdleBars) Heights(n int, heights []float64) {
+	t := float64(n%b.frames) / float64(b.frames)
+	for i := range heights {
+		pos := float64(i) / float64(max(len(heights)-1, 1))
+		v := idleBase
+		for _, h := range b.harmonics {
+			v += h.amp * math.Sin(2*math.Pi*float64(h.cycles)*t+h.phase+h.spread*pos)
+		}
+		heights[i] = min(max(v*(1-0.45*pos), 0.02), 1)
+	}
+}
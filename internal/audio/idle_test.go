@@ -0,0 +1,74 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+// The last frame of a loop must lead smoothly into the first: frame n and
+// frame n+loop are identical, and neighbouring frames across the seam differ
+// no more than neighbouring frames elsewhere.
+func TestIdleBarsLoop(t *testing.T) {
+	const frames, fps, bars = 1800, 30, 64
+	b := NewIdleBars(frames, fps, 1)
+	first, again := make([]float64, bars), make([]float64, bars)
+	b.Heights(0, first)
+	b.Heights(frames, again)
+	for i := range first {
+		if first[i] != again[i] {
+			t.Fatalf("bar %d: frame %d = %g, frame 0 = %g", i, frames, again[i], first[i])
+		}
+	}
+
+	step := func(n int) float64 {
+		a, c := make([]float64, bars), make([]float64, bars)
+		b.Heights(n, a)
+		b.Heights(n+1, c)
+		var d float64
+		for i := range a {
+			d = max(d, math.Abs(a[i]-c[i]))
+		}
+		return d
+	}
+	var largest float64
+	for n := range frames - 1 {
+		largest = max(largest, step(n))
+	}
+	if seam := step(frames - 1); seam > largest*1.01 {
+		t.Errorf("seam step %g is larger than any other (%g)", seam, largest)
+	}
+}
+
+func TestIdleBarsRange(t *testing.T) {
+	b := NewIdleBars(300, 30, 7)
+	heights := make([]float64, 64)
+	var moved bool
+	prev := make([]float64, 64)
+	for n := range 300 {
+		b.Heights(n, heights)
+		for i, h := range heights {
+			if h < 0 || h > 1 || math.IsNaN(h) {
+				t.Fatalf("frame %d bar %d: height %g", n, i, h)
+			}
+			if n > 0 && h != prev[i] {
+				moved = true
+			}
+		}
+		copy(prev, heights)
+	}
+	if !moved {
+		t.Error("bars never moved")
+	}
+
+	// The same seed draws the same loop; another seed a different one.
+	a, c := make([]float64, 64), make([]float64, 64)
+	NewIdleBars(300, 30, 7).Heights(42, a)
+	NewIdleBars(300, 30, 7).Heights(42, c)
+	if a[10] != c[10] {
+		t.Error("same seed drew different loops")
+	}
+	NewIdleBars(300, 30, 8).Heights(42, c)
+	if a[10] == c[10] {
+		t.Error("different seeds drew the same loop")
+	}
+}
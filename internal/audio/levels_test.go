@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+// TestTruePeakInterSample verifies a quarter-sample-rate sine sampled 45°
+// off its crests, whose samples all sit at -3 dB, is estimated near its true
+// full-scale peak.
+func TestTruePeakInterSample(t *testing.T) {
+	samples := make([]float64, 64)
+	for i := range samples {
+		samples[i] = math.Sin(math.Pi/2*float64(i) + math.Pi/4)
+	}
+
+	if got := SamplePeak(samples); math.Abs(got-math.Sqrt2/2) > 1e-9 {
+		t.Fatalf("SamplePeak() = %v, want %v", got, math.Sqrt2/2)
+	}
+	if got := TruePeak(samples); math.Abs(got-1) > 0.05 {
+		t.Errorf("TruePeak() = %v, want ~1.0", got)
+	}
+}
+
+// TestTruePeakNeverBelowSamplePeak verifies the estimate includes the sample
+// peak itself, including on inputs too short to interpolate.
+func TestTruePeakNeverBelowSamplePeak(t *testing.T) {
+	for _, samples := range [][]float64{
+		{0.5},
+		{0, -0.9, 0},
+		{0.1, 0.2, 0.3, 0.4, 0.5, 0.4, 0.3, 0.2, 0.1, 0},
+	} {
+		if tp, sp := TruePeak(samples), SamplePeak(samples); tp < sp {
+			t.Errorf("TruePeak(%v) = %v, below sample peak %v", samples, tp, sp)
+		}
+	}
+	if got := TruePeak(nil); got != 0 {
+		t.Errorf("TruePeak(nil) = %v, want 0", got)
+	}
+}
+
+// TestChannelLevels verifies each channel is measured on its own, and a mono
+// signal fills both sides of a stereo meter.
+func TestChannelLevels(t *testing.T) {
+	rms, peak := make([]float64, 2), make([]float64, 2)
+	ChannelLevels([]float32{0.5, -1, -0.5, 0}, 2, rms, peak)
+	if rms[0] != 0.5 || peak[0] != 0.5 || math.Abs(rms[1]-math.Sqrt(0.5)) > 1e-9 || peak[1] != 1 {
+		t.Errorf("stereo levels RMS %v, peak %v; want [0.5 0.707] and [0.5 1]", rms, peak)
+	}
+	ChannelLevels([]float32{0.25, -0.25}, 1, rms, peak)
+	if rms[0] != 0.25 || rms[1] != 0.25 || peak[0] != 0.25 || peak[1] != 0.25 {
+		t.Errorf("mono levels RMS %v, peak %v; want 0.25 on both", rms, peak)
+	}
+	ChannelLevels(nil, 2, rms, peak)
+	if rms[0] != 0 || peak[1] != 0 {
+		t.Errorf("empty levels RMS %v, peak %v; want zero", rms, peak)
+	}
+}
@@ -0,0 +1,102 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+// interleavedSine returns seconds of a sine at freq and amplitude amp, the
+// same on every channel.
+func interleavedSine(rate, channels int, freq, amp, seconds float64) []float64 {
+	n := int(seconds * float64(rate))
+	samples := make([]float64, n*channels)
+	for i := range n {
+		v := amp * math.Sin(2*math.Pi*freq*float64(i)/float64(rate))
+		for c := range channels {
+			samples[i*channels+c] = v
+		}
+	}
+	return samples
+}
+
+// BS.1770's reference: a full-scale 997Hz sine on one channel reads -3.01
+// LUFS, and on both channels of a stereo pair 0 LUFS.
+func TestLoudnessMeterSine(t *testing.T) {
+	tests := []struct {
+		rate, channels int
+		amp            float64
+		want           float64
+	}{
+		{48000, 1, 1, -3.01},
+		{48000, 2, 1, 0},
+		{44100, 1, 1, -3.01},
+		{44100, 2, 0.1, -20},
+		{22050, 1, 0.5, -9.03},
+	}
+	for _, tt := range tests {
+		m := NewLoudnessMeter(tt.rate, tt.channels)
+		m.Add(interleavedSine(tt.rate, tt.channels, 997, tt.amp, 5))
+		if got := m.Integrated(); math.Abs(got-tt.want) > 0.1 {
+			t.Errorf("%dHz, %d channels, amplitude %g: %.2f LUFS, want %.2f", tt.rate, tt.channels, tt.amp, got, tt.want)
+		}
+	}
+}
+
+// The LFE channel of 5.1 is not counted and the surrounds weigh 1.41.
+func TestLoudnessMeterSurround(t *testing.T) {
+	const rate = 48000
+	front := interleavedSine(rate, 1, 997, 1, 5)
+	for _, tt := range []struct {
+		channel int
+		want    float64
+	}{
+		{0, -3.01},
+		{3, math.Inf(-1)},
+		{4, -3.01 + 10*math.Log10(1.41)},
+	} {
+		samples := make([]float64, len(front)*6)
+		for i, v := range front {
+			samples[i*6+tt.channel] = v
+		}
+		m := NewLoudnessMeter(rate, 6)
+		m.Add(samples)
+		got := m.Integrated()
+		if math.IsInf(tt.want, -1) {
+			if !math.IsInf(got, -1) {
+				t.Errorf("channel %d: %.2f LUFS, want -Inf", tt.channel, got)
+			}
+		} else if math.Abs(got-tt.want) > 0.1 {
+			t.Errorf("channel %d: %.2f LUFS, want %.2f", tt.channel, got, tt.want)
+		}
+	}
+}
+
+// Silence between speech must not drag the measurement down, and a quiet
+// bed 20 LU under the programme falls below the relative gate.
+func TestLoudnessMeterGating(t *testing.T) {
+	const rate = 48000
+	m := NewLoudnessMeter(rate, 1)
+	m.Add(interleavedSine(rate, 1, 997, 0.1, 5))
+	m.Add(make([]float64, 10*rate))
+	m.Add(interleavedSine(rate, 1, 997, 0.01, 10))
+	if got := m.Integrated(); math.Abs(got-(-23.01)) > 0.2 {
+		t.Errorf("gated loudness %.2f LUFS, want -23.01", got)
+	}
+}
+
+func TestLoudnessMeterSilence(t *testing.T) {
+	m := NewLoudnessMeter(48000, 2)
+	if got := m.Integrated(); !math.IsInf(got, -1) {
+		t.Errorf("empty meter: %v, want -Inf", got)
+	}
+	m.Add(make([]float64, 48000*2*2))
+	if got := m.Integrated(); !math.IsInf(got, -1) {
+		t.Errorf("silence: %v, want -Inf", got)
+	}
+	// Shorter than one 400ms block.
+	m = NewLoudnessMeter(48000, 2)
+	m.Add(interleavedSine(48000, 2, 997, 1, 0.2))
+	if got := m.Integrated(); !math.IsInf(got, -1) {
+		t.Errorf("200ms of audio: %v, want -Inf", got)
+	}
+}
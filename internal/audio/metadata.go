@@ -1,33 +1,34 @@
 package audio
 
 import (
-	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/wav"
 )
 
 // Metadata holds information about an audio file.
 type Metadata struct {
 	NumSamples int64
 	SampleRate int
+	Codec      string        // Codec short name, e.g. "pcm_s16le"
+	Channels   int           // Channel count of the source
+	BitDepth   int           // Bits per sample; 0 for codecs without a fixed depth (e.g. MP3)
+	Duration   time.Duration // Stream duration
 }
 
-// GetMetadata uses ffmpeg to extract accurate audio file metadata.
-func GetMetadata(filename string) (*Metadata, error) {
-	inputCtx, audioStreamIdx, err := openAudioFormatCtx(filename)
+// WAVMetadata reads the metadata of a WAV or AIFF file with internal/wav,
+// the decoder --decoder=native and the FFmpeg-free build read it with.
+func WAVMetadata(filename string) (*Metadata, error) {
+	info, err := wav.Probe(filename)
 	if err != nil {
 		return nil, err
 	}
-	defer ffmpeg.AVFormatCloseInput(&inputCtx)
-
-	audioStream := inputCtx.Streams().Get(uintptr(audioStreamIdx)) //nolint:gosec // stream index is non-negative
-	codecpar := audioStream.Codecpar()
-
-	// Total samples derived from stream duration (in time_base units).
-	sampleRate := codecpar.SampleRate()
-	duration := float64(audioStream.Duration()) * float64(audioStream.TimeBase().Num()) / float64(audioStream.TimeBase().Den())
-	numSamples := int64(duration * float64(sampleRate))
-
 	return &Metadata{
-		NumSamples: numSamples,
-		SampleRate: sampleRate,
+		NumSamples: info.Frames,
+		SampleRate: info.SampleRate,
+		Codec:      info.Codec,
+		Channels:   info.Channels,
+		BitDepth:   info.BitDepth,
+		Duration:   info.Duration,
 	}, nil
 }
@@ -0,0 +1,40 @@
+//go:build !lite
+
+package audio
+
+import (
+	"time"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+)
+
+// GetMetadata uses ffmpeg to extract accurate audio file metadata.
+func GetMetadata(filename string) (*Metadata, error) {
+	inputCtx, audioStreamIdx, err := openAudioFormatCtx(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer ffmpeg.AVFormatCloseInput(&inputCtx)
+
+	audioStream := inputCtx.Streams().Get(uintptr(audioStreamIdx)) //nolint:gosec // stream index is non-negative
+	codecpar := audioStream.Codecpar()
+
+	// Total samples derived from stream duration (in time_base units).
+	sampleRate := codecpar.SampleRate()
+	duration := float64(audioStream.Duration()) * float64(audioStream.TimeBase().Num()) / float64(audioStream.TimeBase().Den())
+	numSamples := int64(duration * float64(sampleRate))
+
+	bitDepth := codecpar.BitsPerRawSample()
+	if bitDepth == 0 {
+		bitDepth = codecpar.BitsPerCodedSample()
+	}
+
+	return &Metadata{
+		NumSamples: numSamples,
+		SampleRate: sampleRate,
+		Codec:      ffmpeg.AVCodecGetName(codecpar.CodecId()).String(),
+		Channels:   codecpar.ChLayout().NbChannels(),
+		BitDepth:   bitDepth,
+		Duration:   time.Duration(duration * float64(time.Second)),
+	}, nil
+}
@@ -0,0 +1,221 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+)
+
+// MusicBed is the music a Mixer lays under the voice: the file, its level,
+// and how far it ducks while the voice is speaking (0 leaves it level).
+type MusicBed struct {
+	Path   string
+	GainDB float64
+	DuckDB float64
+}
+
+// Mixer is a Source mixing a looping music bed under a voice, so analysis
+// and encoding both see the mix. The bed is read at the voice's sample rate
+// and restarted whenever it runs out; the voice sets the length, the rate
+// and the channels. With ducking, the voice keys the bed's level: it falls
+// by DuckDB while the voice is speaking and recovers in the pauses.
+type Mixer struct {
+	voice, bed Source
+	gain       float64
+	duck       *ducker
+	channels   int // output channels enabled, 0 for the mono mix only
+
+	bedSamples []float64
+	bedOutput  []float32 // the bed's output frames for the last read
+	pending    []float32 // scaled bed frames to add to the voice's Output
+
+	// played records that the bed has produced samples since it last
+	// started; one that produces none is empty, and left out.
+	played bool
+	empty  bool
+}
+
+// NewMixer opens the music bed at the voice's sample rate and returns the
+// mixer, which closes voice when it is closed.
+func NewMixer(voice Source, music MusicBed) (*Mixer, error) {
+	bed, err := NewStreamingReaderRate(music.Path, voice.SampleRate())
+	if err != nil {
+		return nil, fmt.Errorf("music bed: %w", err)
+	}
+	return newMixer(voice, bed, music), nil
+}
+
+func newMixer(voice, bed Source, music MusicBed) *Mixer {
+	m := &Mixer{voice: voice, bed: bed, gain: math.Pow(10, music.GainDB/20)}
+	if music.DuckDB > 0 {
+		m.duck = newDucker(voice.SampleRate(), music.DuckDB)
+	}
+	return m
+}
+
+// ReadInto reads the voice into dst and adds the bed beneath it.
+func (m *Mixer) ReadInto(dst []float64) (int, error) {
+	n, err := m.voice.ReadInto(dst)
+	if n == 0 {
+		return n, err
+	}
+	m.bedSamples = slices.Grow(m.bedSamples[:0], n)[:n]
+	if err := m.readBed(m.bedSamples); err != nil {
+		return 0, err
+	}
+	for i, v := range dst[:n] {
+		g := m.gain
+		if m.duck != nil {
+			g *= m.duck.gain(v)
+		}
+		dst[i] = v + g*m.bedSamples[i]
+		if m.channels > 0 {
+			for _, s := range m.bedOutput[i*m.channels : (i+1)*m.channels] {
+				m.pending = append(m.pending, float32(g)*s)
+			}
+		}
+	}
+	return n, err
+}
+
+// readBed fills buf from the bed, starting it again at its end, and keeps
+// its output frames, which a Seek would discard, in m.bedOutput.
+func (m *Mixer) readBed(buf []float64) error {
+	m.bedOutput = m.bedOutput[:0]
+	for len(buf) > 0 {
+		if m.empty {
+			clear(buf)
+			m.bedOutput = append(m.bedOutput, make([]float32, len(buf)*m.channels)...)
+			return nil
+		}
+		n, err := m.bed.ReadInto(buf)
+		if n > 0 {
+			m.played = true
+			if m.channels > 0 {
+				m.bedOutput = append(m.bedOutput, m.bed.Output()...)
+			}
+		}
+		buf = buf[n:]
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return fmt.Errorf("reading music: %w", err)
+		}
+		if !m.played {
+			m.empty = true
+			continue
+		}
+		m.played = false
+		if err := m.bed.Seek(0); err != nil {
+			return fmt.Errorf("looping music: %w", err)
+		}
+	}
+	return nil
+}
+
+// EnableOutput enables the multi-channel output on the voice and the bed.
+func (m *Mixer) EnableOutput(channels int) error {
+	if err := m.voice.EnableOutput(channels); err != nil {
+		return err
+	}
+	if err := m.bed.EnableOutput(channels); err != nil {
+		return fmt.Errorf("music: %w", err)
+	}
+	m.channels = channels
+	return nil
+}
+
+// Output returns the voice's output frames with the bed's added.
+func (m *Mixer) Output() []float32 {
+	out := m.voice.Output()
+	for i := range min(len(out), len(m.pending)) {
+		out[i] += m.pending[i]
+	}
+	m.pending = m.pending[:0]
+	return out
+}
+
+// Seek positions the voice at start and the bed at its beginning.
+func (m *Mixer) Seek(start time.Duration) error {
+	if err := m.voice.Seek(start); err != nil {
+		return err
+	}
+	if err := m.bed.Seek(0); err != nil {
+		return fmt.Errorf("music: %w", err)
+	}
+	m.pending = m.pending[:0]
+	m.played, m.empty = false, false
+	if m.duck != nil {
+		m.duck.reset()
+	}
+	return nil
+}
+
+// SampleRate returns the voice's sample rate, which the bed is read at.
+func (m *Mixer) SampleRate() int {
+	return m.voice.SampleRate()
+}
+
+// Channels returns the channel count of the voice.
+func (m *Mixer) Channels() int {
+	return m.voice.Channels()
+}
+
+// Close closes the voice and the bed.
+func (m *Mixer) Close() error {
+	return errors.Join(m.voice.Close(), m.bed.Close())
+}
+
+// Ducking: the voice counts as speaking while its level, a peak follower
+// falling away over duckHold, is above duckThreshold. The bed's gain then
+// falls to the ducked level over duckAttack and recovers over duckRelease,
+// slowly enough that the music swells back rather than jumps.
+const (
+	duckThreshold = 0.01 // -40 dBFS
+	duckHold      = 0.1  // seconds
+	duckAttack    = 0.05 // seconds
+	duckRelease   = 0.5  // seconds
+)
+
+// ducker turns the voice, sample by sample, into the gain for the bed.
+type ducker struct {
+	ducked                float64 // the gain while the voice is speaking
+	hold, attack, release float64 // per-sample smoothing coefficients
+	level, current        float64
+}
+
+func newDucker(sampleRate int, duckDB float64) *ducker {
+	coef := func(seconds float64) float64 {
+		return 1 - math.Exp(-1/(seconds*float64(sampleRate)))
+	}
+	d := &ducker{
+		ducked:  math.Pow(10, -duckDB/20),
+		hold:    coef(duckHold),
+		attack:  coef(duckAttack),
+		release: coef(duckRelease),
+	}
+	d.reset()
+	return d
+}
+
+func (d *ducker) reset() {
+	d.level, d.current = 0, 1
+}
+
+// gain returns the bed's gain for the voice sample v.
+func (d *ducker) gain(v float64) float64 {
+	d.level = max(math.Abs(v), d.level-d.hold*d.level)
+	target, rate := 1.0, d.release
+	if d.level > duckThreshold {
+		target = d.ducked
+	}
+	if target < d.current {
+		rate = d.attack
+	}
+	d.current += (target - d.current) * rate
+	return d.current
+}
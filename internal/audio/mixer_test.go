@@ -0,0 +1,173 @@
+package audio
+
+import (
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// sliceSource is a Source serving samples in pieces of at most piece, with
+// an output whose channel c carries the sample times c+1.
+type sliceSource struct {
+	samples  []float64
+	rate     int
+	piece    int
+	pos      int
+	unread   int
+	channels int
+	out      []float32
+	seeks    int
+}
+
+func (s *sliceSource) ReadInto(dst []float64) (int, error) {
+	if s.pos >= len(s.samples) {
+		return 0, io.EOF
+	}
+	n := copy(dst[:min(len(dst), s.piece)], s.samples[s.pos:])
+	s.pos += n
+	s.unread += n
+	return n, nil
+}
+
+func (s *sliceSource) EnableOutput(channels int) error {
+	s.channels = channels
+	return nil
+}
+
+func (s *sliceSource) Output() []float32 {
+	s.out = s.out[:0]
+	for _, v := range s.samples[s.pos-s.unread : s.pos] {
+		for c := range s.channels {
+			s.out = append(s.out, float32(v)*float32(c+1))
+		}
+	}
+	s.unread = 0
+	return s.out
+}
+
+func (s *sliceSource) Seek(start time.Duration) error {
+	s.pos = min(int(start.Seconds()*float64(s.rate)), len(s.samples))
+	s.unread = 0
+	s.seeks++
+	return nil
+}
+
+func (s *sliceSource) SampleRate() int { return s.rate }
+func (s *sliceSource) Channels() int   { return max(s.channels, 1) }
+func (s *sliceSource) Close() error    { return nil }
+
+// The bed loops under the whole voice at its gain, in the mono mix and the
+// output alike, across reads that end mid-way through it.
+func TestMixerLoopsBed(t *testing.T) {
+	voice := &sliceSource{samples: make([]float64, 10), rate: 100, piece: 4}
+	for i := range voice.samples {
+		voice.samples[i] = float64(i)
+	}
+	bed := &sliceSource{samples: []float64{1, 2, 3}, rate: 100, piece: 2}
+	m := newMixer(voice, bed, MusicBed{GainDB: -20})
+	if err := m.EnableOutput(2); err != nil {
+		t.Fatal(err)
+	}
+
+	var mix []float64
+	var out []float32
+	buf := make([]float64, 3)
+	for {
+		n, err := m.ReadInto(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		mix = append(mix, buf[:n]...)
+		out = append(out, m.Output()...)
+	}
+	if len(mix) != 10 || len(out) != 20 {
+		t.Fatalf("read %d samples and %d output values, want 10 and 20", len(mix), len(out))
+	}
+	for i, got := range mix {
+		b := 0.1 * bed.samples[i%3]
+		if math.Abs(got-(float64(i)+b)) > 1e-9 {
+			t.Errorf("sample %d: %g, want %g", i, got, float64(i)+b)
+		}
+		for c := range 2 {
+			want := float64(c+1) * (float64(i) + b)
+			if got := float64(out[i*2+c]); math.Abs(got-want) > 1e-5 {
+				t.Errorf("output frame %d channel %d: %g, want %g", i, c, got, want)
+			}
+		}
+	}
+	if bed.seeks != 3 {
+		t.Errorf("bed restarted %d times, want 3", bed.seeks)
+	}
+}
+
+// Seek restarts the bed with the voice, so Pass 2 mixes what Pass 1 did.
+func TestMixerSeek(t *testing.T) {
+	voice := &sliceSource{samples: make([]float64, 8), rate: 4, piece: 8}
+	bed := &sliceSource{samples: []float64{1, 2, 3, 4, 5}, rate: 4, piece: 8}
+	m := newMixer(voice, bed, MusicBed{})
+	first := make([]float64, 3)
+	if _, err := m.ReadInto(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Seek(0); err != nil {
+		t.Fatal(err)
+	}
+	again := make([]float64, 3)
+	if _, err := m.ReadInto(again); err != nil {
+		t.Fatal(err)
+	}
+	for i := range first {
+		if first[i] != again[i] || first[i] != bed.samples[i] {
+			t.Errorf("sample %d: %g, then %g after Seek, want %g", i, first[i], again[i], bed.samples[i])
+		}
+	}
+}
+
+// A bed that decodes nothing leaves the voice as it is rather than
+// restarting for ever.
+func TestMixerEmptyBed(t *testing.T) {
+	voice := &sliceSource{samples: []float64{0.5, -0.5, 0.25}, rate: 100, piece: 8}
+	m := newMixer(voice, &sliceSource{rate: 100, piece: 8}, MusicBed{})
+	buf := make([]float64, 3)
+	n, err := m.ReadInto(buf)
+	if err != nil || n != 3 {
+		t.Fatalf("ReadInto: %d, %v", n, err)
+	}
+	for i, v := range buf {
+		if v != voice.samples[i] {
+			t.Errorf("sample %d: %g, want %g", i, v, voice.samples[i])
+		}
+	}
+}
+
+// While the voice speaks the bed falls by the ducking depth, and it comes
+// back up once the voice has been quiet a while.
+func TestMixerDucking(t *testing.T) {
+	const rate = 1000
+	speech := make([]float64, 3*rate)
+	for i := range rate {
+		speech[i] = 0.3 * math.Sin(2*math.Pi*150*float64(i)/rate)
+	}
+	voice := &sliceSource{samples: speech, rate: rate, piece: len(speech)}
+	bed := &sliceSource{samples: []float64{0.1}, rate: rate, piece: 1}
+	m := newMixer(voice, bed, MusicBed{DuckDB: 12})
+	mix := make([]float64, len(speech))
+	if _, err := m.ReadInto(mix); err != nil {
+		t.Fatal(err)
+	}
+
+	bedLevel := func(i int) float64 { return (mix[i] - speech[i]) / 0.1 }
+	if got, want := bedLevel(rate-1), math.Pow(10, -12.0/20); math.Abs(got-want) > 0.01 {
+		t.Errorf("bed under speech at %.3f, want %.3f", got, want)
+	}
+	if got := bedLevel(len(speech) - 1); got < 0.95 {
+		t.Errorf("bed two seconds after speech at %.3f, want it back near 1", got)
+	}
+	if got := bedLevel(0); got < 0.9 {
+		t.Errorf("bed at the first word at %.3f, want the duck to take time", got)
+	}
+}
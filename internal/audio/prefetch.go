@@ -0,0 +1,152 @@
+package audio
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// prefetchDepth is how many reads the Prefetcher decodes ahead: a quarter of
+// a second of video, enough to ride out a slow disk or a large packet
+// without holding much audio.
+const prefetchDepth = 8
+
+// Block is one read handed over by a Prefetcher: Samples is the mono audio,
+// and Output the matching multi-channel frames when the reader's output is
+// enabled. A short block comes only at the end of the stream.
+type Block struct {
+	Samples []float64
+	Output  []float32
+
+	samples []float64
+	err     error
+}
+
+// frameReader is the part of a Source the Prefetcher uses.
+type frameReader interface {
+	sampleReader
+	Output() []float32
+}
+
+// Prefetcher decodes audio on its own goroutine, ahead of the render loop,
+// so drawing and encoding a frame never wait on the disk or the decoder.
+// The first read is first samples long, to fill the FFT window, and every
+// one after it step samples. Blocks are recycled: hand each back with
+// Release once its samples have been used.
+//
+// While a Prefetcher runs it owns the reader; Close it before touching the
+// reader again.
+type Prefetcher struct {
+	blocks chan *Block
+	free   chan *Block
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	finished bool
+}
+
+// NewPrefetcher starts decoding from reader. multi copies the reader's
+// Output alongside the mono samples; enable the output on the reader first.
+func NewPrefetcher(reader Source, first, step int, multi bool) *Prefetcher {
+	return newPrefetcher(reader, first, step, multi)
+}
+
+func newPrefetcher(reader frameReader, first, step int, multi bool) *Prefetcher {
+	p := &Prefetcher{
+		blocks: make(chan *Block, prefetchDepth),
+		free:   make(chan *Block, prefetchDepth+1),
+		done:   make(chan struct{}),
+	}
+	// One block more than the queue holds, for the one the consumer has out.
+	for range prefetchDepth + 1 {
+		p.free <- &Block{samples: make([]float64, max(first, step))}
+	}
+	p.wg.Add(1)
+	go p.run(reader, first, step, multi)
+	return p
+}
+
+// run reads until end of stream, an error, or Close.
+func (p *Prefetcher) run(reader frameReader, size, step int, multi bool) {
+	defer p.wg.Done()
+	for {
+		var b *Block
+		select {
+		case b = <-p.free:
+		case <-p.done:
+			return
+		}
+		n, err := readIntoBuffer(reader, b.samples[:size])
+		b.Samples = b.samples[:n]
+		b.Output = b.Output[:0]
+		if multi && n > 0 {
+			b.Output = append(b.Output, reader.Output()...)
+		}
+		last := err != nil
+		if errors.Is(err, io.EOF) && n > 0 {
+			// Hand over the final partial read; io.EOF follows it.
+			err = nil
+		}
+		b.err = err
+		select {
+		case p.blocks <- b:
+		case <-p.done:
+			return
+		}
+		if last {
+			if err == nil {
+				p.sendEnd()
+			}
+			return
+		}
+		size = step
+	}
+}
+
+// sendEnd queues the io.EOF that follows a final partial read.
+func (p *Prefetcher) sendEnd() {
+	var b *Block
+	select {
+	case b = <-p.free:
+	case <-p.done:
+		return
+	}
+	b.Samples, b.Output, b.err = b.samples[:0], b.Output[:0], io.EOF
+	select {
+	case p.blocks <- b:
+	case <-p.done:
+	}
+}
+
+// Next returns the next read. At the end of the stream it returns io.EOF,
+// after any final short block; a decoding error is returned as it was met.
+// The block is valid until it is released.
+func (p *Prefetcher) Next() (*Block, error) {
+	if p.finished {
+		return nil, io.EOF
+	}
+	b := <-p.blocks
+	if b.err != nil {
+		p.finished = true
+		err := b.err
+		p.Release(b)
+		return nil, err
+	}
+	return b, nil
+}
+
+// Release returns a block for the decoder to fill again.
+func (p *Prefetcher) Release(b *Block) {
+	p.free <- b
+}
+
+// Close stops decoding and waits for the goroutine to let go of the reader.
+// It is safe to call more than once.
+func (p *Prefetcher) Close() {
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+	p.wg.Wait()
+}
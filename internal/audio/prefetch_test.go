@@ -0,0 +1,117 @@
+package audio
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// fakeReader serves a ramp of samples in irregular pieces, as a decoder
+// does, with a two-channel output that mirrors each sample.
+type fakeReader struct {
+	total, pos, unread int
+	piece              int
+	err                error // returned once pos reaches total, instead of io.EOF
+	out                []float32
+}
+
+func (f *fakeReader) ReadInto(dst []float64) (int, error) {
+	if f.pos >= f.total {
+		if f.err != nil {
+			return 0, f.err
+		}
+		return 0, io.EOF
+	}
+	n := min(len(dst), f.piece, f.total-f.pos)
+	for i := range n {
+		dst[i] = float64(f.pos + i)
+	}
+	f.pos += n
+	f.unread += n
+	return n, nil
+}
+
+func (f *fakeReader) Output() []float32 {
+	f.out = f.out[:0]
+	for i := f.pos - f.unread; i < f.pos; i++ {
+		f.out = append(f.out, float32(i), -float32(i))
+	}
+	f.unread = 0
+	return f.out
+}
+
+func TestPrefetcher(t *testing.T) {
+	const first, step, total = 2048, 1470, 2048 + 1470*5 + 100
+	p := newPrefetcher(&fakeReader{total: total, piece: 1000}, first, step, true)
+	defer p.Close()
+
+	var next int
+	var sizes []int
+	for {
+		b, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		sizes = append(sizes, len(b.Samples))
+		if len(b.Output) != 2*len(b.Samples) {
+			t.Fatalf("block of %d samples has %d output values", len(b.Samples), len(b.Output))
+		}
+		for i, v := range b.Samples {
+			if v != float64(next) || b.Output[2*i] != float32(next) {
+				t.Fatalf("sample %d: %g (output %g), want %d", next, v, b.Output[2*i], next)
+			}
+			next++
+		}
+		p.Release(b)
+	}
+	want := []int{first, step, step, step, step, step, 100}
+	if len(sizes) != len(want) {
+		t.Fatalf("block sizes %v, want %v", sizes, want)
+	}
+	for i := range want {
+		if sizes[i] != want[i] {
+			t.Errorf("block %d: %d samples, want %d", i, sizes[i], want[i])
+		}
+	}
+	if _, err := p.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next after the end: %v, want io.EOF", err)
+	}
+}
+
+func TestPrefetcherError(t *testing.T) {
+	broken := errors.New("damaged packet")
+	p := newPrefetcher(&fakeReader{total: 3000, piece: 500, err: broken}, 2048, 1470, false)
+	defer p.Close()
+	b, err := p.Next()
+	if err != nil || len(b.Samples) != 2048 || len(b.Output) != 0 {
+		t.Fatalf("first block: %v, %v", b, err)
+	}
+	p.Release(b)
+	if _, err := p.Next(); !errors.Is(err, broken) {
+		t.Errorf("Next: %v, want %v", err, broken)
+	}
+}
+
+// Closing with the queue full and blocks still out must stop the decoder
+// rather than leave it blocked.
+func TestPrefetcherClose(t *testing.T) {
+	p := newPrefetcher(&fakeReader{total: 1 << 30, piece: 4096}, 2048, 1470, false)
+	if _, err := p.Next(); err != nil {
+		t.Fatal(err)
+	}
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		p.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not stop the decoder")
+	}
+}
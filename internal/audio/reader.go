@@ -1,13 +1,19 @@
+//go:build !lite
+
 package audio
 
 import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"slices"
+	"time"
 	"unsafe"
 
 	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/failure"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
 )
 
 // swrOutBufferSamples is the initial capacity, in samples, of the reusable
@@ -16,12 +22,31 @@ import (
 // allocation occurs in the steady state.
 const swrOutBufferSamples = 8192
 
+// seekPreroll is how far ahead of its target a Seek starts decoding. Lapped
+// codecs build each frame partly from the one before (AAC and Vorbis overlap
+// their transforms, MP3 borrows bits from earlier frames, Opus asks for 80 ms
+// of warm-up), so the first frame decoded after a seek comes out wrong; it
+// falls in the pre-roll, which is dropped with the rest before the target.
+const seekPreroll = 250 * time.Millisecond
+
+// maxFrameChannels bounds the channel count accepted from a decoded frame.
+// FFmpeg's own decoders stay far below it; a larger value only comes from a
+// damaged stream.
+const maxFrameChannels = 64
+
 // StreamingReader provides chunk-based audio reading via FFmpeg's
 // libavformat/libavcodec, supporting any audio format FFmpeg can decode.
 //
 // Decoded samples of any input format and channel layout are converted to
 // packed mono float64 in [-1.0, 1.0] by libswresample, which applies the
-// correct downmix coefficients for multi-channel sources.
+// correct downmix coefficients for multi-channel sources. EnableOutput adds a
+// second conversion to the encoder's channel layout, read back with Output.
+//
+// The first sample read is the first a player would play. libavcodec drops
+// the encoder delay a file declares for its start (an MP3's gapless header,
+// an MP4 edit list, an Opus pre-skip), and priming left in the stream with
+// timestamps before zero is dropped here, so the first video frame lines up
+// with the audio's true start.
 type StreamingReader struct {
 	formatCtx   *ffmpeg.AVFormatContext
 	codecCtx    *ffmpeg.AVCodecContext
@@ -38,24 +63,63 @@ type StreamingReader struct {
 	outLayoutFrame *ffmpeg.AVFrame
 	outPlanes      []unsafe.Pointer
 	outCap         int
+	// The sample format, channel count and rate swr was configured for.
+	inFormat   int
+	inChannels int
+	inRate     int
 	// inPlanes is a reusable scratch slice of the decoded frame's plane
 	// pointers, refilled per frame to avoid an allocation in the read loop.
 	inPlanes []unsafe.Pointer
 
+	// With EnableOutput, multiSwr converts each frame again to interleaved
+	// float32 in the default layout for multiChannels (stereo, 5.1) for the
+	// encoder. multiBuffer holds one multi-channel frame for every mono
+	// sample, in step with sampleBuffer; the first multiRead of them have
+	// been read as mono and wait for Output.
+	multiChannels    int
+	multiSwr         *ffmpeg.SwrContext
+	multiLayoutFrame *ffmpeg.AVFrame
+	multiPlanes      []unsafe.Pointer
+	multiCap         int
+	multiBuffer      []float32
+	multiStore       []float32
+	multiRead        int
+
 	// drained marks that the decoder has been flushed at end-of-stream, so the
 	// delay buffer is not drained twice.
 	drained bool
 
-	// Buffer for leftover samples from previous decode.
+	// After a Seek, and on opening, seekTarget is where the next read should
+	// start and seekFrom where the demuxer was asked to seek to, its pre-roll
+	// ahead; the first decoded frame's timestamp sets skip, the samples still
+	// to drop before the target.
+	seekPending bool
+	seekTarget  time.Duration
+	seekFrom    time.Duration
+	skip        int
+
+	// Buffer for leftover samples from previous decode. Reads consume it from
+	// the front, so sampleStore keeps the whole backing array for
+	// growSampleBuffer to slide the unread samples back into.
 	sampleBuffer []float64
+	sampleStore  []float64
 }
 
 // NewStreamingReader creates a streaming audio reader for the given file.
 // Uses FFmpeg for broad format support (MP3, FLAC, WAV, OGG, AAC, etc.)
 func NewStreamingReader(filename string) (*StreamingReader, error) {
+	return NewStreamingReaderRate(filename, 0)
+}
+
+// NewStreamingReaderRate opens filename like NewStreamingReader, resampling
+// the audio to sampleRate so it can be mixed with another source; 0 keeps
+// the file's own rate.
+func NewStreamingReaderRate(filename string, sampleRate int) (*StreamingReader, error) {
 	d := &StreamingReader{
-		sampleBuffer: make([]float64, 0, 8192),
+		sampleStore: make([]float64, 8192),
+		seekPending: true,
 	}
+	d.sampleBuffer = d.sampleStore[:0]
 
 	formatCtx, streamIndex, err := openAudioFormatCtx(filename)
 	if err != nil {
@@ -69,7 +133,7 @@ func NewStreamingReader(filename string) (*StreamingReader, error) {
 	decoder := ffmpeg.AVCodecFindDecoder(audioStream.Codecpar().CodecId())
 	if decoder == nil {
 		d.Close()
-		return nil, fmt.Errorf("audio decoder not found for codec ID %d", audioStream.Codecpar().CodecId())
+		return nil, failure.Mark(failure.ErrUnsupportedFormat, fmt.Errorf("audio decoder not found for codec ID %d", audioStream.Codecpar().CodecId()))
 	}
 
 	d.codecCtx = ffmpeg.AVCodecAllocContext3(decoder)
@@ -79,26 +143,21 @@ func NewStreamingReader(filename string) (*StreamingReader, error) {
 	}
 
 	ret, err := ffmpeg.AVCodecParametersToContext(d.codecCtx, audioStream.Codecpar())
-	if err != nil {
+	if err := ffmpegutil.Check(ret, err, "failed to copy codec parameters"); err != nil {
 		d.Close()
-		return nil, fmt.Errorf("failed to copy codec parameters: %w", err)
-	}
-	if ret < 0 {
-		d.Close()
-		return nil, fmt.Errorf("failed to copy codec parameters: error code %d", ret)
+		return nil, err
 	}
 
 	ret, err = ffmpeg.AVCodecOpen2(d.codecCtx, decoder, nil)
-	if err != nil {
-		d.Close()
-		return nil, fmt.Errorf("failed to open codec: %w", err)
-	}
-	if ret < 0 {
+	if err := ffmpegutil.Check(ret, err, "failed to open codec"); err != nil {
 		d.Close()
-		return nil, fmt.Errorf("failed to open codec: error code %d", ret)
+		return nil, err
 	}
 
 	d.sampleRate = d.codecCtx.SampleRate()
+	if sampleRate > 0 {
+		d.sampleRate = sampleRate
+	}
 	d.channels = d.codecCtx.ChLayout().NbChannels()
 
 	d.packet = ffmpeg.AVPacketAlloc()
@@ -121,9 +180,23 @@ func NewStreamingReader(filename string) (*StreamingReader, error) {
 	return d, nil
 }
 
+// NewStreamingReaderAt opens filename like NewStreamingReader and seeks to
+// start, for rendering a section of the file.
+func NewStreamingReaderAt(filename string, start time.Duration) (*StreamingReader, error) {
+	d, err := NewStreamingReader(filename)
+	if err != nil || start <= 0 {
+		return d, err
+	}
+	if err := d.Seek(start); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
 // initResampler configures libswresample to convert the decoder's channel
-// layout, sample format, and rate into packed mono float64 at the same rate,
-// and allocates the reusable output buffer. swr handles every sample format,
+// layout, sample format, and rate into packed mono float64 at the reader's
+// rate, and allocates the reusable output buffer. swr handles every sample format,
 // planar or packed, and any channel count, applying correct downmix
 // coefficients in place of a hand-rolled stereo average.
 func (d *StreamingReader) initResampler() error {
@@ -133,59 +206,174 @@ func (d *StreamingReader) initResampler() error {
 	if d.outLayoutFrame == nil {
 		return fmt.Errorf("failed to allocate output layout frame")
 	}
-	outLayout := d.outLayoutFrame.ChLayout()
-	ffmpeg.AVChannelLayoutDefault(outLayout, 1)
+	ffmpeg.AVChannelLayoutDefault(d.outLayoutFrame.ChLayout(), 1)
+
+	if err := d.configureResampler(d.codecCtx.ChLayout(), d.codecCtx.SampleFmt(), d.codecCtx.SampleRate()); err != nil {
+		return err
+	}
+
+	if err := d.growOutputBuffer(swrOutBufferSamples); err != nil {
+		return err
+	}
+
+	return nil
+}
 
+// configureResampler sets up swr to convert from the given input layout,
+// format and rate to packed mono float64 at the reader's sample rate.
+func (d *StreamingReader) configureResampler(inLayout *ffmpeg.AVChannelLayout, inFormat ffmpeg.AVSampleFormat, inRate int) error {
+	if err := openResampler(&d.swr, d.outLayoutFrame.ChLayout(), ffmpeg.AVSampleFmtDbl, d.sampleRate, inLayout, inFormat, inRate); err != nil {
+		return err
+	}
+	if d.multiLayoutFrame != nil {
+		if err := openResampler(&d.multiSwr, d.multiLayoutFrame.ChLayout(), ffmpeg.AVSampleFmtFlt, d.sampleRate, inLayout, inFormat, inRate); err != nil {
+			return err
+		}
+	}
+
+	d.inFormat = int(inFormat)
+	d.inChannels = inLayout.NbChannels()
+	d.inRate = inRate
+	return nil
+}
+
+// openResampler allocates and initialises a swr context converting the input
+// layout, format and rate to the output ones.
+func openResampler(swr **ffmpeg.SwrContext, outLayout *ffmpeg.AVChannelLayout, outFormat ffmpeg.AVSampleFormat, outRate int, inLayout *ffmpeg.AVChannelLayout, inFormat ffmpeg.AVSampleFormat, inRate int) error {
 	ret, err := ffmpeg.SwrAllocSetOpts2(
-		&d.swr,
-		outLayout, ffmpeg.AVSampleFmtDbl, d.sampleRate,
-		d.codecCtx.ChLayout(), d.codecCtx.SampleFmt(), d.sampleRate,
+		swr,
+		outLayout, outFormat, outRate,
+		inLayout, inFormat, inRate,
 		0, nil,
 	)
-	if err != nil {
-		return fmt.Errorf("failed to configure resampler: %w", err)
+	if err := ffmpegutil.Check(ret, err, "failed to configure resampler"); err != nil {
+		return err
 	}
-	if ret < 0 || d.swr == nil {
-		return fmt.Errorf("failed to configure resampler: error code %d", ret)
+	if *swr == nil {
+		return fmt.Errorf("failed to configure resampler")
 	}
 
-	if ret, err := ffmpeg.SwrInit(d.swr); err != nil {
-		return fmt.Errorf("failed to initialise resampler: %w", err)
-	} else if ret < 0 {
-		return fmt.Errorf("failed to initialise resampler: error code %d", ret)
+	ret, err = ffmpeg.SwrInit(*swr)
+	return ffmpegutil.Check(ret, err, "failed to initialise resampler")
+}
+
+// EnableOutput makes the reader also convert the audio to interleaved
+// float32 with channels channels, in FFmpeg's default layout for the count,
+// so the encoder gets the source's own channels rather than the mono
+// downmix: stereo stays stereo, and a surround source is either kept as 5.1
+// or downmixed with swr's standard coefficients. Call it before the first
+// read, or straight after a Seek; enabling the same count again does nothing,
+// so Pass 2 can enable the output on the reader Pass 1 already set up.
+func (d *StreamingReader) EnableOutput(channels int) error {
+	if channels < 1 || channels > maxFrameChannels {
+		return fmt.Errorf("invalid output channel count %d", channels)
+	}
+	if channels == d.multiChannels {
+		return nil
+	}
+	if d.multiLayoutFrame != nil {
+		ffmpeg.AVFrameFree(&d.multiLayoutFrame)
+	}
+	if d.multiPlanes != nil {
+		ffmpeg.AVSamplesFreePlanes(d.multiPlanes)
+		d.multiPlanes = nil
 	}
+	ffmpeg.SwrFree(&d.multiSwr)
+	d.multiLayoutFrame = ffmpeg.AVFrameAlloc()
+	if d.multiLayoutFrame == nil {
+		return fmt.Errorf("failed to allocate output layout frame")
+	}
+	ffmpeg.AVChannelLayoutDefault(d.multiLayoutFrame.ChLayout(), channels)
+	d.multiChannels = channels
+	d.multiStore = make([]float32, len(d.sampleStore)*channels)
+	d.multiBuffer = d.multiStore[:0]
 
-	if err := d.growOutputBuffer(swrOutBufferSamples); err != nil {
+	if err := openResampler(&d.multiSwr, d.multiLayoutFrame.ChLayout(), ffmpeg.AVSampleFmtFlt, d.sampleRate, d.codecCtx.ChLayout(), ffmpeg.AVSampleFormat(d.inFormat), d.inRate); err != nil { //nolint:gosec // inFormat came from a valid AVSampleFormat
 		return err
 	}
+	return d.growOutputBuffer(d.outCap)
+}
 
-	return nil
+// Output returns the interleaved samples, multiChannels per frame, matching
+// the mono samples read since the last call. The slice is only valid until
+// the next read. Without EnableOutput it returns nil.
+func (d *StreamingReader) Output() []float32 {
+	n := d.multiRead * d.multiChannels
+	out := d.multiBuffer[:n]
+	d.multiBuffer = d.multiBuffer[n:]
+	d.multiRead = 0
+	return out
 }
 
-// growOutputBuffer (re)allocates the reusable mono float64 output buffer to hold
-// at least n samples. It is called once at setup and only again if a decoded
-// frame ever exceeds the current capacity, so the steady-state read loop makes
-// no allocations.
-func (d *StreamingReader) growOutputBuffer(n int) error {
-	if n <= d.outCap && d.outPlanes != nil {
+// consume drops n samples read from the front of the sample buffer, marking
+// the matching multi-channel frames for Output.
+func (d *StreamingReader) consume(n int) {
+	d.sampleBuffer = d.sampleBuffer[n:]
+	if d.multiChannels > 0 {
+		d.multiRead += n
+	}
+}
+
+// matchResampler reconfigures swr when a decoded frame's sample format,
+// channel count or rate differs from what it was set up for. Damaged or
+// spliced streams can change these mid-file, and swr reads as many planes as
+// it was configured for, so a frame with fewer would be read out of bounds.
+func (d *StreamingReader) matchResampler() error {
+	format := d.frame.Format()
+	channels := d.frame.ChLayout().NbChannels()
+	rate := d.frame.SampleRate()
+	if rate <= 0 {
+		rate = d.inRate
+	}
+	if format == d.inFormat && channels == d.inChannels && rate == d.inRate {
 		return nil
 	}
-	if d.outPlanes != nil {
-		ffmpeg.AVSamplesFreePlanes(d.outPlanes)
-		d.outPlanes = nil
+	if format < 0 || channels <= 0 || channels > maxFrameChannels {
+		return fmt.Errorf("decoded frame has an invalid layout: format %d, %d channels", format, channels)
 	}
-	planes, _, ret, err := ffmpeg.AVSamplesAlloc(1, n, ffmpeg.AVSampleFmtDbl, 0)
-	if err != nil {
-		return fmt.Errorf("failed to allocate resampler output buffer: %w", err)
+
+	ffmpeg.SwrFree(&d.swr)
+	ffmpeg.SwrFree(&d.multiSwr)
+	return d.configureResampler(d.frame.ChLayout(), ffmpeg.AVSampleFormat(format), rate) //nolint:gosec // format checked non-negative above
+}
+
+// growOutputBuffer (re)allocates the reusable mono float64 output buffer, and
+// the multi-channel one when enabled, to hold at least n samples. It is called
+// once at setup and only again if a decoded frame ever exceeds the current
+// capacity, so the steady-state read loop makes no allocations.
+func (d *StreamingReader) growOutputBuffer(n int) error {
+	if n > d.outCap || d.outPlanes == nil {
+		planes, err := allocSamples(d.outPlanes, 1, n, ffmpeg.AVSampleFmtDbl)
+		if err != nil {
+			return err
+		}
+		d.outPlanes = planes
+		d.outCap = n
 	}
-	if ret < 0 {
-		return fmt.Errorf("failed to allocate resampler output buffer: error code %d", ret)
+	if d.multiChannels > 0 && (n > d.multiCap || d.multiPlanes == nil) {
+		planes, err := allocSamples(d.multiPlanes, d.multiChannels, n, ffmpeg.AVSampleFmtFlt)
+		if err != nil {
+			return err
+		}
+		d.multiPlanes = planes
+		d.multiCap = n
 	}
-	d.outPlanes = planes
-	d.outCap = n
 	return nil
 }
 
+// allocSamples frees old, if any, and allocates a packed sample buffer for n
+// samples of channels channels.
+func allocSamples(old []unsafe.Pointer, channels, n int, format ffmpeg.AVSampleFormat) ([]unsafe.Pointer, error) {
+	if old != nil {
+		ffmpeg.AVSamplesFreePlanes(old)
+	}
+	planes, _, ret, err := ffmpeg.AVSamplesAlloc(channels, n, format, 0)
+	if err := ffmpegutil.Check(ret, err, "failed to allocate resampler output buffer"); err != nil {
+		return nil, err
+	}
+	return planes, nil
+}
+
 // ReadChunk reads the next chunk of samples as float64.
 // Multi-channel input is automatically downmixed to mono.
 // Returns io.EOF when no more samples are available.
@@ -209,7 +397,7 @@ func (d *StreamingReader) ReadInto(dst []float64) (int, error) {
 	// Satisfy from the buffer when possible.
 	if len(d.sampleBuffer) >= numSamples {
 		copy(dst, d.sampleBuffer[:numSamples])
-		d.sampleBuffer = d.sampleBuffer[numSamples:]
+		d.consume(numSamples)
 		return numSamples, nil
 	}
 
@@ -233,15 +421,14 @@ func (d *StreamingReader) ReadInto(dst []float64) (int, error) {
 				if len(d.sampleBuffer) > 0 {
 					n := min(numSamples, len(d.sampleBuffer))
 					copy(dst, d.sampleBuffer[:n])
-					d.sampleBuffer = d.sampleBuffer[n:]
+					d.consume(n)
 					return n, nil
 				}
 				return 0, io.EOF
 			}
-			return 0, fmt.Errorf("failed to read packet: %w", err)
 		}
-		if ret < 0 {
-			return 0, fmt.Errorf("failed to read packet: error code %d", ret)
+		if err := ffmpegutil.Check(ret, err, "failed to read packet"); err != nil {
+			return 0, err
 		}
 
 		if d.packet.StreamIndex() != d.streamIndex {
@@ -258,7 +445,7 @@ func (d *StreamingReader) ReadInto(dst []float64) (int, error) {
 		for {
 			_, err = ffmpeg.AVCodecReceiveFrame(d.codecCtx, d.frame)
 			if err != nil {
-				if errors.Is(err, ffmpeg.AVErrorEOF) || errors.Is(err, ffmpeg.EAgain) {
+				if ffmpegutil.Drained(err) {
 					break
 				}
 				return 0, fmt.Errorf("failed to receive frame: %w", err)
@@ -273,7 +460,7 @@ func (d *StreamingReader) ReadInto(dst []float64) (int, error) {
 	}
 
 	copy(dst, d.sampleBuffer[:numSamples])
-	d.sampleBuffer = d.sampleBuffer[numSamples:]
+	d.consume(numSamples)
 	return numSamples, nil
 }
 
@@ -287,7 +474,7 @@ func (d *StreamingReader) flushDecoder() error {
 	for {
 		_, err := ffmpeg.AVCodecReceiveFrame(d.codecCtx, d.frame)
 		if err != nil {
-			if errors.Is(err, ffmpeg.AVErrorEOF) || errors.Is(err, ffmpeg.EAgain) {
+			if ffmpegutil.Drained(err) {
 				break
 			}
 			return fmt.Errorf("failed to receive frame: %w", err)
@@ -309,9 +496,16 @@ func (d *StreamingReader) flushDecoder() error {
 // any sample format, planar or packed, and any channel layout.
 func (d *StreamingReader) extractSamples() error {
 	nbSamples := d.frame.NbSamples()
-	if nbSamples == 0 {
+	if nbSamples <= 0 {
 		return nil
 	}
+	if err := d.matchResampler(); err != nil {
+		return err
+	}
+	if d.seekPending {
+		d.seekPending = false
+		d.skip = d.samplesBefore(d.frame.BestEffortTimestamp())
+	}
 
 	// Upper bound on the output sample count for this input, accounting for any
 	// samples still buffered inside swr. Grow the reusable output buffer if the
@@ -331,8 +525,8 @@ func (d *StreamingReader) extractSamples() error {
 
 // drainResampler flushes any samples buffered inside swr at end-of-stream by
 // converting with a nil input until it yields nothing further. With output rate
-// equal to input rate swr holds no internal delay, but draining is performed
-// unconditionally so a future rate change cannot silently drop trailing samples.
+// equal to input rate swr holds no internal delay, but a reader opened with
+// NewStreamingReaderRate at another rate does, so draining is unconditional.
 func (d *StreamingReader) drainResampler() error {
 	for {
 		outCount, err := ffmpeg.SwrGetOutSamples(d.swr, 0)
@@ -357,25 +551,67 @@ func (d *StreamingReader) drainResampler() error {
 
 // convertAndAppend runs one swr conversion of inCount input samples (in may be
 // nil to flush) into the reusable output buffer, then appends the produced mono
-// float64 samples onto d.sampleBuffer. Returns the number of samples produced.
+// float64 samples onto d.sampleBuffer, and the multi-channel ones onto
+// d.multiBuffer when enabled. Returns the number of samples produced.
 func (d *StreamingReader) convertAndAppend(in []unsafe.Pointer, inCount, outCount int) (int, error) {
-	got, err := ffmpeg.SwrConvert(d.swr, d.outPlanes, outCount, in, inCount)
-	if err != nil {
-		return 0, fmt.Errorf("failed to resample frame: %w", err)
-	}
-	if got < 0 {
-		return 0, fmt.Errorf("failed to resample frame: error code %d", got)
-	}
-	if got == 0 {
-		return 0, nil
+	got, err := convert(d.swr, d.outPlanes, outCount, in, inCount)
+	if err != nil || got == 0 {
+		return 0, err
+	}
+	// Both conversions run at the input rate, so swr holds nothing back and
+	// each yields one output sample per input sample.
+	var multiGot int
+	if d.multiChannels > 0 {
+		multiGot, err = convert(d.multiSwr, d.multiPlanes, outCount, in, inCount)
+		if err != nil {
+			return 0, err
+		}
+		if multiGot != got {
+			return 0, fmt.Errorf("resampler produced %d mono and %d multi-channel samples", got, multiGot)
+		}
 	}
 
 	// The output buffer is packed mono AVSampleFmtDbl, i.e. a contiguous run of
 	// float64, so reinterpret the first plane as a []float64 and copy onto the
 	// sample-buffer tail. This is the only unsafe access in the read path.
 	out := unsafe.Slice((*float64)(d.outPlanes[0]), got)
-	dst := d.growSampleBuffer(got)
+	skip := min(d.skip, got)
+	d.skip -= skip
+	out = out[skip:]
+	dst := d.growSampleBuffer(len(out))
 	copy(dst, out)
+
+	// Float formats carry NaN and infinity through unchanged, and one would
+	// poison Pass 1's peak and every FFT after it; treat them as silence.
+	for i, v := range dst {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			dst[i] = 0
+		}
+	}
+
+	if d.multiChannels > 0 {
+		multi := unsafe.Slice((*float32)(d.multiPlanes[0]), multiGot*d.multiChannels)[skip*d.multiChannels:]
+		dst := d.growMultiBuffer(len(multi))
+		copy(dst, multi)
+		for i, v := range dst {
+			if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+				dst[i] = 0
+			}
+		}
+	}
+	return len(out), nil
+}
+
+// convert runs one swr conversion of inCount input samples into planes,
+// returning the samples produced.
+func convert(swr *ffmpeg.SwrContext, planes []unsafe.Pointer, outCount int, in []unsafe.Pointer, inCount int) (int, error) {
+	got, err := ffmpeg.SwrConvert(swr, planes, outCount, in, inCount)
+	if err := ffmpegutil.Check(got, err, "failed to resample frame"); err != nil {
+		return 0, err
+	}
+	if got > outCount {
+		return 0, fmt.Errorf("resampler produced %d samples into a %d-sample buffer", got, outCount)
+	}
 	return got, nil
 }
 
@@ -395,13 +631,123 @@ func (d *StreamingReader) framePlanes() []unsafe.Pointer {
 }
 
 // growSampleBuffer extends d.sampleBuffer by n elements and returns the newly
-// added tail region for in-place writing, reusing spare capacity where possible.
+// added tail region for in-place writing. When the space behind the unread
+// samples runs out they are moved back to the start of the backing array, so
+// the buffer only grows for a decode larger than it has ever held and memory
+// stays bounded however long the file.
 func (d *StreamingReader) growSampleBuffer(n int) []float64 {
 	start := len(d.sampleBuffer)
-	d.sampleBuffer = slices.Grow(d.sampleBuffer, n)[:start+n]
+	if cap(d.sampleBuffer)-start < n {
+		if cap(d.sampleStore) >= start+n {
+			d.sampleBuffer = d.sampleStore[:copy(d.sampleStore, d.sampleBuffer)]
+		} else {
+			d.sampleBuffer = slices.Grow(d.sampleBuffer[:start:start], n)
+		}
+		d.sampleStore = d.sampleBuffer[:cap(d.sampleBuffer)]
+	}
+	d.sampleBuffer = d.sampleBuffer[:start+n]
 	return d.sampleBuffer[start:]
 }
 
+// growMultiBuffer is growSampleBuffer for the multi-channel buffer: it
+// extends d.multiBuffer by n elements and returns the new tail, sliding the
+// unread frames back to the start of multiStore when space runs out.
+func (d *StreamingReader) growMultiBuffer(n int) []float32 {
+	start := len(d.multiBuffer)
+	if cap(d.multiBuffer)-start < n {
+		if cap(d.multiStore) >= start+n {
+			d.multiBuffer = d.multiStore[:copy(d.multiStore, d.multiBuffer)]
+		} else {
+			d.multiBuffer = slices.Grow(d.multiBuffer[:start:start], n)
+		}
+		d.multiStore = d.multiBuffer[:cap(d.multiBuffer)]
+	}
+	d.multiBuffer = d.multiBuffer[:start+n]
+	return d.multiBuffer[start:]
+}
+
+// Seek positions the reader so the next read returns the sample at start.
+// The demuxer can only seek to the nearest packet (or keyframe) at or before
+// a point, so the reader seeks there from seekPreroll ahead of start and
+// decodes through to it, dropping every sample before start: the cut is
+// sample-accurate whatever the format, and the first samples read are those
+// a decode from the beginning would give. Seeking past the end leaves the
+// reader at end of stream, and seeking after the end of stream has been read
+// rewinds it, which is how Pass 2 reuses the decoder Pass 1 opened.
+func (d *StreamingReader) Seek(start time.Duration) error {
+	stream := d.formatCtx.Streams().Get(uintptr(d.streamIndex)) //nolint:gosec // stream index is non-negative
+	tb := stream.TimeBase()
+	from := max(start-seekPreroll, 0)
+	ts := int64(from.Seconds()*float64(tb.Den())/float64(tb.Num())) + origin(stream)
+
+	ret, err := ffmpeg.AVSeekFrame(d.formatCtx, d.streamIndex, ts, ffmpeg.AVSeekFlagBackward)
+	if err := ffmpegutil.Check(ret, err, fmt.Sprintf("failed to seek to %v", start)); err != nil {
+		return err
+	}
+
+	ffmpeg.AVCodecFlushBuffers(d.codecCtx)
+	// When resampling, swr still holds samples from before the seek.
+	if d.inRate != d.sampleRate {
+		if err := d.resetResampler(); err != nil {
+			return err
+		}
+	}
+	d.sampleBuffer = d.sampleStore[:0]
+	d.multiBuffer = d.multiStore[:0]
+	d.multiRead = 0
+	d.drained = false
+	d.seekPending = true
+	d.seekTarget, d.seekFrom = start, from
+	d.skip = 0
+	return nil
+}
+
+// resetResampler reinitialises swr, and the multi-channel converter when
+// enabled, dropping any samples buffered inside them.
+func (d *StreamingReader) resetResampler() error {
+	for _, swr := range []*ffmpeg.SwrContext{d.swr, d.multiSwr} {
+		if swr == nil {
+			continue
+		}
+		ret, err := ffmpeg.SwrInit(swr)
+		if err := ffmpegutil.Check(ret, err, "failed to reset resampler"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// samplesBefore returns how many samples of a frame stamped pts (in stream
+// time base) fall before the seek target. A frame without a timestamp is
+// taken to start where the demuxer was asked to seek to, so the pre-roll is
+// still dropped.
+func (d *StreamingReader) samplesBefore(pts int64) int {
+	at := d.seekFrom.Seconds()
+	if pts != ffmpeg.AVNoptsValue {
+		stream := d.formatCtx.Streams().Get(uintptr(d.streamIndex)) //nolint:gosec // stream index is non-negative
+		pts -= origin(stream)
+		tb := stream.TimeBase()
+		at = float64(pts) * float64(tb.Num()) / float64(tb.Den())
+	}
+	return max(int(math.Round((d.seekTarget.Seconds()-at)*float64(d.sampleRate))), 0)
+}
+
+// origin returns the timestamp of stream's first sample, in its time base:
+// where the stream starts, or zero when it starts before that, as the
+// samples stamped before zero are priming for a player to skip.
+func origin(stream *ffmpeg.AVStream) int64 {
+	first := stream.StartTime()
+	if first == ffmpeg.AVNoptsValue {
+		return 0
+	}
+	return max(first, 0)
+}
+
+// Channels returns the channel count of the source.
+func (d *StreamingReader) Channels() int {
+	return d.channels
+}
+
 // SampleRate returns the audio sample rate in Hz.
 func (d *StreamingReader) SampleRate() int {
 	return d.sampleRate
@@ -416,6 +762,16 @@ func (d *StreamingReader) Close() error {
 	if d.swr != nil {
 		ffmpeg.SwrFree(&d.swr)
 	}
+	if d.multiPlanes != nil {
+		ffmpeg.AVSamplesFreePlanes(d.multiPlanes)
+		d.multiPlanes = nil
+	}
+	if d.multiSwr != nil {
+		ffmpeg.SwrFree(&d.multiSwr)
+	}
+	if d.multiLayoutFrame != nil {
+		ffmpeg.AVFrameFree(&d.multiLayoutFrame)
+	}
 	if d.outLayoutFrame != nil {
 		ffmpeg.AVFrameFree(&d.outLayoutFrame)
 	}
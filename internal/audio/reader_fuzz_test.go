@@ -0,0 +1,140 @@
+//go:build !lite
+
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Fuzzing decodes whatever bytes it is given as an audio file. Run it with
+//
+//	go test -fuzz=FuzzStreamingReader ./internal/audio
+//
+// Without -fuzz only the seed corpus below runs.
+
+// wavFormats covers each sample format FFmpeg's WAV demuxer hands the
+// decoder: unsigned 8-bit, 16, 24 and 32-bit integers, and 32 and 64-bit
+// floats.
+var wavFormats = []struct {
+	tag  uint16 // 1 = PCM, 3 = IEEE float
+	bits uint16
+}{
+	{1, 8}, {1, 16}, {1, 24}, {1, 32}, {3, 32}, {3, 64},
+}
+
+// wavBytes builds a WAV file holding a short sine in the given format.
+func wavBytes(tag, bits, channels uint16, frames int) []byte {
+	const rate = 8000
+	blockAlign := channels * bits / 8
+	data := make([]byte, frames*int(blockAlign))
+	for i := range frames {
+		v := 0.5 * math.Sin(2*math.Pi*440*float64(i)/rate)
+		for c := range int(channels) {
+			b := data[(i*int(channels)+c)*int(bits/8):]
+			switch {
+			case tag == 3 && bits == 32:
+				binary.LittleEndian.PutUint32(b, math.Float32bits(float32(v)))
+			case tag == 3:
+				binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+			case bits == 8:
+				b[0] = uint8(128 + int(v*127))
+			default:
+				// Little-endian two's complement, most significant bytes last.
+				s := int64(v * float64(int64(1)<<(bits-1)-1))
+				for k := range int(bits / 8) {
+					b[k] = byte(s >> (8 * k))
+				}
+			}
+		}
+	}
+
+	le := binary.LittleEndian
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	le.PutUint32(header[4:], uint32(36+len(data)))
+	copy(header[8:], "WAVEfmt ")
+	le.PutUint32(header[16:], 16)
+	le.PutUint16(header[20:], tag)
+	le.PutUint16(header[22:], channels)
+	le.PutUint32(header[24:], rate)
+	le.PutUint32(header[28:], rate*uint32(blockAlign))
+	le.PutUint16(header[32:], blockAlign)
+	le.PutUint16(header[34:], bits)
+	copy(header[36:], "data")
+	le.PutUint32(header[40:], uint32(len(data)))
+	return append(header, data...)
+}
+
+// fuzzReadLimit caps the samples decoded per input so a header claiming hours
+// of audio does not stall the fuzzer.
+const fuzzReadLimit = 1 << 18
+
+func FuzzStreamingReader(f *testing.F) {
+	for _, format := range wavFormats {
+		for _, channels := range []uint16{1, 2, 6} {
+			wav := wavBytes(format.tag, format.bits, channels, 4096)
+			f.Add(wav)
+			// Truncated mid-sample, and with the header's sizes left claiming
+			// the full length.
+			f.Add(wav[:len(wav)-len(wav)/3-1])
+		}
+	}
+	// A corrupted header: a zero channel count and an absurd block size.
+	bad := wavBytes(1, 16, 1, 256)
+	binary.LittleEndian.PutUint16(bad[22:], 0)
+	binary.LittleEndian.PutUint16(bad[32:], 0xffff)
+	f.Add(bad)
+	// The head of a real MP3, cut off mid-frame, and with its frames damaged.
+	if mp3, err := os.ReadFile("../../testdata/LMP0.mp3"); err == nil {
+		head := mp3[:min(len(mp3), 64<<10)]
+		f.Add(head)
+		damaged := append([]byte(nil), head...)
+		for i := 4096; i < len(damaged); i += 997 {
+			damaged[i] ^= 0xa5
+		}
+		f.Add(damaged)
+	}
+
+	dir := f.TempDir()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(dir, "input")
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		reader, err := NewStreamingReader(path)
+		if err != nil {
+			return // Rejecting a malformed file is fine; crashing is not.
+		}
+		defer reader.Close()
+		if reader.SampleRate() <= 0 {
+			t.Fatalf("opened with sample rate %d", reader.SampleRate())
+		}
+
+		buf := make([]float64, 1500)
+		for total := 0; total < fuzzReadLimit; {
+			n, err := reader.ReadInto(buf)
+			if n < 0 || n > len(buf) {
+				t.Fatalf("ReadInto returned %d samples into a %d-sample buffer", n, len(buf))
+			}
+			for i, v := range buf[:n] {
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					t.Fatalf("sample %d is %v", total+i, v)
+				}
+			}
+			total += n
+			if err != nil {
+				if !errors.Is(err, io.EOF) && n != 0 {
+					t.Fatalf("ReadInto returned %d samples with error %v", n, err)
+				}
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,53 @@
+//go:build lite
+
+package audio
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/failure"
+	"github.com/linuxmatters/jivefire/internal/wav"
+)
+
+// StreamingReader reads audio in the FFmpeg-free build, with internal/wav's
+// pure-Go decoder in place of libavcodec: only uncompressed WAV and AIFF,
+// mono or stereo, open.
+type StreamingReader struct {
+	*wav.Reader
+}
+
+// NewStreamingReader opens filename for reading from its first sample.
+func NewStreamingReader(filename string) (*StreamingReader, error) {
+	return NewStreamingReaderAt(filename, 0)
+}
+
+// NewStreamingReaderRate opens filename like NewStreamingReader. This build
+// has no libswresample, so a file at a rate other than sampleRate is refused
+// rather than resampled.
+func NewStreamingReaderRate(filename string, sampleRate int) (*StreamingReader, error) {
+	r, err := NewStreamingReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	if r.SampleRate() != sampleRate {
+		_ = r.Close()
+		return nil, failure.Mark(failure.ErrUnsupportedFormat,
+			fmt.Errorf("%s: %d Hz audio cannot be resampled to %d Hz without FFmpeg", filename, r.SampleRate(), sampleRate))
+	}
+	return r, nil
+}
+
+// NewStreamingReaderAt opens filename positioned at start, to the sample.
+func NewStreamingReaderAt(filename string, start time.Duration) (*StreamingReader, error) {
+	r, err := wav.OpenAt(filename, start)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamingReader{r}, nil
+}
+
+// GetMetadata reads a WAV or AIFF file's metadata with internal/wav.
+func GetMetadata(filename string) (*Metadata, error) {
+	return WAVMetadata(filename)
+}
@@ -1,9 +1,15 @@
+//go:build !lite
+
 package audio
 
 import (
 	"errors"
 	"io"
+	"math"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestNewStreamingReader(t *testing.T) {
@@ -221,3 +227,170 @@ func TestStreamingReaderMultipleReads(t *testing.T) {
 
 	t.Logf("Multiple reads consistent: %d samples verified", compareCount)
 }
+
+// readAll decodes every remaining sample from reader.
+func readAll(t *testing.T, reader *StreamingReader) []float64 {
+	t.Helper()
+	var samples []float64
+	for {
+		chunk, err := reader.ReadChunk(4096)
+		if errors.Is(err, io.EOF) {
+			return samples
+		}
+		if err != nil {
+			t.Fatalf("Error reading: %v", err)
+		}
+		samples = append(samples, chunk...)
+	}
+}
+
+func TestStreamingReaderSeekSampleAccurate(t *testing.T) {
+	const rate, seconds = 8000, 4
+	path := filepath.Join(t.TempDir(), "sine.wav")
+	if err := os.WriteFile(path, wavBytes(1, 16, 1, rate*seconds), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	full, err := NewStreamingReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	want := readAll(t, full)
+	full.Close()
+
+	// 1.5s is not on a packet boundary, so some decoded samples must be
+	// dropped to land on it; 100ms is within the pre-roll of the start.
+	for _, start := range []time.Duration{100 * time.Millisecond, 1500 * time.Millisecond, 3*time.Second + 7*time.Millisecond} {
+		reader, err := NewStreamingReaderAt(path, start)
+		if err != nil {
+			t.Fatalf("Failed to open at %v: %v", start, err)
+		}
+		got := readAll(t, reader)
+		reader.Close()
+
+		offset := int(start.Seconds() * rate)
+		if len(got) != len(want)-offset {
+			t.Errorf("Seek to %v: read %d samples, want %d", start, len(got), len(want)-offset)
+		}
+		for i := range min(len(got), len(want)-offset, 1000) {
+			if got[i] != want[offset+i] {
+				t.Fatalf("Seek to %v: sample %d = %f, want %f", start, i, got[i], want[offset+i])
+			}
+		}
+	}
+}
+
+func TestStreamingReaderSeekPastEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "short.wav")
+	if err := os.WriteFile(path, wavBytes(1, 16, 1, 8000), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := NewStreamingReaderAt(path, 5*time.Second)
+	if err != nil {
+		return // Refusing the seek is as good as an empty read.
+	}
+	defer reader.Close()
+	if got := readAll(t, reader); len(got) != 0 {
+		t.Errorf("Read %d samples after seeking past the end, want 0", len(got))
+	}
+}
+
+// Pass 2 rewinds the reader Pass 1 read to the end, so a Seek after end of
+// stream must read the same samples again, with the output re-enabled.
+func TestStreamingReaderRewind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stereo.wav")
+	if err := os.WriteFile(path, wavBytes(1, 16, 2, 8000), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := NewStreamingReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer reader.Close()
+	if err := reader.EnableOutput(2); err != nil {
+		t.Fatalf("EnableOutput: %v", err)
+	}
+	first := readAll(t, reader)
+
+	for _, start := range []time.Duration{0, 250 * time.Millisecond} {
+		if err := reader.Seek(start); err != nil {
+			t.Fatalf("Seek to %v after end of stream: %v", start, err)
+		}
+		if err := reader.EnableOutput(2); err != nil {
+			t.Fatalf("EnableOutput again: %v", err)
+		}
+		offset := int(start.Seconds() * 8000)
+		buf := make([]float64, 1000)
+		var again []float64
+		for {
+			n, err := reader.ReadInto(buf)
+			if out := reader.Output(); len(out) != n*2 {
+				t.Fatalf("Output returned %d values for %d samples", len(out), n)
+			}
+			again = append(again, buf[:n]...)
+			if err != nil {
+				break
+			}
+		}
+		if len(again) != len(first)-offset {
+			t.Fatalf("Rewound to %v: read %d samples, want %d", start, len(again), len(first)-offset)
+		}
+		for i := range again {
+			if again[i] != first[offset+i] {
+				t.Fatalf("Rewound to %v: sample %d = %f, want %f", start, i, again[i], first[offset+i])
+			}
+		}
+	}
+}
+
+func TestStreamingReaderOutputChannels(t *testing.T) {
+	const rate, frames = 8000, 8000
+	for _, tt := range []struct{ source, output int }{{2, 2}, {6, 6}, {6, 2}, {1, 2}} {
+		path := filepath.Join(t.TempDir(), "in.wav")
+		if err := os.WriteFile(path, wavBytes(1, 16, uint16(tt.source), frames), 0o600); err != nil { //nolint:gosec // small test counts
+			t.Fatal(err)
+		}
+		reader, err := NewStreamingReader(path)
+		if err != nil {
+			t.Fatalf("Failed to open: %v", err)
+		}
+		if reader.Channels() != tt.source {
+			t.Errorf("Channels() = %d, want %d", reader.Channels(), tt.source)
+		}
+		if err := reader.EnableOutput(tt.output); err != nil {
+			t.Fatalf("EnableOutput(%d): %v", tt.output, err)
+		}
+
+		// Irregular reads, as the render loop's frames and FFT fill make.
+		buf := make([]float64, 1333)
+		var total int
+		var peak float32
+		for {
+			n, err := reader.ReadInto(buf)
+			out := reader.Output()
+			if len(out) != n*tt.output {
+				t.Fatalf("%d→%d: Output returned %d values for %d samples", tt.source, tt.output, len(out), n)
+			}
+			for _, v := range out {
+				peak = max(peak, v)
+			}
+			total += n
+			if err != nil {
+				break
+			}
+		}
+		reader.Close()
+
+		if total != frames {
+			t.Errorf("%d→%d: read %d samples, want %d", tt.source, tt.output, total, frames)
+		}
+		// The same 0.5 sine is in every source channel; a matching layout must
+		// pass it through at full level.
+		if tt.source == tt.output && math.Abs(float64(peak)-0.5) > 0.01 {
+			t.Errorf("%d→%d: peak %.3f, want 0.5", tt.source, tt.output, peak)
+		}
+		if peak <= 0 {
+			t.Errorf("%d→%d: output is silent", tt.source, tt.output)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/charmbracelet/harmonica"
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// Spring peak-hold tuning.
+const (
+	smootherSpringFreq    = 6.0
+	smootherSpringDamping = 1.0
+	// smootherGain lifts the spring bars to a fuller amplitude. The peak-hold
+	// path has no integrator, so bars otherwise peak at the raw scaled height
+	// and look short (the old leaky-integrator path had a steady-state gain of
+	// roughly 4.3x for free). The soft knee caps the loud bars, so this keeps
+	// dynamic spread rather than flattening everything to full height. Tune
+	// for taste.
+	smootherGain = 2.0
+)
+
+// Smoother turns each frame's raw bar levels into the heights drawn, holding
+// the state that carries from frame to frame:
+//
+//   - auto-sensitivity, which backs off while bars overshoot full height and
+//     creeps back up while they do not;
+//   - soft-knee compression of overshoots, so loud bars flatten smoothly
+//     rather than clipping;
+//   - peak-hold, where each bar rises instantly to a new high and then
+//     springs down towards the raw level. The spring steps once per video
+//     frame, so the fall rate does not depend on the framerate.
+type Smoother struct {
+	maxHeight   float64
+	sensitivity float64
+	springs     []harmonica.Spring
+	pos, vel    []float64
+}
+
+// NewSmoother returns a smoother for numBars bars at fps frames per second,
+// scaling a full-height bar to maxHeight pixels.
+func NewSmoother(numBars int, fps, maxHeight float64) *Smoother {
+	springs := make([]harmonica.Spring, numBars)
+	for i := range springs {
+		springs[i] = harmonica.NewSpring(1/fps, smootherSpringFreq, smootherSpringDamping)
+	}
+	return &Smoother{
+		maxHeight:   maxHeight,
+		sensitivity: 1,
+		springs:     springs,
+		pos:         make([]float64, numBars),
+		vel:         make([]float64, numBars),
+	}
+}
+
+// Sensitivity returns the sensitivity to bin the next frame at.
+func (s *Smoother) Sensitivity() float64 {
+	return s.sensitivity
+}
+
+// MaxHeight returns the height in pixels of a full-height bar.
+func (s *Smoother) MaxHeight() float64 {
+	return s.maxHeight
+}
+
+// Step takes one frame's bar levels from BinFFT, binned at Sensitivity(),
+// and replaces them with the heights to draw in pixels.
+func (s *Smoother) Step(barHeights []float64) {
+	overshoot := false
+	for i, h := range barHeights {
+		if h > config.OvershootThreshold {
+			overshoot = true
+			barHeights[i] = softKnee(h, config.OvershootThreshold)
+		}
+	}
+	if overshoot {
+		s.sensitivity *= config.SensitivityDecay
+	} else {
+		s.sensitivity *= config.SensitivityGrowth
+	}
+	s.sensitivity = min(max(s.sensitivity, config.SensitivityMin), config.SensitivityMax)
+
+	for i, h := range barHeights {
+		target := h * s.maxHeight * smootherGain
+		if target >= s.pos[i] {
+			// Instant rise to the new peak; reset velocity so the fall starts
+			// from rest.
+			s.pos[i], s.vel[i] = target, 0
+		} else {
+			s.pos[i], s.vel[i] = s.springs[i].Update(s.pos[i], s.vel[i], target)
+			if s.pos[i] < 0 {
+				s.pos[i], s.vel[i] = 0, 0
+			}
+		}
+		barHeights[i] = s.pos[i]
+		if barHeights[i] > s.maxHeight {
+			barHeights[i] = softKnee(barHeights[i], s.maxHeight)
+		}
+	}
+}
+
+// softKnee compresses a value above limit: a slight overshoot passes almost
+// unchanged, and the output never exceeds 1+1/e times the limit.
+func softKnee(v, limit float64) float64 {
+	over := v - limit
+	return limit + over*math.Exp(-over/limit)
+}
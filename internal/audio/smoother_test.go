@@ -0,0 +1,121 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+const smootherTestHeight = 100
+
+// A bar rises to a new peak in one frame, then falls back towards the raw
+// level over several, never below zero or past it.
+func TestSmootherPeakHold(t *testing.T) {
+	s := NewSmoother(1, 30, smootherTestHeight)
+	bar := []float64{0.4}
+	s.Step(bar)
+	if want := 0.4 * smootherTestHeight * smootherGain; bar[0] != want {
+		t.Fatalf("rise: %g, want %g in one frame", bar[0], want)
+	}
+
+	peak := bar[0]
+	prev := peak
+	var frames int
+	for ; frames < 60; frames++ {
+		bar[0] = 0
+		s.Step(bar)
+		if bar[0] > prev || bar[0] < 0 {
+			t.Fatalf("frame %d of the fall: %g after %g", frames, bar[0], prev)
+		}
+		prev = bar[0]
+		if bar[0] < 0.05*peak {
+			break
+		}
+	}
+	// A critically damped 6Hz spring settles in under a second: not a
+	// one-frame drop, and not a slow drift.
+	if frames < 3 || frames > 30 {
+		t.Errorf("fell to 5%% of the peak in %d frames, want 3 to 30", frames)
+	}
+
+	// A new, higher peak mid-fall is taken at once.
+	s.Step([]float64{0.1})
+	bar[0] = 0.45
+	s.Step(bar)
+	if want := 0.45 * smootherTestHeight * smootherGain; bar[0] != want {
+		t.Errorf("rise mid-fall: %g, want %g", bar[0], want)
+	}
+}
+
+// The fall takes the same time whatever the framerate.
+func TestSmootherFramerateIndependent(t *testing.T) {
+	fallTime := func(fps float64) float64 {
+		s := NewSmoother(1, fps, smootherTestHeight)
+		s.Step([]float64{0.4})
+		bar := []float64{0}
+		for n := 1; n < 1000; n++ {
+			bar[0] = 0
+			s.Step(bar)
+			if bar[0] < 10 {
+				return float64(n) / fps
+			}
+		}
+		return math.Inf(1)
+	}
+	if a, b := fallTime(30), fallTime(60); math.Abs(a-b) > 1.0/30 {
+		t.Errorf("fall took %.3fs at 30fps and %.3fs at 60fps", a, b)
+	}
+}
+
+// Heights over full height are compressed smoothly rather than clipped:
+// they stay above full height, below its 1+1/e ceiling, and a bigger input
+// never comes out lower near the knee.
+func TestSmootherSoftKnee(t *testing.T) {
+	ceiling := smootherTestHeight * (1 + 1/math.E)
+	prev := 0.0
+	for _, level := range []float64{0.5, 0.55, 0.6, 0.75, 0.9} {
+		s := NewSmoother(1, 30, smootherTestHeight)
+		bar := []float64{level}
+		s.Step(bar)
+		if bar[0] > ceiling+1e-9 {
+			t.Errorf("level %g: %g passes the %g ceiling", level, bar[0], ceiling)
+		}
+		if level > 0.5 && bar[0] <= smootherTestHeight {
+			t.Errorf("level %g: %g was flattened to full height", level, bar[0])
+		}
+		if bar[0] < prev {
+			t.Errorf("level %g: %g is below the quieter %g", level, bar[0], prev)
+		}
+		prev = bar[0]
+	}
+
+	if got := softKnee(1, 1); got != 1 {
+		t.Errorf("softKnee at the limit: %g, want 1", got)
+	}
+	if got, want := softKnee(2, 1), 1+1/math.E; math.Abs(got-want) > 1e-12 {
+		t.Errorf("softKnee(2, 1) = %g, want %g", got, want)
+	}
+}
+
+// Sensitivity backs off while bars overshoot and recovers while they do not,
+// within its limits.
+func TestSmootherSensitivity(t *testing.T) {
+	s := NewSmoother(2, 30, smootherTestHeight)
+	s.Step([]float64{1.5, 0.2})
+	if got := s.Sensitivity(); got != config.SensitivityDecay {
+		t.Errorf("after an overshoot: %g, want %g", got, config.SensitivityDecay)
+	}
+	for range 1000 {
+		s.Step([]float64{1.5, 0.2})
+	}
+	if got := s.Sensitivity(); got != config.SensitivityMin {
+		t.Errorf("after sustained overshoot: %g, want the %g floor", got, config.SensitivityMin)
+	}
+	for range 5000 {
+		s.Step([]float64{0.2, 0.2})
+	}
+	if got := s.Sensitivity(); got != config.SensitivityMax {
+		t.Errorf("after sustained quiet: %g, want the %g ceiling", got, config.SensitivityMax)
+	}
+}
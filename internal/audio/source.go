@@ -0,0 +1,15 @@
+package audio
+
+import "time"
+
+// Source is the audio the two passes read: a StreamingReader, or a Mixer
+// laying a music bed under one.
+type Source interface {
+	ReadInto(dst []float64) (int, error)
+	EnableOutput(channels int) error
+	Output() []float32
+	Seek(start time.Duration) error
+	SampleRate() int
+	Channels() int
+	Close() error
+}
@@ -0,0 +1,253 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+)
+
+// stitchReadSize is the fewest samples a Stitcher reads from a part at once.
+const stitchReadSize = 4096
+
+// Bumpers are the jingles a Stitcher plays around the episode.
+type Bumpers struct {
+	Intro, Outro string        // paths; empty for none
+	Crossfade    time.Duration // how far each join overlaps
+	Length       time.Duration // how much of the episode plays; 0 runs to its end
+}
+
+// Stitcher is a Source playing an intro, the episode and an outro one after
+// another, so the bumpers are analysed and encoded with the episode. The
+// bumpers are read at the episode's sample rate. Each join crossfades the
+// end of one part into the start of the next with equal-power curves, which
+// keep the level steady across material that does not correlate; the last
+// Crossfade of the current part is held back until the next part is read.
+type Stitcher struct {
+	parts    []Source
+	episode  int // index of the episode in parts
+	length   int // the episode's length in samples, 0 to run to its end
+	limit    int // episode samples still to play, when length is set
+	fade     int // crossfade in samples
+	channels int // output channels enabled, 0 for mono only
+	current  int // the part being read; len(parts) once all are
+
+	queue    []float64 // read but not yet returned
+	queueOut []float32 // the output frames matching queue
+	out      []float32 // output frames of the samples returned since Output
+	scratch  []float64
+}
+
+// NewStitcher opens the bumpers at the episode's sample rate and returns the
+// stitcher, which closes episode when it is closed.
+func NewStitcher(episode Source, bumpers Bumpers) (*Stitcher, error) {
+	var intro, outro Source
+	for _, b := range []struct {
+		path string
+		part *Source
+	}{{bumpers.Intro, &intro}, {bumpers.Outro, &outro}} {
+		if b.path == "" {
+			continue
+		}
+		r, err := NewStreamingReaderRate(b.path, episode.SampleRate())
+		if err != nil {
+			if intro != nil {
+				_ = intro.Close()
+			}
+			return nil, fmt.Errorf("%s: %w", b.path, err)
+		}
+		*b.part = r
+	}
+	return newStitcher(intro, episode, outro, bumpers), nil
+}
+
+// newStitcher stitches intro, episode and outro; intro and outro may be nil.
+func newStitcher(intro, episode, outro Source, bumpers Bumpers) *Stitcher {
+	s := &Stitcher{}
+	if intro != nil {
+		s.parts = append(s.parts, intro)
+	}
+	s.episode = len(s.parts)
+	s.parts = append(s.parts, episode)
+	if outro != nil {
+		s.parts = append(s.parts, outro)
+	}
+	rate := float64(episode.SampleRate())
+	s.fade = int(bumpers.Crossfade.Seconds() * rate)
+	s.length = int(math.Round(bumpers.Length.Seconds() * rate))
+	s.limit = s.length
+	return s
+}
+
+// ReadInto fills dst with the next samples of the stitched audio.
+func (s *Stitcher) ReadInto(dst []float64) (int, error) {
+	for len(s.queue) < len(dst)+s.holdBack() && s.current < len(s.parts) {
+		if err := s.readPart(len(dst)); err != nil {
+			return 0, err
+		}
+	}
+	n := min(len(dst), len(s.queue)-s.holdBack())
+	if n <= 0 {
+		return 0, io.EOF
+	}
+	copy(dst, s.queue[:n])
+	s.queue = s.queue[n:]
+	if s.channels > 0 {
+		s.out = append(s.out, s.queueOut[:n*s.channels]...)
+		s.queueOut = s.queueOut[n*s.channels:]
+	}
+	return n, nil
+}
+
+// holdBack is how many queued samples wait for the next join.
+func (s *Stitcher) holdBack() int {
+	if s.current >= len(s.parts)-1 {
+		return 0
+	}
+	return s.fade
+}
+
+// readPart queues at least want samples from the current part, or the rest
+// of it, moving on to the next part when it ends.
+func (s *Stitcher) readPart(want int) error {
+	part := s.parts[s.current]
+	size := s.allowed(s.current, max(want, stitchReadSize))
+	err := error(io.EOF)
+	if size > 0 {
+		s.scratch = slices.Grow(s.scratch[:0], size)[:size]
+		var n int
+		n, err = part.ReadInto(s.scratch)
+		s.queued(s.current, s.scratch[:n])
+	}
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, io.EOF) {
+		return err
+	}
+	return s.next()
+}
+
+// allowed caps a read of n samples from part i at the episode's length.
+func (s *Stitcher) allowed(i, n int) int {
+	if i == s.episode && s.length > 0 {
+		return min(n, s.limit)
+	}
+	return n
+}
+
+// queued appends samples just read from part i, and the matching output.
+func (s *Stitcher) queued(i int, samples []float64) {
+	if len(samples) == 0 {
+		return
+	}
+	s.queue = append(s.queue, samples...)
+	if s.channels > 0 {
+		s.queueOut = append(s.queueOut, s.parts[i].Output()...)
+	}
+	if i == s.episode {
+		s.limit -= len(samples)
+	}
+}
+
+// next moves on from a part that has ended, crossfading the queued tail
+// into the start of the part after it.
+func (s *Stitcher) next() error {
+	s.current++
+	if s.current >= len(s.parts) {
+		return nil
+	}
+	k := s.allowed(s.current, min(s.fade, len(s.queue)))
+	if k <= 0 {
+		return nil
+	}
+	part := s.parts[s.current]
+	s.scratch = slices.Grow(s.scratch[:0], k)[:k]
+	n, err := readIntoBuffer(part, s.scratch)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	var headOut []float32
+	if s.channels > 0 && n > 0 {
+		headOut = part.Output()
+	}
+	if s.current == s.episode {
+		s.limit -= n
+	}
+
+	from := len(s.queue) - n
+	for i, v := range s.scratch[:n] {
+		theta := (float64(i) + 0.5) / float64(n) * math.Pi / 2
+		fadeOut, fadeIn := math.Cos(theta), math.Sin(theta)
+		s.queue[from+i] = s.queue[from+i]*fadeOut + v*fadeIn
+		for c := range s.channels {
+			j := (from+i)*s.channels + c
+			s.queueOut[j] = s.queueOut[j]*float32(fadeOut) + headOut[i*s.channels+c]*float32(fadeIn)
+		}
+	}
+	if err != nil {
+		// The part was shorter than the crossfade.
+		return s.next()
+	}
+	return nil
+}
+
+// EnableOutput enables the multi-channel output on every part.
+func (s *Stitcher) EnableOutput(channels int) error {
+	for _, p := range s.parts {
+		if err := p.EnableOutput(channels); err != nil {
+			return err
+		}
+	}
+	s.channels = channels
+	return nil
+}
+
+// Output returns the output frames matching the samples read since the last
+// call. The slice is only valid until the next read.
+func (s *Stitcher) Output() []float32 {
+	out := s.out
+	s.out = s.out[:0]
+	return out
+}
+
+// Seek starts the stitched audio again from the intro, with the episode
+// positioned at start; it is how Pass 2 rewinds.
+func (s *Stitcher) Seek(start time.Duration) error {
+	for i, p := range s.parts {
+		at := time.Duration(0)
+		if i == s.episode {
+			at = start
+		}
+		if err := p.Seek(at); err != nil {
+			return err
+		}
+	}
+	s.current = 0
+	s.limit = s.length
+	s.queue = s.queue[:0]
+	s.queueOut = s.queueOut[:0]
+	s.out = s.out[:0]
+	return nil
+}
+
+// SampleRate returns the episode's sample rate, which the bumpers are read at.
+func (s *Stitcher) SampleRate() int {
+	return s.parts[s.episode].SampleRate()
+}
+
+// Channels returns the channel count of the episode.
+func (s *Stitcher) Channels() int {
+	return s.parts[s.episode].Channels()
+}
+
+// Close closes every part.
+func (s *Stitcher) Close() error {
+	var errs []error
+	for _, p := range s.parts {
+		errs = append(errs, p.Close())
+	}
+	return errors.Join(errs...)
+}
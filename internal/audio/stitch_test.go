@@ -0,0 +1,116 @@
+package audio
+
+import (
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// constSource returns a sliceSource of n samples at v.
+func constSource(n int, v float64) *sliceSource {
+	s := &sliceSource{samples: make([]float64, n), rate: 100, piece: 7}
+	for i := range s.samples {
+		s.samples[i] = v
+	}
+	return s
+}
+
+// readSource reads src to the end in reads of size samples, collecting the
+// output frames alongside.
+func readSource(t *testing.T, src Source, size int) ([]float64, []float32) {
+	t.Helper()
+	var samples []float64
+	var out []float32
+	buf := make([]float64, size)
+	for {
+		n, err := src.ReadInto(buf)
+		if errors.Is(err, io.EOF) {
+			return samples, out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, buf[:n]...)
+		out = append(out, src.Output()...)
+	}
+}
+
+func TestStitcherJoins(t *testing.T) {
+	intro, episode, outro := constSource(30, 1), constSource(50, 2), constSource(20, 3)
+	s := newStitcher(intro, episode, outro, Bumpers{})
+	if err := s.EnableOutput(2); err != nil {
+		t.Fatal(err)
+	}
+	samples, out := readSource(t, s, 16)
+	if len(samples) != 100 || len(out) != 200 {
+		t.Fatalf("read %d samples and %d output values, want 100 and 200", len(samples), len(out))
+	}
+	for i, v := range samples {
+		want := 1.0
+		if i >= 80 {
+			want = 3
+		} else if i >= 30 {
+			want = 2
+		}
+		if v != want || float64(out[i*2]) != want || float64(out[i*2+1]) != 2*want {
+			t.Fatalf("sample %d: %g (output %g, %g), want %g", i, v, out[i*2], out[i*2+1], want)
+		}
+	}
+}
+
+// Each join overlaps the parts by the crossfade, along equal-power curves.
+func TestStitcherCrossfade(t *testing.T) {
+	intro, episode := constSource(30, 1), constSource(50, 0)
+	s := newStitcher(intro, episode, nil, Bumpers{Crossfade: 100 * time.Millisecond})
+	samples, _ := readSource(t, s, 16)
+	if len(samples) != 70 {
+		t.Fatalf("read %d samples, want 70", len(samples))
+	}
+	for i := range 10 {
+		want := math.Cos((float64(i) + 0.5) / 10 * math.Pi / 2)
+		if got := samples[20+i]; math.Abs(got-want) > 1e-9 {
+			t.Errorf("crossfade sample %d: %g, want %g", i, got, want)
+		}
+	}
+	if samples[19] != 1 || samples[30] != 0 {
+		t.Errorf("samples either side of the crossfade are %g and %g, want 1 and 0", samples[19], samples[30])
+	}
+}
+
+// A crossfade longer than a bumper overlaps all of it, and the episode's
+// length holds even with an outro after it.
+func TestStitcherShortParts(t *testing.T) {
+	intro, episode, outro := constSource(5, 1), constSource(50, 2), constSource(20, 3)
+	s := newStitcher(intro, episode, outro, Bumpers{Crossfade: 100 * time.Millisecond, Length: 300 * time.Millisecond})
+	samples, _ := readSource(t, s, 8)
+	// 5 of intro overlapping the episode's first 5, 30 of episode, the last
+	// 10 of which overlap the outro's first 10, then its remaining 10.
+	if len(samples) != 40 {
+		t.Fatalf("read %d samples, want 40", len(samples))
+	}
+	if samples[len(samples)-1] != 3 {
+		t.Errorf("last sample %g, want the outro's 3", samples[len(samples)-1])
+	}
+}
+
+// Seek starts again from the intro with the episode at the given point.
+func TestStitcherSeek(t *testing.T) {
+	episode := constSource(50, 0)
+	for i := range episode.samples {
+		episode.samples[i] = float64(i)
+	}
+	s := newStitcher(constSource(10, -1), episode, nil, Bumpers{})
+	first, _ := readSource(t, s, 16)
+	if err := s.Seek(200 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	again, _ := readSource(t, s, 16)
+	if len(first) != 60 || len(again) != 40 {
+		t.Fatalf("read %d samples, then %d after Seek, want 60 and 40", len(first), len(again))
+	}
+	if again[0] != -1 || again[10] != 20 {
+		t.Errorf("after Seek: intro %g, episode from %g; want -1 and 20", again[0], again[10])
+	}
+}
@@ -0,0 +1,163 @@
+package audio
+
+import "math"
+
+// Stretcher speeds mono audio up (or slows it down) without changing its
+// pitch, using WSOLA (waveform-similarity overlap-add): Hann-windowed segments
+// are taken from the input every hop*speed samples and overlap-added every
+// hop samples. Each segment is nudged by up to tol samples to the offset
+// whose waveform best continues the previous one, which avoids the warble of
+// plain overlap-add on speech.
+//
+// It is streaming: Process takes input as it is decoded and returns the
+// output completed so far, and Flush returns the rest at end of stream.
+type Stretcher struct {
+	speed    float64
+	n, hop   int
+	tol      int
+	window   []float64
+	in       []float64 // buffered input, in[0] being absolute sample inBase
+	inBase   int
+	frame    int       // segments taken so far
+	prev     int       // absolute input position of the last segment, -1 before the first
+	acc      []float64 // overlap-add accumulator for the next n output samples
+	out      []float64 // reusable output buffer
+	finished bool
+}
+
+// stretchDecimation thins the similarity search: every fourth sample of the
+// overlap is compared, which finds the same offset for speech and music at a
+// quarter of the cost.
+const stretchDecimation = 4
+
+// NewStretcher returns a stretcher playing audio at sampleRate speed times
+// faster. Segments are 40ms, long enough to hold a couple of pitch periods of
+// a low voice, with a 5ms search either side.
+func NewStretcher(sampleRate int, speed float64) *Stretcher {
+	n := max(sampleRate/25&^1, 16)
+	window := make([]float64, n)
+	for i := range window {
+		// Periodic Hann: windows half a segment apart sum to exactly one.
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+	}
+	return &Stretcher{
+		speed:  speed,
+		n:      n,
+		hop:    n / 2,
+		tol:    n / 8,
+		window: window,
+		prev:   -1,
+		acc:    make([]float64, n),
+	}
+}
+
+// Process adds samples to the input and returns the output now complete. The
+// returned slice is reused by the next call.
+func (s *Stretcher) Process(samples []float64) []float64 {
+	s.in = append(s.in, samples...)
+	s.out = s.out[:0]
+	for s.step() {
+	}
+	s.compact()
+	return s.out
+}
+
+// Flush pads the input with silence to finish the last segments and returns
+// the remaining output. The stretcher is spent afterwards.
+func (s *Stretcher) Flush() []float64 {
+	s.out = s.out[:0]
+	if s.finished {
+		return s.out
+	}
+	s.finished = true
+	end := s.inBase + len(s.in)
+	for s.nominal() < end {
+		need := s.nominal() + s.tol + s.n
+		if s.prev >= 0 {
+			need = max(need, s.prev+s.hop+s.n)
+		}
+		if pad := need - (s.inBase + len(s.in)); pad > 0 {
+			s.in = append(s.in, make([]float64, pad)...)
+		}
+		if !s.step() {
+			break
+		}
+	}
+	if s.frame > 0 {
+		// The second half of the last segment has nothing left to overlap.
+		s.out = append(s.out, s.acc[:s.hop]...)
+	}
+	return s.out
+}
+
+// nominal returns the absolute input position of the next segment before the
+// similarity nudge.
+func (s *Stretcher) nominal() int {
+	return int(math.Round(float64(s.frame*s.hop) * s.speed))
+}
+
+// step takes one segment if enough input is buffered, reporting whether it
+// did.
+func (s *Stretcher) step() bool {
+	nominal := s.nominal()
+	pos := nominal
+	if s.prev >= 0 {
+		natural := s.prev + s.hop
+		if max(nominal+s.tol, natural)+s.n > s.inBase+len(s.in) {
+			return false
+		}
+		pos = s.bestMatch(natural, nominal)
+	} else if nominal+s.n > s.inBase+len(s.in) {
+		return false
+	}
+
+	seg := s.in[pos-s.inBase : pos-s.inBase+s.n]
+	for i, w := range s.window {
+		s.acc[i] += w * seg[i]
+	}
+	s.out = append(s.out, s.acc[:s.hop]...)
+	copy(s.acc, s.acc[s.hop:])
+	clear(s.acc[s.n-s.hop:])
+
+	s.prev = pos
+	s.frame++
+	return true
+}
+
+// bestMatch returns the position within tol of nominal whose first
+// half-segment best correlates with the natural continuation of the previous
+// segment. It searches outwards from nominal so ties keep the nominal
+// position.
+func (s *Stretcher) bestMatch(natural, nominal int) int {
+	ref := s.in[natural-s.inBase : natural-s.inBase+s.hop]
+	best, bestScore := nominal, math.Inf(-1)
+	for d := range s.tol + 1 {
+		for side, c := range [2]int{nominal - d, nominal + d} {
+			if c < s.inBase || (d == 0 && side == 1) {
+				continue
+			}
+			cand := s.in[c-s.inBase : c-s.inBase+s.hop]
+			var score float64
+			for i := 0; i < s.hop; i += stretchDecimation {
+				score += ref[i] * cand[i]
+			}
+			if score > bestScore {
+				best, bestScore = c, score
+			}
+		}
+	}
+	return best
+}
+
+// compact drops input no later segment can reach.
+func (s *Stretcher) compact() {
+	keep := s.nominal() - s.tol
+	if s.prev >= 0 {
+		keep = min(keep, s.prev+s.hop)
+	}
+	if drop := keep - s.inBase; drop > 0 {
+		drop = min(drop, len(s.in))
+		s.in = s.in[:copy(s.in, s.in[drop:])]
+		s.inBase += drop
+	}
+}
@@ -0,0 +1,92 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+// stretchSine runs seconds of a sine through a stretcher in irregular chunks,
+// as the decoder hands them over, and returns the output.
+func stretchSine(rate int, freq, speed, seconds float64) []float64 {
+	in := make([]float64, int(seconds*float64(rate)))
+	for i := range in {
+		in[i] = 0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(rate))
+	}
+	s := NewStretcher(rate, speed)
+	var out []float64
+	for len(in) > 0 {
+		n := min(len(in), 1000+len(out)%700)
+		out = append(out, s.Process(in[:n])...)
+		in = in[n:]
+	}
+	return append(out, s.Flush()...)
+}
+
+// zeroCrossingHz estimates the frequency of a sine from its rising zero
+// crossings, ignoring the faded edges.
+func zeroCrossingHz(samples []float64, rate int) float64 {
+	edge := len(samples) / 10
+	body := samples[edge : len(samples)-edge]
+	var first, last, crossings int
+	for i := 1; i < len(body); i++ {
+		if body[i-1] < 0 && body[i] >= 0 {
+			if crossings == 0 {
+				first = i
+			}
+			last = i
+			crossings++
+		}
+	}
+	if crossings < 2 {
+		return 0
+	}
+	return float64(crossings-1) * float64(rate) / float64(last-first)
+}
+
+func TestStretcherLength(t *testing.T) {
+	const rate = 44100
+	for _, speed := range []float64{1.5, 2, 4, 8} {
+		out := stretchSine(rate, 220, speed, 4)
+		want := 4 * rate / speed
+		// Within one segment of the ideal length.
+		if diff := math.Abs(float64(len(out)) - want); diff > rate/25 {
+			t.Errorf("speed %g: %d samples out, want about %.0f", speed, len(out), want)
+		}
+	}
+}
+
+func TestStretcherKeepsPitch(t *testing.T) {
+	const rate = 44100
+	for _, speed := range []float64{1.5, 2, 3} {
+		out := stretchSine(rate, 440, speed, 2)
+		if hz := zeroCrossingHz(out, rate); math.Abs(hz-440) > 440*0.02 {
+			t.Errorf("speed %g: output at %.1f Hz, want 440 Hz", speed, hz)
+		}
+	}
+}
+
+func TestStretcherKeepsLevel(t *testing.T) {
+	const rate = 44100
+	out := stretchSine(rate, 440, 2, 2)
+	edge := len(out) / 10
+	var sum float64
+	for _, v := range out[edge : len(out)-edge] {
+		sum += v * v
+	}
+	rms := math.Sqrt(sum / float64(len(out)-2*edge))
+	// A 0.5 sine has an RMS of 0.354; phase-matched segments add without
+	// cancelling.
+	if rms < 0.3 || rms > 0.4 {
+		t.Errorf("RMS = %.3f, want about 0.354", rms)
+	}
+}
+
+func TestStretcherEmpty(t *testing.T) {
+	s := NewStretcher(44100, 2)
+	if out := s.Process(nil); len(out) != 0 {
+		t.Errorf("Process(nil) returned %d samples", len(out))
+	}
+	if out := s.Flush(); len(out) != 0 {
+		t.Errorf("Flush after no input returned %d samples", len(out))
+	}
+}
@@ -0,0 +1,137 @@
+package audio
+
+import (
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// TestSegment is one part of a calibration signal: a steady tone (FromHz
+// equal to ToHz), a logarithmic sweep from FromHz to ToHz, or pink noise
+// (both zero).
+type TestSegment struct {
+	Start, End   time.Duration
+	Label        string
+	FromHz, ToHz float64
+}
+
+// FrequencyAt returns the frequency sounding at t into the signal, or 0 for
+// noise.
+func (s TestSegment) FrequencyAt(t time.Duration) float64 {
+	if s.FromHz == s.ToHz {
+		return s.FromHz
+	}
+	pos := min(max(float64(t-s.Start)/float64(s.End-s.Start), 0), 1)
+	return s.FromHz * math.Pow(s.ToHz/s.FromHz, pos)
+}
+
+// Calibration levels: tones and the sweep peak at -12dBFS, well clear of
+// clipping, and the noise is a little quieter so its crest factor fits too.
+const (
+	testToneLevel  = 0.25
+	testNoiseLevel = 0.1
+	testToneLength = 2 * time.Second
+	testFade       = 10 * time.Millisecond
+)
+
+// testToneFrequencies are the ISO octave band centres.
+var testToneFrequencies = []float64{31.5, 63, 125, 250, 500, 1000, 2000, 4000, 8000, 16000}
+
+// TestSignal is the calibration signal jivefire test renders: a two second
+// tone at each octave centre between minHz and maxHz, then a sweep across
+// the whole range, then pink noise.
+type TestSignal struct {
+	SampleRate int
+	Segments   []TestSegment
+}
+
+// NewTestSignal lays out the calibration signal. maxHz is held below the
+// Nyquist frequency of sampleRate.
+func NewTestSignal(sampleRate int, minHz, maxHz float64, sweep, noise time.Duration) *TestSignal {
+	maxHz = min(maxHz, 0.45*float64(sampleRate))
+	s := &TestSignal{SampleRate: sampleRate}
+	var at time.Duration
+	add := func(length time.Duration, label string, from, to float64) {
+		s.Segments = append(s.Segments, TestSegment{Start: at, End: at + length, Label: label, FromHz: from, ToHz: to})
+		at += length
+	}
+	for _, f := range testToneFrequencies {
+		if f >= minHz && f <= maxHz {
+			add(testToneLength, FormatFrequency(f)+" tone", f, f)
+		}
+	}
+	if sweep > 0 {
+		add(sweep, "Sweep "+FormatFrequency(minHz)+" to "+FormatFrequency(maxHz), minHz, maxHz)
+	}
+	if noise > 0 {
+		add(noise, "Pink noise", 0, 0)
+	}
+	return s
+}
+
+// Duration returns the length of the whole signal.
+func (s *TestSignal) Duration() time.Duration {
+	if len(s.Segments) == 0 {
+		return 0
+	}
+	return s.Segments[len(s.Segments)-1].End
+}
+
+// Samples returns the signal as mono samples. Each segment fades in and out
+// over 10ms so the joins do not click.
+func (s *TestSignal) Samples() []float32 {
+	rate := float64(s.SampleRate)
+	out := make([]float32, int(s.Duration().Seconds()*rate))
+	rng := rand.New(rand.NewPCG(1, 2))
+	fade := testFade.Seconds() * rate
+	for _, seg := range s.Segments {
+		from, to := int(seg.Start.Seconds()*rate), min(int(seg.End.Seconds()*rate), len(out))
+		n := float64(to - from)
+		var pink pinkNoise
+		for i := from; i < to; i++ {
+			k := float64(i - from)
+			var v float64
+			switch {
+			case seg.FromHz == 0:
+				v = testNoiseLevel * pink.next(rng.NormFloat64())
+			case seg.FromHz == seg.ToHz:
+				v = testToneLevel * math.Sin(2*math.Pi*seg.FromHz*k/rate)
+			default:
+				// The phase of an exponential sweep, integrated so the
+				// frequency glides without jumps.
+				length := n / rate
+				ratio := math.Log(seg.ToHz / seg.FromHz)
+				phase := 2 * math.Pi * seg.FromHz * length / ratio * (math.Exp(k/n*ratio) - 1)
+				v = testToneLevel * math.Sin(phase)
+			}
+			v *= min(k/fade, (n-k)/fade, 1)
+			out[i] = float32(v)
+		}
+	}
+	return out
+}
+
+// pinkNoise filters white noise to fall by 3dB per octave, using Paul
+// Kellett's economy filter, accurate to within 0.5dB above 10Hz.
+type pinkNoise struct {
+	b0, b1, b2 float64
+}
+
+func (p *pinkNoise) next(white float64) float64 {
+	p.b0 = 0.99765*p.b0 + white*0.0990460
+	p.b1 = 0.96300*p.b1 + white*0.2965164
+	p.b2 = 0.57000*p.b2 + white*1.0526913
+	return (p.b0 + p.b1 + p.b2 + white*0.1848) / 3
+}
+
+// FormatFrequency writes a frequency as a label: "63 Hz", "1 kHz", "12.5 kHz".
+func FormatFrequency(hz float64) string {
+	if hz >= 1000 {
+		return strconv.FormatFloat(math.Round(hz/100)/10, 'f', -1, 64) + " kHz"
+	}
+	if hz < 100 {
+		return strconv.FormatFloat(math.Round(hz*10)/10, 'f', -1, 64) + " Hz"
+	}
+	return strconv.FormatFloat(math.Round(hz), 'f', -1, 64) + " Hz"
+}
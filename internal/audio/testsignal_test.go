@@ -0,0 +1,101 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTestSignalSegments(t *testing.T) {
+	s := NewTestSignal(48000, 100, 20000, 10*time.Second, 5*time.Second)
+	var labels []string
+	for _, seg := range s.Segments {
+		labels = append(labels, seg.Label)
+	}
+	want := []string{"125 Hz tone", "250 Hz tone", "500 Hz tone", "1 kHz tone", "2 kHz tone", "4 kHz tone", "8 kHz tone", "16 kHz tone", "Sweep 100 Hz to 20 kHz", "Pink noise"}
+	if len(labels) != len(want) {
+		t.Fatalf("segments %q, want %q", labels, want)
+	}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Errorf("segment %d: %q, want %q", i, labels[i], want[i])
+		}
+	}
+	if got := s.Duration(); got != 31*time.Second {
+		t.Errorf("duration %v, want 31s", got)
+	}
+
+	// A low sample rate holds the sweep below Nyquist and drops the tones
+	// above it.
+	s = NewTestSignal(22050, 20, 20000, time.Second, 0)
+	last := s.Segments[len(s.Segments)-1]
+	if last.ToHz >= 11025 || s.Segments[len(s.Segments)-2].FromHz != 8000 {
+		t.Errorf("at 22050Hz the signal ends %+v", s.Segments[len(s.Segments)-2:])
+	}
+}
+
+func TestTestSegmentFrequencyAt(t *testing.T) {
+	sweep := TestSegment{Start: 10 * time.Second, End: 20 * time.Second, FromHz: 100, ToHz: 10000}
+	for _, tt := range []struct {
+		at   time.Duration
+		want float64
+	}{
+		{10 * time.Second, 100},
+		{15 * time.Second, 1000},
+		{20 * time.Second, 10000},
+		{25 * time.Second, 10000},
+	} {
+		if got := sweep.FrequencyAt(tt.at); math.Abs(got-tt.want) > 1e-6*tt.want {
+			t.Errorf("sweep at %v: %g Hz, want %g", tt.at, got, tt.want)
+		}
+	}
+	if got := (TestSegment{FromHz: 440, ToHz: 440}).FrequencyAt(time.Hour); got != 440 {
+		t.Errorf("tone: %g Hz, want 440", got)
+	}
+}
+
+// Each tone must sound at its frequency, and nothing may clip.
+func TestTestSignalSamples(t *testing.T) {
+	const rate = 48000
+	s := NewTestSignal(rate, 20, 20000, 5*time.Second, 5*time.Second)
+	samples := s.Samples()
+	if want := int(s.Duration().Seconds() * rate); len(samples) != want {
+		t.Fatalf("%d samples, want %d", len(samples), want)
+	}
+	for i, v := range samples {
+		if math.Abs(float64(v)) >= 1 || math.IsNaN(float64(v)) {
+			t.Fatalf("sample %d is %g", i, v)
+		}
+	}
+	for _, seg := range s.Segments {
+		if seg.FromHz == 0 || seg.FromHz != seg.ToHz {
+			continue
+		}
+		from, to := int(seg.Start.Seconds()*rate), int(seg.End.Seconds()*rate)
+		var crossings int
+		for i := from + 1; i < to; i++ {
+			if (samples[i-1] < 0) != (samples[i] < 0) {
+				crossings++
+			}
+		}
+		got := float64(crossings) / 2 / (seg.End - seg.Start).Seconds()
+		if math.Abs(got-seg.FromHz) > 0.02*seg.FromHz+1 {
+			t.Errorf("%s: sounds at %.1f Hz", seg.Label, got)
+		}
+	}
+}
+
+func TestFormatFrequency(t *testing.T) {
+	for hz, want := range map[float64]string{
+		20:    "20 Hz",
+		31.5:  "31.5 Hz",
+		125:   "125 Hz",
+		1000:  "1 kHz",
+		12500: "12.5 kHz",
+		21600: "21.6 kHz",
+	} {
+		if got := FormatFrequency(hz); got != want {
+			t.Errorf("FormatFrequency(%g) = %q, want %q", hz, got, want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package chapters parses episode chapter lists for embedding in the output
+// video and renders them as YouTube description text.
+//
+// A chapters file holds one chapter per line: a timestamp followed by the
+// chapter title, the same layout YouTube accepts in a video description.
+//
+//	00:00 Intro
+//	02:15 News
+//	1:04:30.500 Listener feedback
+//
+// Timestamps are [HH:]MM:SS with optional fractional seconds. Blank lines and
+// lines starting with # are ignored.
+package chapters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chapter is a titled span of the episode. End is zero until SetEnds fills it
+// from the following chapter's start (or the episode duration for the last).
+type Chapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// Load reads and parses a chapters file.
+func Load(path string) ([]Chapter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chapters file: %w", err)
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse reads chapters from r. Start times must be strictly increasing so the
+// derived chapter spans never overlap.
+func Parse(r io.Reader) ([]Chapter, error) {
+	var chapters []Chapter
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		stamp, title, _ := strings.Cut(line, " ")
+		title = strings.TrimSpace(title)
+		// Tolerate the common "00:00 - Intro" separator style
+		title = strings.TrimSpace(strings.TrimPrefix(title, "-"))
+		if title == "" {
+			return nil, fmt.Errorf("line %d: missing chapter title", lineNum)
+		}
+
+		start, err := ParseTimestamp(stamp)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		if n := len(chapters); n > 0 && start <= chapters[n-1].Start {
+			return nil, fmt.Errorf("line %d: chapter %q starts at or before the previous chapter", lineNum, title)
+		}
+
+		chapters = append(chapters, Chapter{Title: title, Start: start})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read chapters: %w", err)
+	}
+
+	return chapters, nil
+}
+
+// ParseTimestamp parses [HH:]MM:SS[.fff] into a duration.
+func ParseTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q (expected [HH:]MM:SS)", s)
+	}
+
+	secs, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || secs < 0 || secs >= 60 {
+		return 0, fmt.Errorf("invalid seconds in timestamp %q", s)
+	}
+
+	mins, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil || mins < 0 {
+		return 0, fmt.Errorf("invalid minutes in timestamp %q", s)
+	}
+
+	hours := 0
+	if len(parts) == 3 {
+		if mins >= 60 {
+			return 0, fmt.Errorf("invalid minutes in timestamp %q", s)
+		}
+		hours, err = strconv.Atoi(parts[0])
+		if err != nil || hours < 0 {
+			return 0, fmt.Errorf("invalid hours in timestamp %q", s)
+		}
+	}
+
+	d := time.Duration(hours)*time.Hour + time.Duration(mins)*time.Minute
+	d += time.Duration(secs * float64(time.Second)).Round(time.Millisecond)
+	return d, nil
+}
+
+// FormatTimestamp renders d as MM:SS, or H:MM:SS once it reaches an hour.
+func FormatTimestamp(d time.Duration) string {
+	total := int(d / time.Second)
+	h, m, s := total/3600, (total/60)%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
+
+// SetEnds fills each chapter's End from the next chapter's Start, ending the
+// last chapter at total. Returns an error if any chapter starts at or after
+// total, since such a chapter would be empty in the output.
+func SetEnds(chapters []Chapter, total time.Duration) error {
+	for i := range chapters {
+		if chapters[i].Start >= total {
+			return fmt.Errorf("chapter %q starts at %s, beyond the end of the audio (%s)",
+				chapters[i].Title, FormatTimestamp(chapters[i].Start), FormatTimestamp(total))
+		}
+		if i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		} else {
+			chapters[i].End = total
+		}
+	}
+	return nil
+}
+
+// Clip returns the chapters within a section of the episode running from
+// start to end (0 for the end of the episode), shifted so the section starts
+// at zero. The chapter already running at start opens the clip at 0:00, and
+// chapters starting at or after end are dropped.
+func Clip(chapters []Chapter, start, end time.Duration) []Chapter {
+	var clipped []Chapter
+	for i, ch := range chapters {
+		if end > 0 && ch.Start >= end {
+			break
+		}
+		if i+1 < len(chapters) && chapters[i+1].Start <= start {
+			continue
+		}
+		ch.Start = max(ch.Start-start, 0)
+		clipped = append(clipped, ch)
+	}
+	return clipped
+}
+
+// Shift moves chapters d later, for audio played before the episode (an
+// intro). The first chapter still starts at zero, taking in what plays
+// before it, so a list YouTube accepts stays accepted.
+func Shift(chapters []Chapter, d time.Duration) []Chapter {
+	shifted := make([]Chapter, len(chapters))
+	for i, ch := range chapters {
+		if i > 0 || ch.Start > 0 {
+			ch.Start += d
+		}
+		shifted[i] = ch
+	}
+	return shifted
+}
+
+// Scale moves chapters onto the timeline of a video playing the episode speed
+// times faster (--speed), dividing each start time by speed.
+func Scale(chapters []Chapter, speed float64) []Chapter {
+	scaled := make([]Chapter, len(chapters))
+	for i, ch := range chapters {
+		ch.Start = time.Duration(float64(ch.Start) / speed)
+		scaled[i] = ch
+	}
+	return scaled
+}
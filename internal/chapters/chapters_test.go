@@ -0,0 +1,170 @@
+package chapters
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParse verifies that chapter lists in the YouTube description layout are
+// parsed, with comments, blank lines and dash separators tolerated.
+func TestParse(t *testing.T) {
+	input := `# Episode 42
+00:00 Intro
+
+02:15 - News
+1:04:30.500 Listener feedback
+`
+	got, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []Chapter{
+		{Title: "Intro", Start: 0},
+		{Title: "News", Start: 2*time.Minute + 15*time.Second},
+		{Title: "Listener feedback", Start: time.Hour + 4*time.Minute + 30*time.Second + 500*time.Millisecond},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Parse() returned %d chapters, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("chapter %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestParse_Errors verifies that malformed lines are rejected with an error
+// rather than silently producing a broken chapter list.
+func TestParse_Errors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{name: "missing title", input: "00:00\n"},
+		{name: "bad timestamp", input: "0a:00 Intro\n"},
+		{name: "seconds out of range", input: "00:75 Intro\n"},
+		{name: "single field timestamp", input: "90 Intro\n"},
+		{name: "out of order", input: "05:00 Second\n01:00 First\n"},
+		{name: "duplicate start", input: "01:00 One\n01:00 Two\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tc.input)); err == nil {
+				t.Errorf("Parse(%q) expected error, got nil", tc.input)
+			}
+		})
+	}
+}
+
+// TestFormatTimestamp verifies the YouTube-style rendering, which drops the
+// hour field for episodes shorter than an hour.
+func TestFormatTimestamp(t *testing.T) {
+	testCases := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "00:00"},
+		{2*time.Minute + 15*time.Second, "02:15"},
+		{59*time.Minute + 59*time.Second + 900*time.Millisecond, "59:59"},
+		{time.Hour + 4*time.Minute + 30*time.Second, "1:04:30"},
+	}
+
+	for _, tc := range testCases {
+		if got := FormatTimestamp(tc.d); got != tc.want {
+			t.Errorf("FormatTimestamp(%v) = %q, want %q", tc.d, got, tc.want)
+		}
+	}
+}
+
+// TestSetEnds verifies that chapter ends chain to the next start and the last
+// chapter closes at the episode duration.
+func TestSetEnds(t *testing.T) {
+	chs := []Chapter{
+		{Title: "Intro", Start: 0},
+		{Title: "News", Start: time.Minute},
+	}
+	if err := SetEnds(chs, 5*time.Minute); err != nil {
+		t.Fatalf("SetEnds() error = %v", err)
+	}
+	if chs[0].End != time.Minute {
+		t.Errorf("first chapter End = %v, want %v", chs[0].End, time.Minute)
+	}
+	if chs[1].End != 5*time.Minute {
+		t.Errorf("last chapter End = %v, want %v", chs[1].End, 5*time.Minute)
+	}
+
+	if err := SetEnds(chs, 30*time.Second); err == nil {
+		t.Error("SetEnds() expected error for chapter beyond audio end, got nil")
+	}
+}
+
+func TestClip(t *testing.T) {
+	chs := []Chapter{
+		{Title: "Intro", Start: 0},
+		{Title: "News", Start: 2 * time.Minute},
+		{Title: "Feature", Start: 10 * time.Minute},
+		{Title: "Outro", Start: 40 * time.Minute},
+	}
+
+	got := Clip(chs, 5*time.Minute, 30*time.Minute)
+	want := []Chapter{
+		{Title: "News", Start: 0},
+		{Title: "Feature", Start: 5 * time.Minute},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Clip() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Clip()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// A start on a chapter boundary keeps that chapter; no end keeps the rest.
+	got = Clip(chs, 10*time.Minute, 0)
+	if len(got) != 2 || got[0].Title != "Feature" || got[0].Start != 0 || got[1].Start != 30*time.Minute {
+		t.Errorf("Clip() from a chapter start = %v", got)
+	}
+
+	if got := Clip(chs, 0, 0); len(got) != len(chs) {
+		t.Errorf("Clip() of the whole episode dropped chapters: %v", got)
+	}
+	if chs[1].Start != 2*time.Minute {
+		t.Error("Clip() modified its input")
+	}
+}
+
+func TestShift(t *testing.T) {
+	chs := []Chapter{
+		{Title: "Intro", Start: 0},
+		{Title: "News", Start: 3 * time.Minute},
+	}
+	got := Shift(chs, 10*time.Second)
+	if got[0].Start != 0 || got[1].Start != 3*time.Minute+10*time.Second {
+		t.Errorf("Shift() = %v", got)
+	}
+	if chs[1].Start != 3*time.Minute {
+		t.Error("Shift() modified its input")
+	}
+	got = Shift([]Chapter{{Title: "News", Start: time.Minute}}, 10*time.Second)
+	if got[0].Start != time.Minute+10*time.Second {
+		t.Errorf("Shift() of a list not starting at zero = %v", got)
+	}
+}
+
+func TestScale(t *testing.T) {
+	chs := []Chapter{
+		{Title: "Intro", Start: 0},
+		{Title: "News", Start: 3 * time.Minute},
+	}
+	got := Scale(chs, 2)
+	if got[0].Start != 0 || got[1].Start != 90*time.Second || got[1].Title != "News" {
+		t.Errorf("Scale() = %v", got)
+	}
+	if chs[1].Start != 3*time.Minute {
+		t.Error("Scale() modified its input")
+	}
+}
@@ -0,0 +1,60 @@
+package chapters
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// YouTube only turns a timestamp list into chapters when it starts at 00:00,
+// has at least three entries, and every chapter lasts at least ten seconds.
+const (
+	youtubeMinChapters        = 3
+	youtubeMinChapterDuration = 10 * time.Second
+)
+
+// Description renders a plain-text video description for pasting into
+// YouTube: the title (with episode number when set), the running time, and
+// the chapter list. Chapters must already have their End set by SetEnds.
+func Description(title string, episode *int, duration time.Duration, chapters []Chapter) string {
+	var b strings.Builder
+
+	if episode != nil {
+		fmt.Fprintf(&b, "%s: Episode %d\n", title, *episode)
+	} else {
+		fmt.Fprintf(&b, "%s\n", title)
+	}
+	fmt.Fprintf(&b, "Duration: %s\n", FormatTimestamp(duration))
+
+	if len(chapters) > 0 {
+		b.WriteString("\nChapters\n")
+		for _, ch := range chapters {
+			fmt.Fprintf(&b, "%s %s\n", FormatTimestamp(ch.Start), ch.Title)
+		}
+	}
+
+	return b.String()
+}
+
+// YouTubeWarnings reports the reasons YouTube would ignore the chapter list
+// in a description. An empty chapter list produces no warnings.
+func YouTubeWarnings(chapters []Chapter) []string {
+	if len(chapters) == 0 {
+		return nil
+	}
+
+	var warnings []string
+	if chapters[0].Start != 0 {
+		warnings = append(warnings, "YouTube requires the first chapter to start at 00:00")
+	}
+	if len(chapters) < youtubeMinChapters {
+		warnings = append(warnings, fmt.Sprintf("YouTube requires at least %d chapters", youtubeMinChapters))
+	}
+	for _, ch := range chapters {
+		if ch.End-ch.Start < youtubeMinChapterDuration {
+			warnings = append(warnings, fmt.Sprintf("chapter %q is shorter than the %s YouTube minimum",
+				ch.Title, youtubeMinChapterDuration))
+		}
+	}
+	return warnings
+}
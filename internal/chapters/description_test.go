@@ -0,0 +1,64 @@
+package chapters
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDescription verifies the text layout pasted into YouTube, with and
+// without an episode number and chapter list.
+func TestDescription(t *testing.T) {
+	episode := 42
+	chs := []Chapter{
+		{Title: "Intro", Start: 0, End: time.Minute},
+		{Title: "News", Start: time.Minute, End: time.Hour + 2*time.Minute},
+	}
+
+	testCases := []struct {
+		name     string
+		episode  *int
+		chapters []Chapter
+		want     string
+	}{
+		{
+			name:     "episode with chapters",
+			episode:  &episode,
+			chapters: chs,
+			want:     "Linux Matters: Episode 42\nDuration: 1:02:00\n\nChapters\n00:00 Intro\n01:00 News\n",
+		},
+		{
+			name: "no episode or chapters",
+			want: "Linux Matters\nDuration: 1:02:00\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Description("Linux Matters", tc.episode, time.Hour+2*time.Minute, tc.chapters)
+			if got != tc.want {
+				t.Errorf("Description() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestYouTubeWarnings verifies each YouTube chapter rule is reported, and that
+// a conforming list produces no warnings.
+func TestYouTubeWarnings(t *testing.T) {
+	valid := []Chapter{
+		{Title: "A", Start: 0, End: time.Minute},
+		{Title: "B", Start: time.Minute, End: 2 * time.Minute},
+		{Title: "C", Start: 2 * time.Minute, End: 3 * time.Minute},
+	}
+	if got := YouTubeWarnings(valid); len(got) != 0 {
+		t.Errorf("YouTubeWarnings(valid) = %v, want none", got)
+	}
+
+	late := []Chapter{
+		{Title: "A", Start: 5 * time.Second, End: 10 * time.Second},
+	}
+	// Late start, too few chapters, and too short
+	if got := YouTubeWarnings(late); len(got) != 3 {
+		t.Errorf("YouTubeWarnings(late) returned %d warnings, want 3: %v", len(got), got)
+	}
+}
@@ -51,14 +51,29 @@ func StyledHelpPrinter(options kong.HelpOptions) kong.HelpPrinter {
 		sb.WriteString(helpDescStyle.Render("Spin your podcast .wav into a groovy MP4 visualiser with spring-driven real-time audio frequencies."))
 		sb.WriteString("\n")
 
+		node := helpNode(ctx)
+		isDefault := node == ctx.Model.DefaultCmd
+
 		// Usage
 		sb.WriteString(helpSectionStyle.Render("Usage:"))
 		sb.WriteString("\n  ")
-		fmt.Fprintf(&sb, "%s [<input> [<output>]] [flags]", ctx.Model.Name)
+		sb.WriteString(usageLine(ctx.Model.Name, node, isDefault))
 		sb.WriteString("\n")
 
+		// Commands section, shown alongside the default command's help
+		if isDefault {
+			cmds := getCommands(ctx)
+			if len(cmds) > 0 {
+				sb.WriteString("\n")
+				sb.WriteString(helpSectionStyle.Render("Commands:"))
+				sb.WriteString("\n")
+				sb.WriteString(argumentTable(cmds))
+				sb.WriteString("\n")
+			}
+		}
+
 		// Arguments section
-		args := getArguments(ctx)
+		args := getArguments(node)
 		if len(args) > 0 {
 			sb.WriteString("\n")
 			sb.WriteString(helpSectionStyle.Render("Arguments:"))
@@ -68,7 +83,7 @@ func StyledHelpPrinter(options kong.HelpOptions) kong.HelpPrinter {
 		}
 
 		// Flags section
-		flags := getFlags(ctx)
+		flags := getFlags(node)
 		if len(flags) > 0 {
 			sb.WriteString("\n")
 			sb.WriteString(helpSectionStyle.Render("Flags:"))
@@ -139,11 +154,49 @@ type flag struct {
 	defaultVal string
 }
 
-func getArguments(ctx *kong.Context) []argument {
+// helpNode returns the command whose help is being shown. Help requested
+// without a command describes the default render command.
+func helpNode(ctx *kong.Context) *kong.Node {
+	if node := ctx.Selected(); node != nil {
+		return node
+	}
+	if ctx.Model.DefaultCmd != nil {
+		return ctx.Model.DefaultCmd
+	}
+	return ctx.Model.Node
+}
+
+// usageLine returns the usage summary for node. The default command runs
+// without naming it, so its name is left out.
+func usageLine(appName string, node *kong.Node, isDefault bool) string {
+	summary := node.Summary()
+	if isDefault {
+		summary = strings.TrimPrefix(strings.TrimPrefix(summary, node.Path()), " ")
+	}
+	if summary == "" {
+		return appName
+	}
+	return appName + " " + summary
+}
+
+// getCommands lists the named subcommands, skipping the default command
+// whose arguments and flags are already shown.
+func getCommands(ctx *kong.Context) []argument {
+	var cmds []argument
+	for _, child := range ctx.Model.Children {
+		if child.Hidden || child == ctx.Model.DefaultCmd {
+			continue
+		}
+		cmds = append(cmds, argument{name: child.Summary(), help: child.Help})
+	}
+	return cmds
+}
+
+func getArguments(node *kong.Node) []argument {
 	var args []argument
 
 	// Parse arguments from the model
-	for _, arg := range ctx.Model.Positional {
+	for _, arg := range node.Positional {
 		name := arg.Summary()
 		help := arg.Help
 		args = append(args, argument{name: name, help: help})
@@ -152,7 +205,7 @@ func getArguments(ctx *kong.Context) []argument {
 	return args
 }
 
-func getFlags(ctx *kong.Context) []flag {
+func getFlags(node *kong.Node) []flag {
 	var flags []flag
 
 	// Always include help flag
@@ -161,8 +214,14 @@ func getFlags(ctx *kong.Context) []flag {
 		help:  "Show context-sensitive help.",
 	})
 
-	// Parse flags from the model
-	for _, f := range ctx.Model.Flags {
+	// Parse flags from the model: the command's own flags first, then those
+	// inherited from the root (version, probe)
+	var all []*kong.Flag
+	groups := node.AllFlags(true)
+	for i := len(groups) - 1; i >= 0; i-- {
+		all = append(all, groups[i]...)
+	}
+	for _, f := range all {
 		if f.Name == "help" {
 			continue // Already added
 		}
@@ -4,6 +4,8 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/alecthomas/kong"
 )
 
 // ansiPattern matches SGR escape sequences so column offsets can be measured on
@@ -81,3 +83,36 @@ func TestArgumentTableAlignsHelpColumn(t *testing.T) {
 			first, second)
 	}
 }
+
+// TestUsageLineOmitsDefaultCommand verifies the default command's usage line
+// leaves out its name, since it runs without one, while other commands keep
+// theirs.
+func TestUsageLineOmitsDefaultCommand(t *testing.T) {
+	var app struct {
+		Render struct {
+			Input string `arg:"" optional:""`
+		} `cmd:"" default:"withargs"`
+		Thumbnail struct {
+			Output string `arg:""`
+		} `cmd:""`
+	}
+	parser, err := kong.New(&app, kong.Name("jivefire"))
+	if err != nil {
+		t.Fatalf("kong.New() error = %v", err)
+	}
+
+	render := parser.Model.DefaultCmd
+	if got, want := usageLine("jivefire", render, true), "jivefire [<input>]"; got != want {
+		t.Errorf("default usage = %q, want %q", got, want)
+	}
+
+	var thumbnail *kong.Node
+	for _, child := range parser.Model.Children {
+		if child.Name == "thumbnail" {
+			thumbnail = child
+		}
+	}
+	if got, want := usageLine("jivefire", thumbnail, false), "jivefire thumbnail <output>"; got != want {
+		t.Errorf("thumbnail usage = %q, want %q", got, want)
+	}
+}
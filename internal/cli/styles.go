@@ -2,7 +2,9 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"charm.land/lipgloss/v2"
 	"github.com/linuxmatters/jivefire/internal/theme"
@@ -80,6 +82,121 @@ func PrintHardwareProbe(encoders []EncoderInfo) {
 	fmt.Println()
 }
 
+// SelftestResult is one encoder's outcome from jivefire selftest for display
+type SelftestResult struct {
+	Name        string
+	Description string
+	Flags       string  // Render flags that select the encoder
+	FPS         float64 // Frames encoded per second of wall time
+	Realtime    float64 // FPS as a multiple of the video's own frame rate
+	Err         error   // Why the encoder failed, nil when it passed
+}
+
+// PrintSelftestHeader prints the title above the self-test results
+func PrintSelftestHeader(seconds int) {
+	fmt.Println(TitleStyle.Render("Jivefire 🔥"))
+	fmt.Println(HeaderStyle.Render(fmt.Sprintf("Encoder Self-Test (%ds of video each)", seconds)))
+}
+
+// PrintSelftestResult prints one encoder's self-test outcome
+func PrintSelftestResult(r SelftestResult) {
+	var status string
+	if r.Err == nil {
+		status = HighlightStyle.Render(fmt.Sprintf("✓ %.0f fps (%.1f× realtime)", r.FPS, r.Realtime))
+	} else {
+		status = ErrorStyle.Render("✗ " + r.Err.Error())
+	}
+	fmt.Printf("  %s (%s): %s\n",
+		ValueStyle.Render(r.Description),
+		KeyStyle.Render(r.Name),
+		status)
+}
+
+// PrintSelftestSummary names the fastest working encoder after the results
+func PrintSelftestSummary(results []SelftestResult) {
+	var best *SelftestResult
+	for i := range results {
+		if results[i].Err == nil && (best == nil || results[i].FPS > best.FPS) {
+			best = &results[i]
+		}
+	}
+	fmt.Println()
+	if best == nil {
+		fmt.Println(ErrorStyle.Render("No encoder produced a decodable video"))
+		return
+	}
+	fmt.Printf("%s %s %s\n\n", KeyStyle.Render("Fastest:"), ValueStyle.Render(best.Name), KeyStyle.Render("("+best.Flags+")"))
+}
+
+// BenchResult is one benchmark's outcome from jivefire bench for display
+type BenchResult struct {
+	Name        string
+	Description string
+	PerFrame    time.Duration // Time per video frame
+	FPS         float64       // Frames per second at that speed
+	Allocs      int64         // Heap allocations per frame
+	Bytes       int64         // Bytes allocated per frame
+	Err         string        // Why the benchmark failed, empty when it ran
+}
+
+// PrintBenchHeader prints the title above the benchmark results
+func PrintBenchHeader(width, height int) {
+	fmt.Println(TitleStyle.Render("Jivefire 🔥"))
+	fmt.Println(HeaderStyle.Render(fmt.Sprintf("Benchmarks (%d×%d, per frame)", width, height)))
+}
+
+// PrintBenchResult prints one benchmark's outcome
+func PrintBenchResult(r BenchResult) {
+	var status string
+	if r.Err == "" {
+		status = HighlightStyle.Render(fmt.Sprintf("%v/frame, %.0f fps", r.PerFrame, r.FPS)) +
+			KeyStyle.Render(fmt.Sprintf(" (%d allocs, %d B)", r.Allocs, r.Bytes))
+	} else {
+		status = ErrorStyle.Render("✗ " + r.Err)
+	}
+	fmt.Printf("  %s (%s): %s\n",
+		ValueStyle.Render(r.Description),
+		KeyStyle.Render(r.Name),
+		status)
+}
+
+// InputReport describes the source audio and the expected output for the
+// pre-flight report printed before rendering
+type InputReport struct {
+	Path          string
+	Codec         string
+	SampleRate    int
+	Channels      int
+	BitDepth      int // 0 when the codec has no fixed depth
+	Duration      time.Duration
+	EstimatedSize string // empty when no video is encoded (--frames-only)
+	FreeSpace     string // empty when not checked (streaming to stdout)
+}
+
+// PrintInputReport prints a styled ffprobe-style summary of the input to w
+func PrintInputReport(w io.Writer, r InputReport) {
+	row := func(key, value string) {
+		fmt.Fprintf(w, "  %s %s\n", KeyStyle.Render(fmt.Sprintf("%-15s", key+":")), ValueStyle.Render(value))
+	}
+
+	fmt.Fprintln(w, HeaderStyle.Render("Input"))
+	row("File", r.Path)
+	row("Codec", r.Codec)
+	row("Sample rate", fmt.Sprintf("%d Hz", r.SampleRate))
+	row("Channels", fmt.Sprintf("%d", r.Channels))
+	if r.BitDepth > 0 {
+		row("Bit depth", fmt.Sprintf("%d-bit", r.BitDepth))
+	}
+	row("Duration", r.Duration.Round(time.Second).String())
+	if r.EstimatedSize != "" {
+		row("Estimated size", r.EstimatedSize)
+	}
+	if r.FreeSpace != "" {
+		row("Free space", r.FreeSpace)
+	}
+	fmt.Fprintln(w)
+}
+
 // PrintError prints an error message
 func PrintError(message string) {
 	fmt.Fprintf(os.Stderr, "%s %s\n", ErrorStyle.Render("Error:"), message)
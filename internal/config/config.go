@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Video settings
@@ -21,6 +22,10 @@ const (
 	// synthetic test signals and as a fallback reference.
 	SampleRate = 44100
 	FFTSize    = 2048
+
+	// NormalizeCeiling is the true peak, in dBTP, --normalize stops raising
+	// the level at, the ceiling podcast platforms ask for.
+	NormalizeCeiling = -1.0
 )
 
 // Visualization settings
@@ -43,6 +48,10 @@ const (
 	SensitivityMin     = 0.05  // Minimum sensitivity floor
 	SensitivityMax     = 2.0   // Maximum sensitivity ceiling
 	OvershootThreshold = 1.0   // Threshold for soft knee compression
+
+	// NoiseGate is the scaled bar magnitude below which a bar is treated as
+	// noise (see audio.Gate); --noise-gate overrides it.
+	NoiseGate = 0.01
 )
 
 // Appearance - Visual styling configuration.
@@ -75,11 +84,285 @@ const (
 	// Thumbnail layout
 	ThumbnailMargin              = 30  // Margin in pixels from edges for thumbnail text
 	ThumbnailTextRotationDegrees = 3.0 // Rotation angle for thumbnail text (degrees, clockwise)
+	ThumbnailMaxLines            = 2   // Default number of lines the thumbnail title is split across
+	ThumbnailMinSize             = 320 // Smallest accepted thumbnail width or height in pixels
+	ThumbnailMaxSize             = 3840
 
 	// Video overlay
 	FramingLineHeight = 4 // Height in pixels of framing lines above/below center gap
+	ProgressBarHeight = 6 // Height in pixels of the progress widget at the frame edge
+
+	// Preview guides (--guides), as fractions of the frame: the action-safe
+	// and title-safe insets from each edge, and the strips YouTube's player
+	// covers with its title bar at the top and its controls at the bottom
+	GuideActionSafe = 0.035
+	GuideTitleSafe  = 0.05
+	GuideTitleBar   = 0.09
+	GuidePlayerBar  = 0.12
+
+	// Ken Burns background motion: the artwork is fitted this many times the
+	// frame size, and the view zooms from all of it to a frame-sized part
+	KenBurnsZoom = 1.25
+
+	// Video title block, wrapped and sized to fit the centre gap
+	TitleFontSize    = 48.0 // Preferred title font size in points
+	TitleMinFontSize = 20.0 // Smallest size tried before the title is truncated
+	TitleMaxLines    = 2    // Default maximum number of wrapped title lines
+	TitlePadding     = 8    // Vertical inset in pixels inside the centre gap
+
+	// Corner badge (episode number or custom logo)
+	BadgePadding   = 30   // Inset in pixels from the frame edges
+	BadgeFontSize  = 48.0 // Episode number size in points, whatever size the title takes
+	BadgeMaxHeight = 120  // Logo badges taller than this are scaled down to fit
+	BadgePulseMin  = 0.6  // Badge opacity at silence when pulsing (1.0 at full loudness)
+
+	// Timecode burn-in (--timecode), in the title font
+	TimecodeFontSize    = 24.0 // Default font size in points
+	TimecodeMinFontSize = 12.0
+	TimecodeMaxFontSize = 72.0
+	TimecodePill        = 0.6 // Default opacity of the panel behind the digits
+
+	// Clip audiograms: the 16:9 frame scaled to the clip width under the
+	// quote, sized down from ClipQuoteFontSize until it fits
+	ClipWidth            = 1080 // Clip width in pixels for every shape
+	ClipMargin           = 72   // Inset in pixels of the quote from the clip edges
+	ClipBackgroundBlur   = 32   // Blur radius of the background behind the quote
+	ClipBackgroundDim    = 0.5  // Darkening of the background behind the quote
+	ClipQuoteFontSize    = 84.0 // Preferred quote font size in points
+	ClipQuoteMinFontSize = 32.0 // Smallest size tried before the quote is truncated
+)
+
+// TextAlign is the horizontal alignment of a text block.
+type TextAlign string
+
+// Text alignments
+const (
+	AlignLeft   TextAlign = "left"
+	AlignCentre TextAlign = "centre"
+	AlignRight  TextAlign = "right"
+)
+
+// ParseTextAlign validates an alignment name from the command line. The
+// American spelling "center" is accepted as an alias for centre.
+func ParseTextAlign(s string) (TextAlign, error) {
+	switch align := TextAlign(strings.ToLower(s)); align {
+	case AlignLeft, AlignCentre, AlignRight:
+		return align, nil
+	case "center":
+		return AlignCentre, nil
+	}
+	return "", fmt.Errorf("invalid alignment %q: must be left, centre or right", s)
+}
+
+// BarColorMode is how the bars are coloured.
+type BarColorMode string
+
+// Bar colour modes
+const (
+	ColorFixed    BarColorMode = "fixed"    // The bar colour, or each band's with --bands
+	ColorReactive BarColorMode = "reactive" // Blended between two colours by the spectral balance
+)
+
+// ParseBarColorMode validates a bar colour mode from the command line.
+func ParseBarColorMode(s string) (BarColorMode, error) {
+	switch mode := BarColorMode(strings.ToLower(s)); mode {
+	case ColorFixed, ColorReactive:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid bar colour mode %q: must be fixed or reactive", s)
+}
+
+// Default bounds of the reactive bar colours: red for bass-heavy sound,
+// yellow for treble-heavy.
+var (
+	defaultReactiveLow  = [3]uint8{224, 48, 30}
+	defaultReactiveHigh = [3]uint8{248, 212, 29}
+)
+
+// CentroidGlow is what follows the spectral centroid, growing brighter for
+// treble-heavy moments.
+type CentroidGlow string
+
+// Centroid glow targets
+const (
+	GlowNone       CentroidGlow = "none"       // Nothing follows it
+	GlowBars       CentroidGlow = "bars"       // The bars' brightness
+	GlowBackground CentroidGlow = "background" // The background's brightness and warmth
+)
+
+// ParseCentroidGlow validates a centroid glow target from the command line.
+func ParseCentroidGlow(s string) (CentroidGlow, error) {
+	switch glow := CentroidGlow(strings.ToLower(s)); glow {
+	case GlowNone, GlowBars, GlowBackground:
+		return glow, nil
+	}
+	return "", fmt.Errorf("invalid centroid glow %q: must be none, bars or background", s)
+}
+
+// WaveformLayout is how the waveform visualizer lays out its history.
+type WaveformLayout string
+
+// Waveform layouts
+const (
+	WaveformScroll WaveformLayout = "scroll" // Newest at the right edge, scrolling left
+	WaveformCentre WaveformLayout = "centre" // Newest in the middle, spreading out to both edges
 )
 
+// ParseWaveformLayout validates a waveform layout from the command line. The
+// American spelling "center" is accepted as an alias for centre.
+func ParseWaveformLayout(s string) (WaveformLayout, error) {
+	switch layout := WaveformLayout(strings.ToLower(s)); layout {
+	case WaveformScroll, WaveformCentre:
+		return layout, nil
+	case "center":
+		return WaveformCentre, nil
+	}
+	return "", fmt.Errorf("invalid waveform layout %q: must be scroll or centre", s)
+}
+
+// Colormap is the palette the spectrogram visualizer maps levels through.
+type Colormap string
+
+// Spectrogram colormaps
+const (
+	ColormapMagma   Colormap = "magma"   // Black through purple and orange to pale yellow
+	ColormapViridis Colormap = "viridis" // Dark blue through green to yellow
+	ColormapFire    Colormap = "fire"    // Black through red and amber to white
+	ColormapGrey    Colormap = "grey"    // Black to white
+)
+
+// ParseColormap validates a spectrogram colormap from the command line. The
+// American spelling "gray" is accepted as an alias for grey.
+func ParseColormap(s string) (Colormap, error) {
+	switch colormap := Colormap(strings.ToLower(s)); colormap {
+	case ColormapMagma, ColormapViridis, ColormapFire, ColormapGrey:
+		return colormap, nil
+	case "gray":
+		return ColormapGrey, nil
+	}
+	return "", fmt.Errorf("invalid colormap %q: must be magma, viridis, fire or grey", s)
+}
+
+// BadgePosition names the frame corner the badge is drawn in.
+type BadgePosition string
+
+// Badge corner placements
+const (
+	BadgeTopLeft     BadgePosition = "top-left"
+	BadgeTopRight    BadgePosition = "top-right"
+	BadgeBottomLeft  BadgePosition = "bottom-left"
+	BadgeBottomRight BadgePosition = "bottom-right"
+)
+
+// ParseBadgePosition validates a corner name from the command line.
+func ParseBadgePosition(s string) (BadgePosition, error) {
+	switch pos := BadgePosition(strings.ToLower(s)); pos {
+	case BadgeTopLeft, BadgeTopRight, BadgeBottomLeft, BadgeBottomRight:
+		return pos, nil
+	}
+	return "", fmt.Errorf("invalid badge position %q: must be top-left, top-right, bottom-left or bottom-right", s)
+}
+
+// BackgroundFit says how a background image whose aspect ratio differs from
+// the video is fitted to the frame.
+type BackgroundFit string
+
+// Background fit modes
+const (
+	FitStretch BackgroundFit = "stretch" // Scale to the frame, distorting the aspect ratio
+	FitCover   BackgroundFit = "cover"   // Fill the frame, cropping the overflow
+	FitContain BackgroundFit = "contain" // Fit inside the frame, letterboxed in black
+)
+
+// ParseBackgroundFit validates a fit mode from the command line.
+func ParseBackgroundFit(s string) (BackgroundFit, error) {
+	switch fit := BackgroundFit(strings.ToLower(s)); fit {
+	case FitStretch, FitCover, FitContain:
+		return fit, nil
+	}
+	return "", fmt.Errorf("invalid background fit %q: must be stretch, cover or contain", s)
+}
+
+// BackgroundMotion is how the background image moves over the episode.
+type BackgroundMotion string
+
+// Background motions
+const (
+	MotionNone     BackgroundMotion = "none"     // A still background
+	MotionKenBurns BackgroundMotion = "kenburns" // A slow zoom and pan across the artwork
+)
+
+// ParseBackgroundMotion validates a background motion from the command line.
+// "ken-burns" is accepted as an alias.
+func ParseBackgroundMotion(s string) (BackgroundMotion, error) {
+	switch motion := BackgroundMotion(strings.ToLower(s)); motion {
+	case MotionNone, MotionKenBurns:
+		return motion, nil
+	case "ken-burns":
+		return MotionKenBurns, nil
+	}
+	return "", fmt.Errorf("invalid background motion %q: must be none or kenburns", s)
+}
+
+// ClipShape is the frame of a `jivefire clip` audiogram.
+type ClipShape string
+
+// Clip shapes
+const (
+	ClipSquare   ClipShape = "square"   // 1080x1080, for feeds
+	ClipVertical ClipShape = "vertical" // 1080x1920, for stories and shorts
+)
+
+// ParseClipShape validates a clip shape from the command line.
+func ParseClipShape(s string) (ClipShape, error) {
+	switch shape := ClipShape(strings.ToLower(s)); shape {
+	case ClipSquare, ClipVertical:
+		return shape, nil
+	}
+	return "", fmt.Errorf("invalid clip shape %q: must be square or vertical", s)
+}
+
+// MaxBands is the most bands --bands can split the bars into: bass, voice
+// and treble.
+const MaxBands = 3
+
+// defaultBandColors colour the bands above the bass, which keeps the bar
+// colour: amber for the voice band and blue for the treble.
+var defaultBandColors = [MaxBands][3]uint8{
+	{BarColorR, BarColorG, BarColorB},
+	{242, 169, 0},
+	{63, 167, 214},
+}
+
+// ParseCrossovers parses --bands: one or two comma-separated frequencies in
+// Hz, rising, splitting the bars into two or three bands.
+func ParseCrossovers(s string) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) >= MaxBands {
+		return nil, fmt.Errorf("%q: give one or two crossover frequencies", s)
+	}
+	crossovers := make([]float64, len(parts))
+	for i, part := range parts {
+		hz, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || hz <= 0 {
+			return nil, fmt.Errorf("%q: crossovers must be positive frequencies in Hz", s)
+		}
+		if i > 0 && hz <= crossovers[i-1] {
+			return nil, fmt.Errorf("%q: crossovers must rise", s)
+		}
+		crossovers[i] = hz
+	}
+	return crossovers, nil
+}
+
+// Size returns the clip's resolution.
+func (s ClipShape) Size() (width, height int) {
+	if s == ClipVertical {
+		return ClipWidth, ClipWidth * 16 / 9
+	}
+	return ClipWidth, ClipWidth
+}
+
 // OptionalColor is an RGB colour that records whether it was explicitly set.
 // When Set is false the colour is treated as absent and defaults apply.
 type OptionalColor struct {
@@ -97,6 +380,165 @@ type RuntimeConfig struct {
 	// Optional image path overrides
 	BackgroundImagePath string
 	ThumbnailImagePath  string
+	BackgroundFit       BackgroundFit
+
+	// Optional background treatment applied once at load: BackgroundDim
+	// darkens by a fraction (0-1), BackgroundBlur is a blur radius in pixels.
+	BackgroundDim  float64
+	BackgroundBlur int
+
+	// Optional background motion over the episode (see
+	// renderer.LoadMotionBackground); empty or MotionNone keeps it still.
+	BackgroundMotion BackgroundMotion
+
+	// Optional thumbnail overrides. A nil ThumbnailRotation keeps the default
+	// angle; zero disables rotation. Zero dimensions use the video resolution.
+	ThumbnailTextColor OptionalColor
+	ThumbnailRotation  *float64
+	ThumbnailAlign     TextAlign
+	ThumbnailMaxLines  int
+	ThumbnailWidth     int
+	ThumbnailHeight    int
+
+	// Optional TrueType font replacing both embedded fonts, for titles in
+	// scripts the embedded Poppins fonts do not cover
+	FontPath string
+
+	// Optional fonts tried, in order, for characters the title and
+	// thumbnail font has no glyph for, such as another script or emoji
+	FontFallbacks []string
+
+	// Optional video title layout overrides
+	TitleAlign    TextAlign
+	TitleMaxLines int
+
+	// Optional corner badge overrides. A badge image replaces the episode
+	// number in the corner; BadgePulse modulates its opacity with loudness.
+	BadgeImagePath string
+	BadgePosition  BadgePosition
+	BadgePadding   *int
+	BadgePulse     bool
+
+	// Corner for the stereo level meter, which shares the badge padding;
+	// empty draws no meter.
+	Meter BadgePosition
+
+	// Corner for the burnt-in timecode, which shares the badge padding;
+	// empty draws none. A nil TimecodePill keeps the default panel opacity,
+	// and 0 draws the digits without one.
+	Timecode     BadgePosition
+	TimecodeSize float64
+	TimecodePill *float64
+
+	// Optional embers rising from the bars with the loudness (--particles)
+	Particles bool
+
+	// Optional quiet-bar shaping (see audio.Gate). A nil NoiseGate keeps the
+	// default threshold; MinBar is the height, as a fraction of the maximum,
+	// quiet bars are lifted to during soft passages (0 leaves them gated).
+	NoiseGate *float64
+	MinBar    float64
+
+	// Optional manual bar calibration replacing the base scale Pass 1
+	// derives from the peak level; 0 keeps the derived one.
+	BaseScale float64
+
+	// Optional frequency range in Hz spread across the bars (see
+	// audio.FreqRange); zero values keep 0 Hz and the Nyquist frequency.
+	FreqMin float64
+	FreqMax float64
+
+	// Optional high- and low-pass cutoffs in Hz for the signal the bars
+	// analyse (see audio.VisFilter); 0 disables each.
+	VisHighPass float64
+	VisLowPass  float64
+	Declip      bool // Rebuild clipped peaks first (see audio.VisFilter)
+
+	// Optional shift of the bars against the audio (--av-offset): positive
+	// draws them later, negative earlier. The encoded audio is untouched.
+	AVOffset time.Duration
+
+	// Optional band colouring: Crossovers in Hz split the bars into bands
+	// coloured, low to high, by BandColors (unset entries take the bar colour
+	// for the bass and defaults above it). BarBands is each bar's band in
+	// bar (low to high) order, filled in once the sample rate is known (see
+	// audio.Bands.Split); nil draws every bar in the bar colour.
+	Crossovers []float64
+	BandColors []OptionalColor
+	BarBands   []int
+
+	// Optional reactive colouring (--bar-color-mode): with ColorReactive the
+	// bars blend from ReactiveLow when the bass dominates to ReactiveHigh
+	// when the treble does; unset bounds take the red and yellow defaults.
+	BarColorMode BarColorMode
+	ReactiveLow  OptionalColor
+	ReactiveHigh OptionalColor
+
+	// Optional centroid glow (--centroid-glow): the bars dim, or the
+	// background dims and warms, for bass-heavy sound and brighten back for
+	// treble-heavy; empty or GlowNone leaves them as they are.
+	CentroidGlow CentroidGlow
+
+	// Optional level change for the encoded audio: GainDB in dB, or
+	// Normalize to a target integrated loudness in LUFS. Zero leaves the
+	// level as it is.
+	GainDB    float64
+	Normalize float64
+
+	// NativeDecoder reads the input with the pure-Go internal/wav decoder
+	// rather than FFmpeg (--decoder=native).
+	NativeDecoder bool
+
+	// Optional music bed mixed under the audio before analysis (see
+	// audio.Mixer): MusicPath looped to the end at MusicGainDB, lowered by
+	// MusicDuckDB more while the voice is speaking. Empty mixes nothing.
+	MusicPath   string
+	MusicGainDB float64
+	MusicDuckDB float64
+
+	// Optional bumpers played before and after the episode (see
+	// audio.Stitcher), each join overlapping by Crossfade. Empty plays none.
+	IntroPath string
+	OutroPath string
+	Crossfade time.Duration
+
+	// Registered visualizer drawn over the background (see
+	// renderer.RegisterVisualizer); empty selects the default bars.
+	// WaveformLayout arranges the waveform visualizer, scrolling when empty;
+	// Colormap colours the spectrogram, magma when empty.
+	Visualizer     string
+	WaveformLayout WaveformLayout
+	Colormap       Colormap
+
+	// Optional Lua script drawing a per-frame overlay (see internal/script)
+	ScriptPath string
+
+	// Optional safe-area guides drawn on the previews, never the encode
+	// (see renderer.DrawGuides)
+	Guides bool
+
+	// Optional --layout: the widgets drawn over the visualizer, in drawing
+	// order (see ParseLayout). Nil draws DefaultLayout.
+	Layout []LayoutWidget
+
+	// Optional audiogram layout for `jivefire clip`: each frame is scaled
+	// onto a ClipShape canvas beneath ClipQuote (see renderer.Clip). Empty
+	// renders the usual 16:9 video.
+	ClipShape ClipShape
+	ClipQuote string
+}
+
+// GetReactiveColors returns the reactive bar colours for bass-heavy and
+// treble-heavy sound (uses overrides or defaults)
+func (c *RuntimeConfig) GetReactiveColors() (low, high [3]uint8) {
+	low, high = defaultReactiveLow, defaultReactiveHigh
+	if c.ReactiveLow.Set {
+		low = [3]uint8{c.ReactiveLow.R, c.ReactiveLow.G, c.ReactiveLow.B}
+	}
+	if c.ReactiveHigh.Set {
+		high = [3]uint8{c.ReactiveHigh.R, c.ReactiveHigh.G, c.ReactiveHigh.B}
+	}
+	return low, high
 }
 
 // GetBarColor returns the bar color RGB values (uses override or default)
@@ -107,6 +549,19 @@ func (c *RuntimeConfig) GetBarColor() (r, g, b uint8) {
 	return BarColorR, BarColorG, BarColorB
 }
 
+// GetBandColor returns the colour of the given band (uses override or
+// default); band 0 is the bar colour unless overridden.
+func (c *RuntimeConfig) GetBandColor(band int) (r, g, b uint8) {
+	if band < len(c.BandColors) && c.BandColors[band].Set {
+		return c.BandColors[band].R, c.BandColors[band].G, c.BandColors[band].B
+	}
+	if band <= 0 || band >= MaxBands {
+		return c.GetBarColor()
+	}
+	rgb := defaultBandColors[band]
+	return rgb[0], rgb[1], rgb[2]
+}
+
 // GetTextColor returns the text color RGB values (uses override or default)
 func (c *RuntimeConfig) GetTextColor() (r, g, b uint8) {
 	if c.TextColor.Set {
@@ -133,6 +588,175 @@ func (c *RuntimeConfig) GetThumbnailImagePath() (path string, isCustom bool) {
 	return ThumbnailImageAsset, false
 }
 
+// GetThumbnailTextColor returns the thumbnail text RGB values (uses the
+// thumbnail override, then the text colour override, then the default)
+func (c *RuntimeConfig) GetThumbnailTextColor() (r, g, b uint8) {
+	if c.ThumbnailTextColor.Set {
+		return c.ThumbnailTextColor.R, c.ThumbnailTextColor.G, c.ThumbnailTextColor.B
+	}
+	return c.GetTextColor()
+}
+
+// GetThumbnailRotation returns the thumbnail text rotation in degrees
+// clockwise (uses override or default)
+func (c *RuntimeConfig) GetThumbnailRotation() float64 {
+	if c.ThumbnailRotation != nil {
+		return *c.ThumbnailRotation
+	}
+	return ThumbnailTextRotationDegrees
+}
+
+// GetThumbnailAlign returns the thumbnail text alignment (uses override or centre)
+func (c *RuntimeConfig) GetThumbnailAlign() TextAlign {
+	if c.ThumbnailAlign != "" {
+		return c.ThumbnailAlign
+	}
+	return AlignCentre
+}
+
+// GetThumbnailMaxLines returns the number of thumbnail title lines (uses override or default)
+func (c *RuntimeConfig) GetThumbnailMaxLines() int {
+	if c.ThumbnailMaxLines > 0 {
+		return c.ThumbnailMaxLines
+	}
+	return ThumbnailMaxLines
+}
+
+// GetThumbnailSize returns the thumbnail resolution (uses override or the
+// video resolution)
+func (c *RuntimeConfig) GetThumbnailSize() (width, height int) {
+	if c.ThumbnailWidth > 0 && c.ThumbnailHeight > 0 {
+		return c.ThumbnailWidth, c.ThumbnailHeight
+	}
+	return Width, Height
+}
+
+// GetVideoSize returns the encoded video resolution: the clip's for an
+// audiogram, otherwise Width×Height.
+func (c *RuntimeConfig) GetVideoSize() (width, height int) {
+	if c.ClipShape != "" {
+		return c.ClipShape.Size()
+	}
+	return Width, Height
+}
+
+// GetTitleFontPath returns the video title font path and whether it is a
+// custom filesystem path (true) or the default embedded asset (false).
+func (c *RuntimeConfig) GetTitleFontPath() (path string, isCustom bool) {
+	if c.FontPath != "" {
+		return c.FontPath, true
+	}
+	return VideoTitleFontAsset, false
+}
+
+// GetThumbnailFontPath returns the thumbnail font path and whether it is a
+// custom filesystem path (true) or the default embedded asset (false).
+func (c *RuntimeConfig) GetThumbnailFontPath() (path string, isCustom bool) {
+	if c.FontPath != "" {
+		return c.FontPath, true
+	}
+	return ThumbnailFontAsset, false
+}
+
+// GetTitleAlign returns the video title alignment (uses override or centre)
+func (c *RuntimeConfig) GetTitleAlign() TextAlign {
+	if c.TitleAlign != "" {
+		return c.TitleAlign
+	}
+	return AlignCentre
+}
+
+// GetBackgroundFit returns the background fit mode (uses override or cover)
+func (c *RuntimeConfig) GetBackgroundFit() BackgroundFit {
+	if c.BackgroundFit != "" {
+		return c.BackgroundFit
+	}
+	return FitCover
+}
+
+// GetTitleMaxLines returns the maximum wrapped title lines (uses override or default)
+func (c *RuntimeConfig) GetTitleMaxLines() int {
+	if c.TitleMaxLines > 0 {
+		return c.TitleMaxLines
+	}
+	return TitleMaxLines
+}
+
+// GetBadgePosition returns the badge corner (uses override or top-right)
+func (c *RuntimeConfig) GetBadgePosition() BadgePosition {
+	if c.BadgePosition != "" {
+		return c.BadgePosition
+	}
+	return BadgeTopRight
+}
+
+// GetLayout returns the widgets to draw, in order (uses override or
+// DefaultLayout)
+func (c *RuntimeConfig) GetLayout() []LayoutWidget {
+	if c.Layout != nil {
+		return c.Layout
+	}
+	return DefaultLayout
+}
+
+// GetBadgePadding returns the badge inset in pixels (uses override or default)
+func (c *RuntimeConfig) GetBadgePadding() int {
+	if c.BadgePadding != nil {
+		return *c.BadgePadding
+	}
+	return BadgePadding
+}
+
+// GetTimecodeSize returns the timecode font size in points (uses override or
+// default)
+func (c *RuntimeConfig) GetTimecodeSize() float64 {
+	if c.TimecodeSize > 0 {
+		return c.TimecodeSize
+	}
+	return TimecodeFontSize
+}
+
+// GetTimecodePill returns the opacity of the panel behind the timecode (uses
+// override or default)
+func (c *RuntimeConfig) GetTimecodePill() float64 {
+	if c.TimecodePill != nil {
+		return *c.TimecodePill
+	}
+	return TimecodePill
+}
+
+// GetNoiseGate returns the bar noise gate threshold (uses override or default)
+func (c *RuntimeConfig) GetNoiseGate() float64 {
+	if c.NoiseGate != nil {
+		return *c.NoiseGate
+	}
+	return NoiseGate
+}
+
+// ParseSize parses a WIDTHxHEIGHT resolution such as 1920x1080, bounded by
+// ThumbnailMinSize and ThumbnailMaxSize
+func ParseSize(size string) (width, height int, err error) {
+	w, h, ok := strings.Cut(strings.ToLower(size), "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid size %q: must be WIDTHxHEIGHT (e.g., 1920x1080)", size)
+	}
+
+	width, err = strconv.Atoi(w)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid width in size %q: %w", size, err)
+	}
+	height, err = strconv.Atoi(h)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid height in size %q: %w", size, err)
+	}
+
+	if width < ThumbnailMinSize || height < ThumbnailMinSize || width > ThumbnailMaxSize || height > ThumbnailMaxSize {
+		return 0, 0, fmt.Errorf("invalid size %q: each dimension must be between %d and %d", size, ThumbnailMinSize, ThumbnailMaxSize)
+	}
+
+	return width, height, nil
+}
+
 // ParseHexColor parses a hex color string (#RRGGBB or RRGGBB) and returns RGB values
 func ParseHexColor(hex string) (r, g, b uint8, err error) {
 	// Remove leading # if present
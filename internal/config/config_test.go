@@ -1,6 +1,7 @@
 package config
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -596,3 +597,286 @@ func TestRuntimeConfig_NilFields(t *testing.T) {
 		})
 	}
 }
+
+// TestParseBadgePosition verifies all four corners are accepted (case
+// insensitively) and anything else is rejected.
+func TestParseBadgePosition(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    BadgePosition
+		wantErr bool
+	}{
+		{input: "top-left", want: BadgeTopLeft},
+		{input: "top-right", want: BadgeTopRight},
+		{input: "Bottom-Left", want: BadgeBottomLeft},
+		{input: "bottom-right", want: BadgeBottomRight},
+		{input: "centre", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseBadgePosition(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBadgePosition(%q) error = %v, wantErr %t", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseBadgePosition(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRuntimeConfig_BadgeDefaults verifies the badge getters fall back to the
+// top-right corner and default padding, and honour an explicit zero padding.
+func TestRuntimeConfig_BadgeDefaults(t *testing.T) {
+	c := &RuntimeConfig{}
+	if got := c.GetBadgePosition(); got != BadgeTopRight {
+		t.Errorf("GetBadgePosition() = %q, want %q", got, BadgeTopRight)
+	}
+	if got := c.GetBadgePadding(); got != BadgePadding {
+		t.Errorf("GetBadgePadding() = %d, want %d", got, BadgePadding)
+	}
+
+	zero := 0
+	c = &RuntimeConfig{BadgePosition: BadgeBottomLeft, BadgePadding: &zero}
+	if got := c.GetBadgePosition(); got != BadgeBottomLeft {
+		t.Errorf("GetBadgePosition() = %q, want %q", got, BadgeBottomLeft)
+	}
+	if got := c.GetBadgePadding(); got != 0 {
+		t.Errorf("GetBadgePadding() = %d, want 0", got)
+	}
+}
+
+// TestRuntimeConfig_NoiseGateDefault verifies GetNoiseGate falls back to the
+// default threshold and honours an explicit zero, which disables the gate.
+func TestRuntimeConfig_NoiseGateDefault(t *testing.T) {
+	c := &RuntimeConfig{}
+	if got := c.GetNoiseGate(); got != NoiseGate {
+		t.Errorf("GetNoiseGate() = %v, want %v", got, NoiseGate)
+	}
+
+	zero := 0.0
+	c = &RuntimeConfig{NoiseGate: &zero}
+	if got := c.GetNoiseGate(); got != 0 {
+		t.Errorf("GetNoiseGate() = %v, want 0", got)
+	}
+}
+
+// TestParseTextAlign verifies the accepted alignment names, including the
+// American "center" alias.
+func TestParseTextAlign(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    TextAlign
+		wantErr bool
+	}{
+		{input: "left", want: AlignLeft},
+		{input: "centre", want: AlignCentre},
+		{input: "Center", want: AlignCentre},
+		{input: "right", want: AlignRight},
+		{input: "justify", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTextAlign(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTextAlign(%q) error = %v, wantErr %t", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTextAlign(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseWaveformLayout verifies the accepted layout names, including the
+// American "center" alias.
+func TestParseWaveformLayout(t *testing.T) {
+	for input, want := range map[string]WaveformLayout{"scroll": WaveformScroll, "centre": WaveformCentre, "Center": WaveformCentre} {
+		if got, err := ParseWaveformLayout(input); err != nil || got != want {
+			t.Errorf("ParseWaveformLayout(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseWaveformLayout("spiral"); err == nil {
+		t.Error("ParseWaveformLayout(\"spiral\") succeeded, want error")
+	}
+}
+
+// TestParseColormap verifies the accepted colormap names, including the
+// American "gray" alias.
+func TestParseColormap(t *testing.T) {
+	for input, want := range map[string]Colormap{"magma": ColormapMagma, "Viridis": ColormapViridis, "fire": ColormapFire, "gray": ColormapGrey} {
+		if got, err := ParseColormap(input); err != nil || got != want {
+			t.Errorf("ParseColormap(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseColormap("rainbow"); err == nil {
+		t.Error("ParseColormap(\"rainbow\") succeeded, want error")
+	}
+}
+
+// TestParseSize verifies WIDTHxHEIGHT parsing and the dimension bounds.
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantW   int
+		wantH   int
+		wantErr bool
+	}{
+		{input: "1920x1080", wantW: 1920, wantH: 1080},
+		{input: "1280X720", wantW: 1280, wantH: 720},
+		{input: "1920", wantErr: true},
+		{input: "axb", wantErr: true},
+		{input: "100x100", wantErr: true},
+		{input: "8000x4000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			w, h, err := ParseSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSize(%q) error = %v, wantErr %t", tt.input, err, tt.wantErr)
+			}
+			if w != tt.wantW || h != tt.wantH {
+				t.Errorf("ParseSize(%q) = %dx%d, want %dx%d", tt.input, w, h, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+// TestRuntimeConfig_ThumbnailDefaults verifies the thumbnail getters fall back
+// to the video text colour, default rotation and video resolution, and that
+// an explicit zero rotation is honoured.
+func TestRuntimeConfig_ThumbnailDefaults(t *testing.T) {
+	c := &RuntimeConfig{TextColor: OptionalColor{R: 1, G: 2, B: 3, Set: true}}
+	if r, g, b := c.GetThumbnailTextColor(); r != 1 || g != 2 || b != 3 {
+		t.Errorf("GetThumbnailTextColor() = (%d, %d, %d), want text colour (1, 2, 3)", r, g, b)
+	}
+	if got := c.GetThumbnailRotation(); got != ThumbnailTextRotationDegrees {
+		t.Errorf("GetThumbnailRotation() = %v, want %v", got, ThumbnailTextRotationDegrees)
+	}
+	if w, h := c.GetThumbnailSize(); w != Width || h != Height {
+		t.Errorf("GetThumbnailSize() = %dx%d, want %dx%d", w, h, Width, Height)
+	}
+
+	zero := 0.0
+	c = &RuntimeConfig{
+		TextColor:          OptionalColor{R: 1, G: 2, B: 3, Set: true},
+		ThumbnailTextColor: OptionalColor{R: 9, G: 8, B: 7, Set: true},
+		ThumbnailRotation:  &zero,
+		ThumbnailWidth:     1920,
+		ThumbnailHeight:    1080,
+	}
+	if r, g, b := c.GetThumbnailTextColor(); r != 9 || g != 8 || b != 7 {
+		t.Errorf("GetThumbnailTextColor() = (%d, %d, %d), want override (9, 8, 7)", r, g, b)
+	}
+	if got := c.GetThumbnailRotation(); got != 0 {
+		t.Errorf("GetThumbnailRotation() = %v, want 0", got)
+	}
+	if w, h := c.GetThumbnailSize(); w != 1920 || h != 1080 {
+		t.Errorf("GetThumbnailSize() = %dx%d, want 1920x1080", w, h)
+	}
+}
+
+// TestParseBackgroundFit verifies the three fit modes are accepted (case
+// insensitively), anything else is rejected, and cover is the default.
+func TestParseBackgroundFit(t *testing.T) {
+	for in, want := range map[string]BackgroundFit{"stretch": FitStretch, "Cover": FitCover, "contain": FitContain} {
+		if got, err := ParseBackgroundFit(in); err != nil || got != want {
+			t.Errorf("ParseBackgroundFit(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseBackgroundFit("tile"); err == nil {
+		t.Error("ParseBackgroundFit(tile) succeeded, want error")
+	}
+	if got := (&RuntimeConfig{}).GetBackgroundFit(); got != FitCover {
+		t.Errorf("GetBackgroundFit() = %q, want %q", got, FitCover)
+	}
+}
+
+func TestParseCrossovers(t *testing.T) {
+	for in, want := range map[string][]float64{"250": {250}, "250, 4000": {250, 4000}} {
+		got, err := ParseCrossovers(in)
+		if err != nil || !slices.Equal(got, want) {
+			t.Errorf("ParseCrossovers(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "bass", "0", "-100", "4000,250", "250,250", "100,1000,8000"} {
+		if _, err := ParseCrossovers(in); err == nil {
+			t.Errorf("ParseCrossovers(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestGetBandColor(t *testing.T) {
+	c := &RuntimeConfig{BarColor: OptionalColor{R: 1, G: 2, B: 3, Set: true}}
+	if r, g, b := c.GetBandColor(0); r != 1 || g != 2 || b != 3 {
+		t.Errorf("bass band = %d,%d,%d, want the bar colour", r, g, b)
+	}
+	if r, g, b := c.GetBandColor(1); [3]uint8{r, g, b} != defaultBandColors[1] {
+		t.Errorf("voice band = %d,%d,%d, want the default", r, g, b)
+	}
+	c.BandColors = []OptionalColor{{}, {R: 9, G: 8, B: 7, Set: true}}
+	if r, g, b := c.GetBandColor(1); r != 9 || g != 8 || b != 7 {
+		t.Errorf("voice band = %d,%d,%d, want the override", r, g, b)
+	}
+}
+
+func TestParseClipShape(t *testing.T) {
+	for in, want := range map[string]ClipShape{"square": ClipSquare, "Vertical": ClipVertical} {
+		if got, err := ParseClipShape(in); err != nil || got != want {
+			t.Errorf("ParseClipShape(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseClipShape("landscape"); err == nil {
+		t.Error("ParseClipShape(landscape) succeeded, want error")
+	}
+	if w, h := (&RuntimeConfig{}).GetVideoSize(); w != Width || h != Height {
+		t.Errorf("GetVideoSize() = %dx%d, want %dx%d", w, h, Width, Height)
+	}
+	if w, h := (&RuntimeConfig{ClipShape: ClipVertical}).GetVideoSize(); w != 1080 || h != 1920 {
+		t.Errorf("vertical clip GetVideoSize() = %dx%d, want 1080x1920", w, h)
+	}
+}
+
+func TestParseBackgroundMotion(t *testing.T) {
+	for in, want := range map[string]BackgroundMotion{"none": MotionNone, "KenBurns": MotionKenBurns, "ken-burns": MotionKenBurns} {
+		if got, err := ParseBackgroundMotion(in); err != nil || got != want {
+			t.Errorf("ParseBackgroundMotion(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseBackgroundMotion("parallax"); err == nil {
+		t.Error("ParseBackgroundMotion(parallax) succeeded, want error")
+	}
+}
+
+func TestParseCentroidGlow(t *testing.T) {
+	for in, want := range map[string]CentroidGlow{"none": GlowNone, "Bars": GlowBars, "background": GlowBackground} {
+		if got, err := ParseCentroidGlow(in); err != nil || got != want {
+			t.Errorf("ParseCentroidGlow(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseCentroidGlow("title"); err == nil {
+		t.Error("ParseCentroidGlow(title) succeeded, want error")
+	}
+}
+
+func TestParseBarColorMode(t *testing.T) {
+	for in, want := range map[string]BarColorMode{"fixed": ColorFixed, "Reactive": ColorReactive} {
+		if got, err := ParseBarColorMode(in); err != nil || got != want {
+			t.Errorf("ParseBarColorMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseBarColorMode("rainbow"); err == nil {
+		t.Error("ParseBarColorMode(rainbow) succeeded, want error")
+	}
+	if low, high := (&RuntimeConfig{}).GetReactiveColors(); low != defaultReactiveLow || high != defaultReactiveHigh {
+		t.Errorf("GetReactiveColors() = %v, %v; want the defaults", low, high)
+	}
+	c := &RuntimeConfig{ReactiveHigh: OptionalColor{R: 1, G: 2, B: 3, Set: true}}
+	if low, high := c.GetReactiveColors(); low != defaultReactiveLow || high != [3]uint8{1, 2, 3} {
+		t.Errorf("GetReactiveColors() = %v, %v; want the default low and the override", low, high)
+	}
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Widget names a layer of the video frame drawn over the visualizer, which
+// a --layout file can place.
+type Widget string
+
+// Widgets a layout can list
+const (
+	WidgetFramingLines Widget = "framing-lines" // Lines above and below the centre gap
+	WidgetParticles    Widget = "particles"     // Embers rising from the bars
+	WidgetTitle        Widget = "title"         // Episode title
+	WidgetBadge        Widget = "badge"         // Episode number or --badge-image logo, in a corner
+	WidgetMeter        Widget = "meter"         // Stereo level meter, in a corner
+	WidgetTimecode     Widget = "timecode"      // HH:MM:SS:FF counter, in a corner
+	WidgetProgress     Widget = "progress"      // Bar along an edge filling as the episode plays
+	WidgetCaptions     Widget = "captions"      // Clip quote or subtitle cue, above the frame or in it
+	WidgetScript       Widget = "script"        // The --script overlay
+)
+
+// Widget anchors besides the corners: title, progress bar and captions
+// placements. AnchorAbove puts the captions on the clip canvas above the
+// frame rather than in it.
+const (
+	AnchorCentre = "centre"
+	AnchorTop    = "top"
+	AnchorBottom = "bottom"
+	AnchorAbove  = "above"
+)
+
+// DefaultLayout is the frame without a --layout file: the framing lines,
+// particles, title, badge, level meter, timecode, captions and script
+// overlay, bottom to top. Widgets without their flags (--particles, --meter,
+// --timecode, --script) draw nothing, and the captions draw only in clips.
+var DefaultLayout = []LayoutWidget{
+	{Widget: WidgetFramingLines},
+	{Widget: WidgetParticles},
+	{Widget: WidgetTitle},
+	{Widget: WidgetBadge},
+	{Widget: WidgetMeter},
+	{Widget: WidgetTimecode},
+	{Widget: WidgetCaptions, Anchor: AnchorAbove},
+	{Widget: WidgetScript},
+}
+
+// LayoutWidget is an entry of a --layout file: a widget, where it is
+// anchored, and its z-order. Higher Z draws later, over lower; entries with
+// the same Z draw in the order listed.
+type LayoutWidget struct {
+	Widget Widget `json:"widget"`
+	Anchor string `json:"anchor,omitempty"`
+	Z      int    `json:"z,omitempty"`
+}
+
+// layoutFile is the --layout JSON document.
+type layoutFile struct {
+	Widgets []LayoutWidget `json:"widgets"`
+}
+
+// LoadLayout reads a --layout file and returns its widgets in drawing
+// order, with anchors normalised. Only the widgets it lists are drawn.
+func LoadLayout(path string) ([]LayoutWidget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f layoutFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	layout, err := ParseLayout(f.Widgets)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return layout, nil
+}
+
+// ParseLayout validates widgets, each listed at most once, and returns them
+// sorted into drawing order.
+func ParseLayout(widgets []LayoutWidget) ([]LayoutWidget, error) {
+	layout := make([]LayoutWidget, 0, len(widgets))
+	seen := make(map[Widget]bool)
+	for _, w := range widgets {
+		w.Widget = Widget(strings.ToLower(string(w.Widget)))
+		if seen[w.Widget] {
+			return nil, fmt.Errorf("widget %q is listed twice", w.Widget)
+		}
+		seen[w.Widget] = true
+		anchor, err := parseAnchor(w.Widget, w.Anchor)
+		if err != nil {
+			return nil, err
+		}
+		w.Anchor = anchor
+		layout = append(layout, w)
+	}
+	slices.SortStableFunc(layout, func(a, b LayoutWidget) int { return a.Z - b.Z })
+	return layout, nil
+}
+
+// parseAnchor validates the anchor of widget w; empty keeps its default.
+func parseAnchor(w Widget, anchor string) (string, error) {
+	anchor = strings.ToLower(anchor)
+	switch w {
+	case WidgetFramingLines, WidgetParticles, WidgetScript:
+		if anchor != "" {
+			return "", fmt.Errorf("widget %q takes no anchor: it follows the visualiser", w)
+		}
+		return "", nil
+	case WidgetTitle:
+		switch anchor {
+		case "", AnchorCentre, "center":
+			return AnchorCentre, nil
+		case AnchorTop, AnchorBottom:
+			return anchor, nil
+		}
+		return "", fmt.Errorf("invalid title anchor %q: must be centre, top or bottom", anchor)
+	case WidgetProgress:
+		switch anchor {
+		case "":
+			return AnchorBottom, nil
+		case AnchorTop, AnchorBottom:
+			return anchor, nil
+		}
+		return "", fmt.Errorf("invalid progress anchor %q: must be top or bottom", anchor)
+	case WidgetCaptions:
+		switch anchor {
+		case "", AnchorAbove:
+			return AnchorAbove, nil
+		case "center":
+			return AnchorCentre, nil
+		case AnchorCentre, AnchorTop, AnchorBottom:
+			return anchor, nil
+		}
+		return "", fmt.Errorf("invalid captions anchor %q: must be above, centre, top or bottom", anchor)
+	case WidgetBadge, WidgetMeter, WidgetTimecode:
+		if anchor == "" {
+			return "", nil
+		}
+		pos, err := ParseBadgePosition(anchor)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s anchor %q: must be top-left, top-right, bottom-left or bottom-right", w, anchor)
+		}
+		return string(pos), nil
+	}
+	return "", fmt.Errorf("unknown widget %q: must be framing-lines, particles, title, badge, meter, timecode, progress, captions or script", w)
+}
+
+// LayoutEntry returns the entry for widget w in layout, and whether it is
+// there.
+func LayoutEntry(layout []LayoutWidget, w Widget) (LayoutWidget, bool) {
+	i := slices.IndexFunc(layout, func(e LayoutWidget) bool { return e.Widget == w })
+	if i < 0 {
+		return LayoutWidget{}, false
+	}
+	return layout[i], true
+}
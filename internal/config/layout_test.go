@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseLayout verifies anchors default and normalise, the widgets sort
+// by z-order keeping the listed order for ties, and bad entries are refused.
+func TestParseLayout(t *testing.T) {
+	layout, err := ParseLayout([]LayoutWidget{
+		{Widget: "Meter", Anchor: "Top-Left", Z: 2},
+		{Widget: WidgetTitle, Anchor: "center"},
+		{Widget: WidgetProgress},
+		{Widget: WidgetBadge, Z: -1},
+		{Widget: WidgetCaptions},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LayoutWidget{
+		{Widget: WidgetBadge, Z: -1},
+		{Widget: WidgetTitle, Anchor: AnchorCentre},
+		{Widget: WidgetProgress, Anchor: AnchorBottom},
+		{Widget: WidgetCaptions, Anchor: AnchorAbove},
+		{Widget: WidgetMeter, Anchor: string(BadgeTopLeft), Z: 2},
+	}
+	for i := range want {
+		if layout[i] != want[i] {
+			t.Errorf("layout[%d] = %+v, want %+v", i, layout[i], want[i])
+		}
+	}
+
+	for _, bad := range [][]LayoutWidget{
+		{{Widget: "clock"}},
+		{{Widget: WidgetTitle}, {Widget: WidgetTitle}},
+		{{Widget: WidgetFramingLines, Anchor: "top"}},
+		{{Widget: WidgetProgress, Anchor: "top-left"}},
+		{{Widget: WidgetBadge, Anchor: "middle"}},
+		{{Widget: WidgetCaptions, Anchor: "bottom-right"}},
+	} {
+		if _, err := ParseLayout(bad); err == nil {
+			t.Errorf("ParseLayout(%+v) accepted", bad)
+		}
+	}
+}
+
+// TestLoadLayout verifies a layout file is read, and a misspelt key is
+// reported rather than ignored.
+func TestLoadLayout(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "layout.json")
+	if err := os.WriteFile(path, []byte(`{"widgets": [{"widget": "progress", "anchor": "top"}, {"widget": "title"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	layout, err := LoadLayout(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layout) != 2 || layout[0].Anchor != AnchorTop {
+		t.Errorf("LoadLayout = %+v", layout)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"widgets": [{"widget": "title", "anchr": "top"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLayout(path); err == nil || !strings.Contains(err.Error(), "anchr") {
+		t.Errorf("LoadLayout with a misspelt key: %v, want it named", err)
+	}
+}
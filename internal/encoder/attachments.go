@@ -0,0 +1,66 @@
+package encoder
+
+import (
+	"fmt"
+	"unsafe"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
+)
+
+// inputPadding is AV_INPUT_BUFFER_PADDING_SIZE: the zeroed bytes FFmpeg
+// expects past the end of any extradata it reads.
+const inputPadding = 64
+
+// Attachment is a file carried inside the output, such as the source audio
+// of an archive render. Only Matroska has attachments.
+type Attachment struct {
+	Name     string // File name the attachment is extracted as
+	MimeType string
+	Data     []byte
+}
+
+// addAttachments adds each configured attachment to the output as an
+// attachment stream, its bytes held as the stream's extradata, as ffmpeg's
+// -attach does. It must run before the header is written, which is when the
+// Matroska muxer writes its Attachments element.
+//
+// The extradata is allocated with av_mallocz and the filename and mimetype
+// tags set on the stream, so avformat_free_context releases both with the
+// rest of the muxer state, including on a partial failure.
+func (e *Encoder) addAttachments() error {
+	if len(e.config.Attachments) == 0 {
+		return nil
+	}
+	if name := e.formatCtx.Oformat().Name().String(); name != "matroska" {
+		return fmt.Errorf("attachments need a Matroska output, not %s", name)
+	}
+
+	for i, a := range e.config.Attachments {
+		stream := ffmpeg.AVFormatNewStream(e.formatCtx, nil)
+		if stream == nil {
+			return fmt.Errorf("failed to create attachment stream %d", i+1)
+		}
+		par := stream.Codecpar()
+		par.SetCodecType(ffmpeg.AVMediaTypeAttachment)
+
+		data := ffmpeg.AVMallocz(uint64(len(a.Data) + inputPadding))
+		if data == nil {
+			return fmt.Errorf("failed to allocate attachment %s", a.Name)
+		}
+		copy(unsafe.Slice((*byte)(data), len(a.Data)), a.Data)
+		par.SetExtradata(data)
+		par.SetExtradataSize(len(a.Data))
+
+		meta := stream.Metadata()
+		if err := ffmpegutil.DictSet(&meta, "filename", a.Name); err != nil {
+			return fmt.Errorf("attachment %s: %w", a.Name, err)
+		}
+		if err := ffmpegutil.DictSet(&meta, "mimetype", a.MimeType); err != nil {
+			return fmt.Errorf("attachment %s: %w", a.Name, err)
+		}
+		stream.SetMetadata(meta)
+	}
+
+	return nil
+}
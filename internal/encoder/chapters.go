@@ -0,0 +1,66 @@
+package encoder
+
+import (
+	"fmt"
+	"unsafe"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
+)
+
+// chapterTimeBase is the millisecond time base used for chapter start/end
+// values. Chapter timestamps are parsed at millisecond precision.
+const chapterTimeBase = 1000
+
+// avChapterSize is sizeof(AVChapter) on 64-bit platforms: int64 id,
+// AVRational time_base, int64 start, int64 end, AVDictionary *metadata.
+const avChapterSize = 40
+
+// addChapters attaches the configured chapters to the output context. It must
+// run before the header is written: the MP4 muxer sizes its QuickTime chapter
+// track at header time and writes the Nero chpl atom from the same list.
+//
+// Chapters are allocated with av_mallocz and attached to the context as they
+// are built, so avformat_free_context releases them (and their metadata)
+// alongside the rest of the muxer state, including on a partial failure.
+func (e *Encoder) addChapters() error {
+	n := len(e.config.Chapters)
+	if n == 0 {
+		return nil
+	}
+
+	arr := ffmpeg.AVMallocz(uint64(n) * uint64(unsafe.Sizeof(uintptr(0))))
+	if arr == nil {
+		return fmt.Errorf("failed to allocate chapter array")
+	}
+	slots := unsafe.Slice((*unsafe.Pointer)(arr), n)
+	e.formatCtx.SetChapters(ffmpeg.ToAVChapterArray(arr))
+
+	tb := ffmpeg.AVMakeQ(1, chapterTimeBase)
+	for i, ch := range e.config.Chapters {
+		if ch.End <= ch.Start {
+			return fmt.Errorf("chapter %q has no duration", ch.Title)
+		}
+
+		p := ffmpeg.AVMallocz(avChapterSize)
+		if p == nil {
+			return fmt.Errorf("failed to allocate chapter %d", i)
+		}
+		slots[i] = p
+		e.formatCtx.SetNbChapters(uint(i + 1))
+
+		chapter := ffmpeg.ToAVChapter(p)
+		chapter.SetId(int64(i))
+		chapter.SetTimeBase(tb)
+		chapter.SetStart(ch.Start.Milliseconds())
+		chapter.SetEnd(ch.End.Milliseconds())
+
+		var meta *ffmpeg.AVDictionary
+		if err := ffmpegutil.DictSet(&meta, "title", ch.Title); err != nil {
+			return fmt.Errorf("chapter %d: %w", i+1, err)
+		}
+		chapter.SetMetadata(meta)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package encoder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VideoCodec names the compression format of the video stream.
+type VideoCodec string
+
+const (
+	CodecH264 VideoCodec = "h264" // H.264/AVC on libx264 or any hardware encoder (default)
+	CodecAV1  VideoCodec = "av1"  // AV1 on NVENC, QSV, VA-API or AMF; there is no software AV1 encoder
+)
+
+// ParseVideoCodec validates a --video-codec value.
+func ParseVideoCodec(s string) (VideoCodec, error) {
+	switch c := VideoCodec(strings.ToLower(s)); c {
+	case CodecH264, CodecAV1:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown video codec %q (must be h264 or av1)", s)
+}
+
+// DisplayName returns the codec's name as it is usually written.
+func (c VideoCodec) DisplayName() string {
+	if c == CodecAV1 {
+		return "AV1"
+	}
+	return "H.264"
+}
+
+// orDefault returns c, or H.264 when c is empty.
+func (c VideoCodec) orDefault() VideoCodec {
+	if c == "" {
+		return CodecH264
+	}
+	return c
+}
+
+// AudioCodec names the compression format of the audio stream.
+type AudioCodec string
+
+const (
+	AudioAAC  AudioCodec = "aac"  // AAC-LC at the source's rate (default)
+	AudioOpus AudioCodec = "opus" // Opus on libopus, resampled to 48 kHz; Matroska and WebM only
+	AudioFLAC AudioCodec = "flac" // Lossless 24-bit FLAC at the source's rate; Matroska only
+)
+
+// opusSampleRate is the rate Opus audio is encoded at. libopus takes a few
+// lower rates too, but decoders play Opus at 48 kHz whatever it was encoded
+// at, so anything else would only be resampled twice.
+const opusSampleRate = 48000
+
+// ParseAudioCodec validates an --audio-codec value.
+func ParseAudioCodec(s string) (AudioCodec, error) {
+	switch c := AudioCodec(strings.ToLower(s)); c {
+	case AudioAAC, AudioOpus, AudioFLAC:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown audio codec %q (must be aac, opus or flac)", s)
+}
+
+// DisplayName returns the codec's name as it is usually written.
+func (c AudioCodec) DisplayName() string {
+	switch c {
+	case AudioOpus:
+		return "Opus"
+	case AudioFLAC:
+		return "FLAC"
+	}
+	return "AAC"
+}
+
+// SampleRate returns the rate the codec encodes audio at sourceRate at.
+func (c AudioCodec) SampleRate(sourceRate int) int {
+	if c == AudioOpus {
+		return opusSampleRate
+	}
+	return sourceRate
+}
+
+// orDefault returns c, or AAC when c is empty.
+func (c AudioCodec) orDefault() AudioCodec {
+	if c == "" {
+		return AudioAAC
+	}
+	return c
+}
+
+// av1Quality maps a profile's H.264-scale quality (0-51) onto the 0-63 scale
+// of NVENC's AV1 cq. The two are near enough proportional over the range the
+// profiles use: the fast profile's CRF 24 lands on CQ 30.
+func av1Quality(quality int) int {
+	return (quality*63 + 25) / 51
+}
+
+// av1QIndex maps a profile's H.264-scale quality onto AV1's 0-255 quantiser
+// index, which VA-API's qp and AMF's qp_i and qp_p take for AV1.
+func av1QIndex(quality int) int {
+	return min(av1Quality(quality)*4, 255)
+}
+
+// mfQuality maps a profile's H.264-scale quality (0-51, lower=better) onto
+// Media Foundation's 0-100 quality, where higher is better.
+func mfQuality(quality int) int {
+	return max(100-(quality*100+25)/51, 0)
+}
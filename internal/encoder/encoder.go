@@ -2,37 +2,87 @@ package encoder
 
 import (
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"math"
+	"runtime"
+	"strconv"
 	"unsafe"
 
 	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/chapters"
+	"github.com/linuxmatters/jivefire/internal/failure"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
 	"github.com/linuxmatters/jivefire/internal/yuv"
 )
 
-// checkFFmpeg provides consistent error handling for FFmpeg API calls.
-// It checks both the Go error (binding issues) and the return code (FFmpeg errors).
-// The op parameter should describe the operation, e.g. "allocate output context".
-func checkFFmpeg(ret int, err error, op string) error {
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+// Config holds the encoder configuration
+type Config struct {
+	OutputPath    string             // Path to output MP4 file
+	Width         int                // Video width in pixels
+	Height        int                // Video height in pixels
+	Framerate     int                // Frames per second
+	SampleRate    int                // Audio sample rate (required for audio encoding)
+	AudioChannels int                // Output audio channels: 1 (mono), 2 (stereo) or 6 (5.1), defaults to 1
+	HWAccel       HWAccelType        // Hardware acceleration type (default: auto-detect)
+	HWDevice      string             // Hardware device, e.g. /dev/dri/renderD129 or a CUDA index (default: the backend's own)
+	VideoCodec    VideoCodec         // Video codec, defaults to H.264; AV1 needs a hardware encoder
+	AudioCodec    AudioCodec         // Audio codec, defaults to AAC; Opus needs a Matroska or WebM output, FLAC Matroska
+	Chapters      []chapters.Chapter // Chapter markers with End set (optional)
+	Keyframes     []int64            // Frames forced to be keyframes, ascending, such as each chapter's first (optional)
+	KeyframeEvery int                // Also force a keyframe every this many frames from the first; 0 for none
+	GOP           int                // Frames from one keyframe to the next; 0 for two seconds
+	BFrames       *int               // Most B-frames in a row, up to MaxBFrames; nil for the profile's own choice per encoder
+	Format        string             // Muxer short name, e.g. "mp4", "mpegts", "hls" or "dash" (guessed from OutputPath when empty)
+	SegmentLength int                // HLS/DASH segment length in seconds, defaults to 6
+	ColorSpace    yuv.ColorSpace     // RGB→YUV matrix and stream colour tags; empty for BT.709, or BT.601 on NVENC at limited range (see Encoder.resolveColour)
+	ColorRange    yuv.ColorRange     // Luma/chroma code range, defaults to limited
+	Profile       Profile            // Rate-control profile, defaults to ProfileFast
+	Options       []Option           // Extra video encoder AVOptions, applied over Jivefire's own (optional)
+	Metadata      []Tag              // Container metadata such as title and date; empty values are skipped (optional)
+	Attachments   []Attachment       // Files carried in the output; Matroska only (optional)
+	AudioOnly     bool               // Write the audio stream alone; Width, Height, Framerate and the video settings are ignored
+	Retries       int                // Times a transient send failure is retried, with a growing pause, before it fails (see Encoder.Retries)
+}
+
+// defaultSegmentLength is the HLS/DASH segment length in seconds, matching
+// the HLS authoring guidance.
+const defaultSegmentLength = 6
+
+// StdoutPath is the OutputPath that streams the muxed output to stdout.
+const StdoutPath = "-"
+
+// stdoutURL is FFmpeg's pipe protocol on file descriptor 1. The muxer writes
+// straight to it, so no per-packet write callback crosses the cgo boundary.
+const stdoutURL = "pipe:1"
+
+// fragmentedMovFlags let the MP4 muxer write to a non-seekable output: an
+// empty moov up front followed by self-contained fragments, instead of
+// seeking back to finalise the moov after the last packet.
+const fragmentedMovFlags = "frag_keyframe+empty_moov+default_base_moof"
+
+// colour returns the configured colour space and range with defaults applied.
+func (c Config) colour() (yuv.ColorSpace, yuv.ColorRange) {
+	cs, cr := c.ColorSpace, c.ColorRange
+	if cs == "" {
+		cs = yuv.BT709
 	}
-	if ret < 0 {
-		return fmt.Errorf("%s: %w", op, ffmpeg.WrapErr(ret))
+	if cr == "" {
+		cr = yuv.RangeLimited
 	}
-	return nil
+	return cs, cr
 }
 
-// Config holds the encoder configuration
-type Config struct {
-	OutputPath    string      // Path to output MP4 file
-	Width         int         // Video width in pixels
-	Height        int         // Video height in pixels
-	Framerate     int         // Frames per second
-	SampleRate    int         // Audio sample rate (required for audio encoding)
-	AudioChannels int         // Output audio channels: 1 (mono) or 2 (stereo), defaults to 1
-	HWAccel       HWAccelType // Hardware acceleration type (default: auto-detect)
+// outputTarget returns the URL and muxer name to open for the configured
+// output. Streaming to stdout defaults to MP4, as there is no file name to
+// guess the container from.
+func (c Config) outputTarget() (url, format string) {
+	if c.OutputPath == StdoutPath {
+		if c.Format == "" {
+			return stdoutURL, "mp4"
+		}
+		return stdoutURL, c.Format
+	}
+	return c.OutputPath, c.Format
 }
 
 // avAudioFIFO wraps FFmpeg's AVAudioFifo, confining the C handle and all
@@ -182,6 +232,10 @@ type Encoder struct {
 	// Output muxer (MP4 container)
 	formatCtx *ffmpeg.AVFormatContext
 
+	// Whether the muxer writes its metadata with the trailer (see
+	// AddMetadata)
+	trailerMetadata bool
+
 	// Video stream and encoder
 	videoStream *ffmpeg.AVStream
 	videoCodec  *ffmpeg.AVCodecContext
@@ -208,109 +262,215 @@ type Encoder struct {
 	// Persistent worker pool for per-frame RGB→YUV row conversion
 	rowPool *yuv.RowPool
 
-	// Input pixel format (RGBA for NVENC, NV12 for Vulkan/QSV, YUV420P for software)
+	// RGB→YUV coefficients for the configured colour space and range
+	matrix yuv.Matrix
+
+	// Input pixel format (RGBA or NV12 for NVENC, NV12 for Vulkan/QSV, YUV420P for software)
 	inputPixFmt ffmpeg.AVPixelFormat
 
 	// Audio stream and encoder
 	audioStream   *ffmpeg.AVStream
 	audioCodec    *ffmpeg.AVCodecContext
 	audioEncFrame *ffmpeg.AVFrame
-	audioFIFO     *avAudioFIFO // AVAudioFifo-backed FIFO for frame size adjustment (FFT needs 2048, AAC expects 1024)
+	audioFIFO     *avAudioFIFO          // AVAudioFifo-backed FIFO for frame size adjustment (FFT needs 2048, AAC expects 1024, Opus 960)
+	resampler     *audioResampler       // SampleRate to the codec's rate, nil when they match
+	audioFormat   ffmpeg.AVSampleFormat // What the encoder takes: planar float (AAC), packed float (libopus) or packed int32 (FLAC)
+
+	// Whether the input frame (swYUVFrame, hwNV12Frame or rgbaFrame) holds
+	// the last frame written, so WriteFrameRGBARows can keep its unchanged rows
+	haveInput bool
 
 	// Timestamp tracking
 	nextVideoPts int64
 	nextAudioPts int64
+
+	// Index of the first of Config.Keyframes not yet sent
+	nextKeyframe int
+
+	// Hardware watchdog: consecutive failed frame sends, and the failure that
+	// triggered a mid-run switch to libx264 (nil while on the original encoder)
+	hwFailures  int
+	fallbackErr error
+
+	// Test hook: when set, each video send first calls it and fails with
+	// its error, as a lost hardware device would
+	failSend func() error
+
+	// Config.Options the video encoder did not recognise
+	unusedOptions []string
+
+	// Transient send failures retried (see Retries)
+	retries []Retry
+
+	// The video packets written, for Stats
+	stats packetStats
 }
 
 // New creates a new encoder instance
 func New(config Config) (*Encoder, error) {
 	// Validate configuration
-	if config.Width <= 0 || config.Height <= 0 {
-		return nil, fmt.Errorf("invalid dimensions: %dx%d", config.Width, config.Height)
-	}
-	if config.Framerate <= 0 {
-		return nil, fmt.Errorf("invalid framerate: %d", config.Framerate)
+	if config.AudioOnly {
+		if config.SampleRate <= 0 {
+			return nil, fmt.Errorf("audio-only output needs a sample rate")
+		}
+	} else {
+		if config.Width <= 0 || config.Height <= 0 {
+			return nil, fmt.Errorf("invalid dimensions: %dx%d", config.Width, config.Height)
+		}
+		if config.Framerate <= 0 {
+			return nil, fmt.Errorf("invalid framerate: %d", config.Framerate)
+		}
+		if config.GOP < 0 {
+			return nil, fmt.Errorf("invalid GOP: %d", config.GOP)
+		}
+		if config.BFrames != nil && (*config.BFrames < 0 || *config.BFrames > MaxBFrames) {
+			return nil, fmt.Errorf("invalid B-frames: %d (must be 0 to %d)", *config.BFrames, MaxBFrames)
+		}
 	}
 	if config.OutputPath == "" {
 		return nil, fmt.Errorf("output path cannot be empty")
 	}
 
+	colorSpace, colorRange := config.colour()
 	return &Encoder{
 		config:       config,
+		matrix:       yuv.NewMatrix(colorSpace, colorRange),
+		stats:        newPacketStats(config.Framerate),
 		nextVideoPts: 0,
 		nextAudioPts: 0,
 	}, nil
 }
 
-// Initialize sets up the FFmpeg encoder pipeline
+// Initialize sets up the FFmpeg encoder pipeline. Its errors are marked
+// failure.ErrEncoderInit.
 func (e *Encoder) Initialize() (err error) {
 	var ret int
 
-	// Suppress FFmpeg log output so it does not corrupt the TUI.
-	ffmpeg.AVLogSetLevel(ffmpeg.AVLogQuiet)
+	ffmpegutil.Init()
 
 	// Persistent worker pool for per-frame RGB→YUV conversion. The row
 	// partition never changes, so reuse long-lived workers across all frames.
 	// Stop the workers if a later setup step fails, since the caller only
 	// defers Close once Initialize returns successfully.
-	e.rowPool = yuv.NewRowPool(e.config.Height)
+	if !e.config.AudioOnly {
+		e.rowPool = yuv.NewRowPool(e.config.Height)
+	}
 	defer func() {
 		if err != nil && e.rowPool != nil {
 			e.rowPool.Close()
 			e.rowPool = nil
 		}
+		err = failure.Mark(failure.ErrEncoderInit, err)
 	}()
 
-	outputPath := ffmpeg.ToCStr(e.config.OutputPath)
-	defer outputPath.Free()
+	url, format := e.config.outputTarget()
+	var cs ffmpegutil.CStrings
+	defer cs.Free()
+	outputPath := cs.New(url)
+	ret, err = ffmpeg.AVFormatAllocOutputContext2(&e.formatCtx, nil, cs.OrNil(format), outputPath)
+	if err := ffmpegutil.Check(ret, err, "allocate output context"); err != nil {
+		return err
+	}
+
+	if !e.config.AudioOnly {
+		if err := e.initializeVideoEncoder(); err != nil {
+			return err
+		}
+	}
+
+	// Segmenting muxers (HLS, DASH) open their own playlist and segment files.
+	if e.formatCtx.Oformat().Flags()&ffmpeg.AVFmtNofile == 0 {
+		var pb *ffmpeg.AVIOContext
+		ret, err = ffmpeg.AVIOOpen(&pb, outputPath, ffmpeg.AVIOFlagWrite)
+		if err := ffmpegutil.Check(ret, err, "open output file"); err != nil {
+			return err
+		}
+		e.formatCtx.SetPb(pb)
+	}
+
+	if e.config.SampleRate > 0 {
+		if err := e.initializeAudioEncoder(); err != nil {
+			return fmt.Errorf("failed to initialize audio encoder: %w", err)
+		}
+	}
+
+	if err := e.addAttachments(); err != nil {
+		return err
+	}
+
+	if err := e.addChapters(); err != nil {
+		return fmt.Errorf("failed to add chapters: %w", err)
+	}
+
+	if err := e.addMetadata(); err != nil {
+		return err
+	}
+
+	var headerOpts *ffmpeg.AVDictionary
+	defer ffmpeg.AVDictFree(&headerOpts)
+	e.setMuxerOptions(&headerOpts, url == stdoutURL)
+	e.trailerMetadata = e.formatCtx.Oformat().Name().String() == "mp4" && url != stdoutURL
 
-	ret, err = ffmpeg.AVFormatAllocOutputContext2(&e.formatCtx, nil, nil, outputPath)
-	if err := checkFFmpeg(ret, err, "allocate output context"); err != nil {
+	ret, err = ffmpeg.AVFormatWriteHeader(e.formatCtx, &headerOpts)
+	if err := ffmpegutil.Check(ret, err, "write header"); err != nil {
 		return err
 	}
 
+	return nil
+}
+
+// initializeVideoEncoder selects the video encoder, hardware first unless
+// configured otherwise, and adds its stream to the output.
+func (e *Encoder) initializeVideoEncoder() error {
+	var ret int
+	var err error
+
 	// Select encoder based on hardware acceleration preference
 	hwAccelType := e.config.HWAccel
 	if hwAccelType == "" {
 		hwAccelType = HWAccelAuto // Default to auto-detection
 	}
 
-	e.hwEncoder = SelectBestEncoder(hwAccelType)
+	videoCodec := e.config.VideoCodec.orDefault()
+	if videoCodec == CodecAV1 && e.formatCtx.Oformat().Name().String() == "mpegts" {
+		return fmt.Errorf("AV1 cannot be muxed into MPEG-TS; use mp4, hls or dash")
+	}
+
+	e.hwEncoder = SelectBestEncoder(hwAccelType, videoCodec, e.config.HWDevice)
+	if e.hwEncoder == nil && videoCodec == CodecAV1 {
+		return failure.Mark(failure.ErrHWUnavailable, fmt.Errorf("no AV1 hardware encoder available (AV1 needs NVENC, QSV, VA-API or AMF)"))
+	}
 
 	var codec *ffmpeg.AVCodec
 	if e.hwEncoder != nil {
 		// Use hardware encoder
-		encoderName := ffmpeg.ToCStr(e.hwEncoder.Name)
-		codec = ffmpeg.AVCodecFindEncoderByName(encoderName)
-		encoderName.Free()
+		codec = ffmpegutil.FindEncoder(e.hwEncoder.Name)
 		if codec == nil {
 			return fmt.Errorf("hardware encoder %s not found", e.hwEncoder.Name)
 		}
 
-		// Create hardware device context
-		// For QSV on Linux with multiple GPUs, try common Intel render nodes
+		// Create hardware device context on the configured device. Without
+		// one, QSV on Linux with multiple GPUs tries common Intel render
+		// nodes before the default. AMF and Media Foundation open their own.
+		devices := []string{e.config.HWDevice}
+		if e.config.HWDevice == "" && e.hwEncoder.Type == HWAccelQSV && runtime.GOOS == "linux" {
+			devices = []string{"/dev/dri/renderD128", "/dev/dri/renderD129", ""}
+		}
 		var deviceCreated bool
-		if e.hwEncoder.Type == HWAccelQSV {
-			// Try specific Intel GPU render nodes first
-			for _, device := range []string{"/dev/dri/renderD128", "/dev/dri/renderD129", ""} {
-				var deviceCStr *ffmpeg.CStr
-				if device != "" {
-					deviceCStr = ffmpeg.ToCStr(device)
-				}
-				ret, err = ffmpeg.AVHWDeviceCtxCreate(&e.hwDeviceCtx, e.hwEncoder.DeviceType, deviceCStr, nil, 0)
-				if deviceCStr != nil {
-					deviceCStr.Free()
-				}
-				if err == nil && ret >= 0 {
-					deviceCreated = true
-					break
-				}
+		if e.hwEncoder.Type.opensOwnDevice() {
+			devices, deviceCreated = nil, true
+		}
+		for _, device := range devices {
+			ret, err = createHWDevice(&e.hwDeviceCtx, e.hwEncoder.DeviceType, device)
+			if err == nil && ret >= 0 {
+				deviceCreated = true
+				break
 			}
-		} else {
-			ret, err = ffmpeg.AVHWDeviceCtxCreate(&e.hwDeviceCtx, e.hwEncoder.DeviceType, nil, nil, 0)
-			deviceCreated = (err == nil && ret >= 0)
 		}
 
+		if !deviceCreated && videoCodec == CodecAV1 {
+			return fmt.Errorf("failed to create %s device, and AV1 has no software encoder", e.hwEncoder.Name)
+		}
 		if !deviceCreated {
 			// Fall back to software if hardware init fails
 			e.hwEncoder = nil
@@ -327,6 +487,7 @@ func (e *Encoder) Initialize() (err error) {
 			return fmt.Errorf("H.264 encoder not found")
 		}
 	}
+	e.resolveColour()
 
 	e.videoStream = ffmpeg.AVFormatNewStream(e.formatCtx, nil)
 	if e.videoStream == nil {
@@ -334,146 +495,269 @@ func (e *Encoder) Initialize() (err error) {
 	}
 	e.videoStream.SetId(0)
 
-	e.videoCodec = ffmpeg.AVCodecAllocContext3(codec)
-	if e.videoCodec == nil {
-		return fmt.Errorf("failed to allocate codec context")
+	if err := e.openVideoCodec(codec); err != nil {
+		return err
 	}
+	e.videoStream.SetTimeBase(ffmpeg.AVMakeQ(1, e.config.Framerate))
 
-	e.videoCodec.SetWidth(e.config.Width)
-	e.videoCodec.SetHeight(e.config.Height)
-
-	if err := e.configurePixelFormat(); err != nil {
+	ret, err = ffmpeg.AVCodecParametersFromContext(e.videoStream.Codecpar(), e.videoCodec)
+	if err := ffmpegutil.Check(ret, err, "copy codec parameters"); err != nil {
 		return err
 	}
+	return nil
+}
 
-	timeBase := ffmpeg.AVMakeQ(1, e.config.Framerate)
-	e.videoCodec.SetTimeBase(timeBase)
-
-	framerate := ffmpeg.AVMakeQ(e.config.Framerate, 1)
-	e.videoCodec.SetFramerate(framerate)
-
-	e.videoCodec.SetGopSize(e.config.Framerate * 2) // Keyframe every 2 seconds
-
-	e.videoStream.SetTimeBase(timeBase)
-
-	var opts *ffmpeg.AVDictionary
-	defer ffmpeg.AVDictFree(&opts)
-
-	if e.hwEncoder != nil {
-		// Hardware encoder options
-		e.setHWEncoderOptions(&opts)
-	} else {
-		// Software encoder (x264) options optimized for visualization content
-		// CRF 24 = good quality for busy visualizations
-		_, _ = ffmpeg.AVDictSet(&opts, ffmpeg.ToCStr("crf"), ffmpeg.ToCStr("24"), 0)
-		// Faster preset prioritizes encoding speed
-		_, _ = ffmpeg.AVDictSet(&opts, ffmpeg.ToCStr("preset"), ffmpeg.ToCStr("veryfast"), 0)
-		// Tune for animation content
-		_, _ = ffmpeg.AVDictSet(&opts, ffmpeg.ToCStr("tune"), ffmpeg.ToCStr("animation"), 0)
-		// Main profile for faster encoding and broad compatibility
-		_, _ = ffmpeg.AVDictSet(&opts, ffmpeg.ToCStr("profile"), ffmpeg.ToCStr("main"), 0)
+// setSoftwareEncoderOptions configures libx264 options optimised for
+// visualisation content at the profile's rate control.
+func setSoftwareEncoderOptions(opts **ffmpeg.AVDictionary, rc rateControl) {
+	// CRF sets the quality (24 = good quality for busy visualisations)
+	_ = ffmpegutil.DictSet(opts, "crf", strconv.Itoa(rc.quality))
+	// Preset trades encoding speed for compression
+	_ = ffmpegutil.DictSet(opts, "preset", rc.x264Preset)
+	// Tune for animation content
+	_ = ffmpegutil.DictSet(opts, "tune", "animation")
+	// Main profile for faster encoding and broad compatibility; High for upload targets
+	_ = ffmpegutil.DictSet(opts, "profile", rc.h264Profile)
+	if rc.speedTweaks {
 		// Single reference frame (simple vertical bar motion doesn't need multiple refs)
-		_, _ = ffmpeg.AVDictSet(&opts, ffmpeg.ToCStr("ref"), ffmpeg.ToCStr("1"), 0)
+		_ = ffmpegutil.DictSet(opts, "ref", "1")
 		// Reduce b-frames for faster encoding (predictable bar motion)
-		_, _ = ffmpeg.AVDictSet(&opts, ffmpeg.ToCStr("bf"), ffmpeg.ToCStr("1"), 0)
+		_ = ffmpegutil.DictSet(opts, "bf", "1")
 		// Simpler subpixel motion estimation (bars move in discrete pixels)
-		_, _ = ffmpeg.AVDictSet(&opts, ffmpeg.ToCStr("subme"), ffmpeg.ToCStr("4"), 0)
-	}
-
-	ret, err = ffmpeg.AVCodecOpen2(e.videoCodec, codec, &opts)
-	if err := checkFFmpeg(ret, err, "open codec"); err != nil {
-		return err
+		_ = ffmpegutil.DictSet(opts, "subme", "4")
 	}
+	// Capped CRF: quality-driven, but never above the VBV peak
+	setRateCap(opts, rc)
+}
 
-	ret, err = ffmpeg.AVCodecParametersFromContext(e.videoStream.Codecpar(), e.videoCodec)
-	if err := checkFFmpeg(ret, err, "copy codec parameters"); err != nil {
-		return err
-	}
-
-	var pb *ffmpeg.AVIOContext
-	ret, err = ffmpeg.AVIOOpen(&pb, outputPath, ffmpeg.AVIOFlagWrite)
-	if err := checkFFmpeg(ret, err, "open output file"); err != nil {
-		return err
-	}
-	e.formatCtx.SetPb(pb)
-
-	if e.config.SampleRate > 0 {
-		if err := e.initializeAudioEncoder(); err != nil {
-			return fmt.Errorf("failed to initialize audio encoder: %w", err)
-		}
+// setRateCap sets the VBV peak bitrate and buffer for a capped profile.
+func setRateCap(opts **ffmpeg.AVDictionary, rc rateControl) {
+	if !rc.capped() {
+		return
 	}
+	_ = ffmpegutil.DictSet(opts, "maxrate", strconv.FormatInt(rc.maxRate, 10))
+	_ = ffmpegutil.DictSet(opts, "bufsize", strconv.FormatInt(rc.bufSize, 10))
+}
 
-	ret, err = ffmpeg.AVFormatWriteHeader(e.formatCtx, nil)
-	if err := checkFFmpeg(ret, err, "write header"); err != nil {
-		return err
+// setBitRate sets the average bitrate target, when the profile has one.
+func setBitRate(opts **ffmpeg.AVDictionary, rc rateControl) {
+	if rc.bitRate <= 0 {
+		return
 	}
-
-	return nil
+	_ = ffmpegutil.DictSet(opts, "b", strconv.FormatInt(rc.bitRate, 10))
 }
 
 // setHWEncoderOptions configures encoder-specific options for hardware encoders
-func (e *Encoder) setHWEncoderOptions(opts **ffmpeg.AVDictionary) {
+// at the profile's rate control.
+func (e *Encoder) setHWEncoderOptions(opts **ffmpeg.AVDictionary, rc rateControl) {
 	if e.hwEncoder == nil {
 		return
 	}
 
+	if e.hwEncoder.Codec == CodecAV1 {
+		e.setAV1EncoderOptions(opts, rc)
+		return
+	}
+
+	quality := strconv.Itoa(rc.quality)
+
 	switch e.hwEncoder.Type {
 	case HWAccelNVENC:
 		// NVENC options optimized for fast visualisation encoding
 		// Preset p1 = fastest encoding (scale runs p1=fastest to p7=slowest)
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("preset"), ffmpeg.ToCStr("p1"), 0)
-		// Low latency tuning - reduces pipeline delay
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("tune"), ffmpeg.ToCStr("ull"), 0)
-		// Target quality (CQ mode) - similar to CRF, lower=better (0-51)
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("rc"), ffmpeg.ToCStr("vbr"), 0)
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("cq"), ffmpeg.ToCStr("24"), 0)
-		// Main profile for broad compatibility
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("profile"), ffmpeg.ToCStr("main"), 0)
-		// No B-frames for faster encoding (visualisation has low motion)
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("bf"), ffmpeg.ToCStr("0"), 0)
-		// Zero latency mode - no reordering delay
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("zerolatency"), ffmpeg.ToCStr("1"), 0)
+		_ = ffmpegutil.DictSet(opts, "preset", rc.nvencPreset)
+		// Target quality (CQ mode) - similar to CRF, lower=better (0-51); VBR
+		// with a maxrate caps the peaks
+		_ = ffmpegutil.DictSet(opts, "rc", "vbr")
+		_ = ffmpegutil.DictSet(opts, "cq", quality)
+		setRateCap(opts, rc)
+		// Main profile for broad compatibility; High for upload targets
+		_ = ffmpegutil.DictSet(opts, "profile", rc.h264Profile)
+		if rc.speedTweaks {
+			// Low latency tuning - reduces pipeline delay
+			_ = ffmpegutil.DictSet(opts, "tune", "ull")
+			// No B-frames for faster encoding (visualisation has low motion)
+			_ = ffmpegutil.DictSet(opts, "bf", "0")
+			// Zero latency mode - no reordering delay
+			_ = ffmpegutil.DictSet(opts, "zerolatency", "1")
+		} else {
+			_ = ffmpegutil.DictSet(opts, "tune", "hq")
+		}
 
 	case HWAccelQSV:
 		// Intel Quick Sync Video options
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("preset"), ffmpeg.ToCStr("medium"), 0)
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("global_quality"), ffmpeg.ToCStr("24"), 0)
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("profile"), ffmpeg.ToCStr("main"), 0)
+		_ = ffmpegutil.DictSet(opts, "preset", "medium")
+		if rc.capped() {
+			// QSV ignores maxrate in ICQ mode, so capped profiles use VBR
+			setBitRate(opts, rc)
+			setRateCap(opts, rc)
+		} else {
+			_ = ffmpegutil.DictSet(opts, "global_quality", quality)
+		}
+		_ = ffmpegutil.DictSet(opts, "profile", rc.h264Profile)
 
 	case HWAccelVulkan:
 		// Vulkan Video options optimized for fast visualisation encoding
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("content"), ffmpeg.ToCStr("rendered"), 0)
-		// Quality level (0-51, lower=better) - same as NVENC CQ
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("qp"), ffmpeg.ToCStr("24"), 0)
-		// Low latency tuning - reduces pipeline delay
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("tune"), ffmpeg.ToCStr("ull"), 0)
-		// Increase async depth for more parallelism (default=2)
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("async_depth"), ffmpeg.ToCStr("4"), 0)
-		// Main profile for broad compatibility
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("profile"), ffmpeg.ToCStr("main"), 0)
-		// Minimal B-frame depth (1 is minimum)
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("b_depth"), ffmpeg.ToCStr("1"), 0)
+		_ = ffmpegutil.DictSet(opts, "content", "rendered")
+		if rc.capped() {
+			// Capped profiles use VBR around the average bitrate
+			_ = ffmpegutil.DictSet(opts, "rc_mode", "vbr")
+			setBitRate(opts, rc)
+			setRateCap(opts, rc)
+		} else {
+			// Quality level (0-51, lower=better) - same as NVENC CQ
+			_ = ffmpegutil.DictSet(opts, "qp", quality)
+		}
+		if rc.speedTweaks {
+			// Low latency tuning - reduces pipeline delay
+			_ = ffmpegutil.DictSet(opts, "tune", "ull")
+			// Increase async depth for more parallelism (default=2)
+			_ = ffmpegutil.DictSet(opts, "async_depth", "4")
+			// Minimal B-frame depth (1 is minimum)
+			_ = ffmpegutil.DictSet(opts, "b_depth", "1")
+		}
+		// Main profile for broad compatibility; High for upload targets
+		_ = ffmpegutil.DictSet(opts, "profile", rc.h264Profile)
 
 	case HWAccelVAAPI:
 		// VA-API options optimized for fast visualisation encoding
-		// Quality level (1-51, lower=better) - CQP rate control
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("qp"), ffmpeg.ToCStr("24"), 0)
-		// Main profile for broad compatibility
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("profile"), ffmpeg.ToCStr("main"), 0)
-		// Low latency: disable B-frames for faster encoding
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("bf"), ffmpeg.ToCStr("0"), 0)
+		if rc.capped() {
+			// Capped profiles use VBR around the average bitrate
+			_ = ffmpegutil.DictSet(opts, "rc_mode", "VBR")
+			setBitRate(opts, rc)
+			setRateCap(opts, rc)
+		} else {
+			// Quality level (1-51, lower=better) - CQP rate control
+			_ = ffmpegutil.DictSet(opts, "qp", quality)
+		}
+		// Main profile for broad compatibility; High for upload targets
+		_ = ffmpegutil.DictSet(opts, "profile", rc.h264Profile)
+		if rc.speedTweaks {
+			// Low latency: disable B-frames for faster encoding
+			_ = ffmpegutil.DictSet(opts, "bf", "0")
+		}
 
 	case HWAccelVideoToolbox:
 		// Apple VideoToolbox options optimised for fast visualisation encoding
 		// Note: VideoToolbox does not support constant quality (CRF/CQ) encoding.
-		// It uses bitrate-based rate control only, so we cannot set quality levels
-		// like other hardware encoders. The encoder will use default VBR settings.
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("profile"), ffmpeg.ToCStr("main"), 0)
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("level"), ffmpeg.ToCStr("4.1"), 0)
+		// It uses bitrate-based rate control only, so profiles set an average
+		// bitrate (and cap) instead; the fast profile keeps the default VBR.
+		setBitRate(opts, rc)
+		setRateCap(opts, rc)
+		_ = ffmpegutil.DictSet(opts, "profile", rc.h264Profile)
+		_ = ffmpegutil.DictSet(opts, "level", "4.1")
 		// Real-time encoding hint - prioritises speed for live/visualisation use
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("realtime"), ffmpeg.ToCStr("1"), 0)
+		if rc.speedTweaks {
+			_ = ffmpegutil.DictSet(opts, "realtime", "1")
+		}
 		// Require hardware encoding - fail if hardware unavailable
-		_, _ = ffmpeg.AVDictSet(opts, ffmpeg.ToCStr("allow_sw"), ffmpeg.ToCStr("0"), 0)
+		_ = ffmpegutil.DictSet(opts, "allow_sw", "0")
+
+	case HWAccelAMF:
+		// AMD AMF options: the speed preset for the fast profile, quality otherwise
+		if rc.speedTweaks {
+			_ = ffmpegutil.DictSet(opts, "usage", "lowlatency")
+			_ = ffmpegutil.DictSet(opts, "quality", "speed")
+			_ = ffmpegutil.DictSet(opts, "bf", "0")
+		} else {
+			_ = ffmpegutil.DictSet(opts, "usage", "transcoding")
+			_ = ffmpegutil.DictSet(opts, "quality", "quality")
+		}
+		if rc.capped() {
+			// Peak-constrained VBR around the average bitrate
+			_ = ffmpegutil.DictSet(opts, "rc", "vbr_peak")
+			setBitRate(opts, rc)
+			setRateCap(opts, rc)
+		} else {
+			// Constant QP (0-51, lower=better) on I and P frames alike
+			_ = ffmpegutil.DictSet(opts, "rc", "cqp")
+			_ = ffmpegutil.DictSet(opts, "qp_i", quality)
+			_ = ffmpegutil.DictSet(opts, "qp_p", quality)
+		}
+		_ = ffmpegutil.DictSet(opts, "profile", rc.h264Profile)
+
+	case HWAccelMF:
+		// Media Foundation has a 0-100 quality mode (higher=better) and
+		// peak-constrained VBR, but no profile names
+		if rc.capped() {
+			_ = ffmpegutil.DictSet(opts, "rate_control", "pc_vbr")
+			setBitRate(opts, rc)
+			setRateCap(opts, rc)
+		} else {
+			_ = ffmpegutil.DictSet(opts, "rate_control", "quality")
+			_ = ffmpegutil.DictSet(opts, "quality", strconv.Itoa(mfQuality(rc.quality)))
+		}
+		if rc.speedTweaks {
+			_ = ffmpegutil.DictSet(opts, "scenario", "live_streaming")
+		}
+		// Require the vendor's hardware MFT rather than Microsoft's software one
+		_ = ffmpegutil.DictSet(opts, "hw_encoding", "1")
+	}
+}
+
+// setAV1EncoderOptions configures the AV1 hardware encoders at the profile's
+// rate control. The profile's quality is on H.264's scale and is mapped onto
+// each encoder's AV1 one; all three use the Main profile (8-bit 4:2:0).
+func (e *Encoder) setAV1EncoderOptions(opts **ffmpeg.AVDictionary, rc rateControl) {
+	switch e.hwEncoder.Type {
+	case HWAccelNVENC:
+		// Same presets and VBR constant quality as H.264, with cq on AV1's 0-63 scale
+		_ = ffmpegutil.DictSet(opts, "preset", rc.nvencPreset)
+		_ = ffmpegutil.DictSet(opts, "rc", "vbr")
+		_ = ffmpegutil.DictSet(opts, "cq", strconv.Itoa(av1Quality(rc.quality)))
+		setRateCap(opts, rc)
+		_ = ffmpegutil.DictSet(opts, "profile", "main")
+		if rc.speedTweaks {
+			_ = ffmpegutil.DictSet(opts, "tune", "ull")
+			_ = ffmpegutil.DictSet(opts, "bf", "0")
+			_ = ffmpegutil.DictSet(opts, "zerolatency", "1")
+		} else {
+			_ = ffmpegutil.DictSet(opts, "tune", "hq")
+		}
+
+	case HWAccelQSV:
+		// ICQ quality runs 1-51 whatever the codec, so it takes the profile's as is
+		_ = ffmpegutil.DictSet(opts, "preset", "medium")
+		if rc.capped() {
+			setBitRate(opts, rc)
+			setRateCap(opts, rc)
+		} else {
+			_ = ffmpegutil.DictSet(opts, "global_quality", strconv.Itoa(rc.quality))
+		}
+		_ = ffmpegutil.DictSet(opts, "profile", "main")
+
+	case HWAccelVAAPI:
+		if rc.capped() {
+			_ = ffmpegutil.DictSet(opts, "rc_mode", "VBR")
+			setBitRate(opts, rc)
+			setRateCap(opts, rc)
+		} else {
+			// CQP on AV1's quantiser index (0-255, lower=better)
+			_ = ffmpegutil.DictSet(opts, "qp", strconv.Itoa(av1QIndex(rc.quality)))
+		}
+		_ = ffmpegutil.DictSet(opts, "profile", "main")
+		if rc.speedTweaks {
+			_ = ffmpegutil.DictSet(opts, "bf", "0")
+		}
+
+	case HWAccelAMF:
+		// Same presets as H.264, with constant QP on AV1's 0-255 quantiser index
+		quality := "quality"
+		if rc.speedTweaks {
+			quality = "speed"
+		}
+		_ = ffmpegutil.DictSet(opts, "quality", quality)
+		if rc.capped() {
+			_ = ffmpegutil.DictSet(opts, "rc", "vbr_peak")
+			setBitRate(opts, rc)
+			setRateCap(opts, rc)
+		} else {
+			qIndex := strconv.Itoa(av1QIndex(rc.quality))
+			_ = ffmpegutil.DictSet(opts, "rc", "cqp")
+			_ = ffmpegutil.DictSet(opts, "qp_i", qIndex)
+			_ = ffmpegutil.DictSet(opts, "qp_p", qIndex)
+		}
+		_ = ffmpegutil.DictSet(opts, "profile", "main")
 	}
 }
 
@@ -508,14 +792,19 @@ func (e *Encoder) setupHWFramesContext(hwPixFmt ffmpeg.AVPixelFormat) error {
 
 	// Initialize the frames context
 	ret, err := ffmpeg.AVHWFrameCtxInit(hwFramesRef)
-	if err := checkFFmpeg(ret, err, "initialize hardware frames context"); err != nil {
+	if err := ffmpegutil.Check(ret, err, "initialize hardware frames context"); err != nil {
 		return err
 	}
 
 	// Attach frames context to the video encoder
 	e.videoCodec.SetHwFramesCtx(ffmpeg.AVBufferRef_(hwFramesRef))
 
-	// Pre-allocate reusable NV12 frame for parallel Go RGBA→NV12 conversion
+	return e.allocNV12Frame()
+}
+
+// allocNV12Frame pre-allocates the reusable NV12 frame for parallel Go
+// RGBA→NV12 conversion.
+func (e *Encoder) allocNV12Frame() error {
 	e.hwNV12Frame = ffmpeg.AVFrameAlloc()
 	if e.hwNV12Frame == nil {
 		return fmt.Errorf("failed to allocate reusable NV12 frame")
@@ -524,23 +813,74 @@ func (e *Encoder) setupHWFramesContext(hwPixFmt ffmpeg.AVPixelFormat) error {
 	e.hwNV12Frame.SetHeight(e.config.Height)
 	e.hwNV12Frame.SetFormat(int(ffmpeg.AVPixFmtNv12))
 
-	ret, err = ffmpeg.AVFrameGetBuffer(e.hwNV12Frame, 0)
-	if err := checkFFmpeg(ret, err, "allocate NV12 buffer"); err != nil {
-		return err
+	ret, err := ffmpeg.AVFrameGetBuffer(e.hwNV12Frame, 0)
+	return ffmpegutil.Check(ret, err, "allocate NV12 buffer")
+}
+
+// resolveColour settles an unset colour space once the encoder is chosen.
+// NVENC converts RGBA on the GPU, but only with the BT.601 limited-range
+// matrix; anything else sends it frames through the Go NV12 converter, which
+// costs the CPU a conversion per frame. So NVENC defaults to BT.601 when the
+// range allows it and every other encoder to BT.709. The choice is kept in
+// the config, so a fallback to libx264 mid-run carries on with the same
+// matrix and tags.
+func (e *Encoder) resolveColour() {
+	if e.config.ColorSpace != "" {
+		return
+	}
+	_, cr := e.config.colour()
+	if e.hwEncoder != nil && e.hwEncoder.Type == HWAccelNVENC && cr == yuv.RangeLimited {
+		e.config.ColorSpace = yuv.BT601
+	} else {
+		e.config.ColorSpace = yuv.BT709
 	}
+	e.matrix = yuv.NewMatrix(e.config.colour())
+}
 
-	return nil
+// nvencConvertsRGBA reports whether NVENC's own RGBA conversion matches the
+// configured colour. NVENC converts RGB input with the BT.601 limited-range
+// matrix and tags the stream to suit, whatever the codec context says, so any
+// other choice needs the Go converter in front of it.
+func (e *Encoder) nvencConvertsRGBA() bool {
+	cs, cr := e.config.colour()
+	return cs == yuv.BT601 && cr == yuv.RangeLimited
+}
+
+// setColourTags labels the stream with the matrix, primaries, transfer and
+// range the frames were converted with, so players decode the colours as
+// rendered. Frames are rendered in sRGB, which shares BT.709's primaries.
+func (e *Encoder) setColourTags() {
+	cs, cr := e.config.colour()
+	if cs == yuv.BT601 {
+		e.videoCodec.SetColorspace(ffmpeg.AVColSpcSmpte170M)
+		e.videoCodec.SetColorPrimaries(ffmpeg.AVColPriSmpte170M)
+		e.videoCodec.SetColorTrc(ffmpeg.AVColTrcSmpte170M)
+	} else {
+		e.videoCodec.SetColorspace(ffmpeg.AVColSpcBt709)
+		e.videoCodec.SetColorPrimaries(ffmpeg.AVColPriBt709)
+		e.videoCodec.SetColorTrc(ffmpeg.AVColTrcBt709)
+	}
+	if cr == yuv.RangeFull {
+		e.videoCodec.SetColorRange(ffmpeg.AVColRangeJpeg)
+	} else {
+		e.videoCodec.SetColorRange(ffmpeg.AVColRangeMpeg)
+	}
 }
 
 // configurePixelFormat sets up pixel formats and hardware context based on encoder type.
-// NVENC: accepts RGBA directly, GPU does colourspace conversion
+// NVENC: accepts RGBA directly, GPU does colourspace conversion (BT.601 limited
+// only); other colour settings send NV12 from the Go converter instead
 // Vulkan/QSV/VA-API: require NV12 uploaded to GPU via hardware frames context
+// AMF/Media Foundation: take NV12 from system memory, like NVENC's NV12 path
 // Software: uses YUV420P with CPU-side RGB→YUV conversion
 func (e *Encoder) configurePixelFormat() error {
-	// Pre-allocate reusable packet for the video receive loop
-	e.pkt = ffmpeg.AVPacketAlloc()
+	// Pre-allocate reusable packet for the video receive loop; a software
+	// fallback keeps the packet from the original encoder.
 	if e.pkt == nil {
-		return fmt.Errorf("failed to allocate reusable packet")
+		e.pkt = ffmpeg.AVPacketAlloc()
+		if e.pkt == nil {
+			return fmt.Errorf("failed to allocate reusable packet")
+		}
 	}
 
 	if e.hwEncoder == nil {
@@ -558,7 +898,7 @@ func (e *Encoder) configurePixelFormat() error {
 		e.swYUVFrame.SetFormat(int(ffmpeg.AVPixFmtYuv420P))
 
 		ret, err := ffmpeg.AVFrameGetBuffer(e.swYUVFrame, 0)
-		if err := checkFFmpeg(ret, err, "allocate YUV buffer"); err != nil {
+		if err := ffmpegutil.Check(ret, err, "allocate YUV buffer"); err != nil {
 			return err
 		}
 		return nil
@@ -566,6 +906,14 @@ func (e *Encoder) configurePixelFormat() error {
 
 	switch e.hwEncoder.Type {
 	case HWAccelNVENC:
+		if !e.nvencConvertsRGBA() {
+			// NVENC reads NV12 from system memory, so no frames context is needed
+			e.inputPixFmt = ffmpeg.AVPixFmtNv12
+			e.videoCodec.SetPixFmt(ffmpeg.AVPixFmtNv12)
+			e.videoCodec.SetHwDeviceCtx(ffmpeg.AVBufferRef_(e.hwDeviceCtx))
+			return e.allocNV12Frame()
+		}
+
 		// NVENC can accept RGBA directly - GPU handles colourspace conversion
 		e.inputPixFmt = ffmpeg.AVPixFmtRgba
 		e.videoCodec.SetPixFmt(ffmpeg.AVPixFmtRgba)
@@ -581,7 +929,7 @@ func (e *Encoder) configurePixelFormat() error {
 		e.rgbaFrame.SetFormat(int(ffmpeg.AVPixFmtRgba))
 
 		ret, err := ffmpeg.AVFrameGetBuffer(e.rgbaFrame, 0)
-		if err := checkFFmpeg(ret, err, "allocate RGBA buffer"); err != nil {
+		if err := ffmpegutil.Check(ret, err, "allocate RGBA buffer"); err != nil {
 			return err
 		}
 
@@ -617,6 +965,12 @@ func (e *Encoder) configurePixelFormat() error {
 			return fmt.Errorf("failed to setup VideoToolbox frames context: %w", err)
 		}
 
+	case HWAccelAMF, HWAccelMF:
+		// AMF and Media Foundation upload NV12 from system memory themselves
+		e.inputPixFmt = ffmpeg.AVPixFmtNv12
+		e.videoCodec.SetPixFmt(ffmpeg.AVPixFmtNv12)
+		return e.allocNV12Frame()
+
 	default:
 		return fmt.Errorf("unsupported hardware encoder type: %s", e.hwEncoder.Type)
 	}
@@ -624,6 +978,64 @@ func (e *Encoder) configurePixelFormat() error {
 	return nil
 }
 
+// openVideoCodec allocates, configures and opens the video codec context for
+// codec, using hardware options when e.hwEncoder is set and libx264 options
+// otherwise.
+func (e *Encoder) openVideoCodec(codec *ffmpeg.AVCodec) error {
+	e.videoCodec = ffmpeg.AVCodecAllocContext3(codec)
+	if e.videoCodec == nil {
+		return fmt.Errorf("failed to allocate codec context")
+	}
+
+	e.videoCodec.SetWidth(e.config.Width)
+	e.videoCodec.SetHeight(e.config.Height)
+
+	if err := e.configurePixelFormat(); err != nil {
+		return err
+	}
+
+	timeBase := ffmpeg.AVMakeQ(1, e.config.Framerate)
+	e.videoCodec.SetTimeBase(timeBase)
+
+	framerate := ffmpeg.AVMakeQ(e.config.Framerate, 1)
+	e.videoCodec.SetFramerate(framerate)
+
+	e.videoCodec.SetGopSize(e.config.gopSize())
+	e.setColourTags()
+
+	var opts *ffmpeg.AVDictionary
+	defer ffmpeg.AVDictFree(&opts)
+
+	rc := rateControlFor(e.config.Profile)
+	if e.hwEncoder != nil {
+		// Hardware encoder options
+		e.setHWEncoderOptions(&opts, rc)
+	} else {
+		setSoftwareEncoderOptions(&opts, rc)
+	}
+	if err := e.setBFrameOptions(&opts); err != nil {
+		return err
+	}
+	e.setKeyframeOptions(&opts)
+	e.setUserOptions(&opts)
+	if e.fallbackErr != nil {
+		// A mid-run libx264 fallback must not reorder (see fallbackToSoftware)
+		_ = ffmpegutil.DictSet(&opts, "bf", "0")
+	}
+
+	ret, err := ffmpeg.AVCodecOpen2(e.videoCodec, codec, &opts)
+	if err := ffmpegutil.Check(ret, err, "open codec"); err != nil {
+		return err
+	}
+	e.recordUnusedOptions(opts)
+	return nil
+}
+
+// VideoFrames returns how many video frames the encoder has accepted.
+func (e *Encoder) VideoFrames() int64 {
+	return e.nextVideoPts
+}
+
 // EncoderName returns the name of the video encoder being used
 func (e *Encoder) EncoderName() string {
 	if e.hwEncoder != nil {
@@ -637,6 +1049,37 @@ func (e *Encoder) IsHardware() bool {
 	return e.hwEncoder != nil
 }
 
+// setMuxerOptions configures the resolved muxer: fragmented MP4 when
+// streaming, and complete VOD playlists of fixed-length segments for HLS
+// (.ts segments, or .m4s for AV1) and DASH (.m4s segments).
+func (e *Encoder) setMuxerOptions(opts **ffmpeg.AVDictionary, streaming bool) {
+	segmentLength := strconv.Itoa(e.segmentLength())
+
+	switch e.formatCtx.Oformat().Name().String() {
+	case "mp4":
+		if streaming {
+			_ = ffmpegutil.DictSet(opts, "movflags", fragmentedMovFlags)
+		}
+	case "hls":
+		_ = ffmpegutil.DictSet(opts, "hls_time", segmentLength)
+		if e.config.VideoCodec == CodecAV1 {
+			// MPEG-TS segments cannot carry AV1
+			_ = ffmpegutil.DictSet(opts, "hls_segment_type", "fmp4")
+		}
+		_ = ffmpegutil.DictSet(opts, "hls_playlist_type", "vod")
+	case "dash":
+		_ = ffmpegutil.DictSet(opts, "seg_duration", segmentLength)
+	}
+}
+
+// segmentLength returns the configured HLS/DASH segment length in seconds.
+func (e *Encoder) segmentLength() int {
+	if e.config.SegmentLength <= 0 {
+		return defaultSegmentLength
+	}
+	return e.config.SegmentLength
+}
+
 // outputChannels returns the configured audio channel count, defaulting to mono.
 func (e *Encoder) outputChannels() int {
 	if e.config.AudioChannels == 0 {
@@ -645,13 +1088,31 @@ func (e *Encoder) outputChannels() int {
 	return e.config.AudioChannels
 }
 
-// initializeAudioEncoder sets up the AAC encoder for direct sample input.
-// Samples are provided via WriteAudioSamples().
+// initializeAudioEncoder sets up the AAC or Opus encoder for direct sample
+// input. Samples are provided via WriteAudioSamples().
 // Requires SampleRate to be set in Config.
 func (e *Encoder) initializeAudioEncoder() error {
-	audioEncoder := ffmpeg.AVCodecFindEncoder(ffmpeg.AVCodecIdAac)
+	audioCodec := e.config.AudioCodec.orDefault()
+	muxer := e.formatCtx.Oformat().Name().String()
+	var audioEncoder *ffmpeg.AVCodec
+	switch audioCodec {
+	case AudioOpus:
+		if muxer != "matroska" && muxer != "webm" {
+			return fmt.Errorf("an Opus audio track needs a Matroska or WebM output, not %s", muxer)
+		}
+		var cs ffmpegutil.CStrings
+		defer cs.Free()
+		audioEncoder = ffmpeg.AVCodecFindEncoderByName(cs.New("libopus"))
+	case AudioFLAC:
+		if muxer != "matroska" {
+			return fmt.Errorf("a FLAC audio track needs a Matroska output, not %s", muxer)
+		}
+		audioEncoder = ffmpeg.AVCodecFindEncoder(ffmpeg.AVCodecIdFlac)
+	default:
+		audioEncoder = ffmpeg.AVCodecFindEncoder(ffmpeg.AVCodecIdAac)
+	}
 	if audioEncoder == nil {
-		return fmt.Errorf("AAC encoder not found")
+		return fmt.Errorf("%s encoder not found", audioCodec.DisplayName())
 	}
 
 	e.audioStream = ffmpeg.AVFormatNewStream(e.formatCtx, nil)
@@ -659,29 +1120,62 @@ func (e *Encoder) initializeAudioEncoder() error {
 		return fmt.Errorf("failed to create audio stream")
 	}
 	e.audioStream.SetId(1)
+	if e.config.AudioOnly {
+		e.audioStream.SetId(0)
+
+		// The video path allocates the shared packet otherwise.
+		e.pkt = ffmpeg.AVPacketAlloc()
+		if e.pkt == nil {
+			return fmt.Errorf("failed to allocate reusable packet")
+		}
+	}
 
 	e.audioCodec = ffmpeg.AVCodecAllocContext3(audioEncoder)
 	if e.audioCodec == nil {
 		return fmt.Errorf("failed to allocate audio encoder context")
 	}
 
-	// Configure AAC encoder using config sample rate
-	e.audioCodec.SetSampleFmt(ffmpeg.AVSampleFmtFltp) // AAC requires float planar
-	e.audioCodec.SetSampleRate(e.config.SampleRate)
+	// AAC requires float planar and runs at the source's rate; libopus takes
+	// interleaved float at 48 kHz. FFmpeg's FLAC encoder takes interleaved
+	// integers, here 24-bit in the top of each int32: the float samples
+	// carry 24 bits, so that is all of them.
+	sampleFmt := ffmpeg.AVSampleFmtFltp
+	switch audioCodec {
+	case AudioOpus:
+		sampleFmt = ffmpeg.AVSampleFmtFlt
+	case AudioFLAC:
+		sampleFmt = ffmpeg.AVSampleFmtS32
+		e.audioCodec.SetBitsPerRawSample(flacBits)
+	}
+	e.audioFormat = sampleFmt
+	e.audioCodec.SetSampleFmt(sampleFmt)
+	e.audioCodec.SetSampleRate(audioCodec.SampleRate(e.config.SampleRate))
 
 	outputChannels := e.outputChannels()
 	ffmpeg.AVChannelLayoutDefault(e.audioCodec.ChLayout(), outputChannels)
 
-	e.audioCodec.SetBitRate(192000) // 192 kbps
+	// 192 kbps AAC for mono and stereo; 5.1 gets about the same per channel
+	// pair. Opus is transparent at lower rates. FLAC, being lossless, takes
+	// what it needs and ignores the bitrate.
+	bitRate := int64(192000)
+	switch {
+	case audioCodec == AudioOpus && outputChannels > 2:
+		bitRate = 256000
+	case audioCodec == AudioOpus:
+		bitRate = 128000
+	case outputChannels > 2:
+		bitRate = 448000
+	}
+	e.audioCodec.SetBitRate(bitRate)
 	e.audioStream.SetTimeBase(ffmpeg.AVMakeQ(1, e.audioCodec.SampleRate()))
 
 	ret, err := ffmpeg.AVCodecOpen2(e.audioCodec, audioEncoder, nil)
-	if err := checkFFmpeg(ret, err, "open audio encoder"); err != nil {
+	if err := ffmpegutil.Check(ret, err, "open audio encoder"); err != nil {
 		return err
 	}
 
 	ret, err = ffmpeg.AVCodecParametersFromContext(e.audioStream.Codecpar(), e.audioCodec)
-	if err := checkFFmpeg(ret, err, "copy audio encoder parameters"); err != nil {
+	if err := ffmpegutil.Check(ret, err, "copy audio encoder parameters"); err != nil {
 		return err
 	}
 
@@ -691,20 +1185,28 @@ func (e *Encoder) initializeAudioEncoder() error {
 	}
 
 	// AVAudioFifo-backed FIFO (packed float32) bridges the FFT chunk size
-	// (2048) to the AAC encoder frame size (1024).
+	// (2048) to the encoder frame size: 1024 for AAC, for Opus 960, its
+	// 20ms frame at 48 kHz, and for FLAC 4608 at 44.1 and 48 kHz.
 	audioFIFO, err := newAVAudioFIFO(outputChannels, e.audioCodec.FrameSize())
 	if err != nil {
 		return err
 	}
 	e.audioFIFO = audioFIFO
 
+	if rate := e.audioCodec.SampleRate(); rate != e.config.SampleRate {
+		e.resampler, err = newAudioResampler(e.audioCodec.ChLayout(), e.config.SampleRate, rate)
+		if err != nil {
+			return err
+		}
+	}
+
 	e.audioEncFrame.SetNbSamples(e.audioCodec.FrameSize())
-	e.audioEncFrame.SetFormat(int(ffmpeg.AVSampleFmtFltp))
+	e.audioEncFrame.SetFormat(int(sampleFmt))
 	ffmpeg.AVChannelLayoutDefault(e.audioEncFrame.ChLayout(), outputChannels)
 	e.audioEncFrame.SetSampleRate(e.audioCodec.SampleRate())
 
 	ret, err = ffmpeg.AVFrameGetBuffer(e.audioEncFrame, 0)
-	if err := checkFFmpeg(ret, err, "allocate encoder frame buffer"); err != nil {
+	if err := ffmpegutil.Check(ret, err, "allocate encoder frame buffer"); err != nil {
 		return err
 	}
 
@@ -712,53 +1214,100 @@ func (e *Encoder) initializeAudioEncoder() error {
 }
 
 // WriteFrameRGBA encodes and writes a single RGBA frame
-// For NVENC: sends RGBA directly to GPU (colourspace conversion on GPU)
+// For NVENC: sends RGBA directly to GPU (colourspace conversion on GPU), or NV12 for non-BT.601-limited colour
 // For Vulkan: converts RGBA→NV12 on CPU, uploads to GPU via hwframe
 // For software: converts to RGB24→YUV420P on CPU then encodes
+// A hardware H.264 encoder that keeps failing is replaced by libx264 mid-run;
+// see Fallback.
 func (e *Encoder) WriteFrameRGBA(rgbaData []byte) error {
+	return e.WriteFrameRGBARows(rgbaData, 0, e.config.Height)
+}
+
+// WriteFrameRGBARows is WriteFrameRGBA for a frame that matches the previous
+// one outside rows [startY, endY): only those rows are converted or copied
+// into the encoder's input frame, which keeps the rest from before. An empty
+// span repeats the previous picture. The first frame, and the first after a
+// fallback to libx264, is taken whole whatever the span.
+func (e *Encoder) WriteFrameRGBARows(rgbaData []byte, startY, endY int) error {
 	// Validate frame size
 	expectedSize := e.config.Width * e.config.Height * 4 // RGBA = 4 bytes per pixel
 	if len(rgbaData) != expectedSize {
 		return fmt.Errorf("invalid RGBA frame size: got %d, expected %d", len(rgbaData), expectedSize)
 	}
 
+	for {
+		y0, y1 := max(startY, 0), min(endY, e.config.Height)
+		if !e.haveInput {
+			y0, y1 = 0, e.config.Height
+		}
+
+		// The timestamp only advances once the encoder accepts the frame, so an
+		// unchanged timestamp marks a hardware upload or send failure that is
+		// safe to retry; packet write errors are returned as they are.
+		pts := e.nextVideoPts
+		err := e.writeFrame(rgbaData, y0, y1)
+		if e.nextVideoPts != pts {
+			e.haveInput = true
+		}
+		if err == nil || e.hwEncoder == nil || e.nextVideoPts != pts {
+			e.hwFailures = 0
+			return err
+		}
+		e.hwFailures++
+		if e.hwFailures >= maxHWFailures {
+			if err := e.fallbackToSoftware(err); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeFrame sends one frame down the path for the current encoder,
+// converting or copying rows [startY, endY) into the input frame.
+func (e *Encoder) writeFrame(rgbaData []byte, startY, endY int) error {
 	// For NVENC, send RGBA directly - GPU does colourspace conversion
 	if e.inputPixFmt == ffmpeg.AVPixFmtRgba {
-		return e.writeFrameRGBADirect(rgbaData)
+		return e.writeFrameRGBADirect(rgbaData, startY, endY)
 	}
 
 	// For Vulkan/QSV/VAAPI/VideoToolbox, convert RGBA→NV12 then upload to GPU;
-	// configurePixelFormat sets NV12 for exactly those hardware encoders.
+	// NVENC without its RGBA path, AMF and Media Foundation take NV12 from
+	// system memory instead.
 	if e.inputPixFmt == ffmpeg.AVPixFmtNv12 {
-		return e.writeFrameHWUpload(rgbaData)
+		if e.hwFramesCtx == nil {
+			return e.writeFrameNV12Direct(rgbaData, startY, endY)
+		}
+		return e.writeFrameHWUpload(rgbaData, startY, endY)
 	}
 
 	// For software encoder, convert RGBA directly to YUV420P (skipping RGB24 intermediate)
-	return e.writeFrameRGBASoftware(rgbaData)
+	return e.writeFrameRGBASoftware(rgbaData, startY, endY)
 }
 
 // writeFrameRGBASoftware converts RGBA directly to YUV420P and encodes.
 // This avoids the intermediate RGB24 buffer allocation for ~3% faster software encoding.
-func (e *Encoder) writeFrameRGBASoftware(rgbaData []byte) error {
+func (e *Encoder) writeFrameRGBASoftware(rgbaData []byte, startY, endY int) error {
 	// Use pre-allocated YUV frame (configured in configurePixelFormat).
-	// Make writable as the encoder may still hold a reference from the previous frame.
+	// Make writable as the encoder may still hold a reference from the previous
+	// frame; a new buffer starts as a copy, so unconverted rows carry over.
 	yuvFrame := e.swYUVFrame
 	if ret, err := ffmpeg.AVFrameMakeWritable(yuvFrame); err != nil {
-		return checkFFmpeg(ret, err, "make YUV frame writable")
+		return ffmpegutil.Check(ret, err, "make YUV frame writable")
 	}
 
 	// Convert RGBA directly to YUV420P (skips RGB24 intermediate)
-	convertRGBAToYUV(e.rowPool, rgbaData, yuvFrame, e.config.Width)
+	convertRGBAToYUV(e.rowPool, &e.matrix, rgbaData, yuvFrame, e.config.Width, startY, endY)
 
-	// Set presentation timestamp
+	// Set presentation timestamp; advanced only once the encoder accepts the
+	// frame, so a retry after a failed send reuses it
 	yuvFrame.SetPts(e.nextVideoPts)
-	e.nextVideoPts++
 
 	// Send frame to encoder
-	ret, err := ffmpeg.AVCodecSendFrame(e.videoCodec, yuvFrame)
-	if err := checkFFmpeg(ret, err, "send frame to encoder"); err != nil {
+	e.markKeyframe(yuvFrame)
+	if err := e.sendFrame(e.videoCodec, yuvFrame, "send frame to encoder", e.receiveAndWriteVideoPackets); err != nil {
 		return err
 	}
+	e.nextVideoPts++
 
 	// Receive and write encoded packets
 	return e.receiveAndWriteVideoPackets()
@@ -766,55 +1315,80 @@ func (e *Encoder) writeFrameRGBASoftware(rgbaData []byte) error {
 
 // writeFrameRGBADirect sends RGBA frame directly to hardware encoder
 // This avoids CPU-side colourspace conversion - GPU handles it
-func (e *Encoder) writeFrameRGBADirect(rgbaData []byte) error {
+func (e *Encoder) writeFrameRGBADirect(rgbaData []byte, startY, endY int) error {
 	// Use pre-allocated RGBA frame (configured in configurePixelFormat).
 	// Make writable as the encoder may still hold a reference from the previous frame.
 	rgbaFrame := e.rgbaFrame
 	if ret, err := ffmpeg.AVFrameMakeWritable(rgbaFrame); err != nil {
-		return checkFFmpeg(ret, err, "make RGBA frame writable")
+		return ffmpegutil.Check(ret, err, "make RGBA frame writable")
 	}
 
 	// Copy RGBA data to frame
 	width := e.config.Width
-	height := e.config.Height
 	linesize := rgbaFrame.Linesize().Get(0)
 	data := rgbaFrame.Data().Get(0)
 
 	// Copy row by row (frame may have padding)
 	srcStride := width * 4
-	for y := range height {
+	for y := startY; y < endY; y++ {
 		srcOffset := y * srcStride
 		dstOffset := y * linesize
 		copy(unsafe.Slice((*byte)(unsafe.Add(data, dstOffset)), srcStride), //nolint:gosec // offset is within allocated frame
 			rgbaData[srcOffset:srcOffset+srcStride])
 	}
 
-	// Set presentation timestamp
+	// Set presentation timestamp; advanced only once the encoder accepts the
+	// frame, so a retry after a failed send reuses it
 	rgbaFrame.SetPts(e.nextVideoPts)
-	e.nextVideoPts++
 
 	// Send frame to encoder
-	ret, err := ffmpeg.AVCodecSendFrame(e.videoCodec, rgbaFrame)
-	if err := checkFFmpeg(ret, err, "send frame to encoder"); err != nil {
+	e.markKeyframe(rgbaFrame)
+	if err := e.sendFrame(e.videoCodec, rgbaFrame, "send frame to encoder", e.receiveAndWriteVideoPackets); err != nil {
 		return err
 	}
+	e.nextVideoPts++
 
 	// Receive and write encoded packets
 	return e.receiveAndWriteVideoPackets()
 }
 
+// writeFrameNV12Direct converts RGBA to NV12 and sends it to an encoder that
+// reads system memory (NVENC when its RGBA conversion does not match the
+// configured colour, AMF and Media Foundation always).
+func (e *Encoder) writeFrameNV12Direct(rgbaData []byte, startY, endY int) error {
+	// Make writable as the encoder may still hold a reference from the previous frame.
+	nv12Frame := e.hwNV12Frame
+	if ret, err := ffmpeg.AVFrameMakeWritable(nv12Frame); err != nil {
+		return ffmpegutil.Check(ret, err, "make NV12 frame writable")
+	}
+
+	convertRGBAToNV12(e.rowPool, &e.matrix, rgbaData, nv12Frame, e.config.Width, startY, endY)
+
+	// Set presentation timestamp; advanced only once the encoder accepts the
+	// frame, so a retry after a failed send reuses it
+	nv12Frame.SetPts(e.nextVideoPts)
+
+	e.markKeyframe(nv12Frame)
+	if err := e.sendFrame(e.videoCodec, nv12Frame, "send frame to encoder", e.receiveAndWriteVideoPackets); err != nil {
+		return err
+	}
+	e.nextVideoPts++
+
+	return e.receiveAndWriteVideoPackets()
+}
+
 // writeFrameHWUpload converts RGBA to NV12, uploads to GPU, and encodes
 // Pipeline: RGBA (CPU) → parallel Go conversion → NV12 (CPU) → AVHWFrameTransferData → GPU → encode
 // Used by Vulkan (h264_vulkan) and QSV (h264_qsv) encoders
 // Uses pre-allocated reusable NV12 frame and parallel Go conversion (8.4× faster than SwsScaleFrame)
-func (e *Encoder) writeFrameHWUpload(rgbaData []byte) error {
+func (e *Encoder) writeFrameHWUpload(rgbaData []byte, startY, endY int) error {
 	width := e.config.Width
 
 	// Use pre-allocated NV12 frame (already configured in setupHWFramesContext)
 	nv12Frame := e.hwNV12Frame
 
 	// Convert RGBA → NV12 using parallel Go conversion (much faster than SwsScaleFrame)
-	convertRGBAToNV12(e.rowPool, rgbaData, nv12Frame, width)
+	convertRGBAToNV12(e.rowPool, &e.matrix, rgbaData, nv12Frame, width, startY, endY)
 
 	// Allocate hardware frame from pool
 	// Note: hwFrame must be allocated per-call as it's returned to pool after encoding
@@ -826,25 +1400,26 @@ func (e *Encoder) writeFrameHWUpload(rgbaData []byte) error {
 
 	// Get buffer from hardware frames context pool
 	ret, err := ffmpeg.AVHWFrameGetBuffer(e.hwFramesCtx, hwFrame, 0)
-	if err := checkFFmpeg(ret, err, "get hardware frame buffer"); err != nil {
+	if err := ffmpegutil.Check(ret, err, "get hardware frame buffer"); err != nil {
 		return err
 	}
 
 	// Upload NV12 frame to GPU memory
 	ret, err = ffmpeg.AVHWFrameTransferData(hwFrame, nv12Frame, 0)
-	if err := checkFFmpeg(ret, err, "upload frame to GPU"); err != nil {
+	if err := ffmpegutil.Check(ret, err, "upload frame to GPU"); err != nil {
 		return err
 	}
 
-	// Copy frame properties for encoder
+	// Copy frame properties for encoder; advanced only once the encoder
+	// accepts the frame, so a retry after a failed send reuses it
 	hwFrame.SetPts(e.nextVideoPts)
-	e.nextVideoPts++
 
 	// Send hardware frame to encoder
-	ret, err = ffmpeg.AVCodecSendFrame(e.videoCodec, hwFrame)
-	if err := checkFFmpeg(ret, err, "send frame to hardware encoder"); err != nil {
+	e.markKeyframe(hwFrame)
+	if err := e.sendFrame(e.videoCodec, hwFrame, "send frame to hardware encoder", e.receiveAndWriteVideoPackets); err != nil {
 		return err
 	}
+	e.nextVideoPts++
 
 	// Receive and write encoded packets
 	return e.receiveAndWriteVideoPackets()
@@ -857,12 +1432,14 @@ func (e *Encoder) receiveAndWriteVideoPackets() error {
 		_, err := ffmpeg.AVCodecReceivePacket(e.videoCodec, pkt)
 		if err != nil {
 			// EAGAIN and EOF are expected - means no more packets available
-			if errors.Is(err, ffmpeg.EAgain) || errors.Is(err, ffmpeg.AVErrorEOF) {
+			if ffmpegutil.Drained(err) {
 				break
 			}
 			return fmt.Errorf("receive packet: %w", err)
 		}
 
+		e.recordPacket(pkt)
+
 		// Set stream index and rescale timestamps
 		pkt.SetStreamIndex(e.videoStream.Index())
 		ffmpeg.AVPacketRescaleTs(pkt, e.videoCodec.TimeBase(), e.videoStream.TimeBase())
@@ -872,7 +1449,7 @@ func (e *Encoder) receiveAndWriteVideoPackets() error {
 		ret, err := ffmpeg.AVInterleavedWriteFrame(e.formatCtx, pkt)
 		ffmpeg.AVPacketUnref(pkt)
 
-		if err := checkFFmpeg(ret, err, "write packet"); err != nil {
+		if err := ffmpegutil.Check(ret, err, "write packet"); err != nil {
 			return err
 		}
 	}
@@ -890,7 +1467,7 @@ func (e *Encoder) receiveAndWriteAudioPackets() error {
 		_, err := ffmpeg.AVCodecReceivePacket(e.audioCodec, pkt)
 		if err != nil {
 			// EAGAIN and EOF are expected - means no more packets available
-			if errors.Is(err, ffmpeg.EAgain) || errors.Is(err, ffmpeg.AVErrorEOF) {
+			if ffmpegutil.Drained(err) {
 				break
 			}
 			return fmt.Errorf("receive audio packet from encoder: %w", err)
@@ -905,7 +1482,7 @@ func (e *Encoder) receiveAndWriteAudioPackets() error {
 		ret, err := ffmpeg.AVInterleavedWriteFrame(e.formatCtx, pkt)
 		ffmpeg.AVPacketUnref(pkt)
 
-		if err := checkFFmpeg(ret, err, "write audio packet"); err != nil {
+		if err := ffmpegutil.Check(ret, err, "write audio packet"); err != nil {
 			return err
 		}
 	}
@@ -913,29 +1490,47 @@ func (e *Encoder) receiveAndWriteAudioPackets() error {
 	return nil
 }
 
-// channelLayoutName returns the human-readable name for a channel count.
-func channelLayoutName(channels int) string {
-	if channels == 2 {
+// ChannelLayoutName returns the human-readable name for a channel count.
+func ChannelLayoutName(channels int) string {
+	switch channels {
+	case 1:
+		return "mono"
+	case 2:
 		return "stereo"
+	case 6:
+		return "5.1"
 	}
-	return "mono"
+	return fmt.Sprintf("%d-channel", channels)
 }
 
 // WriteAudioSamples writes pre-decoded audio samples to the encoder.
-// Samples should be float32, mono or stereo interleaved depending on AudioChannels config.
-// For mono: just the samples. For stereo: L0, R0, L1, R1, ...
-// This method handles FIFO buffering and encodes complete AAC frames.
+// Samples should be float32, interleaved in FFmpeg's default channel order for
+// AudioChannels. For mono: just the samples. For stereo: L0, R0, L1, R1, ...
+// For 5.1: FL, FR, FC, LFE, BL, BR per sample. They are at SampleRate, and
+// resampled here for a codec that runs at another rate.
+// This method handles FIFO buffering and encodes complete frames.
 func (e *Encoder) WriteAudioSamples(samples []float32) error {
 	if e.audioCodec == nil {
 		return nil // No audio configured
 	}
 
-	encoderFrameSize := e.audioCodec.FrameSize() // 1024 for AAC
-	outputChannels := e.outputChannels()
-
+	if e.resampler != nil {
+		var err error
+		if samples, err = e.resampler.convert(samples); err != nil {
+			return err
+		}
+	}
 	if err := e.audioFIFO.write(samples); err != nil {
 		return err
 	}
+	return e.encodeAudioFIFO()
+}
+
+// encodeAudioFIFO encodes the whole encoder frames buffered in the FIFO,
+// leaving any remainder for the next write or the flush.
+func (e *Encoder) encodeAudioFIFO() error {
+	encoderFrameSize := e.audioCodec.FrameSize() // 1024 for AAC, 960 for Opus
+	outputChannels := e.outputChannels()
 
 	// Drain the FIFO one encoder frame at a time. AVAudioFifoSize reports
 	// samples-per-channel, so compare against encoderFrameSize (1024), not
@@ -956,23 +1551,17 @@ func (e *Encoder) WriteAudioSamples(samples []float32) error {
 
 		_, _ = ffmpeg.AVFrameMakeWritable(e.audioEncFrame)
 
-		var writeErr error
-		if outputChannels == 2 {
-			writeErr = writeStereoFloats(e.audioEncFrame, frameSamples)
-		} else {
-			writeErr = writeMonoFloats(e.audioEncFrame, frameSamples)
-		}
+		writeErr := e.writeAudioFrame(frameSamples, outputChannels)
 
 		if writeErr != nil {
 			return fmt.Errorf("failed to write %s samples: %w",
-				channelLayoutName(outputChannels), writeErr)
+				ChannelLayoutName(outputChannels), writeErr)
 		}
 
 		e.audioEncFrame.SetPts(e.nextAudioPts)
 		e.nextAudioPts += int64(encoderFrameSize)
 
-		ret, err := ffmpeg.AVCodecSendFrame(e.audioCodec, e.audioEncFrame)
-		if err := checkFFmpeg(ret, err, "send audio frame to encoder"); err != nil {
+		if err := e.sendFrame(e.audioCodec, e.audioEncFrame, "send audio frame to encoder", e.receiveAndWriteAudioPackets); err != nil {
 			return err
 		}
 
@@ -994,16 +1583,35 @@ func (e *Encoder) FlushAudioEncoder() error {
 	encoderFrameSize := e.audioCodec.FrameSize()
 	outputChannels := e.outputChannels()
 
+	// The resampler holds back the last few milliseconds for its filter.
+	if e.resampler != nil {
+		tail, err := e.resampler.flush()
+		if err != nil {
+			return err
+		}
+		if err := e.audioFIFO.write(tail); err != nil {
+			return err
+		}
+		if err := e.encodeAudioFIFO(); err != nil {
+			return err
+		}
+	}
+
 	// Drain any residual partial frame (< encoderFrameSize samples-per-channel)
-	// from the AVAudioFifo and zero-pad it to a full encoder frame. The residual
-	// now lives in e.audioFIFO since WriteAudioSamples routes through it.
+	// from the AVAudioFifo. The residual now lives in e.audioFIFO since
+	// WriteAudioSamples routes through it. FLAC takes a short last frame
+	// (AV_CODEC_CAP_SMALL_LAST_FRAME), so the lossless track ends where the
+	// audio does; AAC and Opus get a full encoder frame, zero-padded.
 	remaining, err := e.audioFIFO.size()
 	if err != nil {
 		return err
 	}
 	if remaining > 0 {
-		samplesPerFrame := encoderFrameSize * outputChannels
-		frameSamples := make([]float32, samplesPerFrame)
+		frameSize := encoderFrameSize
+		if e.config.AudioCodec.orDefault() == AudioFLAC && e.audioCodec.Codec().Capabilities()&ffmpeg.AVCodecCapSmallLastFrame != 0 {
+			frameSize = remaining
+		}
+		frameSamples := make([]float32, frameSize*outputChannels)
 		partialSamples, err := e.audioFIFO.read(remaining)
 		if err != nil {
 			return err
@@ -1011,23 +1619,18 @@ func (e *Encoder) FlushAudioEncoder() error {
 		copy(frameSamples, partialSamples)
 
 		_, _ = ffmpeg.AVFrameMakeWritable(e.audioEncFrame)
+		e.audioEncFrame.SetNbSamples(frameSize)
 
-		var writeErr error
-		if outputChannels == 2 {
-			writeErr = writeStereoFloats(e.audioEncFrame, frameSamples)
-		} else {
-			writeErr = writeMonoFloats(e.audioEncFrame, frameSamples)
-		}
+		writeErr := e.writeAudioFrame(frameSamples, outputChannels)
 
 		if writeErr != nil {
 			return fmt.Errorf("failed to write final samples: %w", writeErr)
 		}
 
 		e.audioEncFrame.SetPts(e.nextAudioPts)
-		e.nextAudioPts += int64(encoderFrameSize)
+		e.nextAudioPts += int64(frameSize)
 
-		ret, err := ffmpeg.AVCodecSendFrame(e.audioCodec, e.audioEncFrame)
-		if err := checkFFmpeg(ret, err, "send final audio frame"); err != nil {
+		if err := e.sendFrame(e.audioCodec, e.audioEncFrame, "send final audio frame", e.receiveAndWriteAudioPackets); err != nil {
 			return err
 		}
 	}
@@ -1038,41 +1641,63 @@ func (e *Encoder) FlushAudioEncoder() error {
 	return e.receiveAndWriteAudioPackets()
 }
 
-// writeMonoFloats writes mono float samples to a planar encoder frame.
-func writeMonoFloats(frame *ffmpeg.AVFrame, samples []float32) error {
-	nbSamples := len(samples)
-
-	dataPtr := frame.Data().Get(0)
-	if dataPtr == nil {
-		return fmt.Errorf("frame data pointer not allocated")
+// writeAudioFrame writes interleaved float samples for channels channels to
+// the audio encoder frame, in the layout its encoder takes.
+func (e *Encoder) writeAudioFrame(samples []float32, channels int) error {
+	switch e.audioFormat {
+	case ffmpeg.AVSampleFmtFlt:
+		return writePackedFloats(e.audioEncFrame, samples)
+	case ffmpeg.AVSampleFmtS32:
+		return writePackedInts(e.audioEncFrame, samples)
 	}
+	return writePlanarFloats(e.audioEncFrame, samples, channels)
+}
 
-	data := unsafe.Slice((*byte)(dataPtr), nbSamples*4)
+// flacBits is the sample depth of FLAC audio tracks.
+const flacBits = 24
 
-	for i := range nbSamples {
-		binary.LittleEndian.PutUint32(data[i*4:(i+1)*4], math.Float32bits(samples[i]))
+// writePackedInts converts interleaved float samples to flacBits-bit
+// integers, clipped to full scale, in the top bits of a packed int32
+// encoder frame.
+func writePackedInts(frame *ffmpeg.AVFrame, samples []float32) error {
+	ptr := frame.Data().Get(0)
+	if ptr == nil {
+		return fmt.Errorf("frame data pointer not allocated")
+	}
+	const scale = 1<<(flacBits-1) - 1
+	out := unsafe.Slice((*int32)(ptr), len(samples))
+	for i, s := range samples {
+		v := math.Round(float64(min(max(s, -1), 1)) * scale)
+		out[i] = int32(v) << (32 - flacBits) //nolint:gosec // clipped to flacBits bits above
 	}
-
 	return nil
 }
 
-// writeStereoFloats writes interleaved stereo float samples to a planar encoder
-// frame, splitting them into the left and right channel planes.
-func writeStereoFloats(frame *ffmpeg.AVFrame, samples []float32) error {
-	nbSamples := len(samples) / 2
-
-	leftPtr := frame.Data().Get(0)
-	rightPtr := frame.Data().Get(1)
-	if leftPtr == nil || rightPtr == nil {
-		return fmt.Errorf("frame data pointers not allocated")
+// writePackedFloats copies interleaved float samples to a packed encoder
+// frame's single plane.
+func writePackedFloats(frame *ffmpeg.AVFrame, samples []float32) error {
+	ptr := frame.Data().Get(0)
+	if ptr == nil {
+		return fmt.Errorf("frame data pointer not allocated")
 	}
+	copy(unsafe.Slice((*float32)(ptr), len(samples)), samples)
+	return nil
+}
 
-	leftData := unsafe.Slice((*byte)(leftPtr), nbSamples*4)
-	rightData := unsafe.Slice((*byte)(rightPtr), nbSamples*4)
+// writePlanarFloats writes interleaved float samples for channels channels
+// to a planar encoder frame, splitting them into one plane per channel.
+func writePlanarFloats(frame *ffmpeg.AVFrame, samples []float32, channels int) error {
+	nbSamples := len(samples) / channels
 
-	for i := range nbSamples {
-		binary.LittleEndian.PutUint32(leftData[i*4:(i+1)*4], math.Float32bits(samples[i*2]))
-		binary.LittleEndian.PutUint32(rightData[i*4:(i+1)*4], math.Float32bits(samples[i*2+1]))
+	for ch := range channels {
+		ptr := frame.Data().Get(uintptr(ch)) //nolint:gosec // channel index is non-negative
+		if ptr == nil {
+			return fmt.Errorf("frame data pointer for channel %d not allocated", ch)
+		}
+		data := unsafe.Slice((*byte)(ptr), nbSamples*4)
+		for i := range nbSamples {
+			binary.LittleEndian.PutUint32(data[i*4:(i+1)*4], math.Float32bits(samples[i*channels+ch]))
+		}
 	}
 
 	return nil
@@ -1089,7 +1714,7 @@ func (e *Encoder) Close() error {
 		for {
 			ret, err := ffmpeg.AVCodecReceivePacket(e.videoCodec, pkt)
 
-			if errors.Is(err, ffmpeg.AVErrorEOF) || errors.Is(err, ffmpeg.EAgain) {
+			if ffmpegutil.Drained(err) {
 				break
 			}
 
@@ -1125,6 +1750,10 @@ func (e *Encoder) Close() error {
 		e.audioFIFO.free()
 		e.audioFIFO = nil
 	}
+	if e.resampler != nil {
+		e.resampler.free()
+		e.resampler = nil
+	}
 
 	if e.hwDeviceCtx != nil {
 		ffmpeg.AVBufferUnref(&e.hwDeviceCtx)
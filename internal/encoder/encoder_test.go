@@ -1,8 +1,17 @@
 package encoder
 
 import (
+	"errors"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+	"unsafe"
+
+	"github.com/linuxmatters/jivefire/internal/audio"
+	"github.com/linuxmatters/jivefire/internal/yuv"
 )
 
 // newTestFIFO allocates an avAudioFIFO for the given channel count with the AAC
@@ -313,6 +322,329 @@ func TestEncoderPOC(t *testing.T) {
 	t.Logf("Successfully created video: %s (%d bytes)", outputPath, info.Size())
 }
 
+// TestEncoderOptions passes options through to libx264 and reports the one
+// it does not recognise.
+func TestEncoderOptions(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "options.mp4")
+	enc, err := New(Config{
+		OutputPath: outputPath,
+		Width:      320,
+		Height:     240,
+		Framerate:  30,
+		HWAccel:    HWAccelNone,
+		Options:    []Option{{"g", "15"}, {"x264-params", "aq-mode=3"}, {"no-such-option", "1"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	defer enc.Close()
+
+	if got := enc.videoCodec.GopSize(); got != 15 {
+		t.Errorf("GOP size %d, want --encoder-opts g=15 over the default", got)
+	}
+	if got := enc.UnusedOptions(); !slices.Equal(got, []string{"no-such-option"}) {
+		t.Errorf("UnusedOptions = %v, want [no-such-option]", got)
+	}
+}
+
+// TestEncoderKeyframes verifies the frames in Config.Keyframes and on
+// Config.KeyframeEvery come out as I frames, and no others within the GOP.
+func TestEncoderKeyframes(t *testing.T) {
+	enc, err := New(Config{
+		OutputPath:    filepath.Join(t.TempDir(), "keyframes.mp4"),
+		Width:         320,
+		Height:        240,
+		Framerate:     30,
+		HWAccel:       HWAccelNone,
+		Keyframes:     []int64{10, 25},
+		KeyframeEvery: 20,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	defer enc.Close()
+
+	frame := make([]byte, 320*240*4)
+	for range 45 {
+		if err := enc.WriteFrameRGBA(frame); err != nil {
+			t.Fatalf("Failed to write frame: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Failed to close encoder: %v", err)
+	}
+	// Frames 0, 10, 20, 25 and 40
+	if got := enc.Stats().I; got != 5 {
+		t.Errorf("%d I frames, want 5", got)
+	}
+}
+
+// TestEncoderGOP verifies --gop and --bframes reach libx264, and that
+// B-frames beyond the limit or the GOP are refused.
+func TestEncoderGOP(t *testing.T) {
+	bFrames := func(n int) *int { return &n }
+	cfg := Config{Width: 320, Height: 240, Framerate: 30, HWAccel: HWAccelNone, GOP: 15, BFrames: bFrames(3)}
+	cfg.OutputPath = filepath.Join(t.TempDir(), "gop.mp4")
+	enc, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	defer enc.Close()
+	if got := enc.videoCodec.GopSize(); got != 15 {
+		t.Errorf("GOP size %d, want 15", got)
+	}
+	if got := enc.videoCodec.MaxBFrames(); got != 3 {
+		t.Errorf("max B-frames %d, want 3", got)
+	}
+
+	for _, n := range []int{-1, MaxBFrames + 1} {
+		if _, err := New(Config{OutputPath: cfg.OutputPath, Width: 320, Height: 240, Framerate: 30, BFrames: bFrames(n)}); err == nil {
+			t.Errorf("New accepted %d B-frames", n)
+		}
+	}
+	cfg.OutputPath = filepath.Join(t.TempDir(), "long.mp4")
+	cfg.GOP, cfg.BFrames = 4, bFrames(4)
+	long, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := long.Initialize(); err == nil {
+		t.Error("Initialize accepted 4 B-frames in a GOP of 4")
+	}
+}
+
+// TestEncoderSurroundAudio encodes a second of 5.1 audio alongside a frame,
+// covering the planar split for more than two channels.
+func TestEncoderSurroundAudio(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "surround.mp4")
+	config := Config{
+		OutputPath:    outputPath,
+		Width:         1280,
+		Height:        720,
+		Framerate:     30,
+		SampleRate:    48000,
+		AudioChannels: 6,
+		HWAccel:       HWAccelNone,
+	}
+
+	enc, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	defer enc.Close()
+
+	frame := make([]byte, config.Width*config.Height*4)
+	if err := enc.WriteFrameRGBA(frame); err != nil {
+		t.Fatalf("Failed to write RGBA frame: %v", err)
+	}
+	// A different tone in each channel, interleaved FL, FR, FC, LFE, BL, BR.
+	samples := make([]float32, config.SampleRate*6)
+	for i := range samples {
+		ch, n := i%6, i/6
+		samples[i] = float32(0.25 * math.Sin(2*math.Pi*float64(220*(ch+1)*n)/float64(config.SampleRate)))
+	}
+	if err := enc.WriteAudioSamples(samples); err != nil {
+		t.Fatalf("Failed to write 5.1 samples: %v", err)
+	}
+	if err := enc.FlushAudioEncoder(); err != nil {
+		t.Fatalf("Failed to flush audio: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Failed to close encoder: %v", err)
+	}
+
+	if info, err := os.Stat(outputPath); err != nil || info.Size() == 0 {
+		t.Fatalf("Output file missing or empty: %v", err)
+	}
+}
+
+func TestEncoderAudioOnly(t *testing.T) {
+	if _, err := New(Config{OutputPath: "audio.m4a", AudioOnly: true}); err == nil {
+		t.Error("New accepted audio-only output without a sample rate")
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "audio.m4a")
+	enc, err := New(Config{
+		OutputPath:    outputPath,
+		SampleRate:    44100,
+		AudioChannels: 2,
+		AudioOnly:     true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	defer enc.Close()
+
+	samples := make([]float32, 44100*2)
+	for i := range samples {
+		samples[i] = float32(0.25 * math.Sin(2*math.Pi*440*float64(i/2)/44100))
+	}
+	if err := enc.WriteAudioSamples(samples); err != nil {
+		t.Fatalf("Failed to write samples: %v", err)
+	}
+	if err := enc.FlushAudioEncoder(); err != nil {
+		t.Fatalf("Failed to flush audio: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Failed to close encoder: %v", err)
+	}
+
+	if info, err := os.Stat(outputPath); err != nil || info.Size() == 0 {
+		t.Fatalf("Output file missing or empty: %v", err)
+	}
+}
+
+// TestEncoderOpus verifies Opus audio is resampled from 44.1 kHz and muxed
+// into Matroska, and refused by MP4.
+func TestEncoderOpus(t *testing.T) {
+	mp4, err := New(Config{OutputPath: filepath.Join(t.TempDir(), "audio.mp4"), SampleRate: 44100, AudioCodec: AudioOpus, AudioOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mp4.Initialize(); err == nil {
+		t.Error("Initialize accepted Opus audio in MP4")
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "audio.mka")
+	enc, err := New(Config{
+		OutputPath:    outputPath,
+		SampleRate:    44100,
+		AudioChannels: 2,
+		AudioCodec:    AudioOpus,
+		AudioOnly:     true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	defer enc.Close()
+	if got := enc.audioCodec.FrameSize(); got != 960 {
+		t.Errorf("Opus frame size = %d, want 960", got)
+	}
+
+	// Written in uneven chunks, so the resampler's output never lines up
+	// with the encoder's frames.
+	samples := make([]float32, 44100*2)
+	for i := range samples {
+		samples[i] = float32(0.25 * math.Sin(2*math.Pi*440*float64(i/2)/44100))
+	}
+	for len(samples) > 0 {
+		n := min(len(samples), 2*1234)
+		if err := enc.WriteAudioSamples(samples[:n]); err != nil {
+			t.Fatalf("Failed to write samples: %v", err)
+		}
+		samples = samples[n:]
+	}
+	if err := enc.FlushAudioEncoder(); err != nil {
+		t.Fatalf("Failed to flush audio: %v", err)
+	}
+	// One second at 48 kHz, rounded up to whole 20ms frames
+	if enc.nextAudioPts < 48000 || enc.nextAudioPts > 48000+960 {
+		t.Errorf("encoded %d samples at 48 kHz, want one second", enc.nextAudioPts)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Failed to close encoder: %v", err)
+	}
+
+	if info, err := os.Stat(outputPath); err != nil || info.Size() == 0 {
+		t.Fatalf("Output file missing or empty: %v", err)
+	}
+}
+
+// TestEncoderFLAC verifies a FLAC audio track is encoded lossless into
+// Matroska, and refused by MP4.
+func TestEncoderFLAC(t *testing.T) {
+	mp4, err := New(Config{OutputPath: filepath.Join(t.TempDir(), "audio.mp4"), SampleRate: 44100, AudioCodec: AudioFLAC, AudioOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mp4.Initialize(); err == nil {
+		t.Error("Initialize accepted FLAC audio in MP4")
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "audio.mka")
+	enc, err := New(Config{
+		OutputPath:    outputPath,
+		SampleRate:    44100,
+		AudioChannels: 2,
+		AudioCodec:    AudioFLAC,
+		AudioOnly:     true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	defer enc.Close()
+
+	samples := make([]float32, 44100*2)
+	for i := range samples {
+		samples[i] = float32(0.25 * math.Sin(2*math.Pi*440*float64(i/2)/44100))
+	}
+	if err := enc.WriteAudioSamples(samples); err != nil {
+		t.Fatalf("Failed to write samples: %v", err)
+	}
+	if err := enc.FlushAudioEncoder(); err != nil {
+		t.Fatalf("Failed to flush audio: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Failed to close encoder: %v", err)
+	}
+
+	if info, err := os.Stat(outputPath); err != nil || info.Size() == 0 {
+		t.Fatalf("Output file missing or empty: %v", err)
+	}
+
+	// The last, partial FLAC frame is sent short rather than padded, so the
+	// track decodes to exactly the samples written.
+	reader, err := audio.NewStreamingReader(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open output: %v", err)
+	}
+	defer reader.Close()
+	var decoded int
+	buf := make([]float64, 4096)
+	for {
+		n, err := reader.ReadInto(buf)
+		decoded += n
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to decode output: %v", err)
+		}
+	}
+	if decoded != 44100 {
+		t.Errorf("decoded %d samples, want 44100", decoded)
+	}
+}
+
+func TestChannelLayoutName(t *testing.T) {
+	for channels, want := range map[int]string{1: "mono", 2: "stereo", 6: "5.1", 4: "4-channel"} {
+		if got := ChannelLayoutName(channels); got != want {
+			t.Errorf("ChannelLayoutName(%d) = %q, want %q", channels, got, want)
+		}
+	}
+}
+
 // TestEncoderRGBA tests the RGBA frame writing path
 func TestEncoderRGBA(t *testing.T) {
 	outputPath := "../../testdata/poc-rgba-video.mp4"
@@ -370,3 +702,108 @@ func TestEncoderRGBA(t *testing.T) {
 
 	t.Logf("Successfully created RGBA video: %s (%d bytes)", outputPath, info.Size())
 }
+
+// TestConfigOutputTarget verifies stdout output maps to the pipe protocol with
+// an MP4 default, while file outputs pass through for the muxer to guess.
+func TestConfigOutputTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		wantURL    string
+		wantFormat string
+	}{
+		{"file", Config{OutputPath: "out.mp4"}, "out.mp4", ""},
+		{"file with format", Config{OutputPath: "out.bin", Format: "mpegts"}, "out.bin", "mpegts"},
+		{"stdout", Config{OutputPath: StdoutPath}, stdoutURL, "mp4"},
+		{"stdout mpegts", Config{OutputPath: StdoutPath, Format: "mpegts"}, stdoutURL, "mpegts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, format := tt.config.outputTarget()
+			if url != tt.wantURL || format != tt.wantFormat {
+				t.Errorf("outputTarget() = (%q, %q), want (%q, %q)", url, format, tt.wantURL, tt.wantFormat)
+			}
+		})
+	}
+}
+
+// TestResolveColour verifies an unset colour space keeps NVENC on its GPU
+// conversion (BT.601 limited) and gives every other encoder BT.709, while a
+// choice the user made is left alone.
+func TestResolveColour(t *testing.T) {
+	nvenc := &HWEncoder{Type: HWAccelNVENC}
+	tests := []struct {
+		name     string
+		hw       *HWEncoder
+		config   Config
+		want     yuv.ColorSpace
+		wantRGBA bool
+	}{
+		{"software", nil, Config{}, yuv.BT709, false},
+		{"qsv", &HWEncoder{Type: HWAccelQSV}, Config{}, yuv.BT709, false},
+		{"nvenc", nvenc, Config{}, yuv.BT601, true},
+		{"nvenc full range", nvenc, Config{ColorRange: yuv.RangeFull}, yuv.BT709, false},
+		{"nvenc bt709", nvenc, Config{ColorSpace: yuv.BT709}, yuv.BT709, false},
+		{"software bt601", nil, Config{ColorSpace: yuv.BT601}, yuv.BT601, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Encoder{config: tt.config, hwEncoder: tt.hw}
+			e.resolveColour()
+			cs, cr := e.config.colour()
+			if cs != tt.want {
+				t.Errorf("colour space = %q, want %q", cs, tt.want)
+			}
+			// New builds the matrix for a colour chosen up front.
+			if tt.config.ColorSpace == "" && e.matrix != yuv.NewMatrix(cs, cr) {
+				t.Error("matrix does not match the resolved colour")
+			}
+			if got := e.nvencConvertsRGBA(); tt.hw == nvenc && got != tt.wantRGBA {
+				t.Errorf("nvencConvertsRGBA() = %v, want %v", got, tt.wantRGBA)
+			}
+		})
+	}
+}
+
+// TestConvertRGBAToYUVRows verifies converting only the rows that changed
+// gives the same planes as converting the whole frame, including a span that
+// starts on an odd row, below the chroma row it shares.
+func TestConvertRGBAToYUVRows(t *testing.T) {
+	const width, height = 64, 48
+	c, err := NewConverter(width, height)
+	if err != nil {
+		t.Fatalf("NewConverter: %v", err)
+	}
+	defer c.Close()
+
+	planes := func() [][]byte {
+		var out [][]byte
+		for i, rows := range []int{height, height / 2, height / 2} {
+			size := c.frame.Linesize().Get(uintptr(i)) * rows
+			out = append(out, slices.Clone(unsafe.Slice((*byte)(c.frame.Data().Get(uintptr(i))), size)))
+		}
+		return out
+	}
+
+	before := make([]byte, width*height*4)
+	for i := range before {
+		before[i] = uint8(i * 7)
+	}
+	after := slices.Clone(before)
+	const startY, endY = 13, 30
+	for i := startY * width * 4; i < endY*width*4; i++ {
+		after[i] = uint8(i * 13)
+	}
+
+	c.Convert(after)
+	want := planes()
+
+	c.Convert(before)
+	convertRGBAToYUV(c.pool, &c.matrix, after, c.frame, width, startY, endY)
+	got := planes()
+	for i := range want {
+		if !slices.Equal(got[i], want[i]) {
+			t.Errorf("plane %d differs after converting rows %d-%d only", i, startY, endY)
+		}
+	}
+}
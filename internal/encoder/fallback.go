@@ -0,0 +1,108 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+)
+
+// maxHWFailures is how many consecutive failed sends of the same frame a
+// hardware encoder gets before it is replaced by libx264. A single failure
+// can be a busy GPU; repeated failures point at a driver reset or a lost
+// device.
+const maxHWFailures = 3
+
+// Fallback returns the hardware failure that made the encoder switch to
+// libx264 mid-run, or nil if the original encoder finished the job.
+func (e *Encoder) Fallback() error {
+	return e.fallbackErr
+}
+
+// fallbackToSoftware tears down the failing hardware encoder and continues
+// on libx264 from the current frame. Packets the hardware encoder already
+// produced are drained into the output first, so nothing encoded is lost.
+//
+// The output stream keeps the codec parameters written with the header.
+// libx264 repeats its SPS/PPS in-band on every keyframe (the codec is opened
+// without a global header), so decoders pick up the new parameters at the
+// switch point. It is opened without B-frames, whatever the profile or
+// --bframes ask for: the drained packets end at DTS nextVideoPts-1, where a
+// libx264 with B-frames would start its decode timestamps again, and the
+// muxer refuses a DTS that does not rise.
+//
+// AV1 has no software encoder to switch to, so an AV1 run fails instead.
+func (e *Encoder) fallbackToSoftware(cause error) error {
+	name := e.hwEncoder.Name
+	if e.hwEncoder.Codec == CodecAV1 {
+		return fmt.Errorf("%s failed and AV1 has no software fallback: %w", name, cause)
+	}
+
+	codec := ffmpeg.AVCodecFindEncoder(ffmpeg.AVCodecIdH264)
+	if codec == nil {
+		return fmt.Errorf("%s failed and H.264 software encoder not found: %w", name, cause)
+	}
+
+	e.drainVideoCodec()
+	e.freeHWEncoder()
+
+	// Set before opening, which turns off B-frames for the fallback.
+	e.fallbackErr = fmt.Errorf("%s failed at frame %d, switched to libx264: %w", name, e.nextVideoPts, cause)
+	if err := e.openVideoCodec(codec); err != nil {
+		e.fallbackErr = nil
+		return fmt.Errorf("%s failed and software fallback could not start: %w", name, errors.Join(cause, err))
+	}
+
+	e.hwFailures = 0
+	e.haveInput = false
+	return nil
+}
+
+// drainVideoCodec flushes the video codec and writes whatever packets it
+// still holds. Errors are ignored: the codec is being abandoned, and any
+// packets it cannot deliver are lost either way.
+func (e *Encoder) drainVideoCodec() {
+	_, _ = ffmpeg.AVCodecSendFrame(e.videoCodec, nil)
+
+	pkt := e.pkt
+	for {
+		ret, err := ffmpeg.AVCodecReceivePacket(e.videoCodec, pkt)
+		if err != nil || ret < 0 {
+			break
+		}
+		pkt.SetStreamIndex(e.videoStream.Index())
+		ffmpeg.AVPacketRescaleTs(pkt, e.videoCodec.TimeBase(), e.videoStream.TimeBase())
+		_, _ = ffmpeg.AVInterleavedWriteFrame(e.formatCtx, pkt)
+		ffmpeg.AVPacketUnref(pkt)
+	}
+}
+
+// freeHWEncoder releases the video codec, its input frames and every
+// hardware resource tied to it, leaving the encoder ready for openVideoCodec
+// with libx264.
+func (e *Encoder) freeHWEncoder() {
+	ffmpeg.AVCodecFreeContext(&e.videoCodec)
+
+	if e.hwNV12Frame != nil {
+		ffmpeg.AVFrameFree(&e.hwNV12Frame)
+		e.hwNV12Frame = nil
+	}
+	if e.rgbaFrame != nil {
+		ffmpeg.AVFrameFree(&e.rgbaFrame)
+		e.rgbaFrame = nil
+	}
+	if e.swYUVFrame != nil {
+		ffmpeg.AVFrameFree(&e.swYUVFrame)
+		e.swYUVFrame = nil
+	}
+	if e.hwFramesCtx != nil {
+		ffmpeg.AVBufferUnref(&e.hwFramesCtx)
+		e.hwFramesCtx = nil
+	}
+	if e.hwDeviceCtx != nil {
+		ffmpeg.AVBufferUnref(&e.hwDeviceCtx)
+		e.hwDeviceCtx = nil
+	}
+
+	e.hwEncoder = nil
+}
@@ -0,0 +1,67 @@
+package encoder
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// TestFallbackToSoftware stands a libx264 encoder without B-frames in for a
+// hardware one, fails its sends at frame 20 until it is replaced, and checks
+// the replacement is opened without the B-frames its config asks for and
+// the output decodes to every frame written.
+func TestFallbackToSoftware(t *testing.T) {
+	bFrames := func(n int) *int { return &n }
+	outputPath := filepath.Join(t.TempDir(), "fallback.mp4")
+	enc, err := New(Config{
+		OutputPath: outputPath,
+		Width:      320,
+		Height:     240,
+		Framerate:  30,
+		HWAccel:    HWAccelNone,
+		BFrames:    bFrames(0),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	if err := enc.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize encoder: %v", err)
+	}
+	defer enc.Close()
+
+	enc.hwEncoder = &HWEncoder{Name: "h264_test", Codec: CodecH264}
+	enc.config.BFrames = bFrames(2)
+	failures := 0
+	enc.failSend = func() error {
+		if enc.hwEncoder != nil && enc.nextVideoPts == 20 {
+			failures++
+			return errors.New("device lost")
+		}
+		return nil
+	}
+
+	const frames = 45
+	frame := make([]byte, 320*240*4)
+	for i := range frames {
+		frame[0] = byte(i)
+		if err := enc.WriteFrameRGBA(frame); err != nil {
+			t.Fatalf("Failed to write frame %d: %v", i, err)
+		}
+	}
+	if got := enc.videoCodec.MaxBFrames(); got != 0 {
+		t.Errorf("fallback opened with %d B-frames, want 0", got)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Failed to close encoder: %v", err)
+	}
+
+	if failures != maxHWFailures {
+		t.Errorf("%d failed sends before the switch, want %d", failures, maxHWFailures)
+	}
+	if enc.Fallback() == nil {
+		t.Error("Fallback() = nil after the switch to libx264")
+	}
+	if got, err := CountVideoFrames(outputPath); err != nil || got != frames {
+		t.Errorf("CountVideoFrames() = %d, %v; want %d", got, err, frames)
+	}
+}
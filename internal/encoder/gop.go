@@ -0,0 +1,100 @@
+package encoder
+
+import (
+	"fmt"
+	"strconv"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
+)
+
+// defaultGOPSeconds is the keyframe spacing without Config.GOP.
+const defaultGOPSeconds = 2
+
+// MaxBFrames is the most B-frames in a row Config.BFrames may ask for,
+// libx264's limit; NVENC and AMF take fewer (see bFrameLimit).
+const MaxBFrames = 16
+
+// gopSize returns the frames from one keyframe to the next.
+func (c Config) gopSize() int {
+	if c.GOP > 0 {
+		return c.GOP
+	}
+	return c.Framerate * defaultGOPSeconds
+}
+
+// bFrameLimit returns the most B-frames in a row the video encoder takes.
+func (e *Encoder) bFrameLimit() int {
+	if e.hwEncoder != nil {
+		switch e.hwEncoder.Type {
+		case HWAccelNVENC:
+			return 4
+		case HWAccelAMF:
+			return 3
+		}
+	}
+	return MaxBFrames
+}
+
+// setBFrameOptions applies Config.BFrames over the profile's own B-frame
+// policy, once the encoder is known to take that many and they fit in a
+// GOP.
+func (e *Encoder) setBFrameOptions(opts **ffmpeg.AVDictionary) error {
+	if e.config.BFrames == nil {
+		return nil
+	}
+	n := *e.config.BFrames
+	if limit := e.bFrameLimit(); n > limit {
+		return fmt.Errorf("%s takes at most %d B-frames in a row, not %d", e.EncoderName(), limit, n)
+	}
+	if gop := e.config.gopSize(); n >= gop {
+		return fmt.Errorf("%d B-frames in a row do not fit in a GOP of %d frames", n, gop)
+	}
+	_ = ffmpegutil.DictSet(opts, "bf", strconv.Itoa(n))
+	if n > 0 && e.hwEncoder != nil && e.hwEncoder.Type == HWAccelNVENC {
+		// B-frames need the reordering delay the fast profile turns off
+		_ = ffmpegutil.DictSet(opts, "zerolatency", "0")
+	}
+	return nil
+}
+
+// forcesKeyframes reports whether Config asks for keyframes of its own.
+func (e *Encoder) forcesKeyframes() bool {
+	return len(e.config.Keyframes) > 0 || e.config.KeyframeEvery > 0
+}
+
+// setKeyframeOptions makes a frame marked as a keyframe an IDR, from which a
+// player can start decoding, where the encoder would otherwise give it a
+// plain I frame. VA-API and Vulkan always do; AV1 has no distinction.
+func (e *Encoder) setKeyframeOptions(opts **ffmpeg.AVDictionary) {
+	if !e.forcesKeyframes() {
+		return
+	}
+	switch {
+	case e.hwEncoder == nil, e.hwEncoder.Type == HWAccelNVENC:
+		_ = ffmpegutil.DictSet(opts, "forced-idr", "1")
+	case e.hwEncoder.Type == HWAccelQSV:
+		_ = ffmpegutil.DictSet(opts, "forced_idr", "1")
+	}
+}
+
+// markKeyframe marks frame, about to be sent as the next frame, as a
+// keyframe when it is one of Config.Keyframes or falls on
+// Config.KeyframeEvery, and leaves the choice to the encoder otherwise. A
+// retried send marks the same frame again.
+func (e *Encoder) markKeyframe(frame *ffmpeg.AVFrame) {
+	if !e.forcesKeyframes() {
+		return
+	}
+	pts := e.nextVideoPts
+	keys := e.config.Keyframes
+	for e.nextKeyframe < len(keys) && keys[e.nextKeyframe] < pts {
+		e.nextKeyframe++
+	}
+	every := int64(e.config.KeyframeEvery)
+	if e.nextKeyframe < len(keys) && keys[e.nextKeyframe] == pts || every > 0 && pts%every == 0 {
+		frame.SetPictType(ffmpeg.AVPictureTypeI)
+		return
+	}
+	frame.SetPictType(ffmpeg.AVPictureTypeNone)
+}
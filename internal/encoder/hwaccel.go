@@ -1,12 +1,13 @@
 package encoder
 
 import (
-	"os"
 	"runtime"
+	"sync"
 
 	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
 
 	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
 )
 
 // HWAccelType represents a hardware acceleration type
@@ -20,12 +21,23 @@ const (
 	HWAccelVAAPI        HWAccelType = "vaapi"        // VA-API (AMD, Intel, older hardware)
 	HWAccelVulkan       HWAccelType = "vulkan"       // Vulkan Video
 	HWAccelVideoToolbox HWAccelType = "videotoolbox" // Apple VideoToolbox (macOS)
+	HWAccelAMF          HWAccelType = "amf"          // AMD Advanced Media Framework (Windows)
+	HWAccelMF           HWAccelType = "mf"           // Media Foundation hardware encoder (Windows)
 )
 
+// opensOwnDevice reports whether the encoder takes NV12 frames from system
+// memory and opens its own Direct3D 11 device, so no FFmpeg device context is
+// created for it. AMF can share one, but gains nothing when frames come from
+// the CPU anyway.
+func (t HWAccelType) opensOwnDevice() bool {
+	return t == HWAccelAMF || t == HWAccelMF
+}
+
 // HWEncoder represents a detected hardware encoder
 type HWEncoder struct {
 	Name        string      // Encoder name (e.g., "h264_nvenc")
 	Type        HWAccelType // Hardware acceleration type
+	Codec       VideoCodec  // Codec the encoder produces
 	DeviceType  ffmpeg.AVHWDeviceType
 	Available   bool   // Whether hardware is present and working
 	Description string // Human-readable description
@@ -35,6 +47,7 @@ type HWEncoder struct {
 type encoderSpec struct {
 	name       string
 	accelType  HWAccelType
+	codec      VideoCodec
 	deviceType ffmpeg.AVHWDeviceType
 	desc       string
 }
@@ -42,38 +55,36 @@ type encoderSpec struct {
 // linuxEncoderPriority defines the encoder preference order for Linux
 // Priority: nvenc > qsv > vaapi > vulkan > software
 // VAAPI is preferred over Vulkan as it has broader hardware support (AMD, Intel, older Intel)
+// AV1 follows the same order, without Vulkan or a software fallback
 var linuxEncoderPriority = []encoderSpec{
-	{"h264_nvenc", HWAccelNVENC, ffmpeg.AVHWDeviceTypeCuda, "NVIDIA NVENC"},
-	{"h264_qsv", HWAccelQSV, ffmpeg.AVHWDeviceTypeQsv, "Intel Quick Sync Video"},
-	{"h264_vaapi", HWAccelVAAPI, ffmpeg.AVHWDeviceTypeVaapi, "VA-API"},
-	{"h264_vulkan", HWAccelVulkan, ffmpeg.AVHWDeviceTypeVulkan, "Vulkan Video"},
+	{"h264_nvenc", HWAccelNVENC, CodecH264, ffmpeg.AVHWDeviceTypeCuda, "NVIDIA NVENC"},
+	{"h264_qsv", HWAccelQSV, CodecH264, ffmpeg.AVHWDeviceTypeQsv, "Intel Quick Sync Video"},
+	{"h264_vaapi", HWAccelVAAPI, CodecH264, ffmpeg.AVHWDeviceTypeVaapi, "VA-API"},
+	{"h264_vulkan", HWAccelVulkan, CodecH264, ffmpeg.AVHWDeviceTypeVulkan, "Vulkan Video"},
+	{"av1_nvenc", HWAccelNVENC, CodecAV1, ffmpeg.AVHWDeviceTypeCuda, "NVIDIA NVENC AV1"},
+	{"av1_qsv", HWAccelQSV, CodecAV1, ffmpeg.AVHWDeviceTypeQsv, "Intel Quick Sync Video AV1"},
+	{"av1_vaapi", HWAccelVAAPI, CodecAV1, ffmpeg.AVHWDeviceTypeVaapi, "VA-API AV1"},
 }
 
 // macOSEncoderPriority defines the encoder preference order for macOS
 // Priority: videotoolbox > software
 var macOSEncoderPriority = []encoderSpec{
-	{"h264_videotoolbox", HWAccelVideoToolbox, ffmpeg.AVHWDeviceTypeVideotoolbox, "Apple VideoToolbox"},
+	{"h264_videotoolbox", HWAccelVideoToolbox, CodecH264, ffmpeg.AVHWDeviceTypeVideotoolbox, "Apple VideoToolbox"},
 }
 
-// suppressHWProbeLogging temporarily silences FFmpeg and libva logging during
-// hardware probing. Returns a cleanup function that restores the original state.
-func suppressHWProbeLogging() func() {
-	// Save and silence FFmpeg logs
-	oldLevel, _ := ffmpeg.AVLogGetLevel()
-	ffmpeg.AVLogSetLevel(ffmpeg.AVLogQuiet)
-
-	// Save and silence libva logs (VA-API has its own logging separate from FFmpeg)
-	oldLibvaLevel := os.Getenv("LIBVA_MESSAGING_LEVEL")
-	os.Setenv("LIBVA_MESSAGING_LEVEL", "0")
-
-	return func() {
-		ffmpeg.AVLogSetLevel(oldLevel)
-		if oldLibvaLevel == "" {
-			os.Unsetenv("LIBVA_MESSAGING_LEVEL")
-		} else {
-			os.Setenv("LIBVA_MESSAGING_LEVEL", oldLibvaLevel)
-		}
-	}
+// windowsEncoderPriority defines the encoder preference order for Windows
+// Priority: nvenc > qsv > amf > mf > software
+// Media Foundation comes last as it is whichever vendor's MFT is installed,
+// with fewer controls than the vendor's own API; it has no AV1 encoder here.
+// AMF and Media Foundation need no device type (see opensOwnDevice)
+var windowsEncoderPriority = []encoderSpec{
+	{"h264_nvenc", HWAccelNVENC, CodecH264, ffmpeg.AVHWDeviceTypeCuda, "NVIDIA NVENC"},
+	{"h264_qsv", HWAccelQSV, CodecH264, ffmpeg.AVHWDeviceTypeQsv, "Intel Quick Sync Video"},
+	{"h264_amf", HWAccelAMF, CodecH264, 0, "AMD AMF"},
+	{"h264_mf", HWAccelMF, CodecH264, 0, "Media Foundation"},
+	{"av1_nvenc", HWAccelNVENC, CodecAV1, ffmpeg.AVHWDeviceTypeCuda, "NVIDIA NVENC AV1"},
+	{"av1_qsv", HWAccelQSV, CodecAV1, ffmpeg.AVHWDeviceTypeQsv, "Intel Quick Sync Video AV1"},
+	{"av1_amf", HWAccelAMF, CodecAV1, 0, "AMD AMF AV1"},
 }
 
 // setupTestHWFramesContext creates and initialises a hardware frames context for
@@ -109,24 +120,22 @@ func setupTestHWFramesContext(hwDeviceCtx *ffmpeg.AVBufferRef, codecCtx *ffmpeg.
 // testEncoderAvailable performs a full encoder capability test by attempting to
 // configure and open the encoder with proper hardware context. This catches cases
 // where a hardware device exists but doesn't support the specific encoder
-// (e.g., Intel iGPU with Vulkan but no Vulkan Video encoding support).
-func testEncoderAvailable(encoderName string, deviceType ffmpeg.AVHWDeviceType, accelType HWAccelType) bool {
-	restoreLogging := suppressHWProbeLogging()
-	defer restoreLogging()
-
-	encName := ffmpeg.ToCStr(encoderName)
-	defer encName.Free()
-	codec := ffmpeg.AVCodecFindEncoderByName(encName)
+// (e.g., Intel iGPU with Vulkan but no Vulkan Video encoding support). A
+// non-empty device tests that device rather than the backend's default.
+func testEncoderAvailable(encoderName string, deviceType ffmpeg.AVHWDeviceType, accelType HWAccelType, device string) bool {
+	codec := ffmpegutil.FindEncoder(encoderName)
 	if codec == nil {
 		return false
 	}
 
 	var hwDeviceCtx *ffmpeg.AVBufferRef
-	ret, _ := ffmpeg.AVHWDeviceCtxCreate(&hwDeviceCtx, deviceType, nil, nil, 0)
-	if ret < 0 || hwDeviceCtx == nil {
-		return false
+	if !accelType.opensOwnDevice() {
+		ret, _ := createHWDevice(&hwDeviceCtx, deviceType, device)
+		if ret < 0 || hwDeviceCtx == nil {
+			return false
+		}
+		defer ffmpeg.AVBufferUnref(&hwDeviceCtx)
 	}
-	defer ffmpeg.AVBufferUnref(&hwDeviceCtx)
 
 	codecCtx := ffmpeg.AVCodecAllocContext3(codec)
 	if codecCtx == nil {
@@ -178,35 +187,79 @@ func testEncoderAvailable(encoderName string, deviceType ffmpeg.AVHWDeviceType,
 			return false
 		}
 		defer ffmpeg.AVBufferUnref(&hwFramesRef)
+	case HWAccelAMF, HWAccelMF:
+		// AMF and Media Foundation read NV12 from system memory
+		codecCtx.SetPixFmt(ffmpeg.AVPixFmtNv12)
 	default:
 		return false
 	}
 
+	// Media Foundation falls back to Microsoft's software MFT unless told not
+	// to, which would pass the test on any machine
+	var opts *ffmpeg.AVDictionary
+	defer ffmpeg.AVDictFree(&opts)
+	if accelType == HWAccelMF {
+		_ = ffmpegutil.DictSet(&opts, "hw_encoding", "1")
+	}
+
 	// Try to open the encoder - this is the definitive test
-	ret, _ = ffmpeg.AVCodecOpen2(codecCtx, codec, nil)
+	ret, _ := ffmpeg.AVCodecOpen2(codecCtx, codec, &opts)
 	return ret >= 0
 }
 
+// createHWDevice creates a hardware device context of deviceType on device,
+// or on the backend's default device when device is empty.
+func createHWDevice(ctx **ffmpeg.AVBufferRef, deviceType ffmpeg.AVHWDeviceType, device string) (int, error) {
+	var cs ffmpegutil.CStrings
+	defer cs.Free()
+	return ffmpeg.AVHWDeviceCtxCreate(ctx, deviceType, cs.OrNil(device), nil, 0)
+}
+
 // DetectHWEncoders probes for available hardware encoders
 // Returns a list of detected encoders in priority order
 func DetectHWEncoders() []HWEncoder {
-	var encoders []HWEncoder
+	return DetectHWEncodersOn("")
+}
 
+// DetectHWEncodersOn probes the hardware encoders on one device, named as
+// FFmpeg's -init_hw_device takes it: a DRM render node such as
+// /dev/dri/renderD129 for QSV and VA-API, or a GPU index for NVENC and
+// Vulkan. On Windows QSV takes an adapter index too. VideoToolbox, AMF and
+// Media Foundation have no device choice and ignore it. An empty device
+// probes each backend's default, as DetectHWEncoders does.
+//
+// Results come from the probe cache when it holds them (see ProbeCachePath);
+// otherwise every encoder is probed at once, since a VA-API or Vulkan
+// driver can take seconds to initialise, and the results are cached.
+func DetectHWEncodersOn(device string) []HWEncoder {
 	// Select encoder list based on OS
 	var priority []encoderSpec
 
 	switch runtime.GOOS {
 	case "darwin":
 		priority = macOSEncoderPriority
+	case "windows":
+		priority = windowsEncoderPriority
 	default: // Linux and others
 		priority = linuxEncoderPriority
 	}
 
-	// Check each encoder in priority order
-	for _, enc := range priority {
-		encoder := HWEncoder{
+	return cachedProbe(device, priority, probeEncoders)
+}
+
+// probeEncoders tests each encoder in priority on device concurrently,
+// returning them in priority order.
+func probeEncoders(device string, priority []encoderSpec) []HWEncoder {
+	// Probing failures are expected and would otherwise fill the terminal
+	ffmpegutil.Init()
+
+	encoders := make([]HWEncoder, len(priority))
+	var wg sync.WaitGroup
+	for i, enc := range priority {
+		encoders[i] = HWEncoder{
 			Name:        enc.name,
 			Type:        enc.accelType,
+			Codec:       enc.codec,
 			DeviceType:  enc.deviceType,
 			Description: enc.desc,
 			Available:   false,
@@ -215,39 +268,43 @@ func DetectHWEncoders() []HWEncoder {
 		// Perform comprehensive encoder test - this actually attempts to open
 		// the encoder with proper hardware context, catching cases where the
 		// hardware device exists but doesn't support the specific encoder
-		encoder.Available = testEncoderAvailable(enc.name, enc.deviceType, enc.accelType)
-
-		encoders = append(encoders, encoder)
+		wg.Go(func() {
+			encoders[i].Available = testEncoderAvailable(enc.name, enc.deviceType, enc.accelType, device)
+		})
 	}
+	wg.Wait()
 
 	return encoders
 }
 
-// SelectBestEncoder returns the best available encoder based on priority
+// SelectBestEncoder returns the best available encoder for codec based on priority
 // If requestedType is HWAccelAuto, it selects the first available hardware encoder
 // If requestedType is HWAccelNone, it returns nil (use software)
 // Otherwise, it attempts to use the requested type if available
-func SelectBestEncoder(requestedType HWAccelType) *HWEncoder {
+// A non-empty device probes only that device (see DetectHWEncodersOn)
+func SelectBestEncoder(requestedType HWAccelType, codec VideoCodec, device string) *HWEncoder {
 	if requestedType == HWAccelNone {
 		return nil // Explicitly requested software encoding
 	}
 
 	// Detect all available encoders in priority order
-	return SelectBestEncoderFrom(DetectHWEncoders(), requestedType)
+	return SelectBestEncoderFrom(DetectHWEncodersOn(device), requestedType, codec)
 }
 
 // SelectBestEncoderFrom selects the best encoder from an already-probed list,
 // avoiding a redundant hardware probe when the caller already has the result of
 // DetectHWEncoders. Selection semantics match SelectBestEncoder.
-func SelectBestEncoderFrom(encoders []HWEncoder, requestedType HWAccelType) *HWEncoder {
+// Only encoders of codec are considered; an empty codec means H.264.
+func SelectBestEncoderFrom(encoders []HWEncoder, requestedType HWAccelType, codec VideoCodec) *HWEncoder {
 	if requestedType == HWAccelNone {
 		return nil // Explicitly requested software encoding
 	}
+	codec = codec.orDefault()
 
 	if requestedType == HWAccelAuto {
 		// Return first available encoder from the priority list
 		for i := range encoders {
-			if encoders[i].Available {
+			if encoders[i].Codec == codec && encoders[i].Available {
 				return &encoders[i]
 			}
 		}
@@ -256,7 +313,7 @@ func SelectBestEncoderFrom(encoders []HWEncoder, requestedType HWAccelType) *HWE
 
 	// Look for specifically requested encoder type
 	for i := range encoders {
-		if encoders[i].Type == requestedType {
+		if encoders[i].Type == requestedType && encoders[i].Codec == codec {
 			if encoders[i].Available {
 				return &encoders[i]
 			}
@@ -5,6 +5,7 @@ import (
 )
 
 func TestDetectHWEncoders(t *testing.T) {
+	useProbeCache(t)
 	encoders := DetectHWEncoders()
 
 	t.Logf("Detected %d encoder types", len(encoders))
@@ -19,8 +20,9 @@ func TestDetectHWEncoders(t *testing.T) {
 }
 
 func TestSelectBestEncoder(t *testing.T) {
+	useProbeCache(t)
 	// Test auto-detection
-	enc := SelectBestEncoder(HWAccelAuto)
+	enc := SelectBestEncoder(HWAccelAuto, CodecH264, "")
 	if enc != nil {
 		t.Logf("Auto-selected encoder: %s (%s)", enc.Description, enc.Name)
 	} else {
@@ -28,8 +30,41 @@ func TestSelectBestEncoder(t *testing.T) {
 	}
 
 	// Test explicit software selection
-	enc = SelectBestEncoder(HWAccelNone)
+	enc = SelectBestEncoder(HWAccelNone, CodecH264, "")
 	if enc != nil {
 		t.Errorf("Expected nil for HWAccelNone, got %s", enc.Name)
 	}
 }
+
+// An encoder is only selected for the codec it produces, so a working
+// h264_nvenc does not stand in for a missing av1_nvenc.
+func TestSelectBestEncoderFromCodec(t *testing.T) {
+	encoders := []HWEncoder{
+		{Name: "h264_nvenc", Type: HWAccelNVENC, Codec: CodecH264, Available: true},
+		{Name: "h264_vaapi", Type: HWAccelVAAPI, Codec: CodecH264, Available: true},
+		{Name: "av1_nvenc", Type: HWAccelNVENC, Codec: CodecAV1},
+		{Name: "av1_vaapi", Type: HWAccelVAAPI, Codec: CodecAV1, Available: true},
+	}
+	tests := []struct {
+		accel HWAccelType
+		codec VideoCodec
+		want  string
+	}{
+		{HWAccelAuto, "", "h264_nvenc"},
+		{HWAccelAuto, CodecH264, "h264_nvenc"},
+		{HWAccelAuto, CodecAV1, "av1_vaapi"},
+		{HWAccelVAAPI, CodecAV1, "av1_vaapi"},
+		{HWAccelNVENC, CodecAV1, ""},
+		{HWAccelQSV, CodecAV1, ""},
+		{HWAccelNone, CodecAV1, ""},
+	}
+	for _, tt := range tests {
+		var got string
+		if enc := SelectBestEncoderFrom(encoders, tt.accel, tt.codec); enc != nil {
+			got = enc.Name
+		}
+		if got != tt.want {
+			t.Errorf("SelectBestEncoderFrom(%s, %q) = %q, want %q", tt.accel, tt.codec, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package encoder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unsafe"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/failure"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
+)
+
+// A split render (--segments, --segment) encodes the video in segments,
+// each in its own process and perhaps on its own machine, and the audio
+// once. Join muxes them into one output by copying packets, so nothing is
+// encoded twice. The output carries the first segment's codec parameters,
+// which only decode the others when they match: the segments must come
+// from the same encoder with the same options.
+
+// ReadTag returns the value of the container tag key in path, or "" when it
+// has none.
+func ReadTag(path, key string) (string, error) {
+	ffmpegutil.Init()
+	var formatCtx *ffmpeg.AVFormatContext
+	var cs ffmpegutil.CStrings
+	defer cs.Free()
+	ret, err := ffmpeg.AVFormatOpenInput(&formatCtx, cs.New(path), nil, nil)
+	if err := ffmpegutil.Check(ret, err, "open "+path); err != nil {
+		return "", failure.Mark(failure.ErrUnsupportedFormat, err)
+	}
+	defer ffmpeg.AVFormatCloseInput(&formatCtx)
+	entry := ffmpeg.AVDictGet(formatCtx.Metadata(), cs.New(key), nil, 0)
+	if entry == nil {
+		return "", nil
+	}
+	return entry.Value().String(), nil
+}
+
+// Join writes the video of segments, one after another, and the audio of
+// audioPath to cfg.OutputPath, with cfg.Chapters and cfg.Metadata. Only
+// OutputPath, Format, Chapters and Metadata are used from cfg.
+func Join(cfg Config, segments []string, audioPath string) (err error) {
+	if len(segments) == 0 {
+		return errors.New("no segments to join")
+	}
+	ffmpegutil.Init()
+
+	video, err := openPackets(segments[0], ffmpeg.AVMediaTypeVideo)
+	if err != nil {
+		return err
+	}
+	defer func() { video.close() }()
+	audio, err := openPackets(audioPath, ffmpeg.AVMediaTypeAudio)
+	if err != nil {
+		return err
+	}
+	defer audio.close()
+
+	e := &Encoder{config: cfg}
+	defer func() {
+		if closeErr := e.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	url, format := cfg.outputTarget()
+	var cs ffmpegutil.CStrings
+	defer cs.Free()
+	ret, err := ffmpeg.AVFormatAllocOutputContext2(&e.formatCtx, nil, cs.OrNil(format), cs.New(url))
+	if err := ffmpegutil.Check(ret, err, "allocate output context"); err != nil {
+		return err
+	}
+	if e.videoStream, err = e.copyStream(video.stream); err != nil {
+		return err
+	}
+	if e.audioStream, err = e.copyStream(audio.stream); err != nil {
+		return err
+	}
+	if err := e.addChapters(); err != nil {
+		return fmt.Errorf("failed to add chapters: %w", err)
+	}
+	if err := e.addMetadata(); err != nil {
+		return err
+	}
+	if e.formatCtx.Oformat().Flags()&ffmpeg.AVFmtNofile == 0 {
+		var pb *ffmpeg.AVIOContext
+		ret, err = ffmpeg.AVIOOpen(&pb, cs.New(url), ffmpeg.AVIOFlagWrite)
+		if err := ffmpegutil.Check(ret, err, "open output file"); err != nil {
+			return err
+		}
+		e.formatCtx.SetPb(pb)
+	}
+	var headerOpts *ffmpeg.AVDictionary
+	defer ffmpeg.AVDictFree(&headerOpts)
+	e.setMuxerOptions(&headerOpts, url == stdoutURL)
+	ret, err = ffmpeg.AVFormatWriteHeader(e.formatCtx, &headerOpts)
+	if err := ffmpegutil.Check(ret, err, "write header"); err != nil {
+		return err
+	}
+
+	// Writing the header can change the output time bases, so the readers
+	// rescale to them only now.
+	video.to, audio.to = e.videoStream, e.audioStream
+	first := video.stream.Codecpar()
+
+	// Each segment's timestamps start again from zero; they are moved on by
+	// the end of the segments before. The streams are interleaved by
+	// timestamp here, as the muxer would otherwise hold all of the video
+	// waiting for the audio.
+	next := 1
+	lastDts := int64(math.MinInt64)
+	nextVideo := func() (bool, error) {
+		for {
+			err := video.next()
+			if err == nil {
+				return true, nil
+			}
+			if !errors.Is(err, io.EOF) || next == len(segments) {
+				return false, ignoreEOF(err)
+			}
+			offset := video.end
+			video.close()
+			if video, err = openPackets(segments[next], ffmpeg.AVMediaTypeVideo); err != nil {
+				return false, err
+			}
+			if !sameCodec(first, video.stream.Codecpar()) {
+				return false, fmt.Errorf("%s was encoded differently from %s; render every segment with the same --encoder, --profile and --encoder-opts", segments[next], segments[0])
+			}
+			video.to, video.offset, video.end = e.videoStream, offset, offset
+			next++
+		}
+	}
+	haveVideo, err := nextVideo()
+	if err != nil {
+		return err
+	}
+	haveAudio, err := audio.nextOrEOF()
+	if err != nil {
+		return err
+	}
+	for haveVideo || haveAudio {
+		if haveVideo && (!haveAudio || video.seconds() <= audio.seconds()) {
+			// A segment's first frames can decode before the last frames of
+			// the one before; keep the decode order rising.
+			pkt := video.pkt
+			if pkt.Dts() <= lastDts {
+				pkt.SetDts(lastDts + 1)
+				pkt.SetPts(max(pkt.Pts(), pkt.Dts()))
+			}
+			lastDts = pkt.Dts()
+			if err := e.writeCopied(pkt, e.videoStream); err != nil {
+				return err
+			}
+			if haveVideo, err = nextVideo(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.writeCopied(audio.pkt, e.audioStream); err != nil {
+			return err
+		}
+		if haveAudio, err = audio.nextOrEOF(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyStream adds an output stream with the codec parameters of in.
+func (e *Encoder) copyStream(in *ffmpeg.AVStream) (*ffmpeg.AVStream, error) {
+	out := ffmpeg.AVFormatNewStream(e.formatCtx, nil)
+	if out == nil {
+		return nil, errors.New("failed to create output stream")
+	}
+	ret, err := ffmpeg.AVCodecParametersCopy(out.Codecpar(), in.Codecpar())
+	if err := ffmpegutil.Check(ret, err, "copy codec parameters"); err != nil {
+		return nil, err
+	}
+	out.Codecpar().SetCodecTag(0)
+	out.SetTimeBase(in.TimeBase())
+	return out, nil
+}
+
+// writeCopied writes a packet already rescaled to stream's time base.
+func (e *Encoder) writeCopied(pkt *ffmpeg.AVPacket, stream *ffmpeg.AVStream) error {
+	pkt.SetStreamIndex(stream.Index())
+	pkt.SetPos(-1)
+	ret, err := ffmpeg.AVInterleavedWriteFrame(e.formatCtx, pkt)
+	return ffmpegutil.Check(ret, err, "write packet")
+}
+
+// sameCodec reports whether packets coded for b decode with a's parameters.
+func sameCodec(a, b *ffmpeg.AVCodecParameters) bool {
+	if a.CodecId() != b.CodecId() || a.Width() != b.Width() || a.Height() != b.Height() || a.ExtradataSize() != b.ExtradataSize() {
+		return false
+	}
+	n := a.ExtradataSize()
+	return n == 0 || bytes.Equal(unsafe.Slice((*byte)(a.Extradata()), n), unsafe.Slice((*byte)(b.Extradata()), n))
+}
+
+// packetReader reads the packets of one stream of a file, rescaled to the
+// time base of the output stream to and moved on by offset.
+type packetReader struct {
+	formatCtx *ffmpeg.AVFormatContext
+	stream    *ffmpeg.AVStream
+	pkt       *ffmpeg.AVPacket
+
+	to     *ffmpeg.AVStream
+	offset int64 // Added to every timestamp, in to's time base
+	end    int64 // Latest pts+duration returned, in to's time base
+}
+
+// openPackets opens the first stream of kind in path.
+func openPackets(path string, kind ffmpeg.AVMediaType) (*packetReader, error) {
+	r := &packetReader{}
+	var cs ffmpegutil.CStrings
+	defer cs.Free()
+	ret, err := ffmpeg.AVFormatOpenInput(&r.formatCtx, cs.New(path), nil, nil)
+	if err := ffmpegutil.Check(ret, err, "open "+path); err != nil {
+		return nil, failure.Mark(failure.ErrInputNotFound, err)
+	}
+	ret, err = ffmpeg.AVFormatFindStreamInfo(r.formatCtx, nil)
+	if err := ffmpegutil.Check(ret, err, "read stream info of "+path); err != nil {
+		r.close()
+		return nil, err
+	}
+	for i := uintptr(0); i < uintptr(r.formatCtx.NbStreams()); i++ {
+		if s := r.formatCtx.Streams().Get(i); s.Codecpar().CodecType() == kind {
+			r.stream = s
+			break
+		}
+	}
+	if r.stream == nil {
+		r.close()
+		name := "video"
+		if kind == ffmpeg.AVMediaTypeAudio {
+			name = "audio"
+		}
+		return nil, failure.Mark(failure.ErrUnsupportedFormat, fmt.Errorf("%s has no %s stream", path, name))
+	}
+	r.pkt = ffmpeg.AVPacketAlloc()
+	return r, nil
+}
+
+// next reads the stream's next packet into pkt, or returns io.EOF.
+func (r *packetReader) next() error {
+	for {
+		_, err := ffmpeg.AVReadFrame(r.formatCtx, r.pkt)
+		if errors.Is(err, ffmpeg.AVErrorEOF) {
+			return io.EOF
+		}
+		if err != nil {
+			return fmt.Errorf("read packet: %w", err)
+		}
+		if r.pkt.StreamIndex() != r.stream.Index() {
+			ffmpeg.AVPacketUnref(r.pkt)
+			continue
+		}
+		ffmpeg.AVPacketRescaleTs(r.pkt, r.stream.TimeBase(), r.to.TimeBase())
+		r.pkt.SetPts(r.pkt.Pts() + r.offset)
+		r.pkt.SetDts(r.pkt.Dts() + r.offset)
+		r.end = max(r.end, r.pkt.Pts()+r.pkt.Duration())
+		return nil
+	}
+}
+
+// nextOrEOF is next, reporting whether a packet was read.
+func (r *packetReader) nextOrEOF() (bool, error) {
+	err := r.next()
+	return err == nil, ignoreEOF(err)
+}
+
+// seconds returns the decode time of the current packet.
+func (r *packetReader) seconds() float64 {
+	tb := r.to.TimeBase()
+	return float64(r.pkt.Dts()) * float64(tb.Num()) / float64(tb.Den())
+}
+
+func (r *packetReader) close() {
+	if r.pkt != nil {
+		ffmpeg.AVPacketFree(&r.pkt)
+	}
+	if r.formatCtx != nil {
+		ffmpeg.AVFormatCloseInput(&r.formatCtx)
+	}
+}
+
+func ignoreEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package encoder
+
+import (
+	"fmt"
+
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
+)
+
+// Tag is a container metadata entry, such as title or date. The MP4 muxer
+// maps FFmpeg's generic keys to iTunes-style atoms: title to ©nam, artist to
+// ©ART, show to tvsh, date to ©day and episode_sort to tves.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// addMetadata sets the configured tags on the output context. It must run
+// before the header is written, which is when fragmented MP4, MPEG-TS and
+// the segmenting muxers write their metadata.
+func (e *Encoder) addMetadata() error {
+	return e.setTags(e.config.Metadata)
+}
+
+// AddMetadata sets tag on the output after Initialize, for figures known
+// only once the encode is done. A file MP4 writes its udta atoms with the
+// trailer, so a tag added before Close reaches it; the other outputs wrote
+// their metadata with the header and the tag is dropped.
+func (e *Encoder) AddMetadata(tag Tag) error {
+	if !e.trailerMetadata {
+		return nil
+	}
+	return e.setTags([]Tag{tag})
+}
+
+// setTags sets tags on the output context. The dictionary belongs to the
+// context and is freed with it.
+func (e *Encoder) setTags(tags []Tag) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	meta := e.formatCtx.Metadata()
+	for _, tag := range tags {
+		if tag.Value == "" {
+			continue
+		}
+		if err := ffmpegutil.DictSet(&meta, tag.Key, tag.Value); err != nil {
+			return fmt.Errorf("metadata: %w", err)
+		}
+	}
+	e.formatCtx.SetMetadata(meta)
+	return nil
+}
@@ -0,0 +1,64 @@
+package encoder
+
+import (
+	"fmt"
+	"strings"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
+)
+
+// Option is an FFmpeg AVOption for the video encoder, passed through from
+// --encoder-opts for settings Jivefire does not model itself.
+type Option struct {
+	Key   string
+	Value string
+}
+
+// ParseOptions parses an --encoder-opts value: key=value pairs separated by
+// commas, such as "g=60,x264-params=aq-mode=3:aq-strength=0.8". Only the
+// first = of a pair splits it, so values may hold their own. An empty string
+// returns no options.
+func ParseOptions(s string) ([]Option, error) {
+	var opts []Option
+	for pair := range strings.SplitSeq(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("option %q is not key=value", pair)
+		}
+		opts = append(opts, Option{Key: key, Value: strings.TrimSpace(value)})
+	}
+	return opts, nil
+}
+
+// setUserOptions adds Config.Options to opts, replacing any of Jivefire's
+// own settings with the same key.
+func (e *Encoder) setUserOptions(opts **ffmpeg.AVDictionary) {
+	for _, o := range e.config.Options {
+		_ = ffmpegutil.DictSet(opts, o.Key, o.Value)
+	}
+}
+
+// recordUnusedOptions notes the Config.Options the codec left in opts when
+// it opened, which it did not recognise.
+func (e *Encoder) recordUnusedOptions(opts *ffmpeg.AVDictionary) {
+	e.unusedOptions = e.unusedOptions[:0]
+	for _, o := range e.config.Options {
+		if ffmpegutil.DictHas(opts, o.Key) {
+			e.unusedOptions = append(e.unusedOptions, o.Key)
+		}
+	}
+}
+
+// UnusedOptions returns the keys of Config.Options the video encoder did not
+// recognise, as of its last opening (a mid-run fallback to libx264 opens it
+// again). FFmpeg ignores them, so they are worth a warning rather than an
+// error.
+func (e *Encoder) UnusedOptions() []string {
+	return e.unusedOptions
+}
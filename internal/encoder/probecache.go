@@ -0,0 +1,212 @@
+package encoder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+)
+
+var (
+	// ProbeCachePath is the file hardware probe results are kept in between
+	// runs, jivefire/hwprobe.json in the user cache directory. Results are
+	// keyed by a fingerprint of the GPU devices, drivers and FFmpeg build, so
+	// a new card or driver is probed afresh. Empty disables the cache.
+	ProbeCachePath = defaultProbeCachePath()
+
+	// ProbeCacheRefresh ignores cached results and probes again, still
+	// caching what it finds (--no-probe-cache).
+	ProbeCacheRefresh bool
+)
+
+// probeCacheVersion changes whenever probing changes what it reports, so
+// results from an older Jivefire are not trusted.
+const probeCacheVersion = 1
+
+// probeCacheMaxAge bounds how long a result is trusted. Device nodes are
+// recreated at boot, which already changes the fingerprint; this catches
+// driver updates that need no reboot.
+const probeCacheMaxAge = 7 * 24 * time.Hour
+
+// probeFingerprintEnv are the variables that steer which driver or GPU
+// FFmpeg's hardware backends pick up.
+var probeFingerprintEnv = []string{"LIBVA_DRIVER_NAME", "LIBVA_DRIVERS_PATH", "CUDA_VISIBLE_DEVICES", "VK_ICD_FILENAMES", "VK_DRIVER_FILES"}
+
+type probeCacheFile struct {
+	Version int                        `json:"version"`
+	Entries map[string]probeCacheEntry `json:"entries"`
+}
+
+type probeCacheEntry struct {
+	Probed    time.Time       `json:"probed"`
+	Available map[string]bool `json:"available"` // by encoder name
+}
+
+// probeMemo keeps each device's results for the rest of the process, so the
+// check before rendering and the encoder's own selection probe once.
+var probeMemo struct {
+	sync.Mutex
+	results map[string][]HWEncoder
+}
+
+func defaultProbeCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "jivefire", "hwprobe.json")
+}
+
+// cachedProbe returns the encoders in priority on device from this process's
+// earlier probe, the cache file or, failing both, probe. A cache that cannot
+// be read or written only costs a fresh probe.
+func cachedProbe(device string, priority []encoderSpec, probe func(string, []encoderSpec) []HWEncoder) []HWEncoder {
+	probeMemo.Lock()
+	defer probeMemo.Unlock()
+	if encoders, ok := probeMemo.results[device]; ok {
+		return slices.Clone(encoders)
+	}
+
+	key := probeFingerprint(device)
+	var encoders []HWEncoder
+	if !ProbeCacheRefresh {
+		encoders = readProbeCache(ProbeCachePath, key, priority, time.Now())
+	}
+	if encoders == nil {
+		encoders = probe(device, priority)
+		_ = writeProbeCache(ProbeCachePath, key, encoders, time.Now())
+	}
+
+	if probeMemo.results == nil {
+		probeMemo.results = make(map[string][]HWEncoder)
+	}
+	probeMemo.results[device] = encoders
+	return slices.Clone(encoders)
+}
+
+// probeFingerprint identifies what a probe of device depends on: the
+// platform, the FFmpeg build, the DRM nodes present (and when they appeared),
+// the NVIDIA driver version and the environment steering driver choice.
+func probeFingerprint(device string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%s/%s\n%s\n%q\n", probeCacheVersion, runtime.GOOS, runtime.GOARCH, ffmpeg.AVVersionInfo().String(), device)
+	if entries, err := os.ReadDir("/dev/dri"); err == nil {
+		for _, entry := range entries {
+			if info, err := entry.Info(); err == nil {
+				fmt.Fprintf(h, "%s %d\n", entry.Name(), info.ModTime().UnixNano())
+			}
+		}
+	}
+	if f, err := os.Open("/proc/driver/nvidia/version"); err == nil {
+		_, _ = io.Copy(h, f)
+		f.Close()
+	}
+	for _, name := range probeFingerprintEnv {
+		fmt.Fprintf(h, "%s=%s\n", name, os.Getenv(name))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readProbeCache returns the cached encoders for key, or nil when the cache
+// has no current result covering every encoder in priority.
+func readProbeCache(path, key string, priority []encoderSpec, now time.Time) []HWEncoder {
+	cache, err := loadProbeCache(path)
+	if err != nil {
+		return nil
+	}
+	entry, ok := cache.Entries[key]
+	if !ok || now.Sub(entry.Probed) > probeCacheMaxAge || entry.Probed.After(now) {
+		return nil
+	}
+
+	encoders := make([]HWEncoder, 0, len(priority))
+	for _, enc := range priority {
+		available, ok := entry.Available[enc.name]
+		if !ok {
+			return nil
+		}
+		encoders = append(encoders, HWEncoder{
+			Name:        enc.name,
+			Type:        enc.accelType,
+			Codec:       enc.codec,
+			DeviceType:  enc.deviceType,
+			Description: enc.desc,
+			Available:   available,
+		})
+	}
+	return encoders
+}
+
+// writeProbeCache records encoders under key, dropping expired entries. The
+// file is replaced by a rename, so a concurrent run never reads half of it.
+func writeProbeCache(path, key string, encoders []HWEncoder, now time.Time) error {
+	if path == "" {
+		return nil
+	}
+	cache, err := loadProbeCache(path)
+	if err != nil {
+		cache = &probeCacheFile{}
+	}
+	cache.Version = probeCacheVersion
+	if cache.Entries == nil {
+		cache.Entries = make(map[string]probeCacheEntry)
+	}
+	for k, entry := range cache.Entries {
+		if now.Sub(entry.Probed) > probeCacheMaxAge {
+			delete(cache.Entries, k)
+		}
+	}
+	entry := probeCacheEntry{Probed: now, Available: make(map[string]bool, len(encoders))}
+	for _, enc := range encoders {
+		entry.Available[enc.Name] = enc.Available
+	}
+	cache.Entries[key] = entry
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".hwprobe-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func loadProbeCache(path string) (*probeCacheFile, error) {
+	if path == "" {
+		return nil, os.ErrNotExist
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cache probeCacheFile
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+	if cache.Version != probeCacheVersion {
+		return nil, fmt.Errorf("probe cache version %d, want %d", cache.Version, probeCacheVersion)
+	}
+	return &cache, nil
+}
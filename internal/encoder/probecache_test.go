@@ -0,0 +1,125 @@
+package encoder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeProbe stands in for probing real hardware, marking the first encoder
+// available and counting its calls.
+type fakeProbe struct{ calls int }
+
+func (f *fakeProbe) probe(device string, priority []encoderSpec) []HWEncoder {
+	f.calls++
+	encoders := make([]HWEncoder, len(priority))
+	for i, enc := range priority {
+		encoders[i] = HWEncoder{Name: enc.name, Type: enc.accelType, Codec: enc.codec, DeviceType: enc.deviceType, Description: enc.desc, Available: i == 0}
+	}
+	return encoders
+}
+
+// useProbeCache points the cache at a temporary file and forgets this
+// process's earlier probes, restoring both afterwards.
+func useProbeCache(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jivefire", "hwprobe.json")
+	oldPath, oldRefresh := ProbeCachePath, ProbeCacheRefresh
+	ProbeCachePath, ProbeCacheRefresh = path, false
+	forgetProbes()
+	t.Cleanup(func() {
+		ProbeCachePath, ProbeCacheRefresh = oldPath, oldRefresh
+		forgetProbes()
+	})
+	return path
+}
+
+func forgetProbes() {
+	probeMemo.Lock()
+	probeMemo.results = nil
+	probeMemo.Unlock()
+}
+
+func TestProbeCache(t *testing.T) {
+	path := useProbeCache(t)
+	var f fakeProbe
+
+	first := cachedProbe("", linuxEncoderPriority, f.probe)
+	if f.calls != 1 || len(first) != len(linuxEncoderPriority) || !first[0].Available || first[1].Available {
+		t.Fatalf("first probe: %d calls, %+v", f.calls, first)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+
+	// The same process does not probe again.
+	cachedProbe("", linuxEncoderPriority, f.probe)
+	if f.calls != 1 {
+		t.Errorf("second call in process probed again (%d calls)", f.calls)
+	}
+
+	// Nor does the next run, reading the file.
+	forgetProbes()
+	cached := cachedProbe("", linuxEncoderPriority, f.probe)
+	if f.calls != 1 {
+		t.Errorf("next run probed despite the cache (%d calls)", f.calls)
+	}
+	for i := range cached {
+		if cached[i] != first[i] {
+			t.Errorf("cached encoder %d = %+v, probed %+v", i, cached[i], first[i])
+		}
+	}
+
+	// Another device has its own entry.
+	cachedProbe("/dev/dri/renderD129", linuxEncoderPriority, f.probe)
+	if f.calls != 2 {
+		t.Errorf("new device: %d calls, want 2", f.calls)
+	}
+
+	// --no-probe-cache probes afresh.
+	forgetProbes()
+	ProbeCacheRefresh = true
+	cachedProbe("", linuxEncoderPriority, f.probe)
+	if f.calls != 3 {
+		t.Errorf("refresh: %d calls, want 3", f.calls)
+	}
+}
+
+func TestProbeCacheRejects(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "hwprobe.json")
+	var f fakeProbe
+	encoders := f.probe("", linuxEncoderPriority)
+	if err := writeProbeCache(path, "key", encoders, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readProbeCache(path, "key", linuxEncoderPriority, now); len(got) != len(linuxEncoderPriority) {
+		t.Fatalf("fresh entry not read: %+v", got)
+	}
+	if got := readProbeCache(path, "other", linuxEncoderPriority, now); got != nil {
+		t.Errorf("another fingerprint read %+v", got)
+	}
+	if got := readProbeCache(path, "key", linuxEncoderPriority, now.Add(probeCacheMaxAge+time.Hour)); got != nil {
+		t.Errorf("expired entry read %+v", got)
+	}
+	more := append(append([]encoderSpec(nil), linuxEncoderPriority...), encoderSpec{name: "h264_new"})
+	if got := readProbeCache(path, "key", more, now); got != nil {
+		t.Errorf("entry missing an encoder read %+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := readProbeCache(path, "key", linuxEncoderPriority, now); got != nil {
+		t.Errorf("corrupt cache read %+v", got)
+	}
+	// Writing over a corrupt cache replaces it.
+	if err := writeProbeCache(path, "key", encoders, now); err != nil {
+		t.Fatal(err)
+	}
+	if got := readProbeCache(path, "key", linuxEncoderPriority, now); got == nil {
+		t.Error("rewritten cache not read")
+	}
+}
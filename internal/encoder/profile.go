@@ -0,0 +1,98 @@
+package encoder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profile names a rate-control preset for an upload target.
+type Profile string
+
+const (
+	ProfileFast    Profile = "fast"    // Quick constant-quality encode tuned for speed (default)
+	ProfileYouTube Profile = "youtube" // Capped VBR at YouTube's recommended 720p30 bitrate
+	ProfileArchive Profile = "archive" // High quality, uncapped, slower preset
+	ProfileSmall   Profile = "small"   // Smallest files: lower quality, tight cap, slowest preset
+)
+
+// ParseProfile validates a --profile value.
+func ParseProfile(s string) (Profile, error) {
+	switch p := Profile(strings.ToLower(s)); p {
+	case ProfileFast, ProfileYouTube, ProfileArchive, ProfileSmall:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown profile %q (must be fast, youtube, archive or small)", s)
+}
+
+// rateControl holds the settings a profile maps onto each encoder's options.
+//
+// Quality-driven encoders (libx264 CRF, NVENC CQ, QSV ICQ, VA-API and Vulkan
+// QP) use quality, capped by maxRate when it is set. Encoders that only
+// support bitrate targets (VideoToolbox, and QSV, VA-API and Vulkan when
+// capped) aim for bitRate instead.
+//
+// Frames are rendered once and streamed into the encoder, so a true two-pass
+// encode would render the whole episode twice. The small profile gets its
+// size from CRF with a slow preset and a tight VBV cap instead.
+type rateControl struct {
+	quality     int    // CRF/CQ/QP, lower is better (0-51)
+	x264Preset  string // libx264 preset
+	nvencPreset string // NVENC preset, p1 (fastest) to p7 (slowest)
+	h264Profile string // H.264 profile
+	bitRate     int64  // Average bitrate for bitrate-driven encoders, bits/s (0 for the encoder default)
+	maxRate     int64  // VBV peak bitrate cap, bits/s (0 for uncapped)
+	bufSize     int64  // VBV buffer size, bits (0 for uncapped)
+	speedTweaks bool   // Single reference and B-frame with fast subpixel search
+}
+
+// capped reports whether the profile limits peak bitrate.
+func (rc rateControl) capped() bool {
+	return rc.maxRate > 0
+}
+
+// rateControls maps each profile to its settings. YouTube recommends 5 Mbps
+// for 720p at 24-30 fps, with High profile; the buffer allows two seconds at
+// the cap.
+var rateControls = map[Profile]rateControl{
+	ProfileFast: {
+		quality:     24,
+		x264Preset:  "veryfast",
+		nvencPreset: "p1",
+		h264Profile: "main",
+		speedTweaks: true,
+	},
+	ProfileYouTube: {
+		quality:     21,
+		x264Preset:  "fast",
+		nvencPreset: "p4",
+		h264Profile: "high",
+		bitRate:     4_000_000,
+		maxRate:     5_000_000,
+		bufSize:     10_000_000,
+	},
+	ProfileArchive: {
+		quality:     18,
+		x264Preset:  "slow",
+		nvencPreset: "p6",
+		h264Profile: "high",
+		bitRate:     8_000_000,
+	},
+	ProfileSmall: {
+		quality:     28,
+		x264Preset:  "slower",
+		nvencPreset: "p7",
+		h264Profile: "high",
+		bitRate:     1_000_000,
+		maxRate:     1_500_000,
+		bufSize:     3_000_000,
+	},
+}
+
+// rateControlFor returns the settings for p, falling back to the fast profile
+// when p is empty or unknown.
+func rateControlFor(p Profile) rateControl {
+	if rc, ok := rateControls[p]; ok {
+		return rc
+	}
+	return rateControls[ProfileFast]
+}
@@ -0,0 +1,128 @@
+package encoder
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestParseProfile verifies profile names parse case-blind and unknown ones
+// fail.
+func TestParseProfile(t *testing.T) {
+	if got, err := ParseProfile("YouTube"); err != nil || got != ProfileYouTube {
+		t.Errorf("ParseProfile(YouTube) = %q, %v; want youtube", got, err)
+	}
+	if _, err := ParseProfile("broadcast"); err == nil {
+		t.Error("ParseProfile(broadcast) succeeded, want error")
+	}
+}
+
+// TestRateControls verifies every profile has usable settings: a quality in
+// H.264's range, presets for libx264 and NVENC, and a buffer whenever the
+// bitrate is capped.
+func TestRateControls(t *testing.T) {
+	for _, p := range []Profile{ProfileFast, ProfileYouTube, ProfileArchive, ProfileSmall} {
+		rc, ok := rateControls[p]
+		if !ok {
+			t.Errorf("profile %q has no rate control", p)
+			continue
+		}
+		if rc.quality < 0 || rc.quality > 51 {
+			t.Errorf("%s: quality %d outside 0-51", p, rc.quality)
+		}
+		if rc.x264Preset == "" || rc.nvencPreset == "" || rc.h264Profile == "" {
+			t.Errorf("%s: missing preset or H.264 profile", p)
+		}
+		if rc.capped() && (rc.bufSize <= 0 || rc.bitRate <= 0 || rc.bitRate > rc.maxRate) {
+			t.Errorf("%s: capped at %d but bitrate %d, buffer %d", p, rc.maxRate, rc.bitRate, rc.bufSize)
+		}
+	}
+
+	if rc := rateControlFor(""); rc != rateControls[ProfileFast] {
+		t.Error("empty profile does not fall back to fast")
+	}
+	if yt := rateControlFor(ProfileYouTube); yt.maxRate != 5_000_000 {
+		t.Errorf("youtube cap = %d, want YouTube's 5 Mbps for 720p", yt.maxRate)
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	opts, err := ParseOptions(" g=60, x264-params=aq-mode=3:aq-strength=0.8 ,,tune=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Option{{"g", "60"}, {"x264-params", "aq-mode=3:aq-strength=0.8"}, {"tune", ""}}
+	if !slices.Equal(opts, want) {
+		t.Errorf("ParseOptions = %v, want %v", opts, want)
+	}
+	if opts, err := ParseOptions(""); err != nil || opts != nil {
+		t.Errorf("ParseOptions(\"\") = %v, %v; want none", opts, err)
+	}
+	for _, s := range []string{"g", "g=60,bf", "=1"} {
+		if _, err := ParseOptions(s); err == nil {
+			t.Errorf("ParseOptions(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestParseVideoCodec(t *testing.T) {
+	if got, err := ParseVideoCodec("AV1"); err != nil || got != CodecAV1 {
+		t.Errorf("ParseVideoCodec(AV1) = %q, %v; want av1", got, err)
+	}
+	if _, err := ParseVideoCodec("hevc"); err == nil {
+		t.Error("ParseVideoCodec(hevc) succeeded, want error")
+	}
+}
+
+func TestParseAudioCodec(t *testing.T) {
+	if got, err := ParseAudioCodec("Opus"); err != nil || got != AudioOpus {
+		t.Errorf("ParseAudioCodec(Opus) = %q, %v; want opus", got, err)
+	}
+	if _, err := ParseAudioCodec("vorbis"); err == nil {
+		t.Error("ParseAudioCodec(vorbis) succeeded, want error")
+	}
+	if got := AudioOpus.SampleRate(44100); got != 48000 {
+		t.Errorf("Opus SampleRate(44100) = %d, want 48000", got)
+	}
+	if got, err := ParseAudioCodec("flac"); err != nil || got != AudioFLAC {
+		t.Errorf("ParseAudioCodec(flac) = %q, %v; want flac", got, err)
+	}
+	if got := AudioFLAC.SampleRate(96000); got != 96000 {
+		t.Errorf("FLAC SampleRate(96000) = %d, want 96000", got)
+	}
+	if got := AudioAAC.SampleRate(44100); got != 44100 {
+		t.Errorf("AAC SampleRate(44100) = %d, want 44100", got)
+	}
+}
+
+// TestAV1Quality verifies every profile's quality maps inside the AV1
+// encoders' ranges and keeps the profiles in order.
+func TestAV1Quality(t *testing.T) {
+	if got := av1Quality(rateControls[ProfileFast].quality); got != 30 {
+		t.Errorf("fast profile: AV1 CQ %d, want 30", got)
+	}
+	if av1Quality(0) != 0 || av1Quality(51) != 63 || av1QIndex(51) != 252 {
+		t.Errorf("scale ends map to %d-%d, qindex %d; want 0-63, 252", av1Quality(0), av1Quality(51), av1QIndex(51))
+	}
+	order := []Profile{ProfileArchive, ProfileYouTube, ProfileFast, ProfileSmall}
+	for i := 1; i < len(order); i++ {
+		prev, cur := rateControls[order[i-1]].quality, rateControls[order[i]].quality
+		if av1Quality(prev) >= av1Quality(cur) {
+			t.Errorf("%s (%d) does not map below %s (%d)", order[i-1], av1Quality(prev), order[i], av1Quality(cur))
+		}
+	}
+}
+
+// TestMFQuality verifies the Media Foundation quality runs the other way to
+// the profiles' scale, over 0-100, keeping the profiles in order.
+func TestMFQuality(t *testing.T) {
+	if mfQuality(0) != 100 || mfQuality(51) != 0 {
+		t.Errorf("scale ends map to %d and %d, want 100 and 0", mfQuality(0), mfQuality(51))
+	}
+	order := []Profile{ProfileArchive, ProfileYouTube, ProfileFast, ProfileSmall}
+	for i := 1; i < len(order); i++ {
+		prev, cur := rateControls[order[i-1]].quality, rateControls[order[i]].quality
+		if mfQuality(prev) <= mfQuality(cur) {
+			t.Errorf("%s (%d) does not map above %s (%d)", order[i-1], mfQuality(prev), order[i], mfQuality(cur))
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package encoder
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaxQueue is the deepest Queue allowed; every queued frame holds a copy of
+// the picture, about 3.7 MB at 720p.
+const MaxQueue = 64
+
+// frameEncoder is the part of Encoder a Queue drives.
+type frameEncoder interface {
+	WriteFrameRGBARows(rgbaData []byte, startY, endY int) error
+	WriteAudioSamples(samples []float32) error
+	EncoderName() string
+	Stats() Stats
+}
+
+// Queue hands frames and audio to an Encoder on a goroutine of its own, so
+// the render keeps drawing while the encoder converts and sends earlier
+// frames, and a disk or GPU hiccup holds it up only once capacity frames
+// are waiting. Each queued frame is a copy from a fixed pool, so memory
+// stays bounded however far the encoder falls behind.
+//
+// The writes keep the order they were made in and never return an error:
+// the first failure stops the encoder taking more, and is reported by Err
+// from then on and by Close. A capacity of 0 writes straight to the encoder,
+// as if there were no queue.
+//
+// Writes and Waited are for one goroutine, the render loop; Depth,
+// EncoderName and Stats may be read from any.
+type Queue struct {
+	enc      frameEncoder
+	rowBytes int
+	height   int
+	capacity int
+
+	jobs   chan queueJob
+	frames chan *queuedFrame // free frame buffers
+	audio  chan []float32    // spare audio buffers
+	done   chan struct{}     // closed when the worker exits
+	bufs   []*queuedFrame    // every frame buffer, for their stale rows
+	once   sync.Once
+
+	depth   atomic.Int32          // frames queued and not yet encoded
+	name    atomic.Value          // the encoder's name as of its last frame
+	stats   atomic.Pointer[Stats] // the encoder's stats as of its last frame
+	written int                   // frames the encoder has been given
+	queued  int                   // frames the render loop has written
+
+	mu     sync.Mutex
+	err    error // the first failure
+	failed atomic.Bool
+
+	waited  time.Duration // time the writes blocked on a full queue
+	longest time.Duration // the longest single block
+}
+
+// queuedFrame is a pooled copy of a frame. Only the render loop reads or
+// sets its stale rows: those that have changed since pix was filled.
+type queuedFrame struct {
+	pix                  []byte
+	staleStart, staleEnd int
+	startY, endY         int // the rows the frame changed, as written
+}
+
+// queueJob is a frame or a block of audio for the worker.
+type queueJob struct {
+	frame *queuedFrame
+	audio []float32
+}
+
+// NewQueue returns a queue of up to capacity frames in front of enc, which
+// must be initialised, and starts its worker. Close it before the encoder's
+// last frame count, flush or close.
+func NewQueue(enc *Encoder, capacity int) *Queue {
+	return newQueue(enc, enc.config.Width, enc.config.Height, capacity)
+}
+
+func newQueue(enc frameEncoder, width, height, capacity int) *Queue {
+	q := &Queue{
+		enc:      enc,
+		rowBytes: width * 4,
+		height:   height,
+		capacity: max(min(capacity, MaxQueue), 0),
+	}
+	q.name.Store(enc.EncoderName())
+	q.storeStats()
+	if q.capacity == 0 {
+		return q
+	}
+
+	// A frame and its audio go through one job each, and an audio buffer is
+	// back in the pool once the worker has written it.
+	q.jobs = make(chan queueJob, 2*q.capacity)
+	q.frames = make(chan *queuedFrame, q.capacity)
+	q.audio = make(chan []float32, 2*q.capacity+1)
+	q.done = make(chan struct{})
+	for range q.capacity {
+		f := &queuedFrame{staleEnd: height}
+		q.bufs = append(q.bufs, f)
+		q.frames <- f
+	}
+	for range 2*q.capacity + 1 {
+		q.audio <- nil
+	}
+
+	go q.run()
+	return q
+}
+
+// run writes the queued jobs to the encoder until the queue closes. After
+// a failure the jobs are recycled unwritten.
+func (q *Queue) run() {
+	defer close(q.done)
+	for job := range q.jobs {
+		if job.frame != nil {
+			q.writeFrame(job.frame.pix, job.frame.startY, job.frame.endY)
+			q.depth.Add(-1)
+			q.frames <- job.frame
+			continue
+		}
+		q.writeAudio(job.audio)
+		q.audio <- job.audio
+	}
+}
+
+func (q *Queue) writeFrame(rgbaData []byte, startY, endY int) {
+	if q.failed.Load() {
+		return
+	}
+	if err := q.enc.WriteFrameRGBARows(rgbaData, startY, endY); err != nil {
+		q.fail(fmt.Errorf("frame %d: %w", q.written, err))
+	}
+	q.written++
+	q.name.Store(q.enc.EncoderName())
+	q.storeStats()
+}
+
+func (q *Queue) storeStats() {
+	stats := q.enc.Stats()
+	q.stats.Store(&stats)
+}
+
+func (q *Queue) writeAudio(samples []float32) {
+	if q.failed.Load() {
+		return
+	}
+	if err := q.enc.WriteAudioSamples(samples); err != nil {
+		q.fail(fmt.Errorf("audio: %w", err))
+	}
+}
+
+func (q *Queue) fail(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err == nil {
+		q.err = err
+		q.failed.Store(true)
+	}
+}
+
+// WriteFrameRGBA queues a whole frame; see Encoder.WriteFrameRGBA.
+func (q *Queue) WriteFrameRGBA(rgbaData []byte) {
+	q.WriteFrameRGBARows(rgbaData, 0, q.height)
+}
+
+// WriteFrameRGBARows queues a frame that matches the previous one outside
+// rows [startY, endY); see Encoder.WriteFrameRGBARows. A pooled copy only
+// needs the rows that have changed since it last held a frame.
+func (q *Queue) WriteFrameRGBARows(rgbaData []byte, startY, endY int) {
+	if q.failed.Load() {
+		return
+	}
+	if q.capacity == 0 {
+		q.writeFrame(rgbaData, startY, endY)
+		return
+	}
+	if len(rgbaData) != q.rowBytes*q.height {
+		q.fail(fmt.Errorf("frame %d: invalid RGBA frame size: got %d, expected %d", q.queued, len(rgbaData), q.rowBytes*q.height))
+		return
+	}
+
+	startY, endY = max(startY, 0), min(endY, q.height)
+	f := q.takeFrame()
+	for _, b := range q.bufs {
+		b.markStale(startY, endY)
+	}
+	if f.pix == nil {
+		f.pix = make([]byte, len(rgbaData))
+	}
+	copy(f.pix[f.staleStart*q.rowBytes:f.staleEnd*q.rowBytes], rgbaData[f.staleStart*q.rowBytes:f.staleEnd*q.rowBytes])
+	f.staleStart, f.staleEnd = 0, 0
+	f.startY, f.endY = startY, endY
+
+	q.queued++
+	q.depth.Add(1)
+	q.send(queueJob{frame: f})
+}
+
+// markStale adds rows [startY, endY) to the frame's stale rows. The rows
+// are kept as a single span, covering any unchanged rows between.
+func (f *queuedFrame) markStale(startY, endY int) {
+	switch {
+	case startY >= endY:
+	case f.staleStart >= f.staleEnd:
+		f.staleStart, f.staleEnd = startY, endY
+	default:
+		f.staleStart, f.staleEnd = min(f.staleStart, startY), max(f.staleEnd, endY)
+	}
+}
+
+// WriteAudioSamples queues a copy of samples; see Encoder.WriteAudioSamples.
+func (q *Queue) WriteAudioSamples(samples []float32) {
+	if q.failed.Load() {
+		return
+	}
+	if q.capacity == 0 {
+		q.writeAudio(samples)
+		return
+	}
+	var buf []float32
+	select {
+	case buf = <-q.audio:
+	default:
+		q.wait(func() { buf = <-q.audio })
+	}
+	q.send(queueJob{audio: append(slices.Grow(buf[:0], len(samples)), samples...)})
+}
+
+// takeFrame returns a free frame buffer, waiting for the encoder to finish
+// with one if they are all queued.
+func (q *Queue) takeFrame() *queuedFrame {
+	select {
+	case f := <-q.frames:
+		return f
+	default:
+	}
+	var f *queuedFrame
+	q.wait(func() { f = <-q.frames })
+	return f
+}
+
+// send queues job, waiting for room if need be.
+func (q *Queue) send(job queueJob) {
+	select {
+	case q.jobs <- job:
+		return
+	default:
+	}
+	q.wait(func() { q.jobs <- job })
+}
+
+// wait runs a blocking step of a write, adding the time it took to the
+// time waited.
+func (q *Queue) wait(step func()) {
+	t0 := time.Now()
+	step()
+	d := time.Since(t0)
+	q.waited += d
+	q.longest = max(q.longest, d)
+}
+
+// Depth returns how many frames are queued and not yet encoded.
+func (q *Queue) Depth() int {
+	return int(q.depth.Load())
+}
+
+// Capacity returns how many frames the queue holds before the writes wait;
+// 0 for a queue that writes straight to the encoder.
+func (q *Queue) Capacity() int {
+	return q.capacity
+}
+
+// Waited returns how long the writes have waited for the encoder in all,
+// and the longest single wait. An encoder slower than the render keeps the
+// queue full and waits on every frame; a long single wait is a stall.
+func (q *Queue) Waited() (total, longest time.Duration) {
+	return q.waited, q.longest
+}
+
+// EncoderName returns the name of the video encoder as of the last frame it
+// encoded; see Encoder.EncoderName. Unlike the encoder's own, it is safe to
+// call while frames are being encoded.
+func (q *Queue) EncoderName() string {
+	return q.name.Load().(string)
+}
+
+// Stats returns the encoder's stats as of the last frame it encoded; see
+// Encoder.Stats. Unlike the encoder's own, it is safe to call while frames
+// are being encoded.
+func (q *Queue) Stats() Stats {
+	return *q.stats.Load()
+}
+
+// Err returns the first failure of the encoder, or nil.
+func (q *Queue) Err() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.err
+}
+
+// Close waits for the encoder to take everything queued, stops the worker
+// and returns Err. Nothing may be written after it. Safe to call more than
+// once.
+func (q *Queue) Close() error {
+	q.once.Do(func() {
+		if q.jobs != nil {
+			close(q.jobs)
+			<-q.done
+		}
+	})
+	return q.Err()
+}
@@ -0,0 +1,169 @@
+package encoder
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeFrameEncoder records what a Queue writes, keeping an input frame the
+// way Encoder does: only the rows in a frame's span are taken, except for
+// the first frame, which is taken whole.
+type fakeFrameEncoder struct {
+	width, height int
+	input         []byte
+	frames        [][]byte // the input after each frame
+	audio         []float32
+	gate          chan struct{} // each frame waits for a receive, when set
+	failAt        int           // the frame to fail at, from 1; 0 for none
+}
+
+func (f *fakeFrameEncoder) WriteFrameRGBARows(rgbaData []byte, startY, endY int) error {
+	if f.gate != nil {
+		<-f.gate
+	}
+	if len(f.frames)+1 == f.failAt {
+		return errors.New("device lost")
+	}
+	if f.input == nil {
+		f.input = make([]byte, len(rgbaData))
+		startY, endY = 0, f.height
+	}
+	row := f.width * 4
+	copy(f.input[startY*row:endY*row], rgbaData[startY*row:endY*row])
+	f.frames = append(f.frames, slices.Clone(f.input))
+	return nil
+}
+
+func (f *fakeFrameEncoder) WriteAudioSamples(samples []float32) error {
+	f.audio = append(f.audio, samples...)
+	return nil
+}
+
+func (f *fakeFrameEncoder) EncoderName() string { return "fake" }
+
+// Stats counts the first frame as I and the rest as P.
+func (f *fakeFrameEncoder) Stats() Stats {
+	return Stats{I: min(len(f.frames), 1), P: max(len(f.frames)-1, 0)}
+}
+
+// TestQueueFrames verifies queued frames reach the encoder whole and in
+// order, though each write reuses the same source buffer and changes only
+// some rows, and the audio keeps its place between them.
+func TestQueueFrames(t *testing.T) {
+	const width, height = 4, 8
+	for _, capacity := range []int{0, 1, 3} {
+		enc := &fakeFrameEncoder{width: width, height: height}
+		q := newQueue(enc, width, height, capacity)
+
+		src := make([]byte, width*height*4)
+		var want [][]byte
+		for i := range 10 {
+			// Each frame redraws a different band of rows in place.
+			startY, endY := i%height, min(i%height+3, height)
+			for y := startY; y < endY; y++ {
+				for x := range width * 4 {
+					src[y*width*4+x] = byte(i + 1)
+				}
+			}
+			q.WriteAudioSamples([]float32{float32(i)})
+			if i == 0 {
+				q.WriteFrameRGBA(src)
+			} else {
+				q.WriteFrameRGBARows(src, startY, endY)
+			}
+			want = append(want, slices.Clone(src))
+		}
+		if err := q.Close(); err != nil {
+			t.Fatalf("capacity %d: Close: %v", capacity, err)
+		}
+
+		if len(enc.frames) != len(want) {
+			t.Fatalf("capacity %d: encoder got %d frames, want %d", capacity, len(enc.frames), len(want))
+		}
+		for i := range want {
+			if !bytes.Equal(enc.frames[i], want[i]) {
+				t.Errorf("capacity %d: frame %d differs from the one written", capacity, i)
+			}
+		}
+		if !slices.Equal(enc.audio, []float32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+			t.Errorf("capacity %d: audio %v, want 0-9 in order", capacity, enc.audio)
+		}
+		if got := q.Stats(); got != (Stats{I: 1, P: 9}) {
+			t.Errorf("capacity %d: Stats() = %+v, want the stats after the last frame", capacity, got)
+		}
+	}
+}
+
+// TestQueueBackpressure verifies a stalled encoder holds the writes up once
+// the queue is full, and the depth reports the frames waiting.
+func TestQueueBackpressure(t *testing.T) {
+	const width, height, capacity = 2, 2, 2
+	enc := &fakeFrameEncoder{width: width, height: height, gate: make(chan struct{})}
+	q := newQueue(enc, width, height, capacity)
+	frame := make([]byte, width*height*4)
+
+	// The worker holds the first frame at the gate and the queue takes one
+	// more; a third write has no free buffer until the gate opens.
+	q.WriteFrameRGBA(frame)
+	q.WriteFrameRGBA(frame)
+	if depth := q.Depth(); depth != capacity {
+		t.Errorf("stalled encoder: depth %d, want %d", depth, capacity)
+	}
+	wrote := make(chan struct{})
+	go func() {
+		q.WriteFrameRGBA(frame)
+		close(wrote)
+	}()
+	select {
+	case <-wrote:
+		t.Fatal("a write got past a full queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	enc.gate <- struct{}{}
+	<-wrote
+	close(enc.gate)
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if total, longest := q.Waited(); total <= 0 || longest <= 0 {
+		t.Errorf("waited %v, longest %v: want the blocked write counted", total, longest)
+	}
+	if depth := q.Depth(); depth != 0 {
+		t.Errorf("closed queue: depth %d, want 0", depth)
+	}
+	if len(enc.frames) != 3 {
+		t.Errorf("encoder got %d frames, want 3", len(enc.frames))
+	}
+}
+
+// TestQueueError verifies the first failure stops the encoder taking more
+// and is reported with the frame it happened at.
+func TestQueueError(t *testing.T) {
+	const width, height = 2, 2
+	enc := &fakeFrameEncoder{width: width, height: height, failAt: 2}
+	q := newQueue(enc, width, height, 4)
+	frame := make([]byte, width*height*4)
+	for range 4 {
+		q.WriteFrameRGBA(frame)
+	}
+	err := q.Close()
+	if err == nil || err.Error() != "frame 1: device lost" {
+		t.Fatalf("Close: %v, want frame 1's failure", err)
+	}
+	if len(enc.frames) != 1 {
+		t.Errorf("encoder took %d frames, want 1 before the failure", len(enc.frames))
+	}
+	if !errors.Is(q.Err(), err) {
+		t.Errorf("Err after Close: %v, want %v", q.Err(), err)
+	}
+
+	q = newQueue(&fakeFrameEncoder{width: width, height: height}, width, height, 4)
+	q.WriteFrameRGBA(make([]byte, 3))
+	if err := q.Close(); err == nil {
+		t.Error("a short frame was queued without error")
+	}
+}
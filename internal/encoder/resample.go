@@ -0,0 +1,110 @@
+package encoder
+
+import (
+	"fmt"
+	"unsafe"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
+)
+
+// audioResampler converts interleaved float32 audio from the source's rate
+// to the encoder's, ahead of the FIFO, for a codec such as Opus that cannot
+// take the source's rate. Like avAudioFIFO it marshals the samples through
+// AVMalloc-backed scratch planes, so no Go memory crosses the C boundary.
+type audioResampler struct {
+	swr      *ffmpeg.SwrContext
+	channels int
+
+	in, out       unsafe.Pointer
+	inCap, outCap int // In float32 elements
+}
+
+// newAudioResampler returns a resampler from inRate to outRate for packed
+// float32 audio in layout.
+func newAudioResampler(layout *ffmpeg.AVChannelLayout, inRate, outRate int) (*audioResampler, error) {
+	r := &audioResampler{channels: layout.NbChannels()}
+	ret, err := ffmpeg.SwrAllocSetOpts2(&r.swr, layout, ffmpeg.AVSampleFmtFlt, outRate, layout, ffmpeg.AVSampleFmtFlt, inRate, 0, nil)
+	if err := ffmpegutil.Check(ret, err, "configure audio resampler"); err != nil {
+		return nil, err
+	}
+	if r.swr == nil {
+		return nil, fmt.Errorf("failed to configure audio resampler")
+	}
+	ret, err = ffmpeg.SwrInit(r.swr)
+	if err := ffmpegutil.Check(ret, err, "initialise audio resampler"); err != nil {
+		r.free()
+		return nil, err
+	}
+	return r, nil
+}
+
+// growPlane (re)allocates the scratch plane *p, of capacity *capacity, to
+// hold at least n float32 elements.
+func growPlane(p *unsafe.Pointer, capacity *int, n int) error {
+	if n <= *capacity && *p != nil {
+		return nil
+	}
+	if *p != nil {
+		ffmpeg.AVFree(*p)
+		*p, *capacity = nil, 0
+	}
+	*p = ffmpeg.AVMalloc(uint64(n) * uint64(unsafe.Sizeof(float32(0))))
+	if *p == nil {
+		return fmt.Errorf("failed to allocate resampler scratch")
+	}
+	*capacity = n
+	return nil
+}
+
+// convert resamples samples and returns those the resampler produced, which
+// lag the input by its filter delay until flush. The result aliases scratch
+// memory and is valid until the next call.
+func (r *audioResampler) convert(samples []float32) ([]float32, error) {
+	nbSamples := len(samples) / r.channels
+	var in []unsafe.Pointer
+	if nbSamples > 0 {
+		if err := growPlane(&r.in, &r.inCap, len(samples)); err != nil {
+			return nil, err
+		}
+		copy(unsafe.Slice((*float32)(r.in), len(samples)), samples)
+		in = []unsafe.Pointer{r.in}
+	}
+
+	outCount, err := ffmpeg.SwrGetOutSamples(r.swr, nbSamples)
+	if err := ffmpegutil.Check(outCount, err, "size resampler output"); err != nil {
+		return nil, err
+	}
+	if outCount == 0 {
+		return nil, nil
+	}
+	if err := growPlane(&r.out, &r.outCap, outCount*r.channels); err != nil {
+		return nil, err
+	}
+	got, err := ffmpeg.SwrConvert(r.swr, []unsafe.Pointer{r.out}, outCount, in, nbSamples)
+	if err := ffmpegutil.Check(got, err, "resample audio"); err != nil {
+		return nil, err
+	}
+	return unsafe.Slice((*float32)(r.out), got*r.channels), nil
+}
+
+// flush returns the samples still held in the resampler's filter.
+func (r *audioResampler) flush() ([]float32, error) {
+	return r.convert(nil)
+}
+
+// free releases the resampler and its scratch planes. Safe to call on a nil
+// receiver or twice.
+func (r *audioResampler) free() {
+	if r == nil {
+		return
+	}
+	ffmpeg.SwrFree(&r.swr)
+	for _, p := range []*unsafe.Pointer{&r.in, &r.out} {
+		if *p != nil {
+			ffmpeg.AVFree(*p)
+			*p = nil
+		}
+	}
+	r.inCap, r.outCap = 0, 0
+}
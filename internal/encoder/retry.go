@@ -0,0 +1,68 @@
+package encoder
+
+import (
+	"fmt"
+	"time"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
+)
+
+// A send that fails with a transient error (see ffmpegutil.Transient) is
+// retried up to Config.Retries times, pausing retryPause and then twice as
+// long each time, up to maxRetryPause, before the failure is returned. A
+// hardware encoder that keeps failing after its retries still falls back to
+// libx264; see Fallback.
+//
+// Packet writes are not retried: FFmpeg's I/O layer keeps a failed write's
+// error and has dropped its buffered bytes by then, so writing again would
+// only hide a damaged file.
+const (
+	retryPause    = 20 * time.Millisecond
+	maxRetryPause = time.Second
+)
+
+// Retry records a transient failure the encoder retried.
+type Retry struct {
+	Audio   bool  // An audio send rather than a video frame
+	Pts     int64 // The frame being sent: a video frame number, or an audio sample
+	Attempt int   // 1 for the first retry of this send
+	Err     error
+}
+
+func (r Retry) String() string {
+	if r.Audio {
+		return fmt.Sprintf("audio at sample %d, retry %d: %v", r.Pts, r.Attempt, r.Err)
+	}
+	return fmt.Sprintf("frame %d, retry %d: %v", r.Pts, r.Attempt, r.Err)
+}
+
+// Retries returns the transient failures retried so far, in order.
+func (e *Encoder) Retries() []Retry {
+	return e.retries
+}
+
+// sendFrame sends frame to codec, retrying a transient failure. A codec
+// that answers EAGAIN wants its packets read first, so drain runs before
+// each retry.
+func (e *Encoder) sendFrame(codec *ffmpeg.AVCodecContext, frame *ffmpeg.AVFrame, op string, drain func() error) error {
+	pause := retryPause
+	for attempt := 1; ; attempt++ {
+		if e.failSend != nil && codec == e.videoCodec {
+			if err := e.failSend(); err != nil {
+				return err
+			}
+		}
+		ret, err := ffmpeg.AVCodecSendFrame(codec, frame)
+		err = ffmpegutil.Check(ret, err, op)
+		if err == nil || !ffmpegutil.Transient(err) || attempt > e.config.Retries {
+			return err
+		}
+		e.retries = append(e.retries, Retry{Audio: codec == e.audioCodec, Pts: frame.Pts(), Attempt: attempt, Err: err})
+		time.Sleep(pause)
+		pause = min(2*pause, maxRetryPause)
+		if err := drain(); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package encoder
+
+import (
+	"encoding/binary"
+	"unsafe"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+)
+
+// qp2Lambda is FFmpeg's FF_QP2LAMBDA: the quality in a packet's
+// AV_PKT_DATA_QUALITY_STATS side data is the quantiser times this.
+const qp2Lambda = 118
+
+// Stats summarises the video packets the encoder has written, for the
+// progress display. Bitrate and QP cover the last second of video; the
+// frame counts, the whole encode.
+type Stats struct {
+	Bitrate float64 // Bits per second
+	QP      float64 // Mean quantiser; 0 when the encoder reports none
+	I, P, B int     // Frames of each type
+}
+
+// packetSample is one packet in the stats window.
+type packetSample struct {
+	bits int
+	qp   float64 // 0 when the encoder reported none
+}
+
+// packetStats accumulates Stats from the packets written, over a window of
+// a second's worth of packets. The zero value counts frames but keeps no
+// window.
+type packetStats struct {
+	window []packetSample
+	next   int
+	counts Stats
+}
+
+func newPacketStats(framerate int) packetStats {
+	return packetStats{window: make([]packetSample, 0, max(framerate, 1))}
+}
+
+// add records a packet of size bytes and picture type pictType, with the
+// quantiser qp or 0 for none.
+func (s *packetStats) add(size int, pictType int, qp float64) {
+	switch pictType {
+	case int(ffmpeg.AVPictureTypeI):
+		s.counts.I++
+	case int(ffmpeg.AVPictureTypeB):
+		s.counts.B++
+	default:
+		s.counts.P++
+	}
+	sample := packetSample{bits: size * 8, qp: qp}
+	switch {
+	case cap(s.window) == 0:
+		return
+	case len(s.window) < cap(s.window):
+		s.window = append(s.window, sample)
+	default:
+		s.window[s.next] = sample
+		s.next = (s.next + 1) % len(s.window)
+	}
+}
+
+// stats returns the summary so far. Until the window fills, the bitrate is
+// scaled up from the packets there are.
+func (s *packetStats) stats() Stats {
+	out := s.counts
+	if len(s.window) == 0 {
+		return out
+	}
+	var bits, qps int
+	var qp float64
+	for _, p := range s.window {
+		bits += p.bits
+		if p.qp > 0 {
+			qp += p.qp
+			qps++
+		}
+	}
+	out.Bitrate = float64(bits) * float64(cap(s.window)) / float64(len(s.window))
+	if qps > 0 {
+		out.QP = qp / float64(qps)
+	}
+	return out
+}
+
+// recordPacket adds pkt, an encoded video packet, to the stats. The picture
+// type and quantiser come from the quality side data the software encoders
+// and most hardware ones attach; without it a key frame counts as I and
+// anything else as P.
+func (e *Encoder) recordPacket(pkt *ffmpeg.AVPacket) {
+	pictType := int(ffmpeg.AVPictureTypeP)
+	if pkt.Flags()&ffmpeg.AVPktFlagKey != 0 {
+		pictType = int(ffmpeg.AVPictureTypeI)
+	}
+	var qp float64
+	var size uint64
+	if data := ffmpeg.AVPacketGetSideData(pkt, ffmpeg.AVPktDataQualityStats, &size); data != nil && size >= 5 {
+		// quality as a little-endian int32, then the picture type
+		side := unsafe.Slice((*byte)(data), 5)
+		qp = float64(int32(binary.LittleEndian.Uint32(side))) / qp2Lambda //nolint:gosec // the quality is stored as an int32
+		if t := int(side[4]); t >= int(ffmpeg.AVPictureTypeI) && t <= int(ffmpeg.AVPictureTypeB) {
+			pictType = t
+		}
+	}
+	e.stats.add(pkt.Size(), pictType, qp)
+}
+
+// Stats returns the video packets written so far, summarised. Like the
+// rest of the encoder it is not safe to call while a frame is being
+// written; Queue.Stats is.
+func (e *Encoder) Stats() Stats {
+	return e.stats.stats()
+}
@@ -0,0 +1,33 @@
+package encoder
+
+import (
+	"testing"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+)
+
+// TestPacketStats verifies the bitrate and quantiser follow the last
+// second's packets, scaled up until a second has passed, while the frame
+// counts cover every packet.
+func TestPacketStats(t *testing.T) {
+	s := newPacketStats(4)
+	s.add(1000, int(ffmpeg.AVPictureTypeI), 20)
+	s.add(500, int(ffmpeg.AVPictureTypeB), 0)
+	if got := s.stats(); got.Bitrate != 1500*8*2 || got.QP != 20 {
+		t.Errorf("half a second in: %+v, want 24000 b/s at QP 20", got)
+	}
+
+	for range 4 {
+		s.add(250, int(ffmpeg.AVPictureTypeP), 30)
+	}
+	want := Stats{Bitrate: 250 * 8 * 4, QP: 30, I: 1, P: 4, B: 1}
+	if got := s.stats(); got != want {
+		t.Errorf("stats() = %+v, want %+v", got, want)
+	}
+
+	var zero packetStats
+	zero.add(100, int(ffmpeg.AVPictureTypeI), 0)
+	if got := zero.stats(); got != (Stats{I: 1}) {
+		t.Errorf("zero value stats() = %+v, want the count alone", got)
+	}
+}
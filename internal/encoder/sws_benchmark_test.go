@@ -231,10 +231,11 @@ func BenchmarkRGBAToYUVDirect(b *testing.B) {
 
 	pool := yuv.NewRowPool(benchHeight)
 	defer pool.Close()
+	matrix := yuv.NewMatrix(yuv.BT601, yuv.RangeFull)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		convertRGBAToYUV(pool, rgbaData, yuvFrame, benchWidth)
+		convertRGBAToYUV(pool, &matrix, rgbaData, yuvFrame, benchWidth, 0, benchHeight)
 	}
 }
 
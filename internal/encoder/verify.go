@@ -0,0 +1,104 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+	"github.com/linuxmatters/jivefire/internal/ffmpegutil"
+)
+
+// CountVideoFrames decodes every frame of the first video stream in path and
+// returns how many there were. It checks that an encoder's output plays
+// back: a stream that opens but fails part way through is an error, not a
+// short count.
+func CountVideoFrames(path string) (int, error) {
+	ffmpegutil.Init()
+	var formatCtx *ffmpeg.AVFormatContext
+	var cs ffmpegutil.CStrings
+	defer cs.Free()
+	ret, err := ffmpeg.AVFormatOpenInput(&formatCtx, cs.New(path), nil, nil)
+	if err := ffmpegutil.Check(ret, err, "open output"); err != nil {
+		return 0, err
+	}
+	defer ffmpeg.AVFormatCloseInput(&formatCtx)
+	ret, err = ffmpeg.AVFormatFindStreamInfo(formatCtx, nil)
+	if err := ffmpegutil.Check(ret, err, "read stream info"); err != nil {
+		return 0, err
+	}
+
+	streamIdx := -1
+	var stream *ffmpeg.AVStream
+	for i := uintptr(0); i < uintptr(formatCtx.NbStreams()); i++ {
+		s := formatCtx.Streams().Get(i)
+		if s.Codecpar().CodecType() == ffmpeg.AVMediaTypeVideo {
+			streamIdx, stream = int(i), s //nolint:gosec // stream count fits in int
+			break
+		}
+	}
+	if stream == nil {
+		return 0, fmt.Errorf("no video stream in output")
+	}
+
+	decoder := ffmpeg.AVCodecFindDecoder(stream.Codecpar().CodecId())
+	if decoder == nil {
+		return 0, fmt.Errorf("no decoder for codec ID %d", stream.Codecpar().CodecId())
+	}
+	codecCtx := ffmpeg.AVCodecAllocContext3(decoder)
+	if codecCtx == nil {
+		return 0, fmt.Errorf("failed to allocate decoder context")
+	}
+	defer ffmpeg.AVCodecFreeContext(&codecCtx)
+	ret, err = ffmpeg.AVCodecParametersToContext(codecCtx, stream.Codecpar())
+	if err := ffmpegutil.Check(ret, err, "copy codec parameters"); err != nil {
+		return 0, err
+	}
+	ret, err = ffmpeg.AVCodecOpen2(codecCtx, decoder, nil)
+	if err := ffmpegutil.Check(ret, err, "open decoder"); err != nil {
+		return 0, err
+	}
+
+	packet := ffmpeg.AVPacketAlloc()
+	defer ffmpeg.AVPacketFree(&packet)
+	frame := ffmpeg.AVFrameAlloc()
+	defer ffmpeg.AVFrameFree(&frame)
+
+	var frames int
+	receive := func() error {
+		for {
+			_, err := ffmpeg.AVCodecReceiveFrame(codecCtx, frame)
+			if ffmpegutil.Drained(err) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("decode frame %d: %w", frames, err)
+			}
+			frames++
+			ffmpeg.AVFrameUnref(frame)
+		}
+	}
+
+	for {
+		_, err := ffmpeg.AVReadFrame(formatCtx, packet)
+		if errors.Is(err, ffmpeg.AVErrorEOF) {
+			break
+		}
+		if err != nil {
+			return frames, fmt.Errorf("read packet: %w", err)
+		}
+		if packet.StreamIndex() == streamIdx {
+			_, err = ffmpeg.AVCodecSendPacket(codecCtx, packet)
+			if err == nil {
+				err = receive()
+			}
+		}
+		ffmpeg.AVPacketUnref(packet)
+		if err != nil {
+			return frames, err
+		}
+	}
+	if _, err := ffmpeg.AVCodecSendPacket(codecCtx, nil); err != nil {
+		return frames, fmt.Errorf("flush decoder: %w", err)
+	}
+	return frames, receive()
+}
@@ -0,0 +1,72 @@
+// Package failure names the ways a run can fail that scripts wrapping
+// jivefire may want to tell apart, and gives each its own exit code.
+// Packages mark an error with one of the sentinels where they know the
+// cause; the command maps whatever reaches it to the exit code.
+package failure
+
+import "errors"
+
+// The failures with exit codes of their own. Match them with errors.Is.
+var (
+	ErrInputNotFound     = errors.New("input not found")
+	ErrUnsupportedFormat = errors.New("unsupported input format")
+	ErrEncoderInit       = errors.New("encoder initialisation failed")
+	ErrHWUnavailable     = errors.New("hardware encoder unavailable")
+	ErrCancelled         = errors.New("cancelled")
+)
+
+// Exit codes. Any other failure exits with ExitFailure; command-line parse
+// errors exit with 80, Kong's usage error code.
+const (
+	ExitOK                = 0
+	ExitFailure           = 1
+	ExitInputNotFound     = 3
+	ExitUnsupportedFormat = 4
+	ExitEncoderInit       = 5
+	ExitHWUnavailable     = 6
+	ExitCancelled         = 130 // As for a shell job stopped by Ctrl+C
+)
+
+// exitCodes are checked in order, so an error marked with more than one
+// reports the most specific: a missing hardware encoder ahead of the failed
+// initialisation it caused.
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{ErrCancelled, ExitCancelled},
+	{ErrInputNotFound, ExitInputNotFound},
+	{ErrUnsupportedFormat, ExitUnsupportedFormat},
+	{ErrHWUnavailable, ExitHWUnavailable},
+	{ErrEncoderInit, ExitEncoderInit},
+}
+
+// ExitCode returns the exit code for err: ExitOK for nil, the code of the
+// first sentinel it matches, or ExitFailure.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	for _, c := range exitCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return ExitFailure
+}
+
+// Mark returns err marked as a kind of failure, one of the sentinels,
+// without changing its message. A nil err or kind returns err as it is.
+func Mark(kind, err error) error {
+	if err == nil || kind == nil {
+		return err
+	}
+	return &marked{kind: kind, err: err}
+}
+
+type marked struct {
+	kind, err error
+}
+
+func (m *marked) Error() string   { return m.err.Error() }
+func (m *marked) Unwrap() []error { return []error{m.err, m.kind} }
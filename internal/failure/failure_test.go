@@ -0,0 +1,48 @@
+package failure
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	cause := errors.New("no such encoder")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, ExitOK},
+		{"unclassified", cause, ExitFailure},
+		{"sentinel", ErrInputNotFound, ExitInputNotFound},
+		{"marked", Mark(ErrUnsupportedFormat, cause), ExitUnsupportedFormat},
+		{"wrapped mark", fmt.Errorf("opening audio stream: %w", Mark(ErrCancelled, cause)), ExitCancelled},
+		{"most specific", Mark(ErrEncoderInit, Mark(ErrHWUnavailable, cause)), ExitHWUnavailable},
+		{"encoder init", Mark(ErrEncoderInit, cause), ExitEncoderInit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMark(t *testing.T) {
+	cause := errors.New("device busy")
+	err := Mark(ErrEncoderInit, cause)
+	if err.Error() != "device busy" {
+		t.Errorf("message %q, want the cause's unchanged", err)
+	}
+	if !errors.Is(err, cause) || !errors.Is(err, ErrEncoderInit) {
+		t.Error("marked error does not match both its cause and its kind")
+	}
+	if Mark(ErrEncoderInit, nil) != nil {
+		t.Error("marking nil is not nil")
+	}
+	if Mark(nil, cause) != cause {
+		t.Error("marking with no kind changed the error")
+	}
+}
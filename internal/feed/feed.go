@@ -0,0 +1,240 @@
+// Package feed reads episode details from a podcast RSS feed for --rss: the
+// title, episode number, artwork and publish date, so they need not be
+// passed by hand.
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Episode is one item of a feed, with the show's details filled in where
+// the item leaves them out.
+type Episode struct {
+	GUID      string
+	Title     string
+	Show      string    // Channel title
+	Author    string    // itunes:author of the item, or of the channel
+	Number    *int      // itunes:episode, nil when the feed has none
+	Artwork   string    // itunes:image URL of the item, or of the channel
+	Published time.Time // pubDate, zero when missing or unparseable
+}
+
+// Feed is a parsed podcast feed.
+type Feed struct {
+	Show     string
+	Episodes []Episode // In feed order, normally newest first
+}
+
+const (
+	// fetchTimeout bounds each download; a feed server that hangs should
+	// fail the run rather than stall it.
+	fetchTimeout = 30 * time.Second
+	// maxFeedSize and maxArtworkSize cap downloads. Feeds of long-running
+	// shows reach a few megabytes; artwork is meant to be at most 3000px.
+	maxFeedSize    = 32 << 20
+	maxArtworkSize = 16 << 20
+)
+
+const itunesNS = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+type rssImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	GUID    string    `xml:"guid"`
+	Title   string    `xml:"title"`
+	PubDate string    `xml:"pubDate"`
+	Episode string    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	Author  string    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+	Image   *rssImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+}
+
+type rssChannel struct {
+	Title  string    `xml:"title"`
+	Author string    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+	Image  *rssImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	Items  []rssItem `xml:"item"`
+}
+
+type rssDocument struct {
+	Channel rssChannel `xml:"channel"`
+}
+
+// Parse reads an RSS 2.0 podcast feed.
+func Parse(r io.Reader) (*Feed, error) {
+	var doc rssDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parsing feed: %w", err)
+	}
+	ch := doc.Channel
+	if len(ch.Items) == 0 {
+		return nil, fmt.Errorf("feed has no episodes")
+	}
+
+	f := &Feed{Show: strings.TrimSpace(ch.Title)}
+	for _, item := range ch.Items {
+		ep := Episode{
+			GUID:      strings.TrimSpace(item.GUID),
+			Title:     strings.TrimSpace(item.Title),
+			Show:      f.Show,
+			Author:    strings.TrimSpace(item.Author),
+			Published: parseDate(item.PubDate),
+		}
+		if ep.Author == "" {
+			ep.Author = strings.TrimSpace(ch.Author)
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(item.Episode)); err == nil && n >= 0 {
+			ep.Number = &n
+		}
+		switch {
+		case item.Image != nil && item.Image.Href != "":
+			ep.Artwork = strings.TrimSpace(item.Image.Href)
+		case ch.Image != nil:
+			ep.Artwork = strings.TrimSpace(ch.Image.Href)
+		}
+		f.Episodes = append(f.Episodes, ep)
+	}
+	return f, nil
+}
+
+// parseDate reads an RFC 822 pubDate, allowing the variations feeds use in
+// practice: numeric zones, and a missing day name or seconds.
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{
+		time.RFC1123Z, time.RFC1123,
+		"Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST",
+		"2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04 -0700",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Episode returns the episode with guid, or the first (newest) episode when
+// guid is empty.
+func (f *Feed) Episode(guid string) (Episode, error) {
+	if guid == "" {
+		return f.Episodes[0], nil
+	}
+	for _, ep := range f.Episodes {
+		if ep.GUID == guid {
+			return ep, nil
+		}
+	}
+	return Episode{}, fmt.Errorf("no episode with GUID %q in the feed", guid)
+}
+
+// Fetch reads the feed at source, an http or https URL or a local file.
+func Fetch(source string) (*Feed, error) {
+	body, err := open(source, maxFeedSize)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return Parse(body)
+}
+
+// FetchArtwork downloads the artwork at url into dir, named by a hash of the
+// URL so a later render of the same show reuses it, and returns its path.
+func FetchArtwork(url, dir string) (string, error) {
+	sum := sha256.Sum256([]byte(url))
+	ext := strings.ToLower(path.Ext(strings.SplitN(url, "?", 2)[0]))
+	if ext != ".png" && ext != ".webp" {
+		ext = ".jpg"
+	}
+	dest := filepath.Join(dir, hex.EncodeToString(sum[:8])+ext)
+	if _, err := os.Stat(dest); err == nil {
+		return dest, nil
+	}
+
+	body, err := open(url, maxArtworkSize)
+	if err != nil {
+		return "", fmt.Errorf("downloading artwork: %w", err)
+	}
+	defer body.Close()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	// Download beside the destination and rename, so an interrupted download
+	// is never mistaken for cached artwork.
+	tmp, err := os.CreateTemp(dir, ".artwork-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dest)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("downloading artwork: %w", err)
+	}
+	return dest, nil
+}
+
+// open returns the contents of an http(s) URL or a local file, reading at
+// most limit bytes.
+func open(source string, limit int64) (io.ReadCloser, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		return limited{io.LimitReader(f, limit), f}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "jivefire")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("%s answered %s", source, resp.Status)
+	}
+	return limited{io.LimitReader(resp.Body, limit), closer{resp.Body, cancel}}, nil
+}
+
+// limited reads through a size limit and closes the underlying source.
+type limited struct {
+	io.Reader
+	io.Closer
+}
+
+// closer closes a response body and releases its request context.
+type closer struct {
+	body   io.Closer
+	cancel context.CancelFunc
+}
+
+func (c closer) Close() error {
+	defer c.cancel()
+	return c.body.Close()
+}
@@ -0,0 +1,160 @@
+package feed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<channel>
+  <title>Linux Matters</title>
+  <itunes:author>Linux Matters</itunes:author>
+  <itunes:image href="https://example.com/show.png"/>
+  <item>
+    <guid isPermaLink="false">lm-67</guid>
+    <title>Terminal Velocity</title>
+    <pubDate>Tue, 7 Oct 2025 07:00:00 +0000</pubDate>
+    <itunes:episode>67</itunes:episode>
+    <itunes:image href="https://example.com/67.jpg"/>
+  </item>
+  <item>
+    <guid>lm-66</guid>
+    <title>  Trailer  </title>
+    <pubDate>not a date</pubDate>
+    <itunes:author>Guest Host</itunes:author>
+  </item>
+</channel>
+</rss>`
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(testFeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Show != "Linux Matters" || len(f.Episodes) != 2 {
+		t.Fatalf("show %q with %d episodes", f.Show, len(f.Episodes))
+	}
+
+	ep := f.Episodes[0]
+	if ep.GUID != "lm-67" || ep.Title != "Terminal Velocity" || ep.Author != "Linux Matters" {
+		t.Errorf("episode 67 = %+v", ep)
+	}
+	if ep.Number == nil || *ep.Number != 67 {
+		t.Errorf("episode number %v, want 67", ep.Number)
+	}
+	if ep.Artwork != "https://example.com/67.jpg" {
+		t.Errorf("artwork %q, want the item's image", ep.Artwork)
+	}
+	if want := time.Date(2025, 10, 7, 7, 0, 0, 0, time.UTC); !ep.Published.Equal(want) {
+		t.Errorf("published %v, want %v", ep.Published, want)
+	}
+
+	ep = f.Episodes[1]
+	if ep.Title != "Trailer" || ep.Author != "Guest Host" || ep.Number != nil || !ep.Published.IsZero() {
+		t.Errorf("episode 66 = %+v", ep)
+	}
+	if ep.Artwork != "https://example.com/show.png" {
+		t.Errorf("artwork %q, want the show's image", ep.Artwork)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, doc := range []string{
+		"",
+		"<rss><channel><title>Empty</title></channel></rss>",
+		"<html><body>Not a feed</body></html>",
+	} {
+		if _, err := Parse(strings.NewReader(doc)); err == nil {
+			t.Errorf("Parse(%q) succeeded", doc)
+		}
+	}
+}
+
+func TestFeedEpisode(t *testing.T) {
+	f, err := Parse(strings.NewReader(testFeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep, err := f.Episode(""); err != nil || ep.GUID != "lm-67" {
+		t.Errorf("Episode(\"\") = %q, %v; want the newest", ep.GUID, err)
+	}
+	if ep, err := f.Episode("lm-66"); err != nil || ep.Title != "Trailer" {
+		t.Errorf("Episode(lm-66) = %q, %v", ep.Title, err)
+	}
+	if _, err := f.Episode("lm-1"); err == nil {
+		t.Error("Episode(lm-1) found an episode not in the feed")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed.xml":
+			_, _ = w.Write([]byte(testFeed))
+		case "/art.png":
+			_, _ = w.Write([]byte("png"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	f, err := Fetch(srv.URL + "/feed.xml")
+	if err != nil || len(f.Episodes) != 2 {
+		t.Fatalf("Fetch over HTTP: %v", err)
+	}
+	if _, err := Fetch(srv.URL + "/missing.xml"); err == nil {
+		t.Error("Fetch of a 404 succeeded")
+	}
+
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	if err := os.WriteFile(path, []byte(testFeed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Fetch(path); err != nil {
+		t.Errorf("Fetch of a local file: %v", err)
+	}
+}
+
+func TestFetchArtwork(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/art.png" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path, err := FetchArtwork(srv.URL+"/art.png?v=2", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Ext(path) != ".png" {
+		t.Errorf("artwork saved as %s, want a .png", path)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "png" {
+		t.Errorf("artwork holds %q", data)
+	}
+
+	// A second fetch of the same URL is served from dir.
+	if again, err := FetchArtwork(srv.URL+"/art.png?v=2", dir); err != nil || again != path || requests != 1 {
+		t.Errorf("second fetch: %s, %v after %d requests", again, err, requests)
+	}
+
+	if _, err := FetchArtwork(srv.URL+"/missing.jpg", dir); err == nil {
+		t.Error("FetchArtwork of a 404 succeeded")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files in the artwork directory, want 1", len(entries))
+	}
+}
@@ -0,0 +1,135 @@
+// Package ffmpegutil holds the FFmpeg plumbing the audio decoder and the
+// encoder share: process-wide setup and log policy, error translation, and C
+// string lifetimes.
+package ffmpegutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"syscall"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+)
+
+var initOnce sync.Once
+
+// Init applies the process-wide FFmpeg setup, once; later calls do nothing.
+// FFmpeg and libva log to stderr, which would corrupt the TUI, so both are
+// silenced for the whole run rather than around individual calls. A
+// LIBVA_MESSAGING_LEVEL the user set is left alone. Every entry point into
+// FFmpeg calls Init first, so the decoder, the encoder and hardware probing
+// need not agree on who goes first.
+func Init() {
+	initOnce.Do(func() {
+		ffmpeg.AVLogSetLevel(ffmpeg.AVLogQuiet)
+		if _, ok := os.LookupEnv("LIBVA_MESSAGING_LEVEL"); !ok {
+			os.Setenv("LIBVA_MESSAGING_LEVEL", "0")
+		}
+	})
+}
+
+// Check returns nil when an FFmpeg call succeeded, or its failure with op,
+// what was being done, in front: "open codec: Invalid argument". The
+// bindings return an error for a negative code, but a bare negative ret is
+// translated too, so no message falls back to a raw error number.
+func Check(ret int, err error, op string) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if ret < 0 {
+		return fmt.Errorf("%s: %w", op, ffmpeg.WrapErr(ret))
+	}
+	return nil
+}
+
+// Drained reports whether err from a send or receive call is EAGAIN or EOF:
+// the codec wants more input, or has no more output, rather than failing.
+func Drained(err error) bool {
+	return errors.Is(err, ffmpeg.EAgain) || errors.Is(err, ffmpeg.AVErrorEOF)
+}
+
+// transientErrnos are the errors FFmpeg passes up from the system that can
+// clear by themselves: a busy device, an interrupted call or a timeout.
+var transientErrnos = []syscall.Errno{syscall.EAGAIN, syscall.EBUSY, syscall.EINTR, syscall.ETIMEDOUT}
+
+// Transient reports whether err from a send or write is worth retrying:
+// EAGAIN, where the codec wants its output read first, or one of
+// transientErrnos. Anything else, a bad argument, a full disk or a lost
+// device, fails the same way the second time.
+func Transient(err error) bool {
+	if errors.Is(err, ffmpeg.EAgain) {
+		return true
+	}
+	var averr ffmpeg.AVError
+	if !errors.As(err, &averr) {
+		return false
+	}
+	return slices.Contains(transientErrnos, syscall.Errno(-averr.Code))
+}
+
+// CStrings collects the C strings for a call so they can be freed together
+// once it returns:
+//
+//	var cs ffmpegutil.CStrings
+//	defer cs.Free()
+//	ffmpeg.AVFormatOpenInput(&ctx, cs.New(path), nil, nil)
+//
+// The zero value is ready to use.
+type CStrings []*ffmpeg.CStr
+
+// New returns s as a C string, freed with the rest.
+func (cs *CStrings) New(s string) *ffmpeg.CStr {
+	c := ffmpeg.ToCStr(s)
+	*cs = append(*cs, c)
+	return c
+}
+
+// OrNil is New, but returns nil for an empty s, for the optional arguments
+// FFmpeg takes as NULL.
+func (cs *CStrings) OrNil(s string) *ffmpeg.CStr {
+	if s == "" {
+		return nil
+	}
+	return cs.New(s)
+}
+
+// Free frees the C strings and empties cs.
+func (cs *CStrings) Free() {
+	for _, c := range *cs {
+		c.Free()
+	}
+	*cs = (*cs)[:0]
+}
+
+// DictSet sets key to value in an FFmpeg dictionary, allocating it if *dict
+// is nil. FFmpeg copies both strings, so they are freed on return.
+func DictSet(dict **ffmpeg.AVDictionary, key, value string) error {
+	var cs CStrings
+	defer cs.Free()
+	ret, err := ffmpeg.AVDictSet(dict, cs.New(key), cs.New(value), 0)
+	return Check(ret, err, "set "+key)
+}
+
+// DictHas reports whether dict holds key.
+func DictHas(dict *ffmpeg.AVDictionary, key string) bool {
+	var cs CStrings
+	defer cs.Free()
+	return ffmpeg.AVDictGet(dict, cs.New(key), nil, 0) != nil
+}
+
+// FindEncoder returns the encoder with the given name, or nil when this
+// FFmpeg build has none.
+func FindEncoder(name string) *ffmpeg.AVCodec {
+	var cs CStrings
+	defer cs.Free()
+	return ffmpeg.AVCodecFindEncoderByName(cs.New(name))
+}
+
+// Version returns the version of the FFmpeg libraries linked in, such as
+// "n8.0".
+func Version() string {
+	return ffmpeg.AVVersionInfo().String()
+}
@@ -0,0 +1,80 @@
+package ffmpegutil
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+
+	ffmpeg "github.com/linuxmatters/ffmpeg-statigo"
+)
+
+func TestCheck(t *testing.T) {
+	if err := Check(0, nil, "open codec"); err != nil {
+		t.Errorf("success: %v", err)
+	}
+
+	bindingErr := errors.New("binding failed")
+	if err := Check(0, bindingErr, "open codec"); !errors.Is(err, bindingErr) || !strings.HasPrefix(err.Error(), "open codec: ") {
+		t.Errorf("binding error: %v", err)
+	}
+
+	// A bare negative code is translated rather than shown as a number
+	err := Check(-22, nil, "open codec") // AVERROR(EINVAL)
+	if err == nil || !strings.HasPrefix(err.Error(), "open codec: ") || strings.Contains(err.Error(), "-22") {
+		t.Errorf("negative code: %v", err)
+	}
+}
+
+func TestDrained(t *testing.T) {
+	for _, err := range []error{ffmpeg.EAgain, ffmpeg.AVErrorEOF, Check(0, ffmpeg.AVErrorEOF, "receive packet")} {
+		if !Drained(err) {
+			t.Errorf("Drained(%v) = false", err)
+		}
+	}
+	if Drained(nil) || Drained(errors.New("decode failed")) {
+		t.Error("Drained is true for a success or a real failure")
+	}
+}
+
+func TestTransient(t *testing.T) {
+	for _, err := range []error{ffmpeg.EAgain, Check(0, ffmpeg.AVError{Code: -int(syscall.EINTR)}, "write packet"), ffmpeg.AVError{Code: -int(syscall.EBUSY)}} {
+		if !Transient(err) {
+			t.Errorf("Transient(%v) = false", err)
+		}
+	}
+	for _, err := range []error{nil, ffmpeg.AVErrorEOF, ffmpeg.AVError{Code: -int(syscall.EINVAL)}, ffmpeg.AVError{Code: -int(syscall.ENOSPC)}, errors.New("device lost")} {
+		if Transient(err) {
+			t.Errorf("Transient(%v) = true", err)
+		}
+	}
+}
+
+func TestCStrings(t *testing.T) {
+	var cs CStrings
+	if cs.OrNil("") != nil {
+		t.Error("OrNil of an empty string is not nil")
+	}
+	if got := cs.New("nv12").String(); got != "nv12" {
+		t.Errorf("New(\"nv12\") = %q", got)
+	}
+	cs.OrNil("mp4")
+	if len(cs) != 2 {
+		t.Fatalf("holding %d C strings, want 2", len(cs))
+	}
+	cs.Free()
+	if len(cs) != 0 {
+		t.Errorf("holding %d C strings after Free", len(cs))
+	}
+}
+
+func TestDict(t *testing.T) {
+	var dict *ffmpeg.AVDictionary
+	defer ffmpeg.AVDictFree(&dict)
+	if err := DictSet(&dict, "preset", "medium"); err != nil {
+		t.Fatal(err)
+	}
+	if !DictHas(dict, "preset") || DictHas(dict, "tune") {
+		t.Errorf("DictHas: preset %v, tune %v; want true, false", DictHas(dict, "preset"), DictHas(dict, "tune"))
+	}
+}
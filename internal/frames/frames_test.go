@@ -0,0 +1,72 @@
+package frames
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"png": PNG, "JPEG": JPEG, "jpg": JPEG} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("webp"); err == nil {
+		t.Error("ParseFormat(webp) succeeded, want error")
+	}
+}
+
+// TestWriter verifies frames are numbered from 1 in the named format,
+// decode back at their size, and are counted towards Bytes.
+func TestWriter(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 18))
+	img.SetRGBA(1, 1, color.RGBA{R: 255, A: 255})
+
+	for _, tt := range []struct {
+		format Format
+		decode func(f *os.File) (image.Image, error)
+	}{
+		{PNG, func(f *os.File) (image.Image, error) { return png.Decode(f) }},
+		{JPEG, func(f *os.File) (image.Image, error) { return jpeg.Decode(f) }},
+	} {
+		t.Run(string(tt.format), func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "nested", "frames")
+			w, err := New(dir, tt.format)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for n := range 2 {
+				if err := w.Write(n, img); err != nil {
+					t.Fatalf("Write(%d) error = %v", n, err)
+				}
+			}
+
+			wantPath := filepath.Join(dir, "frame-000002"+tt.format.Ext())
+			if got := w.Path(1); got != wantPath {
+				t.Errorf("Path(1) = %q, want %q", got, wantPath)
+			}
+			f, err := os.Open(wantPath)
+			if err != nil {
+				t.Fatalf("second frame not written: %v", err)
+			}
+			defer f.Close()
+			decoded, err := tt.decode(f)
+			if err != nil {
+				t.Fatalf("decoding frame: %v", err)
+			}
+			if decoded.Bounds() != img.Bounds() {
+				t.Errorf("frame bounds = %v, want %v", decoded.Bounds(), img.Bounds())
+			}
+
+			info, _ := f.Stat()
+			if w.Bytes() < info.Size() || w.Bytes() > 2*info.Size()+64 {
+				t.Errorf("Bytes() = %d, want the size of both frames (each about %d)", w.Bytes(), info.Size())
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package frames
+
+import (
+	"bufio"
+	"encoding/binary"
+	"math"
+	"os"
+)
+
+// wavHeaderSize is the length of the canonical RIFF/WAVE header written
+// before the samples.
+const wavHeaderSize = 44
+
+// WAVWriter writes 16-bit PCM WAV audio. The size fields in the header are
+// filled in by Close, so the file is only valid once closed.
+type WAVWriter struct {
+	f         *os.File
+	bw        *bufio.Writer
+	dataBytes int64
+	buf       []byte
+}
+
+// CreateWAV creates a WAV file at path for interleaved samples with the given
+// rate and channel count.
+func CreateWAV(path string, sampleRate, channels int) (*WAVWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &WAVWriter{f: f, bw: bufio.NewWriter(f)}
+
+	blockAlign := channels * 2
+	header := make([]byte, wavHeaderSize)
+	copy(header[0:], "RIFF")
+	copy(header[8:], "WAVE")
+	copy(header[12:], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)                            // fmt chunk size
+	binary.LittleEndian.PutUint16(header[20:], 1)                             // PCM
+	binary.LittleEndian.PutUint16(header[22:], uint16(channels))              //nolint:gosec // 1, 2 or 6
+	binary.LittleEndian.PutUint32(header[24:], uint32(sampleRate))            //nolint:gosec // audio sample rates fit
+	binary.LittleEndian.PutUint32(header[28:], uint32(sampleRate*blockAlign)) //nolint:gosec // as above
+	binary.LittleEndian.PutUint16(header[32:], uint16(blockAlign))            //nolint:gosec // at most 12
+	binary.LittleEndian.PutUint16(header[34:], 16)                            // bits per sample
+	copy(header[36:], "data")
+	if _, err := w.bw.Write(header); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends interleaved samples in [-1, 1]; anything outside is clipped.
+func (w *WAVWriter) Write(samples []float32) error {
+	if cap(w.buf) < len(samples)*2 {
+		w.buf = make([]byte, len(samples)*2)
+	}
+	buf := w.buf[:len(samples)*2]
+	for i, s := range samples {
+		v := int16(math.Round(float64(min(max(s, -1), 1)) * math.MaxInt16))
+		binary.LittleEndian.PutUint16(buf[i*2:], uint16(v)) //nolint:gosec // two's complement reinterpretation
+	}
+	n, err := w.bw.Write(buf)
+	w.dataBytes += int64(n)
+	return err
+}
+
+// Close writes the RIFF and data chunk sizes into the header and closes the
+// file. Sizes are 32-bit, so audio beyond 4 GiB (about 12 hours of 48 kHz
+// mono) is truncated in the header.
+func (w *WAVWriter) Close() error {
+	err := w.bw.Flush()
+	data := uint32(min(w.dataBytes, math.MaxUint32-wavHeaderSize)) //nolint:gosec // clamped above
+	var size [4]byte
+	if err == nil {
+		binary.LittleEndian.PutUint32(size[:], data+wavHeaderSize-8)
+		_, err = w.f.WriteAt(size[:], 4)
+	}
+	if err == nil {
+		binary.LittleEndian.PutUint32(size[:], data)
+		_, err = w.f.WriteAt(size[:], wavHeaderSize-4)
+	}
+	if closeErr := w.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
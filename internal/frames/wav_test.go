@@ -0,0 +1,55 @@
+package frames
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWAVWriter verifies the header describes 16-bit PCM at the given rate
+// and channel count, the sizes are patched on Close and samples are clipped.
+func TestWAVWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), AudioFile)
+	w, err := CreateWAV(path, 48000, 2)
+	if err != nil {
+		t.Fatalf("CreateWAV() error = %v", err)
+	}
+	if err := w.Write([]float32{0, 0.5, -1, 2}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Write([]float32{-2, 1}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != wavHeaderSize+12 {
+		t.Fatalf("file is %d bytes, want %d", len(data), wavHeaderSize+12)
+	}
+	le := binary.LittleEndian
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" || string(data[36:40]) != "data" {
+		t.Error("missing RIFF/WAVE/data markers")
+	}
+	if got := le.Uint32(data[4:]); got != uint32(len(data)-8) {
+		t.Errorf("RIFF size = %d, want %d", got, len(data)-8)
+	}
+	if got := le.Uint32(data[40:]); got != 12 {
+		t.Errorf("data size = %d, want 12", got)
+	}
+	if ch, rate, bits := le.Uint16(data[22:]), le.Uint32(data[24:]), le.Uint16(data[34:]); ch != 2 || rate != 48000 || bits != 16 {
+		t.Errorf("format = %d ch, %d Hz, %d bit; want 2 ch, 48000 Hz, 16 bit", ch, rate, bits)
+	}
+
+	want := []int16{0, 16384, -32767, 32767, -32767, 32767}
+	for i, w := range want {
+		if got := int16(le.Uint16(data[wavHeaderSize+i*2:])); got != w { //nolint:gosec // reinterpreting PCM
+			t.Errorf("sample %d = %d, want %d", i, got, w)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package locale
+
+// catalogues holds the translated languages. Keys are the English messages
+// as the UI passes them to T and Sprintf, padding and all; a message missing
+// from a language is shown in English. French sets its colons off with a
+// no-break space, and groups thousands with a narrow one.
+var catalogues = map[string]*Locale{
+	"de": {Lang: "de", decimal: ",", group: ".", messages: map[string]string{
+		// Progress
+		"Pass 1: Analysing Audio":               "Durchgang 1: Audio wird analysiert",
+		"Pass 2: Rendering & Encoding":          "Durchgang 2: Rendern & Kodieren",
+		"Pass 2: Rendering & Encoding (paused)": "Durchgang 2: Rendern & Kodieren (pausiert)",
+		"Pass 2: Aborting…":                     "Durchgang 2: Abbruch…",
+		"Pass 2: Stopping and finalising…":      "Durchgang 2: Wird angehalten und abgeschlossen…",
+		"Analysing...":                          "Analysiere...",
+		"Starting analysis...":                  "Analyse startet...",
+		"Starting render...":                    "Rendern startet...",
+		"  %s frames  │  Elapsed: %s":           "  %s Frames  │  Vergangen: %s",
+		"Time":                                  "Zeit",
+		"Speed":                                 "Tempo",
+		"Size":                                  "Größe",
+		"Duration":                              "Dauer",
+		"ETA":                                   "Rest",
+		"Frame: ":                               "Frame: ",
+		"RMS":                                   "RMS",
+		"Peak":                                  "Peak",
+		"True peak":                             "True Peak",
+		"no clipping":                           "keine Übersteuerung",
+		"CLIP":                                  "CLIP",
+		"Encoder":                               "Encoder",
+		"Bitrate":                               "Bitrate",
+		"QP":                                    "QP",
+		"Frames":                                "Frames",
+
+		// Key help
+		"pause":          "Pause",
+		"resume":         "weiter",
+		"preview":        "Vorschau",
+		"encoder":        "Encoder",
+		"rate":           "Rate",
+		"slower preview": "langsamere Vorschau",
+		"live":           "live",
+		"quit":           "beenden",
+		"stop":           "anhalten",
+		"abort":          "abbrechen",
+
+		// Completion and cancellation
+		"aborted at frame %d of %d; removed %s":                    "abgebrochen bei Frame %d von %d; %s entfernt",
+		"stopped at frame %d of %d; partial video finalised in %s": "angehalten bei Frame %d von %d; Teilvideo in %s abgeschlossen",
+		"✓ Encoding Complete!":                                     "✓ Kodierung abgeschlossen!",
+		"Output:":                                                  "Ausgabe:",
+		"Video:":                                                   "Video:",
+		"Audio:":                                                   "Audio:",
+		"%s frames, %s fps average":                                "%s Frames, im Schnitt %s fps",
+		"%s samples processed":                                     "%s Samples verarbeitet",
+		"Pass 1: Audio Analysis":                                   "Durchgang 1: Audioanalyse",
+		"Peak Level:":                                              "Spitzenpegel:",
+		"RMS Level:":                                               "RMS-Pegel:",
+		"Dynamic Range:":                                           "Dynamikumfang:",
+		"True Peak:":                                               "True Peak:",
+		"Loudness:":                                                "Lautheit:",
+		"DC Offset:":                                               "Gleichspannungsversatz:",
+		"Clipping:":                                                "Übersteuerung:",
+		"Optimal Scale:":                                           "Optimale Skalierung:",
+		"Analysis Time:":                                           "Analysezeit:",
+		"Thumbnail:":                                               "Vorschaubild:",
+		"Visualisation:":                                           "Visualisierung:",
+		"Video encoding:":                                          "Videokodierung:",
+		"Audio encoding:":                                          "Audiokodierung:",
+		"Runtime:":                                                 "Laufzeit:",
+		"GPU pipeline:":                                            "GPU-Pipeline:",
+		"Total time:":                                              "Gesamtzeit:",
+
+		// Frame timings
+		"Frame Timings":            "Frame-Zeiten",
+		"FFT:":                     "FFT:",
+		"Binning:":                 "Bänder:",
+		"Drawing:":                 "Zeichnen:",
+		"Encoding:":                "Kodieren:",
+		"Frame:":                   "Frame:",
+		"fft":                      "FFT",
+		"bin":                      "Bänder",
+		"draw":                     "Zeichnen",
+		"encode":                   "Kodieren",
+		"No frames slower than %s": "Keine Frames langsamer als %s",
+		"%s slow frames over %s":   "%s langsame Frames über %s",
+		"%s slow frame over %s":    "%s langsamer Frame über %s",
+		"Frame %d (%s):":           "Frame %d (%s):",
+		"%s, %s in %s":             "%s, davon %s in %s",
+	}},
+
+	"es": {Lang: "es", decimal: ",", group: ".", messages: map[string]string{
+		// Progress
+		"Pass 1: Analysing Audio":               "Pasada 1: analizando el audio",
+		"Pass 2: Rendering & Encoding":          "Pasada 2: renderizado y codificación",
+		"Pass 2: Rendering & Encoding (paused)": "Pasada 2: renderizado y codificación (en pausa)",
+		"Pass 2: Aborting…":                     "Pasada 2: cancelando…",
+		"Pass 2: Stopping and finalising…":      "Pasada 2: deteniendo y finalizando…",
+		"Analysing...":                          "Analizando...",
+		"Starting analysis...":                  "Iniciando el análisis...",
+		"Starting render...":                    "Iniciando el renderizado...",
+		"  %s frames  │  Elapsed: %s":           "  %s fotogramas  │  Transcurrido: %s",
+		"Time":                                  "Tiempo",
+		"Speed":                                 "Velocidad",
+		"Size":                                  "Tamaño",
+		"Duration":                              "Duración",
+		"ETA":                                   "Resta",
+		"Frame: ":                               "Fotograma: ",
+		"RMS":                                   "RMS",
+		"Peak":                                  "Pico",
+		"True peak":                             "Pico real",
+		"no clipping":                           "sin saturación",
+		"CLIP":                                  "SATURA",
+		"Encoder":                               "Codificador",
+		"Bitrate":                               "Tasa de bits",
+		"QP":                                    "QP",
+		"Frames":                                "Fotogramas",
+
+		// Key help
+		"pause":          "pausa",
+		"resume":         "reanudar",
+		"preview":        "vista previa",
+		"encoder":        "codificador",
+		"rate":           "frecuencia",
+		"slower preview": "vista previa más lenta",
+		"live":           "en vivo",
+		"quit":           "salir",
+		"stop":           "detener",
+		"abort":          "cancelar",
+
+		// Completion and cancellation
+		"aborted at frame %d of %d; removed %s":                    "cancelado en el fotograma %d de %d; %s eliminado",
+		"stopped at frame %d of %d; partial video finalised in %s": "detenido en el fotograma %d de %d; vídeo parcial finalizado en %s",
+		"✓ Encoding Complete!":                                     "✓ ¡Codificación completada!",
+		"Output:":                                                  "Salida:",
+		"Video:":                                                   "Vídeo:",
+		"Audio:":                                                   "Audio:",
+		"%s frames, %s fps average":                                "%s fotogramas, %s fps de media",
+		"%s samples processed":                                     "%s muestras procesadas",
+		"Pass 1: Audio Analysis":                                   "Pasada 1: análisis de audio",
+		"Peak Level:":                                              "Nivel de pico:",
+		"RMS Level:":                                               "Nivel RMS:",
+		"Dynamic Range:":                                           "Rango dinámico:",
+		"True Peak:":                                               "Pico real:",
+		"Loudness:":                                                "Sonoridad:",
+		"DC Offset:":                                               "Desplazamiento de CC:",
+		"Clipping:":                                                "Recorte:",
+		"Optimal Scale:":                                           "Escala óptima:",
+		"Analysis Time:":                                           "Tiempo de análisis:",
+		"Thumbnail:":                                               "Miniatura:",
+		"Visualisation:":                                           "Visualización:",
+		"Video encoding:":                                          "Codificación de vídeo:",
+		"Audio encoding:":                                          "Codificación de audio:",
+		"Runtime:":                                                 "Tiempo de ejecución:",
+		"GPU pipeline:":                                            "Canalización de GPU:",
+		"Total time:":                                              "Tiempo total:",
+
+		// Frame timings
+		"Frame Timings":            "Tiempos por fotograma",
+		"FFT:":                     "FFT:",
+		"Binning:":                 "Agrupación:",
+		"Drawing:":                 "Dibujo:",
+		"Encoding:":                "Codificación:",
+		"Frame:":                   "Fotograma:",
+		"fft":                      "FFT",
+		"bin":                      "agrupación",
+		"draw":                     "dibujo",
+		"encode":                   "codificación",
+		"No frames slower than %s": "Ningún fotograma más lento de %s",
+		"%s slow frames over %s":   "%s fotogramas lentos de más de %s",
+		"%s slow frame over %s":    "%s fotograma lento de más de %s",
+		"Frame %d (%s):":           "Fotograma %d (%s):",
+		"%s, %s in %s":             "%s, %s en %s",
+	}},
+
+	"fr": {Lang: "fr", decimal: ",", group: "\u202f", messages: map[string]string{
+		// Progress
+		"Pass 1: Analysing Audio":               "Passe 1\u00a0: analyse de l'audio",
+		"Pass 2: Rendering & Encoding":          "Passe 2\u00a0: rendu et encodage",
+		"Pass 2: Rendering & Encoding (paused)": "Passe 2\u00a0: rendu et encodage (en pause)",
+		"Pass 2: Aborting…":                     "Passe 2\u00a0: interruption…",
+		"Pass 2: Stopping and finalising…":      "Passe 2\u00a0: arrêt et finalisation…",
+		"Analysing...":                          "Analyse...",
+		"Starting analysis...":                  "Démarrage de l'analyse...",
+		"Starting render...":                    "Démarrage du rendu...",
+		"  %s frames  │  Elapsed: %s":           "  %s images  │  Écoulé\u00a0: %s",
+		"Time":                                  "Temps",
+		"Speed":                                 "Vitesse",
+		"Size":                                  "Taille",
+		"Duration":                              "Durée",
+		"ETA":                                   "Reste",
+		"Frame: ":                               "Image\u00a0: ",
+		"RMS":                                   "RMS",
+		"Peak":                                  "Crête",
+		"True peak":                             "Crête vraie",
+		"no clipping":                           "pas d'écrêtage",
+		"CLIP":                                  "ÉCRÊTÉ",
+		"Encoder":                               "Encodeur",
+		"Bitrate":                               "Débit",
+		"QP":                                    "QP",
+		"Frames":                                "Images",
+
+		// Key help
+		"pause":          "pause",
+		"resume":         "reprendre",
+		"preview":        "aperçu",
+		"encoder":        "encodeur",
+		"rate":           "cadence",
+		"slower preview": "aperçu plus lent",
+		"live":           "direct",
+		"quit":           "quitter",
+		"stop":           "arrêter",
+		"abort":          "interrompre",
+
+		// Completion and cancellation
+		"aborted at frame %d of %d; removed %s":                    "interrompu à l'image %d sur %d\u00a0; %s supprimé",
+		"stopped at frame %d of %d; partial video finalised in %s": "arrêté à l'image %d sur %d\u00a0; vidéo partielle finalisée dans %s",
+		"✓ Encoding Complete!":                                     "✓ Encodage terminé\u00a0!",
+		"Output:":                                                  "Sortie\u00a0:",
+		"Video:":                                                   "Vidéo\u00a0:",
+		"Audio:":                                                   "Audio\u00a0:",
+		"%s frames, %s fps average":                                "%s images, %s i/s en moyenne",
+		"%s samples processed":                                     "%s échantillons traités",
+		"Pass 1: Audio Analysis":                                   "Passe 1\u00a0: analyse audio",
+		"Peak Level:":                                              "Niveau crête\u00a0:",
+		"RMS Level:":                                               "Niveau RMS\u00a0:",
+		"Dynamic Range:":                                           "Plage dynamique\u00a0:",
+		"True Peak:":                                               "Crête vraie\u00a0:",
+		"Loudness:":                                                "Sonie\u00a0:",
+		"DC Offset:":                                               "Composante continue\u00a0:",
+		"Clipping:":                                                "Écrêtage\u00a0:",
+		"Optimal Scale:":                                           "Échelle optimale\u00a0:",
+		"Analysis Time:":                                           "Durée d'analyse\u00a0:",
+		"Thumbnail:":                                               "Miniature\u00a0:",
+		"Visualisation:":                                           "Visualisation\u00a0:",
+		"Video encoding:":                                          "Encodage vidéo\u00a0:",
+		"Audio encoding:":                                          "Encodage audio\u00a0:",
+		"Runtime:":                                                 "Exécution\u00a0:",
+		"GPU pipeline:":                                            "Pipeline GPU\u00a0:",
+		"Total time:":                                              "Durée totale\u00a0:",
+
+		// Frame timings
+		"Frame Timings":            "Temps par image",
+		"FFT:":                     "FFT\u00a0:",
+		"Binning:":                 "Regroupement\u00a0:",
+		"Drawing:":                 "Dessin\u00a0:",
+		"Encoding:":                "Encodage\u00a0:",
+		"Frame:":                   "Image\u00a0:",
+		"fft":                      "FFT",
+		"bin":                      "regroupement",
+		"draw":                     "dessin",
+		"encode":                   "encodage",
+		"No frames slower than %s": "Aucune image plus lente que %s",
+		"%s slow frames over %s":   "%s images lentes au-delà de %s",
+		"%s slow frame over %s":    "%s image lente au-delà de %s",
+		"Frame %d (%s):":           "Image %d (%s)\u00a0:",
+		"%s, %s in %s":             "%s, dont %s en %s",
+	}},
+}
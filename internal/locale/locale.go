@@ -0,0 +1,144 @@
+// Package locale translates the progress and summary output and formats its
+// numbers for the language chosen with --lang. Messages are looked up by
+// their English text, so untranslated strings fall back to English and the
+// catalogue only needs the ones that differ.
+package locale
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Locale is a language's messages and number format. A nil *Locale is
+// English, so the zero value of anything holding one needs no setup.
+type Locale struct {
+	Lang     string            // ISO 639-1 code, such as "de"
+	messages map[string]string // Translations keyed by the English text
+	decimal  string            // Decimal separator
+	group    string            // Thousands separator
+}
+
+// English is the default locale: the messages as written, with 1,234.5
+// number formatting.
+var English = &Locale{Lang: "en", decimal: ".", group: ","}
+
+// Languages returns the supported language codes, sorted.
+func Languages() []string {
+	langs := []string{English.Lang}
+	for lang := range catalogues {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// Parse returns the locale for a language code. POSIX locale names and
+// regional tags (de_DE.UTF-8, pt-BR) select their base language; an empty
+// code is English.
+func Parse(s string) (*Locale, error) {
+	lang := strings.ToLower(s)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	switch lang {
+	case "", "c", "posix", English.Lang:
+		return English, nil
+	}
+	if l, ok := catalogues[lang]; ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("unsupported language %q: must be one of %s", s, strings.Join(Languages(), ", "))
+}
+
+// FromEnv returns the locale named by LC_ALL, LC_MESSAGES or LANG, the first
+// that is set, as the C library chooses it. An unsupported language falls
+// back to English rather than failing, as the environment was not aimed at
+// jivefire.
+func FromEnv() *Locale {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			if l, err := Parse(v); err == nil {
+				return l
+			}
+			return English
+		}
+	}
+	return English
+}
+
+// T returns the translation of an English message, or the message itself
+// when the language has none.
+func (l *Locale) T(msg string) string {
+	if l != nil {
+		if t, ok := l.messages[msg]; ok {
+			return t
+		}
+	}
+	return msg
+}
+
+// Sprintf formats with the translation of an English format string. The
+// translation must keep the verbs in the same order; quantities should be
+// passed already formatted by Int or Float.
+func (l *Locale) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(l.T(format), args...)
+}
+
+// Int formats an integer with thousands separators.
+func (l *Locale) Int(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, s = "-", s[1:]
+	}
+	return sign + l.groupDigits(s)
+}
+
+// Float formats a number with prec decimal places, using the language's
+// decimal and thousands separators.
+func (l *Locale) Float(v float64, prec int) string {
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if _, err := strconv.Atoi(whole); err != nil {
+		return sign + s // Inf or NaN
+	}
+	s = l.groupDigits(whole)
+	if hasFrac {
+		s += l.format().decimal + frac
+	}
+	return sign + s
+}
+
+// groupDigits inserts the thousands separator into a run of digits.
+func (l *Locale) groupDigits(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	lead := len(digits) % 3
+	if lead > 0 {
+		b.WriteString(digits[:lead])
+	}
+	for i := lead; i < len(digits); i += 3 {
+		if i > 0 {
+			b.WriteString(l.format().group)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// format returns l, or English for a nil locale.
+func (l *Locale) format() *Locale {
+	if l == nil {
+		return English
+	}
+	return l
+}
@@ -0,0 +1,110 @@
+package locale
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// TestParse verifies language codes, POSIX locale names and regional tags
+// select their language, and unknown languages fail.
+func TestParse(t *testing.T) {
+	for input, want := range map[string]string{
+		"":            "en",
+		"C":           "en",
+		"en_GB.UTF-8": "en",
+		"de":          "de",
+		"de_AT.UTF-8": "de",
+		"fr-CA":       "fr",
+		"ES":          "es",
+	} {
+		l, err := Parse(input)
+		if err != nil || l.Lang != want {
+			t.Errorf("Parse(%q) = %v, %v; want %s", input, l, err, want)
+		}
+	}
+	if _, err := Parse("tlh"); err == nil {
+		t.Error("Parse(\"tlh\") succeeded, want error")
+	}
+}
+
+// TestFromEnv verifies the C library's precedence, and that an unsupported
+// environment falls back to English.
+func TestFromEnv(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "fr_FR.UTF-8")
+	t.Setenv("LANG", "de_DE.UTF-8")
+	if got := FromEnv().Lang; got != "fr" {
+		t.Errorf("FromEnv() = %s, want LC_MESSAGES's fr", got)
+	}
+	t.Setenv("LC_ALL", "ja_JP.UTF-8")
+	if got := FromEnv().Lang; got != "en" {
+		t.Errorf("FromEnv() with an unsupported LC_ALL = %s, want en", got)
+	}
+}
+
+// TestNumbers verifies grouping and decimal separators in each language.
+func TestNumbers(t *testing.T) {
+	de, _ := Parse("de")
+	fr, _ := Parse("fr")
+	var unset *Locale
+	for _, tt := range []struct {
+		loc  *Locale
+		got  string
+		want string
+	}{
+		{English, English.Int(1234567), "1,234,567"},
+		{English, English.Int(-999), "-999"},
+		{English, English.Float(-1234.5, 2), "-1,234.50"},
+		{de, de.Float(1234.5, 1), "1.234,5"},
+		{de, de.Float(0.25, 3), "0,250"},
+		{fr, fr.Int(48000), "48 000"},
+		{unset, unset.Float(12.5, 1), "12.5"},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%v: %q, want %q", tt.loc, tt.got, tt.want)
+		}
+	}
+}
+
+// TestTranslate verifies lookups fall back to English, and a nil locale is
+// English.
+func TestTranslate(t *testing.T) {
+	de, _ := Parse("de")
+	if got := de.T("Speed"); got != "Tempo" {
+		t.Errorf("de.T(\"Speed\") = %q, want Tempo", got)
+	}
+	if got := de.T("not in the catalogue"); got != "not in the catalogue" {
+		t.Errorf("untranslated message = %q, want it unchanged", got)
+	}
+	var unset *Locale
+	if got := unset.Sprintf("%s samples processed", "10"); got != "10 samples processed" {
+		t.Errorf("nil locale Sprintf = %q", got)
+	}
+}
+
+// TestCatalogues verifies every language translates the same messages, and
+// keeps each message's verbs in order so Sprintf's arguments still fit.
+func TestCatalogues(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]`)
+	var keys []string
+	for msg := range catalogues["de"].messages {
+		keys = append(keys, msg)
+	}
+	slices.Sort(keys)
+	for lang, l := range catalogues {
+		if len(l.messages) != len(keys) {
+			t.Errorf("%s has %d messages, de has %d", lang, len(l.messages), len(keys))
+		}
+		for _, msg := range keys {
+			tr, ok := l.messages[msg]
+			if !ok {
+				t.Errorf("%s lacks %q", lang, msg)
+				continue
+			}
+			if got, want := verbs.FindAllString(tr, -1), verbs.FindAllString(msg, -1); !slices.Equal(got, want) {
+				t.Errorf("%s %q has verbs %v, want %v", lang, tr, got, want)
+			}
+		}
+	}
+}
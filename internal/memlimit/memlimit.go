@@ -0,0 +1,128 @@
+// Package memlimit applies --max-memory: it sets the Go runtime's soft memory
+// limit, so the garbage collector works harder as the heap approaches it, and
+// provides a guard both passes poll to stop a render whose live heap still
+// grows past the limit.
+package memlimit
+
+import (
+	"fmt"
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps the accepted suffixes to their multipliers. Decimal and
+// binary suffixes both mean powers of 1024, as memory sizes usually do.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+}
+
+// minLimit is the smallest accepted limit; below this the render cannot hold
+// even one frame and its buffers.
+const minLimit = 64 << 20
+
+// ParseSize parses a --max-memory value such as "512M", "1.5GiB" or
+// "800mb". An empty string or "0" means no limit and returns 0.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	unit, ok := sizeUnits[strings.TrimSpace(s[i:])]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit in %q (use K, M or G)", s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	bytes := n * float64(unit)
+	if bytes > math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	limit := int64(bytes)
+	if limit < minLimit {
+		return 0, fmt.Errorf("size %q is below the %dMiB minimum", s, minLimit>>20)
+	}
+	return limit, nil
+}
+
+// liveHeapMetric is the heap still reachable after the last GC, which is
+// what cannot be reclaimed by collecting harder.
+const liveHeapMetric = "/gc/heap/live:bytes"
+
+// Guard checks the live Go heap against a limit. A nil Guard never trips, so
+// callers can hold one unconditionally.
+type Guard struct {
+	limit  int64
+	sample []metrics.Sample
+}
+
+// New sets limit as the runtime's soft memory limit and returns a guard for
+// it, or nil when limit is 0. Memory allocated by FFmpeg in C is outside the
+// Go heap and is not counted; the render keeps that to fixed-size codec and
+// frame buffers.
+func New(limit int64) *Guard {
+	if limit <= 0 {
+		return nil
+	}
+	debug.SetMemoryLimit(limit)
+	return &Guard{
+		limit:  limit,
+		sample: []metrics.Sample{{Name: liveHeapMetric}},
+	}
+}
+
+// Limit returns the configured limit in bytes, 0 for a nil guard.
+func (g *Guard) Limit() int64 {
+	if g == nil {
+		return 0
+	}
+	return g.limit
+}
+
+// LiveHeap returns the live Go heap in bytes as of the last GC.
+func LiveHeap() int64 {
+	sample := []metrics.Sample{{Name: liveHeapMetric}}
+	metrics.Read(sample)
+	return liveBytes(sample[0])
+}
+
+func liveBytes(s metrics.Sample) int64 {
+	if s.Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return int64(min(s.Value.Uint64(), math.MaxInt64)) //nolint:gosec // clamped to MaxInt64
+}
+
+// Check returns an error once the live heap exceeds the limit. It reads a
+// runtime metric without stopping the world, so it is cheap enough to call
+// on every progress update.
+func (g *Guard) Check() error {
+	if g == nil {
+		return nil
+	}
+	metrics.Read(g.sample)
+	if live := liveBytes(g.sample[0]); live > g.limit {
+		return fmt.Errorf("live heap %dMiB exceeds --max-memory %dMiB", live>>20, g.limit>>20)
+	}
+	return nil
+}
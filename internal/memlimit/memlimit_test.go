@@ -0,0 +1,87 @@
+package memlimit
+
+import (
+	"math"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+// TestParseSize verifies sizes in bytes or with K, M or G suffixes, in either
+// case, with optional B or iB and fractions, and that empty means no limit.
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"", 0},
+		{"0", 0},
+		{"512M", 512 << 20},
+		{"512mb", 512 << 20},
+		{"2G", 2 << 30},
+		{"2GiB", 2 << 30},
+		{"1.5g", 3 << 29},
+		{" 100 MiB ", 100 << 20},
+		{"1048576k", 1 << 30},
+		{"134217728", 128 << 20},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil {
+			t.Errorf("ParseSize(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestParseSizeInvalid verifies unknown units, negative or malformed numbers,
+// a bare unit, sizes too small to render in and ones that overflow fail.
+func TestParseSizeInvalid(t *testing.T) {
+	for _, in := range []string{"lots", "12T", "-1G", "1.2.3M", "M", "10M", "1e30G"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) succeeded, want error", in)
+		}
+	}
+}
+
+// TestNilGuard verifies no limit gives a nil guard that never trips.
+func TestNilGuard(t *testing.T) {
+	g := New(0)
+	if g != nil {
+		t.Fatal("New(0) returned a guard, want nil")
+	}
+	if err := g.Check(); err != nil {
+		t.Errorf("nil guard Check() = %v, want nil", err)
+	}
+	if g.Limit() != 0 {
+		t.Errorf("nil guard Limit() = %d, want 0", g.Limit())
+	}
+}
+
+// TestGuardCheck verifies a guard sets the runtime's soft memory limit,
+// passes while the live heap is under it and trips once the heap is over.
+func TestGuardCheck(t *testing.T) {
+	defer debug.SetMemoryLimit(math.MaxInt64)
+
+	g := New(64 << 30)
+	if got := debug.SetMemoryLimit(-1); got != 64<<30 {
+		t.Errorf("runtime memory limit = %d, want %d", got, int64(64<<30))
+	}
+	if err := g.Check(); err != nil {
+		t.Errorf("Check() under limit = %v, want nil", err)
+	}
+	// The live heap is only measured by a collection.
+	runtime.GC()
+	if LiveHeap() <= 0 {
+		t.Error("LiveHeap() = 0, want the test's own heap")
+	}
+
+	// A guard whose limit is below the current heap trips.
+	tight := &Guard{limit: 1, sample: g.sample}
+	if err := tight.Check(); err == nil {
+		t.Error("Check() over limit = nil, want error")
+	}
+}
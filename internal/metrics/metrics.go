@@ -0,0 +1,115 @@
+// Package metrics serves a render's progress at /metrics in the Prometheus
+// text format (--metrics-addr), so a render left running on a server can be
+// watched with the usual scrapers and dashboards. The endpoint lives as long
+// as the render's process.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stages of a render, reported as jivefire_stage.
+const (
+	StageStarting  = 0
+	StageAnalysing = 1
+	StageRendering = 2
+	StageFinished  = 3
+)
+
+// Render holds the figures the endpoint reports, updated from the progress
+// the render sends its display. Every method is safe for concurrent use.
+type Render struct {
+	version   string
+	frameRate float64
+
+	mu          sync.Mutex
+	stage       int
+	analysed    int
+	rendered    int
+	expected    int
+	fps         float64
+	outputBytes int64
+}
+
+// New returns the metrics of a render by jivefire version at frameRate
+// video frames per second.
+func New(version string, frameRate int) *Render {
+	return &Render{version: version, frameRate: float64(frameRate)}
+}
+
+// Analysing records Pass 1 reaching frame of about total.
+func (r *Render) Analysing(frame, total int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stage = StageAnalysing
+	r.analysed = frame
+	r.expected = total
+}
+
+// Rendering records Pass 2 having rendered frames of total in elapsed, with
+// outputBytes written so far.
+func (r *Render) Rendering(frames, total int, elapsed time.Duration, outputBytes int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stage = StageRendering
+	r.rendered = frames
+	r.expected = total
+	r.outputBytes = outputBytes
+	if elapsed > 0 {
+		r.fps = float64(frames) / elapsed.Seconds()
+	}
+}
+
+// Finished records the render completing with frames and outputBytes.
+func (r *Render) Finished(frames int, outputBytes int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stage = StageFinished
+	r.rendered = frames
+	r.expected = frames
+	r.outputBytes = outputBytes
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (r *Render) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	var b strings.Builder
+	metric := func(name, kind, help string, value any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+	}
+	fmt.Fprintf(&b, "# HELP jivefire_info The jivefire version rendering.\n# TYPE jivefire_info gauge\njivefire_info{version=%q} 1\n", r.version)
+	metric("jivefire_stage", "gauge", "Render stage: 0 starting, 1 analysing, 2 rendering, 3 finished.", r.stage)
+	metric("jivefire_frames_analysed_total", "counter", "Frames read by the analysis pass.", r.analysed)
+	metric("jivefire_frames_rendered_total", "counter", "Frames drawn and encoded.", r.rendered)
+	metric("jivefire_frames_expected", "gauge", "Frames the render is expected to have.", r.expected)
+	metric("jivefire_render_fps", "gauge", "Frames rendered per second.", r.fps)
+	metric("jivefire_render_realtime_ratio", "gauge", "Seconds of video rendered per second.", r.fps/r.frameRate)
+	metric("jivefire_output_bytes", "gauge", "Size of the output so far.", r.outputBytes)
+	r.mu.Unlock()
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Serve listens on addr and serves r at /metrics until the process exits.
+// The listener is opened before Serve returns, so a bad or busy address is
+// reported up front.
+func Serve(addr string, r *Render) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	return nil
+}
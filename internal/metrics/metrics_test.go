@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWriteTo verifies the exposition carries each figure with its type,
+// and the realtime ratio follows from the frame rate.
+func TestWriteTo(t *testing.T) {
+	r := New("v1.2.3", 30)
+	r.Analysing(500, 1000)
+	r.Rendering(300, 1000, 5*time.Second, 4096)
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`jivefire_info{version="v1.2.3"} 1`,
+		"# TYPE jivefire_frames_rendered_total counter",
+		"jivefire_stage 2\n",
+		"jivefire_frames_analysed_total 500\n",
+		"jivefire_frames_rendered_total 300\n",
+		"jivefire_frames_expected 1000\n",
+		"jivefire_render_fps 60\n",
+		"jivefire_render_realtime_ratio 2\n",
+		"jivefire_output_bytes 4096\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("WriteTo() output lacks %q:\n%s", want, b.String())
+		}
+	}
+
+	r.Finished(310, 5000)
+	b.Reset()
+	_, _ = r.WriteTo(&b)
+	if !strings.Contains(b.String(), "jivefire_stage 3\n") || !strings.Contains(b.String(), "jivefire_frames_expected 310\n") {
+		t.Errorf("after Finished():\n%s", b.String())
+	}
+}
+
+// TestServe verifies the endpoint answers at /metrics and a busy address is
+// refused before any render starts.
+func TestServe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("no loopback network")
+	}
+	addr := ln.Addr().String()
+	if err := Serve(addr, New("dev", 30)); err == nil {
+		t.Error("Serve() on a busy address succeeded")
+	}
+	_ = ln.Close()
+
+	if err := Serve(addr, New("dev", 30)); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "jivefire_stage 0") {
+		t.Errorf("GET /metrics = %d:\n%s", resp.StatusCode, body)
+	}
+}
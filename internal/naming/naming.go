@@ -0,0 +1,126 @@
+// Package naming expands output filename templates such as
+// "{slug}-e{episode:03d}-{date}.mp4" so batch jobs need not build file names
+// themselves.
+package naming
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Vars are the values available to an output template.
+type Vars struct {
+	Title   string
+	Episode *int   // nil when no episode number was given
+	Input   string // input file path; {input} uses its base name without extension
+	Date    time.Time
+}
+
+// Variables lists the template variables, for help and error text.
+const Variables = "{title}, {slug}, {episode}, {input}, {date}"
+
+// placeholderPattern matches {name} and {name:spec}, where spec is a printf
+// verb without the leading % (e.g. 03d).
+var placeholderPattern = regexp.MustCompile(`\{([a-z]+)(?::([^}]*))?\}`)
+
+// specPattern limits format specs to padded integer and string verbs.
+var specPattern = regexp.MustCompile(`^-?0?[0-9]*[ds]$`)
+
+// Expand substitutes every placeholder in tmpl. It fails on unknown
+// variables, invalid specs, {episode} without an episode number, {input}
+// without an input file name, and results that are not a usable file name.
+func Expand(tmpl string, v Vars) (string, error) {
+	var firstErr error
+	out := placeholderPattern.ReplaceAllStringFunc(tmpl, func(match string) string {
+		parts := placeholderPattern.FindStringSubmatch(match)
+		s, err := expandOne(parts[1], parts[2], v)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return s
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	if strings.ContainsAny(out, "{}") {
+		return "", fmt.Errorf("unbalanced braces in template %q", tmpl)
+	}
+	if filepath.Base(out) == "." || os.IsPathSeparator(out[len(out)-1]) {
+		return "", fmt.Errorf("template %q expands to no file name", tmpl)
+	}
+	return out, nil
+}
+
+// expandOne formats a single variable with an optional spec.
+func expandOne(name, spec string, v Vars) (string, error) {
+	var value any
+	switch name {
+	case "title":
+		value = sanitise(v.Title)
+	case "slug":
+		value = Slug(v.Title)
+	case "input":
+		base := filepath.Base(v.Input)
+		stem := strings.TrimSuffix(base, filepath.Ext(base))
+		if stem == "" {
+			return "", fmt.Errorf("template uses {input} but there is no input file name")
+		}
+		value = stem
+	case "date":
+		value = v.Date.Format(time.DateOnly)
+	case "episode":
+		if v.Episode == nil {
+			return "", fmt.Errorf("template uses {episode} but no episode number is set")
+		}
+		value = *v.Episode
+	default:
+		return "", fmt.Errorf("unknown template variable {%s} (available: %s)", name, Variables)
+	}
+
+	if spec == "" {
+		return fmt.Sprint(value), nil
+	}
+	if !specPattern.MatchString(spec) {
+		return "", fmt.Errorf("invalid format %q for {%s}", spec, name)
+	}
+	_, isInt := value.(int)
+	if isInt != strings.HasSuffix(spec, "d") {
+		return "", fmt.Errorf("format %q does not suit {%s}", spec, name)
+	}
+	return fmt.Sprintf("%"+spec, value), nil
+}
+
+// sanitise replaces path separators and characters that commonly break
+// shells or filesystems, keeping the title otherwise readable.
+func sanitise(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || unicode.IsControl(r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
+
+// Slug returns a lower-case, hyphen-separated form of s containing only
+// letters and digits, e.g. "macOS Made Me Snap!" becomes "macos-made-me-snap".
+func Slug(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package naming
+
+import (
+	"testing"
+	"time"
+)
+
+// TestExpand verifies variable substitution, format specs and the error cases.
+func TestExpand(t *testing.T) {
+	episode := 7
+	vars := Vars{
+		Title:   "macOS Made Me Snap!",
+		Episode: &episode,
+		Input:   "/audio/LMP65.wav",
+		Date:    time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		vars    Vars
+		want    string
+		wantErr bool
+	}{
+		{name: "all variables", tmpl: "{slug}-e{episode:03d}-{date}.mp4", vars: vars, want: "macos-made-me-snap-e007-2026-03-09.mp4"},
+		{name: "input base name", tmpl: "out/{input}.mp4", vars: vars, want: "out/LMP65.mp4"},
+		{name: "title keeps case", tmpl: "{title}.mp4", vars: vars, want: "macOS Made Me Snap!.mp4"},
+		{name: "title path separators replaced", tmpl: "{title}.mp4", vars: Vars{Title: "AC/DC"}, want: "AC-DC.mp4"},
+		{name: "plain episode", tmpl: "ep{episode}.mp4", vars: vars, want: "ep7.mp4"},
+		{name: "no placeholders", tmpl: "static.mp4", vars: vars, want: "static.mp4"},
+		{name: "unknown variable", tmpl: "{show}.mp4", vars: vars, wantErr: true},
+		{name: "missing episode", tmpl: "e{episode}.mp4", vars: Vars{Title: "x"}, wantErr: true},
+		{name: "missing input", tmpl: "{input}.png", vars: Vars{Title: "x"}, wantErr: true},
+		{name: "input without a stem", tmpl: "{input}.png", vars: Vars{Input: "/audio/.wav"}, wantErr: true},
+		{name: "integer spec on string", tmpl: "{title:03d}.mp4", vars: vars, wantErr: true},
+		{name: "unsafe spec", tmpl: "{episode:v}.mp4", vars: vars, wantErr: true},
+		{name: "unbalanced brace", tmpl: "{slug.mp4", vars: vars, wantErr: true},
+		{name: "empty name", tmpl: "out/", vars: vars, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.tmpl, tt.vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expand(%q) error = %v, wantErr %t", tt.tmpl, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Expand(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSlug verifies punctuation and whitespace collapse to single hyphens.
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"Linux Matters":         "linux-matters",
+		"  Spaces -- & dashes ": "spaces-dashes",
+		"Café Crème 2":          "café-crème-2",
+		"!!!":                   "",
+	}
+	for in, want := range tests {
+		if got := Slug(in); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package notify tells automation that a render has finished or failed: it
+// POSTs the run report to a URL (--notify-url) and runs a shell command with
+// the outcome in its environment (--notify-cmd).
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/report"
+)
+
+// Hooks are the notifications to fire at the end of a run.
+type Hooks struct {
+	URL        string // POST the report JSON here (empty for none)
+	Command    string // Run this through the shell (empty for none)
+	ReportPath string // Where --report wrote the report, passed to Command (optional)
+}
+
+// Timeouts bound each hook so a hung endpoint or script cannot keep the
+// process alive after the render.
+const (
+	postTimeout    = 30 * time.Second
+	commandTimeout = 5 * time.Minute
+)
+
+// ValidateURL checks a --notify-url value.
+func ValidateURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http or https URL", s)
+	}
+	return nil
+}
+
+// Fire sends r to every configured hook. A failing hook does not stop the
+// others; their errors are joined.
+func (h Hooks) Fire(r report.Report) error {
+	var errs []error
+	if h.URL != "" {
+		if err := Post(h.URL, r); err != nil {
+			errs = append(errs, fmt.Errorf("--notify-url: %w", err))
+		}
+	}
+	if h.Command != "" {
+		if err := Run(h.Command, r, h.ReportPath); err != nil {
+			errs = append(errs, fmt.Errorf("--notify-cmd: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Post sends r as JSON to target, expecting a 2xx response.
+func Post(target string, r report.Report) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), postTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "jivefire/"+r.Version)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Read a little of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s answered %s", target, resp.Status)
+	}
+	return nil
+}
+
+// Run executes command through the shell with Env's variables added to the
+// environment. Its output goes to stderr, since stdout may be carrying the
+// video.
+func Run(command string, r report.Report, reportPath string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	name, args := shell(command)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), Env(r, reportPath)...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// shell returns the program and arguments running command: sh -c, or cmd /C
+// on Windows, where variables are written %JIVEFIRE_STATUS% instead.
+func shell(command string) (string, []string) {
+	if runtime.GOOS == "windows" {
+		return "cmd", []string{"/C", command}
+	}
+	return "sh", []string{"-c", command}
+}
+
+// Env returns the variables describing r for a --notify-cmd command:
+//
+//	JIVEFIRE_STATUS    completed, failed or cancelled
+//	JIVEFIRE_INPUT     the input audio file
+//	JIVEFIRE_OUTPUT    the output video (or --frames-dir directory)
+//	JIVEFIRE_DURATION  length of the input audio in seconds, 0 unless completed
+//	JIVEFIRE_ELAPSED   seconds the run took
+//	JIVEFIRE_ENCODER   the video encoder, empty unless completed
+//	JIVEFIRE_ERROR     why the run did not complete, empty if it did
+//	JIVEFIRE_REPORT    the --report file, empty without one
+func Env(r report.Report, reportPath string) []string {
+	seconds := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 3, 64)
+	}
+	return []string{
+		"JIVEFIRE_STATUS=" + r.Status,
+		"JIVEFIRE_INPUT=" + r.Input,
+		"JIVEFIRE_OUTPUT=" + r.Output,
+		"JIVEFIRE_DURATION=" + seconds(r.Audio.Duration),
+		"JIVEFIRE_ELAPSED=" + seconds(r.Timings.Total),
+		"JIVEFIRE_ENCODER=" + r.Encoder.Name,
+		"JIVEFIRE_ERROR=" + r.Error,
+		"JIVEFIRE_REPORT=" + reportPath,
+	}
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/report"
+)
+
+func TestValidateURL(t *testing.T) {
+	for _, s := range []string{"https://example.com/hooks/jivefire", "http://localhost:8080"} {
+		if err := ValidateURL(s); err != nil {
+			t.Errorf("ValidateURL(%q) = %v, want nil", s, err)
+		}
+	}
+	for _, s := range []string{"example.com/hook", "ftp://example.com", "https://", ":"} {
+		if err := ValidateURL(s); err == nil {
+			t.Errorf("ValidateURL(%q) succeeded, want error", s)
+		}
+	}
+}
+
+// TestPost verifies the report arrives as JSON and that a non-2xx answer is
+// an error.
+func TestPost(t *testing.T) {
+	var got report.Report
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding posted report: %v", err)
+		}
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	r := report.Report{Version: "dev", Status: report.StatusCompleted, Output: "episode.mp4"}
+	if err := Post(srv.URL+"/done", r); err != nil {
+		t.Fatalf("Post() = %v", err)
+	}
+	if got.Status != report.StatusCompleted || got.Output != "episode.mp4" || contentType != "application/json" {
+		t.Errorf("posted %+v as %q, want the report as application/json", got, contentType)
+	}
+	if err := Post(srv.URL+"/broken", r); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Post() to a failing endpoint = %v, want a 500 error", err)
+	}
+}
+
+// TestRun verifies the command sees the outcome in its environment.
+func TestRun(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "env")
+	r := report.Report{
+		Status:  report.StatusFailed,
+		Error:   "initialising encoder: no device",
+		Input:   "in.wav",
+		Output:  "out dir/episode.mp4",
+		Timings: report.Timings{Total: 2.5},
+	}
+	cmd := `printf '%s\n' "$JIVEFIRE_STATUS" "$JIVEFIRE_OUTPUT" "$JIVEFIRE_ELAPSED" "$JIVEFIRE_ERROR" > "$OUT"`
+	t.Setenv("OUT", out)
+	if err := Run(cmd, r, ""); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"failed", "out dir/episode.mp4", "2.500", "initialising encoder: no device"}
+	if got := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); !slices.Equal(got, want) {
+		t.Errorf("command saw %q, want %q", got, want)
+	}
+
+	if err := Run("exit 3", r, ""); err == nil {
+		t.Error("Run() of a failing command succeeded, want error")
+	}
+}
+
+// A failing hook does not stop the other from firing.
+func TestFire(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "fired")
+	h := Hooks{URL: "http://127.0.0.1:0/unreachable", Command: "touch " + out}
+	if err := h.Fire(report.Report{Status: report.StatusCompleted}); err == nil || !strings.Contains(err.Error(), "--notify-url") {
+		t.Errorf("Fire() = %v, want a --notify-url error", err)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("--notify-cmd did not run after --notify-url failed: %v", err)
+	}
+}
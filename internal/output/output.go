@@ -0,0 +1,65 @@
+// Package output resolves where a render's files go. A local path is written
+// in place; a remote destination such as s3://bucket/episode.mp4 is staged in
+// a local directory, so the muxer can seek as usual, and delivered once the
+// render has finished, along with the thumbnail and any other files written
+// beside it.
+package output
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Destination is where a render's output ends up.
+type Destination interface {
+	// Path is the local file the render writes its output to. Sidecars such
+	// as the thumbnail go beside it.
+	Path() string
+	// String is the destination as the user gave it, for messages and the
+	// run report.
+	String() string
+	// Publish delivers the files written beside Path to the destination. It
+	// does nothing for a local destination.
+	Publish() error
+	// Discard removes anything staged for a render that did not finish. It
+	// does nothing for a local destination.
+	Discard() error
+}
+
+// schemes maps URL schemes to remote destinations.
+var schemes = map[string]func(*url.URL) (Destination, error){
+	"s3": newS3Destination,
+}
+
+// Open resolves dest, which is a local path (or "-" for stdout) or a URL with
+// a registered scheme.
+func Open(dest string) (Destination, error) {
+	scheme, _, ok := strings.Cut(dest, "://")
+	if !ok {
+		return local(dest), nil
+	}
+	open, ok := schemes[strings.ToLower(scheme)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported output scheme %q", scheme)
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return nil, err
+	}
+	return open(u)
+}
+
+// IsRemote reports whether dest names a remote destination rather than a
+// local path.
+func IsRemote(dest string) bool {
+	return strings.Contains(dest, "://")
+}
+
+// local is a destination on the local file system, written in place.
+type local string
+
+func (l local) Path() string   { return string(l) }
+func (l local) String() string { return string(l) }
+func (l local) Publish() error { return nil }
+func (l local) Discard() error { return nil }
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenLocal(t *testing.T) {
+	for _, dest := range []string{"episode.mp4", "/tmp/out/episode.m3u8", "-"} {
+		d, err := Open(dest)
+		if err != nil {
+			t.Fatalf("Open(%q): %v", dest, err)
+		}
+		if d.Path() != dest || d.String() != dest || IsRemote(dest) {
+			t.Errorf("Open(%q) = %q, %q", dest, d.Path(), d.String())
+		}
+		if err := d.Publish(); err != nil {
+			t.Errorf("local Publish: %v", err)
+		}
+	}
+}
+
+func TestOpenRemote(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	d, err := Open("s3://bucket/shows/lm-67.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Discard()
+	if filepath.Base(d.Path()) != "lm-67.mp4" || d.String() != "s3://bucket/shows/lm-67.mp4" {
+		t.Errorf("s3 destination writes %s for %s", d.Path(), d)
+	}
+	if _, err := os.Stat(filepath.Dir(d.Path())); err != nil {
+		t.Errorf("staging directory: %v", err)
+	}
+	if err := d.Discard(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(d.Path())); !os.IsNotExist(err) {
+		t.Errorf("staging directory left behind after Discard")
+	}
+
+	for _, dest := range []string{"s3://bucket", "s3://bucket/", "s3://bucket/shows/", "ftp://host/x.mp4"} {
+		if _, err := Open(dest); err == nil {
+			t.Errorf("Open(%q) succeeded", dest)
+		}
+	}
+
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	if _, err := Open("s3://bucket/x.mp4"); err == nil {
+		t.Error("Open without credentials succeeded")
+	}
+}
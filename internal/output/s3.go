@@ -0,0 +1,456 @@
+package output
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 multipart limits every part but the last to at least 5 MiB and an
+// upload to 10,000 parts. Parts start at 16 MiB and grow for files over
+// 160 GB.
+const (
+	s3PartSize = 16 << 20
+	s3MaxParts = 10000
+	// s3Attempts is how often each request is tried before the upload is
+	// given up; headless boxes often sit behind flaky links.
+	s3Attempts = 3
+	// s3RequestTimeout bounds each request, a part at a time, so a stalled
+	// connection is retried rather than hanging the run.
+	s3RequestTimeout = 10 * time.Minute
+)
+
+// s3Destination stages the render in a temporary directory and uploads every
+// file in it to the bucket, beside the output's key.
+type s3Destination struct {
+	raw    string
+	bucket string
+	key    string
+	dir    string
+	client *s3Client
+}
+
+// newS3Destination parses s3://bucket/key. Credentials and region come from
+// the standard AWS environment variables; AWS_ENDPOINT_URL_S3 (or
+// AWS_ENDPOINT_URL) points at S3-compatible storage such as MinIO or R2.
+func newS3Destination(u *url.URL) (Destination, error) {
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" || strings.HasSuffix(key, "/") {
+		return nil, fmt.Errorf("%s: want s3://bucket/path/name.ext", u)
+	}
+	client, err := s3ClientFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "jivefire-s3-")
+	if err != nil {
+		return nil, err
+	}
+	return &s3Destination{raw: u.String(), bucket: u.Host, key: key, dir: dir, client: client}, nil
+}
+
+func (d *s3Destination) Path() string   { return filepath.Join(d.dir, path.Base(d.key)) }
+func (d *s3Destination) String() string { return d.raw }
+func (d *s3Destination) Discard() error { return os.RemoveAll(d.dir) }
+
+// Publish uploads the staged files and removes them. The output itself goes
+// last, so a player or pipeline watching for it never finds it before its
+// thumbnail or, for HLS and DASH, before its segments. On failure the staged
+// files are kept and the error names where they are.
+func (d *s3Destination) Publish() error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	main := path.Base(d.key)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[j].Name() == main && entries[i].Name() != main
+	})
+	prefix := path.Dir(d.key)
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		key := e.Name()
+		if prefix != "." {
+			key = prefix + "/" + key
+		}
+		if err := d.client.upload(d.bucket, key, filepath.Join(d.dir, e.Name())); err != nil {
+			return fmt.Errorf("uploading s3://%s/%s: %w (the render is kept in %s)", d.bucket, key, err, d.dir)
+		}
+	}
+	return os.RemoveAll(d.dir)
+}
+
+// s3Client signs requests with AWS Signature Version 4.
+type s3Client struct {
+	accessKey, secretKey, sessionToken string
+	region                             string
+	endpoint                           *url.URL // nil for AWS itself
+	partSize                           int64
+	http                               *http.Client
+	now                                func() time.Time
+}
+
+func s3ClientFromEnv() (*s3Client, error) {
+	c := &s3Client{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		region:       cmp.Or(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1"),
+		partSize:     s3PartSize,
+		http:         http.DefaultClient,
+		now:          time.Now,
+	}
+	if c.accessKey == "" || c.secretKey == "" {
+		return nil, errors.New("S3 output needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY set")
+	}
+	if endpoint := cmp.Or(os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")); endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+		}
+		c.endpoint = u
+	}
+	return c, nil
+}
+
+// objectURL returns the URL of key in bucket. AWS is addressed
+// virtual-hosted style, except for bucket names with dots, which TLS
+// certificates do not cover; custom endpoints use path style, which
+// S3-compatible servers all accept.
+func (c *s3Client) objectURL(bucket, key string) *url.URL {
+	if c.endpoint != nil {
+		u := *c.endpoint
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + bucket + "/" + key
+		return &u
+	}
+	if strings.Contains(bucket, ".") {
+		return &url.URL{Scheme: "https", Host: "s3." + c.region + ".amazonaws.com", Path: "/" + bucket + "/" + key}
+	}
+	return &url.URL{Scheme: "https", Host: bucket + ".s3." + c.region + ".amazonaws.com", Path: "/" + key}
+}
+
+// upload puts the file at local to key: in one request when it fits a
+// part, otherwise as a multipart upload, aborted if any part fails.
+func (c *s3Client) upload(bucket, key, local string) error {
+	f, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	partSize := max(c.partSize, (size+s3MaxParts-1)/s3MaxParts)
+	if size <= partSize {
+		_, err := c.do(http.MethodPut, bucket, key, nil, io.NewSectionReader(f, 0, size), contentType)
+		return err
+	}
+
+	body, err := c.do(http.MethodPost, bucket, key, url.Values{"uploads": {""}}, nil, contentType)
+	if err != nil {
+		return err
+	}
+	var started struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(body, &started); err != nil || started.UploadID == "" {
+		return fmt.Errorf("starting multipart upload: unexpected response %q", body)
+	}
+	uploadID := started.UploadID
+
+	type part struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+	var parts []part
+	for offset, n := int64(0), 1; offset < size; offset, n = offset+partSize, n+1 {
+		q := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {uploadID}}
+		etag, err := c.doETag(http.MethodPut, bucket, key, q, io.NewSectionReader(f, offset, min(partSize, size-offset)))
+		if err != nil {
+			_, _ = c.do(http.MethodDelete, bucket, key, url.Values{"uploadId": {uploadID}}, nil, "")
+			return fmt.Errorf("part %d: %w", n, err)
+		}
+		parts = append(parts, part{PartNumber: n, ETag: etag})
+	}
+
+	complete, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []part   `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	// A completion can fail inside a 200 response, with an <Error> body.
+	body, err = c.do(http.MethodPost, bucket, key, url.Values{"uploadId": {uploadID}}, bytes.NewReader(complete), "application/xml")
+	if err == nil && bytes.Contains(body, []byte("<Error>")) {
+		err = fmt.Errorf("completing multipart upload: %s", s3ErrorMessage(body))
+	}
+	if err != nil {
+		_, _ = c.do(http.MethodDelete, bucket, key, url.Values{"uploadId": {uploadID}}, nil, "")
+	}
+	return err
+}
+
+// doETag sends a part and returns the ETag that identifies it in the
+// completion.
+func (c *s3Client) doETag(method, bucket, key string, query url.Values, body io.ReadSeeker) (string, error) {
+	var etag string
+	err := c.retry(func() error {
+		resp, err := c.send(method, bucket, key, query, body, "")
+		if err != nil {
+			return err
+		}
+		etag = resp.Header.Get("ETag")
+		_ = resp.Body.Close()
+		if etag == "" {
+			return errors.New("no ETag in the response")
+		}
+		return nil
+	})
+	return etag, err
+}
+
+// do sends a request, retrying failures, and returns the response body.
+func (c *s3Client) do(method, bucket, key string, query url.Values, body io.ReadSeeker, contentType string) ([]byte, error) {
+	var data []byte
+	err := c.retry(func() error {
+		resp, err := c.send(method, bucket, key, query, body, contentType)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		data, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return err
+	})
+	return data, err
+}
+
+// retry calls fn up to s3Attempts times, backing off between attempts, until
+// it succeeds or fails permanently.
+func (c *s3Client) retry(fn func() error) error {
+	var err error
+	for attempt := range s3Attempts {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		if err = fn(); err == nil {
+			return nil
+		}
+		var status *s3StatusError
+		if errors.As(err, &status) && status.code < 500 {
+			return err
+		}
+	}
+	return err
+}
+
+// s3StatusError is a non-2xx response. Only 5xx responses are retried.
+type s3StatusError struct {
+	code    int
+	message string
+}
+
+func (e *s3StatusError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.code, http.StatusText(e.code), e.message)
+}
+
+// send signs and sends one request, rewinding body first so a retry sends it
+// whole. The caller closes the response body.
+func (c *s3Client) send(method, bucket, key string, query url.Values, body io.ReadSeeker, contentType string) (*http.Response, error) {
+	payloadHash := emptySHA256
+	var size int64
+	if body != nil {
+		h := sha256.New()
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		n, err := io.Copy(h, body)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		payloadHash, size = hex.EncodeToString(h.Sum(nil)), n
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	u := c.objectURL(bucket, key)
+	u.RawQuery = query.Encode()
+	// An empty body must still go with a Content-Length, not chunked.
+	reqBody := io.Reader(http.NoBody)
+	if size > 0 {
+		reqBody = io.NopCloser(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	c.sign(req, payloadHash)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{resp.Body, cancel}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		_ = resp.Body.Close()
+		return nil, &s3StatusError{code: resp.StatusCode, message: s3ErrorMessage(msg)}
+	}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's context when its response body closes.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// s3ErrorMessage returns the message of an S3 <Error> body, or the body
+// itself when it is not one.
+func s3ErrorMessage(body []byte) string {
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(body, &e) == nil && e.Code != "" {
+		return e.Code + ": " + e.Message
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// emptySHA256 is the payload hash of a request without a body.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign adds the AWS Signature Version 4 Authorization header to req, signing
+// the host and every header already set. It rewrites the URL's path and
+// query into their canonical encodings, so what is sent is what was signed.
+func (c *s3Client) sign(req *http.Request, payloadHash string) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	req.URL.RawPath = s3Escape(req.URL.Path, false)
+	req.URL.RawQuery = canonicalQuery(req.URL.Query())
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.RawPath,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := amzDate[:8] + "/" + c.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + c.secretKey)
+	for _, part := range []string{amzDate[:8], c.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+c.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery encodes query sorted by key, as SigV4 requires.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape percent-encodes s as SigV4 does: everything but unreserved
+// characters, and '/' too unless it separates path segments.
+func s3Escape(s string, slash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || (ch == '/' && !slash) {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,207 @@
+// Package playback plays the audio being encoded through the system's sound
+// output, by piping it, as raw float samples, to a player process, so a
+// render can be heard alongside its preview.
+package playback
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Players are tried in order and looked up on PATH: PipeWire's pw-play, then
+// ffplay, whose SDL output reaches CoreAudio on macOS and PulseAudio or ALSA
+// elsewhere.
+var Players = []string{"pw-play", "ffplay"}
+
+// Latency is how long after a sample is written the player is taken to
+// sound it: pw-play is asked for this much buffering, and ffplay's SDL
+// output holds about as much.
+const Latency = 100 * time.Millisecond
+
+// resync is how far behind the audio clock the render may fall, through a
+// slow frame or a pause, before the clock is restarted from the present;
+// the player will have run dry by then and plays on from what comes next.
+const resync = 250 * time.Millisecond
+
+// queueDepth is how many writes wait for the player before more are dropped,
+// about half a second of video.
+const queueDepth = 16
+
+// Player streams interleaved float32 samples to a player process. Writes are
+// handed to a writer goroutine, so a stalled player never holds up encoding,
+// and Pace holds the render loop to the sound's real-time clock.
+type Player struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	chunks chan []byte   // samples waiting for the writer
+	free   chan []byte   // spare sample buffers
+	done   chan struct{} // closed when the writer exits
+	closed atomic.Bool   // the player went away
+	once   sync.Once
+
+	start time.Time // when the audio at position 0 is heard
+}
+
+// playerArgs returns name's arguments for raw float32 samples at sampleRate
+// with channels interleaved, read from stdin.
+func playerArgs(name string, sampleRate, channels int) []string {
+	rate, count := strconv.Itoa(sampleRate), strconv.Itoa(channels)
+	if name == "pw-play" {
+		return []string{
+			"--format", "f32",
+			"--rate", rate,
+			"--channels", count,
+			"--latency", strconv.Itoa(int(Latency.Milliseconds())) + "ms",
+			"-",
+		}
+	}
+	layout := map[int]string{1: "mono", 2: "stereo", 6: "5.1"}[channels]
+	if layout == "" {
+		layout = count + "c"
+	}
+	return []string{
+		"-hide_banner", "-loglevel", "error",
+		"-nodisp", "-autoexit",
+		"-fflags", "nobuffer",
+		"-f", "f32le",
+		"-ar", rate,
+		"-ch_layout", layout,
+		"-i", "pipe:0",
+	}
+}
+
+// find returns the first of Players on PATH.
+func find() (string, error) {
+	for _, name := range Players {
+		if _, err := exec.LookPath(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("none of %v found on PATH", Players)
+}
+
+// Available reports whether a player can be found on PATH.
+func Available() error {
+	_, err := find()
+	return err
+}
+
+// Open starts a player for audio at sampleRate with channels interleaved.
+func Open(sampleRate, channels int) (*Player, error) {
+	name, err := find()
+	if err != nil {
+		return nil, err
+	}
+	return start(exec.Command(name, playerArgs(name, sampleRate, channels)...)) //nolint:gosec // fixed programs and arguments
+}
+
+// start runs cmd with its stdin as the sample pipe.
+func start(cmd *exec.Cmd) (*Player, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating %s pipe: %w", cmd.Path, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", cmd.Path, err)
+	}
+
+	p := &Player{
+		cmd:    cmd,
+		stdin:  stdin,
+		chunks: make(chan []byte, queueDepth),
+		free:   make(chan []byte, queueDepth+1),
+		done:   make(chan struct{}),
+	}
+	for range queueDepth + 1 {
+		p.free <- nil
+	}
+
+	go p.write()
+	return p, nil
+}
+
+// write feeds queued samples to the player until the queue closes. After a
+// failed write, normally because the player exited, they are recycled
+// unwritten.
+func (p *Player) write() {
+	defer close(p.done)
+	for buf := range p.chunks {
+		if !p.closed.Load() {
+			if _, err := p.stdin.Write(buf); err != nil {
+				p.closed.Store(true)
+			}
+		}
+		p.free <- buf
+	}
+}
+
+// Write queues a copy of samples to be played. It never blocks: the samples
+// are dropped if the player has fallen that far behind or gone away.
+func (p *Player) Write(samples []float32) {
+	if p.closed.Load() || len(samples) == 0 {
+		return
+	}
+	select {
+	case buf := <-p.free:
+		buf = slices.Grow(buf[:0], 4*len(samples))
+		for _, v := range samples {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+		}
+		select {
+		case p.chunks <- buf:
+		default:
+			p.free <- buf
+		}
+	default:
+	}
+}
+
+// Pace blocks until the audio at position is being heard, timed from the
+// first call, so the frame shown next lines up with its sound.
+func (p *Player) Pace(position time.Duration) {
+	if p.closed.Load() {
+		return
+	}
+	var sleep time.Duration
+	p.start, sleep = pace(time.Now(), p.start, position)
+	time.Sleep(sleep)
+}
+
+// pace returns the clock's start, with the audio at position 0 heard then,
+// and how long to wait at now for the audio at position. A zero start, on
+// the first call, or one the render has fallen resync behind restarts the
+// clock so that position is heard a Latency after now.
+func pace(now, start time.Time, position time.Duration) (time.Time, time.Duration) {
+	if start.IsZero() || now.Sub(start.Add(position)) > resync {
+		return now.Add(Latency - position), Latency
+	}
+	return start, max(start.Add(position).Sub(now), 0)
+}
+
+// Closed reports whether the player has gone away, so the audio is no longer
+// heard.
+func (p *Player) Closed() bool {
+	return p.closed.Load()
+}
+
+// Close stops the player. It is killed rather than left to play out its
+// buffered audio after the encode has finished, which also unblocks a write
+// stuck on a stalled player. Safe to call more than once.
+func (p *Player) Close() {
+	p.once.Do(func() {
+		_ = p.cmd.Process.Kill()
+		close(p.chunks)
+		<-p.done
+		_ = p.stdin.Close()
+		_ = p.cmd.Wait()
+	})
+}
@@ -0,0 +1,92 @@
+package playback
+
+import (
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPlayerArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		want [][]string
+	}{
+		{"pw-play", [][]string{{"--format", "f32"}, {"--rate", "48000"}, {"--channels", "2"}}},
+		{"ffplay", [][]string{{"-f", "f32le"}, {"-ar", "48000"}, {"-ch_layout", "stereo"}, {"-i", "pipe:0"}}},
+	} {
+		args := playerArgs(tt.name, 48000, 2)
+		for _, want := range tt.want {
+			i := slices.Index(args, want[0])
+			if i < 0 || i+1 >= len(args) || args[i+1] != want[1] {
+				t.Errorf("playerArgs(%q) missing %s %s: %q", tt.name, want[0], want[1], args)
+			}
+		}
+	}
+}
+
+func TestPace(t *testing.T) {
+	now := time.Now()
+	start, sleep := pace(now, time.Time{}, 0)
+	if sleep != Latency || !start.Equal(now.Add(Latency)) {
+		t.Errorf("first call: start %v after now, sleep %v; want %v, %v", start.Sub(now), sleep, Latency, Latency)
+	}
+
+	// On time, a frame's audio is waited for from the clock's start.
+	if _, sleep := pace(now, start, 40*time.Millisecond); sleep != Latency+40*time.Millisecond {
+		t.Errorf("ahead of the audio: sleep %v, want %v", sleep, Latency+40*time.Millisecond)
+	}
+	// A little behind, the render carries straight on.
+	later := now.Add(Latency + time.Second + 100*time.Millisecond)
+	if got, sleep := pace(later, start, time.Second); sleep != 0 || !got.Equal(start) {
+		t.Errorf("slightly behind: sleep %v, clock moved %v", sleep, got.Sub(start))
+	}
+	// Far behind, after a pause say, the clock restarts.
+	paused := now.Add(10 * time.Second)
+	if got, sleep := pace(paused, start, time.Second); sleep != Latency || !got.Equal(paused.Add(Latency-time.Second)) {
+		t.Errorf("after a pause: start %v, sleep %v", got.Sub(paused), sleep)
+	}
+}
+
+// TestPlayerWritesAudio verifies samples reach the player's stdin and Close
+// returns once it has been stopped.
+func TestPlayerWritesAudio(t *testing.T) {
+	p, err := start(exec.Command("sh", "-c", "cat >/dev/null"))
+	if err != nil {
+		t.Skipf("sh unavailable: %v", err)
+	}
+	samples := make([]float32, 1600)
+	for range 100 {
+		p.Write(samples)
+	}
+	if p.Closed() {
+		t.Error("Closed() = true while the player is running")
+	}
+	p.Close()
+	p.Close() // a second Close is a no-op
+}
+
+// TestPlayerExits verifies a player that goes away makes Write and Pace
+// no-ops rather than blocking.
+func TestPlayerExits(t *testing.T) {
+	p, err := start(exec.Command("true"))
+	if err != nil {
+		t.Skipf("true unavailable: %v", err)
+	}
+	defer p.Close()
+
+	samples := make([]float32, 1<<18)
+	deadline := time.Now().Add(5 * time.Second)
+	for !p.Closed() && time.Now().Before(deadline) {
+		p.Write(samples)
+		time.Sleep(time.Millisecond)
+	}
+	if !p.Closed() {
+		t.Fatal("writes to an exited player never failed")
+	}
+	began := time.Now()
+	p.Pace(time.Hour)
+	if waited := time.Since(began); waited > time.Second {
+		t.Errorf("Pace waited %v for an exited player", waited)
+	}
+}
@@ -0,0 +1,21 @@
+package preflight
+
+import (
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// FreeSpace returns the bytes available to the current user, after any disk
+// quota, on the volume holding path's directory.
+func FreeSpace(path string) (uint64, error) {
+	dir, err := windows.UTF16PtrFromString(filepath.Dir(path))
+	if err != nil {
+		return 0, err
+	}
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &available, &total, &free); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
@@ -0,0 +1,56 @@
+// Package preflight checks a render can finish before any encoding starts:
+// it estimates the output size and verifies the destination has room for it.
+package preflight
+
+import (
+	"fmt"
+	"time"
+)
+
+// Bitrates used for the size estimate. Video runs at constant quality rather
+// than a fixed bitrate, so videoBitrate is a generous ceiling for 720p
+// visualiser footage; audioBitrate matches the AAC encoder.
+const (
+	videoBitrate = 3_000_000
+	audioBitrate = 192_000
+)
+
+// headroom is the fraction added on top of the estimate before comparing it
+// with free space, covering container overhead and busier-than-usual footage.
+const headroom = 0.1
+
+// EstimateOutputSize returns the expected output size in bytes for a render
+// of the given duration.
+func EstimateOutputSize(duration time.Duration) int64 {
+	return int64(duration.Seconds() * (videoBitrate + audioBitrate) / 8)
+}
+
+// CheckSpace returns the free space on the filesystem holding outputPath, and
+// an error when it is less than the estimated size plus headroom.
+func CheckSpace(outputPath string, estimated int64) (uint64, error) {
+	free, err := FreeSpace(outputPath)
+	if err != nil {
+		return 0, fmt.Errorf("checking free space: %w", err)
+	}
+	needed := uint64(float64(estimated) * (1 + headroom))
+	if free < needed {
+		return free, fmt.Errorf("not enough disk space for %s: need about %s, %s free",
+			outputPath, FormatBytes(int64(needed)), FormatBytes(int64(free))) //nolint:gosec // sizes are far below MaxInt64
+	}
+	return free, nil
+}
+
+// FormatBytes formats a byte count in binary units, e.g. "152.8 MB".
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	switch {
+	case bytes < unit:
+		return fmt.Sprintf("%d B", bytes)
+	case bytes < unit*unit:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/unit)
+	case bytes < unit*unit*unit:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(unit*unit))
+	default:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(unit*unit*unit))
+	}
+}
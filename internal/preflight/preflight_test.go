@@ -0,0 +1,57 @@
+package preflight
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestEstimateOutputSize verifies the estimate scales with duration at the
+// combined video and audio bitrate.
+func TestEstimateOutputSize(t *testing.T) {
+	if got := EstimateOutputSize(0); got != 0 {
+		t.Errorf("EstimateOutputSize(0) = %d, want 0", got)
+	}
+
+	got := EstimateOutputSize(time.Hour)
+	want := int64(3600 * (videoBitrate + audioBitrate) / 8)
+	if got != want {
+		t.Errorf("EstimateOutputSize(1h) = %d, want %d", got, want)
+	}
+}
+
+// TestCheckSpace verifies a small estimate passes on the temp filesystem and
+// an impossible one is refused.
+func TestCheckSpace(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.mp4")
+
+	free, err := CheckSpace(out, 1024)
+	if err != nil {
+		t.Errorf("CheckSpace(1 KB) error = %v", err)
+	}
+	if free == 0 {
+		t.Error("CheckSpace(1 KB) reported no free space")
+	}
+	if _, err := CheckSpace(out, math.MaxInt64/2); err == nil {
+		t.Error("CheckSpace(huge) = nil, want insufficient space error")
+	}
+}
+
+// TestFormatBytes verifies unit selection at each boundary.
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{512, "512 B"},
+		{1536, "1.5 KB"},
+		{10 * 1024 * 1024, "10.0 MB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.bytes); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
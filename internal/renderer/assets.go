@@ -3,15 +3,17 @@ package renderer
 import (
 	"bytes"
 	"embed"
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
-	"image/png"
+	"io"
 	"os"
+	"unicode"
 
-	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
 	"github.com/linuxmatters/jivefire/internal/config"
-	"golang.org/x/image/draw"
+	"github.com/linuxmatters/jivefire/internal/failure"
 	"golang.org/x/image/font"
 )
 
@@ -31,77 +33,200 @@ func loadImageData(path string, isCustom bool) ([]byte, error) {
 	return embeddedAssets.ReadFile(path)
 }
 
-// LoadBackgroundImage loads and scales the background image (from custom path or embedded asset)
-func LoadBackgroundImage(runtimeConfig *config.RuntimeConfig) (*image.RGBA, error) {
-	data, err := loadImageData(runtimeConfig.GetBackgroundImagePath())
+// maxImageSide bounds the artwork accepted, so a mistaken or hostile file
+// cannot allocate gigabytes when decoded: 16384 pixels a side is well beyond
+// any print-quality cover art.
+const maxImageSide = 16384
+
+// ValidateImage checks that the file at path can be used as a background or
+// thumbnail image: a PNG, JPEG or WebP of a sensible size. It reads only the
+// header, so it is cheap enough to run before a render starts. Errors are
+// marked failure.ErrUnsupportedFormat.
+func ValidateImage(path string) error {
+	f, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	img, err := png.Decode(bytes.NewReader(data))
-	if err != nil {
-		return nil, err
+	defer f.Close()
+	if err := checkImage(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
 	}
+	return nil
+}
 
-	rgba := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
-
-	// ApproxBiLinear is the fastest pure-Go scaler per speedtest-resize benchmarks.
-	draw.ApproxBiLinear.Scale(rgba, rgba.Bounds(), img, img.Bounds(), draw.Over, nil)
+// checkImage reads an image header and reports why the image is unusable.
+func checkImage(r io.Reader) error {
+	cfg, format, err := image.DecodeConfig(r)
+	switch {
+	case errors.Is(err, image.ErrFormat):
+		err = errors.New("not a PNG, JPEG or WebP image")
+	case err != nil:
+		err = fmt.Errorf("unreadable image: %w", err)
+	case cfg.Width < 1 || cfg.Height < 1:
+		err = fmt.Errorf("%s image has no pixels (%dx%d)", format, cfg.Width, cfg.Height)
+	case cfg.Width > maxImageSide || cfg.Height > maxImageSide:
+		err = fmt.Errorf("%s image is %dx%d, larger than %d pixels a side", format, cfg.Width, cfg.Height, maxImageSide)
+	}
+	return failure.Mark(failure.ErrUnsupportedFormat, err)
+}
 
-	return rgba, nil
+// decodeImage decodes a PNG, JPEG or WebP, converted to sRGB when it embeds
+// a wide-gamut colour profile.
+func decodeImage(data []byte) (image.Image, error) {
+	if err := checkImage(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, failure.Mark(failure.ErrUnsupportedFormat, err)
+	}
+	return toSRGB(img, data), nil
 }
 
 // LoadFont loads the embedded TrueType font for video title overlay
 func LoadFont(size float64) (font.Face, error) {
-	fontBytes, err := embeddedAssets.ReadFile(config.VideoTitleFontAsset)
+	f, err := parseFont(config.VideoTitleFontAsset, false)
 	if err != nil {
 		return nil, err
 	}
+	return newFontFace(f, size), nil
+}
 
-	f, err := truetype.Parse(fontBytes)
+// parseFont reads and parses a TrueType font from the filesystem when isCustom
+// is true, otherwise from the embedded assets.
+func parseFont(path string, isCustom bool) (*truetype.Font, error) {
+	fontBytes, err := loadFontData(path, isCustom)
 	if err != nil {
 		return nil, err
 	}
 
-	face := truetype.NewFace(f, &truetype.Options{
+	return truetype.Parse(fontBytes)
+}
+
+// loadFontData reads font bytes from the filesystem when isCustom is true,
+// otherwise from the embedded assets.
+func loadFontData(path string, isCustom bool) ([]byte, error) {
+	if isCustom {
+		return os.ReadFile(path)
+	}
+	return embeddedAssets.ReadFile(path)
+}
+
+// newFontFace returns a hinted face for video overlay text at the given size.
+func newFontFace(f *truetype.Font, size float64) font.Face {
+	return truetype.NewFace(f, &truetype.Options{
 		Size:    size,
 		DPI:     72,
 		Hinting: font.HintingFull,
 	})
+}
+
+// MissingGlyphs returns the distinct characters in text that neither the
+// title font (custom or embedded) nor any fallback font has a glyph for;
+// these render as blank boxes. Spaces and other whitespace are ignored.
+func MissingGlyphs(text string, runtimeConfig *config.RuntimeConfig) ([]rune, error) {
+	chain, err := titleFontChain(runtimeConfig)
+	if err != nil {
+		return nil, err
+	}
 
-	return face, nil
+	var missing []rune
+	seen := make(map[rune]bool)
+	for _, r := range text {
+		if unicode.IsSpace(r) || seen[r] {
+			continue
+		}
+		seen[r] = true
+		if _, _, ok := chain.glyph(r); !ok {
+			missing = append(missing, r)
+		}
+	}
+	return missing, nil
 }
 
-// DrawCenterText draws text centered horizontally at the specified Y position
-func DrawCenterText(img *image.RGBA, face font.Face, text string, centerY int, textColor color.RGBA) {
-	d := newTextDrawer(img, face, textColor)
+// LoadTitleFont loads the title font (custom or embedded) at the largest size, from
+// config.TitleFontSize down to config.TitleMinFontSize, at which the wrapped
+// title fits the centre gap within the configured maximum line count. Titles
+// that do not fit even at the minimum size are truncated when drawn.
+func LoadTitleFont(title string, runtimeConfig *config.RuntimeConfig) (font.Face, error) {
+	maxWidth := barsWidth()
+	maxHeight := config.CenterGap - 2*config.TitlePadding
+	maxLines := runtimeConfig.GetTitleMaxLines()
 
-	bounds, _ := d.BoundString(text)
-	textWidth := (bounds.Max.X - bounds.Min.X).Ceil()
-	textHeight := (bounds.Max.Y - bounds.Min.Y).Ceil()
+	chain, err := titleFontChain(runtimeConfig)
+	if err != nil {
+		return nil, err
+	}
 
-	// DrawString places the baseline at y, so offset below centre to visually
-	// centre the glyph block.
-	x := (config.Width - textWidth) / 2
-	y := centerY + (textHeight / 3)
+	for size := config.TitleFontSize; size > config.TitleMinFontSize; size -= 2 {
+		face := newShapedFace(chain, size)
+		lines := wrapText(face, title, maxWidth)
+		if len(lines) <= maxLines && textBlockFits(face, lines, maxWidth, maxHeight) {
+			return face, nil
+		}
+	}
 
-	d.Dot = freetype.Pt(x, y)
-	d.DrawString(text)
+	return newShapedFace(chain, config.TitleMinFontSize), nil
+}
+
+// LoadBadgeFont loads the title font (custom or embedded) at
+// config.BadgeFontSize for the episode number badge, which stays that size
+// whatever LoadTitleFont picks for the title.
+func LoadBadgeFont(runtimeConfig *config.RuntimeConfig) (font.Face, error) {
+	chain, err := titleFontChain(runtimeConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newShapedFace(chain, config.BadgeFontSize), nil
 }
 
-// DrawEpisodeNumber draws the episode number in the top right corner
-func DrawEpisodeNumber(img *image.RGBA, face font.Face, episodeNum string, textColor color.RGBA) {
-	d := newTextDrawer(img, face, textColor)
+// DrawTextBlock draws lines stacked and vertically centred on centerY, aligned
+// within the horizontal span starting at left and width pixels wide.
+func DrawTextBlock(img *image.RGBA, face font.Face, lines []string, centerY, left, width int, align config.TextAlign, textColor color.RGBA) {
+	lineHeight := face.Metrics().Height.Ceil()
+
+	for i, line := range lines {
+		textWidth, bounds := measureTextBounds(face, line)
+		textHeight := (bounds.Max.Y - bounds.Min.Y).Ceil()
+
+		var x int
+		switch align {
+		case config.AlignLeft:
+			x = left
+		case config.AlignRight:
+			x = left + width - textWidth
+		default:
+			x = left + (width-textWidth)/2
+		}
+
+		// drawString places the baseline at y, so offset below each line's
+		// centre to visually centre the glyph block.
+		lineCentreY := centerY + (2*i-len(lines)+1)*lineHeight/2
+		y := lineCentreY + (textHeight / 3)
+
+		drawString(img, face, line, x, y, textColor)
+	}
+}
 
-	bounds, _ := d.BoundString(episodeNum)
-	textWidth := (bounds.Max.X - bounds.Min.X).Ceil()
+// DrawEpisodeNumber draws the episode number in the given corner, inset by
+// padding pixels from the adjacent edges
+func DrawEpisodeNumber(img *image.RGBA, face font.Face, episodeNum string, textColor color.RGBA, pos config.BadgePosition, padding int) {
+	textWidth, bounds := measureTextBounds(face, episodeNum)
 	textHeight := (bounds.Max.Y - bounds.Min.Y).Ceil()
 
-	// Top-right corner, inset 30px from the edges.
-	offset := 30
-	x := config.Width - textWidth - offset
-	y := textHeight + offset
+	// drawString places the baseline at y, so the top corners offset by the
+	// glyph height while the bottom corners sit the baseline on the inset.
+	x := padding
+	y := textHeight + padding
+	switch pos {
+	case config.BadgeTopRight:
+		x = config.Width - textWidth - padding
+	case config.BadgeBottomLeft:
+		y = config.Height - padding - bounds.Max.Y.Ceil()
+	case config.BadgeBottomRight:
+		x = config.Width - textWidth - padding
+		y = config.Height - padding - bounds.Max.Y.Ceil()
+	}
 
-	d.Dot = freetype.Pt(x, y)
-	d.DrawString(episodeNum)
+	drawString(img, face, episodeNum, x, y, textColor)
 }
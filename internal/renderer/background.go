@@ -0,0 +1,208 @@
+package renderer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // register JPEG backgrounds with image.Decode
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register WebP backgrounds with image.Decode
+)
+
+// LoadBackgroundImage loads the background image (a custom PNG, JPEG or WebP,
+// or the embedded asset) fitted to the video frame per the configured fit
+// mode, then blurs and darkens it as configured so bars and text stay
+// readable over busy artwork.
+func LoadBackgroundImage(runtimeConfig *config.RuntimeConfig) (*image.RGBA, error) {
+	bg, err := loadFittedBackground(runtimeConfig, config.Width, config.Height)
+	if err != nil {
+		return nil, err
+	}
+	blurImage(bg, runtimeConfig.BackgroundBlur)
+	dimImage(bg, runtimeConfig.BackgroundDim)
+	return bg, nil
+}
+
+// loadFittedBackground decodes and fits the background to width×height.
+// Scaled results are cached on disk, keyed by the image contents, so
+// re-rendering with large artwork skips the scale.
+func loadFittedBackground(runtimeConfig *config.RuntimeConfig, width, height int) (*image.RGBA, error) {
+	data, err := loadImageData(runtimeConfig.GetBackgroundImagePath())
+	if err != nil {
+		return nil, err
+	}
+	fit := runtimeConfig.GetBackgroundFit()
+
+	cachePath := backgroundCachePath(data, fit, width, height)
+	if cached := loadCachedBackground(cachePath, width, height); cached != nil {
+		return cached, nil
+	}
+
+	img, err := decodeImage(data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding background image: %w", err)
+	}
+
+	// Artwork already at the size needs no scaling, or caching.
+	if img.Bounds().Size() == image.Pt(width, height) {
+		rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		return rgba, nil
+	}
+
+	rgba := fitImage(img, width, height, fit)
+	storeCachedBackground(cachePath, rgba)
+	return rgba, nil
+}
+
+// fitImage scales img onto a width×height canvas. Catmull-Rom is slower than
+// the bilinear scaler used per frame elsewhere, but this runs once per render
+// and keeps downscaled artwork sharp.
+func fitImage(img image.Image, width, height int, fit config.BackgroundFit) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	src := img.Bounds()
+	sw, sh := float64(src.Dx()), float64(src.Dy())
+
+	switch fit {
+	case config.FitCover:
+		// Scale to fill, then take the centred part of the source that fits.
+		scale := max(float64(width)/sw, float64(height)/sh)
+		cw, ch := int(float64(width)/scale), int(float64(height)/scale)
+		x0 := src.Min.X + (src.Dx()-cw)/2
+		y0 := src.Min.Y + (src.Dy()-ch)/2
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, image.Rect(x0, y0, x0+cw, y0+ch), draw.Src, nil)
+	case config.FitContain:
+		scale := min(float64(width)/sw, float64(height)/sh)
+		w, h := int(sw*scale+0.5), int(sh*scale+0.5)
+		x0, y0 := (width-w)/2, (height-h)/2
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+		draw.CatmullRom.Scale(dst, image.Rect(x0, y0, x0+w, y0+h), img, src, draw.Src, nil)
+	default:
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	}
+	return dst
+}
+
+// blurImage applies an approximate Gaussian blur of the given radius in
+// pixels to img in place: three passes of a separable box blur, clamping at
+// the edges so the border does not darken.
+func blurImage(img *image.RGBA, radius int) {
+	if radius < 1 {
+		return
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	tmp := make([]uint8, len(img.Pix))
+	for range 3 {
+		for y := range h {
+			for c := range 4 {
+				boxBlurLine(tmp, img.Pix, y*img.Stride+c, 4, w, radius)
+			}
+		}
+		for x := range w {
+			for c := range 4 {
+				boxBlurLine(img.Pix, tmp, x*4+c, img.Stride, h, radius)
+			}
+		}
+	}
+}
+
+// boxBlurLine averages n values of src, step bytes apart from start, over a
+// sliding window of radius either side, writing the result to dst.
+func boxBlurLine(dst, src []uint8, start, step, n, radius int) {
+	at := func(i int) int {
+		return int(src[start+min(max(i, 0), n-1)*step])
+	}
+	div := 2*radius + 1
+	sum := 0
+	for i := -radius; i <= radius; i++ {
+		sum += at(i)
+	}
+	for i := range n {
+		dst[start+i*step] = uint8((sum + div/2) / div) //nolint:gosec // an average of bytes
+		sum += at(i+radius+1) - at(i-radius)
+	}
+}
+
+// dimImage darkens img in place by the fraction dim (0 leaves it unchanged,
+// 1 turns it black), keeping alpha.
+func dimImage(img *image.RGBA, dim float64) {
+	if dim <= 0 {
+		return
+	}
+	var table [256]uint8
+	for i := range table {
+		table[i] = uint8(float64(i)*(1-min(dim, 1)) + 0.5)
+	}
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = table[img.Pix[i]]
+		img.Pix[i+1] = table[img.Pix[i+1]]
+		img.Pix[i+2] = table[img.Pix[i+2]]
+	}
+}
+
+// backgroundCacheVersion is bumped when the fitting changes what a cached
+// background holds; version 2 converts wide-gamut artwork to sRGB.
+const backgroundCacheVersion = 2
+
+// backgroundCachePath returns where the scaled background for data is
+// cached, or "" when there is no user cache directory.
+func backgroundCachePath(data []byte, fit config.BackgroundFit, width, height int) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	name := fmt.Sprintf("%s-%s-%dx%d-v%d.png", hex.EncodeToString(sum[:12]), fit, width, height, backgroundCacheVersion)
+	return filepath.Join(dir, "jivefire", "backgrounds", name)
+}
+
+// loadCachedBackground returns the cached width×height background at path,
+// or nil when it is missing or unreadable.
+func loadCachedBackground(path string, width, height int) *image.RGBA {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil || img.Bounds() != image.Rect(0, 0, width, height) {
+		return nil
+	}
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
+	return rgba
+}
+
+// storeCachedBackground writes img to the cache. The cache is an
+// optimisation, so failures are ignored; the file is renamed into place so a
+// concurrent render never reads a partial image.
+func storeCachedBackground(path string, img *image.RGBA) {
+	if path == "" || os.MkdirAll(filepath.Dir(path), 0o755) != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "bg-*.png")
+	if err != nil {
+		return
+	}
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	err = enc.Encode(tmp, img)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), path) != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
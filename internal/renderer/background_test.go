@@ -0,0 +1,145 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// halvesImage returns a w×h image, red on the left half and blue on the
+// right.
+func halvesImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// TestFitImage verifies each fit mode's geometry on an image twice as wide as
+// it is tall fitted to 16:9.
+func TestFitImage(t *testing.T) {
+	src := halvesImage(200, 100)
+	black := color.RGBA{A: 255}
+
+	contain := fitImage(src, 160, 90, config.FitContain)
+	if got := contain.RGBAAt(80, 2); got != black {
+		t.Errorf("contain: top edge = %v, want black letterbox", got)
+	}
+	if got := contain.RGBAAt(2, 45); got.R < 200 {
+		t.Errorf("contain: left middle = %v, want the red half", got)
+	}
+
+	cover := fitImage(src, 160, 90, config.FitCover)
+	if got := cover.RGBAAt(80, 1); got == black {
+		t.Error("cover: top edge is letterboxed, want filled")
+	}
+	if left, right := cover.RGBAAt(0, 45), cover.RGBAAt(159, 45); left.R < 200 || right.B < 200 {
+		t.Errorf("cover: edges = %v, %v; want red and blue", left, right)
+	}
+
+	stretch := fitImage(src, 160, 90, config.FitStretch)
+	if got := stretch.RGBAAt(79, 0); got.R < 200 {
+		t.Errorf("stretch: pixel left of centre = %v, want red", got)
+	}
+	if got := stretch.RGBAAt(81, 89); got.B < 200 {
+		t.Errorf("stretch: pixel right of centre = %v, want blue", got)
+	}
+}
+
+// TestLoadBackgroundImageJPEG verifies a JPEG background is decoded, fitted
+// to the frame and cached for the next load.
+func TestLoadBackgroundImageJPEG(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "art.jpg")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(f, halvesImage(400, 400), nil); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	runtimeConfig := &config.RuntimeConfig{BackgroundImagePath: path, BackgroundFit: config.FitContain}
+	bg, err := LoadBackgroundImage(runtimeConfig)
+	if err != nil {
+		t.Fatalf("LoadBackgroundImage() error = %v", err)
+	}
+	if bg.Bounds() != image.Rect(0, 0, config.Width, config.Height) {
+		t.Fatalf("background bounds = %v, want the video frame", bg.Bounds())
+	}
+	if got := bg.RGBAAt(10, config.Height/2); got != (color.RGBA{A: 255}) {
+		t.Errorf("pillarbox pixel = %v, want black", got)
+	}
+
+	data, _ := os.ReadFile(path)
+	cachePath := backgroundCachePath(data, config.FitContain, config.Width, config.Height)
+	if _, err := os.Stat(cachePath); err != nil {
+		t.Fatalf("scaled background not cached: %v", err)
+	}
+	cached, err := LoadBackgroundImage(runtimeConfig)
+	if err != nil {
+		t.Fatalf("cached LoadBackgroundImage() error = %v", err)
+	}
+	if string(cached.Pix) != string(bg.Pix) {
+		t.Error("cached background differs from the freshly scaled one")
+	}
+}
+
+// TestLoadBackgroundImageRejectsUnknown verifies files in other formats fail
+// with a decode error rather than rendering a blank background.
+func TestLoadBackgroundImageRejectsUnknown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "art.gif")
+	if err := os.WriteFile(path, []byte("GIF89a not really"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBackgroundImage(&config.RuntimeConfig{BackgroundImagePath: path}); err == nil {
+		t.Error("LoadBackgroundImage() succeeded, want error")
+	}
+}
+
+// TestBlurImage verifies a flat image is unchanged (edges included) and a
+// bright point spreads into its neighbours.
+func TestBlurImage(t *testing.T) {
+	flat := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	for i := range flat.Pix {
+		flat.Pix[i] = 100
+	}
+	blurImage(flat, 3)
+	for i, v := range flat.Pix {
+		if v != 100 {
+			t.Fatalf("flat image changed at byte %d: %d, want 100", i, v)
+		}
+	}
+
+	point := image.NewRGBA(image.Rect(0, 0, 21, 21))
+	point.SetRGBA(10, 10, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	blurImage(point, 2)
+	centre, near, far := point.RGBAAt(10, 10).R, point.RGBAAt(12, 10).R, point.RGBAAt(20, 20).R
+	if centre == 255 || near == 0 || far != 0 || near > centre {
+		t.Errorf("blurred point: centre %d, near %d, far %d; want spread falling off with distance", centre, near, far)
+	}
+}
+
+// TestDimImage verifies dimming scales colour by the remaining fraction and
+// leaves alpha alone.
+func TestDimImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.SetRGBA(0, 0, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+	dimImage(img, 0.4)
+	if got, want := img.RGBAAt(0, 0), (color.RGBA{R: 120, G: 60, B: 30, A: 255}); got != want {
+		t.Errorf("dimmed pixel = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package renderer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+	"golang.org/x/image/draw"
+)
+
+// LoadBadgeImage loads the custom corner badge logo, scaling it down
+// (preserving aspect ratio) when taller than config.BadgeMaxHeight. Returns a
+// nil image and no error when no badge image is configured.
+func LoadBadgeImage(runtimeConfig *config.RuntimeConfig) (*image.RGBA, error) {
+	if runtimeConfig.BadgeImagePath == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(runtimeConfig.BadgeImagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if h > config.BadgeMaxHeight {
+		w = w * config.BadgeMaxHeight / h
+		h = config.BadgeMaxHeight
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(rgba, rgba.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	return rgba, nil
+}
+
+// badgeOrigin returns the top-left pixel for a w×h badge placed in the given
+// corner, inset by padding from both adjacent edges.
+func badgeOrigin(pos config.BadgePosition, w, h, padding int) image.Point {
+	x, y := padding, padding
+	switch pos {
+	case config.BadgeTopRight:
+		x = config.Width - w - padding
+	case config.BadgeBottomLeft:
+		y = config.Height - h - padding
+	case config.BadgeBottomRight:
+		x = config.Width - w - padding
+		y = config.Height - h - padding
+	}
+	return image.Point{X: x, Y: y}
+}
+
+// badgeOpacity maps a 0-1 loudness level onto the pulse opacity range.
+func badgeOpacity(level float64) float64 {
+	return config.BadgePulseMin + (1-config.BadgePulseMin)*max(0, min(level, 1))
+}
+
+// drawBadgeImage composites the logo badge into its corner at the given
+// opacity (1.0 draws it unmodified).
+func drawBadgeImage(img *image.RGBA, badge *image.RGBA, pos config.BadgePosition, padding int, opacity float64) {
+	b := badge.Bounds()
+	origin := badgeOrigin(pos, b.Dx(), b.Dy(), padding)
+	dst := image.Rectangle{Min: origin, Max: origin.Add(b.Size())}
+
+	if opacity >= 1 {
+		draw.Draw(img, dst, badge, b.Min, draw.Over)
+		return
+	}
+	mask := image.NewUniform(color.Alpha{A: uint8(opacity * 255)})
+	draw.DrawMask(img, dst, badge, b.Min, mask, image.Point{}, draw.Over)
+}
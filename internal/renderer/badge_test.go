@@ -0,0 +1,123 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// TestBadgeOrigin verifies each corner placement honours the padding on both
+// adjacent edges.
+func TestBadgeOrigin(t *testing.T) {
+	const w, h, pad = 100, 50, 20
+	tests := []struct {
+		pos  config.BadgePosition
+		want image.Point
+	}{
+		{config.BadgeTopLeft, image.Pt(pad, pad)},
+		{config.BadgeTopRight, image.Pt(config.Width-w-pad, pad)},
+		{config.BadgeBottomLeft, image.Pt(pad, config.Height-h-pad)},
+		{config.BadgeBottomRight, image.Pt(config.Width-w-pad, config.Height-h-pad)},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.pos), func(t *testing.T) {
+			if got := badgeOrigin(tt.pos, w, h, pad); got != tt.want {
+				t.Errorf("badgeOrigin(%s) = %v, want %v", tt.pos, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBadgeOpacity verifies the pulse maps silence to the minimum opacity and
+// clamps out-of-range levels.
+func TestBadgeOpacity(t *testing.T) {
+	if got := badgeOpacity(0); got != config.BadgePulseMin {
+		t.Errorf("badgeOpacity(0) = %v, want %v", got, config.BadgePulseMin)
+	}
+	if got := badgeOpacity(1); got != 1 {
+		t.Errorf("badgeOpacity(1) = %v, want 1", got)
+	}
+	if got := badgeOpacity(5); got != 1 {
+		t.Errorf("badgeOpacity(5) = %v, want 1 (clamped)", got)
+	}
+}
+
+// TestFrameDrawBadgeImage verifies a logo badge lands in the configured corner
+// and that LoadBadgeImage scales tall logos down to the maximum height.
+func TestFrameDrawBadgeImage(t *testing.T) {
+	// Solid white logo, twice the maximum badge height
+	src := image.NewRGBA(image.Rect(0, 0, config.BadgeMaxHeight, config.BadgeMaxHeight*2))
+	for i := range src.Pix {
+		src.Pix[i] = 255
+	}
+	path := filepath.Join(t.TempDir(), "logo.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, src); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	pad := 10
+	runtimeConfig := &config.RuntimeConfig{
+		BadgeImagePath: path,
+		BadgePosition:  config.BadgeBottomLeft,
+		BadgePadding:   &pad,
+	}
+	badge, err := LoadBadgeImage(runtimeConfig)
+	if err != nil {
+		t.Fatalf("LoadBadgeImage() error = %v", err)
+	}
+	if got := badge.Bounds().Size(); got != image.Pt(config.BadgeMaxHeight/2, config.BadgeMaxHeight) {
+		t.Fatalf("badge size = %v, want %dx%d", got, config.BadgeMaxHeight/2, config.BadgeMaxHeight)
+	}
+
+	frame := NewFrame(nil, nil, PodcastMeta{}, runtimeConfig)
+	frame.SetBadgeImage(badge)
+	frame.Draw(make([]float64, config.NumBars))
+	img := frame.GetImage()
+
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	if got := img.RGBAAt(pad, config.Height-pad-1); got != white {
+		t.Errorf("pixel inside badge = %v, want %v", got, white)
+	}
+	if got := img.RGBAAt(pad-1, config.Height-pad-1); got == white {
+		t.Error("pixel left of badge is white, badge not inset by padding")
+	}
+}
+
+// TestBadgeFontKeepsSize verifies the episode number is drawn the same size
+// whether the title is short or long enough to shrink its face.
+func TestBadgeFontKeepsSize(t *testing.T) {
+	runtimeConfig := &config.RuntimeConfig{}
+	badgeFace, err := LoadBadgeFont(runtimeConfig)
+	if err != nil {
+		t.Fatalf("LoadBadgeFont() error = %v", err)
+	}
+	badge := func(title string) (image.Rectangle, float64) {
+		face, err := LoadTitleFont(title, runtimeConfig)
+		if err != nil {
+			t.Fatalf("LoadTitleFont() error = %v", err)
+		}
+		frame := NewFrame(nil, face, PodcastMeta{Title: title, Episode: new(65)}, runtimeConfig)
+		frame.SetBadgeFont(badgeFace)
+		return frame.episode.img.Bounds(), float64(face.Metrics().Height.Ceil())
+	}
+
+	short, shortHeight := badge("Linux Matters")
+	long, longHeight := badge("An Episode Title Long Enough That the Title Has to Shrink to Fit Two Lines in the Centre Gap")
+	if longHeight >= shortHeight {
+		t.Fatalf("long title line height %g, want below the short title's %g", longHeight, shortHeight)
+	}
+	if long != short {
+		t.Errorf("badge bounds with a long title = %v, want %v as with a short one", long, short)
+	}
+}
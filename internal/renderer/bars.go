@@ -0,0 +1,310 @@
+package renderer
+
+import (
+	"image"
+	"math"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+func init() {
+	RegisterVisualizer(DefaultVisualizer, func(runtimeConfig *config.RuntimeConfig) (Visualizer, error) {
+		b := newBarsVisualizer(runtimeConfig)
+		if b.glow != nil {
+			return glowingBars{b}, nil
+		}
+		return b, nil
+	})
+}
+
+// barsVisualizer is the built-in visualizer: vertically and horizontally
+// mirrored bars either side of the centre gap, fading from bright at the gap
+// to dim at the tips. With --bands each bar takes its band's colour, and with
+// --bar-color-mode reactive every bar takes the colour of the spectral
+// balance. --centroid-glow=bars dims them with the spectral centroid.
+type barsVisualizer struct {
+	startX       int
+	centerY      int
+	maxBarHeight int
+	heights      []float64 // Bar heights from the last Process call
+	drawn        rowSpan   // Rows the last Draw painted
+
+	// Pre-computed values
+	intensityTable []uint8      // Pre-computed intensity values for opaque gradient (0.5 to 1.0)
+	barColorTable  [][][3]uint8 // Pre-computed colors at different intensity levels, per band
+	barBand        []int        // Band of each displayed bar, left to right
+	pixelPattern   []byte       // One bar-wide scanline, reused for every bar
+
+	// Reactive colouring: a colour table per step of the blend from the low
+	// to the high colour, and the smoothed balance (0 bass to 1 treble)
+	// choosing the step; balance is negative before the first sound.
+	reactive [][][3]uint8
+	balance  float64
+
+	// Centroid glow: nil without --centroid-glow=bars. glowLevels maps each
+	// gradient intensity to its value at glowStep, the identity without.
+	glow       *centroidGlow
+	glowStep   int
+	glowLevels [256]uint8
+}
+
+// Reactive colouring settings
+const (
+	reactiveSteps     = 64  // Colour tables between the two bounds
+	reactiveSmoothing = 0.5 // Time constant in seconds of the balance
+)
+
+// newBarsVisualizer pre-computes the bar gradient in the configured colour.
+func newBarsVisualizer(runtimeConfig *config.RuntimeConfig) *barsVisualizer {
+	centerY := config.Height / 2
+
+	// Calculate maximum possible bar height
+	maxBarHeight := centerY - config.CenterGap/2
+
+	// Pre-compute intensity gradient table (0.5 to 1.0 range for opaque gradient)
+	// This creates a fade from dim at tips to bright at center without alpha blending
+	intensityTable := make([]uint8, maxBarHeight)
+	for i := range maxBarHeight {
+		distanceFromCenter := float64(i) / float64(maxBarHeight)
+		intensityFactor := 1.0 - (distanceFromCenter * 0.5) // 0.5 at tips, 1.0 at center
+		intensityTable[i] = uint8(intensityFactor * 255)
+	}
+
+	// Bands are in bar (low to high) order; the displayed bars run centre
+	// out, as audio.RearrangeFrequenciesCenterOut lays out the heights.
+	barBand := make([]int, config.NumBars)
+	bands := 1
+	if len(runtimeConfig.BarBands) == config.NumBars {
+		center := config.NumBars / 2
+		for i := range center {
+			band := runtimeConfig.BarBands[i]
+			barBand[center-1-i], barBand[center+i] = band, band
+			bands = max(bands, band+1)
+		}
+	}
+
+	// Pre-compute each band's colors at different intensity levels (0-255)
+	// Colors are fully opaque - RGB values dimmed by intensity, alpha always 255
+	barColorTable := make([][][3]uint8, bands)
+	for band := range barColorTable {
+		barR, barG, barB := runtimeConfig.GetBandColor(band)
+		barColorTable[band] = intensityColors(barR, barG, barB)
+	}
+
+	// Reactive colouring looks its table up each frame rather than building
+	// one, so the blend is precomputed across reactiveSteps.
+	var reactive [][][3]uint8
+	if runtimeConfig.BarColorMode == config.ColorReactive {
+		low, high := runtimeConfig.GetReactiveColors()
+		reactive = make([][][3]uint8, reactiveSteps)
+		for step := range reactive {
+			t := float64(step) / (reactiveSteps - 1)
+			var c [3]uint8
+			for i := range c {
+				c[i] = uint8(float64(low[i]) + (float64(high[i])-float64(low[i]))*t + 0.5)
+			}
+			reactive[step] = intensityColors(c[0], c[1], c[2])
+		}
+	}
+
+	var glow *centroidGlow
+	if runtimeConfig.CentroidGlow == config.GlowBars {
+		glow = newCentroidGlow()
+	}
+
+	return &barsVisualizer{
+		startX:         (config.Width - barsWidth()) / 2,
+		centerY:        centerY,
+		maxBarHeight:   maxBarHeight,
+		heights:        make([]float64, config.NumBars),
+		intensityTable: intensityTable,
+		barColorTable:  barColorTable,
+		barBand:        barBand,
+		pixelPattern:   make([]byte, config.BarWidth*4),
+		reactive:       reactive,
+		balance:        -1,
+		glow:           glow,
+		glowStep:       glowSteps - 1,
+		glowLevels:     glowTable(glowSteps-1, 0),
+	}
+}
+
+// intensityColors returns the colour r, g, b at each intensity level
+// (0-255). Colours are fully opaque - RGB values dimmed by intensity.
+func intensityColors(r, g, b uint8) [][3]uint8 {
+	table := make([][3]uint8, 256)
+	for intensity := range 256 {
+		factor := float64(intensity) / 255.0
+		table[intensity][0] = uint8(float64(r) * factor)
+		table[intensity][1] = uint8(float64(g) * factor)
+		table[intensity][2] = uint8(float64(b) * factor)
+	}
+	return table
+}
+
+// Process keeps a copy of the frame's bar heights for Draw, and moves the
+// reactive balance towards the frame's.
+func (b *barsVisualizer) Process(barHeights []float64, _ time.Duration) {
+	copy(b.heights, barHeights)
+	if b.reactive == nil {
+		return
+	}
+	// The left half runs from the treble at the edge to the bass at the
+	// centre; the balance is the height-weighted mean position. Silence
+	// keeps the last colour.
+	halfBars := config.NumBars / 2
+	var sum, weighted float64
+	for i, h := range b.heights[:halfBars] {
+		sum += h
+		weighted += h * float64(halfBars-1-i) / float64(halfBars-1)
+	}
+	if sum < 1 {
+		return
+	}
+	balance := weighted / sum
+	if b.balance < 0 {
+		b.balance = balance
+		return
+	}
+	b.balance += (balance - b.balance) * (1 - math.Exp(-1/(config.Framerate*reactiveSmoothing)))
+}
+
+// colorTable returns the colours of the bar displayed at index i.
+func (b *barsVisualizer) colorTable(i int) [][3]uint8 {
+	if b.reactive != nil {
+		step := int(max(b.balance, 0)*(reactiveSteps-1) + 0.5)
+		return b.reactive[step]
+	}
+	return b.barColorTable[b.barBand[i]]
+}
+
+// Draw renders all bars using horizontal + vertical symmetry optimization.
+// The frequency data is arranged symmetrically: bars 0-31 are mirrored to create bars 32-63.
+// We render only the first 32 bars upward, then mirror 3 times:
+//  1. Vertical mirror → bars 0-31 downward
+//  2. Horizontal mirror → bars 32-63 upward
+//  3. Both mirrors → bars 32-63 downward
+//
+// This renders 1/4 of the pixels and is ~4x faster.
+func (b *barsVisualizer) Draw(img *image.RGBA) {
+	// Render only the left half (bars 0-31) upward, then mirror each bar in 3
+	// operations to fill the remaining 3/4 of the bars within the same iteration.
+	// The mirrors read only pixels written by renderBar earlier in this iteration
+	// (the left upward bar), so merging the former render/mirror loops keeps output
+	// identical. The clamped barHeight feeds renderBar; the mirrors derive yStart
+	// from the unclamped barHeight, matching the original mirror loop.
+	halfBars := config.NumBars / 2
+	tallest := 0
+	for i := range halfBars {
+		barHeight := int(b.heights[i])
+		if barHeight <= 0 {
+			continue
+		}
+
+		xLeft := b.startX + i*(config.BarWidth+config.BarGap)
+		if xLeft+config.BarWidth > config.Width {
+			continue
+		}
+		tallest = max(tallest, barHeight)
+
+		yEnd := b.centerY - config.CenterGap/2
+
+		// Render upward bar (left half) with the clamped height - always opaque,
+		// no background blending needed.
+		clampedHeight := min(barHeight, b.maxBarHeight)
+		b.renderBar(img, b.colorTable(i), xLeft, b.centerY-clampedHeight-config.CenterGap/2, yEnd, clampedHeight)
+
+		// Mirror using the unclamped barHeight, matching the original mirror loop:
+		// 1. Vertical mirror → left-side downward bar
+		// 2. Horizontal mirror → right-side upward bar
+		// 3. Both mirrors → right-side downward bar
+		xRight := b.startX + (config.NumBars-1-i)*(config.BarWidth+config.BarGap)
+		yStart := b.centerY - barHeight - config.CenterGap/2
+
+		b.mirrorBarVertical(img, xLeft, yStart, yEnd)
+		mirrorBarHorizontal(img, xLeft, xRight, yStart, yEnd)
+		b.mirrorBarVertical(img, xRight, yStart, yEnd)
+	}
+
+	b.drawn = rowSpan{}
+	if tallest > 0 {
+		b.drawn = newRowSpan(b.centerY-config.CenterGap/2-tallest, b.centerY+config.CenterGap/2+tallest)
+	}
+}
+
+// DrawnRows reports the rows of the tallest bar drawn, up and down.
+func (b *barsVisualizer) DrawnRows() (startY, endY int) {
+	return b.drawn.startY, b.drawn.endY
+}
+
+// renderBar renders a single upward bar with opaque gradient (no alpha blending)
+// from the colours of its band
+func (b *barsVisualizer) renderBar(img *image.RGBA, colorTable [][3]uint8, x, yStart, yEnd, barHeight int) {
+	pixelPattern := b.pixelPattern
+	for y := yStart; y < yEnd; y++ {
+		if y < 0 {
+			continue
+		}
+
+		// Calculate intensity for fade gradient (dim at tip → bright at center)
+		distanceFromCenter := yEnd - 1 - y
+		intensityIndex := (distanceFromCenter * b.maxBarHeight) / barHeight
+		if intensityIndex >= b.maxBarHeight {
+			intensityIndex = b.maxBarHeight - 1
+		}
+		intensity := b.glowLevels[b.intensityTable[intensityIndex]]
+		colors := &colorTable[intensity]
+
+		// Fill pixel pattern once for this scanline
+		for px := range config.BarWidth {
+			offset := px * 4
+			pixelPattern[offset] = colors[0]
+			pixelPattern[offset+1] = colors[1]
+			pixelPattern[offset+2] = colors[2]
+			pixelPattern[offset+3] = 255 // Fully opaque
+		}
+
+		// Write entire bar width with single copy
+		offset := y*img.Stride + x*4
+		copy(img.Pix[offset:offset+config.BarWidth*4], pixelPattern)
+	}
+}
+
+// mirrorBarVertical creates downward bar by mirroring upward bar pixels.
+// Copies scanlines in reverse order to preserve the fade gradient.
+func (b *barsVisualizer) mirrorBarVertical(img *image.RGBA, x, yStart, yEnd int) {
+	upwardHeight := yEnd - yStart
+	downStart := b.centerY + config.CenterGap/2
+
+	// Copy each scanline from upward bar in reverse order
+	for i := range upwardHeight {
+		srcY := yEnd - 1 - i  // Read from bottom of upward bar
+		dstY := downStart + i // Write to top of downward bar
+
+		if srcY < 0 || dstY >= config.Height {
+			continue
+		}
+
+		srcOffset := srcY*img.Stride + x*4
+		dstOffset := dstY*img.Stride + x*4
+		copy(img.Pix[dstOffset:dstOffset+config.BarWidth*4],
+			img.Pix[srcOffset:srcOffset+config.BarWidth*4])
+	}
+}
+
+// mirrorBarHorizontal creates right-side bar by copying left-side bar pixels.
+// Copies the entire upward bar from left position to right position.
+func mirrorBarHorizontal(img *image.RGBA, xLeft, xRight, yStart, yEnd int) {
+	// Copy each scanline from left bar to right bar
+	for y := yStart; y < yEnd; y++ {
+		if y < 0 || y >= config.Height {
+			continue
+		}
+
+		srcOffset := y*img.Stride + xLeft*4
+		dstOffset := y*img.Stride + xRight*4
+		copy(img.Pix[dstOffset:dstOffset+config.BarWidth*4],
+			img.Pix[srcOffset:srcOffset+config.BarWidth*4])
+	}
+}
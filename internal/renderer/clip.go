@@ -0,0 +1,99 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+)
+
+// Clip lays rendered frames out as a square or vertical audiogram: the 16:9
+// frame scaled to the clip's width, low in the canvas, with the quote drawn
+// large above it over a blurred, darkened copy of the background. Everything
+// but the frame is drawn once; Compose only scales each frame into place.
+type Clip struct {
+	canvas    *image.RGBA
+	frameRect image.Rectangle
+}
+
+// NewClip prepares the layout for runtimeConfig.ClipShape. bg is the frame
+// background (nil for plain black); the quote is clipQuote's, drawn above the
+// frame only when the layout's captions widget is anchored there. Anchored in
+// the frame, NewFrame draws it instead.
+func NewClip(bg *image.RGBA, meta PodcastMeta, runtimeConfig *config.RuntimeConfig) (*Clip, error) {
+	width, height := runtimeConfig.ClipShape.Size()
+
+	var canvas *image.RGBA
+	if bg != nil {
+		canvas = fitImage(bg, width, height, config.FitCover)
+		blurImage(canvas, config.ClipBackgroundBlur)
+		dimImage(canvas, config.ClipBackgroundDim)
+	} else {
+		canvas = image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	}
+
+	// Vertical clips lift the frame off the bottom edge, where platforms
+	// overlay their own captions and buttons.
+	frameHeight := width * config.Height / config.Width
+	bottom := (height - width) / 4
+	frameRect := image.Rect(0, height-bottom-frameHeight, width, height-bottom)
+
+	if entry, ok := config.LayoutEntry(runtimeConfig.GetLayout(), config.WidgetCaptions); ok && entry.Anchor == config.AnchorAbove {
+		top := config.ClipMargin + bottom
+		maxWidth := width - 2*config.ClipMargin
+		maxHeight := frameRect.Min.Y - config.ClipMargin - top
+		face, lines, err := fitQuote(clipQuote(meta, runtimeConfig), maxWidth, maxHeight, runtimeConfig)
+		if err != nil {
+			return nil, err
+		}
+		r, g, b := runtimeConfig.GetTextColor()
+		DrawTextBlock(canvas, face, lines, top+maxHeight/2, config.ClipMargin, maxWidth, config.AlignCentre, color.RGBA{R: r, G: g, B: b, A: 255})
+	}
+
+	return &Clip{canvas: canvas, frameRect: frameRect}, nil
+}
+
+// clipQuote returns the text of the captions widget: runtimeConfig.ClipQuote,
+// from --quote or the subtitle cues, or the title when there is none.
+func clipQuote(meta PodcastMeta, runtimeConfig *config.RuntimeConfig) string {
+	if runtimeConfig.ClipQuote != "" {
+		return runtimeConfig.ClipQuote
+	}
+	return meta.Title
+}
+
+// fitQuote returns the title font at the largest size, from
+// config.ClipQuoteFontSize down to config.ClipQuoteMinFontSize, at which the
+// wrapped quote fits maxWidth×maxHeight, with its lines. A quote too long
+// even at the smallest size is truncated with an ellipsis.
+func fitQuote(quote string, maxWidth, maxHeight int, runtimeConfig *config.RuntimeConfig) (font.Face, []string, error) {
+	chain, err := titleFontChain(runtimeConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	for size := config.ClipQuoteFontSize; size > config.ClipQuoteMinFontSize; size -= 4 {
+		face := newShapedFace(chain, size)
+		lines := wrapText(face, quote, maxWidth)
+		if textBlockFits(face, lines, maxWidth, maxHeight) {
+			return face, lines, nil
+		}
+	}
+	face := newShapedFace(chain, config.ClipQuoteMinFontSize)
+	maxLines := max(maxHeight/face.Metrics().Height.Ceil(), 1)
+	return face, truncateLines(face, wrapText(face, quote, maxWidth), maxLines, maxWidth), nil
+}
+
+// Size returns the clip's resolution.
+func (c *Clip) Size() (width, height int) {
+	return c.canvas.Bounds().Dx(), c.canvas.Bounds().Dy()
+}
+
+// Compose scales frame into its place on the clip and returns the clip. The
+// returned image is reused by the next call.
+func (c *Clip) Compose(frame *image.RGBA) *image.RGBA {
+	draw.ApproxBiLinear.Scale(c.canvas, c.frameRect, frame, frame.Bounds(), draw.Src, nil)
+	return c.canvas
+}
@@ -0,0 +1,118 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+func TestClipLayout(t *testing.T) {
+	frame := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	red := color.RGBA{R: 255, A: 255}
+	draw.Draw(frame, frame.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+
+	for _, tt := range []struct {
+		shape         config.ClipShape
+		width, height int
+	}{
+		{config.ClipSquare, 1080, 1080},
+		{config.ClipVertical, 1080, 1920},
+	} {
+		rc := &config.RuntimeConfig{ClipShape: tt.shape, ClipQuote: "Linux is only free if your time has no value."}
+		clip, err := NewClip(nil, PodcastMeta{Title: "Terminal Velocity"}, rc)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.shape, err)
+		}
+		if w, h := clip.Size(); w != tt.width || h != tt.height {
+			t.Errorf("%s clip is %dx%d, want %dx%d", tt.shape, w, h, tt.width, tt.height)
+		}
+
+		img := clip.Compose(frame)
+		if img.Bounds().Dx() != tt.width || img.Bounds().Dy() != tt.height {
+			t.Fatalf("%s: composed %v", tt.shape, img.Bounds())
+		}
+		// The frame fills the clip's width below the quote, in its 16:9 shape.
+		if clip.frameRect.Dx() != tt.width || clip.frameRect.Dy() != tt.width*9/16 {
+			t.Errorf("%s: frame placed at %v", tt.shape, clip.frameRect)
+		}
+		mid := clip.frameRect.Min.Add(clip.frameRect.Size().Div(2))
+		if got := img.RGBAAt(mid.X, mid.Y); got != red {
+			t.Errorf("%s: centre of the frame is %v, want the frame's red", tt.shape, got)
+		}
+		// The quote lands above the frame: something other than black.
+		if !hasNonBlack(img, image.Rect(0, 0, tt.width, clip.frameRect.Min.Y)) {
+			t.Errorf("%s: nothing drawn above the frame", tt.shape)
+		}
+	}
+}
+
+// TestClipCaptions verifies captions anchored in the frame are drawn there
+// by the captions widget rather than above it, and a layout without the
+// widget draws no quote at all.
+func TestClipCaptions(t *testing.T) {
+	frame := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	for _, tt := range []struct {
+		layout        []config.LayoutWidget
+		above, inside bool
+	}{
+		{[]config.LayoutWidget{{Widget: config.WidgetCaptions, Anchor: config.AnchorBottom}}, false, true},
+		{[]config.LayoutWidget{{Widget: config.WidgetFramingLines}}, false, false},
+	} {
+		rc := &config.RuntimeConfig{ClipShape: config.ClipSquare, ClipQuote: "Linux is only free if your time has no value.", Layout: tt.layout}
+		meta := PodcastMeta{Title: "Terminal Velocity"}
+		clip, err := NewClip(nil, meta, rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := clip.Compose(frame)
+		if got := hasNonBlack(img, image.Rect(0, 0, img.Bounds().Dx(), clip.frameRect.Min.Y)); got != tt.above {
+			t.Errorf("layout %+v: drawn above the frame = %v, want %v", tt.layout, got, tt.above)
+		}
+
+		f := NewFrame(nil, nil, meta, rc)
+		f.Draw(make([]float64, config.NumBars))
+		bottom := image.Rect(0, config.Height-config.CenterGap, config.Width, config.Height)
+		if got := hasNonBlack(f.img, bottom); got != tt.inside {
+			t.Errorf("layout %+v: drawn at the bottom of the frame = %v, want %v", tt.layout, got, tt.inside)
+		}
+	}
+}
+
+func hasNonBlack(img *image.RGBA, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if c := img.RGBAAt(x, y); c.R|c.G|c.B != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// A quote too long for the space is drawn at the smallest size, truncated.
+func TestFitQuote(t *testing.T) {
+	rc := &config.RuntimeConfig{}
+	face, lines, err := fitQuote("Short and sweet.", 936, 329, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || face.Metrics().Height.Ceil() < 84 {
+		t.Errorf("short quote: %d lines at line height %d, want one line at full size", len(lines), face.Metrics().Height.Ceil())
+	}
+
+	long := strings.Repeat("and then we talked about package managers for a while ", 20)
+	face, lines, err = fitQuote(long, 936, 329, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(lines) * face.Metrics().Height.Ceil(); got > 329 {
+		t.Errorf("long quote is %dpx tall, more than the 329px available", got)
+	}
+	if !strings.HasSuffix(lines[len(lines)-1], "…") {
+		t.Errorf("long quote not truncated: last line %q", lines[len(lines)-1])
+	}
+}
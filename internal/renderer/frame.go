@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"time"
 
 	"github.com/linuxmatters/jivefire/internal/config"
 	"golang.org/x/image/font"
@@ -17,59 +18,120 @@ type PodcastMeta struct {
 	Episode *int
 }
 
-// Frame represents a single video frame with visualization bars
+// Frame represents a single video frame: background, visualizer, and the
+// widgets of the layout over it (framing lines, title, badge and so on)
 type Frame struct {
 	img        *image.RGBA
 	bgImage    *image.RGBA
-	fontFace   font.Face
 	centerY    int
 	startX     int
 	totalWidth int
 
-	// Text overlay
-	episodeNum string
-	hasEpisode bool
-	title      string
-	textColor  color.RGBA // Text color for overlays
+	vis        Visualizer
+	overlay    Visualizer // Optional layer drawn over everything else (--script)
+	frameIndex int        // Frames drawn so far, giving each frame's timestamp
+
+	// Text rasterised once: the title wrapped to fit the centre gap and, in
+	// clips, captions anchored in the frame, in NewFrame, and the episode
+	// number badge, in SetBadgeFont. Nil without a font (or episode).
+	title      *textOverlay
+	captions   *textOverlay
+	episode    *textOverlay
+	episodeStr string // The episode number as drawn; empty without one
+
+	// Corner badge: the episode number, or a logo when badgeImg is set
+	badgeImg     *image.RGBA
+	badgePos     config.BadgePosition
+	badgePadding int
+	badgePulse   bool
+	level        float64 // Current loudness (0-1) driving the badge pulse and particles
+
+	meter *levelMeter // Optional stereo level meter (--meter)
+
+	// Optional timecode burn-in (--timecode), once SetTimecode gives it a
+	// face
+	timecode     *timecode
+	timecodePos  config.BadgePosition
+	timecodePill float64
+	particles    *particleField // Optional embers (--particles)
+
+	// Widgets drawn over the visualizer, in order, and what they share
+	widgets     []widget
+	textColor   [3]uint8
+	totalFrames int // Frames in the episode, for the progress widget and motion
+
+	motion *kenBurns // Optional moving background (--background-motion)
+
+	// Optional centroid glow of the background (--centroid-glow): each
+	// channel's table at glowStep, and whether the step changed since the
+	// last frame.
+	glow        *centroidGlow
+	glowStep    int
+	warmth      [3][256]uint8
+	glowChanged bool
+
+	// Rows of the last frame that can differ from the one before, and the
+	// rows painted over the static layers; see DirtyRows. redraw marks the
+	// next frame as wholly changed.
+	dirty, drawn rowSpan
+	redraw       bool
 
 	// Pre-computed values
-	maxBarHeight    int
-	intensityTable  []uint8    // Pre-computed intensity values for opaque gradient (0.5 to 1.0)
-	barColorTable   [][3]uint8 // Pre-computed bar colors at different intensity levels
-	framingLineData []byte     // Pre-rendered framing line pixel pattern
+	framingLineData []byte // Pre-rendered framing line pixel pattern
 	hasBackground   bool
 }
 
-// NewFrame creates a new optimized frame renderer
-func NewFrame(bgImage *image.RGBA, fontFace font.Face, meta PodcastMeta, runtimeConfig *config.RuntimeConfig) *Frame {
-	totalWidth := config.NumBars*config.BarWidth + (config.NumBars-1)*config.BarGap
-	startX := (config.Width - totalWidth) / 2
-	centerY := config.Height / 2
+// rowSpan is the image rows [startY, endY); it is empty when startY >= endY.
+type rowSpan struct {
+	startY, endY int
+}
 
-	// Calculate maximum possible bar height
-	maxBarHeight := centerY - config.CenterGap/2
+// allRows spans the whole frame.
+var allRows = rowSpan{0, config.Height}
 
-	barR, barG, barB := runtimeConfig.GetBarColor()
-	textR, textG, textB := runtimeConfig.GetTextColor()
+// newRowSpan returns the rows [startY, endY) that lie within the frame.
+func newRowSpan(startY, endY int) rowSpan {
+	return rowSpan{max(startY, 0), min(endY, config.Height)}
+}
 
-	// Pre-compute intensity gradient table (0.5 to 1.0 range for opaque gradient)
-	// This creates a fade from dim at tips to bright at center without alpha blending
-	intensityTable := make([]uint8, maxBarHeight)
-	for i := range maxBarHeight {
-		distanceFromCenter := float64(i) / float64(maxBarHeight)
-		intensityFactor := 1.0 - (distanceFromCenter * 0.5) // 0.5 at tips, 1.0 at center
-		intensityTable[i] = uint8(intensityFactor * 255)
+func (s rowSpan) empty() bool {
+	return s.startY >= s.endY
+}
+
+// union returns the smallest span covering both s and o.
+func (s rowSpan) union(o rowSpan) rowSpan {
+	switch {
+	case s.empty():
+		return o
+	case o.empty():
+		return s
 	}
+	return rowSpan{min(s.startY, o.startY), max(s.endY, o.endY)}
+}
 
-	// Pre-compute bar colors at different intensity levels (0-255)
-	// Colors are fully opaque - RGB values dimmed by intensity, alpha always 255
-	barColorTable := make([][3]uint8, 256)
-	for intensity := range 256 {
-		factor := float64(intensity) / 255.0
-		barColorTable[intensity][0] = uint8(float64(barR) * factor)
-		barColorTable[intensity][1] = uint8(float64(barG) * factor)
-		barColorTable[intensity][2] = uint8(float64(barB) * factor)
+// visualizerRows returns the rows vis painted in its last Draw: all of them
+// unless it is a BoundedVisualizer.
+func visualizerRows(vis Visualizer) rowSpan {
+	if bounded, ok := vis.(BoundedVisualizer); ok {
+		return newRowSpan(bounded.DrawnRows())
 	}
+	return allRows
+}
+
+// barsWidth returns the pixel width of the full bar block, which also bounds
+// the title text in the centre gap.
+func barsWidth() int {
+	return config.NumBars*config.BarWidth + (config.NumBars-1)*config.BarGap
+}
+
+// NewFrame creates a new optimized frame renderer drawing the default bars;
+// SetVisualizer swaps in another visualizer.
+func NewFrame(bgImage *image.RGBA, fontFace font.Face, meta PodcastMeta, runtimeConfig *config.RuntimeConfig) *Frame {
+	totalWidth := barsWidth()
+	startX := (config.Width - totalWidth) / 2
+	centerY := config.Height / 2
+
+	textR, textG, textB := runtimeConfig.GetTextColor()
 
 	// Pre-render the framing-line pattern in the text colour.
 	framingLineData := make([]byte, totalWidth*4)
@@ -81,38 +143,77 @@ func NewFrame(bgImage *image.RGBA, fontFace font.Face, meta PodcastMeta, runtime
 		framingLineData[offset+3] = 255   // A
 	}
 
-	// Omit the episode overlay entirely when no episode number was supplied.
-	hasEpisode := meta.Episode != nil
+	// The text never changes, so it is drawn once here and composited onto
+	// each frame. The face is expected to come from LoadTitleFont, so the
+	// wrapped lines normally fit without truncation. The episode number
+	// waits for SetBadgeFont, as it keeps its size however far the title
+	// shrinks.
+	textColor := color.RGBA{R: textR, G: textG, B: textB, A: 255}
+	badgePos, badgePadding := runtimeConfig.GetBadgePosition(), runtimeConfig.GetBadgePadding()
+	layout := runtimeConfig.GetLayout()
+	titleEntry, _ := config.LayoutEntry(layout, config.WidgetTitle)
+	titleY := titleCentreY(titleEntry.Anchor, badgePadding)
+	var title *textOverlay
+	if fontFace != nil {
+		titleLines := wrapText(fontFace, meta.Title, totalWidth)
+		titleLines = truncateLines(fontFace, titleLines, runtimeConfig.GetTitleMaxLines(), totalWidth)
+		titleAlign := runtimeConfig.GetTitleAlign()
+		title = newTextOverlay(func(img *image.RGBA) {
+			DrawTextBlock(img, fontFace, titleLines, titleY, startX, totalWidth, titleAlign, textColor)
+		})
+	}
 	var episodeStr string
-	if hasEpisode {
+	if meta.Episode != nil {
 		episodeStr = formatEpisodeNumber(*meta.Episode)
 	}
 
 	f := &Frame{
 		img:             image.NewRGBA(image.Rect(0, 0, config.Width, config.Height)),
 		bgImage:         bgImage,
-		fontFace:        fontFace,
 		centerY:         centerY,
 		startX:          startX,
 		totalWidth:      totalWidth,
-		episodeNum:      episodeStr,
-		hasEpisode:      hasEpisode,
-		title:           meta.Title,
-		textColor:       color.RGBA{R: textR, G: textG, B: textB, A: 255},
-		maxBarHeight:    maxBarHeight,
-		intensityTable:  intensityTable,
-		barColorTable:   barColorTable,
+		vis:             newBarsVisualizer(runtimeConfig),
+		title:           title,
+		episodeStr:      episodeStr,
+		badgePos:        badgePos,
+		badgePadding:    badgePadding,
+		badgePulse:      runtimeConfig.BadgePulse,
+		level:           1,
 		framingLineData: framingLineData,
 		hasBackground:   bgImage != nil,
+		redraw:          true,
+		textColor:       [3]uint8{textR, textG, textB},
+	}
+	if entry, ok := config.LayoutEntry(layout, config.WidgetCaptions); ok && entry.Anchor != config.AnchorAbove && runtimeConfig.ClipShape != "" {
+		f.captions = newCaptions(clipQuote(meta, runtimeConfig), entry.Anchor, startX, totalWidth, badgePadding, textColor, runtimeConfig)
+	}
+	if runtimeConfig.Meter != "" {
+		f.meter = newLevelMeter(runtimeConfig.Meter, badgePadding)
+	}
+	if runtimeConfig.Particles {
+		f.particles = newParticleField()
+	}
+	f.timecodePos, f.timecodePill = runtimeConfig.Timecode, runtimeConfig.GetTimecodePill()
+	if runtimeConfig.CentroidGlow == config.GlowBackground {
+		f.glow, f.glowStep = newCentroidGlow(), glowSteps-1
+	}
+	for _, entry := range layout {
+		if w := newWidget(entry); w != nil {
+			f.widgets = append(f.widgets, w)
+		}
 	}
 
 	return f
 }
 
-// Draw renders the visualization bars using pre-computed values
+// Draw renders the next video frame for barHeights. It is called once per
+// frame in order, which gives the visualizer each frame's timestamp.
 func (f *Frame) Draw(barHeights []float64) {
 	// Clear or copy background
-	if f.hasBackground {
+	if f.motion != nil {
+		f.motion.draw(f.img, f.motionProgress())
+	} else if f.hasBackground {
 		copy(f.img.Pix, f.bgImage.Pix)
 	} else {
 		// Fast clear to black using optimized pattern
@@ -127,140 +228,148 @@ func (f *Frame) Draw(barHeights []float64) {
 			copy(f.img.Pix[i:i+32], blackPattern[:])
 		}
 	}
+	if f.glow != nil && f.glowStep != glowSteps-1 {
+		f.warmBackground()
+	}
 
-	f.drawBars(barHeights)
-	f.drawFramingLines()
+	t := time.Duration(f.frameIndex) * time.Second / config.FPS
+	f.frameIndex++
+	f.vis.Process(barHeights, t)
+	f.vis.Draw(f.img)
+	for _, w := range f.widgets {
+		w.draw(f, barHeights, t)
+	}
 
-	// Apply text overlay (self-guards on a nil font face)
-	f.applyTextOverlay()
+	// Everything else is the same on every frame, so a row can only differ
+	// from the last frame where either frame painted over it.
+	drawn := visualizerRows(f.vis)
+	if f.motion != nil {
+		drawn = allRows
+	}
+	for _, w := range f.widgets {
+		drawn = drawn.union(w.rows(f))
+	}
+	f.dirty = drawn.union(f.drawn)
+	if f.redraw || f.glowChanged {
+		f.dirty = allRows
+	}
+	f.drawn, f.redraw, f.glowChanged = drawn, false, false
 }
 
-// drawBars renders all bars using horizontal + vertical symmetry optimization.
-// The frequency data is arranged symmetrically: bars 0-31 are mirrored to create bars 32-63.
-// We render only the first 32 bars upward, then mirror 3 times:
-//  1. Vertical mirror → bars 0-31 downward
-//  2. Horizontal mirror → bars 32-63 upward
-//  3. Both mirrors → bars 32-63 downward
-//
-// This renders 1/4 of the pixels and is ~4x faster.
-func (f *Frame) drawBars(barHeights []float64) {
-	// Pre-allocate pixel pattern buffer (reused for all bars)
-	pixelPattern := make([]byte, config.BarWidth*4)
-
-	// Render only the left half (bars 0-31) upward, then mirror each bar in 3
-	// operations to fill the remaining 3/4 of the bars within the same iteration.
-	// The mirrors read only pixels written by renderBar earlier in this iteration
-	// (the left upward bar), so merging the former render/mirror loops keeps output
-	// identical. The clamped barHeight feeds renderBar; the mirrors derive yStart
-	// from the unclamped barHeight, matching the original mirror loop.
-	halfBars := config.NumBars / 2
-	for i := range halfBars {
-		barHeight := int(barHeights[i])
-		if barHeight <= 0 {
-			continue
-		}
+// DirtyRows returns the rows [startY, endY) of the last frame drawn that can
+// differ from the frame drawn before it; the rows outside are unchanged. An
+// empty span means the frame repeats the last. The first frame, and the
+// first after a visualizer, overlay or badge change, is dirty throughout.
+func (f *Frame) DirtyRows() (startY, endY int) {
+	return f.dirty.startY, f.dirty.endY
+}
 
-		xLeft := f.startX + i*(config.BarWidth+config.BarGap)
-		if xLeft+config.BarWidth > config.Width {
-			continue
-		}
+// badgeRows returns the rows the corner badge covers, empty when there is
+// no badge.
+func (f *Frame) badgeRows() rowSpan {
+	switch {
+	case f.badgeImg != nil:
+		b := f.badgeImg.Bounds()
+		origin := badgeOrigin(f.badgePos, b.Dx(), b.Dy(), f.badgePadding)
+		return newRowSpan(origin.Y, origin.Y+b.Dy())
+	case f.episode != nil:
+		b := f.episode.img.Bounds()
+		return newRowSpan(b.Min.Y, b.Max.Y)
+	}
+	return rowSpan{}
+}
 
-		yEnd := f.centerY - config.CenterGap/2
+// SetVisualizer replaces the visualizer drawn between the background and the
+// text overlay.
+func (f *Frame) SetVisualizer(vis Visualizer) {
+	f.vis = vis
+	f.redraw = true
+}
 
-		// Render upward bar (left half) with the clamped height - always opaque,
-		// no background blending needed.
-		clampedHeight := min(barHeight, f.maxBarHeight)
-		f.renderBar(xLeft, f.centerY-clampedHeight-config.CenterGap/2, yEnd, clampedHeight, pixelPattern)
+// SetOverlay sets a layer drawn on top of the finished frame, after the title
+// and badge; nil removes it.
+func (f *Frame) SetOverlay(overlay Visualizer) {
+	f.overlay = overlay
+	f.redraw = true
+}
 
-		// Mirror using the unclamped barHeight, matching the original mirror loop:
-		// 1. Vertical mirror → left-side downward bar
-		// 2. Horizontal mirror → right-side upward bar
-		// 3. Both mirrors → right-side downward bar
-		xRight := f.startX + (config.NumBars-1-i)*(config.BarWidth+config.BarGap)
-		yStart := f.centerY - barHeight - config.CenterGap/2
+// SetBadgeImage sets a logo to draw in the badge corner in place of the
+// episode number. A nil image restores the episode number badge.
+func (f *Frame) SetBadgeImage(badge *image.RGBA) {
+	f.badgeImg = badge
+	f.redraw = true
+}
 
-		f.mirrorBarVertical(xLeft, yStart, yEnd)
-		f.mirrorBarHorizontal(xLeft, xRight, yStart, yEnd)
-		f.mirrorBarVertical(xRight, yStart, yEnd)
+// SetBadgeFont sets the face the episode number badge is drawn in, from
+// LoadBadgeFont; nil leaves the frame without it. It has no effect when no
+// episode number was supplied.
+func (f *Frame) SetBadgeFont(face font.Face) {
+	f.episode = nil
+	if face != nil && f.episodeStr != "" {
+		textColor := color.RGBA{R: f.textColor[0], G: f.textColor[1], B: f.textColor[2], A: 255}
+		f.episode = newTextOverlay(func(img *image.RGBA) {
+			DrawEpisodeNumber(img, face, f.episodeStr, textColor, f.badgePos, f.badgePadding)
+		})
 	}
+	f.redraw = true
 }
 
-// renderBar renders a single upward bar with opaque gradient (no alpha blending)
-func (f *Frame) renderBar(x, yStart, yEnd, barHeight int, pixelPattern []byte) {
-	for y := yStart; y < yEnd; y++ {
-		if y < 0 {
-			continue
-		}
+// SetLevel sets the current loudness (0-1) used to pulse the badge and to
+// spawn particles. It has no effect unless either is enabled.
+func (f *Frame) SetLevel(level float64) {
+	f.level = max(0, min(level, 1))
+}
 
-		// Calculate intensity for fade gradient (dim at tip → bright at center)
-		distanceFromCenter := yEnd - 1 - y
-		intensityIndex := (distanceFromCenter * f.maxBarHeight) / barHeight
-		if intensityIndex >= f.maxBarHeight {
-			intensityIndex = f.maxBarHeight - 1
-		}
-		intensity := f.intensityTable[intensityIndex]
-		colors := &f.barColorTable[intensity]
-
-		// Fill pixel pattern once for this scanline
-		for px := range config.BarWidth {
-			offset := px * 4
-			pixelPattern[offset] = colors[0]
-			pixelPattern[offset+1] = colors[1]
-			pixelPattern[offset+2] = colors[2]
-			pixelPattern[offset+3] = 255 // Fully opaque
-		}
+// SetFrameIndex sets the index of the next frame drawn, which times the
+// visualizer and overlay. A segment of a split render starts from its place
+// in the episode, so scripts see the same clock as a whole render.
+func (f *Frame) SetFrameIndex(index int) {
+	f.frameIndex = index
+}
 
-		// Write entire bar width with single copy
-		offset := y*f.img.Stride + x*4
-		copy(f.img.Pix[offset:offset+config.BarWidth*4], pixelPattern)
+// SetMeterLevels advances the level meter by a frame with each channel's
+// linear RMS and peak (1 is full scale). It has no effect without a meter.
+func (f *Frame) SetMeterLevels(rms, peak []float64) {
+	if f.meter != nil {
+		f.meter.setLevels(rms, peak)
 	}
 }
 
-// mirrorBarVertical creates downward bar by mirroring upward bar pixels.
-// Copies scanlines in reverse order to preserve the fade gradient.
-func (f *Frame) mirrorBarVertical(x, yStart, yEnd int) {
-	upwardHeight := yEnd - yStart
-	downStart := f.centerY + config.CenterGap/2
-
-	// Copy each scanline from upward bar in reverse order
-	for i := range upwardHeight {
-		srcY := yEnd - 1 - i  // Read from bottom of upward bar
-		dstY := downStart + i // Write to top of downward bar
-
-		if srcY < 0 || dstY >= config.Height {
-			continue
-		}
+// drawBadge renders the corner badge: the logo when one is set, otherwise the
+// episode number (when supplied and a font is available).
+func (f *Frame) drawBadge() {
+	opacity := 1.0
+	if f.badgePulse {
+		opacity = badgeOpacity(f.level)
+	}
 
-		srcOffset := srcY*f.img.Stride + x*4
-		dstOffset := dstY*f.img.Stride + x*4
-		copy(f.img.Pix[dstOffset:dstOffset+config.BarWidth*4],
-			f.img.Pix[srcOffset:srcOffset+config.BarWidth*4])
+	if f.badgeImg != nil {
+		drawBadgeImage(f.img, f.badgeImg, f.badgePos, f.badgePadding, opacity)
+		return
+	}
+	if f.episode != nil {
+		f.episode.drawOnto(f.img, opacity)
 	}
 }
 
-// mirrorBarHorizontal creates right-side bar by copying left-side bar pixels.
-// Copies the entire upward bar from left position to right position.
-func (f *Frame) mirrorBarHorizontal(xLeft, xRight, yStart, yEnd int) {
-	// Copy each scanline from left bar to right bar
-	for y := yStart; y < yEnd; y++ {
-		if y < 0 || y >= config.Height {
-			continue
-		}
-
-		srcOffset := y*f.img.Stride + xLeft*4
-		dstOffset := y*f.img.Stride + xRight*4
-		copy(f.img.Pix[dstOffset:dstOffset+config.BarWidth*4],
-			f.img.Pix[srcOffset:srcOffset+config.BarWidth*4])
+// newCaptions rasterises a clip's quote centred at anchor, as the title is,
+// sized by fitQuote to the width of the bars and the height of the centre
+// gap. It returns nil when the title font cannot be loaded.
+func newCaptions(quote, anchor string, startX, width, padding int, textColor color.RGBA, runtimeConfig *config.RuntimeConfig) *textOverlay {
+	face, lines, err := fitQuote(quote, width, config.CenterGap-2*config.TitlePadding, runtimeConfig)
+	if err != nil {
+		return nil
 	}
+	y := titleCentreY(anchor, padding)
+	return newTextOverlay(func(img *image.RGBA) {
+		DrawTextBlock(img, face, lines, y, startX, width, config.AlignCentre, textColor)
+	})
 }
 
-// applyTextOverlay renders text onto the frame
+// applyTextOverlay composites the pre-rendered title onto the frame
 func (f *Frame) applyTextOverlay() {
-	if f.fontFace != nil {
-		DrawCenterText(f.img, f.fontFace, f.title, f.centerY, f.textColor)
-		if f.hasEpisode {
-			DrawEpisodeNumber(f.img, f.fontFace, f.episodeNum, f.textColor)
-		}
+	if f.title != nil {
+		f.title.drawOnto(f.img, 1)
 	}
 }
 
@@ -2,6 +2,7 @@ package renderer
 
 import (
 	"image"
+	"slices"
 	"testing"
 
 	"github.com/linuxmatters/jivefire/internal/config"
@@ -60,6 +61,7 @@ func BenchmarkFrameWithText(b *testing.B) {
 	fontFace := basicfont.Face7x13
 	runtimeConfig := &config.RuntimeConfig{}
 	frame := NewFrame(bgImage, fontFace, PodcastMeta{Title: "Test Episode", Episode: new(1)}, runtimeConfig)
+	frame.SetBadgeFont(fontFace)
 	barHeights := generateTestBarHeights()
 
 	b.ResetTimer()
@@ -85,6 +87,7 @@ func TestFrameRendering(t *testing.T) {
 	fontFace := basicfont.Face7x13
 	runtimeConfig := &config.RuntimeConfig{}
 	frame := NewFrame(bgImage, fontFace, PodcastMeta{Title: "Linux Matters", Episode: new(42)}, runtimeConfig)
+	frame.SetBadgeFont(fontFace)
 
 	// Test with various bar heights
 	barHeights := generateTestBarHeights()
@@ -115,3 +118,60 @@ func TestFrameRendering(t *testing.T) {
 		t.Errorf("Unexpected color at bar position: got R=%d", r)
 	}
 }
+
+// TestFrameDirtyRows verifies every row outside DirtyRows is unchanged from
+// the previous frame, and that the span shrinks to the bars once the first
+// frame is out.
+func TestFrameDirtyRows(t *testing.T) {
+	runtimeConfig := &config.RuntimeConfig{BadgePulse: true}
+	frame := NewFrame(nil, basicfont.Face7x13, PodcastMeta{Title: "Linux Matters", Episode: new(42)}, runtimeConfig)
+	frame.SetBadgeFont(basicfont.Face7x13)
+
+	heights := make([]float64, config.NumBars)
+	frame.Draw(heights)
+	if startY, endY := frame.DirtyRows(); startY != 0 || endY != config.Height {
+		t.Errorf("first frame dirty rows %d-%d, want the whole frame", startY, endY)
+	}
+
+	prev := slices.Clone(frame.GetImage().Pix)
+	stride := frame.GetImage().Stride
+	for n, scale := range []float64{0.2, 0.5, 0.1, 0, 0, 0.3} {
+		for i := range heights {
+			heights[i] = scale * float64(config.Height/2) * float64(1+i%3) / 3
+		}
+		frame.SetLevel(scale)
+		frame.Draw(heights)
+		startY, endY := frame.DirtyRows()
+		img := frame.GetImage()
+		for y := range config.Height {
+			if y >= startY && y < endY {
+				continue
+			}
+			if !slices.Equal(img.Pix[y*stride:(y+1)*stride], prev[y*stride:(y+1)*stride]) {
+				t.Errorf("frame %d: row %d changed outside dirty rows %d-%d", n, y, startY, endY)
+				break
+			}
+		}
+		if endY-startY >= config.Height {
+			t.Errorf("frame %d: dirty rows %d-%d cover the whole frame", n, startY, endY)
+		}
+		copy(prev, img.Pix)
+	}
+
+	// Silence after silence repeats the frame, apart from the pulsing badge.
+	frame.SetLevel(0)
+	clear(heights)
+	frame.Draw(heights)
+	frame.Draw(heights)
+	if startY, endY := frame.DirtyRows(); startY != frame.badgeRows().startY || endY != frame.badgeRows().endY {
+		t.Errorf("silent frame dirty rows %d-%d, want the badge's %v", startY, endY, frame.badgeRows())
+	}
+
+	// A visualizer that cannot bound its drawing dirties every row.
+	frame.SetVisualizer(&recordingVisualizer{})
+	frame.Draw(heights)
+	frame.Draw(heights)
+	if startY, endY := frame.DirtyRows(); startY != 0 || endY != config.Height {
+		t.Errorf("unbounded visualizer dirty rows %d-%d, want the whole frame", startY, endY)
+	}
+}
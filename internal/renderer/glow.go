@@ -0,0 +1,113 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// The centroid glow (--centroid-glow) follows the spectral centroid of the
+// audio features: the bars, or the background, dim a little when the sound
+// is bass-heavy and brighten back to their own colours when it is
+// treble-heavy, so speech gets a touch of life from its sibilants and
+// plosives. The glow is eased like the reactive bar colours and quantised to
+// a few dozen steps, each a lookup table built only when the step changes.
+// The background also warms as it dims, losing more blue than red.
+
+// Centroid glow tuning
+const (
+	centroidFeature = "centroid" // audio.FeatureCentroid
+	glowLowHz       = 500.0      // Centroids at or below draw the dimmest
+	glowHighHz      = 4000.0     // Centroids at or above draw at full brightness
+	glowDepth       = 0.25       // Brightness lost at the dimmest
+	glowSmoothing   = 0.5        // Time constant in seconds
+	glowSteps       = 64
+)
+
+// glowWarmth is the further share of red, green and blue the background
+// loses at the dimmest.
+var glowWarmth = [3]float64{0, 0.06, 0.16}
+
+// centroidGlow eases the centroid into a level from 0 (bass-heavy) to 1
+// (treble-heavy); it is negative before the first sound, which draws at
+// full brightness.
+type centroidGlow struct {
+	level float64
+}
+
+func newCentroidGlow() *centroidGlow {
+	return &centroidGlow{level: -1}
+}
+
+// update moves the glow towards a frame's centroid in Hz. Silence, with no
+// centroid, keeps the last level.
+func (g *centroidGlow) update(hz float64) {
+	if hz <= 0 {
+		return
+	}
+	target := max(0, min(math.Log(hz/glowLowHz)/math.Log(glowHighHz/glowLowHz), 1))
+	if g.level < 0 {
+		g.level = target
+		return
+	}
+	g.level += (target - g.level) * (1 - math.Exp(-1/(config.Framerate*glowSmoothing)))
+}
+
+// step returns the level quantised to one of glowSteps; the top step is
+// full brightness.
+func (g *centroidGlow) step() int {
+	if g.level < 0 {
+		return glowSteps - 1
+	}
+	return int(g.level*(glowSteps-1) + 0.5)
+}
+
+// glowTable maps each channel value (0-255) to its value at step, losing a
+// further warmth share at the dimmest. The top step is the identity.
+func glowTable(step int, warmth float64) [256]uint8 {
+	dim := 1 - float64(step)/(glowSteps-1)
+	scale := (1 - glowDepth*dim) * (1 - warmth*dim)
+	var table [256]uint8
+	for i := range table {
+		table[i] = uint8(float64(i)*scale + 0.5)
+	}
+	return table
+}
+
+// glowingBars is the bars with --centroid-glow=bars. Only they read the
+// audio features, so plain bars leave Pass 2 without extracting them.
+type glowingBars struct {
+	*barsVisualizer
+}
+
+// Features moves the bars' glow towards the frame's centroid.
+func (b glowingBars) Features(features map[string]float64) {
+	b.glow.update(features[centroidFeature])
+	if step := b.glow.step(); step != b.glowStep {
+		b.glowStep, b.glowLevels = step, glowTable(step, 0)
+	}
+}
+
+// SetCentroid moves the background's glow towards the frame's spectral
+// centroid in Hz. It has no effect without --centroid-glow=background.
+func (f *Frame) SetCentroid(hz float64) {
+	if f.glow == nil {
+		return
+	}
+	f.glow.update(hz)
+	if step := f.glow.step(); step != f.glowStep {
+		f.glowStep, f.glowChanged = step, true
+		for c := range f.warmth {
+			f.warmth[c] = glowTable(step, glowWarmth[c])
+		}
+	}
+}
+
+// warmBackground dims and warms the background just drawn by the glow.
+func (f *Frame) warmBackground() {
+	r, g, b := &f.warmth[0], &f.warmth[1], &f.warmth[2]
+	pix := f.img.Pix
+	for i := 0; i < len(pix); i += 4 {
+		pix[i], pix[i+1], pix[i+2] = r[pix[i]], g[pix[i+1]], b[pix[i+2]]
+	}
+}
@@ -0,0 +1,110 @@
+package renderer
+
+import (
+	"image"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// TestCentroidGlow verifies the glow starts at full brightness, jumps to
+// the first sound's level, eases between levels and holds through silence.
+func TestCentroidGlow(t *testing.T) {
+	g := newCentroidGlow()
+	if step := g.step(); step != glowSteps-1 {
+		t.Errorf("before any sound: step %d, want %d", step, glowSteps-1)
+	}
+	g.update(200)
+	if step := g.step(); step != 0 {
+		t.Errorf("bass-heavy first frame: step %d, want 0", step)
+	}
+	g.update(8000)
+	if step := g.step(); step == 0 || step == glowSteps-1 {
+		t.Errorf("first treble frame: step %d, want part-way", step)
+	}
+	for range 3 * config.FPS {
+		g.update(8000)
+	}
+	if step := g.step(); step != glowSteps-1 {
+		t.Errorf("after 3 s of treble: step %d, want %d", step, glowSteps-1)
+	}
+	g.update(0)
+	if step := g.step(); step != glowSteps-1 {
+		t.Errorf("silence moved the glow to step %d", step)
+	}
+}
+
+// TestGlowingBars verifies --centroid-glow=bars registers bars that read the
+// features and dim for bass-heavy sound.
+func TestGlowingBars(t *testing.T) {
+	vis, err := NewVisualizer(DefaultVisualizer, &config.RuntimeConfig{CentroidGlow: config.GlowBars})
+	if err != nil {
+		t.Fatal(err)
+	}
+	glowing, ok := vis.(FeatureVisualizer)
+	if !ok {
+		t.Fatal("glowing bars do not read the features")
+	}
+	plain, err := NewVisualizer(DefaultVisualizer, &config.RuntimeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := plain.(FeatureVisualizer); ok {
+		t.Error("plain bars read the features")
+	}
+
+	heights := make([]float64, config.NumBars)
+	heights[config.NumBars/2] = 100
+	bars := glowing.(glowingBars).barsVisualizer
+	y := bars.centerY - config.CenterGap/2 - 1
+	x := bars.startX + config.NumBars/2*(config.BarWidth+config.BarGap)
+	draw := func(centroid float64) uint8 {
+		glowing.Features(map[string]float64{centroidFeature: centroid})
+		glowing.Process(heights, 0)
+		img := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+		glowing.Draw(img)
+		return img.RGBAAt(x, y).R
+	}
+	bass := draw(200)
+	for range 3 * config.FPS {
+		draw(8000)
+	}
+	treble := draw(8000)
+	if want := uint8(float64(treble)*(1-glowDepth) + 0.5); bass > want+1 || bass+1 < want {
+		t.Errorf("bass-heavy bar red %d, treble-heavy %d: want %d, dimmed by %.0f%%", bass, treble, want, glowDepth*100)
+	}
+}
+
+// TestBackgroundGlow verifies --centroid-glow=background dims and warms the
+// background for bass-heavy sound, marks the frame dirty when the glow
+// changes, and leaves it alone without the option.
+func TestBackgroundGlow(t *testing.T) {
+	bg := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	for i := range bg.Pix {
+		bg.Pix[i] = 200
+	}
+	frame := NewFrame(bg, nil, PodcastMeta{}, &config.RuntimeConfig{CentroidGlow: config.GlowBackground})
+	heights := make([]float64, config.NumBars)
+	frame.Draw(heights)
+	if got := frame.img.RGBAAt(0, 0); got.R != 200 || got.B != 200 {
+		t.Errorf("before any sound: background %v, want it untouched", got)
+	}
+
+	frame.Draw(heights)
+	frame.SetCentroid(200)
+	frame.Draw(heights)
+	got := frame.img.RGBAAt(0, 0)
+	if got.R >= 200 || got.B >= got.G || got.G >= got.R {
+		t.Errorf("bass-heavy background %v, want dimmed with blue lost most", got)
+	}
+	if start, end := frame.DirtyRows(); start != 0 || end != config.Height {
+		t.Errorf("glow change dirtied rows %d-%d, want the whole frame", start, end)
+	}
+
+	plain := NewFrame(bg, nil, PodcastMeta{}, &config.RuntimeConfig{})
+	plain.SetCentroid(200)
+	plain.Draw(heights)
+	if got := plain.img.RGBAAt(0, 0); got.R != 200 {
+		t.Errorf("without the option: background %v, want it untouched", got)
+	}
+}
@@ -0,0 +1,172 @@
+package renderer
+
+import (
+	"flag"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// The golden images pin the rendered output, so a refactor of the mirroring,
+// gradient or text code cannot change it unnoticed. After a deliberate change
+// to the look, rewrite them with
+//
+//	go test ./internal/renderer -run TestGoldenFrames -update
+//
+// and check the new images before committing them.
+var updateGolden = flag.Bool("update", false, "rewrite the golden images in testdata/golden")
+
+// Golden comparison tolerance. A pixel differs when any channel moves more
+// than goldenChannelDelta, which forgives rounding in scaling and glyph
+// antialiasing; an image fails when more than goldenMaxDiffer of its pixels
+// do, or any pixel moves more than goldenMaxDelta.
+const (
+	goldenChannelDelta = 8
+	goldenMaxDelta     = 64
+	goldenMaxDiffer    = 0.001
+)
+
+// goldenHeights returns a fixed spread of bar heights, in display order:
+// tall at the centre falling away to the edges, with a ripple so
+// neighbouring bars differ and one bar overshooting the maximum.
+func goldenHeights() []float64 {
+	maxHeight := float64(config.Height/2 - config.CenterGap/2)
+	heights := make([]float64, config.NumBars)
+	center := config.NumBars / 2
+	for i := range center {
+		h := maxHeight * (0.9 - 0.75*float64(i)/float64(center)) * (1 + 0.15*math.Sin(float64(i)*1.7))
+		heights[center-1-i], heights[center+i] = h, h
+	}
+	heights[center+3] = maxHeight * 1.2
+	return heights
+}
+
+func TestGoldenFrames(t *testing.T) {
+	episode := 42
+	meta := PodcastMeta{Title: "The Golden Master Episode", Episode: &episode}
+
+	split := make([]int, config.NumBars)
+	for i := range split {
+		split[i] = min(i*3/(config.NumBars/2), 2)
+	}
+	themed := &config.RuntimeConfig{
+		BarColor:   config.OptionalColor{R: 0x1d, G: 0xb9, B: 0x54, Set: true},
+		TextColor:  config.OptionalColor{R: 0xf0, G: 0xf0, B: 0xf0, Set: true},
+		TitleAlign: config.AlignLeft,
+		Crossovers: []float64{250, 4000},
+		BarBands:   split,
+	}
+
+	for _, tt := range []struct {
+		name          string
+		runtimeConfig *config.RuntimeConfig
+	}{
+		{"default", &config.RuntimeConfig{}},
+		{"themed", themed},
+		{"square", &config.RuntimeConfig{ClipShape: config.ClipSquare, ClipQuote: "Free as in freedom, not as in beer."}},
+		{"vertical", &config.RuntimeConfig{ClipShape: config.ClipVertical}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			face, err := LoadTitleFont(meta.Title, tt.runtimeConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			badgeFace, err := LoadBadgeFont(tt.runtimeConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			frame := NewFrame(nil, face, meta, tt.runtimeConfig)
+			frame.SetBadgeFont(badgeFace)
+			frame.Draw(goldenHeights())
+			img := frame.GetImage()
+			if tt.runtimeConfig.ClipShape != "" {
+				clip, err := NewClip(nil, meta, tt.runtimeConfig)
+				if err != nil {
+					t.Fatal(err)
+				}
+				img = clip.Compose(img)
+			}
+			checkGolden(t, tt.name, img)
+		})
+	}
+}
+
+// checkGolden compares img with testdata/golden/<name>.png, or rewrites that
+// file with -update. A mismatching image is saved to the temporary directory
+// for inspection.
+func checkGolden(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".png")
+	if *updateGolden {
+		if err := writePNG(path, img); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := readPNG(path)
+	if err != nil {
+		t.Fatalf("reading golden image (run with -update to create it): %v", err)
+	}
+	if want.Bounds() != img.Bounds() {
+		t.Fatalf("rendered %v, golden image is %v", img.Bounds(), want.Bounds())
+	}
+
+	var differ, worst int
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			got := img.RGBAAt(x, y)
+			r, g, bl, _ := want.At(x, y).RGBA()
+			delta := max(absDiff(got.R, uint8(r>>8)), absDiff(got.G, uint8(g>>8)), absDiff(got.B, uint8(bl>>8)))
+			worst = max(worst, delta)
+			if delta > goldenChannelDelta {
+				differ++
+			}
+		}
+	}
+	if frac := float64(differ) / float64(b.Dx()*b.Dy()); frac > goldenMaxDiffer || worst > goldenMaxDelta {
+		got := filepath.Join(os.TempDir(), "jivefire-golden-"+name+".png")
+		if err := writePNG(got, img); err != nil {
+			t.Logf("could not save the rendered image: %v", err)
+		}
+		t.Errorf("%d pixels (%.3f%%) differ from %s, by up to %d; rendered image saved to %s", differ, 100*frac, path, worst, got)
+	}
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,80 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// Guide colours: the safe areas are outlined, and the strips YouTube's
+// player covers are shaded, so text can be checked against both at a glance.
+var (
+	guideActionColor = color.RGBA{R: 64, G: 200, B: 255, A: 255}
+	guideTitleColor  = color.RGBA{R: 255, G: 210, B: 64, A: 255}
+	guidePlayerShade = color.RGBA{R: 200, G: 32, B: 32, A: 255}
+)
+
+const (
+	guideLineWidth  = 2
+	guideShadeAlpha = 96 // Opacity of the player strips
+)
+
+// DrawGuides draws the safe-area guides (--guides) over a 16:9 frame img in
+// place: the action-safe and title-safe rectangles, and the strips covered
+// by the YouTube player's title bar and controls, each labelled. It is for
+// the previews, so callers draw on a copy of the frame they encode.
+func DrawGuides(img *image.RGBA) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	titleBar := int(float64(h) * config.GuideTitleBar)
+	playerBar := int(float64(h) * config.GuidePlayerBar)
+	shadeRect(img, image.Rect(0, 0, w, titleBar), guidePlayerShade)
+	shadeRect(img, image.Rect(0, h-playerBar, w, h), guidePlayerShade)
+	drawMeterText(img, "PLAYER TITLE", 8, titleBar-16, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	drawMeterText(img, "PLAYER CONTROLS", 8, h-playerBar+4, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+
+	for _, guide := range []struct {
+		inset float64
+		label string
+		col   color.RGBA
+	}{
+		{config.GuideActionSafe, "ACTION SAFE", guideActionColor},
+		{config.GuideTitleSafe, "TITLE SAFE", guideTitleColor},
+	} {
+		dx, dy := int(float64(w)*guide.inset), int(float64(h)*guide.inset)
+		r := image.Rect(dx, dy, w-dx, h-dy)
+		outlineRect(img, r, guide.col)
+		drawMeterText(img, guide.label, r.Max.X-7*len(guide.label)-4, r.Min.Y+4, guide.col)
+	}
+}
+
+// outlineRect draws the edges of r, guideLineWidth wide, inside it.
+func outlineRect(img *image.RGBA, r image.Rectangle, col color.RGBA) {
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+guideLineWidth), col)
+	fillRect(img, image.Rect(r.Min.X, r.Max.Y-guideLineWidth, r.Max.X, r.Max.Y), col)
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+guideLineWidth, r.Max.Y), col)
+	fillRect(img, image.Rect(r.Max.X-guideLineWidth, r.Min.Y, r.Max.X, r.Max.Y), col)
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, col color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, col)
+		}
+	}
+}
+
+// shadeRect blends col over r at guideShadeAlpha.
+func shadeRect(img *image.RGBA, r image.Rectangle, col color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	src := [3]int{int(col.R), int(col.G), int(col.B)}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(r.Min.X, y):img.PixOffset(r.Max.X, y)]
+		for i := 0; i < len(row); i += 4 {
+			for c := range 3 {
+				row[i+c] = uint8((int(row[i+c])*(255-guideShadeAlpha) + src[c]*guideShadeAlpha + 127) / 255) //nolint:gosec // a weighted average of bytes
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// TestDrawGuides verifies the safe areas are outlined and the player strips
+// shaded, leaving the middle of the frame as it was.
+func TestDrawGuides(t *testing.T) {
+	w, h := config.Width, config.Height
+	grey := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = grey.R, grey.G, grey.B, grey.A
+	}
+	DrawGuides(img)
+
+	actionY := int(float64(h) * config.GuideActionSafe)
+	titleX := int(float64(w) * config.GuideTitleSafe)
+	for _, tc := range []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"action-safe top edge", w / 2, actionY, guideActionColor},
+		{"title-safe left edge", titleX, h / 2, guideTitleColor},
+		{"middle", w / 2, h / 2, grey},
+	} {
+		if got := img.RGBAAt(tc.x, tc.y); got != tc.want {
+			t.Errorf("%s (%d,%d) = %v, want %v", tc.name, tc.x, tc.y, got, tc.want)
+		}
+	}
+
+	// The controls strip is tinted towards the shade, not painted over.
+	got := img.RGBAAt(w/2, h-int(float64(h)*config.GuidePlayerBar)/2)
+	if got == grey || got.R <= grey.R || got.G >= grey.G {
+		t.Errorf("controls strip = %v, want grey tinted red", got)
+	}
+	if got := img.RGBAAt(w/2, h-int(float64(h)*config.GuidePlayerBar)-1); got != grey {
+		t.Errorf("row above the controls strip = %v, want it untouched", got)
+	}
+}
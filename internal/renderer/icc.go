@@ -0,0 +1,342 @@
+package renderer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+// Artwork exported from photo tools often carries an ICC profile for a wider
+// gamut than sRGB, typically Display P3 or Adobe RGB. Go's decoders ignore
+// it, so the pixel values would be shown as sRGB and the artwork would come
+// out washed out. toSRGB converts images with a matrix/TRC RGB profile, the
+// kind those colour spaces use; images without a profile are taken to be
+// sRGB already, and other profiles (CMYK, LUT-based) are left as decoded.
+
+// xyz50ToSRGB converts from the ICC profile connection space, XYZ relative
+// to D50, to linear sRGB (Bradford adapted), row-major.
+var xyz50ToSRGB = [9]float64{
+	3.1338561, -1.6168667, -0.4906146,
+	-0.9787684, 1.9161415, 0.0334540,
+	0.0719453, -0.2289914, 1.4052427,
+}
+
+// iccTransform converts 8-bit pixels in a profile's colour space to sRGB.
+type iccTransform struct {
+	linear [3][256]float64 // Each channel's tone curve, to linear light
+	matrix [9]float64      // Linear profile RGB to linear sRGB, row-major
+}
+
+// toSRGB returns img converted to sRGB per the ICC profile embedded in data,
+// the encoded image, or img itself when there is nothing to convert.
+func toSRGB(img image.Image, data []byte) image.Image {
+	profile := embeddedICC(data)
+	if profile == nil {
+		return img
+	}
+	t, err := parseICC(profile)
+	if err != nil || t.isSRGB() {
+		return img
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+	t.apply(dst)
+	return dst
+}
+
+// apply converts img's pixels in place, keeping alpha.
+func (t *iccTransform) apply(img *image.NRGBA) {
+	const steps = 4096
+	var encode [steps + 1]uint8
+	for i := range encode {
+		encode[i] = uint8(math.Round(srgbEncode(float64(i)/steps) * 255))
+	}
+	m := &t.matrix
+	for i := 0; i < len(img.Pix); i += 4 {
+		r := t.linear[0][img.Pix[i]]
+		g := t.linear[1][img.Pix[i+1]]
+		b := t.linear[2][img.Pix[i+2]]
+		for c := range 3 {
+			v := m[c*3]*r + m[c*3+1]*g + m[c*3+2]*b
+			img.Pix[i+c] = encode[int(min(max(v, 0), 1)*steps+0.5)]
+		}
+	}
+}
+
+// isSRGB reports whether t is close enough to sRGB that converting would
+// change no pixel by more than rounding: the profile is sRGB under another
+// name, as most embedded profiles are.
+func (t *iccTransform) isSRGB() bool {
+	for i, v := range t.matrix {
+		want := 0.0
+		if i%4 == 0 {
+			want = 1
+		}
+		if math.Abs(v-want) > 0.002 {
+			return false
+		}
+	}
+	for c := range 3 {
+		for i, v := range t.linear[c] {
+			if math.Abs(srgbEncode(v)*255-float64(i)) > 0.5 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// srgbEncode applies the sRGB transfer function to linear light v in [0, 1].
+func srgbEncode(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+var errICCUnsupported = errors.New("not a matrix/TRC RGB profile")
+
+// parseICC reads the primaries and tone curves of an RGB display profile.
+func parseICC(p []byte) (*iccTransform, error) {
+	if len(p) < 132 || string(p[16:20]) != "RGB " || string(p[20:24]) != "XYZ " {
+		return nil, errICCUnsupported
+	}
+	tags := make(map[string][]byte)
+	n := int(binary.BigEndian.Uint32(p[128:]))
+	for i := range n {
+		e := 132 + i*12
+		if e+12 > len(p) {
+			return nil, errICCUnsupported
+		}
+		off, size := binary.BigEndian.Uint32(p[e+4:]), binary.BigEndian.Uint32(p[e+8:])
+		if uint64(off)+uint64(size) > uint64(len(p)) {
+			return nil, errICCUnsupported
+		}
+		tags[string(p[e:e+4])] = p[off : off+size]
+	}
+
+	var t iccTransform
+	var primaries [9]float64 // Linear profile RGB to XYZ, row-major
+	for c, name := range []string{"r", "g", "b"} {
+		xyz, ok := iccXYZ(tags[name+"XYZ"])
+		if !ok {
+			return nil, errICCUnsupported
+		}
+		for row := range 3 {
+			primaries[row*3+c] = xyz[row]
+		}
+		curve, ok := iccCurve(tags[name+"TRC"])
+		if !ok {
+			return nil, errICCUnsupported
+		}
+		for i := range t.linear[c] {
+			t.linear[c][i] = min(max(curve(float64(i)/255), 0), 1)
+		}
+	}
+	for row := range 3 {
+		for col := range 3 {
+			for k := range 3 {
+				t.matrix[row*3+col] += xyz50ToSRGB[row*3+k] * primaries[k*3+col]
+			}
+		}
+	}
+	return &t, nil
+}
+
+// iccXYZ reads an XYZType tag.
+func iccXYZ(tag []byte) ([3]float64, bool) {
+	if len(tag) < 20 || string(tag[:4]) != "XYZ " {
+		return [3]float64{}, false
+	}
+	return [3]float64{s15Fixed16(tag[8:]), s15Fixed16(tag[12:]), s15Fixed16(tag[16:])}, true
+}
+
+// iccCurve reads a curveType or parametricCurveType tag as a function from
+// encoded values to linear light, both in [0, 1].
+func iccCurve(tag []byte) (func(float64) float64, bool) {
+	if len(tag) < 12 {
+		return nil, false
+	}
+	switch string(tag[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if len(tag) < 12+2*n {
+			return nil, false
+		}
+		switch n {
+		case 0:
+			return func(x float64) float64 { return x }, true
+		case 1:
+			g := float64(binary.BigEndian.Uint16(tag[12:])) / 256
+			return func(x float64) float64 { return math.Pow(x, g) }, true
+		}
+		table := make([]float64, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+2*i:])) / 65535
+		}
+		return func(x float64) float64 {
+			pos := x * float64(n-1)
+			i := min(int(pos), n-2)
+			return table[i] + (table[i+1]-table[i])*(pos-float64(i))
+		}, true
+	case "para":
+		counts := []int{1, 3, 4, 5, 7}
+		kind := int(binary.BigEndian.Uint16(tag[8:]))
+		if kind >= len(counts) || len(tag) < 12+4*counts[kind] {
+			return nil, false
+		}
+		var a [7]float64 // g, a, b, c, d, e, f
+		for i := range counts[kind] {
+			a[i] = s15Fixed16(tag[12+4*i:])
+		}
+		g, ca, cb, cc, cd, ce, cf := a[0], a[1], a[2], a[3], a[4], a[5], a[6]
+		pow := func(x float64) float64 { return math.Pow(max(ca*x+cb, 0), g) }
+		switch kind {
+		case 0:
+			return func(x float64) float64 { return math.Pow(x, g) }, true
+		case 1:
+			return func(x float64) float64 {
+				if x >= -cb/ca {
+					return pow(x)
+				}
+				return 0
+			}, true
+		case 2:
+			return func(x float64) float64 {
+				if x >= -cb/ca {
+					return pow(x) + cc
+				}
+				return cc
+			}, true
+		case 3:
+			return func(x float64) float64 {
+				if x >= cd {
+					return pow(x)
+				}
+				return cc * x
+			}, true
+		default:
+			return func(x float64) float64 {
+				if x >= cd {
+					return pow(x) + ce
+				}
+				return cc*x + cf
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// s15Fixed16 reads an ICC signed 15.16 fixed-point number.
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536 //nolint:gosec // a two's complement field
+}
+
+// embeddedICC returns the ICC profile embedded in a PNG, JPEG or WebP, or nil
+// when it has none. A PNG marked sRGB has no profile to apply.
+func embeddedICC(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return pngICC(data[8:])
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		return jpegICC(data[2:])
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return webpICC(data[12:])
+	}
+	return nil
+}
+
+// pngICC reads the iCCP chunk, which precedes the image data.
+func pngICC(data []byte) []byte {
+	for len(data) >= 12 {
+		n := int(binary.BigEndian.Uint32(data))
+		kind := string(data[4:8])
+		if n > len(data)-12 || kind == "IDAT" || kind == "sRGB" {
+			return nil
+		}
+		if kind == "iCCP" {
+			chunk := data[8 : 8+n]
+			name := bytes.IndexByte(chunk, 0)
+			if name < 0 || name+2 > len(chunk) || chunk[name+1] != 0 {
+				return nil
+			}
+			r, err := zlib.NewReader(bytes.NewReader(chunk[name+2:]))
+			if err != nil {
+				return nil
+			}
+			profile, err := io.ReadAll(io.LimitReader(r, 1<<22))
+			if err != nil {
+				return nil
+			}
+			return profile
+		}
+		data = data[12+n:]
+	}
+	return nil
+}
+
+// jpegICC joins the profile from its APP2 segments, which may split it, in
+// the order they are numbered.
+func jpegICC(data []byte) []byte {
+	const sig = "ICC_PROFILE\x00"
+	var parts [][]byte
+	for len(data) >= 4 && data[0] == 0xff {
+		marker := data[1]
+		if marker == 0xff {
+			data = data[1:] // Fill byte
+			continue
+		}
+		if marker == 0xda || marker == 0xd9 {
+			break // Start of scan, or end of image
+		}
+		n := int(binary.BigEndian.Uint16(data[2:]))
+		if n < 2 || n+2 > len(data) {
+			break
+		}
+		seg := data[4 : 2+n]
+		if marker == 0xe2 && len(seg) > len(sig)+2 && string(seg[:len(sig)]) == sig {
+			seq, count := int(seg[len(sig)]), int(seg[len(sig)+1])
+			if parts == nil {
+				parts = make([][]byte, count)
+			}
+			if seq < 1 || seq > len(parts) {
+				return nil
+			}
+			parts[seq-1] = seg[len(sig)+2:]
+		}
+		data = data[2+n:]
+	}
+	var profile []byte
+	for _, p := range parts {
+		if p == nil {
+			return nil
+		}
+		profile = append(profile, p...)
+	}
+	return profile
+}
+
+// webpICC reads the ICCP chunk of an extended WebP.
+func webpICC(data []byte) []byte {
+	for len(data) >= 8 {
+		n := int(binary.LittleEndian.Uint32(data[4:]))
+		if n > len(data)-8 {
+			return nil
+		}
+		if string(data[:4]) == "ICCP" {
+			return data[8 : 8+n]
+		}
+		next := 8 + n + n%2
+		if next > len(data) {
+			return nil
+		}
+		data = data[next:]
+	}
+	return nil
+}
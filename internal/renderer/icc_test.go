@@ -0,0 +1,249 @@
+package renderer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/failure"
+)
+
+// Primaries of sRGB and Display P3 as ICC rXYZ, gXYZ and bXYZ tags hold
+// them: XYZ relative to D50.
+var (
+	srgbPrimaries = [3][3]float64{
+		{0.4360747, 0.2225045, 0.0139322},
+		{0.3850649, 0.7168786, 0.0971045},
+		{0.1430804, 0.0606169, 0.7141733},
+	}
+	p3Primaries = [3][3]float64{
+		{0.5151, 0.2412, -0.0011},
+		{0.2919, 0.6922, 0.0419},
+		{0.1571, 0.0666, 0.7841},
+	}
+)
+
+// s15 encodes v as an ICC s15Fixed16Number.
+func s15(v float64) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(int32(math.Round(v*65536)))) //nolint:gosec // two's complement
+}
+
+// srgbCurve is the sRGB tone curve as a parametricCurveType tag.
+func srgbCurve() []byte {
+	tag := []byte("para\x00\x00\x00\x00\x00\x03\x00\x00")
+	for _, v := range []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045} {
+		tag = append(tag, s15(v)...)
+	}
+	return tag
+}
+
+// linearCurve is an identity curveType tag: the profile's values are linear
+// light.
+func linearCurve() []byte {
+	return []byte("curv\x00\x00\x00\x00\x00\x00\x00\x00")
+}
+
+// testProfile builds a minimal RGB display profile from primaries and one
+// tone curve shared by the three channels.
+func testProfile(primaries [3][3]float64, curve []byte) []byte {
+	type tag struct {
+		sig  string
+		data []byte
+	}
+	var tags []tag
+	for c, name := range []string{"r", "g", "b"} {
+		xyz := []byte("XYZ \x00\x00\x00\x00")
+		for _, v := range primaries[c] {
+			xyz = append(xyz, s15(v)...)
+		}
+		tags = append(tags, tag{name + "XYZ", xyz}, tag{name + "TRC", curve})
+	}
+
+	header := make([]byte, 128)
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	copy(header[36:], "acsp")
+	table := binary.BigEndian.AppendUint32(nil, uint32(len(tags)))
+	var data []byte
+	offset := 128 + 4 + 12*len(tags)
+	for _, t := range tags {
+		table = append(table, t.sig...)
+		table = binary.BigEndian.AppendUint32(table, uint32(offset+len(data))) //nolint:gosec // small test profile
+		table = binary.BigEndian.AppendUint32(table, uint32(len(t.data)))
+		data = append(data, t.data...)
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+	}
+	p := append(append(header, table...), data...)
+	binary.BigEndian.PutUint32(p, uint32(len(p))) //nolint:gosec // small test profile
+	return p
+}
+
+// pngWithProfile encodes img as a PNG carrying profile in an iCCP chunk.
+func pngWithProfile(t *testing.T, img image.Image, profile []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	var z bytes.Buffer
+	w := zlib.NewWriter(&z)
+	_, _ = w.Write(profile)
+	_ = w.Close()
+	body := append([]byte("test\x00\x00"), z.Bytes()...)
+
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(body))) //nolint:gosec // small test chunk
+	chunk = append(chunk, "iCCP"...)
+	chunk = append(chunk, body...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	// The iCCP chunk goes straight after IHDR, which always ends at byte 33.
+	encoded := buf.Bytes()
+	return append(append(append([]byte{}, encoded[:33]...), chunk...), encoded[33:]...)
+}
+
+// flatImage returns a 4×4 image of one colour.
+func flatImage(c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 4 {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// decodedAt decodes data and returns its top-left pixel.
+func decodedAt(t *testing.T, data []byte) color.RGBA {
+	t.Helper()
+	img, err := decodeImage(data)
+	if err != nil {
+		t.Fatalf("decodeImage() error = %v", err)
+	}
+	r, g, b, a := img.At(0, 0).RGBA()
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
+}
+
+// near reports whether each channel of got is within 1 of want.
+func near(got, want color.RGBA) bool {
+	d := func(a, b uint8) bool { return max(a, b)-min(a, b) <= 1 }
+	return d(got.R, want.R) && d(got.G, want.G) && d(got.B, want.B) && got.A == want.A
+}
+
+// TestToSRGBConvertsProfile verifies pixels are re-encoded through the
+// embedded profile's tone curve and primaries.
+func TestToSRGBConvertsProfile(t *testing.T) {
+	// Linear-light sRGB: 128 is 50% light, which sRGB encodes as 188.
+	data := pngWithProfile(t, flatImage(color.RGBA{128, 128, 128, 255}), testProfile(srgbPrimaries, linearCurve()))
+	if got, want := decodedAt(t, data), (color.RGBA{188, 188, 188, 255}); !near(got, want) {
+		t.Errorf("linear profile: pixel = %v, want %v", got, want)
+	}
+
+	// Display P3 shares sRGB's white point and tone curve, so grey is kept,
+	// while its green is beyond sRGB's and clips to sRGB's purest green.
+	p3 := testProfile(p3Primaries, srgbCurve())
+	if got, want := decodedAt(t, pngWithProfile(t, flatImage(color.RGBA{128, 128, 128, 255}), p3)), (color.RGBA{128, 128, 128, 255}); !near(got, want) {
+		t.Errorf("P3 grey = %v, want %v", got, want)
+	}
+	if got, want := decodedAt(t, pngWithProfile(t, flatImage(color.RGBA{0, 255, 0, 255}), p3)), (color.RGBA{0, 255, 0, 255}); !near(got, want) {
+		t.Errorf("P3 green = %v, want %v", got, want)
+	}
+	if got := decodedAt(t, pngWithProfile(t, flatImage(color.RGBA{60, 160, 60, 255}), p3)); got.G <= 160 || got.R >= 60 {
+		t.Errorf("P3 (60, 160, 60) = %v, want greener in sRGB", got)
+	}
+}
+
+// TestToSRGBSkipsSRGB verifies an sRGB profile, and no profile at all,
+// leave the decoded image untouched.
+func TestToSRGBSkipsSRGB(t *testing.T) {
+	tr, err := parseICC(testProfile(srgbPrimaries, srgbCurve()))
+	if err != nil {
+		t.Fatalf("parseICC() error = %v", err)
+	}
+	if !tr.isSRGB() {
+		t.Error("sRGB profile not recognised as sRGB")
+	}
+
+	img := flatImage(color.RGBA{10, 20, 30, 255})
+	if got := toSRGB(img, nil); got != image.Image(img) {
+		t.Error("image without a profile was converted")
+	}
+	if _, err := parseICC([]byte("not a profile")); !errors.Is(err, errICCUnsupported) {
+		t.Errorf("parseICC(garbage) error = %v, want errICCUnsupported", err)
+	}
+}
+
+// TestEmbeddedICCJPEG verifies a profile split across APP2 segments is
+// joined in sequence order.
+func TestEmbeddedICCJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flatImage(color.RGBA{A: 255}), nil); err != nil {
+		t.Fatal(err)
+	}
+	profile := testProfile(p3Primaries, srgbCurve())
+	half := len(profile) / 2
+	segment := func(seq int, part []byte) []byte {
+		body := append([]byte("ICC_PROFILE\x00"), byte(seq), 2)
+		body = append(body, part...)
+		seg := []byte{0xff, 0xe2}
+		return append(binary.BigEndian.AppendUint16(seg, uint16(len(body)+2)), body...) //nolint:gosec // small test segment
+	}
+	encoded := buf.Bytes()
+	data := append([]byte{0xff, 0xd8}, segment(2, profile[half:])...)
+	data = append(data, segment(1, profile[:half])...)
+	data = append(data, encoded[2:]...)
+
+	if got := embeddedICC(data); !bytes.Equal(got, profile) {
+		t.Errorf("embeddedICC() = %d bytes, want the %d byte profile", len(got), len(profile))
+	}
+	if embeddedICC(encoded) != nil {
+		t.Error("embeddedICC() found a profile in a plain JPEG")
+	}
+}
+
+// TestValidateImage verifies unusable artwork is reported as an unsupported
+// format before any render starts.
+func TestValidateImage(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	var wide bytes.Buffer
+	if err := png.Encode(&wide, image.NewGray(image.Rect(0, 0, maxImageSide+1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	var ok bytes.Buffer
+	if err := png.Encode(&ok, flatImage(color.RGBA{A: 255})); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{
+		"art.gif":       []byte("GIF89a\x01\x00\x01\x00"),
+		"truncated.png": ok.Bytes()[:20],
+		"wide.png":      wide.Bytes(),
+	} {
+		err := ValidateImage(write(name, data))
+		if !errors.Is(err, failure.ErrUnsupportedFormat) {
+			t.Errorf("ValidateImage(%s) error = %v, want ErrUnsupportedFormat", name, err)
+		}
+	}
+	if err := ValidateImage(write("ok.png", ok.Bytes())); err != nil {
+		t.Errorf("ValidateImage(ok.png) error = %v", err)
+	}
+}
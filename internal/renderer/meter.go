@@ -0,0 +1,184 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Level meter layout and ballistics. The scale runs from meterFloor up to 0
+// dBFS; the bar turns amber above meterAmber and red above meterRed. The
+// peak-hold marker stays put for meterHold, then falls at meterFall.
+const (
+	meterFloor = -60.0 // dBFS
+	meterAmber = -18.0 // dBFS
+	meterRed   = -6.0  // dBFS
+	meterHold  = 1.5   // seconds
+	meterFall  = 20.0  // dB per second
+
+	meterMargin     = 8
+	meterLabelW     = 30 // Scale labels, three 7px characters, a tick and gaps
+	meterBarW       = 14
+	meterBarGap     = 4
+	meterScaleH     = 200
+	meterTextH      = 13 // basicfont.Face7x13
+	meterTextGap    = 6
+	meterWidth      = 2*meterMargin + meterLabelW + 2*meterBarW + meterBarGap
+	meterHeight     = 2*meterMargin + 2*(meterTextH+meterTextGap) + meterScaleH
+	meterBarTop     = meterMargin + meterTextH + meterTextGap
+	meterMarkerH    = 2
+	meterPanelAlpha = 160 // Panel opacity behind the scale
+)
+
+// meterTicks are the labelled points on the dBFS scale.
+var meterTicks = []float64{0, -6, -12, -18, -24, -36, -48, -60}
+
+// levelMeter is a stereo level meter in a frame corner: for each channel a
+// bar to the frame's RMS level, a line at its instantaneous peak and a
+// peak-hold marker, on a dBFS scale. The panel and scale never change, so
+// they are drawn once and composited under the moving parts.
+type levelMeter struct {
+	origin image.Point
+	panel  *image.RGBA
+	colour [meterScaleH][3]uint8 // Bar colour by row from the top
+
+	rms, peak, hold [2]float64 // dBFS
+	held            [2]float64 // Seconds each hold marker has stood
+}
+
+func newLevelMeter(pos config.BadgePosition, padding int) *levelMeter {
+	m := &levelMeter{
+		origin: badgeOrigin(pos, meterWidth, meterHeight, padding),
+		panel:  image.NewRGBA(image.Rect(0, 0, meterWidth, meterHeight)),
+	}
+	for c := range m.rms {
+		m.rms[c], m.peak[c], m.hold[c] = meterFloor, meterFloor, meterFloor
+	}
+	for y := range meterScaleH {
+		db := meterFloor * float64(y) / meterScaleH
+		switch {
+		case db > meterRed:
+			m.colour[y] = [3]uint8{231, 76, 60}
+		case db > meterAmber:
+			m.colour[y] = [3]uint8{248, 179, 29}
+		default:
+			m.colour[y] = [3]uint8{46, 204, 113}
+		}
+	}
+
+	draw.Draw(m.panel, m.panel.Bounds(), image.NewUniform(color.RGBA{A: meterPanelAlpha}), image.Point{}, draw.Src)
+	label := color.RGBA{R: 220, G: 220, B: 220, A: 255}
+	trough := color.RGBA{R: 40, G: 40, B: 40, A: 255}
+	for c := range 2 {
+		x := meterBarX(c)
+		draw.Draw(m.panel, image.Rect(x, meterBarTop, x+meterBarW, meterBarTop+meterScaleH), image.NewUniform(trough), image.Point{}, draw.Src)
+		drawMeterText(m.panel, []string{"L", "R"}[c], x+(meterBarW-7)/2, meterMargin, label)
+	}
+	for _, db := range meterTicks {
+		y := meterBarTop + meterRow(db)
+		text := fmt.Sprintf("%3.0f", db)
+		drawMeterText(m.panel, text, meterMargin, min(max(y-meterTextH/2, 0), meterHeight-meterTextH), label)
+		draw.Draw(m.panel, image.Rect(meterMargin+meterLabelW-7, y, meterMargin+meterLabelW-3, y+1), image.NewUniform(label), image.Point{}, draw.Src)
+	}
+	drawMeterText(m.panel, "dBFS", (meterWidth-4*7)/2, meterHeight-meterMargin-meterTextH, label)
+	return m
+}
+
+// meterBarX returns the panel column of a channel's bar.
+func meterBarX(channel int) int {
+	return meterMargin + meterLabelW + channel*(meterBarW+meterBarGap)
+}
+
+// meterRow returns the scale row, from the top, of a level in dBFS.
+func meterRow(db float64) int {
+	db = min(max(db, meterFloor), 0)
+	return min(int(db/meterFloor*meterScaleH), meterScaleH-1)
+}
+
+// drawMeterText draws a label with its top-left corner at x, y.
+func drawMeterText(img *image.RGBA, text string, x, y int, col color.RGBA) {
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y+basicfont.Face7x13.Ascent),
+	}
+	d.DrawString(text)
+}
+
+// dBFS converts a linear level to dBFS, at the meter floor for silence.
+func dBFS(level float64) float64 {
+	if level <= 0 {
+		return meterFloor
+	}
+	return max(20*math.Log10(level), meterFloor)
+}
+
+// setLevels advances the meter one frame with each channel's linear RMS and
+// peak; a missing channel repeats the last given.
+func (m *levelMeter) setLevels(rms, peak []float64) {
+	if len(rms) == 0 || len(peak) == 0 {
+		return
+	}
+	const dt = 1.0 / config.FPS
+	for c := range m.rms {
+		m.rms[c] = dBFS(rms[min(c, len(rms)-1)])
+		m.peak[c] = max(dBFS(peak[min(c, len(peak)-1)]), m.rms[c])
+		m.held[c] += dt
+		if m.held[c] > meterHold {
+			m.hold[c] = max(m.hold[c]-meterFall*dt, meterFloor)
+		}
+		if m.peak[c] >= m.hold[c] {
+			m.hold[c], m.held[c] = m.peak[c], 0
+		}
+	}
+}
+
+// draw composites the panel into the frame and paints the bars and markers.
+func (m *levelMeter) draw(img *image.RGBA) {
+	dst := image.Rectangle{Min: m.origin, Max: m.origin.Add(m.panel.Bounds().Size())}
+	draw.Draw(img, dst, m.panel, image.Point{}, draw.Over)
+	for c := range m.rms {
+		x := m.origin.X + meterBarX(c)
+		top := m.origin.Y + meterBarTop
+		if m.rms[c] > meterFloor {
+			for y := meterRow(m.rms[c]); y < meterScaleH; y++ {
+				m.fillRow(img, x, top+y, m.colour[y])
+			}
+		}
+		for _, mark := range []struct {
+			db     float64
+			colour [3]uint8
+		}{{m.peak[c], [3]uint8{255, 255, 255}}, {m.hold[c], m.colour[meterRow(m.hold[c])]}} {
+			if mark.db <= meterFloor {
+				continue
+			}
+			for dy := range meterMarkerH {
+				m.fillRow(img, x, top+min(meterRow(mark.db)+dy, meterScaleH-1), mark.colour)
+			}
+		}
+	}
+}
+
+// fillRow paints one bar-wide row of the meter at x, y.
+func (m *levelMeter) fillRow(img *image.RGBA, x, y int, c [3]uint8) {
+	if y < 0 || y >= config.Height || x < 0 || x+meterBarW > config.Width {
+		return
+	}
+	offset := y*img.Stride + x*4
+	for px := range meterBarW {
+		img.Pix[offset+px*4], img.Pix[offset+px*4+1], img.Pix[offset+px*4+2], img.Pix[offset+px*4+3] = c[0], c[1], c[2], 255
+	}
+}
+
+// rows returns the frame rows the meter covers.
+func (m *levelMeter) rows() rowSpan {
+	return newRowSpan(m.origin.Y, m.origin.Y+meterHeight)
+}
@@ -0,0 +1,58 @@
+package renderer
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// TestMeterBallistics verifies the levels read in dBFS, and the peak-hold
+// marker stands for meterHold before falling at meterFall.
+func TestMeterBallistics(t *testing.T) {
+	m := newLevelMeter(config.BadgeBottomLeft, 30)
+	m.setLevels([]float64{0.1}, []float64{0.5})
+	if math.Abs(m.rms[1]+20) > 1e-9 || math.Abs(m.peak[0]-20*math.Log10(0.5)) > 1e-9 {
+		t.Errorf("levels %v dBFS RMS, %v peak; want -20 and -6 on both channels", m.rms, m.peak)
+	}
+	held := m.hold[0]
+	frames := int(meterHold*config.FPS) + config.FPS/2
+	for range frames {
+		m.setLevels([]float64{0}, []float64{0})
+	}
+	if m.peak[0] != meterFloor {
+		t.Errorf("peak after silence at %v dBFS, want the floor", m.peak[0])
+	}
+	fell := held - m.hold[0]
+	if want := meterFall * 0.5; math.Abs(fell-want) > meterFall/config.FPS*2 {
+		t.Errorf("hold fell %.1f dB half a second after its hold, want about %.1f", fell, want)
+	}
+}
+
+// TestMeterDraw verifies the meter sits in its corner and the bar reaches
+// the RMS level in the colour of its zone.
+func TestMeterDraw(t *testing.T) {
+	m := newLevelMeter(config.BadgeBottomLeft, 30)
+	if m.origin.X != 30 || m.origin.Y != config.Height-meterHeight-30 {
+		t.Errorf("meter origin %v, want bottom-left inset by 30", m.origin)
+	}
+	m.setLevels([]float64{0.5, 0.01}, []float64{0.5, 0.01})
+	img := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	m.draw(img)
+
+	top := m.origin.Y + meterBarTop
+	left, right := m.origin.X+meterBarX(0)+1, m.origin.X+meterBarX(1)+1
+	if got := img.RGBAAt(left, top+meterScaleH-1); got.G != 204 {
+		t.Errorf("bottom of the left bar = %v, want green", got)
+	}
+	if got := img.RGBAAt(left, top+meterRow(-10)); got.R != 248 {
+		t.Errorf("left bar at -10 dBFS = %v, want amber", got)
+	}
+	if got := img.RGBAAt(right, top+meterRow(-30)); got.G == 204 {
+		t.Errorf("right bar drawn above its -40 dBFS level: %v", got)
+	}
+	if rows := m.rows(); rows.startY != m.origin.Y || rows.endY != m.origin.Y+meterHeight {
+		t.Errorf("rows = %v, want the panel", rows)
+	}
+}
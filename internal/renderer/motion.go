@@ -0,0 +1,125 @@
+package renderer
+
+import (
+	"image"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/yuv"
+)
+
+// Ken Burns motion (--background-motion kenburns) draws each frame's
+// background from artwork fitted larger than the frame: the view starts on
+// the whole of it and eases in to a frame-sized part, drifting right, by the
+// end of the episode. The view is a scale and offset of the source, sampled
+// bilinearly, so the drift is smooth rather than stepping a pixel at a time.
+
+// kenBurnsPan is how far the view drifts right, as a fraction of the room
+// either side of centre at the end.
+const kenBurnsPan = 0.5
+
+// LoadMotionBackground loads the background for Ken Burns motion: fitted to
+// config.KenBurnsZoom times the frame size, then blurred (the radius scaled
+// to match) and darkened like LoadBackgroundImage.
+func LoadMotionBackground(runtimeConfig *config.RuntimeConfig) (*image.RGBA, error) {
+	width, height := kenBurnsSize()
+	bg, err := loadFittedBackground(runtimeConfig, width, height)
+	if err != nil {
+		return nil, err
+	}
+	blurImage(bg, int(float64(runtimeConfig.BackgroundBlur)*config.KenBurnsZoom+0.5))
+	dimImage(bg, runtimeConfig.BackgroundDim)
+	return bg, nil
+}
+
+// kenBurnsSize returns the size the motion background is fitted to.
+func kenBurnsSize() (width, height int) {
+	return int(config.Width * config.KenBurnsZoom), int(config.Height * config.KenBurnsZoom)
+}
+
+// kenBurns samples the view of src for each frame.
+type kenBurns struct {
+	src  *image.RGBA
+	pool *yuv.RowPool
+
+	// Per-frame column taps, reused: the source column left of each output
+	// pixel and the weight (0-256) of the one to its right
+	srcX []int
+	wx   []int32
+}
+
+func newKenBurns(src *image.RGBA) *kenBurns {
+	return &kenBurns{
+		src:  src,
+		pool: yuv.NewRowPool(config.Height),
+		srcX: make([]int, config.Width),
+		wx:   make([]int32, config.Width),
+	}
+}
+
+// view returns the part of the source shown progress p (0-1) through the
+// episode: its top-left corner and its width, the height following from the
+// frame's aspect ratio.
+func (k *kenBurns) view(p float64) (x0, y0, width float64) {
+	p = max(0, min(p, 1))
+	e := p * p * (3 - 2*p) // Ease in and out
+	sw, sh := float64(k.src.Bounds().Dx()), float64(k.src.Bounds().Dy())
+	zoom := 1 + (sw/config.Width-1)*e
+	width = sw / zoom
+	height := width * config.Height / config.Width
+	x0 = (sw - width) / 2 * (1 + kenBurnsPan*e)
+	y0 = (sh - height) / 2
+	return x0, y0, width
+}
+
+// draw fills dst, the frame, with the view progress p through the episode.
+func (k *kenBurns) draw(dst *image.RGBA, p float64) {
+	x0, y0, width := k.view(p)
+	scale := width / config.Width
+	maxX, maxY := k.src.Bounds().Dx()-2, k.src.Bounds().Dy()-2
+	for x := range config.Width {
+		sx := x0 + (float64(x)+0.5)*scale - 0.5
+		ix := max(0, min(int(sx), maxX))
+		k.srcX[x] = ix
+		k.wx[x] = int32(max(0, min((sx-float64(ix))*256, 256)))
+	}
+	src := k.src
+	k.pool.Run(func(startY, endY int) {
+		for y := startY; y < endY; y++ {
+			sy := y0 + (float64(y)+0.5)*scale - 0.5
+			iy := max(0, min(int(sy), maxY))
+			wy := int32(max(0, min((sy-float64(iy))*256, 256)))
+			top := src.Pix[iy*src.Stride:]
+			bottom := src.Pix[(iy+1)*src.Stride:]
+			row := dst.Pix[y*dst.Stride : y*dst.Stride+config.Width*4]
+			for x := range config.Width {
+				i, wx := k.srcX[x]*4, k.wx[x]
+				for c := range 3 {
+					t := int32(top[i+c])*(256-wx) + int32(top[i+4+c])*wx
+					b := int32(bottom[i+c])*(256-wx) + int32(bottom[i+4+c])*wx
+					row[x*4+c] = uint8((t*(256-wy) + b*wy + 1<<15) >> 16) //nolint:gosec // a weighted average of bytes
+				}
+				row[x*4+3] = 255
+			}
+		}
+	})
+}
+
+// SetBackgroundMotion makes the background move with Ken Burns motion
+// across src, from LoadMotionBackground, in place of the still background;
+// nil keeps it still. The motion runs over SetTotalFrames frames.
+func (f *Frame) SetBackgroundMotion(src *image.RGBA) {
+	f.motion = nil
+	if src != nil {
+		f.motion = newKenBurns(src)
+	}
+	f.redraw = true
+}
+
+// motionProgress returns how far through the episode the frame about to be
+// drawn is, from 0 to 1; 0 until SetTotalFrames.
+func (f *Frame) motionProgress() float64 {
+	if f.totalFrames <= 1 {
+		return 0
+	}
+	return float64(f.frameIndex) / float64(f.totalFrames-1)
+}
@@ -0,0 +1,67 @@
+package renderer
+
+import (
+	"image"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// motionSource returns a motion-sized background with a distinct colour in
+// every pixel.
+func motionSource() *image.RGBA {
+	w, h := kenBurnsSize()
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			i := src.PixOffset(x, y)
+			src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3] = uint8(x), uint8(y), uint8(x/256+y/256*8), 255
+		}
+	}
+	return src
+}
+
+// TestKenBurnsView verifies the view starts on the whole source and ends on
+// a frame-sized part right of centre, staying inside the source throughout.
+func TestKenBurnsView(t *testing.T) {
+	k := newKenBurns(motionSource())
+	sw, sh := kenBurnsSize()
+	if x0, y0, width := k.view(0); x0 != 0 || y0 != 0 || width != float64(sw) {
+		t.Errorf("view(0) = %v, %v, %v; want the whole source", x0, y0, width)
+	}
+	x0, y0, width := k.view(1)
+	if width != config.Width || y0 != float64(sh-config.Height)/2 || x0 <= float64(sw-config.Width)/2 {
+		t.Errorf("view(1) = %v, %v, %v; want a frame-sized part right of centre", x0, y0, width)
+	}
+	for p := 0.0; p <= 1; p += 0.05 {
+		x0, y0, width := k.view(p)
+		height := width * config.Height / config.Width
+		if x0 < 0 || y0 < 0 || x0+width > float64(sw) || y0+height > float64(sh) {
+			t.Errorf("view(%.2f) = %v, %v, %v leaves the source", p, x0, y0, width)
+		}
+	}
+}
+
+// TestKenBurnsDraw verifies the end of the motion copies its part of the
+// source pixel for pixel, and a frame drawn with motion is dirty throughout.
+func TestKenBurnsDraw(t *testing.T) {
+	src := motionSource()
+	f := NewFrame(src, nil, PodcastMeta{}, &config.RuntimeConfig{Layout: []config.LayoutWidget{}})
+	f.SetBackgroundMotion(src)
+	f.SetTotalFrames(10)
+	bars := make([]float64, config.NumBars)
+	f.Draw(bars)
+	f.SetFrameIndex(9)
+	f.Draw(bars)
+
+	x0, y0, _ := f.motion.view(1)
+	for _, pt := range []image.Point{{0, 0}, {640, 10}, {config.Width - 1, config.Height - 1}} {
+		want := src.RGBAAt(pt.X+int(x0), pt.Y+int(y0))
+		if got := f.img.RGBAAt(pt.X, pt.Y); got != want {
+			t.Errorf("pixel %v = %v, want source %v", pt, got, want)
+		}
+	}
+	if start, end := f.DirtyRows(); start != 0 || end != config.Height {
+		t.Errorf("dirty rows [%d, %d), want the whole frame", start, end)
+	}
+}
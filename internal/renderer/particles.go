@@ -0,0 +1,149 @@
+package renderer
+
+import (
+	"math/rand/v2"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// Particles (--particles) are embers rising from the bar tips: more spawn,
+// and faster, as the audio gets louder, and each cools from yellow to red
+// as it fades. The field is simulated a frame at a time. Spawns are drawn
+// from a generator seeded with the frame index, and each ember wanders by a
+// hash of its own seed and the frame, so the same episode always throws the
+// same embers, and a segment of a split render matches a whole render once
+// its warm-up has outlasted the embers from before it.
+
+// Particle tuning, in pixels, seconds and particles per frame.
+const (
+	maxParticles     = 1024 // Above what particleRate and particleLifeMax can reach
+	particleRate     = 6.0  // Spawned per frame at full loudness
+	particleLifeMin  = 1.0
+	particleLifeMax  = 2.5
+	particleSpeedMin = 80.0  // Upward speed at silence
+	particleSpeedMax = 300.0 // Added at full loudness
+	particleDrift    = 30.0  // Largest sideways speed at spawn
+	particleJitter   = 120.0 // Sideways wander, per second
+	particleBuoyancy = 40.0  // Upward acceleration
+	particleDrag     = 0.98  // Velocity kept each frame
+	particleSize     = 3
+	particleSeed     = 0x6a69766566697265 // "jivefire"
+)
+
+// emberColors are an ember's colour from its spawn to the end of its life.
+var emberColors = [...][3]float64{
+	{255, 214, 90},
+	{245, 120, 30},
+	{160, 30, 10},
+}
+
+type particle struct {
+	x, y, vx, vy float64
+	life, span   float64 // Seconds left, and at spawn
+	seed         uint64  // Drives its wander
+}
+
+// particleField is the embers on screen.
+type particleField struct {
+	particles []particle
+	pcg       *rand.PCG
+	rng       *rand.Rand
+	drawn     rowSpan // Rows the last draw painted
+}
+
+func newParticleField() *particleField {
+	pcg := rand.NewPCG(particleSeed, 0)
+	return &particleField{
+		particles: make([]particle, 0, maxParticles),
+		pcg:       pcg,
+		rng:       rand.New(pcg),
+	}
+}
+
+// step advances the field a frame: frame is the frame's index in the
+// episode, energy its loudness (0-1) and barHeights the displayed bars in
+// pixels, left to right.
+func (p *particleField) step(frame int, energy float64, barHeights []float64) {
+	index := uint64(frame) //nolint:gosec // frame indices are never negative
+	p.pcg.Seed(particleSeed, index)
+	const dt = 1.0 / config.FPS
+
+	kept := p.particles[:0]
+	for _, pt := range p.particles {
+		pt.life -= dt
+		wander := float64(splitmix64(pt.seed+index)>>11)/(1<<53)*2 - 1
+		pt.vx = (pt.vx + wander*particleJitter*dt) * particleDrag
+		pt.vy = (pt.vy - particleBuoyancy*dt) * particleDrag
+		pt.x += pt.vx * dt
+		pt.y += pt.vy * dt
+		if pt.life > 0 && pt.y > -particleSize && pt.x > -particleSize && pt.x < config.Width {
+			kept = append(kept, pt)
+		}
+	}
+	p.particles = kept
+
+	// The fraction of a particle left over spawns one by chance.
+	energy = max(0, min(energy, 1))
+	spawn := energy * particleRate
+	count := int(spawn)
+	if p.rng.Float64() < spawn-float64(count) {
+		count++
+	}
+	maxHeight := config.Height/2 - config.CenterGap/2
+	for k := range min(count, maxParticles-len(p.particles)) {
+		bar := p.rng.IntN(len(barHeights))
+		h := min(int(barHeights[bar]), maxHeight)
+		if h <= 0 {
+			continue
+		}
+		span := particleLifeMin + p.rng.Float64()*(particleLifeMax-particleLifeMin)
+		p.particles = append(p.particles, particle{
+			x:    float64((config.Width-barsWidth())/2+bar*(config.BarWidth+config.BarGap)) + p.rng.Float64()*config.BarWidth,
+			y:    float64(config.Height/2 - config.CenterGap/2 - h),
+			vx:   (p.rng.Float64()*2 - 1) * particleDrift,
+			vy:   -(particleSpeedMin + p.rng.Float64()*particleSpeedMax*energy),
+			life: span,
+			span: span,
+			seed: index<<8 | uint64(k), //nolint:gosec // k is at most particleRate
+		})
+	}
+}
+
+// draw adds the embers' light onto img.
+func (p *particleField) draw(f *Frame) {
+	img := f.img
+	p.drawn = rowSpan{}
+	for _, pt := range p.particles {
+		age := 1 - pt.life/pt.span
+		col := emberColor(age)
+		alpha := pt.life / pt.span
+		x0, y0 := int(pt.x), int(pt.y)
+		for y := max(y0, 0); y < min(y0+particleSize, config.Height); y++ {
+			row := img.Pix[y*img.Stride:]
+			for x := max(x0, 0); x < min(x0+particleSize, config.Width); x++ {
+				i := x * 4
+				for c := range 3 {
+					row[i+c] = uint8(min(float64(row[i+c])+col[c]*alpha, 255))
+				}
+			}
+		}
+		p.drawn = p.drawn.union(newRowSpan(y0, y0+particleSize))
+	}
+}
+
+// splitmix64 returns a well-mixed hash of x.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// emberColor returns the colour of an ember age (0-1) through its life.
+func emberColor(age float64) [3]float64 {
+	pos := max(0, min(age, 1)) * float64(len(emberColors)-1)
+	i := min(int(pos), len(emberColors)-2)
+	t := pos - float64(i)
+	a, b := emberColors[i], emberColors[i+1]
+	return [3]float64{a[0] + (b[0]-a[0])*t, a[1] + (b[1]-a[1])*t, a[2] + (b[2]-a[2])*t}
+}
@@ -0,0 +1,78 @@
+package renderer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// TestParticlesFollowLoudness verifies silence spawns no embers, loud audio
+// spawns them at the bar tips, and they rise.
+func TestParticlesFollowLoudness(t *testing.T) {
+	bars := make([]float64, config.NumBars)
+	for i := range bars {
+		bars[i] = 100
+	}
+	p := newParticleField()
+	for frame := range config.FPS {
+		p.step(frame, 0, bars)
+	}
+	if len(p.particles) != 0 {
+		t.Fatalf("%d embers after a second of silence, want none", len(p.particles))
+	}
+
+	p.step(config.FPS, 1, bars)
+	if len(p.particles) != particleRate {
+		t.Fatalf("%d embers spawned at full loudness, want %v", len(p.particles), particleRate)
+	}
+	tip := float64(config.Height/2 - config.CenterGap/2 - 100)
+	for _, pt := range p.particles {
+		if pt.y != tip {
+			t.Errorf("ember spawned at y %v, want the bar tip at %v", pt.y, tip)
+		}
+	}
+	p.step(config.FPS+1, 0, bars)
+	for _, pt := range p.particles {
+		if pt.y >= tip {
+			t.Errorf("ember at y %v a frame later, want it risen above %v", pt.y, tip)
+		}
+	}
+}
+
+// TestParticlesRejoin verifies a field started partway through, as a
+// segment of a split render is, throws exactly the embers of one run from
+// the start once the embers from before it have died.
+func TestParticlesRejoin(t *testing.T) {
+	bars := make([]float64, config.NumBars)
+	whole, segment := newParticleField(), newParticleField()
+	start := 3 * config.FPS
+	end := start + int(particleLifeMax*config.FPS) + 1
+	for frame := range end {
+		for i := range bars {
+			bars[i] = float64((frame*7 + i*13) % 200)
+		}
+		energy := float64(frame%10) / 10
+		whole.step(frame, energy, bars)
+		if frame >= start {
+			segment.step(frame, energy, bars)
+		}
+	}
+	if len(whole.particles) == 0 || !slices.Equal(whole.particles, segment.particles) {
+		t.Errorf("segment has %d embers, whole render %d; want the same", len(segment.particles), len(whole.particles))
+	}
+}
+
+// TestParticlesDraw verifies embers brighten the frame where they are and
+// report those rows as drawn.
+func TestParticlesDraw(t *testing.T) {
+	f := NewFrame(nil, nil, PodcastMeta{}, &config.RuntimeConfig{Particles: true, Layout: []config.LayoutWidget{{Widget: config.WidgetParticles}}})
+	f.particles.particles = append(f.particles.particles, particle{x: 100, y: 50, life: 1, span: 1})
+	f.particles.draw(f)
+	if c := f.img.RGBAAt(101, 51); c.R != 255 || c.G != 214 {
+		t.Errorf("ember pixel = %v, want a fresh yellow ember", c)
+	}
+	if rows := f.particles.drawn; rows != (rowSpan{50, 50 + particleSize}) {
+		t.Errorf("drawn rows = %v, want the ember's", rows)
+	}
+}
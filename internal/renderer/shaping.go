@@ -0,0 +1,364 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"unicode"
+
+	"github.com/go-text/typesetting/di"
+	gotext "github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/shaping"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// fontChain is a font followed by its fallbacks: each character is drawn in
+// the first font with a glyph for it. It implements shaping.Fontmap.
+type fontChain []*gotext.Face
+
+// loadFontChain parses the font at path (custom or the embedded asset), then
+// the fallback fonts and, after a custom font, the embedded asset, so text a
+// script font lacks, such as Latin episode names, still has glyphs.
+func loadFontChain(path string, isCustom bool, asset string, fallbacks []string) (fontChain, error) {
+	var chain fontChain
+	add := func(path string, isCustom bool) error {
+		data, err := loadFontData(path, isCustom)
+		if err != nil {
+			return err
+		}
+		face, err := gotext.ParseTTF(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		chain = append(chain, face)
+		return nil
+	}
+
+	if err := add(path, isCustom); err != nil {
+		return nil, err
+	}
+	for _, fallback := range fallbacks {
+		if err := add(fallback, true); err != nil {
+			return nil, err
+		}
+	}
+	if isCustom {
+		if err := add(asset, false); err != nil {
+			return nil, err
+		}
+	}
+	return chain, nil
+}
+
+// titleFontChain loads the title font chain.
+func titleFontChain(runtimeConfig *config.RuntimeConfig) (fontChain, error) {
+	path, isCustom := runtimeConfig.GetTitleFontPath()
+	return loadFontChain(path, isCustom, config.VideoTitleFontAsset, runtimeConfig.FontFallbacks)
+}
+
+// thumbnailFontChain loads the thumbnail font chain.
+func thumbnailFontChain(runtimeConfig *config.RuntimeConfig) (fontChain, error) {
+	path, isCustom := runtimeConfig.GetThumbnailFontPath()
+	return loadFontChain(path, isCustom, config.ThumbnailFontAsset, runtimeConfig.FontFallbacks)
+}
+
+// glyph returns the first font in the chain with a glyph for r, and the glyph.
+func (c fontChain) glyph(r rune) (*gotext.Face, gotext.GID, bool) {
+	for _, face := range c {
+		if gid, ok := face.NominalGlyph(r); ok {
+			return face, gid, true
+		}
+	}
+	return nil, 0, false
+}
+
+// ResolveFace returns the first font with a glyph for r, or the first font
+// when none has one, so the character shows as its missing-glyph box.
+func (c fontChain) ResolveFace(r rune) *gotext.Face {
+	if face, _, ok := c.glyph(r); ok {
+		return face
+	}
+	return c[0]
+}
+
+// shapedFace is title text at one size over a font chain. Whole strings are
+// shaped with HarfBuzz, so complex scripts join and reorder, right-to-left
+// runs read in the right order and missing characters fall back along the
+// chain; measureTextBounds and drawString use that path. The font.Face
+// methods, which see one rune at a time, serve callers with a font.Drawer.
+// Like the truetype faces it is not safe for concurrent use.
+type shapedFace struct {
+	chain  fontChain
+	size   fixed.Int26_6
+	shaper shaping.HarfbuzzShaper
+	seg    shaping.Segmenter
+	raster vector.Rasterizer
+}
+
+// newShapedFace returns chain at size pixels.
+func newShapedFace(chain fontChain, size float64) *shapedFace {
+	return &shapedFace{chain: chain, size: fixed.Int26_6(math.Round(size * 64))}
+}
+
+// scale converts face's font units to pixels at this size.
+func (f *shapedFace) scale(face *gotext.Face) float32 {
+	return float32(f.size) / 64 / float32(face.Upem())
+}
+
+// layout shapes text into runs in visual order, left to right.
+func (f *shapedFace) layout(text string) []shaping.Output {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return nil
+	}
+	dir := di.DirectionLTR
+	if rightToLeft(runes) {
+		dir = di.DirectionRTL
+	}
+	input := shaping.Input{Text: runes, RunEnd: len(runes), Direction: dir, Size: f.size}
+
+	var runs []shaping.Output
+	for _, run := range f.seg.Split(input, f.chain) {
+		runs = append(runs, f.shaper.Shape(run))
+	}
+	visualOrder(runs, dir)
+	return runs
+}
+
+// rightToLeft reports whether the first strongly directional character in
+// runes, which sets the paragraph direction, is from a right-to-left script.
+func rightToLeft(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko) {
+			return true
+		}
+		if unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return false
+}
+
+// visualOrder sorts runs, in logical order, into visual order the way
+// go-text's line wrapper does: each stretch against the paragraph direction
+// is reversed, then a right-to-left paragraph is reversed as a whole.
+func visualOrder(runs []shaping.Output, dir di.Direction) {
+	start := -1
+	for i := 0; i <= len(runs); i++ {
+		if i < len(runs) && runs[i].Direction.Progression() != dir.Progression() {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			slices.Reverse(runs[start:i])
+			start = -1
+		}
+	}
+	if dir.Progression() == di.TowardTopLeft {
+		slices.Reverse(runs)
+	}
+}
+
+// bounds returns the ink bounds of text relative to the dot, and its
+// advance, as font.Drawer.BoundString does.
+func (f *shapedFace) bounds(text string) (fixed.Rectangle26_6, fixed.Int26_6) {
+	var bounds fixed.Rectangle26_6
+	var dot fixed.Int26_6
+	for _, run := range f.layout(text) {
+		for _, g := range run.Glyphs {
+			if g.Width != 0 && g.Height != 0 {
+				x := dot + g.XOffset + g.XBearing
+				y := -(g.YOffset + g.YBearing)
+				bounds = bounds.Union(fixed.Rectangle26_6{
+					Min: fixed.Point26_6{X: x, Y: y},
+					Max: fixed.Point26_6{X: x + g.Width, Y: y - g.Height},
+				})
+			}
+			dot += g.Advance
+		}
+	}
+	return bounds, dot
+}
+
+// draw draws text in col with the dot, on the baseline at the left end of
+// the text, at dot. Outline glyphs are filled with col; bitmap colour glyphs,
+// such as emoji, are drawn in their own colours. Vector colour glyphs (COLR
+// and SVG) fall back to their outline.
+func (f *shapedFace) draw(dst *image.RGBA, text string, dot fixed.Point26_6, col color.RGBA) {
+	src := image.NewUniform(col)
+	for _, run := range f.layout(text) {
+		scale := f.scale(run.Face)
+		for _, g := range run.Glyphs {
+			origin := fixed.Point26_6{X: dot.X + g.XOffset, Y: dot.Y - g.YOffset}
+			switch data := run.Face.GlyphData(g.GlyphID).(type) {
+			case gotext.GlyphOutline:
+				f.fill(dst, data, origin, scale, src)
+			case gotext.GlyphBitmap:
+				if !drawBitmap(dst, data, g, origin) && data.Outline != nil {
+					f.fill(dst, *data.Outline, origin, scale, src)
+				}
+			case gotext.GlyphSVG:
+				f.fill(dst, data.Outline, origin, scale, src)
+			case gotext.GlyphColor:
+				if outline, ok := run.Face.GlyphDataOutline(g.GlyphID); ok {
+					f.fill(dst, outline, origin, scale, src)
+				}
+			}
+			dot.X += g.Advance
+		}
+	}
+}
+
+// fill rasterises outline, in font units scaled by scale, with its origin at
+// origin, through src onto dst.
+func (f *shapedFace) fill(dst draw.Image, outline gotext.GlyphOutline, origin fixed.Point26_6, scale float32, src image.Image) {
+	if len(outline.Segments) == 0 {
+		return
+	}
+	minX, minY := float32(math.Inf(1)), float32(math.Inf(1))
+	maxX, maxY := float32(math.Inf(-1)), float32(math.Inf(-1))
+	for i := range outline.Segments {
+		for _, p := range outline.Segments[i].ArgsSlice() {
+			minX, maxX = min(minX, p.X), max(maxX, p.X)
+			minY, maxY = min(minY, p.Y), max(maxY, p.Y)
+		}
+	}
+	ox, oy := float32(origin.X)/64, float32(origin.Y)/64
+	rect := image.Rect(
+		int(math.Floor(float64(ox+minX*scale))), int(math.Floor(float64(oy-maxY*scale))),
+		int(math.Ceil(float64(ox+maxX*scale))), int(math.Ceil(float64(oy-minY*scale))),
+	).Intersect(dst.Bounds())
+	if rect.Empty() {
+		return
+	}
+
+	// Font units are y-up; the rasteriser's origin is rect's top-left.
+	x0, y0 := ox-float32(rect.Min.X), oy-float32(rect.Min.Y)
+	px := func(p ot.SegmentPoint) (float32, float32) {
+		return x0 + p.X*scale, y0 - p.Y*scale
+	}
+	f.raster.Reset(rect.Dx(), rect.Dy())
+	f.raster.DrawOp = draw.Over
+	for _, s := range outline.Segments {
+		switch s.Op {
+		case ot.SegmentOpMoveTo:
+			f.raster.MoveTo(px(s.Args[0]))
+		case ot.SegmentOpLineTo:
+			f.raster.LineTo(px(s.Args[0]))
+		case ot.SegmentOpQuadTo:
+			x1, y1 := px(s.Args[0])
+			x2, y2 := px(s.Args[1])
+			f.raster.QuadTo(x1, y1, x2, y2)
+		case ot.SegmentOpCubeTo:
+			x1, y1 := px(s.Args[0])
+			x2, y2 := px(s.Args[1])
+			x3, y3 := px(s.Args[2])
+			f.raster.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	f.raster.ClosePath()
+	f.raster.Draw(dst, rect, src, image.Point{})
+}
+
+// drawBitmap draws a PNG or JPEG glyph, as colour emoji fonts hold, scaled
+// to the glyph's box at origin. It reports false for other formats.
+func drawBitmap(dst *image.RGBA, bitmap gotext.GlyphBitmap, g shaping.Glyph, origin fixed.Point26_6) bool {
+	if bitmap.Format != gotext.PNG && bitmap.Format != gotext.JPG {
+		return false
+	}
+	img, _, err := image.Decode(bytes.NewReader(bitmap.Data))
+	if err != nil {
+		return false
+	}
+	x, y := origin.X+g.XBearing, origin.Y-g.YBearing
+	rect := image.Rect(x.Round(), y.Round(), (x + g.Width).Round(), (y - g.Height).Round())
+	draw.BiLinear.Scale(dst, rect, img, img.Bounds(), draw.Over, nil)
+	return true
+}
+
+// Close satisfies the font.Face interface.
+func (f *shapedFace) Close() error { return nil }
+
+// Metrics satisfies the font.Face interface with the first font's metrics.
+// Like the truetype faces, Height is the font size.
+func (f *shapedFace) Metrics() font.Metrics {
+	face := f.chain[0]
+	extents, _ := face.FontHExtents()
+	scale := float64(f.scale(face)) * 64
+	return font.Metrics{
+		Height:  f.size,
+		Ascent:  fixed.Int26_6(math.Ceil(scale * float64(extents.Ascender))),
+		Descent: fixed.Int26_6(math.Ceil(scale * float64(-extents.Descender))),
+	}
+}
+
+// Kern satisfies the font.Face interface. Kerning between whole strings comes
+// from shaping, so pairs of single runes are not kerned.
+func (f *shapedFace) Kern(r0, r1 rune) fixed.Int26_6 { return 0 }
+
+// GlyphBounds satisfies the font.Face interface, using the first font in the
+// chain with a glyph for r.
+func (f *shapedFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	face, gid, ok := f.chain.glyph(r)
+	if !ok {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	bounds, advance := f.glyphBounds(face, gid)
+	return bounds, advance, true
+}
+
+// GlyphAdvance satisfies the font.Face interface.
+func (f *shapedFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	_, advance, ok := f.GlyphBounds(r)
+	return advance, ok
+}
+
+// Glyph satisfies the font.Face interface, rasterising the outline of the
+// first font in the chain with a glyph for r into a mask.
+func (f *shapedFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	face, gid, ok := f.chain.glyph(r)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	bounds, advance := f.glyphBounds(face, gid)
+	dr := image.Rect(
+		(dot.X + bounds.Min.X).Floor(), (dot.Y + bounds.Min.Y).Floor(),
+		(dot.X + bounds.Max.X).Ceil(), (dot.Y + bounds.Max.Y).Ceil(),
+	)
+	mask := image.NewAlpha(dr)
+	if outline, ok := face.GlyphDataOutline(gid); ok {
+		f.fill(mask, outline, dot, f.scale(face), image.Opaque)
+	}
+	return dr, mask, dr.Min, advance, true
+}
+
+// glyphBounds returns the bounds, relative to the dot, and advance of glyph
+// gid in face.
+func (f *shapedFace) glyphBounds(face *gotext.Face, gid gotext.GID) (fixed.Rectangle26_6, fixed.Int26_6) {
+	scale := f.scale(face) * 64
+	advance := fixed.Int26_6(math.Round(float64(face.HorizontalAdvance(gid) * scale)))
+	extents, ok := face.GlyphExtents(gid)
+	if !ok {
+		return fixed.Rectangle26_6{}, advance
+	}
+	return fixed.Rectangle26_6{
+		Min: fixed.Point26_6{
+			X: fixed.Int26_6(math.Floor(float64(extents.XBearing * scale))),
+			Y: fixed.Int26_6(math.Floor(float64(-extents.YBearing * scale))),
+		},
+		Max: fixed.Point26_6{
+			X: fixed.Int26_6(math.Ceil(float64((extents.XBearing + extents.Width) * scale))),
+			Y: fixed.Int26_6(math.Ceil(float64(-(extents.YBearing + extents.Height) * scale))),
+		},
+	}, advance
+}
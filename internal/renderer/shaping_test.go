@@ -0,0 +1,136 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+)
+
+// goFontChain returns the Go Regular font, which has no Devanagari, chained
+// before the embedded Poppins, which has.
+func goFontChain(t *testing.T) fontChain {
+	t.Helper()
+	chain, err := loadFontChain(goFontPath(t), true, config.VideoTitleFontAsset, nil)
+	if err != nil {
+		t.Fatalf("loadFontChain() error = %v", err)
+	}
+	return chain
+}
+
+// TestShapedFaceReordersVowelSign verifies Devanagari is shaped: the vowel
+// sign ि follows its consonant in the text but is drawn before it, leaving
+// the consonant second.
+func TestShapedFaceReordersVowelSign(t *testing.T) {
+	chain, err := titleFontChain(&config.RuntimeConfig{})
+	if err != nil {
+		t.Fatalf("titleFontChain() error = %v", err)
+	}
+	runs := newShapedFace(chain, 48).layout("कि")
+	if len(runs) != 1 || len(runs[0].Glyphs) != 2 {
+		t.Fatalf("layout(कि) = %d runs, want 1 run of 2 glyphs", len(runs))
+	}
+	ka, _ := chain[0].NominalGlyph('क')
+	if got := runs[0].Glyphs[1].GlyphID; got != ka {
+		t.Errorf("second glyph = %d, want the consonant %d", got, ka)
+	}
+}
+
+// TestFontChainFallback verifies characters the first font lacks are shaped
+// in the next font with them, and only characters no font has are missing.
+func TestFontChainFallback(t *testing.T) {
+	chain := goFontChain(t)
+	runs := newShapedFace(chain, 48).layout("Go कि")
+	if len(runs) != 2 {
+		t.Fatalf("layout() = %d runs, want 2", len(runs))
+	}
+	if runs[0].Face != chain[0] || runs[1].Face != chain[1] {
+		t.Error("Latin and Devanagari runs not shaped in the Go and Poppins fonts respectively")
+	}
+
+	missing, err := MissingGlyphs("Go कि 日本", &config.RuntimeConfig{FontPath: goFontPath(t)})
+	if err != nil {
+		t.Fatalf("MissingGlyphs() error = %v", err)
+	}
+	if string(missing) != "日本" {
+		t.Errorf("MissingGlyphs() = %q, want %q", string(missing), "日本")
+	}
+}
+
+// goFontPath writes the Go Regular font to a temporary file for --font.
+func goFontPath(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "Go-Regular.ttf")
+	if err := os.WriteFile(path, goregular.TTF, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestVisualOrder verifies mixed-direction text is laid out in visual order,
+// with the paragraph direction taken from the first letter.
+func TestVisualOrder(t *testing.T) {
+	chain, err := titleFontChain(&config.RuntimeConfig{})
+	if err != nil {
+		t.Fatalf("titleFontChain() error = %v", err)
+	}
+	face := newShapedFace(chain, 48)
+
+	tests := []struct {
+		text string
+		want []int // Offset of each run's first rune, left to right
+	}{
+		{"Linux Matters", []int{0}},
+		{"abc שלום", []int{0, 4}},
+		{"שלום abc", []int{5, 0}},
+		{"שלום abc ×", []int{8, 5, 0}},
+	}
+	for _, tt := range tests {
+		runs := face.layout(tt.text)
+		var got []int
+		for _, run := range runs {
+			got = append(got, run.Runes.Offset)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("layout(%q) run offsets = %v, want %v", tt.text, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("layout(%q) run offsets = %v, want %v", tt.text, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// TestShapedFaceBounds verifies the measured bounds enclose the drawn
+// pixels to within a pixel, so text is placed where it is measured.
+func TestShapedFaceBounds(t *testing.T) {
+	face := newShapedFace(goFontChain(t), 48)
+	const text = "Linux Matters कि"
+	bounds, advance := face.bounds(text)
+	if advance <= bounds.Max.X-bounds.Min.X-fixed.I(2) {
+		t.Errorf("advance %v narrower than the ink bounds %v", advance, bounds)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 600, 100))
+	drawString(img, face, text, 20, 70, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	var ink image.Rectangle
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			if img.RGBAAt(x, y).A > 0 {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	want := image.Rect(20+bounds.Min.X.Floor(), 70+bounds.Min.Y.Floor(), 20+bounds.Max.X.Ceil(), 70+bounds.Max.Y.Ceil())
+	if ink.Empty() || !ink.In(want.Inset(-1)) || want.Dx()-ink.Dx() > 2 || want.Dy()-ink.Dy() > 2 {
+		t.Errorf("drawn ink %v, measured bounds %v", ink, want)
+	}
+}
@@ -0,0 +1,129 @@
+package renderer
+
+import (
+	"image"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+func init() {
+	RegisterVisualizer(SpectrogramVisualizer, func(runtimeConfig *config.RuntimeConfig) (Visualizer, error) {
+		return newSpectrogramVisualizer(runtimeConfig), nil
+	})
+}
+
+// SpectrogramVisualizer is the built-in scrolling spectrogram, selected with
+// --visualizer=spectrogram.
+const SpectrogramVisualizer = "spectrogram"
+
+// spectrogramColumn is the width in pixels each frame adds, which sets how
+// much history fits across the frame: 640 columns, about 21s.
+const spectrogramColumn = 2
+
+// SpectrumVisualizer is a Visualizer that draws the full spectrum rather
+// than the bars. Spectrum is called before each Process with the frame's
+// column, low frequency first, each level 0-1 on a dB scale; the column may
+// be any length.
+type SpectrumVisualizer interface {
+	Visualizer
+	Spectrum(column []float64)
+}
+
+// colormapStops are the evenly spaced colours each colormap blends between.
+var colormapStops = map[config.Colormap][][3]uint8{
+	config.ColormapMagma:   {{0, 0, 4}, {59, 15, 112}, {140, 41, 129}, {222, 73, 104}, {254, 159, 109}, {252, 253, 191}},
+	config.ColormapViridis: {{68, 1, 84}, {59, 82, 139}, {33, 145, 140}, {94, 201, 98}, {253, 231, 37}},
+	config.ColormapFire:    {{0, 0, 0}, {128, 0, 0}, {220, 50, 0}, {248, 179, 29}, {255, 255, 255}},
+	config.ColormapGrey:    {{0, 0, 0}, {255, 255, 255}},
+}
+
+// spectrogramVisualizer keeps the spectrogram as a texture of the rows it
+// covers. Each frame shifts the texture left by a column and paints the new
+// column at the right edge, high frequencies at the top, so drawing is a copy
+// into the frame. The texture is opaque and covers the background where it
+// lies, the same rows the bars can reach.
+type spectrogramVisualizer struct {
+	top, rows int
+	texture   []byte        // rows of config.Width RGBA pixels
+	palette   [256][3]uint8 // colormap by level
+	column    []float64     // the column for the next Process, from Spectrum
+	fed       bool          // Spectrum was called for the frame being processed
+}
+
+func newSpectrogramVisualizer(runtimeConfig *config.RuntimeConfig) *spectrogramVisualizer {
+	maxHeight := config.Height/2 - config.CenterGap/2
+	s := &spectrogramVisualizer{
+		top:  config.Height/2 - maxHeight,
+		rows: 2 * maxHeight,
+	}
+	stops, ok := colormapStops[runtimeConfig.Colormap]
+	if !ok {
+		stops = colormapStops[config.ColormapMagma]
+	}
+	for i := range s.palette {
+		pos := float64(i) / 255 * float64(len(stops)-1)
+		lo := min(int(pos), len(stops)-2)
+		frac := pos - float64(lo)
+		for c := range 3 {
+			a, b := float64(stops[lo][c]), float64(stops[lo+1][c])
+			s.palette[i][c] = uint8(a + (b-a)*frac + 0.5)
+		}
+	}
+	s.texture = make([]byte, s.rows*config.Width*4)
+	silence := s.palette[0]
+	for i := 0; i < len(s.texture); i += 4 {
+		s.texture[i], s.texture[i+1], s.texture[i+2], s.texture[i+3] = silence[0], silence[1], silence[2], 255
+	}
+	return s
+}
+
+// Spectrum records the frame's column for the next Process.
+func (s *spectrogramVisualizer) Spectrum(column []float64) {
+	s.column = append(s.column[:0], column...)
+	s.fed = true
+}
+
+// Process scrolls the texture and paints the frame's column. Without a
+// Spectrum call, as in renders that only have bars (jivefire idle and
+// bench), the bars stand in for the spectrum: the right half of the
+// centre-out heights runs from bass to treble.
+func (s *spectrogramVisualizer) Process(barHeights []float64, _ time.Duration) {
+	if !s.fed {
+		maxHeight := float64(s.rows / 2)
+		s.column = s.column[:0]
+		for _, h := range barHeights[len(barHeights)/2:] {
+			s.column = append(s.column, min(max(h/maxHeight, 0), 1))
+		}
+	}
+	s.fed = false
+
+	stride := config.Width * 4
+	right := (config.Width - spectrogramColumn) * 4
+	for y := range s.rows {
+		line := s.texture[y*stride : (y+1)*stride]
+		copy(line, line[spectrogramColumn*4:])
+		colour := s.palette[0]
+		if len(s.column) > 0 {
+			level := s.column[(s.rows-1-y)*len(s.column)/s.rows]
+			colour = s.palette[int(min(max(level, 0), 1)*255+0.5)]
+		}
+		for x := right; x < stride; x += 4 {
+			line[x], line[x+1], line[x+2] = colour[0], colour[1], colour[2]
+		}
+	}
+}
+
+// Draw copies the texture into the frame.
+func (s *spectrogramVisualizer) Draw(img *image.RGBA) {
+	stride := config.Width * 4
+	for y := range s.rows {
+		offset := (s.top + y) * img.Stride
+		copy(img.Pix[offset:offset+stride], s.texture[y*stride:(y+1)*stride])
+	}
+}
+
+// DrawnRows reports the rows the texture covers, all of which scroll.
+func (s *spectrogramVisualizer) DrawnRows() (startY, endY int) {
+	return s.top, s.top + s.rows
+}
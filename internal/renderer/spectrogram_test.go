@@ -0,0 +1,53 @@
+package renderer
+
+import (
+	"image"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// TestSpectrogramScrolls verifies each column is painted at the right edge
+// with low frequencies at the bottom, and moves left a column per frame.
+func TestSpectrogramScrolls(t *testing.T) {
+	vis := newSpectrogramVisualizer(&config.RuntimeConfig{Colormap: config.ColormapGrey})
+	vis.Spectrum([]float64{1, 0}) // Loud bass, silent treble
+	vis.Process(nil, 0)
+	vis.Spectrum([]float64{0, 0})
+	vis.Process(nil, 0)
+	img := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	vis.Draw(img)
+
+	bottom := vis.top + vis.rows - 1
+	older := config.Width - spectrogramColumn - 1
+	for _, tt := range []struct {
+		name string
+		x, y int
+		want uint8
+	}{
+		{"older bass", older, bottom, 255},
+		{"older treble", older, vis.top, 0},
+		{"newest bass", config.Width - 1, bottom, 0},
+	} {
+		if got := img.RGBAAt(tt.x, tt.y); got.R != tt.want || got.A != 255 {
+			t.Errorf("%s = %v, want grey %d", tt.name, got, tt.want)
+		}
+	}
+	if startY, endY := vis.DrawnRows(); startY != vis.top || endY != vis.top+vis.rows {
+		t.Errorf("DrawnRows() = %d, %d; want %d, %d", startY, endY, vis.top, vis.top+vis.rows)
+	}
+}
+
+// TestSpectrogramColormap verifies the palettes run from their first stop to
+// their last, and an unknown colormap falls back to magma.
+func TestSpectrogramColormap(t *testing.T) {
+	for colormap, stops := range colormapStops {
+		vis := newSpectrogramVisualizer(&config.RuntimeConfig{Colormap: colormap})
+		if vis.palette[0] != stops[0] || vis.palette[255] != stops[len(stops)-1] {
+			t.Errorf("%s palette runs %v to %v, want %v to %v", colormap, vis.palette[0], vis.palette[255], stops[0], stops[len(stops)-1])
+		}
+	}
+	if vis := newSpectrogramVisualizer(&config.RuntimeConfig{}); vis.palette[255] != colormapStops[config.ColormapMagma][5] {
+		t.Errorf("default palette ends at %v, want magma", vis.palette[255])
+	}
+}
@@ -3,7 +3,9 @@ package renderer
 import (
 	"image"
 	"image/color"
+	"strings"
 
+	"github.com/linuxmatters/jivefire/internal/config"
 	"golang.org/x/image/font"
 	"golang.org/x/image/math/fixed"
 )
@@ -17,17 +19,170 @@ func newTextDrawer(img *image.RGBA, face font.Face, col color.RGBA) *font.Drawer
 	}
 }
 
-// measureStringWidth returns the pixel width of text as rendered by the drawer.
-func measureStringWidth(d *font.Drawer, text string) int {
-	bounds, _ := d.BoundString(text)
-	return (bounds.Max.X - bounds.Min.X).Ceil()
-}
-
 // measureTextBounds returns the pixel width and full bounds of text as rendered by the given face.
 // Used when callers need both width and the vertical extent (ascent/descent).
 func measureTextBounds(face font.Face, text string) (int, fixed.Rectangle26_6) {
-	d := &font.Drawer{Face: face}
-	bounds, _ := d.BoundString(text)
+	var bounds fixed.Rectangle26_6
+	if sf, ok := face.(*shapedFace); ok {
+		bounds, _ = sf.bounds(text)
+	} else {
+		d := &font.Drawer{Face: face}
+		bounds, _ = d.BoundString(text)
+	}
 	width := (bounds.Max.X - bounds.Min.X).Ceil()
 	return width, bounds
 }
+
+// drawString draws text with face in col, with the dot on the baseline at
+// (x, y). A shaped face lays the whole string out; others draw it glyph by
+// glyph.
+func drawString(img *image.RGBA, face font.Face, text string, x, y int, col color.RGBA) {
+	if sf, ok := face.(*shapedFace); ok {
+		sf.draw(img, text, fixed.P(x, y), col)
+		return
+	}
+	d := newTextDrawer(img, face, col)
+	d.Dot = fixed.P(x, y)
+	d.DrawString(text)
+}
+
+// wrapText greedily breaks text into lines no wider than maxWidth pixels when
+// rendered with face. A single word wider than maxWidth gets a line to itself
+// rather than being split mid-word.
+func wrapText(face font.Face, text string, maxWidth int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var lines []string
+	current := words[0]
+	for _, word := range words[1:] {
+		candidate := current + " " + word
+		if width, _ := measureTextBounds(face, candidate); width <= maxWidth {
+			current = candidate
+			continue
+		}
+		lines = append(lines, current)
+		current = word
+	}
+	return append(lines, current)
+}
+
+// textBlockFits reports whether lines rendered with face fit within maxWidth
+// and, stacked at the face's line height, within maxHeight.
+func textBlockFits(face font.Face, lines []string, maxWidth, maxHeight int) bool {
+	if len(lines)*face.Metrics().Height.Ceil() > maxHeight {
+		return false
+	}
+	for _, line := range lines {
+		if width, _ := measureTextBounds(face, line); width > maxWidth {
+			return false
+		}
+	}
+	return true
+}
+
+// truncateLines limits lines to maxLines, folding the overflow into the final
+// line and trimming it with an ellipsis until it fits within maxWidth.
+func truncateLines(face font.Face, lines []string, maxLines, maxWidth int) []string {
+	if len(lines) <= maxLines {
+		return lines
+	}
+
+	kept := append([]string(nil), lines[:maxLines]...)
+	words := strings.Fields(strings.Join(lines[maxLines-1:], " "))
+	for len(words) > 1 {
+		if width, _ := measureTextBounds(face, strings.Join(words, " ")+"…"); width <= maxWidth {
+			break
+		}
+		words = words[:len(words)-1]
+	}
+	kept[maxLines-1] = strings.Join(words, " ") + "…"
+	return kept
+}
+
+// textOverlay is text rasterised once, for text that is the same on every
+// frame. It holds premultiplied RGBA trimmed to the drawn pixels, in frame
+// coordinates, and the runs of drawn pixels along each row, so compositing
+// skips the gaps between glyphs and copies their solid interiors outright.
+type textOverlay struct {
+	img  *image.RGBA
+	runs []overlayRun
+}
+
+// overlayRun is n pixels from (x, y), all opaque or all partly transparent.
+type overlayRun struct {
+	x, y, n int
+	opaque  bool
+}
+
+// newTextOverlay rasterises whatever draw renders onto a transparent frame.
+// It returns nil when nothing was drawn.
+func newTextOverlay(draw func(img *image.RGBA)) *textOverlay {
+	canvas := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	draw(canvas)
+
+	o := &textOverlay{}
+	bounds := image.Rectangle{}
+	for y := range config.Height {
+		row := canvas.Pix[canvas.PixOffset(0, y):][:config.Width*4]
+		for x := 0; x < config.Width; {
+			a := row[x*4+3]
+			if a == 0 {
+				x++
+				continue
+			}
+			run := overlayRun{x: x, y: y, opaque: a == 255}
+			for x < config.Width && row[x*4+3] != 0 && (row[x*4+3] == 255) == run.opaque {
+				x++
+			}
+			run.n = x - run.x
+			o.runs = append(o.runs, run)
+			bounds = bounds.Union(image.Rect(run.x, y, x, y+1))
+		}
+	}
+	if len(o.runs) == 0 {
+		return nil
+	}
+
+	o.img = image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		copy(o.img.Pix[o.img.PixOffset(bounds.Min.X, y):o.img.PixOffset(bounds.Max.X, y)],
+			canvas.Pix[canvas.PixOffset(bounds.Min.X, y):canvas.PixOffset(bounds.Max.X, y)])
+	}
+	return o
+}
+
+// drawOnto composites the overlay over dst, a full frame, at the given
+// opacity (1.0 draws it unmodified).
+func (o *textOverlay) drawOnto(dst *image.RGBA, opacity float64) {
+	scale := uint32(max(0, min(opacity, 1)) * 255)
+	if scale == 0 {
+		return
+	}
+	for _, run := range o.runs {
+		src := o.img.Pix[o.img.PixOffset(run.x, run.y):][:run.n*4]
+		out := dst.Pix[dst.PixOffset(run.x, run.y):][:run.n*4]
+		if run.opaque && scale == 255 {
+			copy(out, src)
+			continue
+		}
+		// Premultiplied over: out = src*s + out*(1 - a*s).
+		for i := 0; i+3 < len(src) && i+3 < len(out); i += 4 {
+			s, d := src[i:i+4:i+4], out[i:i+4:i+4]
+			if scale == 255 {
+				keep := 255 - uint32(s[3])
+				d[0] = s[0] + uint8((uint32(d[0])*keep+127)/255)
+				d[1] = s[1] + uint8((uint32(d[1])*keep+127)/255)
+				d[2] = s[2] + uint8((uint32(d[2])*keep+127)/255)
+				d[3] = s[3] + uint8((uint32(d[3])*keep+127)/255)
+				continue
+			}
+			keep := 255*255 - uint32(s[3])*scale
+			for c := range 4 {
+				d[c] = uint8((uint32(s[c])*scale*255 + uint32(d[c])*keep + 255*255/2) / (255 * 255))
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+	"golang.org/x/image/font/basicfont"
+)
+
+// TestWrapText verifies greedy wrapping against measured widths. basicfont
+// glyphs are a fixed 7px wide, so line widths are easy to reason about.
+func TestWrapText(t *testing.T) {
+	face := basicfont.Face7x13
+
+	tests := []struct {
+		name     string
+		text     string
+		maxWidth int
+		want     []string
+	}{
+		{name: "fits on one line", text: "Linux Matters", maxWidth: 200, want: []string{"Linux Matters"}},
+		{name: "wraps at word boundary", text: "aaa bbb ccc", maxWidth: 7 * 7, want: []string{"aaa bbb", "ccc"}},
+		{name: "long word kept whole", text: "a supercalifragilistic b", maxWidth: 35, want: []string{"a", "supercalifragilistic", "b"}},
+		{name: "empty", text: "   ", maxWidth: 100, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapText(face, tt.text, tt.maxWidth)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("wrapText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTruncateLines verifies overflow lines are folded into the last kept
+// line and trimmed with an ellipsis to fit the width.
+func TestTruncateLines(t *testing.T) {
+	face := basicfont.Face7x13
+	lines := []string{"one two", "three four", "five six"}
+
+	got := truncateLines(face, lines, 2, 7*12)
+	if len(got) != 2 {
+		t.Fatalf("truncateLines() returned %d lines, want 2", len(got))
+	}
+	if got[1] != "three four…" {
+		t.Errorf("last line = %q, want %q", got[1], "three four…")
+	}
+
+	if got := truncateLines(face, lines, 3, 100); len(got) != 3 || got[2] != "five six" {
+		t.Errorf("truncateLines() altered lines within the limit: %q", got)
+	}
+}
+
+// TestLoadTitleFont verifies short titles keep the preferred size while long
+// titles shrink to wrap within the centre gap.
+func TestLoadTitleFont(t *testing.T) {
+	runtimeConfig := &config.RuntimeConfig{}
+	preferred, err := LoadFont(config.TitleFontSize)
+	if err != nil {
+		t.Fatalf("LoadFont() error = %v", err)
+	}
+
+	short, err := LoadTitleFont("Linux Matters", runtimeConfig)
+	if err != nil {
+		t.Fatalf("LoadTitleFont() error = %v", err)
+	}
+	if short.Metrics().Height != preferred.Metrics().Height {
+		t.Errorf("short title font height = %v, want preferred %v", short.Metrics().Height, preferred.Metrics().Height)
+	}
+
+	longTitle := "Frankenstein's Ubuntu Server Framework and the High Precision Solid Metal Balls of Destiny"
+	long, err := LoadTitleFont(longTitle, runtimeConfig)
+	if err != nil {
+		t.Fatalf("LoadTitleFont() error = %v", err)
+	}
+	if long.Metrics().Height >= preferred.Metrics().Height {
+		t.Errorf("long title font height = %v, want smaller than %v", long.Metrics().Height, preferred.Metrics().Height)
+	}
+
+	lines := wrapText(long, longTitle, barsWidth())
+	if len(lines) > config.TitleMaxLines {
+		t.Errorf("long title wrapped to %d lines, want at most %d", len(lines), config.TitleMaxLines)
+	}
+	if !textBlockFits(long, lines, barsWidth(), config.CenterGap-2*config.TitlePadding) {
+		t.Error("long title block does not fit the centre gap")
+	}
+}
+
+// TestMissingGlyphs verifies characters outside the embedded font are
+// reported once each, while Latin text with diacritics is fully covered.
+func TestMissingGlyphs(t *testing.T) {
+	runtimeConfig := &config.RuntimeConfig{}
+
+	missing, err := MissingGlyphs("Café Crème", runtimeConfig)
+	if err != nil {
+		t.Fatalf("MissingGlyphs() error = %v", err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("MissingGlyphs(Latin) = %q, want none", string(missing))
+	}
+
+	missing, err = MissingGlyphs("Linux 日本 日本", runtimeConfig)
+	if err != nil {
+		t.Fatalf("MissingGlyphs() error = %v", err)
+	}
+	if string(missing) != "日本" {
+		t.Errorf("MissingGlyphs(CJK) = %q, want %q", string(missing), "日本")
+	}
+}
+
+// TestTextOverlay verifies compositing the cached overlay matches drawing the
+// text directly, at full opacity and at the half premultiplied colour the
+// pulsing badge used before, up to rounding.
+func TestTextOverlay(t *testing.T) {
+	face, err := LoadTitleFont("Overlay", &config.RuntimeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	textColor := color.RGBA{R: 0xf8, G: 0xb8, B: 0x40, A: 255}
+	draw := func(img *image.RGBA, col color.RGBA) {
+		DrawTextBlock(img, face, []string{"Overlay", "Text"}, config.Height/2, 0, config.Width, config.AlignCentre, col)
+	}
+	overlay := newTextOverlay(func(img *image.RGBA) { draw(img, textColor) })
+	if overlay == nil {
+		t.Fatal("newTextOverlay() = nil, want the drawn text")
+	}
+	if b := overlay.img.Bounds(); b.Dx() >= config.Width/2 || b.Dy() >= config.Height/2 {
+		t.Errorf("overlay bounds %v not trimmed to the text", b)
+	}
+
+	for _, opacity := range []float64{1, 0.5} {
+		want := patternImage()
+		draw(want, color.RGBA{
+			R: uint8(float64(textColor.R) * opacity),
+			G: uint8(float64(textColor.G) * opacity),
+			B: uint8(float64(textColor.B) * opacity),
+			A: uint8(float64(textColor.A) * opacity),
+		})
+		got := patternImage()
+		overlay.drawOnto(got, opacity)
+		worst := 0
+		for i := range got.Pix {
+			worst = max(worst, absDiff(got.Pix[i], want.Pix[i]))
+		}
+		if worst > 2 {
+			t.Errorf("opacity %.1f: composited overlay differs from direct drawing by up to %d", opacity, worst)
+		}
+	}
+
+	if newTextOverlay(func(*image.RGBA) {}) != nil {
+		t.Error("newTextOverlay() of nothing drawn != nil")
+	}
+}
+
+// patternImage returns an opaque frame with a gradient for text to blend
+// into.
+func patternImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	for i := 0; i < len(img.Pix); i += 4 {
+		px := i / 4
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = uint8(px%config.Width), uint8(px/config.Width), 96, 255
+	}
+	return img
+}
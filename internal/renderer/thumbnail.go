@@ -1,7 +1,6 @@
 package renderer
 
 import (
-	"bytes"
 	"fmt"
 	"image"
 	"image/color"
@@ -10,48 +9,69 @@ import (
 	"os"
 	"strings"
 
-	"github.com/golang/freetype"
-	"github.com/golang/freetype/truetype"
 	"github.com/linuxmatters/jivefire/internal/config"
 	"golang.org/x/image/draw"
 	"golang.org/x/image/font"
 	"golang.org/x/image/math/f64"
+	"golang.org/x/image/math/fixed"
 )
 
-// getThumbnailTextColor returns the text color for thumbnail (uses runtime config or default)
-func getThumbnailTextColor(runtimeConfig *config.RuntimeConfig) color.RGBA {
-	r, g, b := runtimeConfig.GetTextColor()
-	return color.RGBA{R: r, G: g, B: b, A: 255}
+// thumbnailLayout holds the resolved thumbnail options for one render.
+type thumbnailLayout struct {
+	width, height int
+	rotation      float64 // Degrees clockwise; zero disables rotation
+	align         config.TextAlign
+	textColor     color.RGBA
 }
 
-// GenerateThumbnail creates a YouTube thumbnail with the title text overlaid, at
-// the same resolution as the video (1280x720).
+// newThumbnailLayout resolves the thumbnail options from the runtime config.
+func newThumbnailLayout(runtimeConfig *config.RuntimeConfig) thumbnailLayout {
+	w, h := runtimeConfig.GetThumbnailSize()
+	r, g, b := runtimeConfig.GetThumbnailTextColor()
+	return thumbnailLayout{
+		width:     w,
+		height:    h,
+		rotation:  runtimeConfig.GetThumbnailRotation(),
+		align:     runtimeConfig.GetThumbnailAlign(),
+		textColor: color.RGBA{R: r, G: g, B: b, A: 255},
+	}
+}
+
+// GenerateThumbnail creates a YouTube thumbnail with the title text overlaid,
+// at the video resolution (1280x720) unless a thumbnail size is configured.
 func GenerateThumbnail(outputPath string, meta PodcastMeta, runtimeConfig *config.RuntimeConfig) error {
-	thumbImg, err := loadThumbnailBackground(runtimeConfig)
+	layout := newThumbnailLayout(runtimeConfig)
+
+	thumbImg, err := loadThumbnailBackground(runtimeConfig, layout.width, layout.height)
 	if err != nil {
 		return fmt.Errorf("failed to load thumbnail background: %w", err)
 	}
 
-	fontData, err := embeddedAssets.ReadFile(config.ThumbnailFontAsset)
-	if err != nil {
-		return fmt.Errorf("failed to load bold font: %w", err)
-	}
+	return renderThumbnail(thumbImg, outputPath, meta, runtimeConfig, layout)
+}
+
+// GenerateThumbnailWithBackground creates a thumbnail like GenerateThumbnail
+// but over the given background, such as a rendered video frame, instead of
+// the thumbnail image. The background is only read, so a live frame buffer
+// may be passed directly.
+func GenerateThumbnailWithBackground(outputPath string, background image.Image, meta PodcastMeta, runtimeConfig *config.RuntimeConfig) error {
+	layout := newThumbnailLayout(runtimeConfig)
+	thumbImg := scaleThumbnailBackground(background, layout.width, layout.height)
+	return renderThumbnail(thumbImg, outputPath, meta, runtimeConfig, layout)
+}
 
-	parsedFont, err := truetype.Parse(fontData)
+// renderThumbnail draws the title onto thumbImg and saves it as a PNG.
+func renderThumbnail(thumbImg *image.RGBA, outputPath string, meta PodcastMeta, runtimeConfig *config.RuntimeConfig, layout thumbnailLayout) error {
+	chain, err := thumbnailFontChain(runtimeConfig)
 	if err != nil {
-		return fmt.Errorf("failed to parse font: %w", err)
+		return fmt.Errorf("failed to load thumbnail font: %w", err)
 	}
 
-	line1, line2 := splitTitle(meta.Title)
-	fontSize := findOptimalFontSize(parsedFont, line1, line2)
+	lines := splitTitle(meta.Title, runtimeConfig.GetThumbnailMaxLines())
+	fontSize := findOptimalFontSize(chain, lines, layout.width, layout.height)
+	face := newShapedFace(chain, fontSize)
 
-	face := truetype.NewFace(parsedFont, &truetype.Options{
-		Size: fontSize,
-		DPI:  72,
-	})
-	defer face.Close()
-
-	drawThumbnailText(thumbImg, face, line1, line2, runtimeConfig)
+	drawThumbnailText(thumbImg, face, lines, layout)
 
 	if err := saveThumbnail(thumbImg, outputPath); err != nil {
 		return fmt.Errorf("failed to save thumbnail: %w", err)
@@ -60,92 +80,94 @@ func GenerateThumbnail(outputPath string, meta PodcastMeta, runtimeConfig *confi
 	return nil
 }
 
-// loadThumbnailBackground loads and scales the thumbnail background (from custom path or embedded asset)
-func loadThumbnailBackground(runtimeConfig *config.RuntimeConfig) (*image.RGBA, error) {
-	data, err := loadImageData(runtimeConfig.GetThumbnailImagePath())
+// loadThumbnailBackground loads the thumbnail background (from custom path or
+// embedded asset) at the thumbnail size. A custom image of another size is
+// cropped to fill it, as a thumbnail has no letterbox to spare.
+func loadThumbnailBackground(runtimeConfig *config.RuntimeConfig, width, height int) (*image.RGBA, error) {
+	path, isCustom := runtimeConfig.GetThumbnailImagePath()
+	data, err := loadImageData(path, isCustom)
 	if err != nil {
 		return nil, err
 	}
 
-	img, err := png.Decode(bytes.NewReader(data))
+	img, err := decodeImage(data)
 	if err != nil {
 		return nil, err
 	}
 
+	if isCustom && img.Bounds().Size() != image.Pt(width, height) {
+		return fitImage(img, width, height, config.FitCover), nil
+	}
+	return scaleThumbnailBackground(img, width, height), nil
+}
+
+// scaleThumbnailBackground copies img into a new RGBA image at the thumbnail
+// resolution, scaling only when the sizes differ.
+func scaleThumbnailBackground(img image.Image, width, height int) *image.RGBA {
 	// Skip scaling when the source already matches the target resolution.
 	bounds := img.Bounds()
-	if bounds.Dx() == config.Width && bounds.Dy() == config.Height {
-		rgba := image.NewRGBA(bounds)
-		draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
-		return rgba, nil
+	if bounds.Dx() == width && bounds.Dy() == height {
+		rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+		return rgba
 	}
 
-	dst := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
 	draw.BiLinear.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
-	return dst, nil
+	return dst
 }
 
-// splitTitle splits the title into 2 roughly equal lines
-func splitTitle(title string) (string, string) {
+// splitTitle splits the title into at most maxLines lines holding roughly
+// equal numbers of words. Titles with fewer words than maxLines get one word
+// per line.
+func splitTitle(title string, maxLines int) []string {
 	words := strings.Fields(title)
-	if len(words) == 0 {
-		return "", ""
-	}
-	if len(words) == 1 {
-		return words[0], ""
-	}
+	n := min(len(words), max(maxLines, 1))
 
-	// Split at the midpoint for roughly equal lines
-	mid := len(words) / 2
-	line1 := strings.Join(words[:mid], " ")
-	line2 := strings.Join(words[mid:], " ")
-
-	return line1, line2
+	lines := make([]string, 0, n)
+	for i := range n {
+		lines = append(lines, strings.Join(words[i*len(words)/n:(i+1)*len(words)/n], " "))
+	}
+	return lines
 }
 
 // findOptimalFontSize finds the largest font size that fits within constraints
 // Constraints:
 // - ThumbnailMargin from left and right edges
-// - Line 1 starts at top margin (ThumbnailMargin)
-// - Bottom edge of line 2 must not extend below center line (y=360)
-func findOptimalFontSize(parsedFont *truetype.Font, line1, line2 string) float64 {
-	centerY := config.Height / 2
-	maxWidth := config.Width - (2 * config.ThumbnailMargin)
-
-	// Start with a large size and reduce until it fits
-	for size := 150.0; size > 10.0; size -= 2.0 {
-		face := truetype.NewFace(parsedFont, &truetype.Options{
-			Size: size,
-			DPI:  72,
-		})
-
-		// Measure both lines
-		width1, bounds1 := measureTextBounds(face, line1)
-		width2, bounds2 := measureTextBounds(face, line2)
-
-		face.Close()
-
-		// Check if both lines fit within width constraint
-		if width1 > maxWidth || width2 > maxWidth {
-			continue
+// - The first line starts at top margin (ThumbnailMargin)
+// - Bottom edge of the last line must not extend below the centre line
+func findOptimalFontSize(chain fontChain, lines []string, width, height int) float64 {
+	centerY := height / 2
+	maxWidth := width - (2 * config.ThumbnailMargin)
+
+	// Start with a large size (150pt at 720p, scaled with the resolution) and
+	// reduce until it fits
+	for size := 150.0 * float64(height) / config.Height; size > 10.0; size -= 2.0 {
+		face := newShapedFace(chain, size)
+
+		fits := true
+		blockHeight := 0
+		for _, line := range lines {
+			w, bounds := measureTextBounds(face, line)
+			if w > maxWidth {
+				fits = false
+				break
+			}
+			blockHeight += (bounds.Max.Y - bounds.Min.Y).Ceil()
 		}
 
-		// Calculate line spacing (50% of font size for more vertical spacing)
-		lineSpacing := int(size * 0.5)
-
-		// Height of each line (from top to bottom of glyphs)
-		height1 := (bounds1.Max.Y - bounds1.Min.Y).Ceil()
-		height2 := (bounds2.Max.Y - bounds2.Min.Y).Ceil()
+		if !fits {
+			continue
+		}
 
-		// Calculate where line 2 bottom would be:
-		// Line 1 top: margin
-		// Line 1 bottom: margin + height1
-		// Line 2 top: margin + height1 + lineSpacing
-		// Line 2 bottom: margin + height1 + lineSpacing + height2
-		line2Bottom := config.ThumbnailMargin + height1 + lineSpacing + height2
+		// Line spacing (50% of font size for more vertical spacing) between
+		// each pair of lines
+		if len(lines) > 1 {
+			blockHeight += (len(lines) - 1) * int(size*0.5)
+		}
 
-		// Check if line 2 bottom fits above center line
-		if line2Bottom <= centerY {
+		// Check if the last line's bottom fits above the centre line
+		if config.ThumbnailMargin+blockHeight <= centerY {
 			return size
 		}
 	}
@@ -153,61 +175,74 @@ func findOptimalFontSize(parsedFont *truetype.Font, line1, line2 string) float64
 	return 10.0 // Minimum fallback size
 }
 
-// drawThumbnailText draws the title text on the thumbnail with a slight rotation
-// Line 1 is top-aligned at the ThumbnailMargin
-// Bottom edge of line 2 must not extend below center line
-// Text is rotated ThumbnailTextRotationDegrees clockwise for dynamic effect
-func drawThumbnailText(img *image.RGBA, face font.Face, line1, line2 string, runtimeConfig *config.RuntimeConfig) {
+// drawThumbnailText draws the title text on the thumbnail with an optional rotation
+// The first line is top-aligned at the ThumbnailMargin
+// Bottom edge of the last line must not extend below the centre line
+// Text is rotated layout.rotation degrees clockwise for dynamic effect
+func drawThumbnailText(img *image.RGBA, face font.Face, lines []string, layout thumbnailLayout) {
+	if len(lines) == 0 {
+		return
+	}
+
 	// Measure text dimensions - bounds.Min.Y is negative (ascent), bounds.Max.Y is positive (descent)
-	width1, bounds1 := measureTextBounds(face, line1)
-	width2, bounds2 := measureTextBounds(face, line2)
+	widths := make([]int, len(lines))
+	bounds := make([]fixed.Rectangle26_6, len(lines))
+	for i, line := range lines {
+		widths[i], bounds[i] = measureTextBounds(face, line)
+	}
 
 	// Calculate line spacing (50% of font size for more vertical spacing)
 	metrics := face.Metrics()
 	fontSize := float64(metrics.Height) / 64.0 // Convert from fixed.Int26_6 to float64
 	lineSpacing := int(fontSize * 0.5)
 
-	// Calculate the height of each line (from visual top to visual bottom)
-	height1 := (bounds1.Max.Y - bounds1.Min.Y).Ceil()
-	height2 := (bounds2.Max.Y - bounds2.Min.Y).Ceil()
-
 	// Calculate total text block dimensions
-	maxWidth := width1
-	maxWidth = max(maxWidth, width2)
-	totalHeight := height1 + lineSpacing + height2
+	maxWidth := 0
+	totalHeight := (len(lines) - 1) * lineSpacing
+	for i := range lines {
+		maxWidth = max(maxWidth, widths[i])
+		totalHeight += (bounds[i].Max.Y - bounds[i].Min.Y).Ceil()
+	}
 
 	// Create a temporary image for drawing text (larger to accommodate rotation)
 	// Use 1.5x size to ensure no clipping during rotation
 	tempSize := int(float64(maxWidth+totalHeight) * 1.5)
 	tempImg := image.NewRGBA(image.Rect(0, 0, tempSize, tempSize))
 
-	// Draw text on temporary image, centered
-	// The baseline is where DrawString draws. The visual top is baseline + bounds.Min.Y (Min.Y is negative)
-	tempCenterY := tempSize / 2
-
-	// Position line 1: we want the text block centered, so calculate baseline positions
-	// Text block spans from (center - totalHeight/2) to (center + totalHeight/2)
-	// Line 1's visual top should be at (center - totalHeight/2)
-	// Since visual top = baseline + bounds1.Min.Y, we get: baseline = visualTop - bounds1.Min.Y
-	line1VisualTop := tempCenterY - totalHeight/2
-	line1BaselineY := line1VisualTop - bounds1.Min.Y.Ceil() // Min.Y is negative, so this adds to visualTop
+	// Center of rotation (center of temp image)
+	cx := float64(tempSize) / 2.0
+	cy := float64(tempSize) / 2.0
 
-	// Line 2's visual top is line1VisualTop + height1 + lineSpacing
-	line2VisualTop := line1VisualTop + height1 + lineSpacing
-	line2BaselineY := line2VisualTop - bounds2.Min.Y.Ceil()
+	// The text block spans maxWidth, centred on the temp image; lines are
+	// aligned within it.
+	blockLeft := (tempSize - maxWidth) / 2
+	lineX := func(i int) int {
+		switch layout.align {
+		case config.AlignLeft:
+			return blockLeft
+		case config.AlignRight:
+			return blockLeft + maxWidth - widths[i]
+		default:
+			return (tempSize - widths[i]) / 2
+		}
+	}
 
-	drawCenteredLineOnTemp(tempImg, face, line1, tempSize, line1BaselineY, runtimeConfig)
-	drawCenteredLineOnTemp(tempImg, face, line2, tempSize, line2BaselineY, runtimeConfig)
+	// Position each line: the text block is centred vertically on the temp
+	// image. The baseline is where drawString draws and the visual top is
+	// baseline + bounds.Min.Y (Min.Y is negative), so baseline = visualTop - Min.Y.
+	line1VisualTop := (tempSize / 2) - totalHeight/2
+	visualTop := line1VisualTop
+	for i, line := range lines {
+		baselineY := visualTop - bounds[i].Min.Y.Ceil()
+		drawLineOnTemp(tempImg, face, line, lineX(i), baselineY, layout.textColor)
+		visualTop += (bounds[i].Max.Y - bounds[i].Min.Y).Ceil() + lineSpacing
+	}
 
 	// Create rotation matrix for thumbnail text rotation (clockwise)
-	angle := -config.ThumbnailTextRotationDegrees * math.Pi / 180.0 // Negative for clockwise
+	angle := -layout.rotation * math.Pi / 180.0 // Negative for clockwise
 	cos := math.Cos(angle)
 	sin := math.Sin(angle)
 
-	// Center of rotation (center of temp image)
-	cx := float64(tempSize) / 2.0
-	cy := float64(tempSize) / 2.0
-
 	// Create affine transformation matrix
 	// Translate to origin, rotate, translate back
 	m := f64.Aff3{
@@ -221,28 +256,30 @@ func drawThumbnailText(img *image.RGBA, face font.Face, line1, line2 string, run
 	// Apply rotation
 	draw.BiLinear.Transform(rotatedImg, m, tempImg, tempImg.Bounds(), draw.Over, nil)
 
-	// Calculate the position to composite rotated text onto thumbnail
-	// For a clockwise rotation, the highest point will be the top-right corner of line 1
-
-	// Line 1's visual top in tempImg coordinates
-	line1Top := float64(line1VisualTop)
+	// Calculate the position to composite rotated text onto thumbnail. The
+	// highest point after rotation is one of the top corners of the first line
+	// (the top-right for a clockwise rotation).
+	line1Top := float64(line1VisualTop) - cy // Relative to rotation center
+	line1Left := float64(lineX(0)) - cx
+	line1Right := line1Left + float64(widths[0])
 
-	// Get the right edge of line 1
-	line1Right := cx + float64(width1)/2.0
-
-	// This is the point that will be highest after clockwise rotation
-	topRightX := line1Right - cx // Relative to rotation center
-	topRightY := line1Top - cy   // Relative to rotation center
-
-	// Apply rotation to find where this point ends up
-	// For clockwise rotation: y' = x*sin + y*cos
-	rotatedTopY := sin*topRightX + cos*topRightY
+	// Apply rotation to find where these points end up: y' = x*sin + y*cos
+	rotatedTopY := min(sin*line1Left+cos*line1Top, sin*line1Right+cos*line1Top)
 
 	// Translate back to tempImg coordinates
 	highestPointY := rotatedTopY + cy
 
-	// Position the rotated text block centered horizontally
-	destX := (config.Width - tempSize) / 2
+	// Position the rotated text block horizontally per the alignment, with
+	// left and right aligned blocks inset by the margin
+	var destX int
+	switch layout.align {
+	case config.AlignLeft:
+		destX = config.ThumbnailMargin - blockLeft
+	case config.AlignRight:
+		destX = layout.width - config.ThumbnailMargin - (blockLeft + maxWidth)
+	default:
+		destX = (layout.width - tempSize) / 2
+	}
 
 	// For vertical positioning:
 	// highestPointY is the highest point of the rotated text in tempImg coordinates
@@ -256,20 +293,13 @@ func drawThumbnailText(img *image.RGBA, face font.Face, line1, line2 string, run
 	draw.Draw(img, destRect, rotatedImg, image.Point{}, draw.Over)
 }
 
-// drawCenteredLineOnTemp draws a line of text centered on a temporary image
-func drawCenteredLineOnTemp(img *image.RGBA, face font.Face, text string, imgWidth, baselineY int, runtimeConfig *config.RuntimeConfig) {
+// drawLineOnTemp draws a line of text on a temporary image with its left edge at x
+func drawLineOnTemp(img *image.RGBA, face font.Face, text string, x, baselineY int, textColor color.RGBA) {
 	if text == "" {
 		return
 	}
 
-	d := newTextDrawer(img, face, getThumbnailTextColor(runtimeConfig))
-	textWidth := measureStringWidth(d, text)
-
-	// Center horizontally
-	x := (imgWidth - textWidth) / 2
-
-	d.Dot = freetype.Pt(x, baselineY)
-	d.DrawString(text)
+	drawString(img, face, text, x, baselineY, textColor)
 }
 
 // saveThumbnail saves the thumbnail image to a PNG file
@@ -1,6 +1,8 @@
 package renderer
 
 import (
+	"bytes"
+	"image/png"
 	"os"
 	"path/filepath"
 	"testing"
@@ -53,3 +55,87 @@ func TestGenerateSampleThumbnail(t *testing.T) {
 		})
 	}
 }
+
+// TestSplitTitle verifies titles split into roughly equal word groups across
+// the requested number of lines, matching the original two-line midpoint split.
+func TestSplitTitle(t *testing.T) {
+	tests := []struct {
+		title    string
+		maxLines int
+		want     []string
+	}{
+		{"Panache, for Men", 2, []string{"Panache,", "for Men"}},
+		{"High Precision Solid Metal Balls", 2, []string{"High Precision", "Solid Metal Balls"}},
+		{"High Precision Solid Metal Balls", 3, []string{"High", "Precision Solid", "Metal Balls"}},
+		{"Single", 3, []string{"Single"}},
+		{"Two Words", 1, []string{"Two Words"}},
+		{"", 2, []string{}},
+	}
+
+	for _, tt := range tests {
+		got := splitTitle(tt.title, tt.maxLines)
+		if len(got) != len(tt.want) {
+			t.Errorf("splitTitle(%q, %d) = %q, want %q", tt.title, tt.maxLines, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("splitTitle(%q, %d) = %q, want %q", tt.title, tt.maxLines, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// TestGenerateThumbnail_CustomLayout verifies a thumbnail rendered at a custom
+// resolution with unrotated, left-aligned, three-line text is written at the
+// requested size.
+func TestGenerateThumbnail_CustomLayout(t *testing.T) {
+	zero := 0.0
+	runtimeConfig := &config.RuntimeConfig{
+		ThumbnailTextColor: config.OptionalColor{R: 255, G: 255, B: 255, Set: true},
+		ThumbnailRotation:  &zero,
+		ThumbnailAlign:     config.AlignLeft,
+		ThumbnailMaxLines:  3,
+		ThumbnailWidth:     1920,
+		ThumbnailHeight:    1080,
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "thumb.png")
+	if err := GenerateThumbnail(outputPath, PodcastMeta{Title: "High Precision Solid Metal Balls"}, runtimeConfig); err != nil {
+		t.Fatalf("GenerateThumbnail() error = %v", err)
+	}
+
+	f, err := os.Open(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cfg, err := png.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf("decoding thumbnail: %v", err)
+	}
+	if cfg.Width != 1920 || cfg.Height != 1080 {
+		t.Errorf("thumbnail size = %dx%d, want 1920x1080", cfg.Width, cfg.Height)
+	}
+}
+
+// TestGenerateThumbnailWithBackground verifies a rendered frame can stand in
+// for the thumbnail background and leaves the source frame untouched.
+func TestGenerateThumbnailWithBackground(t *testing.T) {
+	frame := NewFrame(nil, nil, PodcastMeta{}, &config.RuntimeConfig{})
+	frame.Draw(generateTestBarHeights())
+	before := append([]byte(nil), frame.GetImage().Pix...)
+
+	outputPath := filepath.Join(t.TempDir(), "out-1.png")
+	if err := GenerateThumbnailWithBackground(outputPath, frame.GetImage(), PodcastMeta{Title: "Panache, for Men"}, &config.RuntimeConfig{}); err != nil {
+		t.Fatalf("GenerateThumbnailWithBackground() error = %v", err)
+	}
+	if _, err := os.Stat(outputPath); err != nil {
+		t.Fatalf("thumbnail file was not created: %v", err)
+	}
+	if !bytes.Equal(before, frame.GetImage().Pix) {
+		t.Error("GenerateThumbnailWithBackground() modified the source frame")
+	}
+}
@@ -0,0 +1,132 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// The timecode burn-in (--timecode) prints each frame's place in the video
+// as HH:MM:SS:FF, non-drop-frame at config.FPS, so an editor reviewing an
+// episode can note a cut to the frame. Each character sits in a cell of
+// fixed width, so the counter holds still as the digits change, over a
+// rounded panel drawn once.
+
+// timecode is the burnt-in counter in a frame corner.
+type timecode struct {
+	face   font.Face
+	origin image.Point
+	panel  *image.RGBA // The rounded panel, nil when it is transparent
+	width  int
+	height int
+	padX   int
+	base   int // Baseline row from the panel's top
+	cell   int // Width of each digit's cell
+	colon  int // Width of each colon's cell
+	colour color.RGBA
+}
+
+// LoadTimecodeFont loads the title font (custom or embedded) at the
+// configured timecode size. Returns a nil face and no error when no
+// timecode is configured.
+func LoadTimecodeFont(runtimeConfig *config.RuntimeConfig) (font.Face, error) {
+	if runtimeConfig.Timecode == "" {
+		return nil, nil
+	}
+	f, err := parseFont(runtimeConfig.GetTitleFontPath())
+	if err != nil {
+		return nil, err
+	}
+	return newFontFace(f, runtimeConfig.GetTimecodeSize()), nil
+}
+
+func newTimecode(face font.Face, pos config.BadgePosition, padding int, pill float64, colour color.RGBA) *timecode {
+	tc := &timecode{face: face, colour: colour}
+	for r := '0'; r <= '9'; r++ {
+		if adv, ok := face.GlyphAdvance(r); ok {
+			tc.cell = max(tc.cell, adv.Ceil())
+		}
+	}
+	if adv, ok := face.GlyphAdvance(':'); ok {
+		tc.colon = adv.Ceil()
+	}
+
+	metrics := face.Metrics()
+	ascent, descent := metrics.Ascent.Ceil(), metrics.Descent.Ceil()
+	padY := (ascent + descent) / 4
+	tc.height = ascent + descent + 2*padY
+	tc.padX = tc.height / 2
+	tc.base = padY + ascent
+	tc.width = 2*tc.padX + 8*tc.cell + 3*tc.colon
+	tc.origin = badgeOrigin(pos, tc.width, tc.height, padding)
+	if pill > 0 {
+		tc.panel = roundedPanel(tc.width, tc.height, pill)
+	}
+	return tc
+}
+
+// roundedPanel returns a black w×h panel with fully rounded ends at opacity,
+// its edges antialiased.
+func roundedPanel(w, h int, opacity float64) *image.RGBA {
+	panel := image.NewRGBA(image.Rect(0, 0, w, h))
+	r := float64(h) / 2
+	for y := range h {
+		for x := range w {
+			// Distance outside the straight middle, then from the end's centre
+			cx := max(r-float64(x)-0.5, float64(x)+0.5-(float64(w)-r), 0)
+			d := math.Hypot(cx, float64(y)+0.5-r)
+			cover := max(0, min(r-d+0.5, 1))
+			panel.Pix[panel.PixOffset(x, y)+3] = uint8(cover*opacity*255 + 0.5)
+		}
+	}
+	return panel
+}
+
+// formatTimecode returns frame n's timecode at fps frames per second. The
+// hours wrap after 99.
+func formatTimecode(n, fps int) string {
+	s := n / fps
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", s/3600%100, s/60%60, s%60, n%fps)
+}
+
+// draw paints frame n's timecode over its panel.
+func (tc *timecode) draw(img *image.RGBA, n int) {
+	if tc.panel != nil {
+		dst := image.Rectangle{Min: tc.origin, Max: tc.origin.Add(tc.panel.Bounds().Size())}
+		draw.Draw(img, dst, tc.panel, image.Point{}, draw.Over)
+	}
+	d := newTextDrawer(img, tc.face, tc.colour)
+	x := tc.origin.X + tc.padX
+	for _, r := range formatTimecode(n, config.FPS) {
+		cell := tc.cell
+		if r == ':' {
+			cell = tc.colon
+		}
+		adv, _ := tc.face.GlyphAdvance(r)
+		d.Dot = fixed.P(x, tc.origin.Y+tc.base).Add(fixed.Point26_6{X: (fixed.I(cell) - adv) / 2})
+		d.DrawString(string(r))
+		x += cell
+	}
+}
+
+// rows returns the frame rows the timecode covers.
+func (tc *timecode) rows() rowSpan {
+	return newRowSpan(tc.origin.Y, tc.origin.Y+tc.height)
+}
+
+// SetTimecode burns a timecode into each frame in face, from
+// LoadTimecodeFont: the frame's place in the video, in the corner and over
+// the panel the runtime config gave NewFrame. A nil face draws none.
+func (f *Frame) SetTimecode(face font.Face) {
+	f.timecode = nil
+	if face != nil && f.timecodePos != "" {
+		f.timecode = newTimecode(face, f.timecodePos, f.badgePadding, f.timecodePill, color.RGBA{R: f.textColor[0], G: f.textColor[1], B: f.textColor[2], A: 255})
+	}
+	f.redraw = true
+}
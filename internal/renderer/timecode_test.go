@@ -0,0 +1,73 @@
+package renderer
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+func TestFormatTimecode(t *testing.T) {
+	tests := []struct {
+		frame int
+		want  string
+	}{
+		{0, "00:00:00:00"},
+		{29, "00:00:00:29"},
+		{30, "00:00:01:00"},
+		{(59*60+59)*30 + 12, "00:59:59:12"},
+		{3600*30*2 + 61*30 + 1, "02:01:01:01"},
+		{3600 * 30 * 100, "00:00:00:00"},
+	}
+	for _, tt := range tests {
+		if got := formatTimecode(tt.frame, 30); got != tt.want {
+			t.Errorf("formatTimecode(%d, 30) = %q, want %q", tt.frame, got, tt.want)
+		}
+	}
+}
+
+// TestTimecodeDraw verifies the timecode sits in its corner over a darkened
+// panel, changes with every frame and marks its rows dirty, and that
+// without a face nothing is drawn.
+func TestTimecodeDraw(t *testing.T) {
+	rc := &config.RuntimeConfig{Timecode: config.BadgeBottomLeft}
+	face, err := LoadTimecodeFont(rc)
+	if err != nil || face == nil {
+		t.Fatalf("LoadTimecodeFont() = %v, %v", face, err)
+	}
+	bg := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	for i := range bg.Pix {
+		bg.Pix[i] = 200
+	}
+	frame := NewFrame(bg, nil, PodcastMeta{}, rc)
+	frame.SetTimecode(face)
+	tc := frame.timecode
+	if tc.origin.X != config.BadgePadding || tc.origin.Y != config.Height-tc.height-config.BadgePadding {
+		t.Errorf("timecode origin %v, want bottom-left inset by %d", tc.origin, config.BadgePadding)
+	}
+
+	heights := make([]float64, config.NumBars)
+	frame.Draw(heights)
+	if got := frame.img.RGBAAt(tc.origin.X+tc.width/2, tc.origin.Y+1); got.R >= 200 {
+		t.Errorf("panel = %v, want the background darkened", got)
+	}
+	box := image.Rect(tc.origin.X, tc.origin.Y, tc.origin.X+tc.width, tc.origin.Y+tc.height)
+	first := bytes.Clone(frame.img.SubImage(box).(*image.RGBA).Pix)
+	frame.Draw(heights)
+	if bytes.Equal(first, frame.img.SubImage(box).(*image.RGBA).Pix) {
+		t.Error("the timecode is the same on consecutive frames")
+	}
+	if start, end := frame.DirtyRows(); start > tc.origin.Y || end < tc.origin.Y+tc.height {
+		t.Errorf("dirty rows %d-%d miss the timecode at %d-%d", start, end, tc.origin.Y, tc.origin.Y+tc.height)
+	}
+
+	if face, err := LoadTimecodeFont(&config.RuntimeConfig{}); face != nil || err != nil {
+		t.Errorf("LoadTimecodeFont() without a corner = %v, %v, want nil", face, err)
+	}
+	plain := NewFrame(bg, nil, PodcastMeta{}, &config.RuntimeConfig{})
+	plain.SetTimecode(face)
+	if plain.timecode != nil {
+		t.Error("SetTimecode drew a timecode without a corner")
+	}
+}
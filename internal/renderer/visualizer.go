@@ -0,0 +1,99 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// Visualizer draws the audio-reactive part of each video frame. Process is
+// called once per frame with the bar heights in pixels (centre-out order, as
+// the bars are laid out) and the frame's timestamp; Draw then paints onto the
+// frame, after the background and before the framing lines, title and badge.
+//
+// A visualizer is used from the render loop only, so it needs no locking, and
+// may keep any state between frames. It must not retain barHeights, which the
+// caller reuses.
+type Visualizer interface {
+	Process(barHeights []float64, t time.Duration)
+	Draw(img *image.RGBA)
+}
+
+// BoundedVisualizer is a Visualizer that knows which rows its last Draw
+// painted, [startY, endY). Frame.DirtyRows relies on it to bound what changes
+// from one frame to the next; any other visualizer counts as painting every
+// row.
+type BoundedVisualizer interface {
+	Visualizer
+	DrawnRows() (startY, endY int)
+}
+
+// FeatureVisualizer is a Visualizer that reacts to the audio features of
+// audio.FeatureSet (spectral centroid, flux, zero-crossing rate and any a
+// custom build registers) as well as the bars. Features is called before
+// each Process with the frame's features by name; it must not retain the
+// map, which the caller reuses.
+type FeatureVisualizer interface {
+	Visualizer
+	Features(features map[string]float64)
+}
+
+// VisualizerFactory creates a visualizer for one render, reading colours and
+// other overrides from runtimeConfig.
+type VisualizerFactory func(runtimeConfig *config.RuntimeConfig) (Visualizer, error)
+
+// DefaultVisualizer is the built-in mirrored bar spectrum.
+const DefaultVisualizer = "bars"
+
+var (
+	visualizersMu sync.RWMutex
+	visualizers   = make(map[string]VisualizerFactory)
+)
+
+// RegisterVisualizer makes a visualizer available to --visualizer under name.
+// Custom builds call it from an init function in a file added to the main
+// package (or a package it imports). It panics if name is empty or already
+// registered, as that is a programming error.
+func RegisterVisualizer(name string, factory VisualizerFactory) {
+	visualizersMu.Lock()
+	defer visualizersMu.Unlock()
+	if name == "" || factory == nil {
+		panic("renderer: RegisterVisualizer needs a name and a factory")
+	}
+	if _, dup := visualizers[name]; dup {
+		panic(fmt.Sprintf("renderer: visualizer %q registered twice", name))
+	}
+	visualizers[name] = factory
+}
+
+// Visualizers returns the registered visualizer names, sorted.
+func Visualizers() []string {
+	visualizersMu.RLock()
+	defer visualizersMu.RUnlock()
+	names := make([]string, 0, len(visualizers))
+	for name := range visualizers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// NewVisualizer creates the visualizer registered under name; an empty name
+// selects DefaultVisualizer.
+func NewVisualizer(name string, runtimeConfig *config.RuntimeConfig) (Visualizer, error) {
+	if name == "" {
+		name = DefaultVisualizer
+	}
+	visualizersMu.RLock()
+	factory, ok := visualizers[name]
+	visualizersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown visualizer %q (available: %s)", name, strings.Join(Visualizers(), ", "))
+	}
+	return factory(runtimeConfig)
+}
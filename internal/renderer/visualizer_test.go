@@ -0,0 +1,175 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// recordingVisualizer fills the frame with one colour and records the
+// timestamps it was given.
+type recordingVisualizer struct {
+	times []time.Duration
+	fill  color.RGBA
+}
+
+func (r *recordingVisualizer) Process(_ []float64, t time.Duration) {
+	r.times = append(r.times, t)
+}
+
+func (r *recordingVisualizer) Draw(img *image.RGBA) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = r.fill.R, r.fill.G, r.fill.B, r.fill.A
+	}
+}
+
+// TestVisualizerRegistry verifies the bars are registered by default, custom
+// visualizers can be added and created by name, and unknown names fail.
+func TestVisualizerRegistry(t *testing.T) {
+	if !slices.Contains(Visualizers(), DefaultVisualizer) {
+		t.Fatalf("Visualizers() = %q, want %q registered", Visualizers(), DefaultVisualizer)
+	}
+	if _, err := NewVisualizer("", &config.RuntimeConfig{}); err != nil {
+		t.Errorf("NewVisualizer(\"\") error = %v, want the default", err)
+	}
+
+	RegisterVisualizer("test-recording", func(*config.RuntimeConfig) (Visualizer, error) {
+		return &recordingVisualizer{}, nil
+	})
+	vis, err := NewVisualizer("test-recording", &config.RuntimeConfig{})
+	if err != nil {
+		t.Fatalf("NewVisualizer() error = %v", err)
+	}
+	if _, ok := vis.(*recordingVisualizer); !ok {
+		t.Errorf("NewVisualizer() = %T, want *recordingVisualizer", vis)
+	}
+
+	if _, err := NewVisualizer("no-such-visualizer", &config.RuntimeConfig{}); err == nil {
+		t.Error("NewVisualizer(unknown) succeeded, want error")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate name did not panic")
+		}
+	}()
+	RegisterVisualizer(DefaultVisualizer, func(*config.RuntimeConfig) (Visualizer, error) { return nil, nil })
+}
+
+// TestFrameUsesVisualizer verifies a custom visualizer draws over the
+// background, under the framing lines, and sees frame-rate timestamps.
+func TestFrameUsesVisualizer(t *testing.T) {
+	frame := NewFrame(nil, nil, PodcastMeta{}, &config.RuntimeConfig{})
+	vis := &recordingVisualizer{fill: color.RGBA{G: 200, A: 255}}
+	frame.SetVisualizer(vis)
+
+	heights := make([]float64, config.NumBars)
+	for range 3 {
+		frame.Draw(heights)
+	}
+
+	want := []time.Duration{0, time.Second / config.FPS, 2 * time.Second / config.FPS}
+	if !slices.Equal(vis.times, want) {
+		t.Errorf("timestamps = %v, want %v", vis.times, want)
+	}
+
+	img := frame.GetImage()
+	if got := img.RGBAAt(0, 0); got != vis.fill {
+		t.Errorf("corner pixel = %v, want the visualizer fill %v", got, vis.fill)
+	}
+	lineY := config.Height/2 + config.CenterGap/2
+	if got := img.RGBAAt(config.Width/2, lineY); got == vis.fill {
+		t.Error("framing line not drawn over the visualizer")
+	}
+}
+
+// TestBarsBandColors verifies each bar is drawn in its band's colour, with
+// the bass band at the centre and the highest band at the edges.
+func TestBarsBandColors(t *testing.T) {
+	split := make([]int, config.NumBars)
+	for i := range split {
+		split[i] = min(i*3/(config.NumBars/2), 2)
+	}
+	runtimeConfig := &config.RuntimeConfig{
+		BarBands: split,
+		BandColors: []config.OptionalColor{
+			{R: 200, Set: true},
+			{G: 200, Set: true},
+			{B: 200, Set: true},
+		},
+	}
+	vis := newBarsVisualizer(runtimeConfig)
+	heights := make([]float64, config.NumBars)
+	for i := range heights {
+		heights[i] = float64(vis.maxBarHeight)
+	}
+	vis.Process(heights, 0)
+	img := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	vis.Draw(img)
+
+	// Just outside the centre gap, where bars are at full brightness.
+	y := vis.centerY - config.CenterGap/2 - 1
+	barX := func(i int) int { return vis.startX + i*(config.BarWidth+config.BarGap) }
+	center := config.NumBars / 2
+	for _, tt := range []struct {
+		bar  int
+		want color.RGBA
+	}{
+		{center - 1, color.RGBA{R: 200, A: 255}},
+		{center, color.RGBA{R: 200, A: 255}},
+		{0, color.RGBA{B: 200, A: 255}},
+		{config.NumBars - 1, color.RGBA{B: 200, A: 255}},
+	} {
+		if got := img.RGBAAt(barX(tt.bar), y); got != tt.want {
+			t.Errorf("bar %d = %v, want %v", tt.bar, got, tt.want)
+		}
+	}
+}
+
+// TestBarsReactiveColors verifies reactive bars take the low colour when
+// only the bass sounds, ease towards the high colour when the treble takes
+// over, and keep their colour through silence.
+func TestBarsReactiveColors(t *testing.T) {
+	runtimeConfig := &config.RuntimeConfig{
+		BarColorMode: config.ColorReactive,
+		ReactiveLow:  config.OptionalColor{R: 250, Set: true},
+		ReactiveHigh: config.OptionalColor{B: 250, Set: true},
+	}
+	vis := newBarsVisualizer(runtimeConfig)
+	center := config.NumBars / 2
+	y := vis.centerY - config.CenterGap/2 - 1
+	x := vis.startX + (center-1)*(config.BarWidth+config.BarGap)
+	draw := func(heights []float64) color.RGBA {
+		vis.Process(heights, 0)
+		img := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+		vis.Draw(img)
+		return img.RGBAAt(x, y)
+	}
+
+	bass, treble := make([]float64, config.NumBars), make([]float64, config.NumBars)
+	bass[center-1], bass[center] = 100, 100
+	treble[0], treble[config.NumBars-1] = 100, 100
+	treble[center-1], treble[center] = 1, 1
+	if got := draw(bass); got != (color.RGBA{R: 250, A: 255}) {
+		t.Errorf("bass-only bar = %v, want the low colour", got)
+	}
+	first := draw(treble)
+	if first.B == 0 || first.B >= 250 {
+		t.Errorf("first treble frame = %v, want part-way to the high colour", first)
+	}
+	var got color.RGBA
+	for range 3 * config.FPS {
+		got = draw(treble)
+	}
+	if got.R > 10 || got.B < 240 {
+		t.Errorf("after 3 s of treble = %v, want the high colour", got)
+	}
+	vis.Process(make([]float64, config.NumBars), 0)
+	if step := int(vis.balance*(reactiveSteps-1) + 0.5); step < reactiveSteps-2 {
+		t.Errorf("silence moved the balance to step %d, want it kept", step)
+	}
+}
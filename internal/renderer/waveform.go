@@ -0,0 +1,157 @@
+package renderer
+
+import (
+	"image"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+func init() {
+	RegisterVisualizer(WaveformVisualizer, func(runtimeConfig *config.RuntimeConfig) (Visualizer, error) {
+		return newWaveformVisualizer(runtimeConfig), nil
+	})
+}
+
+// WaveformVisualizer is the built-in amplitude waveform, the classic
+// audiogram look, selected with --visualizer=waveform.
+const WaveformVisualizer = "waveform"
+
+// waveformColumn is the width in pixels of one frame's column, which sets
+// how much history fits across the frame: 320 columns, about 10s.
+const waveformColumn = 4
+
+// LevelVisualizer is a Visualizer that follows the audio's level as well as
+// its spectrum. Levels is called before each Process with the frame's RMS
+// and peak, scaled so the loudest peak Pass 1 found is 1.
+type LevelVisualizer interface {
+	Visualizer
+	Levels(rms, peak float64)
+}
+
+// waveformVisualizer draws a history of frame levels as columns mirrored
+// about the centre line: the peak envelope dim, the RMS body within it in
+// the full bar colour. It scrolls in from the right edge, or with
+// --waveform-layout=centre spreads out from the middle to both edges.
+type waveformVisualizer struct {
+	centre    bool
+	centerY   int
+	maxHeight int
+
+	// history is a ring of frame levels, head the next slot written.
+	rms, peak []float64
+	head      int
+	filled    int
+
+	levelled bool    // Levels was called for the frame being processed
+	drawn    rowSpan // Rows the last Draw painted
+
+	body, edge []byte // One column-wide scanline in each colour
+}
+
+func newWaveformVisualizer(runtimeConfig *config.RuntimeConfig) *waveformVisualizer {
+	columns := config.Width / waveformColumn
+	centre := runtimeConfig.WaveformLayout == config.WaveformCentre
+	if centre {
+		columns = (columns + 1) / 2
+	}
+	barR, barG, barB := runtimeConfig.GetBarColor()
+	w := &waveformVisualizer{
+		centre:    centre,
+		centerY:   config.Height / 2,
+		maxHeight: config.Height/2 - config.CenterGap/2,
+		rms:       make([]float64, columns),
+		peak:      make([]float64, columns),
+		body:      make([]byte, waveformColumn*4),
+		edge:      make([]byte, waveformColumn*4),
+	}
+	for x := range waveformColumn {
+		copy(w.body[x*4:], []byte{barR, barG, barB, 255})
+		copy(w.edge[x*4:], []byte{barR / 2, barG / 2, barB / 2, 255})
+	}
+	return w
+}
+
+// Levels records the frame's level for the next Process.
+func (w *waveformVisualizer) Levels(rms, peak float64) {
+	w.push(rms, peak)
+	w.levelled = true
+}
+
+// Process adds the frame to the history. Without a Levels call, as in
+// renders that only have bars (jivefire idle and bench), the mean and the
+// tallest of the bar heights stand in for the RMS and peak.
+func (w *waveformVisualizer) Process(barHeights []float64, _ time.Duration) {
+	if w.levelled {
+		w.levelled = false
+		return
+	}
+	var sum, tallest float64
+	for _, h := range barHeights {
+		sum += h
+		tallest = max(tallest, h)
+	}
+	if len(barHeights) > 0 {
+		sum /= float64(len(barHeights))
+	}
+	w.push(sum/float64(w.maxHeight), tallest/float64(w.maxHeight))
+}
+
+func (w *waveformVisualizer) push(rms, peak float64) {
+	w.rms[w.head] = min(max(rms, 0), 1)
+	w.peak[w.head] = min(max(peak, rms, 0), 1)
+	w.head = (w.head + 1) % len(w.rms)
+	w.filled = min(w.filled+1, len(w.rms))
+}
+
+// Draw paints the history, newest frame at the right edge (or the centre).
+func (w *waveformVisualizer) Draw(img *image.RGBA) {
+	tallest := 0
+	columns := len(w.rms)
+	for age := range w.filled {
+		i := (w.head - 1 - age + columns) % columns
+		peak := int(w.peak[i] * float64(w.maxHeight))
+		if peak <= 0 {
+			continue
+		}
+		tallest = max(tallest, peak)
+		rms := int(w.rms[i] * float64(w.maxHeight))
+		if w.centre {
+			right := config.Width/2 + age*waveformColumn
+			w.drawColumn(img, right, rms, peak)
+			w.drawColumn(img, config.Width-waveformColumn-right, rms, peak)
+		} else {
+			w.drawColumn(img, config.Width-(age+1)*waveformColumn, rms, peak)
+		}
+	}
+	w.drawn = rowSpan{}
+	if tallest > 0 {
+		w.drawn = newRowSpan(w.centerY-tallest, w.centerY+tallest)
+	}
+}
+
+// DrawnRows reports the rows of the tallest column drawn.
+func (w *waveformVisualizer) DrawnRows() (startY, endY int) {
+	return w.drawn.startY, w.drawn.endY
+}
+
+// drawColumn paints one column at x: the body rms pixels either side of the
+// centre line, then the edge out to peak.
+func (w *waveformVisualizer) drawColumn(img *image.RGBA, x, rms, peak int) {
+	if x < 0 || x+waveformColumn > config.Width {
+		return
+	}
+	for d := range peak {
+		line := w.edge
+		if d < rms {
+			line = w.body
+		}
+		for _, y := range [2]int{w.centerY - 1 - d, w.centerY + d} {
+			if y < 0 || y >= config.Height {
+				continue
+			}
+			offset := y*img.Stride + x*4
+			copy(img.Pix[offset:offset+waveformColumn*4], line)
+		}
+	}
+}
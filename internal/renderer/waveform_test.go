@@ -0,0 +1,69 @@
+package renderer
+
+import (
+	"image"
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// TestWaveformScroll verifies the newest frame is drawn at the right edge,
+// its RMS body brighter than the peak edge around it, and older frames to
+// its left.
+func TestWaveformScroll(t *testing.T) {
+	vis := newWaveformVisualizer(&config.RuntimeConfig{})
+	vis.Levels(0.5, 1)
+	vis.Process(nil, 0)
+	vis.Levels(0.25, 0.5)
+	vis.Process(nil, 0)
+	img := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	vis.Draw(img)
+
+	newest, older := config.Width-1, config.Width-waveformColumn-1
+	body := img.RGBAAt(newest, vis.centerY)
+	edge := img.RGBAAt(newest, vis.centerY-vis.maxHeight/3)
+	if body.A != 255 || edge.A != 255 || edge.R >= body.R {
+		t.Errorf("newest column body %v and edge %v, want both drawn with the edge dimmer", body, edge)
+	}
+	if got := img.RGBAAt(newest, vis.centerY-vis.maxHeight*3/4); got.A != 0 {
+		t.Errorf("newest column drawn above its peak: %v", got)
+	}
+	if got := img.RGBAAt(older, vis.centerY-vis.maxHeight*3/4); got.A != 255 {
+		t.Errorf("older column not drawn to its peak: %v", got)
+	}
+	if startY, endY := vis.DrawnRows(); startY != vis.centerY-vis.maxHeight || endY != vis.centerY+vis.maxHeight {
+		t.Errorf("DrawnRows() = %d, %d; want the tallest column", startY, endY)
+	}
+}
+
+// TestWaveformCentre verifies the centre layout mirrors the history out
+// from the middle.
+func TestWaveformCentre(t *testing.T) {
+	vis := newWaveformVisualizer(&config.RuntimeConfig{WaveformLayout: config.WaveformCentre})
+	vis.Levels(1, 1)
+	vis.Process(nil, 0)
+	img := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	vis.Draw(img)
+
+	for _, x := range []int{config.Width/2 - 1, config.Width / 2} {
+		if got := img.RGBAAt(x, vis.centerY); got.A != 255 {
+			t.Errorf("column at x=%d not drawn: %v", x, got)
+		}
+	}
+	if got := img.RGBAAt(config.Width-1, vis.centerY); got.A != 0 {
+		t.Errorf("edge drawn before the history reached it: %v", got)
+	}
+}
+
+// TestWaveformFromBars verifies that without Levels, as when idle or
+// benchmarking, the bar heights drive the waveform.
+func TestWaveformFromBars(t *testing.T) {
+	vis := newWaveformVisualizer(&config.RuntimeConfig{})
+	heights := make([]float64, config.NumBars)
+	heights[0] = float64(vis.maxHeight)
+	vis.Process(heights, 0)
+	i := (vis.head - 1 + len(vis.peak)) % len(vis.peak)
+	if vis.peak[i] != 1 || vis.rms[i] != 1/float64(config.NumBars) {
+		t.Errorf("levels from bars = %g, %g; want 1 and %g", vis.rms[i], vis.peak[i], 1/float64(config.NumBars))
+	}
+}
@@ -0,0 +1,190 @@
+package renderer
+
+import (
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// widget is a layer of the frame drawn over the visualizer. Frame draws its
+// widgets in the order of the layout (see config.ParseLayout), so a --layout
+// file can leave them out, anchor them and stack them.
+type widget interface {
+	draw(f *Frame, barHeights []float64, t time.Duration)
+	// rows returns the rows the widget painted in its last draw that can
+	// differ from frame to frame; see Frame.DirtyRows.
+	rows(f *Frame) rowSpan
+}
+
+// newWidget returns the widget for a layout entry.
+func newWidget(entry config.LayoutWidget) widget {
+	switch entry.Widget {
+	case config.WidgetFramingLines:
+		return framingLinesWidget{}
+	case config.WidgetParticles:
+		return particlesWidget{}
+	case config.WidgetTitle:
+		return titleWidget{}
+	case config.WidgetBadge:
+		return badgeWidget{}
+	case config.WidgetMeter:
+		return meterWidget{}
+	case config.WidgetTimecode:
+		return timecodeWidget{}
+	case config.WidgetProgress:
+		y := 0
+		if entry.Anchor == config.AnchorBottom {
+			y = config.Height - config.ProgressBarHeight
+		}
+		return progressWidget{y: y}
+	case config.WidgetCaptions:
+		return captionsWidget{}
+	case config.WidgetScript:
+		return scriptWidget{}
+	}
+	return nil
+}
+
+// titleCentreY returns the row the title block is centred on for anchor:
+// the centre gap, or a gap's height in from the top or bottom edge.
+func titleCentreY(anchor string, padding int) int {
+	switch anchor {
+	case config.AnchorTop:
+		return padding + config.CenterGap/2
+	case config.AnchorBottom:
+		return config.Height - padding - config.CenterGap/2
+	}
+	return config.Height / 2
+}
+
+type framingLinesWidget struct{}
+
+func (framingLinesWidget) draw(f *Frame, _ []float64, _ time.Duration) { f.drawFramingLines() }
+func (framingLinesWidget) rows(*Frame) rowSpan                         { return rowSpan{} }
+
+type particlesWidget struct{}
+
+func (particlesWidget) draw(f *Frame, barHeights []float64, _ time.Duration) {
+	if f.particles != nil {
+		// frameIndex has already moved on to the next frame.
+		f.particles.step(f.frameIndex-1, f.level, barHeights)
+		f.particles.draw(f)
+	}
+}
+
+func (particlesWidget) rows(f *Frame) rowSpan {
+	if f.particles != nil {
+		return f.particles.drawn
+	}
+	return rowSpan{}
+}
+
+type titleWidget struct{}
+
+func (titleWidget) draw(f *Frame, _ []float64, _ time.Duration) { f.applyTextOverlay() }
+func (titleWidget) rows(*Frame) rowSpan                         { return rowSpan{} }
+
+// captionsWidget draws a clip's quote when the layout anchors it in the
+// frame; anchored above, Clip draws it on the canvas instead.
+type captionsWidget struct{}
+
+func (captionsWidget) draw(f *Frame, _ []float64, _ time.Duration) {
+	if f.captions != nil {
+		f.captions.drawOnto(f.img, 1)
+	}
+}
+
+func (captionsWidget) rows(*Frame) rowSpan { return rowSpan{} }
+
+type badgeWidget struct{}
+
+func (badgeWidget) draw(f *Frame, _ []float64, _ time.Duration) { f.drawBadge() }
+
+func (badgeWidget) rows(f *Frame) rowSpan {
+	if f.badgePulse {
+		return f.badgeRows()
+	}
+	return rowSpan{}
+}
+
+type meterWidget struct{}
+
+func (meterWidget) draw(f *Frame, _ []float64, _ time.Duration) {
+	if f.meter != nil {
+		f.meter.draw(f.img)
+	}
+}
+
+func (meterWidget) rows(f *Frame) rowSpan {
+	if f.meter != nil {
+		return f.meter.rows()
+	}
+	return rowSpan{}
+}
+
+type timecodeWidget struct{}
+
+func (timecodeWidget) draw(f *Frame, _ []float64, _ time.Duration) {
+	if f.timecode != nil {
+		// frameIndex has already moved on to the next frame.
+		f.timecode.draw(f.img, f.frameIndex-1)
+	}
+}
+
+func (timecodeWidget) rows(f *Frame) rowSpan {
+	if f.timecode != nil {
+		return f.timecode.rows()
+	}
+	return rowSpan{}
+}
+
+type scriptWidget struct{}
+
+func (scriptWidget) draw(f *Frame, barHeights []float64, t time.Duration) {
+	if f.overlay != nil {
+		f.overlay.Process(barHeights, t)
+		f.overlay.Draw(f.img)
+	}
+}
+
+func (scriptWidget) rows(f *Frame) rowSpan {
+	if f.overlay != nil {
+		return visualizerRows(f.overlay)
+	}
+	return rowSpan{}
+}
+
+// progressWidget fills a bar across the frame, from the left, as the
+// episode plays: frame i of n fills (i+1)/n of the width. It draws nothing
+// until SetTotalFrames gives n.
+type progressWidget struct {
+	y int // Top row of the bar
+}
+
+func (w progressWidget) draw(f *Frame, _ []float64, _ time.Duration) {
+	if f.totalFrames <= 0 {
+		return
+	}
+	// frameIndex has already moved on to the next frame.
+	width := min(f.frameIndex*config.Width/f.totalFrames, config.Width)
+	textR, textG, textB := f.textColor[0], f.textColor[1], f.textColor[2]
+	for y := w.y; y < w.y+config.ProgressBarHeight; y++ {
+		row := f.img.Pix[y*f.img.Stride : y*f.img.Stride+width*4]
+		for x := 0; x < len(row); x += 4 {
+			row[x], row[x+1], row[x+2], row[x+3] = textR, textG, textB, 255
+		}
+	}
+}
+
+func (w progressWidget) rows(f *Frame) rowSpan {
+	if f.totalFrames <= 0 {
+		return rowSpan{}
+	}
+	return newRowSpan(w.y, w.y+config.ProgressBarHeight)
+}
+
+// SetTotalFrames sets the frames in the whole episode, which the progress
+// widget fills towards.
+func (f *Frame) SetTotalFrames(n int) {
+	f.totalFrames = n
+}
@@ -0,0 +1,70 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+)
+
+// TestLayoutOrder verifies a frame draws the widgets of its layout in order
+// and leaves out those it does not list: here the title.
+func TestLayoutOrder(t *testing.T) {
+	layout, err := config.ParseLayout([]config.LayoutWidget{
+		{Widget: config.WidgetProgress, Anchor: "top", Z: 1},
+		{Widget: config.WidgetFramingLines},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := LoadTitleFont("Layout Test", &config.RuntimeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := &config.RuntimeConfig{Layout: layout, TextColor: config.OptionalColor{R: 10, G: 20, B: 30, Set: true}}
+	f := NewFrame(nil, face, PodcastMeta{Title: "Layout Test"}, rc)
+	if len(f.widgets) != 2 {
+		t.Fatalf("%d widgets, want 2", len(f.widgets))
+	}
+	if _, ok := f.widgets[1].(progressWidget); !ok {
+		t.Errorf("widgets %T, want the progress bar last", f.widgets)
+	}
+	f.Draw(make([]float64, config.NumBars))
+	for x := range config.Width {
+		for y := config.Height/2 - config.CenterGap/2 + config.FramingLineHeight; y < config.Height/2+config.CenterGap/2; y++ {
+			if c := f.img.RGBAAt(x, y); c.R != 0 || c.G != 0 || c.B != 0 {
+				t.Fatalf("pixel (%d, %d) in the centre gap = %v, want no title", x, y, c)
+			}
+		}
+	}
+}
+
+// TestProgressWidget verifies the progress bar fills its share of the width
+// frame by frame, from SetTotalFrames and the frame index, and reports its
+// rows as changing.
+func TestProgressWidget(t *testing.T) {
+	rc := &config.RuntimeConfig{Layout: []config.LayoutWidget{{Widget: config.WidgetProgress, Anchor: config.AnchorBottom}}}
+	f := NewFrame(nil, nil, PodcastMeta{}, rc)
+	bars := make([]float64, config.NumBars)
+	f.Draw(bars)
+	if start, end := f.DirtyRows(); start != 0 || end != config.Height {
+		t.Errorf("first frame dirty rows [%d, %d), want the whole frame", start, end)
+	}
+	f.Draw(bars)
+	if start, end := f.DirtyRows(); start != end {
+		t.Errorf("dirty rows [%d, %d) without a total, want none", start, end)
+	}
+
+	f.SetTotalFrames(4)
+	f.SetFrameIndex(1)
+	f.Draw(bars)
+	y := config.Height - 1
+	if c := f.img.RGBAAt(config.Width/2-1, y); c.R != config.TextColorR {
+		t.Errorf("half-way pixel of frame 2 of 4 = %v, want the bar", c)
+	}
+	if c := f.img.RGBAAt(config.Width/2, y); c.R != 0 {
+		t.Errorf("pixel past half-way of frame 2 of 4 = %v, want it empty", c)
+	}
+	if start, end := f.DirtyRows(); start != config.Height-config.ProgressBarHeight || end != config.Height {
+		t.Errorf("dirty rows [%d, %d), want the bar's", start, end)
+	}
+}
@@ -0,0 +1,40 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// ProvenanceKey names the provenance record inside the output's tag, as the
+// one key of a JSON object, so tools can tell it from an ordinary comment.
+const ProvenanceKey = "com.jivefire.report"
+
+// Provenance is the record a render embeds in its output so a published
+// file can be traced back to the release and configuration that made it.
+type Provenance struct {
+	Version  string  `json:"version"`
+	Settings string  `json:"settings"` // SettingsHash of the render options
+	Encoder  Encoder `json:"encoder"`
+	Timings  Timings `json:"timings"`
+}
+
+// Value returns p as the tag value: {"com.jivefire.report": {...}}.
+func (p Provenance) Value() (string, error) {
+	data, err := json.Marshal(map[string]Provenance{ProvenanceKey: p})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// SettingsHash returns "sha256:" and the hex digest of settings encoded as
+// JSON, which is stable for equal values.
+func SettingsHash(settings any) (string, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,220 @@
+// Package report writes a machine-readable JSON record of a finished render,
+// carrying the figures shown in the completion summary so render performance
+// can be compared across machines and releases.
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/timing"
+)
+
+// Report is the top-level JSON document. Durations are in seconds and sizes
+// in bytes. A failed or cancelled run carries only the fields known when it
+// stopped.
+type Report struct {
+	Version   string    `json:"version"`
+	Generated time.Time `json:"generated"`
+	Status    string    `json:"status"`          // StatusCompleted, StatusFailed or StatusCancelled
+	Error     string    `json:"error,omitempty"` // Why the run did not complete
+	Host      Host      `json:"host"`
+	Input     string    `json:"input"`
+	Output    string    `json:"output"`
+	Encoder   Encoder   `json:"encoder"`
+	Video     Video     `json:"video"`
+	Audio     Audio     `json:"audio"`
+	Size      Size      `json:"size"`
+	Timings   Timings   `json:"timings"`
+	Speed     Speed     `json:"speed"`
+	Profile   Profile   `json:"audio_profile"`
+
+	FrameTimings *FrameTimings `json:"frame_timings,omitempty"`
+}
+
+// Run outcomes for Report.Status.
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Host identifies the machine the render ran on.
+type Host struct {
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	CPUs      int    `json:"cpus"`
+	GoVersion string `json:"go_version"`
+}
+
+// Encoder records the video encoder that produced the output.
+type Encoder struct {
+	Name     string `json:"name"`
+	Hardware bool   `json:"hardware"`
+	Profile  string `json:"profile,omitempty"` // --profile rate control
+	Retries  int    `json:"retries,omitempty"` // Transient send failures retried
+}
+
+// Video holds the frame counts; EstimatedFrames comes from the input metadata
+// before decoding, Frames from the finished encode.
+type Video struct {
+	Width           int `json:"width"`
+	Height          int `json:"height"`
+	FPS             int `json:"fps"`
+	Frames          int `json:"frames"`
+	EstimatedFrames int `json:"estimated_frames"`
+}
+
+// Audio holds the decoded input figures.
+type Audio struct {
+	SampleRate       int     `json:"sample_rate"`
+	Channels         int     `json:"channels"`
+	Duration         float64 `json:"duration_seconds"`
+	SamplesProcessed int64   `json:"samples_processed"`
+}
+
+// Size compares the pre-flight size estimate with the written file.
+type Size struct {
+	Estimated int64 `json:"estimated_bytes"`
+	Actual    int64 `json:"actual_bytes"`
+}
+
+// Timings is the per-stage breakdown from the completion summary. Other is
+// the unaccounted runtime or GPU pipeline time, floored at zero.
+type Timings struct {
+	Analysis      float64 `json:"analysis_seconds"`
+	Thumbnail     float64 `json:"thumbnail_seconds"`
+	Visualisation float64 `json:"visualisation_seconds"`
+	VideoEncoding float64 `json:"video_encoding_seconds"`
+	AudioEncoding float64 `json:"audio_encoding_seconds"`
+	Other         float64 `json:"other_seconds"`
+	Total         float64 `json:"total_seconds"`
+}
+
+// Speed holds throughput figures: Realtime is video seconds encoded per
+// wall-clock second, FramesPerSecond the overall encode rate.
+type Speed struct {
+	Realtime        float64 `json:"realtime"`
+	FramesPerSecond float64 `json:"frames_per_second"`
+}
+
+// Profile is the Pass 1 audio analysis, levels in dB.
+type Profile struct {
+	PeakLevel    float64 `json:"peak_db"`
+	RMSLevel     float64 `json:"rms_db"`
+	DynamicRange float64 `json:"dynamic_range_db"`
+	DCOffset     float64 `json:"dc_offset_percent"`
+	Clipped      float64 `json:"clipped_percent"`
+	OptimalScale float64 `json:"optimal_scale"`
+}
+
+// FrameTimings is the per-frame distribution of each Pass 2 stage, with the
+// frames flagged as abnormally slow.
+type FrameTimings struct {
+	Frames        int64         `json:"frames"`
+	Stages        []StageTiming `json:"stages"`
+	Total         StageTiming   `json:"total"`
+	SlowThreshold float64       `json:"slow_threshold_seconds"`
+	SlowFrames    int64         `json:"slow_frames"`
+	Slowest       []SlowFrame   `json:"slowest"`
+}
+
+// StageTiming holds one stage's per-frame percentiles and a small histogram.
+type StageTiming struct {
+	Name      string      `json:"name"`
+	P50       float64     `json:"p50_seconds"`
+	P95       float64     `json:"p95_seconds"`
+	P99       float64     `json:"p99_seconds"`
+	Max       float64     `json:"max_seconds"`
+	Histogram []Histogram `json:"histogram"`
+}
+
+// Histogram is one bar of a stage histogram: Frames took up to UpTo seconds
+// (and longer than the previous bar's edge).
+type Histogram struct {
+	UpTo   float64 `json:"up_to_seconds"`
+	Frames int64   `json:"frames"`
+}
+
+// SlowFrame is one of the slowest frames (1-based) and the stage that took
+// longest within it.
+type SlowFrame struct {
+	Frame     int     `json:"frame"`
+	Total     float64 `json:"total_seconds"`
+	Stage     string  `json:"stage"`
+	StageTime float64 `json:"stage_seconds"`
+}
+
+// NewFrameTimings converts a timing summary for the report, or returns nil
+// when no frames were timed.
+func NewFrameTimings(s *timing.Summary) *FrameTimings {
+	if s == nil {
+		return nil
+	}
+	ft := &FrameTimings{
+		Frames:        s.Frames,
+		Total:         newStageTiming(s.Total),
+		SlowThreshold: s.SlowThreshold.Seconds(),
+		SlowFrames:    s.SlowFrames,
+		Slowest:       []SlowFrame{},
+	}
+	for _, st := range s.Stages {
+		ft.Stages = append(ft.Stages, newStageTiming(st))
+	}
+	for _, f := range s.Slowest {
+		ft.Slowest = append(ft.Slowest, SlowFrame{
+			Frame:     f.Frame,
+			Total:     f.Total.Seconds(),
+			Stage:     f.Stage.String(),
+			StageTime: f.StageTime.Seconds(),
+		})
+	}
+	return ft
+}
+
+func newStageTiming(st timing.StageSummary) StageTiming {
+	t := StageTiming{
+		Name: st.Name,
+		P50:  st.P50.Seconds(),
+		P95:  st.P95.Seconds(),
+		P99:  st.P99.Seconds(),
+		Max:  st.Max.Seconds(),
+	}
+	for _, b := range st.Histogram {
+		t.Histogram = append(t.Histogram, Histogram{UpTo: b.Upper.Seconds(), Frames: b.Count})
+	}
+	return t
+}
+
+// CurrentHost describes the running machine.
+func CurrentHost() Host {
+	return Host{
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		GoVersion: runtime.Version(),
+	}
+}
+
+// NewSpeed derives the throughput of encoding frames at fps in total time.
+func NewSpeed(frames, fps int, total time.Duration) Speed {
+	if total <= 0 || fps <= 0 {
+		return Speed{}
+	}
+	videoSeconds := float64(frames) / float64(fps)
+	return Speed{
+		Realtime:        videoSeconds / total.Seconds(),
+		FramesPerSecond: float64(frames) / total.Seconds(),
+	}
+}
+
+// Write saves r as indented JSON at path.
+func Write(path string, r Report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,138 @@
+package report
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/timing"
+)
+
+func TestNewSpeed(t *testing.T) {
+	// 300 frames at 30 fps is 10s of video; encoded in 5s that is 2× realtime.
+	got := NewSpeed(300, 30, 5*time.Second)
+	if math.Abs(got.Realtime-2) > 1e-9 || math.Abs(got.FramesPerSecond-60) > 1e-9 {
+		t.Errorf("NewSpeed() = %+v, want realtime 2, 60 fps", got)
+	}
+	if got := NewSpeed(300, 30, 0); got != (Speed{}) {
+		t.Errorf("NewSpeed() with zero time = %+v, want zero", got)
+	}
+}
+
+// TestWriteRoundTrip verifies the report is written as JSON with the
+// documented field names.
+func TestWriteRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	r := Report{
+		Version: "dev",
+		Encoder: Encoder{Name: "libx264"},
+		Size:    Size{Estimated: 100, Actual: 90},
+		Timings: Timings{Total: 1.5},
+	}
+	if err := Write(path, r); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	size, _ := doc["size"].(map[string]any)
+	if size["estimated_bytes"] != float64(100) || size["actual_bytes"] != float64(90) {
+		t.Errorf("size = %v, want estimated_bytes 100 and actual_bytes 90", doc["size"])
+	}
+	timings, _ := doc["timings"].(map[string]any)
+	if timings["total_seconds"] != 1.5 {
+		t.Errorf("timings = %v, want total_seconds 1.5", doc["timings"])
+	}
+}
+
+// TestNewFrameTimings verifies stage percentiles, histogram bars and slow
+// frames carry over in seconds, and that an untimed render is omitted.
+func TestNewFrameTimings(t *testing.T) {
+	if NewFrameTimings(nil) != nil {
+		t.Error("NewFrameTimings(nil) is not nil")
+	}
+
+	var rec timing.Recorder
+	for frame := 1; frame <= 100; frame++ {
+		rec.Add(timing.StageDraw, 2*time.Millisecond)
+		encode := 2 * time.Millisecond
+		if frame == 40 {
+			encode = 50 * time.Millisecond
+		}
+		rec.Add(timing.StageEncode, encode)
+		rec.EndFrame(frame)
+	}
+
+	ft := NewFrameTimings(rec.Summary())
+	if ft.Frames != 100 || len(ft.Stages) != 4 || ft.Stages[3].Name != "encode" {
+		t.Fatalf("NewFrameTimings() = %+v, want 100 frames over fft, bin, draw and encode", ft)
+	}
+	if ft.Total.Max != 0.052 {
+		t.Errorf("total max = %v, want 0.052", ft.Total.Max)
+	}
+	var frames int64
+	for _, b := range ft.Total.Histogram {
+		frames += b.Frames
+	}
+	if frames != 100 {
+		t.Errorf("total histogram holds %d frames, want 100", frames)
+	}
+	if ft.SlowFrames != 1 || len(ft.Slowest) != 1 || ft.Slowest[0].Frame != 40 || ft.Slowest[0].Stage != "encode" {
+		t.Errorf("slow frames = %d %+v, want frame 40 in encode", ft.SlowFrames, ft.Slowest)
+	}
+
+	data, err := json.Marshal(ft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{`"p95_seconds"`, `"up_to_seconds"`, `"slow_threshold_seconds"`, `"stage_seconds"`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("JSON missing %s: %s", key, data)
+		}
+	}
+}
+
+// TestProvenanceValue verifies the provenance tag is a JSON object holding
+// the record under ProvenanceKey.
+func TestProvenanceValue(t *testing.T) {
+	want := Provenance{
+		Version:  "dev",
+		Settings: "sha256:00",
+		Encoder:  Encoder{Name: "libx264", Profile: "youtube"},
+		Timings:  Timings{Visualisation: 2, Total: 3.5},
+	}
+	value, err := want.Value()
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	if !strings.HasPrefix(value, `{"com.jivefire.report":{`) {
+		t.Errorf("Value() = %s, want an object under %q", value, ProvenanceKey)
+	}
+	var doc map[string]Provenance
+	if err := json.Unmarshal([]byte(value), &doc); err != nil || len(doc) != 1 || doc[ProvenanceKey] != want {
+		t.Errorf("Value() = %s (%v), want only %+v under %q", value, err, want, ProvenanceKey)
+	}
+}
+
+func TestSettingsHash(t *testing.T) {
+	type settings struct{ Speed float64 }
+	a, err := SettingsHash(settings{Speed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := SettingsHash(settings{Speed: 1})
+	c, _ := SettingsHash(settings{Speed: 2})
+	if a != b || a == c || !strings.HasPrefix(a, "sha256:") || len(a) != len("sha256:")+64 {
+		t.Errorf("SettingsHash() = %s, %s, %s; want equal for equal settings only", a, b, c)
+	}
+}
@@ -0,0 +1,258 @@
+// Package script runs a user-supplied Lua script once per video frame so it
+// can overlay its own animated elements on top of the rendered frame.
+//
+// The script defines a global function frame(bars, t), called with a table of
+// the bar heights in pixels (1-based, centre-out order as drawn) and the
+// frame's timestamp in seconds. It draws through the global draw table:
+//
+//	draw.rect(x, y, w, h [, colour [, alpha]])
+//	draw.line(x1, y1, x2, y2 [, colour [, width [, alpha]]])
+//	draw.text(x, y, s [, colour [, size [, alpha]]])
+//
+// Colours are hex strings such as "#F8B31D", alpha runs from 0 to 1 and text
+// is placed by its top-left corner. The globals width, height, fps and
+// num_bars describe the video, and the global table features holds the
+// frame's audio features by name: centroid (Hz), flux and zcr (0-1), and any
+// a custom build registers. Only the base, table, string and math
+// libraries are available; scripts cannot touch files or run programs.
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+	lua "github.com/yuin/gopher-lua"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// frameTimeout bounds a single frame() call, and running the script's top
+// level at load, so a runaway loop in a script stops the script instead of
+// hanging the render.
+const frameTimeout = time.Second
+
+// defaultTextSize is the draw.text point size when none is given.
+const defaultTextSize = 24
+
+// FontLoader returns a face for text at the given point size.
+type FontLoader func(size float64) (font.Face, error)
+
+// Script is a loaded per-frame script. It satisfies renderer.Visualizer, so
+// the frame renderer drives it like any other layer: Process runs frame() and
+// records its drawing, Draw replays it onto the frame.
+//
+// After a script error the script is disabled for the rest of the render and
+// Err reports what went wrong; the video carries on without the overlay.
+type Script struct {
+	state    *lua.LState
+	frameFn  lua.LValue
+	bars     *lua.LTable
+	features *lua.LTable
+	loadFont FontLoader
+	faces    map[float64]font.Face
+
+	ops    []func(img *image.RGBA)
+	raster vector.Rasterizer
+	frame  int
+	err    error
+}
+
+// Load compiles and runs the script at path, which must define frame(bars, t).
+func Load(path string, loadFont FontLoader) (*Script, error) {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	// The base library can still read and run other files.
+	for _, name := range []string{"dofile", "loadfile", "require"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	s := &Script{
+		state:    L,
+		bars:     L.CreateTable(config.NumBars, 0),
+		features: L.NewTable(),
+		loadFont: loadFont,
+		faces:    make(map[float64]font.Face),
+	}
+	L.SetGlobal("width", lua.LNumber(config.Width))
+	L.SetGlobal("height", lua.LNumber(config.Height))
+	L.SetGlobal("fps", lua.LNumber(config.FPS))
+	L.SetGlobal("num_bars", lua.LNumber(config.NumBars))
+	L.SetGlobal("features", s.features)
+	L.SetGlobal("draw", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"rect": s.luaRect,
+		"line": s.luaLine,
+		"text": s.luaText,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), frameTimeout)
+	L.SetContext(ctx)
+	err := L.DoFile(path)
+	L.RemoveContext()
+	cancel()
+	if err != nil {
+		L.Close()
+		return nil, err
+	}
+	s.frameFn = L.GetGlobal("frame")
+	if s.frameFn.Type() != lua.LTFunction {
+		L.Close()
+		return nil, errors.New("script does not define a frame(bars, t) function")
+	}
+	return s, nil
+}
+
+// Close releases the Lua state.
+func (s *Script) Close() {
+	s.state.Close()
+}
+
+// Err returns the error that disabled the script, if any.
+func (s *Script) Err() error {
+	return s.err
+}
+
+// Features sets the features table the next frame() reads.
+func (s *Script) Features(features map[string]float64) {
+	for name, v := range features {
+		s.features.RawSetString(name, lua.LNumber(v))
+	}
+}
+
+// Process calls frame(bars, t) and records the drawing it asks for.
+func (s *Script) Process(barHeights []float64, t time.Duration) {
+	s.ops = s.ops[:0]
+	if s.err != nil {
+		return
+	}
+	for i, h := range barHeights {
+		s.bars.RawSetInt(i+1, lua.LNumber(h))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), frameTimeout)
+	defer cancel()
+	s.state.SetContext(ctx)
+	err := s.state.CallByParam(lua.P{Fn: s.frameFn, NRet: 0, Protect: true}, s.bars, lua.LNumber(t.Seconds()))
+	s.state.RemoveContext()
+	if err != nil {
+		s.err = fmt.Errorf("frame %d: %w", s.frame, err)
+		s.ops = s.ops[:0]
+	}
+	s.frame++
+}
+
+// Draw replays the current frame's drawing onto img.
+func (s *Script) Draw(img *image.RGBA) {
+	for _, op := range s.ops {
+		op(img)
+	}
+}
+
+// colourArg reads an optional hex colour and alpha from the Lua stack.
+func (s *Script) colourArg(colourArg, alphaArg int) color.NRGBA {
+	r, g, b, err := config.ParseHexColor(s.state.OptString(colourArg, "#FFFFFF"))
+	if err != nil {
+		s.state.ArgError(colourArg, err.Error())
+	}
+	alpha := min(max(float64(s.state.OptNumber(alphaArg, 1)), 0), 1)
+	return color.NRGBA{R: r, G: g, B: b, A: uint8(math.Round(alpha * 255))}
+}
+
+// luaRect implements draw.rect(x, y, w, h [, colour [, alpha]]).
+func (s *Script) luaRect(L *lua.LState) int {
+	x, y := int(L.CheckNumber(1)), int(L.CheckNumber(2))
+	w, h := int(L.CheckNumber(3)), int(L.CheckNumber(4))
+	src := image.NewUniform(s.colourArg(5, 6))
+	rect := image.Rect(x, y, x+w, y+h)
+	s.ops = append(s.ops, func(img *image.RGBA) {
+		draw.Draw(img, rect, src, image.Point{}, draw.Over)
+	})
+	return 0
+}
+
+// luaLine implements draw.line(x1, y1, x2, y2 [, colour [, width [, alpha]]])
+// as an anti-aliased quad, so translucent lines blend evenly.
+func (s *Script) luaLine(L *lua.LState) int {
+	x1, y1 := float64(L.CheckNumber(1)), float64(L.CheckNumber(2))
+	x2, y2 := float64(L.CheckNumber(3)), float64(L.CheckNumber(4))
+	src := image.NewUniform(s.colourArg(5, 7))
+	width := float64(L.OptNumber(6, 1))
+	length := math.Hypot(x2-x1, y2-y1)
+	if width <= 0 || length == 0 {
+		return 0
+	}
+
+	// Offset both ends perpendicular to the line by half the width.
+	nx, ny := -(y2-y1)/length*width/2, (x2-x1)/length*width/2
+	quad := [4][2]float64{{x1 + nx, y1 + ny}, {x2 + nx, y2 + ny}, {x2 - nx, y2 - ny}, {x1 - nx, y1 - ny}}
+	bounds := image.Rect(
+		int(math.Floor(min(quad[0][0], quad[1][0], quad[2][0], quad[3][0]))),
+		int(math.Floor(min(quad[0][1], quad[1][1], quad[2][1], quad[3][1]))),
+		int(math.Ceil(max(quad[0][0], quad[1][0], quad[2][0], quad[3][0]))),
+		int(math.Ceil(max(quad[0][1], quad[1][1], quad[2][1], quad[3][1]))),
+	)
+
+	s.ops = append(s.ops, func(img *image.RGBA) {
+		clip := bounds.Intersect(img.Bounds())
+		if clip.Empty() {
+			return
+		}
+		// Rasterise only the clipped bounding box, in its own coordinates.
+		z := &s.raster
+		z.Reset(clip.Dx(), clip.Dy())
+		z.DrawOp = draw.Over
+		ox, oy := float32(clip.Min.X), float32(clip.Min.Y)
+		z.MoveTo(float32(quad[0][0])-ox, float32(quad[0][1])-oy)
+		for _, p := range quad[1:] {
+			z.LineTo(float32(p[0])-ox, float32(p[1])-oy)
+		}
+		z.ClosePath()
+		z.Draw(img, clip, src, image.Point{})
+	})
+	return 0
+}
+
+// luaText implements draw.text(x, y, s [, colour [, size [, alpha]]]).
+func (s *Script) luaText(L *lua.LState) int {
+	x, y := int(L.CheckNumber(1)), int(L.CheckNumber(2))
+	text := L.CheckString(3)
+	src := image.NewUniform(s.colourArg(4, 6))
+	size := float64(L.OptNumber(5, defaultTextSize))
+	if size <= 0 {
+		L.ArgError(5, "size must be positive")
+	}
+
+	face, ok := s.faces[size]
+	if !ok {
+		var err error
+		if face, err = s.loadFont(size); err != nil {
+			L.RaiseError("loading font: %v", err)
+		}
+		s.faces[size] = face
+	}
+
+	s.ops = append(s.ops, func(img *image.RGBA) {
+		d := font.Drawer{Dst: img, Src: src, Face: face, Dot: fixed.P(x, y+face.Metrics().Ascent.Ceil())}
+		d.DrawString(text)
+	})
+	return 0
+}
@@ -0,0 +1,171 @@
+package script
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/config"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+// basicFont loads the fixed 7x13 face whatever size is asked for.
+func basicFont(float64) (font.Face, error) {
+	return basicfont.Face7x13, nil
+}
+
+// loadScript writes src to a temporary .lua file and loads it.
+func loadScript(t *testing.T, src string) *Script {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "custom.lua")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path, basicFont)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// runFrame processes and draws one frame onto a black canvas.
+func runFrame(s *Script, bars []float64, t time.Duration) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	s.Process(bars, t)
+	s.Draw(img)
+	return img
+}
+
+// TestScriptDraws verifies frame() sees the bars and timestamp and its
+// rectangles, lines and text land on the frame, blended by alpha.
+func TestScriptDraws(t *testing.T) {
+	s := loadScript(t, `
+function frame(bars, t)
+  if t < 1 then return end
+  draw.rect(10, 10, bars[1], 5, "#FF0000")
+  draw.rect(100, 10, 10, 10, "#FFFFFF", 0.5)
+  draw.line(0, 100, width, 100, "00FF00", 3)
+  draw.text(200, 200, "Hi " .. num_bars, "#0000FF", 18)
+end`)
+
+	bars := make([]float64, config.NumBars)
+	bars[0] = 20
+
+	if img := runFrame(s, bars, 0); img.RGBAAt(10, 10) != (color.RGBA{A: 255}) {
+		t.Error("script drew before t = 1s")
+	}
+
+	img := runFrame(s, bars, 2*time.Second)
+	if err := s.Err(); err != nil {
+		t.Fatalf("script error: %v", err)
+	}
+	red := color.RGBA{R: 255, A: 255}
+	if got := img.RGBAAt(29, 14); got != red {
+		t.Errorf("pixel inside bars[1]-wide rect = %v, want %v", got, red)
+	}
+	if got := img.RGBAAt(30, 14); got == red {
+		t.Error("rect wider than bars[1]")
+	}
+	if got := img.RGBAAt(105, 15); got.R < 120 || got.R > 135 {
+		t.Errorf("half-alpha white over black = %v, want about 128 grey", got)
+	}
+	if got := img.RGBAAt(640, 100); got.G != 255 {
+		t.Errorf("pixel on line = %v, want green", got)
+	}
+	if got := img.RGBAAt(640, 103); got.G != 0 {
+		t.Errorf("pixel below 3px line = %v, want untouched", got)
+	}
+
+	var blue int
+	for y := 200; y < 215; y++ {
+		for x := 200; x < 250; x++ {
+			if img.RGBAAt(x, y).B > 128 {
+				blue++
+			}
+		}
+	}
+	if blue == 0 {
+		t.Error("text not drawn")
+	}
+}
+
+// TestScriptErrorDisables verifies a runtime error stops the script, is
+// reported with its frame, and leaves later frames untouched.
+func TestScriptErrorDisables(t *testing.T) {
+	s := loadScript(t, `
+function frame(bars, t)
+  draw.rect(0, 0, 10, 10)
+  if t > 0 then error("boom") end
+end`)
+	bars := make([]float64, config.NumBars)
+
+	runFrame(s, bars, 0)
+	runFrame(s, bars, time.Second/config.FPS)
+	if err := s.Err(); err == nil || !strings.Contains(err.Error(), "frame 1") || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Err() = %v, want the frame 1 error", err)
+	}
+	if img := runFrame(s, bars, 0); img.RGBAAt(0, 0) != (color.RGBA{A: 255}) {
+		t.Error("disabled script still drew")
+	}
+}
+
+// TestScriptFeatures verifies frame() reads the features table set before
+// it runs.
+func TestScriptFeatures(t *testing.T) {
+	s := loadScript(t, `
+function frame(bars, t)
+  if features.centroid > 1000 then draw.rect(0, 0, 10, 10, "#FFFFFF") end
+end`)
+	bars := make([]float64, config.NumBars)
+
+	s.Features(map[string]float64{"centroid": 440})
+	if img := runFrame(s, bars, 0); img.RGBAAt(0, 0) != (color.RGBA{A: 255}) || s.Err() != nil {
+		t.Errorf("low centroid drew or failed: %v", s.Err())
+	}
+	s.Features(map[string]float64{"centroid": 3000})
+	if img := runFrame(s, bars, 0); img.RGBAAt(0, 0) != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("high centroid did not draw: %v", s.Err())
+	}
+}
+
+// TestScriptTimeout verifies a frame() that never returns is stopped.
+func TestScriptTimeout(t *testing.T) {
+	s := loadScript(t, `function frame(bars, t) while true do end end`)
+	runFrame(s, make([]float64, config.NumBars), 0)
+	if s.Err() == nil {
+		t.Error("runaway frame() not stopped")
+	}
+}
+
+// TestLoadRejects verifies scripts without frame(), scripts reaching for
+// files or the OS, and scripts whose top level never returns fail to load.
+func TestLoadRejects(t *testing.T) {
+	for name, src := range map[string]string{
+		"no frame":  `x = 1`,
+		"syntax":    `function frame(`,
+		"os access": `os.execute("true") function frame() end`,
+		"io access": `io.open("/etc/passwd") function frame() end`,
+		"dofile":    `dofile("/etc/passwd") function frame() end`,
+		"runaway":   `while true do end function frame() end`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bad.lua")
+			if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if s, err := Load(path, basicFont); err == nil {
+				s.Close()
+				t.Error("Load() succeeded, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Package subtitles reads SRT and WebVTT transcripts, for the quote text of
+// `jivefire clip`.
+//
+// Both formats are blocks separated by blank lines; a block with a timing
+// line ("00:01:02,500 --> 00:01:05,000", or with a full stop before the
+// milliseconds in WebVTT) is a cue, and the lines after it are its text.
+// Cue numbers, the WEBVTT header, NOTE, STYLE and REGION blocks, cue
+// settings and inline tags such as <i> or <v Speaker> are ignored.
+package subtitles
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/chapters"
+)
+
+// Cue is a caption shown from Start to End.
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// Load reads and parses a subtitles file.
+func Load(path string) ([]Cue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open subtitles: %w", err)
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// tags matches inline markup: <i>, </b>, <v Speaker>, <00:01.500> karaoke
+// timestamps and SRT's <font color=...>.
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+// Parse reads SRT or WebVTT cues from r.
+func Parse(r io.Reader) ([]Cue, error) {
+	var cues []Cue
+	var cue *Cue
+	var text []string
+	flush := func() {
+		if cue != nil {
+			cue.Text = cleanText(text)
+			if cue.Text != "" {
+				cues = append(cues, *cue)
+			}
+		}
+		cue, text = nil, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		// Editors on Windows start the file with a byte order mark.
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		switch {
+		case line == "":
+			flush()
+		case strings.Contains(line, "-->"):
+			flush()
+			start, end, err := parseTiming(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			cue = &Cue{Start: start, End: end}
+		case cue != nil:
+			text = append(text, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("no cues found")
+	}
+	return cues, nil
+}
+
+// parseTiming reads a "start --> end [settings]" line.
+func parseTiming(line string) (start, end time.Duration, err error) {
+	from, to, _ := strings.Cut(line, "-->")
+	fields := strings.Fields(to)
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("cue timing %q has no end", line)
+	}
+	if start, err = parseTimestamp(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parseTimestamp(fields[0]); err != nil {
+		return 0, 0, err
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("cue ends before it starts: %q", line)
+	}
+	return start, end, nil
+}
+
+// parseTimestamp reads an [HH:]MM:SS timestamp with a comma (SRT) or full
+// stop (WebVTT) before the milliseconds.
+func parseTimestamp(s string) (time.Duration, error) {
+	return chapters.ParseTimestamp(strings.Replace(strings.TrimSpace(s), ",", ".", 1))
+}
+
+// cleanText joins a cue's lines into one, without markup.
+func cleanText(lines []string) string {
+	joined := html.UnescapeString(tags.ReplaceAllString(strings.Join(lines, " "), ""))
+	return strings.Join(strings.Fields(joined), " ")
+}
+
+// Text returns the words spoken in the span from start to end: the text of
+// every cue whose midpoint falls within it, so a cue straddling an edge goes
+// with whichever side holds most of it. A zero end runs to the last cue.
+func Text(cues []Cue, start, end time.Duration) string {
+	var words []string
+	for _, c := range cues {
+		mid := c.Start + (c.End-c.Start)/2
+		if mid >= start && (end == 0 || mid < end) {
+			words = append(words, c.Text)
+		}
+	}
+	return strings.Join(words, " ")
+}
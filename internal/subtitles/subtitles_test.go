@@ -0,0 +1,95 @@
+package subtitles
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const srt = `1
+00:12:00,000 --> 00:12:04,500
+<i>Linux is only free</i>
+if your time has no value.
+
+2
+00:12:04,500 --> 00:12:09,000
+- That's the joke &amp; the point.
+
+3
+00:12:44,000 --> 00:12:50,000
+Moving on.
+`
+
+const vtt = `WEBVTT
+
+NOTE Exported from the edit
+
+1
+12:00.000 --> 12:04.500 align:start position:10%
+<v Martin>Linux is only free
+if your time has no value.</v>
+
+12:04.500 --> 12:09.000
+That's the joke.
+`
+
+func TestParse(t *testing.T) {
+	cues, err := Parse(strings.NewReader(srt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cues) != 3 {
+		t.Fatalf("%d SRT cues, want 3", len(cues))
+	}
+	want := Cue{Start: 12 * time.Minute, End: 12*time.Minute + 4500*time.Millisecond, Text: "Linux is only free if your time has no value."}
+	if cues[0] != want {
+		t.Errorf("first SRT cue = %+v, want %+v", cues[0], want)
+	}
+	if cues[1].Text != "- That's the joke & the point." {
+		t.Errorf("second SRT cue text %q", cues[1].Text)
+	}
+
+	cues, err = Parse(strings.NewReader(vtt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cues) != 2 || cues[0] != want {
+		t.Fatalf("WebVTT cues = %+v", cues)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, doc := range []string{
+		"",
+		"WEBVTT\n\nNOTE nothing here\n",
+		"1\n00:00:05,000 --> 00:00:01,000\nBackwards\n",
+		"1\n00:00:01,000 -->\nNo end\n",
+		"1\nsoon --> later\nNot a time\n",
+	} {
+		if _, err := Parse(strings.NewReader(doc)); err == nil {
+			t.Errorf("Parse(%q) succeeded", doc)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	cues, err := Parse(strings.NewReader(srt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		start, end time.Duration
+		want       string
+	}{
+		{12 * time.Minute, 12*time.Minute + 45*time.Second, "Linux is only free if your time has no value. - That's the joke & the point."},
+		// The second cue's midpoint, 12:06.75, is past the end.
+		{12 * time.Minute, 12*time.Minute + 6*time.Second, "Linux is only free if your time has no value."},
+		{12*time.Minute + 40*time.Second, 0, "Moving on."},
+		{time.Minute, 2 * time.Minute, ""},
+	}
+	for _, tt := range tests {
+		if got := Text(cues, tt.start, tt.end); got != tt.want {
+			t.Errorf("Text(%v, %v) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Package timing records how long each Pass 2 pipeline stage takes per frame
+// and summarises the distribution, so a stutter shows up as a slow percentile
+// or a flagged frame instead of vanishing into the stage totals.
+package timing
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Stage is one timed step of rendering a frame.
+type Stage int
+
+const (
+	StageFFT    Stage = iota // FFT of the audio chunk
+	StageBin                 // Binning, sensitivity, spring dynamics and rearranging
+	StageDraw                // Drawing the frame
+	StageEncode              // Video encoding and image sequence writes
+	numStages
+)
+
+var stageNames = [numStages]string{"fft", "bin", "draw", "encode"}
+
+// String returns the stage's lower-case name, as used in the run report.
+func (s Stage) String() string {
+	if s < 0 || s >= numStages {
+		return "unknown"
+	}
+	return stageNames[s]
+}
+
+// Histogram buckets grow by 2^(1/8) (about 9%) from 1µs, covering up to
+// 2^24µs (about 17s) before the last bucket catches everything slower. Memory
+// stays fixed however long the episode, and percentiles are accurate to
+// within one bucket.
+const (
+	bucketsPerOctave = 8
+	numBuckets       = 24*bucketsPerOctave + 1
+	bucketBase       = time.Microsecond
+)
+
+// bucketFor returns the histogram bucket holding d.
+func bucketFor(d time.Duration) int {
+	if d <= bucketBase {
+		return 0
+	}
+	i := int(math.Log2(float64(d)/float64(bucketBase)) * bucketsPerOctave)
+	return min(i, numBuckets-1)
+}
+
+// bucketUpper returns the exclusive upper edge of bucket i.
+func bucketUpper(i int) time.Duration {
+	return time.Duration(float64(bucketBase) * math.Exp2(float64(i+1)/bucketsPerOctave))
+}
+
+// Histogram counts durations in logarithmic buckets.
+type Histogram struct {
+	counts [numBuckets]int64
+	n      int64
+	max    time.Duration
+}
+
+// Add records one duration.
+func (h *Histogram) Add(d time.Duration) {
+	h.counts[bucketFor(d)]++
+	h.n++
+	h.max = max(h.max, d)
+}
+
+// Count returns the number of durations recorded.
+func (h *Histogram) Count() int64 {
+	return h.n
+}
+
+// Max returns the longest duration recorded.
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+// Percentile returns the duration below which fraction p (0-1) of the
+// recorded durations fall, as the upper edge of the bucket holding it,
+// capped at the maximum seen.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.n == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p * float64(h.n)))
+	rank = max(rank, 1)
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			return min(bucketUpper(i), h.max)
+		}
+	}
+	return h.max
+}
+
+// countFrom returns how many durations fall in bucket i or above.
+func (h *Histogram) countFrom(i int) int64 {
+	var n int64
+	for _, c := range h.counts[i:] {
+		n += c
+	}
+	return n
+}
+
+// Bucket is one bar of a summarised histogram: Count durations up to Upper.
+type Bucket struct {
+	Upper time.Duration
+	Count int64
+}
+
+// Compact merges the occupied range of buckets into at most n bars of equal
+// logarithmic width, small enough to draw in the summary.
+func (h *Histogram) Compact(n int) []Bucket {
+	if h.n == 0 || n <= 0 {
+		return nil
+	}
+	lo, hi := 0, numBuckets-1
+	for h.counts[lo] == 0 {
+		lo++
+	}
+	for h.counts[hi] == 0 {
+		hi--
+	}
+
+	width := (hi - lo + n) / n // ceil((hi-lo+1)/n)
+	var bars []Bucket
+	for start := lo; start <= hi; start += width {
+		end := min(start+width, hi+1)
+		var b Bucket
+		for i := start; i < end; i++ {
+			b.Count += h.counts[i]
+		}
+		b.Upper = min(bucketUpper(end-1), h.max)
+		bars = append(bars, b)
+	}
+	return bars
+}
+
+// Slow-frame detection: a frame is flagged when its total time is more than
+// slowFactor times the median, and at least slowFloor, so sub-millisecond
+// jitter on a fast machine is not reported as a stutter.
+const (
+	slowFactor    = 4
+	slowFloor     = 5 * time.Millisecond
+	maxSlowFrames = 5
+)
+
+// HistogramWidth is the number of bars in each summarised histogram.
+const HistogramWidth = 12
+
+// SlowFrame describes one of the slowest frames and the stage that took
+// longest within it.
+type SlowFrame struct {
+	Frame     int // 1-based, matching --frames-dir numbering
+	Total     time.Duration
+	Stage     Stage
+	StageTime time.Duration
+}
+
+// Recorder collects per-frame stage timings. Add accumulates the current
+// frame's stages; EndFrame commits them. It is not safe for concurrent use;
+// the render loop owns it.
+type Recorder struct {
+	stages  [numStages]Histogram
+	total   Histogram
+	current [numStages]time.Duration
+	slowest []SlowFrame // the slowest frames seen, longest first
+}
+
+// Add adds d to the current frame's time for stage s.
+func (r *Recorder) Add(s Stage, d time.Duration) {
+	r.current[s] += d
+}
+
+// EndFrame records the current frame's stage times under the given 1-based
+// frame number and starts the next frame.
+func (r *Recorder) EndFrame(frame int) {
+	var total time.Duration
+	slow := SlowFrame{Frame: frame}
+	for s, d := range r.current {
+		r.stages[s].Add(d)
+		total += d
+		if d > slow.StageTime {
+			slow.Stage, slow.StageTime = Stage(s), d
+		}
+	}
+	r.total.Add(total)
+	slow.Total = total
+	r.current = [numStages]time.Duration{}
+
+	if len(r.slowest) == maxSlowFrames && total <= r.slowest[maxSlowFrames-1].Total {
+		return
+	}
+	i := sort.Search(len(r.slowest), func(i int) bool { return r.slowest[i].Total < total })
+	r.slowest = append(r.slowest, SlowFrame{})
+	copy(r.slowest[i+1:], r.slowest[i:])
+	r.slowest[i] = slow
+	if len(r.slowest) > maxSlowFrames {
+		r.slowest = r.slowest[:maxSlowFrames]
+	}
+}
+
+// StageSummary is the distribution of one stage's per-frame time.
+type StageSummary struct {
+	Name          string
+	P50, P95, P99 time.Duration
+	Max           time.Duration
+	Histogram     []Bucket
+}
+
+// Summary is the per-frame timing report for a render.
+type Summary struct {
+	Frames        int64
+	Stages        []StageSummary // fft, bin, draw, encode
+	Total         StageSummary   // whole frame
+	SlowThreshold time.Duration  // frames slower than this are flagged
+	SlowFrames    int64          // how many frames were flagged
+	Slowest       []SlowFrame    // the worst flagged frames, longest first
+}
+
+// Summary returns the distribution of each stage and the frames flagged as
+// abnormally slow. A recorder with no frames returns nil.
+func (r *Recorder) Summary() *Summary {
+	if r.total.Count() == 0 {
+		return nil
+	}
+
+	s := &Summary{
+		Frames: r.total.Count(),
+		Total:  summarise("total", &r.total),
+	}
+	for st := range numStages {
+		s.Stages = append(s.Stages, summarise(st.String(), &r.stages[st]))
+	}
+
+	// Only individual frames are kept for the slowest few, so the count comes
+	// from the histogram: the threshold is rounded up to the next bucket edge
+	// and everything from there up is flagged.
+	first := bucketFor(max(slowFactor*s.Total.P50, slowFloor)) + 1
+	s.SlowThreshold = bucketUpper(first - 1)
+	s.SlowFrames = r.total.countFrom(min(first, numBuckets-1))
+	for _, f := range r.slowest {
+		if bucketFor(f.Total) >= first {
+			s.Slowest = append(s.Slowest, f)
+		}
+	}
+	return s
+}
+
+func summarise(name string, h *Histogram) StageSummary {
+	return StageSummary{
+		Name:      name,
+		P50:       h.Percentile(0.50),
+		P95:       h.Percentile(0.95),
+		P99:       h.Percentile(0.99),
+		Max:       h.Max(),
+		Histogram: h.Compact(HistogramWidth),
+	}
+}
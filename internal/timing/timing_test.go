@@ -0,0 +1,136 @@
+package timing
+
+import (
+	"testing"
+	"time"
+)
+
+// within reports whether got is no more than one bucket (about 9%) away
+// from want.
+func within(got, want time.Duration) bool {
+	return float64(got) >= float64(want)*0.9 && float64(got) <= float64(want)*1.1
+}
+
+// TestHistogramPercentiles verifies percentiles land within a bucket of the
+// true value, the 100th is the exact maximum, and every sample is counted.
+func TestHistogramPercentiles(t *testing.T) {
+	var h Histogram
+	for i := 1; i <= 1000; i++ {
+		h.Add(time.Duration(i) * time.Microsecond)
+	}
+
+	for _, tt := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0.50, 500 * time.Microsecond},
+		{0.95, 950 * time.Microsecond},
+		{0.99, 990 * time.Microsecond},
+	} {
+		if got := h.Percentile(tt.p); !within(got, tt.want) {
+			t.Errorf("Percentile(%.2f) = %v, want about %v", tt.p, got, tt.want)
+		}
+	}
+	if got := h.Percentile(1); got != time.Millisecond {
+		t.Errorf("Percentile(1) = %v, want the maximum 1ms", got)
+	}
+	if h.Count() != 1000 {
+		t.Errorf("Count() = %d, want 1000", h.Count())
+	}
+}
+
+// TestHistogramCompact verifies the occupied range is merged into at most the
+// requested number of bars without losing any counts.
+func TestHistogramCompact(t *testing.T) {
+	var h Histogram
+	for i := 1; i <= 200; i++ {
+		h.Add(time.Duration(i) * 50 * time.Microsecond)
+	}
+
+	bars := h.Compact(HistogramWidth)
+	if len(bars) == 0 || len(bars) > HistogramWidth {
+		t.Fatalf("Compact() returned %d bars, want 1-%d", len(bars), HistogramWidth)
+	}
+	var total int64
+	for i, b := range bars {
+		total += b.Count
+		if i > 0 && b.Upper <= bars[i-1].Upper {
+			t.Errorf("bar %d edge %v not above %v", i, b.Upper, bars[i-1].Upper)
+		}
+	}
+	if total != 200 {
+		t.Errorf("bars hold %d durations, want 200", total)
+	}
+	if last := bars[len(bars)-1].Upper; last != 10*time.Millisecond {
+		t.Errorf("last edge = %v, want the 10ms maximum", last)
+	}
+
+	var empty Histogram
+	if bars := empty.Compact(HistogramWidth); bars != nil {
+		t.Errorf("empty Compact() = %v, want nil", bars)
+	}
+}
+
+// TestRecorderFlagsSlowFrames verifies per-stage distributions and that a
+// frame far slower than the median is flagged with the stage that caused it.
+func TestRecorderFlagsSlowFrames(t *testing.T) {
+	var r Recorder
+	for frame := range 300 {
+		r.Add(StageFFT, 200*time.Microsecond)
+		r.Add(StageBin, 100*time.Microsecond)
+		r.Add(StageDraw, 2*time.Millisecond)
+		encode := 3 * time.Millisecond
+		if frame == 150 {
+			encode = 80 * time.Millisecond
+		}
+		r.Add(StageEncode, encode)
+		r.EndFrame(frame)
+	}
+
+	s := r.Summary()
+	if s == nil {
+		t.Fatal("Summary() = nil after 300 frames")
+	}
+	if s.Frames != 300 || len(s.Stages) != int(numStages) {
+		t.Fatalf("Summary() has %d frames and %d stages, want 300 and %d", s.Frames, len(s.Stages), numStages)
+	}
+	if s.Stages[StageDraw].Name != "draw" || !within(s.Stages[StageDraw].P50, 2*time.Millisecond) {
+		t.Errorf("draw p50 = %v (%s), want about 2ms", s.Stages[StageDraw].P50, s.Stages[StageDraw].Name)
+	}
+	if !within(s.Total.P50, 5300*time.Microsecond) {
+		t.Errorf("total p50 = %v, want about 5.3ms", s.Total.P50)
+	}
+
+	if s.SlowFrames != 1 || len(s.Slowest) != 1 {
+		t.Fatalf("flagged %d frames (%d listed), want 1", s.SlowFrames, len(s.Slowest))
+	}
+	if f := s.Slowest[0]; f.Frame != 150 || f.Stage != StageEncode || f.StageTime != 80*time.Millisecond {
+		t.Errorf("slowest frame = %+v, want frame 150 in encode", f)
+	}
+	if s.SlowThreshold < 4*s.Total.P50 {
+		t.Errorf("slow threshold %v below 4× the median %v", s.SlowThreshold, s.Total.P50)
+	}
+
+	var none Recorder
+	if none.Summary() != nil {
+		t.Error("Summary() with no frames is not nil")
+	}
+}
+
+// TestRecorderKeepsSlowest verifies only the slowest frames are kept, longest
+// first.
+func TestRecorderKeepsSlowest(t *testing.T) {
+	var r Recorder
+	for frame := range 20 {
+		r.Add(StageEncode, time.Duration(frame+1)*time.Millisecond)
+		r.EndFrame(frame)
+	}
+	if len(r.slowest) != maxSlowFrames {
+		t.Fatalf("kept %d frames, want %d", len(r.slowest), maxSlowFrames)
+	}
+	for i, f := range r.slowest {
+		if want := 19 - i; f.Frame != want {
+			t.Errorf("slowest[%d] = frame %d, want %d", i, f.Frame, want)
+		}
+	}
+}
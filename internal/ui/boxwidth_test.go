@@ -46,14 +46,17 @@ func boxWidthFixture(t *testing.T, width int) *Model {
 		bars[i] = 0.5
 	}
 	m.renderState = RenderProgress{
-		Frame:       250,
-		TotalFrames: 1000,
-		FileSize:    12345678,
-		VideoCodec:  "H.264 1920×1080",
-		AudioCodec:  "AAC 44.1㎑ stereo",
-		EncoderName: "h264_vaapi",
-		BarHeights:  bars,
-		FrameData:   image.NewRGBA(image.Rect(0, 0, 1920, 1080)),
+		Frame:         250,
+		TotalFrames:   1000,
+		FileSize:      12345678,
+		VideoCodec:    "H.264 1920×1080",
+		AudioCodec:    "AAC 44.1㎑ stereo",
+		EncoderName:   "h264_vaapi",
+		BarHeights:    bars,
+		FrameData:     image.NewRGBA(image.Rect(0, 0, 1920, 1080)),
+		QueueDepth:    8,
+		QueueCapacity: 8,
+		Encoder:       EncoderStats{Bitrate: 12_345_678, QP: 51, I: 12_345, P: 123_456, B: 123_456},
 	}
 	for i := range m.spectrumPos {
 		m.spectrumPos[i] = 0.5
@@ -110,6 +113,7 @@ func TestBoxesShareOuterWidth(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			m := boxWidthFixture(t, tc.width)
+			m.encoderShown = true
 			boxes := renderAllBoxes(m)
 
 			for name, box := range boxes {
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CancelMode records how the user asked to stop an encode.
+type CancelMode int32
+
+const (
+	CancelNone     CancelMode = iota // Keep encoding
+	CancelFinalise                   // Stop and finalise the partial output
+	CancelDiscard                    // Stop and delete the output
+)
+
+// Controls carries the interactive key controls from the UI to the render
+// loop. The model sets them from the Bubbletea goroutine and the render loop
+// polls them between frames, so every method is safe for concurrent use.
+type Controls struct {
+	mu     sync.Mutex
+	resume chan struct{} // non-nil while paused; closed to resume
+
+	cancel  atomic.Int32
+	preview atomic.Bool
+}
+
+// NewControls returns controls for a running encode with the preview enabled.
+func NewControls() *Controls {
+	c := &Controls{}
+	c.preview.Store(true)
+	return c
+}
+
+// SetPaused pauses or resumes the render loop at its next frame boundary.
+func (c *Controls) SetPaused(paused bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case paused && c.resume == nil:
+		c.resume = make(chan struct{})
+	case !paused && c.resume != nil:
+		close(c.resume)
+		c.resume = nil
+	}
+}
+
+// Paused reports whether the render loop has been asked to pause.
+func (c *Controls) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resume != nil
+}
+
+// WaitWhilePaused blocks the render loop until it is resumed or cancelled,
+// returning how long it waited so paused time can be left out of timings.
+func (c *Controls) WaitWhilePaused() time.Duration {
+	c.mu.Lock()
+	resume := c.resume
+	c.mu.Unlock()
+	if resume == nil {
+		return 0
+	}
+	start := time.Now()
+	<-resume
+	return time.Since(start)
+}
+
+// Cancel asks the render loop to stop, resuming it first if paused. The first
+// request wins; a later one cannot turn a discard into a finalise.
+func (c *Controls) Cancel(mode CancelMode) {
+	c.cancel.CompareAndSwap(int32(CancelNone), int32(mode))
+	c.SetPaused(false)
+}
+
+// Cancelled returns the requested cancel mode, CancelNone while encoding should
+// carry on.
+func (c *Controls) Cancelled() CancelMode {
+	return CancelMode(c.cancel.Load())
+}
+
+// SetPreviewEnabled turns preview frames on or off; while off the render loop
+// skips copying frames for the UI.
+func (c *Controls) SetPreviewEnabled(enabled bool) {
+	c.preview.Store(enabled)
+}
+
+// PreviewEnabled reports whether the UI wants preview frames.
+func (c *Controls) PreviewEnabled() bool {
+	return c.preview.Load()
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+// TestControlsPauseResume verifies WaitWhilePaused blocks until resumed and
+// reports the time spent waiting.
+func TestControlsPauseResume(t *testing.T) {
+	c := NewControls()
+	if got := c.WaitWhilePaused(); got != 0 {
+		t.Fatalf("WaitWhilePaused() while running = %v, want 0", got)
+	}
+
+	c.SetPaused(true)
+	c.SetPaused(true) // pausing twice must not replace the resume channel
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		c.SetPaused(false)
+	}()
+	if got := c.WaitWhilePaused(); got < 20*time.Millisecond {
+		t.Errorf("WaitWhilePaused() = %v, want at least 20ms", got)
+	}
+	if c.Paused() {
+		t.Error("Paused() still true after resume")
+	}
+}
+
+// TestControlsCancel verifies cancelling resumes a paused loop and the first
+// cancel mode sticks.
+func TestControlsCancel(t *testing.T) {
+	c := NewControls()
+	c.SetPaused(true)
+	c.Cancel(CancelDiscard)
+	if c.Paused() {
+		t.Error("Cancel() left the loop paused")
+	}
+	c.Cancel(CancelFinalise)
+	if got := c.Cancelled(); got != CancelDiscard {
+		t.Errorf("Cancelled() = %v, want CancelDiscard", got)
+	}
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/locale"
+	"github.com/linuxmatters/jivefire/internal/theme"
+	"github.com/linuxmatters/jivefire/internal/timing"
+)
+
+// histogramBars draws each histogram bar as a block scaled to the fullest
+// bar; empty bars are left blank so gaps in the distribution stay visible.
+// Unlike sparkline the scale starts at zero, as the counts are absolute.
+func histogramBars(bars []timing.Bucket) string {
+	var peak int64
+	for _, b := range bars {
+		peak = max(peak, b.Count)
+	}
+	var s strings.Builder
+	for _, b := range bars {
+		if b.Count == 0 {
+			s.WriteRune(' ')
+			continue
+		}
+		level := int(b.Count * int64(len(sparklineBlocks)-1) / peak)
+		s.WriteRune(sparklineBlocks[level])
+	}
+	return s.String()
+}
+
+// formatFrameTime renders a per-frame duration with enough precision for
+// sub-millisecond stages.
+func formatFrameTime(loc *locale.Locale, d time.Duration) string {
+	ms := float64(d) / float64(time.Millisecond)
+	switch {
+	case ms < 1:
+		return loc.Float(ms, 2) + "ms"
+	case ms < 10:
+		return loc.Float(ms, 1) + "ms"
+	}
+	return loc.Float(ms, 0) + "ms"
+}
+
+// stageLabels are the summary labels for the timing stages.
+var stageLabels = map[string]string{
+	"fft":    "FFT:",
+	"bin":    "Binning:",
+	"draw":   "Drawing:",
+	"encode": "Encoding:",
+	"total":  "Frame:",
+}
+
+// renderFrameTimings renders the per-stage percentiles with a histogram of
+// each stage's frame times, followed by any frames flagged as slow.
+func renderFrameTimings(loc *locale.Locale, t *timing.Summary) string {
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.FireOrange)
+	labelStyle := lipgloss.NewStyle().Faint(true)
+	valueStyle := lipgloss.NewStyle()
+	sparkStyle := lipgloss.NewStyle().Foreground(theme.FireYellow)
+	warnStyle := lipgloss.NewStyle().Foreground(theme.FireRed)
+
+	var s strings.Builder
+	s.WriteString(headerStyle.Render(loc.T("Frame Timings")))
+	s.WriteString("\n")
+
+	tbl := summaryTable().StyleFunc(func(row, col int) lipgloss.Style {
+		switch {
+		case col == 0:
+			return labelStyle.PaddingLeft(2).PaddingRight(2)
+		case row == 0:
+			return labelStyle.PaddingRight(2)
+		case col == 4:
+			return sparkStyle
+		default:
+			return valueStyle.PaddingRight(2)
+		}
+	})
+	tbl.Row("", "p50", "p95", "p99", "")
+	for _, st := range append(t.Stages, t.Total) {
+		tbl.Row(loc.T(stageLabels[st.Name]), formatFrameTime(loc, st.P50), formatFrameTime(loc, st.P95), formatFrameTime(loc, st.P99), histogramBars(st.Histogram))
+	}
+	s.WriteString(tbl.Render())
+	s.WriteString("\n")
+
+	if t.SlowFrames == 0 {
+		fmt.Fprintf(&s, "  %s", labelStyle.Render(loc.Sprintf("No frames slower than %s", formatFrameTime(loc, t.SlowThreshold))))
+		return s.String()
+	}
+
+	slow := "%s slow frames over %s"
+	if t.SlowFrames == 1 {
+		slow = "%s slow frame over %s"
+	}
+	fmt.Fprintf(&s, "  %s\n", warnStyle.Render(loc.Sprintf(slow, loc.Int(int64(t.SlowFrames)), formatFrameTime(loc, t.SlowThreshold))))
+	for _, f := range t.Slowest {
+		at := time.Duration(f.Frame-1) * time.Second / config.FPS
+		fmt.Fprintf(&s, "  %s %s\n",
+			labelStyle.Render(loc.Sprintf("Frame %d (%s):", f.Frame, formatClock(at))),
+			loc.Sprintf("%s, %s in %s", formatFrameTime(loc, f.Total), formatFrameTime(loc, f.StageTime), loc.T(f.Stage.String())))
+	}
+	return strings.TrimSuffix(s.String(), "\n")
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/timing"
+)
+
+// sampleFrameTimings returns timings for 3000 steady frames with one encode
+// stall at frame 1234.
+func sampleFrameTimings() *timing.Summary {
+	var r timing.Recorder
+	for frame := 1; frame <= 3000; frame++ {
+		r.Add(timing.StageFFT, 150*time.Microsecond+time.Duration(frame%7)*10*time.Microsecond)
+		r.Add(timing.StageBin, 40*time.Microsecond)
+		r.Add(timing.StageDraw, 1800*time.Microsecond+time.Duration(frame%11)*50*time.Microsecond)
+		encode := 2500 * time.Microsecond
+		if frame == 1234 {
+			encode = 90 * time.Millisecond
+		}
+		r.Add(timing.StageEncode, encode)
+		r.EndFrame(frame)
+	}
+	return r.Summary()
+}
+
+// TestRenderFrameTimings verifies the summary lists every stage with its
+// percentiles and names the stalled frame, its timestamp and the stage at
+// fault.
+func TestRenderFrameTimings(t *testing.T) {
+	out := stripStyles(renderFrameTimings(nil, sampleFrameTimings()))
+
+	for _, want := range []string{
+		"Frame Timings", "p50", "p95", "p99",
+		"FFT:", "Binning:", "Drawing:", "Encoding:", "Frame:",
+		"1 slow frame over",
+		"Frame 1234 (00:41):", "90ms in encode",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("frame timings missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestHistogramBars verifies bucket counts scale to block heights against
+// the fullest bucket, with an empty bucket left blank.
+func TestHistogramBars(t *testing.T) {
+	got := histogramBars([]timing.Bucket{{Count: 8}, {Count: 0}, {Count: 1}, {Count: 4}})
+	if got != "█ ▁▄" {
+		t.Errorf("histogramBars() = %q, want %q", got, "█ ▁▄")
+	}
+}
+
+// TestFormatFrameTime verifies frame times shed decimals as they grow: two
+// under 1ms, one under 10ms and none beyond.
+func TestFormatFrameTime(t *testing.T) {
+	for _, tt := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{420 * time.Microsecond, "0.42ms"},
+		{3140 * time.Microsecond, "3.1ms"},
+		{45 * time.Millisecond, "45ms"},
+	} {
+		if got := formatFrameTime(nil, tt.d); got != tt.want {
+			t.Errorf("formatFrameTime(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
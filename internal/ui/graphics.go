@@ -0,0 +1,255 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// GraphicsProtocol selects how the video preview is drawn in the terminal.
+type GraphicsProtocol string
+
+const (
+	GraphicsAuto   GraphicsProtocol = "auto"   // Detect from the environment
+	GraphicsBlocks GraphicsProtocol = "blocks" // 24-bit colour block characters
+	GraphicsKitty  GraphicsProtocol = "kitty"  // Kitty graphics protocol (kitty, Ghostty)
+	GraphicsITerm2 GraphicsProtocol = "iterm2" // iTerm2 inline images (iTerm2, WezTerm)
+	GraphicsSixel  GraphicsProtocol = "sixel"  // DEC Sixel (foot, mlterm, Windows Terminal, xterm -ti vt340)
+)
+
+// ParseGraphicsProtocol validates a --preview-protocol value.
+func ParseGraphicsProtocol(s string) (GraphicsProtocol, error) {
+	switch p := GraphicsProtocol(strings.ToLower(s)); p {
+	case GraphicsAuto, GraphicsBlocks, GraphicsKitty, GraphicsITerm2, GraphicsSixel:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown preview protocol %q (must be auto, blocks, kitty, iterm2 or sixel)", s)
+}
+
+// DetectGraphicsProtocol picks an image protocol from the variables terminals
+// set for themselves, falling back to block characters. Querying the terminal
+// would be more thorough but races the UI for stdin, so only the environment
+// is consulted. Windows Terminal sets WT_SESSION rather than a TERM of its
+// own, and draws Sixel from version 1.22; the older console host gets blocks.
+func DetectGraphicsProtocol(getenv func(string) string) GraphicsProtocol {
+	term := getenv("TERM")
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty", getenv("TERM_PROGRAM") == "ghostty":
+		return GraphicsKitty
+	case getenv("TERM_PROGRAM") == "iTerm.app", getenv("TERM_PROGRAM") == "WezTerm":
+		return GraphicsITerm2
+	case strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "mlterm"), strings.Contains(term, "sixel"), getenv("WT_SESSION") != "":
+		return GraphicsSixel
+	}
+	return GraphicsBlocks
+}
+
+// Cell size in pixels assumed when sizing preview images. Kitty and iTerm2
+// scale the image to the reserved cells; Sixel draws at this size.
+const (
+	graphicsCellWidth  = 8
+	graphicsCellHeight = 16
+)
+
+// kittyImageID is the fixed image id for the preview. Retransmitting under the
+// same id replaces the previous frame instead of stacking images.
+const kittyImageID = 1
+
+// kittyChunkSize is the largest base64 payload per Kitty escape sequence.
+const kittyChunkSize = 4096
+
+// graphicsPreviewImage scales frame down to the pixel size of the preview
+// area, so the escape payload stays small.
+func graphicsPreviewImage(frame *image.RGBA, config PreviewConfig) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, config.Width*graphicsCellWidth, config.Height*graphicsCellHeight))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), frame, frame.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// EncodeGraphicsFrame returns the escape sequence drawing frame at the given
+// 1-based terminal cell position in the preview's cell size, or "" for the
+// block protocol.
+func EncodeGraphicsFrame(protocol GraphicsProtocol, frame *image.RGBA, config PreviewConfig, row, col int) string {
+	img := graphicsPreviewImage(frame, config)
+
+	var seq string
+	switch protocol {
+	case GraphicsKitty:
+		seq = encodeKitty(img, config.Width, config.Height)
+	case GraphicsITerm2:
+		seq = encodeITerm2(img, config.Width, config.Height)
+	case GraphicsSixel:
+		seq = encodeSixel(img)
+	default:
+		return ""
+	}
+
+	// Save the cursor, draw at the preview origin, then restore it so the
+	// UI renderer's idea of the cursor position is undisturbed.
+	return "\x1b7\x1b[" + strconv.Itoa(row) + ";" + strconv.Itoa(col) + "H" + seq + "\x1b8"
+}
+
+// ClearGraphics returns the sequence removing the preview image, for
+// protocols whose images live outside the text cells; "" otherwise.
+func ClearGraphics(protocol GraphicsProtocol) string {
+	if protocol == GraphicsKitty {
+		return fmt.Sprintf("\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", kittyImageID)
+	}
+	return ""
+}
+
+// encodePNGBase64 returns img as base64-encoded PNG.
+func encodePNGBase64(img image.Image) string {
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	_ = enc.Encode(&buf, img) // writing to a bytes.Buffer cannot fail
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// encodeKitty transmits and places img over cols×rows cells using the Kitty
+// graphics protocol, split into chunks. C=1 leaves the cursor in place and
+// q=2 suppresses the terminal's acknowledgements, which would otherwise
+// arrive on stdin as stray input.
+func encodeKitty(img image.Image, cols, rows int) string {
+	data := encodePNGBase64(img)
+
+	var b strings.Builder
+	first := true
+	for len(data) > 0 {
+		n := min(len(data), kittyChunkSize)
+		chunk := data[:n]
+		data = data[n:]
+
+		more := 0
+		if len(data) > 0 {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(&b, "\x1b_Ga=T,f=100,i=%d,c=%d,r=%d,C=1,q=2,m=%d;%s\x1b\\", kittyImageID, cols, rows, more, chunk)
+			first = false
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return b.String()
+}
+
+// encodeITerm2 draws img over cols×rows cells as an iTerm2 inline image.
+func encodeITerm2(img image.Image, cols, rows int) string {
+	data := encodePNGBase64(img)
+	return fmt.Sprintf("\x1b]1337;File=inline=1;width=%d;height=%d;preserveAspectRatio=0:%s\a", cols, rows, data)
+}
+
+// sixelLevels is the number of levels per channel in the fixed Sixel palette
+// (6×6×6 = 216 colours, within the 256 registers common terminals provide).
+const sixelLevels = 6
+
+// encodeSixel draws img as Sixel graphics quantised to a fixed colour cube.
+// Each band of six pixel rows is emitted once per colour present in it, with
+// run-length encoding for repeated columns.
+func encodeSixel(img *image.RGBA) string {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	// Quantise every pixel to its palette index once.
+	idx := make([]uint8, w*h)
+	for y := range h {
+		row := img.Pix[y*img.Stride:]
+		for x := range w {
+			r := int(row[x*4]) * (sixelLevels - 1) / 255
+			g := int(row[x*4+1]) * (sixelLevels - 1) / 255
+			bl := int(row[x*4+2]) * (sixelLevels - 1) / 255
+			idx[y*w+x] = uint8((r*sixelLevels+g)*sixelLevels + bl) //nolint:gosec // at most 215
+		}
+	}
+
+	var b strings.Builder
+	// P2=1 leaves unset pixels transparent so each colour pass only paints its
+	// own pixels; the raster attributes set a 1:1 pixel aspect ratio.
+	fmt.Fprintf(&b, "\x1bP0;1;0q\"1;1;%d;%d", w, h)
+	for i := range sixelLevels * sixelLevels * sixelLevels {
+		r := i / (sixelLevels * sixelLevels)
+		g := i / sixelLevels % sixelLevels
+		bl := i % sixelLevels
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*100/(sixelLevels-1), g*100/(sixelLevels-1), bl*100/(sixelLevels-1))
+	}
+
+	bits := make([]byte, w)
+	for band := 0; band < h; band += 6 {
+		var present [sixelLevels * sixelLevels * sixelLevels]bool
+		for y := band; y < min(band+6, h); y++ {
+			for x := range w {
+				present[idx[y*w+x]] = true
+			}
+		}
+
+		for c, ok := range present {
+			if !ok {
+				continue
+			}
+			for x := range w {
+				var v byte
+				for dy := 0; dy < 6 && band+dy < h; dy++ {
+					if int(idx[(band+dy)*w+x]) == c {
+						v |= 1 << dy
+					}
+				}
+				bits[x] = v + '?'
+			}
+			fmt.Fprintf(&b, "#%d", c)
+			writeSixelRuns(&b, bits)
+			b.WriteByte('$')
+		}
+		b.WriteByte('-')
+	}
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+// writeSixelRuns writes sixel characters with runs of four or more collapsed
+// into the !count form.
+func writeSixelRuns(b *strings.Builder, bits []byte) {
+	for i := 0; i < len(bits); {
+		j := i
+		for j < len(bits) && bits[j] == bits[i] {
+			j++
+		}
+		if n := j - i; n >= 4 {
+			fmt.Fprintf(b, "!%d%c", n, bits[i])
+		} else {
+			for range n {
+				b.WriteByte(bits[i])
+			}
+		}
+		i = j
+	}
+}
+
+// RenderPreviewPlaceholder returns the preview border around blank cells,
+// reserving the space an image protocol draws into.
+func RenderPreviewPlaceholder(config PreviewConfig) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(previewTopBorder(config))
+	b.WriteString("\n")
+	for range config.Height {
+		b.WriteString("│")
+		b.WriteString(strings.Repeat(" ", config.Width))
+		b.WriteString("│\n")
+	}
+	b.WriteString("└")
+	b.WriteString(strings.Repeat("─", config.Width))
+	b.WriteString("┘")
+	return b.String()
+}
+
+// previewTopBorder is the preview box's top edge, also used to locate the box
+// in the rendered view.
+func previewTopBorder(config PreviewConfig) string {
+	return "┌" + strings.Repeat("─", config.Width) + "┐"
+}
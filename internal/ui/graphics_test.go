@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// TestDetectGraphicsProtocol verifies terminals are recognised from the
+// variables they export, and anything unknown falls back to blocks.
+func TestDetectGraphicsProtocol(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want GraphicsProtocol
+	}{
+		{"kitty window", map[string]string{"KITTY_WINDOW_ID": "1", "TERM": "xterm-256color"}, GraphicsKitty},
+		{"ghostty", map[string]string{"TERM": "xterm-ghostty"}, GraphicsKitty},
+		{"iterm2", map[string]string{"TERM_PROGRAM": "iTerm.app"}, GraphicsITerm2},
+		{"wezterm", map[string]string{"TERM_PROGRAM": "WezTerm"}, GraphicsITerm2},
+		{"foot", map[string]string{"TERM": "foot-extra"}, GraphicsSixel},
+		{"windows terminal", map[string]string{"WT_SESSION": "0b5b3a52-7f1e-4f0c-9d2e-1c6d0a8b9e41"}, GraphicsSixel},
+		{"plain xterm", map[string]string{"TERM": "xterm-256color"}, GraphicsBlocks},
+		{"nothing set", nil, GraphicsBlocks},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(k string) string { return tt.env[k] }
+			if got := DetectGraphicsProtocol(getenv); got != tt.want {
+				t.Errorf("DetectGraphicsProtocol() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGraphicsProtocol(t *testing.T) {
+	if got, err := ParseGraphicsProtocol("Kitty"); err != nil || got != GraphicsKitty {
+		t.Errorf("ParseGraphicsProtocol(Kitty) = %q, %v; want kitty", got, err)
+	}
+	if _, err := ParseGraphicsProtocol("vt100"); err == nil {
+		t.Error("ParseGraphicsProtocol(vt100) succeeded, want error")
+	}
+}
+
+// solidFrame returns a w×h frame filled with c.
+func solidFrame(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// TestEncodeGraphicsFrame verifies each protocol's framing: positioned between
+// cursor save and restore, chunked Kitty payloads ending with m=0, and no
+// output for blocks.
+func TestEncodeGraphicsFrame(t *testing.T) {
+	config := DefaultPreviewConfig()
+	frame := solidFrame(128, 72, color.RGBA{R: 255, A: 255})
+
+	if got := EncodeGraphicsFrame(GraphicsBlocks, frame, config, 3, 4); got != "" {
+		t.Errorf("blocks produced %d bytes, want none", len(got))
+	}
+
+	for _, p := range []GraphicsProtocol{GraphicsKitty, GraphicsITerm2, GraphicsSixel} {
+		seq := EncodeGraphicsFrame(p, frame, config, 3, 4)
+		if !strings.HasPrefix(seq, "\x1b7\x1b[3;4H") || !strings.HasSuffix(seq, "\x1b8") {
+			t.Errorf("%s: sequence not wrapped in a positioned cursor save/restore", p)
+		}
+	}
+
+	kitty := encodeKitty(frame, config.Width, config.Height)
+	if !strings.HasPrefix(kitty, "\x1b_Ga=T,f=100,") {
+		t.Errorf("kitty sequence starts %q, want a transmit-and-place command", kitty[:min(len(kitty), 20)])
+	}
+	if !strings.Contains(kitty, "m=0;") || !strings.HasSuffix(kitty, "\x1b\\") {
+		t.Error("kitty sequence missing its final chunk")
+	}
+}
+
+// TestEncodeSixel verifies the Sixel stream has one band per six pixel rows
+// and collapses a solid row into a single run per colour.
+func TestEncodeSixel(t *testing.T) {
+	img := solidFrame(40, 13, color.RGBA{B: 255, A: 255})
+	seq := encodeSixel(img)
+
+	if !strings.HasPrefix(seq, "\x1bP") || !strings.HasSuffix(seq, "\x1b\\") {
+		t.Fatal("sixel sequence not wrapped in DCS")
+	}
+	if bands := strings.Count(seq, "-"); bands != 3 {
+		t.Errorf("sixel bands = %d, want 3 for 13 rows", bands)
+	}
+	// Pure blue is palette index 5; the last band holds one row (bit 0).
+	if !strings.Contains(seq, "#5!40~$") || !strings.Contains(seq, "#5!40@$") {
+		t.Error("solid rows not run-length encoded")
+	}
+}
+
+// TestViewLocatesPreview verifies the view records the terminal cell just
+// inside the placeholder border, where images are drawn.
+func TestViewLocatesPreview(t *testing.T) {
+	m := NewModel(false)
+	m.SetPreviewProtocol(GraphicsKitty)
+	m.phase = PhaseRendering
+	m.Update(RenderProgress{
+		Frame:       10,
+		TotalFrames: 100,
+		BarHeights:  []float64{0.5, 0.5},
+		FrameData:   solidFrame(128, 72, color.RGBA{A: 255}),
+	})
+
+	content := m.View().Content
+	if m.previewRow == 0 {
+		t.Fatal("preview origin not found in view")
+	}
+	lines := strings.Split(content, "\n")
+	if !strings.Contains(lines[m.previewRow-2], previewTopBorder(DefaultPreviewConfig())) {
+		t.Errorf("line above preview origin = %q, want the top border", lines[m.previewRow-2])
+	}
+	if m.previewCol < 2 {
+		t.Errorf("preview column = %d, want inside the border", m.previewCol)
+	}
+}
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/linuxmatters/jivefire/internal/locale"
+	"github.com/linuxmatters/jivefire/internal/theme"
+)
+
+// meterFloorDB is the bottom of the level meter scale; anything quieter reads
+// as -∞.
+const meterFloorDB = -60.0
+
+// meterLabelWidth and meterValueWidth reserve the label column left of each
+// meter and the readout column right of it.
+const (
+	meterLabelWidth = 6
+	meterValueWidth = 12
+)
+
+// meterScaleMarks are the dB values labelled under the meters.
+var meterScaleMarks = []float64{-48, -36, -24, -12, -6, 0}
+
+// meterEighths are the left-aligned eighth blocks giving the meter fill
+// sub-cell resolution.
+var meterEighths = []rune{'▏', '▎', '▍', '▌', '▋', '▊', '▉', '█'}
+
+// toDB converts a linear full-scale level to dB, -Inf for silence.
+func toDB(level float64) float64 {
+	if level <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(level)
+}
+
+// meterFraction maps a dB level onto the meter scale, 0 at the floor and 1
+// at 0 dB.
+func meterFraction(db float64) float64 {
+	return min(max((db-meterFloorDB)/-meterFloorDB, 0), 1)
+}
+
+// formatDB renders a level readout, "-∞" below the meter floor.
+func formatDB(loc *locale.Locale, db float64, unit string) string {
+	if db < meterFloorDB {
+		return "-∞ " + unit
+	}
+	return loc.Float(db, 1) + " " + unit
+}
+
+// meterZoneColour returns the colour for a meter cell at level db: orange
+// for comfortable levels, yellow approaching full scale, red in the last dB.
+func meterZoneColour(db float64) color.Color {
+	switch {
+	case db > -1:
+		return theme.FireRed
+	case db > -6:
+		return theme.FireYellow
+	default:
+		return theme.FireOrange
+	}
+}
+
+// meterBar draws a width-cell meter filled to db, with a peak-hold marker at
+// holdDB when it lies beyond the fill. Each cell takes the colour of the
+// level it represents.
+func meterBar(db, holdDB float64, width int) string {
+	eighths := int(meterFraction(db) * float64(width*8))
+	holdCol := -1
+	if holdDB >= meterFloorDB {
+		holdCol = min(int(meterFraction(holdDB)*float64(width)), width-1)
+	}
+
+	empty := lipgloss.NewStyle().Foreground(midGrey)
+	var b strings.Builder
+	for col := range width {
+		cellDB := meterFloorDB * (1 - float64(col)/float64(width))
+		fill := min(max(eighths-col*8, 0), 8)
+		switch {
+		case fill > 0:
+			b.WriteString(lipgloss.NewStyle().Foreground(meterZoneColour(cellDB)).Render(string(meterEighths[fill-1])))
+		case col == holdCol:
+			b.WriteString(lipgloss.NewStyle().Foreground(meterZoneColour(holdDB)).Render("│"))
+		default:
+			b.WriteString(empty.Render("░"))
+		}
+	}
+	return b.String()
+}
+
+// meterScale renders the dB labels for a width-cell meter, each centred on
+// its position and dropped where it would touch its neighbour.
+func meterScale(width int) string {
+	row := []rune(strings.Repeat(" ", width))
+	copy(row, []rune("-∞"))
+	next := 3 // first free column after the -∞ label and a gap
+	for _, mark := range meterScaleMarks {
+		label := []rune(fmt.Sprintf("%.0f", mark))
+		start := min(int(meterFraction(mark)*float64(width))-len(label)/2, width-len(label))
+		if start < next {
+			continue
+		}
+		copy(row[start:], label)
+		next = start + len(label) + 1
+	}
+	return string(row)
+}
+
+// LevelMeters holds the Pass 1 meter state: the latest frame's levels and
+// what has been seen so far, all linear full scale.
+type LevelMeters struct {
+	RMS         float64
+	Peak        float64
+	PeakHold    float64
+	TruePeakMax float64
+	Clipped     bool
+}
+
+// update folds the latest frame's levels into the meter state. Clipping
+// latches once any sample or the estimated true peak reaches full scale.
+func (lm *LevelMeters) update(rms, peak, truePeak float64) {
+	lm.RMS, lm.Peak = rms, peak
+	lm.PeakHold = max(lm.PeakHold, peak)
+	lm.TruePeakMax = max(lm.TruePeakMax, truePeak)
+	if peak >= 1 || truePeak >= 1 {
+		lm.Clipped = true
+	}
+}
+
+// render draws the RMS and peak meters, their dB scale and a true-peak and
+// clip line, width cells wide.
+func (lm *LevelMeters) render(loc *locale.Locale, width int) string {
+	barWidth := max(width-meterLabelWidth-meterValueWidth, 10)
+	labelStyle := lipgloss.NewStyle().Foreground(theme.WarmGray).Width(meterLabelWidth)
+	valueStyle := lipgloss.NewStyle().Bold(true).Width(meterValueWidth).Align(lipgloss.Right)
+
+	var s strings.Builder
+	rmsDB, peakDB := toDB(lm.RMS), toDB(lm.Peak)
+	s.WriteString(labelStyle.Render(loc.T("RMS")))
+	s.WriteString(meterBar(rmsDB, math.Inf(-1), barWidth))
+	s.WriteString(valueStyle.Render(formatDB(loc, rmsDB, "dBFS")))
+	s.WriteString("\n")
+	s.WriteString(labelStyle.Render(loc.T("Peak")))
+	s.WriteString(meterBar(peakDB, toDB(lm.PeakHold), barWidth))
+	s.WriteString(valueStyle.Render(formatDB(loc, peakDB, "dBFS")))
+	s.WriteString("\n")
+	s.WriteString(strings.Repeat(" ", meterLabelWidth))
+	s.WriteString(lipgloss.NewStyle().Foreground(midGrey).Render(meterScale(barWidth)))
+	s.WriteString("\n")
+
+	truePeak := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Foreground(theme.WarmGray).Render(loc.T("True peak")+" "),
+		lipgloss.NewStyle().Bold(true).Render(formatDB(loc, toDB(lm.TruePeakMax), "dBTP")),
+	)
+	status := lipgloss.NewStyle().Faint(true).Render(loc.T("no clipping"))
+	if lm.Clipped {
+		status = lipgloss.NewStyle().Bold(true).Foreground(theme.FireCrimson).Render(loc.T("CLIP"))
+	}
+	gap := max(width-lipgloss.Width(truePeak)-lipgloss.Width(status), 1)
+	s.WriteString(truePeak)
+	s.WriteString(strings.Repeat(" ", gap))
+	s.WriteString(status)
+	return s.String()
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+)
+
+// TestLevelMetersUpdate verifies the peak hold and true-peak maximum only
+// rise, and clipping latches once reached.
+func TestLevelMetersUpdate(t *testing.T) {
+	var lm LevelMeters
+	lm.update(0.1, 0.5, 0.6)
+	lm.update(0.05, 0.2, 0.3)
+	if lm.Peak != 0.2 || lm.PeakHold != 0.5 || lm.TruePeakMax != 0.6 {
+		t.Errorf("meters = %+v, want peak 0.2, hold 0.5, true peak 0.6", lm)
+	}
+	if lm.Clipped {
+		t.Error("clipped before reaching full scale")
+	}
+
+	lm.update(0.3, 0.9, 1.05) // inter-sample overshoot
+	lm.update(0.1, 0.2, 0.2)
+	if !lm.Clipped {
+		t.Error("true-peak overshoot did not latch clipping")
+	}
+}
+
+// TestMeterBar verifies the fill scales with level and the meter is always
+// the requested width.
+func TestMeterBar(t *testing.T) {
+	for _, db := range []float64{math.Inf(-1), -70, -30, -3, 0, 3} {
+		if w := lipgloss.Width(meterBar(db, -6, 50)); w != 50 {
+			t.Errorf("meterBar(%v) width = %d, want 50", db, w)
+		}
+	}
+	if strings.Contains(meterBar(math.Inf(-1), math.Inf(-1), 20), "█") {
+		t.Error("silent meter has fill")
+	}
+	if n := strings.Count(meterBar(0, 0, 20), "█"); n != 20 {
+		t.Errorf("full-scale meter has %d full cells, want 20", n)
+	}
+}
+
+// TestMeterScale verifies the -∞ and 0 dB ends are labelled within the meter
+// width.
+func TestMeterScale(t *testing.T) {
+	scale := meterScale(56)
+	if n := len([]rune(scale)); n != 56 {
+		t.Fatalf("scale width = %d, want 56", n)
+	}
+	if !strings.HasPrefix(scale, "-∞") || !strings.HasSuffix(scale, "0") || !strings.Contains(scale, "-12") {
+		t.Errorf("scale = %q, want -∞ … -12 … 0 labels", scale)
+	}
+}
+
+// TestLevelMetersRender verifies every meter line fits the box content width
+// and the readouts are in dBFS and dBTP.
+func TestLevelMetersRender(t *testing.T) {
+	lm := LevelMeters{RMS: 0.125, Peak: 0.5, PeakHold: 0.7, TruePeakMax: 1.1, Clipped: true}
+	out := lm.render(nil, 74)
+	for i, line := range strings.Split(out, "\n") {
+		if w := lipgloss.Width(line); w > 74 {
+			t.Errorf("line %d width = %d, want at most 74", i, w)
+		}
+	}
+	for _, want := range []string{"-18.1 dBFS", "-6.0 dBFS", "0.8 dBTP", "CLIP"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("meters missing %q:\n%s", want, out)
+		}
+	}
+}
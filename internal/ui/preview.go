@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"strconv"
@@ -22,6 +23,54 @@ func DefaultPreviewConfig() PreviewConfig {
 	}
 }
 
+// Preview size bounds in terminal cells. The preview sits inside the progress
+// box, which is sized for the default width, so that is also the widest.
+const (
+	MinPreviewWidth  = 16
+	MaxPreviewWidth  = 72
+	MinPreviewHeight = 4
+	MaxPreviewHeight = 40
+)
+
+// ParsePreviewSize parses a preview size in terminal cells: WIDTHxHEIGHT, or
+// a width alone for the height that keeps the video 16:9 (a cell is about
+// twice as tall as it is wide, so 72 gives the default 72x20).
+func ParsePreviewSize(s string) (PreviewConfig, error) {
+	w, h, hasHeight := strings.Cut(strings.ToLower(s), "x")
+	width, err := strconv.Atoi(w)
+	if err != nil {
+		return PreviewConfig{}, fmt.Errorf("invalid preview size %q: must be WIDTHxHEIGHT or WIDTH in terminal cells (e.g., 48x13 or 48)", s)
+	}
+	height := (width*9 + 16) / 32
+	if hasHeight {
+		if height, err = strconv.Atoi(h); err != nil {
+			return PreviewConfig{}, fmt.Errorf("invalid height in preview size %q: %w", s, err)
+		}
+	}
+	if width < MinPreviewWidth || width > MaxPreviewWidth || height < MinPreviewHeight || height > MaxPreviewHeight {
+		return PreviewConfig{}, fmt.Errorf("invalid preview size %q: width must be between %d and %d, height between %d and %d",
+			s, MinPreviewWidth, MaxPreviewWidth, MinPreviewHeight, MaxPreviewHeight)
+	}
+	return PreviewConfig{Width: width, Height: height}, nil
+}
+
+// fitTo shrinks c, keeping its shape, to fit within width×height cells, but
+// no smaller than the minimum preview size. A bound of zero or less is
+// ignored.
+func (c PreviewConfig) fitTo(width, height int) PreviewConfig {
+	scale := 1.0
+	if width > 0 {
+		scale = min(scale, float64(width)/float64(c.Width))
+	}
+	if height > 0 {
+		scale = min(scale, float64(height)/float64(c.Height))
+	}
+	return PreviewConfig{
+		Width:  max(int(float64(c.Width)*scale), MinPreviewWidth),
+		Height: max(int(float64(c.Height)*scale), MinPreviewHeight),
+	}
+}
+
 // DownsampleFrame takes a full-resolution RGB frame and downsamples it to preview size
 // Each terminal cell represents a rectangular region of the source image
 // Averages all pixels in each region for smooth, high-quality downsampling
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestParsePreviewSize(t *testing.T) {
+	for in, want := range map[string]PreviewConfig{
+		"48x13": {48, 13},
+		"72X30": {72, 30},
+		"72":    DefaultPreviewConfig(),
+		"32":    {32, 9},
+	} {
+		if got, err := ParsePreviewSize(in); err != nil || got != want {
+			t.Errorf("ParsePreviewSize(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "big", "48x", "100x20", "8x4", "48x2"} {
+		if _, err := ParsePreviewSize(in); err == nil {
+			t.Errorf("ParsePreviewSize(%q) succeeded, want error", in)
+		}
+	}
+}
+
+// TestPreviewFitsTerminal verifies the preview shrinks, keeping its shape,
+// to fit a narrow terminal and the rows the rest of the view leaves free,
+// and is drawn at the new size straight away.
+func TestPreviewFitsTerminal(t *testing.T) {
+	m := NewModel(false)
+	m.phase = PhaseRendering
+	m.Update(tea.WindowSizeMsg{Width: 60, Height: 200})
+	m.Update(RenderProgress{
+		Frame:       10,
+		TotalFrames: 100,
+		BarHeights:  []float64{0.5, 0.5},
+		FrameData:   solidFrame(128, 72, color.RGBA{R: 200, A: 255}),
+	})
+	content := m.View().Content
+	if want := (PreviewConfig{Width: 52, Height: 14}); m.shownPreview != want {
+		t.Fatalf("preview in a 60-column terminal = %v, want %v", m.shownPreview, want)
+	}
+	if !strings.Contains(content, previewTopBorder(m.shownPreview)) {
+		t.Error("view does not hold the resized preview")
+	}
+
+	// Short terminal: the next view leaves room for the rest of the UI.
+	height := m.chromeRows + 8
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: height})
+	content = m.View().Content
+	if m.shownPreview.Height != 8 {
+		t.Errorf("preview height = %d, want the 8 free rows", m.shownPreview.Height)
+	}
+	if rows := strings.Count(content, "\n") + 1; rows > height {
+		t.Errorf("view is %d rows, taller than the %d-row terminal", rows, height)
+	}
+}
+
+func TestSetPreviewRate(t *testing.T) {
+	for fps, want := range map[float64]int{30: 0, 10: 1, 4: 2, 2: 3, 1: 4, 0.25: 5} {
+		if got := previewStepFor(fps); got != want {
+			t.Errorf("previewStepFor(%g) = %d, want %d", fps, got, want)
+		}
+	}
+
+	m := NewModel(false)
+	m.SetPreviewRate(1)
+	m.SetPreviewProtocol(GraphicsKitty)
+	if previewIntervals[m.previewStep] != time.Second {
+		t.Errorf("preview interval = %v, want the 1/s asked for over the protocol default", previewIntervals[m.previewStep])
+	}
+}
@@ -15,7 +15,9 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/harmonica"
 	"github.com/linuxmatters/jivefire/internal/config"
+	"github.com/linuxmatters/jivefire/internal/locale"
 	"github.com/linuxmatters/jivefire/internal/theme"
+	"github.com/linuxmatters/jivefire/internal/timing"
 )
 
 // Phase represents the current processing phase
@@ -27,14 +29,16 @@ const (
 	PhaseComplete
 )
 
-// AnalysisProgress represents progress updates from Pass 1 audio analysis
+// AnalysisProgress represents progress updates from Pass 1 audio analysis.
+// The levels are the latest frame's, linear full scale.
 type AnalysisProgress struct {
-	Frame       int
-	TotalFrames int
-	CurrentRMS  float64
-	CurrentPeak float64
-	BarHeights  []float64
-	Duration    time.Duration
+	Frame           int
+	TotalFrames     int
+	CurrentRMS      float64
+	CurrentPeak     float64 // largest absolute sample
+	CurrentTruePeak float64 // estimated inter-sample peak
+	BarHeights      []float64
+	Duration        time.Duration
 }
 
 // AnalysisComplete signals completion of Pass 1 with audio profile data
@@ -42,6 +46,10 @@ type AnalysisComplete struct {
 	PeakMagnitude float64
 	RMSLevel      float64
 	DynamicRange  float64 // raw peak/RMS ratio; converted to dB at assignment
+	TruePeak      float64 // linear full scale; converted to dB at assignment
+	Loudness      float64 // integrated loudness of the encoded audio, LUFS
+	DCOffset      float64 // mean sample value, linear full scale
+	Clipped       float64 // share of samples clipped, 0-1
 	Duration      time.Duration
 	OptimalScale  float64
 	AnalysisTime  time.Duration
@@ -59,6 +67,33 @@ type RenderProgress struct {
 	VideoCodec  string
 	AudioCodec  string
 	EncoderName string
+
+	// Frames waiting for the encoder, and the most that may; a capacity of
+	// 0 hides the count.
+	QueueDepth    int
+	QueueCapacity int
+
+	// The video encoder's figures for the Encoder panel
+	Encoder EncoderStats
+}
+
+// EncoderStats are the video encoder's figures for the Encoder panel: the
+// bitrate and mean quantiser over the last second of video, and the frames
+// of each type so far. A QP of 0 is one the encoder does not report; no
+// frames at all hide the panel.
+type EncoderStats struct {
+	Bitrate float64 // Bits per second
+	QP      float64
+	I, P, B int
+}
+
+// RenderCancelled signals Pass 2 stopped early at the user's request
+type RenderCancelled struct {
+	OutputFile    string
+	Frames        int // frames encoded before stopping
+	TotalFrames   int
+	Discarded     bool // the output was deleted rather than finalised
+	AssetWarnings []string
 }
 
 // RenderComplete signals completion of Pass 2
@@ -72,8 +107,10 @@ type RenderComplete struct {
 	TotalTime        time.Duration
 	ThumbnailTime    time.Duration
 	SamplesProcessed int64
-	EncoderName      string // Video encoder used (e.g., "h264_nvenc", "libx264")
-	EncoderIsHW      bool   // Whether the encoder was hardware-backed
+	EncoderName      string          // Video encoder used (e.g., "h264_nvenc", "libx264")
+	EncoderIsHW      bool            // Whether the encoder was hardware-backed
+	EncoderRetries   int             // Transient encoder failures retried
+	FrameTimings     *timing.Summary // Per-frame stage percentiles and slow frames (nil with no frames)
 
 	// AssetWarnings carries non-fatal asset-load warnings collected during Pass
 	// 2. Routing them through this message means they cross the same channel as
@@ -88,6 +125,10 @@ type AudioProfile struct {
 	PeakLevel    float64 // in dB
 	RMSLevel     float64 // in dB
 	DynamicRange float64 // in dB (converted from the raw peak/RMS ratio)
+	TruePeak     float64 // in dBTP
+	Loudness     float64 // in LUFS
+	DCOffset     float64 // in percent of full scale
+	Clipped      float64 // in percent of the samples
 	OptimalScale float64
 	AnalysisTime time.Duration
 }
@@ -113,26 +154,87 @@ func tickCmd() tea.Cmd {
 
 // keyMap holds the key bindings for the progress UI. It implements the
 // help.KeyMap interface so the help component can render the footer affordance.
+// Disabled bindings neither match nor appear in the footer.
 type keyMap struct {
-	Quit key.Binding
+	Pause   key.Binding
+	Preview key.Binding
+	Stats   key.Binding
+	Faster  key.Binding
+	Slower  key.Binding
+	Quit    key.Binding
+	Abort   key.Binding
 }
 
-// ShortHelp returns the bindings shown in the single-line help footer.
+// ShortHelp returns the bindings shown in the single-line help footer. Slower
+// shares the Faster entry.
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Quit}
+	return []key.Binding{k.Pause, k.Preview, k.Stats, k.Faster, k.Quit, k.Abort}
 }
 
 // FullHelp returns the bindings grouped into columns for the expanded help view.
 func (k keyMap) FullHelp() [][]key.Binding {
-	return [][]key.Binding{{k.Quit}}
+	return [][]key.Binding{{k.Pause, k.Preview, k.Faster, k.Slower}, {k.Stats, k.Quit, k.Abort}}
+}
+
+// newKeyMap returns the Pass 1 bindings: only quit, which exits at once as
+// nothing has been encoded. enableRenderKeys adds the Pass 2 controls.
+func newKeyMap(loc *locale.Locale) keyMap {
+	return keyMap{
+		Pause:   key.NewBinding(key.WithKeys("p"), key.WithHelp("p", loc.T("pause")), key.WithDisabled()),
+		Preview: key.NewBinding(key.WithKeys("v"), key.WithHelp("v", loc.T("preview")), key.WithDisabled()),
+		Stats:   key.NewBinding(key.WithKeys("e"), key.WithHelp("e", loc.T("encoder")), key.WithDisabled()),
+		Faster:  key.NewBinding(key.WithKeys("+", "="), key.WithHelp("+/-", loc.T("rate")), key.WithDisabled()),
+		Slower:  key.NewBinding(key.WithKeys("-", "_"), key.WithHelp("-", loc.T("slower preview")), key.WithDisabled()),
+		Quit: key.NewBinding(
+			key.WithKeys("q", "ctrl+c"),
+			key.WithHelp("q", loc.T("quit")),
+		),
+		Abort: key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", loc.T("abort")), key.WithDisabled()),
+	}
 }
 
-// keys defines the active key bindings for the progress UI.
-var keys = keyMap{
-	Quit: key.NewBinding(
-		key.WithKeys("q", "ctrl+c"),
-		key.WithHelp("q", "quit"),
-	),
+// previewIntervals are the preview refresh steps + and - move between, fastest
+// first. Zero redraws the preview on every repaint.
+var previewIntervals = []time.Duration{
+	0,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+}
+
+// graphicsPreviewStep is the default refresh step for image protocols (twice a
+// second); each refresh re-encodes and transmits a full frame.
+const graphicsPreviewStep = 3
+
+// previewStepFor returns the previewIntervals step nearest a refresh rate in
+// frames per second. Live redraws on every repaint, so it stands for the
+// repaint interval.
+func previewStepFor(fps float64) int {
+	want := math.Log(float64(time.Second) / fps)
+	best, bestDist := 0, math.Inf(1)
+	for i, d := range previewIntervals {
+		if d == 0 {
+			d = uiTickInterval
+		}
+		if dist := math.Abs(math.Log(float64(d)) - want); dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// previewRateLabel describes a refresh interval for the help footer.
+func previewRateLabel(loc *locale.Locale, d time.Duration) string {
+	if d == 0 {
+		return loc.T("live")
+	}
+	rate := float64(time.Second) / float64(d)
+	if rate == math.Trunc(rate) {
+		return loc.Float(rate, 0) + "/s"
+	}
+	return loc.Float(rate, 1) + "/s"
 }
 
 // Model implements the unified Bubbletea model for both passes
@@ -148,6 +250,7 @@ type Model struct {
 
 	// Pass 1 state
 	analysisProgress AnalysisProgress
+	meters           LevelMeters
 
 	// Pass 2 state
 	renderState RenderProgress
@@ -171,10 +274,50 @@ type Model struct {
 
 	// UI state
 	width           int
+	height          int
 	noPreview       bool
 	cachedPreview   string
 	cachedFrameNum  int
 	completionDelay time.Duration
+	loc             *locale.Locale // Language of the UI and summary; nil is English
+
+	// Interactive controls shared with the render loop. previewStep indexes
+	// previewIntervals; previewDrawn is when the preview last refreshed.
+	keys          keyMap
+	controls      *Controls
+	paused        bool
+	pausedAt      time.Time
+	stopping      CancelMode
+	cancelled     *RenderCancelled
+	previewHidden bool
+	encoderShown  bool // The Encoder panel is expanded
+	previewStep   int
+	previewDrawn  time.Time
+	previewRate   float64 // Refresh rate asked for in frames per second; zero for the default
+
+	// Preview size: as asked for (zero for the default); as last drawn or
+	// sent, shrunk to fit the terminal; and as placed in the current view
+	// (zero when there is none). chromeRows counts the rows of the Pass 2 view
+	// other than the preview's, to bound its height.
+	previewSize  PreviewConfig
+	drawnPreview PreviewConfig
+	shownPreview PreviewConfig
+	chromeRows   int
+
+	// Frequency label row under the spectrum: each displayed bar's range in
+	// Hz (nil when off) and the rendered row, cached per spectrum width.
+	axisLow   []float64
+	axisHigh  []float64
+	axisCache string
+	axisWidth int
+
+	// Image-protocol preview: the protocol in use (empty or GraphicsBlocks for
+	// the block renderer), the 1-based cell where the placeholder's content
+	// starts (found in View), and the last frame sent.
+	graphics         GraphicsProtocol
+	previewRow       int
+	previewCol       int
+	graphicsFrameNum int
 }
 
 // boxDesignWidth is the fixed shared outer width for every bordered box. It is
@@ -279,7 +422,7 @@ func NewModel(noPreview bool) *Model {
 		springs[i] = harmonica.NewSpring(spectrumSpringDelta, spectrumSpringFreq, spectrumSpringDamping)
 	}
 
-	return &Model{
+	m := &Model{
 		progressBar:     p,
 		summaryBar:      summaryBar,
 		help:            h,
@@ -287,10 +430,237 @@ func NewModel(noPreview bool) *Model {
 		phase:           PhaseAnalysis,
 		completionDelay: 2 * time.Second,
 		noPreview:       noPreview,
+		keys:            newKeyMap(nil),
+		controls:        NewControls(),
 		spectrumSprings: springs,
 		spectrumPos:     make([]float64, config.NumBars),
 		spectrumVel:     make([]float64, config.NumBars),
 	}
+	m.setPreviewStep(0)
+	return m
+}
+
+// SetPreviewProtocol selects an image protocol for the video preview. Auto
+// must be resolved by the caller; GraphicsBlocks keeps the block renderer.
+func (m *Model) SetPreviewProtocol(protocol GraphicsProtocol) {
+	m.graphics = protocol
+	m.setPreviewStep(m.defaultPreviewStep())
+}
+
+// SetPreviewRate sets the preview refresh rate in frames per second, rounded
+// to the nearest step + and - move between; zero keeps the default for the
+// preview protocol.
+func (m *Model) SetPreviewRate(fps float64) {
+	m.previewRate = fps
+	m.setPreviewStep(m.defaultPreviewStep())
+}
+
+// SetPreviewSize sets the preview size in terminal cells; a zero size is the
+// default. The preview shrinks from it, keeping its shape, while the terminal
+// is too small to show it whole.
+func (m *Model) SetPreviewSize(size PreviewConfig) {
+	m.previewSize = size
+}
+
+// defaultPreviewStep is the refresh step before any + or -: the rate set with
+// SetPreviewRate, twice a second for image protocols, or live.
+func (m *Model) defaultPreviewStep() int {
+	switch {
+	case m.previewRate > 0:
+		return previewStepFor(m.previewRate)
+	case m.usesGraphics():
+		return graphicsPreviewStep
+	}
+	return 0
+}
+
+// previewConfig returns the preview size to draw: the size asked for, shrunk
+// to the width the box has inside a narrow terminal and the rows the rest of
+// the view leaves free.
+func (m *Model) previewConfig() PreviewConfig {
+	size := m.previewSize
+	if size.Width == 0 {
+		size = DefaultPreviewConfig()
+	}
+	rows := 0
+	if m.height > 0 && m.chromeRows > 0 {
+		rows = m.height - m.chromeRows
+	}
+	return size.fitTo(m.boxContentWidth()-8, rows)
+}
+
+// SetLocale sets the language of the progress UI and completion summary;
+// nil is English. Call it before the program starts, as it resets the key
+// help to Pass 1's.
+func (m *Model) SetLocale(loc *locale.Locale) {
+	m.loc = loc
+	m.keys = newKeyMap(loc)
+	m.setPreviewStep(m.previewStep)
+}
+
+// SetFrequencyAxis turns on the frequency label row under the spectrum. low
+// and high give each displayed bar's frequency range in Hz, in the same order
+// as RenderProgress.BarHeights.
+func (m *Model) SetFrequencyAxis(low, high []float64) {
+	m.axisLow, m.axisHigh = low, high
+	m.axisCache, m.axisWidth = "", 0
+}
+
+// frequencyAxis returns the label row for the current spectrum width.
+func (m *Model) frequencyAxis() string {
+	if width := m.spectrumWidth(); width != m.axisWidth {
+		m.axisCache = renderFrequencyAxis(m.axisLow, m.axisHigh, width)
+		m.axisWidth = width
+	}
+	return m.axisCache
+}
+
+// Controls returns the controls the render loop polls for pause, cancel and
+// preview requests made from the keyboard.
+func (m *Model) Controls() *Controls {
+	return m.controls
+}
+
+// usesGraphics reports whether the preview is drawn with an image protocol.
+func (m *Model) usesGraphics() bool {
+	return !m.noPreview && m.graphics != "" && m.graphics != GraphicsBlocks
+}
+
+// previewShown reports whether the preview is on screen: enabled on the
+// command line and not hidden with v.
+func (m *Model) previewShown() bool {
+	return !m.noPreview && !m.previewHidden
+}
+
+// setPreviewStep moves to a previewIntervals step, clamped to the ends, and
+// shows the resulting rate in the footer.
+func (m *Model) setPreviewStep(step int) {
+	m.previewStep = min(max(step, 0), len(previewIntervals)-1)
+	m.keys.Faster.SetHelp("+/-", previewRateLabel(m.loc, previewIntervals[m.previewStep]))
+}
+
+// previewDue reports whether the preview refresh interval has passed.
+func (m *Model) previewDue() bool {
+	return time.Since(m.previewDrawn) >= previewIntervals[m.previewStep]
+}
+
+// enableRenderKeys switches the footer to the Pass 2 controls. q now stops
+// gracefully and ctrl+c aborts; the preview keys need a preview to act on.
+func (m *Model) enableRenderKeys() {
+	m.keys.Pause.SetEnabled(true)
+	m.keys.Preview.SetEnabled(!m.noPreview)
+	m.keys.Stats.SetEnabled(true)
+	m.keys.Faster.SetEnabled(!m.noPreview)
+	m.keys.Slower.SetEnabled(!m.noPreview)
+	m.keys.Quit.SetKeys("q")
+	m.keys.Quit.SetHelp("q", m.loc.T("stop"))
+	m.keys.Abort.SetEnabled(true)
+}
+
+// pass2Elapsed returns Pass 2 wall-clock time excluding pauses; it stands
+// still while paused.
+func (m *Model) pass2Elapsed() time.Duration {
+	if m.paused {
+		return m.pausedAt.Sub(m.pass2StartTime)
+	}
+	return time.Since(m.pass2StartTime)
+}
+
+// togglePause pauses or resumes the render loop. Time spent paused is folded
+// into pass2StartTime so elapsed, speed and ETA ignore it.
+func (m *Model) togglePause() {
+	if m.paused {
+		m.pass2StartTime = m.pass2StartTime.Add(time.Since(m.pausedAt))
+		m.paused = false
+		m.keys.Pause.SetHelp("p", m.loc.T("pause"))
+	} else {
+		m.pausedAt = time.Now()
+		m.paused = true
+		m.keys.Pause.SetHelp("p", m.loc.T("resume"))
+	}
+	m.controls.SetPaused(m.paused)
+}
+
+// stop asks the render loop to finish early. A stop also resumes a paused
+// encode so the loop can reach its cancel check.
+func (m *Model) stop(mode CancelMode) {
+	if m.paused {
+		m.togglePause()
+	}
+	m.stopping = mode
+	m.keys.Pause.SetEnabled(false)
+	m.controls.Cancel(mode)
+}
+
+// handleRenderKey applies a Pass 2 key press.
+func (m *Model) handleRenderKey(msg tea.KeyPressMsg) tea.Cmd {
+	switch {
+	case m.stopping != CancelNone && (key.Matches(msg, m.keys.Quit) || key.Matches(msg, m.keys.Abort)):
+		// A second stop request while finalising gives up waiting.
+		return tea.Quit
+	case key.Matches(msg, m.keys.Abort):
+		m.stop(CancelDiscard)
+	case key.Matches(msg, m.keys.Quit):
+		m.stop(CancelFinalise)
+	case key.Matches(msg, m.keys.Pause):
+		m.togglePause()
+	case key.Matches(msg, m.keys.Preview):
+		m.previewHidden = !m.previewHidden
+		m.controls.SetPreviewEnabled(!m.previewHidden)
+		m.previewDrawn = time.Time{}
+		m.graphicsFrameNum = 0
+		if m.usesGraphics() {
+			// Images are drawn outside the renderer's cell model, so clear the
+			// screen to take the old one away with the box layout change.
+			if clear := ClearGraphics(m.graphics); clear != "" {
+				return tea.Batch(tea.Raw(clear), tea.ClearScreen)
+			}
+			return tea.ClearScreen
+		}
+	case key.Matches(msg, m.keys.Stats):
+		m.encoderShown = !m.encoderShown
+		if m.usesGraphics() && m.previewShown() {
+			// The panel moves the preview down; clear the old image.
+			if clear := ClearGraphics(m.graphics); clear != "" {
+				return tea.Batch(tea.Raw(clear), tea.ClearScreen)
+			}
+			return tea.ClearScreen
+		}
+	case key.Matches(msg, m.keys.Faster):
+		m.setPreviewStep(m.previewStep - 1)
+	case key.Matches(msg, m.keys.Slower):
+		m.setPreviewStep(m.previewStep + 1)
+	}
+	return nil
+}
+
+// graphicsCmd returns a command drawing the latest frame with the image
+// protocol, or nil when nothing new is due. The frame is encoded here rather
+// than in the command, as the producer reuses the frame buffers.
+func (m *Model) graphicsCmd() tea.Cmd {
+	if !m.usesGraphics() || !m.previewShown() || m.phase != PhaseRendering || m.previewRow == 0 {
+		return nil
+	}
+	if m.renderState.FrameData == nil || m.renderState.Frame == m.graphicsFrameNum || !m.previewDue() {
+		return nil
+	}
+	m.graphicsFrameNum = m.renderState.Frame
+	m.previewDrawn = time.Now()
+	return tea.Raw(EncodeGraphicsFrame(m.graphics, m.renderState.FrameData, m.shownPreview, m.previewRow, m.previewCol))
+}
+
+// locatePreview records where the preview placeholder's content starts in the
+// rendered view, so images land inside its border.
+func (m *Model) locatePreview(content string) {
+	m.previewRow, m.previewCol = 0, 0
+	border := previewTopBorder(m.shownPreview)
+	for i, line := range strings.Split(content, "\n") {
+		if j := strings.Index(line, border); j >= 0 {
+			m.previewRow = i + 2                        // the row below the border, 1-based
+			m.previewCol = lipgloss.Width(line[:j]) + 2 // inside the left edge, 1-based
+			return
+		}
+	}
 }
 
 // Init initializes the model and starts both the self-perpetuating UI tick and
@@ -305,12 +675,14 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
+		m.height = msg.Height
 		// Fixed design-width bar: stable regardless of terminal width.
 		m.progressBar.SetWidth(m.progressBarWidth())
 		return m, nil
 
 	case AnalysisProgress:
 		m.analysisProgress = msg
+		m.meters.update(msg.CurrentRMS, msg.CurrentPeak, msg.CurrentTruePeak)
 		// Drive the bar's spring toward the new target. The producer owns the
 		// target percent; the bar's own FrameMsg loop animates the fill. Only
 		// applies when a total frame count is known; otherwise the no-total
@@ -328,6 +700,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			PeakLevel:    20 * math.Log10(msg.PeakMagnitude),
 			RMSLevel:     20 * math.Log10(msg.RMSLevel),
 			DynamicRange: 20 * math.Log10(msg.DynamicRange),
+			TruePeak:     toDB(msg.TruePeak),
+			Loudness:     msg.Loudness,
+			DCOffset:     msg.DCOffset * 100,
+			Clipped:      msg.Clipped * 100,
 			OptimalScale: msg.OptimalScale,
 			AnalysisTime: msg.AnalysisTime,
 		}
@@ -339,6 +715,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.progressBar = newProgressBar(m.progressBarWidth())
 		m.phase = PhaseRendering
 		m.pass2StartTime = time.Now()
+		m.enableRenderKeys()
 		return m, nil
 
 	case RenderProgress:
@@ -356,9 +733,20 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.complete = &msg
 		m.phase = PhaseComplete
 
-		return m, tea.Tick(m.completionDelay, func(t time.Time) tea.Msg {
+		quit := tea.Tick(m.completionDelay, func(t time.Time) tea.Msg {
 			return progressQuitMsg{}
 		})
+		if clear := ClearGraphics(m.graphics); clear != "" && m.usesGraphics() {
+			return m, tea.Batch(tea.Raw(clear), quit)
+		}
+		return m, quit
+
+	case RenderCancelled:
+		m.cancelled = &msg
+		if clear := ClearGraphics(m.graphics); clear != "" && m.usesGraphics() {
+			return m, tea.Sequence(tea.Raw(clear), tea.Quit)
+		}
+		return m, tea.Quit
 
 	case tickMsg:
 		// Advance the spectrum springs one step toward the producer-owned target,
@@ -368,6 +756,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// race. This clock is distinct from spinner.TickMsg; never re-issue one
 		// from the other.
 		m.advanceSpectrumSprings()
+		if cmd := m.graphicsCmd(); cmd != nil {
+			return m, tea.Batch(tickCmd(), cmd)
+		}
 		return m, tickCmd()
 
 	case spinner.TickMsg:
@@ -389,12 +780,16 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 
 	case tea.KeyPressMsg:
-		// Any key dismisses the completed view; otherwise only the quit binding
-		// (q / ctrl+c) exits during processing.
+		// Any key dismisses the completed view. During Pass 2 the render loop
+		// is stopped through the controls and quits the UI once the output is
+		// dealt with; during Pass 1 nothing is written yet, so quit exits at once.
 		if m.complete != nil {
 			return m, tea.Quit
 		}
-		if key.Matches(msg, keys.Quit) {
+		if m.phase == PhaseRendering {
+			return m, m.handleRenderKey(msg)
+		}
+		if key.Matches(msg, m.keys.Quit) {
 			return m, tea.Quit
 		}
 	}
@@ -408,7 +803,14 @@ func (m *Model) View() tea.View {
 	if m.phase == PhaseComplete {
 		content = m.renderFinalProgress() + "\n" + m.renderComplete()
 	} else {
+		m.shownPreview = PreviewConfig{}
 		content = m.renderProgress()
+		if m.usesGraphics() {
+			m.locatePreview(content)
+		}
+		if m.shownPreview.Height > 0 {
+			m.chromeRows = strings.Count(content, "\n") + 1 - m.shownPreview.Height
+		}
 	}
 
 	// Alternate screen buffer prevents ghost box edges when the view height
@@ -428,12 +830,34 @@ func (m *Model) CompletionSummary() string {
 }
 
 // AssetWarnings returns the non-fatal asset-load warnings delivered with the
-// RenderComplete message, or nil if Pass 2 did not complete.
+// RenderComplete or RenderCancelled message, or nil if Pass 2 did not finish.
 func (m *Model) AssetWarnings() []string {
-	if m.complete == nil {
-		return nil
+	switch {
+	case m.complete != nil:
+		return m.complete.AssetWarnings
+	case m.cancelled != nil:
+		return m.cancelled.AssetWarnings
 	}
-	return m.complete.AssetWarnings
+	return nil
+}
+
+// Result returns the Pass 2 completion record and the Pass 1 audio profile,
+// or nil for either that never arrived.
+func (m *Model) Result() (*RenderComplete, *AudioProfile) {
+	return m.complete, m.audioProfile
+}
+
+// CancelSummary describes an encode stopped from the keyboard and what became
+// of its output. Returns empty string if the encode was not cancelled.
+func (m *Model) CancelSummary() string {
+	c := m.cancelled
+	if c == nil {
+		return ""
+	}
+	if c.Discarded {
+		return m.loc.Sprintf("aborted at frame %d of %d; removed %s", c.Frames, c.TotalFrames, c.OutputFile)
+	}
+	return m.loc.Sprintf("stopped at frame %d of %d; partial video finalised in %s", c.Frames, c.TotalFrames, c.OutputFile)
 }
 
 // renderFinalProgress renders the progress UI in its final completed state
@@ -447,7 +871,7 @@ func (m *Model) renderFinalProgress() string {
 
 	s.WriteString(title)
 	s.WriteString("\n")
-	s.WriteString(lipgloss.NewStyle().Foreground(theme.FireOrange).Render("Pass 2: Rendering & Encoding"))
+	s.WriteString(lipgloss.NewStyle().Foreground(theme.FireOrange).Render(m.loc.T("Pass 2: Rendering & Encoding")))
 	s.WriteString("\n\n")
 
 	writeProgressRow(&s, m.progressBar.ViewAs(1.0), 100)
@@ -467,10 +891,10 @@ func (m *Model) renderFinalProgress() string {
 		sourceDuration = m.audioProfile.Duration
 	}
 
-	timeCard := gaugeCard("⏱", lipgloss.Color("#FFFFFF"), "Time", formatDuration(m.complete.TotalTime), finalCardWidth)
-	speedCard := gaugeCard("⚡", theme.WarmGray, "Speed", fmt.Sprintf("%.1f×", finalSpeed), finalCardWidth)
-	sizeCard := gaugeCard("🖬", lipgloss.Color("#FF8C00"), "Size", formatSizeGlyph(m.complete.FileSize), finalCardWidth)
-	durationCard := gaugeCard("🎜", lipgloss.Color("#FF2D2D"), "Duration", formatDuration(sourceDuration), finalCardWidth)
+	timeCard := gaugeCard("⏱", lipgloss.Color("#FFFFFF"), m.loc.T("Time"), formatDuration(m.loc, m.complete.TotalTime), finalCardWidth)
+	speedCard := gaugeCard("⚡", theme.WarmGray, m.loc.T("Speed"), m.loc.Float(finalSpeed, 1)+"×", finalCardWidth)
+	sizeCard := gaugeCard("🖬", lipgloss.Color("#FF8C00"), m.loc.T("Size"), formatSizeGlyph(m.loc, m.complete.FileSize), finalCardWidth)
+	durationCard := gaugeCard("🎜", lipgloss.Color("#FF2D2D"), m.loc.T("Duration"), formatDuration(m.loc, sourceDuration), finalCardWidth)
 
 	cardsRow := lipgloss.JoinHorizontal(lipgloss.Top, timeCard, " ", speedCard, " ", sizeCard, " ", durationCard)
 	s.WriteString(cardsRow)
@@ -502,12 +926,19 @@ func (m *Model) renderProgress() string {
 	s.WriteString("\n")
 
 	var phaseLabel string
-	if m.phase == PhaseAnalysis {
+	switch {
+	case m.phase == PhaseAnalysis:
 		phaseLabel = "Pass 1: Analysing Audio"
-	} else {
+	case m.stopping == CancelDiscard:
+		phaseLabel = "Pass 2: Aborting…"
+	case m.stopping == CancelFinalise:
+		phaseLabel = "Pass 2: Stopping and finalising…"
+	case m.paused:
+		phaseLabel = "Pass 2: Rendering & Encoding (paused)"
+	default:
 		phaseLabel = "Pass 2: Rendering & Encoding"
 	}
-	s.WriteString(lipgloss.NewStyle().Foreground(theme.FireOrange).Render(phaseLabel))
+	s.WriteString(lipgloss.NewStyle().Foreground(theme.FireOrange).Render(m.loc.T(phaseLabel)))
 	s.WriteString("\n\n")
 
 	if m.phase == PhaseAnalysis {
@@ -540,10 +971,17 @@ func (m *Model) renderProgress() string {
 // renderHelpFooter renders the single-line quit affordance for the live
 // in-progress UI. It is intentionally excluded from the completion summary.
 func (m *Model) renderHelpFooter() string {
-	return m.help.View(keys)
+	return m.help.View(m.keys)
 }
 
 func (m *Model) renderAnalysisProgress(s *strings.Builder) {
+	defer func() {
+		if m.analysisProgress.Frame > 0 {
+			s.WriteString("\n\n")
+			s.WriteString(m.meters.render(m.loc, m.boxContentWidth()-6))
+		}
+	}()
+
 	switch {
 	case m.analysisProgress.TotalFrames > 0:
 		percent := float64(m.analysisProgress.Frame) / float64(m.analysisProgress.TotalFrames)
@@ -552,15 +990,15 @@ func (m *Model) renderAnalysisProgress(s *strings.Builder) {
 		// No total: show frame count with elapsed time. Spinner signals live work.
 		s.WriteString(m.spinner.View())
 		s.WriteString(" ")
-		s.WriteString(lipgloss.NewStyle().Faint(true).Render("Analysing..."))
-		fmt.Fprintf(s, "  %d frames  │  Elapsed: %s",
-			m.analysisProgress.Frame,
-			formatDuration(m.analysisProgress.Duration))
+		s.WriteString(lipgloss.NewStyle().Faint(true).Render(m.loc.T("Analysing...")))
+		s.WriteString(m.loc.Sprintf("  %s frames  │  Elapsed: %s",
+			m.loc.Int(int64(m.analysisProgress.Frame)),
+			formatDuration(m.loc, m.analysisProgress.Duration)))
 	default:
 		// Dead air before any frames arrive: spinner is the only motion.
 		s.WriteString(m.spinner.View())
 		s.WriteString(" ")
-		s.WriteString(lipgloss.NewStyle().Faint(true).Render("Starting analysis..."))
+		s.WriteString(lipgloss.NewStyle().Faint(true).Render(m.loc.T("Starting analysis...")))
 	}
 }
 
@@ -569,7 +1007,7 @@ func (m *Model) renderRenderingProgress(s *strings.Builder) {
 		// Dead air before the first render frame: spinner is the only motion.
 		s.WriteString(m.spinner.View())
 		s.WriteString(" ")
-		s.WriteString(lipgloss.NewStyle().Faint(true).Render("Starting render..."))
+		s.WriteString(lipgloss.NewStyle().Faint(true).Render(m.loc.T("Starting render...")))
 		return
 	}
 
@@ -583,7 +1021,7 @@ func (m *Model) renderRenderingProgress(s *strings.Builder) {
 	// ~60ms UI tick advances it (and the ETA/speed derived from it) between
 	// p.Send data updates, rather than freezing on the stale message field.
 	// Fall back to the message field only if pass2StartTime is unset.
-	elapsed := time.Since(m.pass2StartTime)
+	elapsed := m.pass2Elapsed()
 	if m.pass2StartTime.IsZero() {
 		elapsed = m.renderState.Elapsed
 	}
@@ -605,12 +1043,12 @@ func (m *Model) renderRenderingProgress(s *strings.Builder) {
 	// sparkline of recent speed samples), Size (live output file size) and ETA.
 	// The card inner widths are chosen so the joined row plus its three
 	// separators fits the 74-cell box content area without wrapping.
-	timeCard := gaugeCard("⏱", lipgloss.Color("#FFFFFF"), "Time", fmt.Sprintf("%s / %s",
+	timeCard := gaugeCard("⏱", lipgloss.Color("#FFFFFF"), m.loc.T("Time"), fmt.Sprintf("%s / %s",
 		formatClock(elapsed), formatClock(estimatedTotal)), 13)
-	speedValue := fmt.Sprintf("%.1f× %s", speed, sparkline(m.speedHistory))
-	speedCard := gaugeCard("⚡", theme.WarmGray, "Speed", speedValue, 12)
-	sizeCard := gaugeCard("🖬", lipgloss.Color("#FF8C00"), "Size", formatSizeGlyph(m.renderState.FileSize), 11)
-	etaCard := gaugeCard("🞋", lipgloss.Color("#FF2D2D"), "ETA", formatClock(eta), 10)
+	speedValue := fmt.Sprintf("%s× %s", m.loc.Float(speed, 1), sparkline(m.speedHistory))
+	speedCard := gaugeCard("⚡", theme.WarmGray, m.loc.T("Speed"), speedValue, 12)
+	sizeCard := gaugeCard("🖬", lipgloss.Color("#FF8C00"), m.loc.T("Size"), formatSizeGlyph(m.loc, m.renderState.FileSize), 11)
+	etaCard := gaugeCard("🞋", lipgloss.Color("#FF2D2D"), m.loc.T("ETA"), formatClock(eta), 10)
 
 	cardsRow := lipgloss.JoinHorizontal(lipgloss.Top, timeCard, " ", speedCard, " ", sizeCard, " ", etaCard)
 	s.WriteString(cardsRow)
@@ -619,6 +1057,45 @@ func (m *Model) renderRenderingProgress(s *strings.Builder) {
 	// gauge cards. The codec info is right-aligned to end under the cards row.
 	s.WriteString("\n")
 	m.writeFrameSourceLine(s, lipgloss.Width(cardsRow))
+	m.writeEncoderPanel(s, lipgloss.Width(cardsRow))
+}
+
+// writeEncoderPanel writes the Encoder panel, when expanded with e, under
+// the frame line: a heading, then cards for the bitrate and quantiser over
+// the last second and the count of each frame type, together rowWidth
+// wide. An image sequence or a join has no encoder figures, and no panel.
+func (m *Model) writeEncoderPanel(s *strings.Builder, rowWidth int) {
+	stats := m.renderState.Encoder
+	if !m.encoderShown || stats.I+stats.P+stats.B == 0 {
+		return
+	}
+	qp := "–"
+	if stats.QP > 0 {
+		qp = m.loc.Float(stats.QP, 1)
+	}
+	frames := fmt.Sprintf("I %s · P %s · B %s", m.loc.Int(int64(stats.I)), m.loc.Int(int64(stats.P)), m.loc.Int(int64(stats.B)))
+
+	// Each card is 4 cells wider than its content; the frames card takes
+	// what the others leave.
+	const bitrateWidth, qpWidth = 14, 10
+	framesWidth := max(rowWidth-(bitrateWidth+4)-(qpWidth+4)-2-4, 1)
+	row := lipgloss.JoinHorizontal(lipgloss.Top,
+		gaugeCard("≈", theme.FireYellow, m.loc.T("Bitrate"), formatBitrate(m.loc, stats.Bitrate), bitrateWidth), " ",
+		gaugeCard("◐", theme.WarmGray, m.loc.T("QP"), qp, qpWidth), " ",
+		gaugeCard("▤", theme.FireOrange, m.loc.T("Frames"), frames, framesWidth),
+	)
+	s.WriteString("\n\n")
+	s.WriteString(lipgloss.NewStyle().Foreground(theme.WarmGray).Bold(true).Render(m.loc.T("Encoder")))
+	s.WriteString("\n")
+	s.WriteString(row)
+}
+
+// formatBitrate returns bits per second in Mb/s, or kb/s below one.
+func formatBitrate(loc *locale.Locale, bps float64) string {
+	if bps >= 1e6 {
+		return loc.Float(bps/1e6, 2) + " Mb/s"
+	}
+	return loc.Float(bps/1e3, 0) + " kb/s"
 }
 
 // recordSpeedSample appends the current realtime speed to the bounded history
@@ -628,7 +1105,7 @@ func (m *Model) recordSpeedSample(msg RenderProgress) {
 	if msg.TotalFrames == 0 {
 		return
 	}
-	elapsed := time.Since(m.pass2StartTime)
+	elapsed := m.pass2Elapsed()
 	if m.pass2StartTime.IsZero() {
 		elapsed = msg.Elapsed
 	}
@@ -655,20 +1132,44 @@ func (m *Model) renderSpectrumAndStats(s *strings.Builder) {
 	// over its inputs.
 	spectrum := renderSpectrum(m.spectrumPos, m.spectrumWidth())
 	s.WriteString(spectrum)
+	if len(m.axisLow) > 0 {
+		s.WriteString("\n")
+		s.WriteString(m.frequencyAxis())
+	}
 
-	// Video preview
-	if !m.noPreview {
-		if m.renderState.FrameData != nil && m.renderState.Frame != m.cachedFrameNum {
-			config := DefaultPreviewConfig()
-			preview := DownsampleFrame(m.renderState.FrameData, config)
-			m.cachedPreview = RenderPreview(preview)
-			m.cachedFrameNum = m.renderState.Frame
-		}
-
-		if m.cachedPreview != "" {
+	// Video preview: an image protocol draws into a blank placeholder once a
+	// frame has arrived; otherwise block characters carry the picture,
+	// refreshed at the rate chosen with + and -. A preview resized to fit the
+	// terminal is redrawn at once rather than when next due.
+	if !m.previewShown() {
+		return
+	}
+	config := m.previewConfig()
+	if m.usesGraphics() {
+		if m.renderState.FrameData != nil {
+			if config != m.drawnPreview {
+				m.drawnPreview = config
+				m.graphicsFrameNum = 0
+				m.previewDrawn = time.Time{}
+			}
 			s.WriteString("\n")
-			s.WriteString(m.cachedPreview)
+			s.WriteString(RenderPreviewPlaceholder(config))
+			m.shownPreview = config
 		}
+		return
+	}
+	if m.renderState.FrameData != nil && (config != m.drawnPreview || m.renderState.Frame != m.cachedFrameNum && m.previewDue()) {
+		preview := DownsampleFrame(m.renderState.FrameData, config)
+		m.cachedPreview = RenderPreview(preview)
+		m.cachedFrameNum = m.renderState.Frame
+		m.previewDrawn = time.Now()
+		m.drawnPreview = config
+	}
+
+	if m.cachedPreview != "" {
+		s.WriteString("\n")
+		s.WriteString(m.cachedPreview)
+		m.shownPreview = m.drawnPreview
 	}
 }
 
@@ -677,27 +1178,40 @@ func (m *Model) renderSpectrumAndStats(s *strings.Builder) {
 // side), which also equals the box content area, so the spectrum spans the
 // preview edge to edge. Without a preview it falls back to the box content area.
 func (m *Model) spectrumWidth() int {
-	if !m.noPreview {
-		return DefaultPreviewConfig().Width + 2
+	if m.previewShown() {
+		return m.previewConfig().Width + 2
 	}
 	return max(m.boxContentWidth()-6, 10)
 }
 
-// writeFrameSourceLine writes the "<spinner> Frame X / Y … video · audio" line.
-// The animated spinner (mid grey) leads the frame counter on the left; the codec
-// info (video codec with the live encoder name in mid-grey brackets, then the
-// audio codec) is right-aligned so its last character ends at rowWidth, the gauge
-// cards row width. The output file size lives in the Size gauge card; the source
-// duration is omitted. The codec summary is dropped until any codec data arrives.
+// writeFrameSourceLine writes the "<spinner> Frame X / Y ⧗ N/M … video · audio"
+// line. The animated spinner (mid grey) leads the frame counter on the left,
+// followed by the frames waiting for the encoder when there is a queue (orange
+// while it is full); the codec info (video codec with the live encoder name in
+// mid-grey brackets, then the audio codec) is right-aligned so its last
+// character ends at rowWidth, the gauge cards row width. The output file size
+// lives in the Size gauge card; the source duration is omitted. The codec
+// summary is dropped until any codec data arrives.
 func (m *Model) writeFrameSourceLine(s *strings.Builder, rowWidth int) {
 	labelStyle := lipgloss.NewStyle().Foreground(theme.WarmGray)
 	valueStyle := lipgloss.NewStyle().Bold(true)
 
 	frame := lipgloss.JoinHorizontal(lipgloss.Top,
 		m.spinner.View(),
-		labelStyle.Render("Frame: "),
+		labelStyle.Render(m.loc.T("Frame: ")),
 		valueStyle.Render(fmt.Sprintf("%d / %d", m.renderState.Frame, m.renderState.TotalFrames)),
 	)
+	if capacity := m.renderState.QueueCapacity; capacity > 0 {
+		depthStyle := valueStyle
+		if m.renderState.QueueDepth >= capacity {
+			depthStyle = depthStyle.Foreground(theme.FireOrange)
+		}
+		frame = lipgloss.JoinHorizontal(lipgloss.Top,
+			frame,
+			labelStyle.Render(" ⧗ "),
+			depthStyle.Render(fmt.Sprintf("%d/%d", m.renderState.QueueDepth, capacity)),
+		)
+	}
 
 	writeFrameLine(s, frame, m.codecInfo(m.renderState.EncoderName), rowWidth)
 }
@@ -714,7 +1228,7 @@ func (m *Model) writeFinalFrameLine(s *strings.Builder, rowWidth int) {
 
 	frame := lipgloss.JoinHorizontal(lipgloss.Top,
 		checkStyle.Render("✓ "),
-		labelStyle.Render("Frame: "),
+		labelStyle.Render(m.loc.T("Frame: ")),
 		valueStyle.Render(fmt.Sprintf("%d / %d", m.complete.TotalFrames, m.complete.TotalFrames)),
 	)
 
@@ -792,14 +1306,14 @@ func compactCodec(codec string) string {
 	return strings.Join(kept, " ")
 }
 
-func formatDuration(d time.Duration) string {
+func formatDuration(loc *locale.Locale, d time.Duration) string {
 	if d == 0 {
 		return "0s"
 	}
 	if d < time.Second {
 		return fmt.Sprintf("%dms", d.Milliseconds())
 	}
-	return fmt.Sprintf("%.1fs", d.Seconds())
+	return loc.Float(d.Seconds(), 1) + "s"
 }
 
 // formatClock renders a duration as a whole-second clock: MM:SS, scaling to
@@ -823,7 +1337,7 @@ func formatClock(d time.Duration) string {
 // formatSizeGlyph formats a byte count for the compact Size gauge card. MB and
 // GB use the single-cell unit glyphs ㎆ and ㎇ with no separating space (e.g.
 // "152.8㎆", "1.2㎇"); the rare small cases keep plain "KB"/"B".
-func formatSizeGlyph(bytes int64) string {
+func formatSizeGlyph(loc *locale.Locale, bytes int64) string {
 	const unit = 1024
 	switch {
 	case bytes <= 0:
@@ -831,10 +1345,10 @@ func formatSizeGlyph(bytes int64) string {
 	case bytes < unit:
 		return fmt.Sprintf("%d B", bytes)
 	case bytes < unit*unit:
-		return fmt.Sprintf("%.1f KB", float64(bytes)/unit)
+		return loc.Float(float64(bytes)/unit, 1) + " KB"
 	case bytes < unit*unit*unit:
-		return fmt.Sprintf("%.1f㎆", float64(bytes)/(unit*unit))
+		return loc.Float(float64(bytes)/(unit*unit), 1) + "㎆"
 	default:
-		return fmt.Sprintf("%.1f㎇", float64(bytes)/(unit*unit*unit))
+		return loc.Float(float64(bytes)/(unit*unit*unit), 1) + "㎇"
 	}
 }
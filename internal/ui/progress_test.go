@@ -513,3 +513,143 @@ func TestUpdateProgressQuitMsgQuits(t *testing.T) {
 		t.Error("progressQuitMsg cmd did not yield tea.QuitMsg")
 	}
 }
+
+// renderingModel returns a model that has entered Pass 2, with the render
+// key bindings enabled.
+func renderingModel(t *testing.T, noPreview bool) *Model {
+	t.Helper()
+	m := NewModel(noPreview)
+	m.Update(AnalysisComplete{PeakMagnitude: 1, RMSLevel: 0.5, DynamicRange: 2})
+	if m.phase != PhaseRendering {
+		t.Fatalf("phase = %v, want PhaseRendering", m.phase)
+	}
+	return m
+}
+
+// TestRenderKeysStopGracefully verifies q and ctrl+c during Pass 2 ask the
+// render loop to stop instead of quitting, and a second press quits at once.
+func TestRenderKeysStopGracefully(t *testing.T) {
+	tests := []struct {
+		name string
+		key  tea.KeyPressMsg
+		want CancelMode
+	}{
+		{"q finalises", tea.KeyPressMsg{Code: 'q', Text: "q"}, CancelFinalise},
+		{"ctrl+c discards", tea.KeyPressMsg{Code: 'c', Mod: tea.ModCtrl}, CancelDiscard},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := renderingModel(t, true)
+			_, cmd := m.Update(tc.key)
+			assertCmdNil(t, cmd)
+			if got := m.Controls().Cancelled(); got != tc.want {
+				t.Errorf("Cancelled() = %v, want %v", got, tc.want)
+			}
+
+			_, cmd = m.Update(tc.key)
+			if _, ok := assertCmdMsg(t, cmd).(tea.QuitMsg); !ok {
+				t.Error("second stop request did not quit")
+			}
+		})
+	}
+}
+
+// TestRenderKeysPauseAndPreview verifies p toggles the shared pause state, v
+// hides the preview and stops frames being sent, and +/- step the refresh rate
+// within bounds.
+func TestRenderKeysPauseAndPreview(t *testing.T) {
+	m := renderingModel(t, false)
+	controls := m.Controls()
+
+	m.Update(tea.KeyPressMsg{Code: 'p', Text: "p"})
+	if !m.paused || !controls.Paused() {
+		t.Fatal("p did not pause")
+	}
+	m.Update(tea.KeyPressMsg{Code: 'p', Text: "p"})
+	if m.paused || controls.Paused() {
+		t.Fatal("second p did not resume")
+	}
+
+	m.Update(tea.KeyPressMsg{Code: 'v', Text: "v"})
+	if m.previewShown() || controls.PreviewEnabled() {
+		t.Error("v did not hide the preview")
+	}
+
+	m.Update(tea.KeyPressMsg{Code: '-', Text: "-"})
+	m.Update(tea.KeyPressMsg{Code: '-', Text: "-"})
+	if m.previewStep != 2 {
+		t.Errorf("previewStep after two - = %d, want 2", m.previewStep)
+	}
+	for range len(previewIntervals) {
+		m.Update(tea.KeyPressMsg{Code: '+', Text: "+"})
+	}
+	if m.previewStep != 0 {
+		t.Errorf("previewStep after repeated + = %d, want 0", m.previewStep)
+	}
+}
+
+// TestRenderKeysDisabledWithoutPreview verifies the preview keys do nothing
+// when the preview was turned off on the command line.
+func TestRenderKeysDisabledWithoutPreview(t *testing.T) {
+	m := renderingModel(t, true)
+	m.Update(tea.KeyPressMsg{Code: 'v', Text: "v"})
+	if m.previewHidden {
+		t.Error("v toggled a disabled preview")
+	}
+}
+
+// TestEncoderPanel verifies e expands and collapses the Encoder panel, and
+// that it stays hidden without encoder figures.
+func TestEncoderPanel(t *testing.T) {
+	m := renderingModel(t, true)
+	m.Update(RenderProgress{Frame: 30, TotalFrames: 300, BarHeights: make([]float64, 64)})
+	if strings.Contains(m.renderProgress(), "Bitrate") {
+		t.Error("Encoder panel shown before e")
+	}
+	m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	if strings.Contains(m.renderProgress(), "Bitrate") {
+		t.Error("Encoder panel shown without encoder figures")
+	}
+
+	m.Update(RenderProgress{Frame: 31, TotalFrames: 300, BarHeights: make([]float64, 64),
+		Encoder: EncoderStats{Bitrate: 2_450_000, QP: 23.25, I: 1, P: 22, B: 8}})
+	view := m.renderProgress()
+	for _, want := range []string{"Encoder", "2.45 Mb/s", "23.2", "I 1 · P 22 · B 8"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Encoder panel lacks %q", want)
+		}
+	}
+	m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	if strings.Contains(m.renderProgress(), "Bitrate") {
+		t.Error("second e did not collapse the Encoder panel")
+	}
+}
+
+// TestUpdateRenderCancelledQuits verifies the cancelled message quits the UI
+// and describes what happened to the output.
+func TestUpdateRenderCancelledQuits(t *testing.T) {
+	m := renderingModel(t, true)
+	_, cmd := m.Update(RenderCancelled{OutputFile: "out.mp4", Frames: 10, TotalFrames: 100, Discarded: true})
+	if _, ok := assertCmdMsg(t, cmd).(tea.QuitMsg); !ok {
+		t.Error("RenderCancelled did not quit")
+	}
+	if got, want := m.CancelSummary(), "aborted at frame 10 of 100; removed out.mp4"; got != want {
+		t.Errorf("CancelSummary() = %q, want %q", got, want)
+	}
+	if m.CompletionSummary() != "" {
+		t.Error("CompletionSummary() not empty after cancel")
+	}
+}
+
+func TestPreviewRateLabel(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                      "live",
+		100 * time.Millisecond: "10/s",
+		250 * time.Millisecond: "4/s",
+		2 * time.Second:        "0.5/s",
+	} {
+		if got := previewRateLabel(nil, d); got != want {
+			t.Errorf("previewRateLabel(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"math"
 	"slices"
+	"strconv"
 	"strings"
 
 	"charm.land/lipgloss/v2"
@@ -81,11 +82,7 @@ func renderSpectrum(barHeights []float64, width int) string {
 	// has exactly width columns.
 	displayHeights := make([]float64, width)
 	for col := range width {
-		src := col * len(barHeights) / width
-		if src >= len(barHeights) {
-			src = len(barHeights) - 1
-		}
-		displayHeights[col] = barHeights[src] / maxHeight
+		displayHeights[col] = barHeights[columnBar(col, len(barHeights), width)] / maxHeight
 	}
 
 	var result strings.Builder
@@ -95,6 +92,71 @@ func renderSpectrum(barHeights []float64, width int) string {
 	return result.String()
 }
 
+// columnBar maps a spectrum column to the bar it shows when n bars are
+// resampled across width columns.
+func columnBar(col, n, width int) int {
+	return min(col*n/width, n-1)
+}
+
+// axisMarkers are the frequencies labelled under the spectrum, most useful
+// first; later markers only fill space the earlier ones left free.
+var axisMarkers = []float64{250, 1000, 4000, 12000, 2000, 8000, 16000, 500, 20000, 60}
+
+// renderFrequencyAxis lays out frequency labels for a spectrum width columns
+// wide. low and high are each bar's frequency range in Hz, in display order.
+// A marker is centred under each run of columns whose bar contains it (two
+// runs for the centre-out mirrored layout) and dropped where it would crowd
+// a label already placed. The function is pure over its arguments.
+func renderFrequencyAxis(low, high []float64, width int) string {
+	row := []rune(strings.Repeat(" ", width))
+	taken := make([]bool, width)
+
+	for _, f := range axisMarkers {
+		label := []rune(formatFrequency(f))
+		for _, run := range markerRuns(low, high, width, f) {
+			start := (run[0]+run[1])/2 - len(label)/2
+			end := start + len(label)
+			if start < 0 || end > width || slices.Contains(taken[max(start-1, 0):min(end+1, width)], true) {
+				continue
+			}
+			copy(row[start:end], label)
+			for i := start; i < end; i++ {
+				taken[i] = true
+			}
+		}
+	}
+
+	return lipgloss.NewStyle().Foreground(theme.WarmGray).Render(string(row))
+}
+
+// markerRuns returns the inclusive [first, last] column runs showing a bar
+// whose range contains f.
+func markerRuns(low, high []float64, width int, f float64) [][2]int {
+	var runs [][2]int
+	inRun := false
+	for col := range width {
+		bar := columnBar(col, len(low), width)
+		if f >= low[bar] && f < high[bar] {
+			if !inRun {
+				runs = append(runs, [2]int{col, col})
+				inRun = true
+			}
+			runs[len(runs)-1][1] = col
+		} else {
+			inRun = false
+		}
+	}
+	return runs
+}
+
+// formatFrequency renders a marker frequency compactly: "250Hz", "1k", "1.5k".
+func formatFrequency(f float64) string {
+	if f < 1000 {
+		return strconv.FormatFloat(f, 'f', -1, 64) + "Hz"
+	}
+	return strconv.FormatFloat(f/1000, 'f', -1, 64) + "k"
+}
+
 // spectrumRow builds one of the two spectrum rows. Each column shows the bar
 // portion that falls in this row. Kept separate from renderSpectrum to keep that
 // function's cyclomatic complexity within the lint budget.
@@ -166,3 +166,38 @@ func TestRenderSpectrumNormalisesToBars(t *testing.T) {
 		t.Errorf("bottom row not at full height; normalisation is wrong: %q", bottom)
 	}
 }
+
+// TestRenderFrequencyAxis verifies markers land under the bars containing
+// them, mirrored for the centre-out layout, and never overlap.
+func TestRenderFrequencyAxis(t *testing.T) {
+	// Four bars of 1 kHz each, mirrored: 3k 2k 1k 0k | 0k 1k 2k 3k.
+	low := []float64{3000, 2000, 1000, 0, 0, 1000, 2000, 3000}
+	high := make([]float64, len(low))
+	for i, l := range low {
+		high[i] = l + 1000
+	}
+
+	axis := stripStyles(renderFrequencyAxis(low, high, 40))
+	if got := len([]rune(axis)); got != 40 {
+		t.Fatalf("axis is %d columns, want 40", got)
+	}
+	// Each bar spans five columns; 1k sits under bars 2 and 5, 2k under 1 and 6.
+	if i := strings.Index(axis, "1k"); i < 10 || i >= 15 {
+		t.Errorf("first 1k label at column %d, want under bar 2 (columns 10-14): %q", i, axis)
+	}
+	if strings.Count(axis, "1k") != 2 || strings.Count(axis, "2k") != 2 {
+		t.Errorf("markers not mirrored: %q", axis)
+	}
+	// The 250Hz and 500Hz markers share the centre bars; only the first fits.
+	if strings.Count(axis, "250Hz") != 1 || strings.Contains(axis, "500Hz") {
+		t.Errorf("centre markers crowded: %q", axis)
+	}
+}
+
+func TestFormatFrequency(t *testing.T) {
+	for f, want := range map[float64]string{60: "60Hz", 1000: "1k", 1500: "1.5k", 12000: "12k"} {
+		if got := formatFrequency(f); got != want {
+			t.Errorf("formatFrequency(%v) = %q, want %q", f, got, want)
+		}
+	}
+}
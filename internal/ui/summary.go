@@ -17,7 +17,7 @@ func (m *Model) renderComplete() string {
 	title := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(theme.FireYellow).
-		Render("✓ Encoding Complete!")
+		Render(m.loc.T("✓ Encoding Complete!"))
 
 	s.WriteString(title)
 	s.WriteString("\n\n")
@@ -26,16 +26,24 @@ func (m *Model) renderComplete() string {
 	dimLabel := lipgloss.NewStyle().Faint(true)
 
 	// Output summary. Size, source duration, total time taken and the encoder now
-	// live in the finished Pass 2 box above, so they are omitted here.
-	fmt.Fprintf(&s, "%s%s\n", dimLabel.Render("Output:   "), m.complete.OutputFile)
+	// live in the finished Pass 2 box above, so they are omitted here. The
+	// labels are padded to the widest, whatever their language.
+	labels := []string{m.loc.T("Output:"), m.loc.T("Video:"), m.loc.T("Audio:")}
+	labelWidth := 0
+	for _, l := range labels {
+		labelWidth = max(labelWidth, lipgloss.Width(l))
+	}
+	label := func(i int) string {
+		return dimLabel.Render(labels[i] + strings.Repeat(" ", labelWidth-lipgloss.Width(labels[i])+3))
+	}
+	fmt.Fprintf(&s, "%s%s\n", label(0), m.complete.OutputFile)
 
 	videoDuration := time.Duration(m.complete.TotalFrames) * time.Second / config.FPS
-	fmt.Fprintf(&s, "%s%d frames, %.2f fps average\n",
-		dimLabel.Render("Video:    "),
-		m.complete.TotalFrames,
-		float64(m.complete.TotalFrames)/videoDuration.Seconds())
+	fmt.Fprintf(&s, "%s%s\n", label(1), m.loc.Sprintf("%s frames, %s fps average",
+		m.loc.Int(int64(m.complete.TotalFrames)),
+		m.loc.Float(float64(m.complete.TotalFrames)/videoDuration.Seconds(), 2)))
 	if m.complete.SamplesProcessed > 0 {
-		fmt.Fprintf(&s, "%s%d samples processed\n\n", dimLabel.Render("Audio:    "), m.complete.SamplesProcessed)
+		fmt.Fprintf(&s, "%s%s\n\n", label(2), m.loc.Sprintf("%s samples processed", m.loc.Int(m.complete.SamplesProcessed)))
 	} else {
 		s.WriteString("\n")
 	}
@@ -51,7 +59,7 @@ func (m *Model) renderComplete() string {
 		totalMs = 1
 	}
 
-	s.WriteString(headerStyle.Render("Pass 1: Audio Analysis"))
+	s.WriteString(headerStyle.Render(m.loc.T("Pass 1: Audio Analysis")))
 	s.WriteString("\n")
 
 	// Pass 1 table: a borderless two-column label/value grid. The table handles
@@ -64,11 +72,15 @@ func (m *Model) renderComplete() string {
 			}
 			return valueStyle
 		})
-		pass1.Row("Peak Level:", fmt.Sprintf("%.1f ㏈", m.audioProfile.PeakLevel))
-		pass1.Row("RMS Level:", fmt.Sprintf("%.1f ㏈", m.audioProfile.RMSLevel))
-		pass1.Row("Dynamic Range:", fmt.Sprintf("%.1f ㏈", m.audioProfile.DynamicRange))
-		pass1.Row("Optimal Scale:", fmt.Sprintf("%.3f", m.audioProfile.OptimalScale))
-		pass1.Row("Analysis Time:", highlightValueStyle.Render(formatDuration(m.audioProfile.AnalysisTime)))
+		pass1.Row(m.loc.T("Peak Level:"), m.loc.Float(m.audioProfile.PeakLevel, 1)+" ㏈")
+		pass1.Row(m.loc.T("RMS Level:"), m.loc.Float(m.audioProfile.RMSLevel, 1)+" ㏈")
+		pass1.Row(m.loc.T("Dynamic Range:"), m.loc.Float(m.audioProfile.DynamicRange, 1)+" ㏈")
+		pass1.Row(m.loc.T("True Peak:"), formatDB(m.loc, m.audioProfile.TruePeak, "㏈TP"))
+		pass1.Row(m.loc.T("Loudness:"), formatDB(m.loc, m.audioProfile.Loudness, "LUFS"))
+		pass1.Row(m.loc.T("DC Offset:"), m.loc.Float(m.audioProfile.DCOffset, 2)+" %")
+		pass1.Row(m.loc.T("Clipping:"), m.loc.Float(m.audioProfile.Clipped, 2)+" %")
+		pass1.Row(m.loc.T("Optimal Scale:"), m.loc.Float(m.audioProfile.OptimalScale, 3))
+		pass1.Row(m.loc.T("Analysis Time:"), highlightValueStyle.Render(formatDuration(m.loc, m.audioProfile.AnalysisTime)))
 		s.WriteString(pass1.Render())
 		s.WriteString("\n")
 	}
@@ -76,7 +88,7 @@ func (m *Model) renderComplete() string {
 	s.WriteString("\n")
 
 	// Pass 2 Performance Breakdown
-	s.WriteString(headerStyle.Render("Pass 2: Rendering & Encoding"))
+	s.WriteString(headerStyle.Render(m.loc.T("Pass 2: Rendering & Encoding")))
 	s.WriteString("\n")
 
 	// Pass 2 table: label, duration, percentage and a rendered summary bar. The
@@ -97,8 +109,8 @@ func (m *Model) renderComplete() string {
 	barRow := func(label string, duration time.Duration) {
 		pct := int(float64(duration.Milliseconds()) * 100 / float64(totalMs))
 		pass2.Row(
-			label,
-			fmt.Sprintf("~%s", formatDuration(duration)),
+			m.loc.T(label),
+			fmt.Sprintf("~%s", formatDuration(m.loc, duration)),
 			fmt.Sprintf("(~%d%%)", pct),
 			m.summaryBar.ViewAs(float64(duration.Milliseconds())/float64(totalMs)),
 		)
@@ -131,9 +143,14 @@ func (m *Model) renderComplete() string {
 	}
 
 	// Total time gets its own label/value row with the highlight style applied.
-	pass2.Row("Total time:", highlightValueStyle.Render(formatDuration(m.complete.TotalTime)), "", "")
+	pass2.Row(m.loc.T("Total time:"), highlightValueStyle.Render(formatDuration(m.loc, m.complete.TotalTime)), "", "")
 	s.WriteString(pass2.Render())
 
+	if m.complete.FrameTimings != nil {
+		s.WriteString("\n\n")
+		s.WriteString(renderFrameTimings(m.loc, m.complete.FrameTimings))
+	}
+
 	return lipgloss.NewStyle().
 		BorderStyle(lipgloss.RoundedBorder()).
 		BorderForeground(theme.FireOrange).
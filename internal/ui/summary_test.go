@@ -4,6 +4,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/linuxmatters/jivefire/internal/locale"
 )
 
 // TestRenderCompleteUsesTable verifies the completion summary renders through
@@ -94,3 +96,48 @@ func TestRenderCompleteUsesTable(t *testing.T) {
 		t.Error("CompletionSummary missing table-based Pass 1 section")
 	}
 }
+
+// TestRenderCompleteLocalised verifies SetLocale translates the summary's
+// labels and formats its numbers for the language.
+func TestRenderCompleteLocalised(t *testing.T) {
+	de, err := locale.Parse("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewModel(true)
+	m.SetLocale(de)
+	m.audioProfile = &AudioProfile{
+		Duration:     185 * time.Second,
+		PeakLevel:    -1.2,
+		RMSLevel:     -14.8,
+		DynamicRange: 13.6,
+		OptimalScale: 1.234,
+		AnalysisTime: 1500 * time.Millisecond,
+	}
+	m.complete = &RenderComplete{
+		OutputFile:       "out.mp4",
+		TotalFrames:      4500,
+		TotalTime:        13 * time.Second,
+		FileSize:         10485760,
+		SamplesProcessed: 8880000,
+	}
+
+	out := stripStyles(m.renderComplete())
+	for _, want := range []string{
+		"Kodierung abgeschlossen!",
+		"Spitzenpegel:", "-1,2 ㏈",
+		"Optimale Skalierung:", "1,234",
+		"8.880.000 Samples verarbeitet",
+		"4.500 Frames",
+		"Gesamtzeit:",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("German summary missing %q:\n%s", want, out)
+		}
+	}
+	for _, english := range []string{"Peak Level:", "Total time:", "samples processed"} {
+		if strings.Contains(out, english) {
+			t.Errorf("German summary still contains %q", english)
+		}
+	}
+}
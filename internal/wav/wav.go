@@ -0,0 +1,462 @@
+// Package wav decodes uncompressed WAV and AIFF audio in pure Go, for
+// --decoder=native. It needs neither cgo nor FFmpeg, so it also builds for
+// tools and platforms without the static FFmpeg libraries, and a Reader
+// serves anywhere an audio.Source does.
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/failure"
+)
+
+// readFrames is how many sample frames a Reader reads from the file at once.
+const readFrames = 4096
+
+// Info describes a file's audio.
+type Info struct {
+	SampleRate int
+	Channels   int
+	BitDepth   int
+	Codec      string // FFmpeg's name for the sample format, e.g. "pcm_s16le", so reports read the same either way
+	Frames     int64  // Sample frames in the file
+	Duration   time.Duration
+}
+
+// format is a file's sample layout and where its samples are.
+type format struct {
+	Info
+	float      bool
+	bigEndian  bool
+	unsigned   bool  // 8-bit WAV samples are offset binary
+	dataOffset int64 // Byte offset of the first sample frame
+}
+
+// blockAlign returns the bytes in one sample frame.
+func (f format) blockAlign() int {
+	return f.Channels * f.BitDepth / 8
+}
+
+// Probe reads the header of a WAV or AIFF file.
+func Probe(path string) (Info, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Info{}, openFailure(err)
+	}
+	defer file.Close()
+	f, err := parse(file)
+	if err != nil {
+		return Info{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return f.Info, nil
+}
+
+// openFailure marks an error opening the input the way the FFmpeg reader
+// does: a missing file, and any other failure to open it as is.
+func openFailure(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return failure.Mark(failure.ErrInputNotFound, err)
+	}
+	return err
+}
+
+// unsupported marks err as a file the native decoder cannot read.
+func unsupported(format string, args ...any) error {
+	return failure.Mark(failure.ErrUnsupportedFormat, fmt.Errorf(format, args...))
+}
+
+// parse reads the header of the file r, which must be seekable, and returns
+// the layout of its samples.
+func parse(r io.ReadSeeker) (format, error) {
+	var head [12]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return format{}, unsupported("not a WAV or AIFF file")
+	}
+	size, err := r.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = r.Seek(int64(len(head)), io.SeekStart)
+	}
+	if err != nil {
+		return format{}, err
+	}
+
+	var f format
+	switch {
+	case string(head[0:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		f, err = parseWAV(r)
+	case string(head[0:4]) == "FORM" && (string(head[8:12]) == "AIFF" || string(head[8:12]) == "AIFC"):
+		f, err = parseAIFF(r, string(head[8:12]) == "AIFC")
+	default:
+		return format{}, unsupported("not a WAV or AIFF file (the native decoder reads no other format)")
+	}
+	if err != nil {
+		return format{}, err
+	}
+
+	switch {
+	case f.SampleRate <= 0:
+		return format{}, unsupported("invalid sample rate %d", f.SampleRate)
+	case f.Channels < 1 || f.Channels > 2:
+		return format{}, unsupported("%d channels: the native decoder reads mono and stereo", f.Channels)
+	case f.float && f.BitDepth != 32 && f.BitDepth != 64,
+		!f.float && (f.BitDepth < 8 || f.BitDepth > 32 || f.BitDepth%8 != 0):
+		return format{}, unsupported("%d-bit samples are not supported", f.BitDepth)
+	}
+	f.Codec = codecName(f)
+
+	// A file cut short, or a header left unfinished by a recorder that
+	// stopped, holds only the frames that are there.
+	available := (size - f.dataOffset) / int64(f.blockAlign())
+	if f.Frames <= 0 || f.Frames > available {
+		f.Frames = max(available, 0)
+	}
+	f.Duration = time.Duration(f.Frames) * time.Second / time.Duration(f.SampleRate)
+	return f, nil
+}
+
+// parseWAV walks the chunks of a RIFF/WAVE file, r positioned after its
+// header, for the format and data chunks.
+func parseWAV(r io.ReadSeeker) (format, error) {
+	le := binary.LittleEndian
+	var f format
+	var haveFormat bool
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return format{}, unsupported("no data chunk")
+		}
+		id, size := string(hdr[0:4]), int64(le.Uint32(hdr[4:]))
+		switch id {
+		case "fmt ":
+			if size < 16 || size > 1<<16 {
+				return format{}, unsupported("invalid fmt chunk")
+			}
+			b := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return format{}, unsupported("truncated fmt chunk")
+			}
+			tag := le.Uint16(b[0:])
+			f.Channels = int(le.Uint16(b[2:]))
+			f.SampleRate = int(le.Uint32(b[4:]))
+			f.BitDepth = int(le.Uint16(b[14:]))
+			// WAVE_FORMAT_EXTENSIBLE keeps the real tag at the start of its
+			// sub-format GUID.
+			if tag == 0xfffe && size >= 26 {
+				tag = le.Uint16(b[24:])
+			}
+			switch tag {
+			case 1:
+				f.unsigned = f.BitDepth == 8
+			case 3:
+				f.float = true
+			default:
+				return format{}, unsupported("WAV format tag %#x: the native decoder reads PCM and float samples", tag)
+			}
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return format{}, unsupported("data chunk before the fmt chunk")
+			}
+			offset, err := r.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return format{}, err
+			}
+			f.dataOffset = offset
+			// Size 0 or 0xFFFFFFFF marks audio written without seeking back.
+			if size > 0 && size < math.MaxUint32 {
+				f.Frames = size / int64(max(f.Channels*f.BitDepth/8, 1))
+			}
+			return f, nil
+		default:
+			if _, err := r.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return format{}, err
+			}
+		}
+	}
+}
+
+// parseAIFF walks the chunks of an AIFF or AIFF-C file, r positioned after
+// its header, for the COMM and SSND chunks.
+func parseAIFF(r io.ReadSeeker, compressed bool) (format, error) {
+	be := binary.BigEndian
+	f := format{bigEndian: true}
+	var haveComm bool
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return format{}, unsupported("no SSND chunk")
+		}
+		id, size := string(hdr[0:4]), int64(be.Uint32(hdr[4:]))
+		switch id {
+		case "COMM":
+			if size < 18 || size > 1<<16 {
+				return format{}, unsupported("invalid COMM chunk")
+			}
+			b := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return format{}, unsupported("truncated COMM chunk")
+			}
+			f.Channels = int(be.Uint16(b[0:]))
+			f.Frames = int64(be.Uint32(b[2:]))
+			f.BitDepth = int(be.Uint16(b[6:]))
+			f.SampleRate = int(math.Round(extended(b[8:18])))
+			if compressed {
+				if size < 22 {
+					return format{}, unsupported("invalid COMM chunk")
+				}
+				switch kind := string(b[18:22]); kind {
+				case "NONE", "twos":
+				case "sowt":
+					f.bigEndian = false
+				case "fl32", "FL32":
+					f.float, f.BitDepth = true, 32
+				case "fl64", "FL64":
+					f.float, f.BitDepth = true, 64
+				default:
+					return format{}, unsupported("AIFF-C compression %q: the native decoder reads uncompressed samples", kind)
+				}
+			}
+			// AIFF rounds sample sizes like 20 bits up to whole bytes.
+			f.BitDepth = (f.BitDepth + 7) / 8 * 8
+			haveComm = true
+		case "SSND":
+			if !haveComm {
+				return format{}, unsupported("SSND chunk before the COMM chunk")
+			}
+			var b [8]byte
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				return format{}, unsupported("truncated SSND chunk")
+			}
+			offset, err := r.Seek(int64(be.Uint32(b[0:])), io.SeekCurrent)
+			if err != nil {
+				return format{}, err
+			}
+			f.dataOffset = offset
+			return f, nil
+		default:
+			if _, err := r.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return format{}, err
+			}
+		}
+	}
+}
+
+// extended converts an 80-bit IEEE 754 extended float, the AIFF sample rate.
+func extended(b []byte) float64 {
+	exp := int(binary.BigEndian.Uint16(b[0:]) & 0x7fff)
+	mant := binary.BigEndian.Uint64(b[2:])
+	if exp == 0 && mant == 0 {
+		return 0
+	}
+	return math.Ldexp(float64(mant), exp-16383-63)
+}
+
+// codecName returns FFmpeg's name for f's samples.
+func codecName(f format) string {
+	kind := "s"
+	switch {
+	case f.float:
+		kind = "f"
+	case f.unsigned:
+		kind = "u"
+	}
+	if f.BitDepth == 8 {
+		return fmt.Sprintf("pcm_%s8", kind)
+	}
+	endian := "le"
+	if f.bigEndian {
+		endian = "be"
+	}
+	return fmt.Sprintf("pcm_%s%d%s", kind, f.BitDepth, endian)
+}
+
+// Reader streams a WAV or AIFF file's samples as a mono downmix, and
+// optionally as interleaved float32 for the encoder, with the methods of an
+// audio.Source.
+type Reader struct {
+	file   *os.File
+	format format
+	pos    int64 // Sample frames read
+
+	raw    []byte    // Undecoded sample frames, readFrames long
+	frame  []float64 // One decoded sample frame, reused
+	out    int       // Output channels; 0 until EnableOutput
+	output []float32 // Interleaved samples matching the reads since Output
+}
+
+// Open opens a WAV or AIFF file for reading from its first sample.
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, openFailure(err)
+	}
+	f, err := parse(file)
+	if err == nil {
+		_, err = file.Seek(f.dataOffset, io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Reader{
+		file:   file,
+		raw:    make([]byte, readFrames*f.blockAlign()),
+		format: f,
+		frame:  make([]float64, f.Channels),
+	}, nil
+}
+
+// OpenAt opens path like Open and seeks to start, for rendering a section of
+// the file.
+func OpenAt(path string, start time.Duration) (*Reader, error) {
+	r, err := Open(path)
+	if err != nil || start <= 0 {
+		return r, err
+	}
+	if err := r.Seek(start); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// ReadInto fills dst with the next samples, downmixed to mono as FFmpeg
+// downmixes stereo, each channel at -3 dB, and returns the number written.
+// At end of stream it returns the final partial count, then io.EOF.
+func (r *Reader) ReadInto(dst []float64) (int, error) {
+	n := 0
+	for n < len(dst) {
+		frames := min(int64(len(dst)-n), int64(readFrames), r.format.Frames-r.pos)
+		if frames <= 0 {
+			break
+		}
+		align := r.format.blockAlign()
+		got, err := io.ReadFull(r.file, r.raw[:int(frames)*align])
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				return n, err
+			}
+			// The file ended before its header said it would.
+			frames = int64(got / align)
+			r.format.Frames = r.pos + frames
+		}
+		for i := range int(frames) {
+			dst[n] = r.decode(r.raw[i*align:])
+			n++
+		}
+		r.pos += frames
+	}
+	if n == 0 && len(dst) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// decode converts the sample frame at the start of b, appending it to the
+// output when enabled, and returns its mono downmix.
+func (r *Reader) decode(b []byte) float64 {
+	bytesPer := r.format.BitDepth / 8
+	for c := range r.frame {
+		r.frame[c] = r.sample(b[c*bytesPer:])
+	}
+	switch r.out {
+	case 0:
+	case 1:
+		r.output = append(r.output, float32(mono(r.frame)))
+	default:
+		r.output = append(r.output, float32(r.frame[0]), float32(r.frame[len(r.frame)-1]))
+	}
+	return mono(r.frame)
+}
+
+// mono returns a sample frame's mono downmix.
+func mono(frame []float64) float64 {
+	if len(frame) == 1 {
+		return frame[0]
+	}
+	return (frame[0] + frame[1]) * math.Sqrt2 / 2
+}
+
+// sample converts one sample at the start of b to float in [-1, 1).
+func (r *Reader) sample(b []byte) float64 {
+	f := r.format
+	var order binary.ByteOrder = binary.LittleEndian
+	if f.bigEndian {
+		order = binary.BigEndian
+	}
+	switch {
+	case f.float && f.BitDepth == 32:
+		return float64(math.Float32frombits(order.Uint32(b)))
+	case f.float:
+		return math.Float64frombits(order.Uint64(b))
+	case f.unsigned:
+		return (float64(b[0]) - 128) / 128
+	}
+	bytesPer := f.BitDepth / 8
+	var v uint32
+	for k := range bytesPer {
+		i := k
+		if !f.bigEndian {
+			i = bytesPer - 1 - k
+		}
+		v = v<<8 | uint32(b[i])
+	}
+	// Move the sample to the top of 32 bits to sign-extend it.
+	return float64(int32(v<<(32-f.BitDepth))) / (1 << 31) //nolint:gosec // two's complement reinterpretation
+}
+
+// EnableOutput makes the reader also return the samples as interleaved
+// float32 with channels channels, 1 or 2: the source's own, its mono
+// downmix or a mono source on both sides. Call it before the first read, or
+// straight after a Seek.
+func (r *Reader) EnableOutput(channels int) error {
+	if channels < 1 || channels > 2 {
+		return fmt.Errorf("the native decoder writes mono or stereo, not %d channels", channels)
+	}
+	r.out = channels
+	return nil
+}
+
+// Output returns the interleaved samples matching the mono samples read
+// since the last call. The slice is only valid until the next read. Without
+// EnableOutput it returns nil.
+func (r *Reader) Output() []float32 {
+	out := r.output
+	r.output = r.output[:0]
+	return out
+}
+
+// Seek positions the reader so the next read returns the sample at start;
+// the samples are uncompressed, so the cut is exact. Seeking past the end
+// leaves the reader at end of stream.
+func (r *Reader) Seek(start time.Duration) error {
+	frame := min(max(int64(math.Round(start.Seconds()*float64(r.format.SampleRate))), 0), r.format.Frames)
+	if _, err := r.file.Seek(r.format.dataOffset+frame*int64(r.format.blockAlign()), io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to %v: %w", start, err)
+	}
+	r.pos = frame
+	r.output = r.output[:0]
+	return nil
+}
+
+// SampleRate returns the audio sample rate in Hz.
+func (r *Reader) SampleRate() int {
+	return r.format.SampleRate
+}
+
+// Channels returns the channel count of the source.
+func (r *Reader) Channels() int {
+	return r.format.Channels
+}
+
+// Close closes the file.
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,296 @@
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/linuxmatters/jivefire/internal/failure"
+)
+
+const testRate = 8000
+
+// testSample is channel c of sample frame i in every test file: a ramp
+// that fits all sample sizes exactly.
+func testSample(i, c int) float64 {
+	return float64((i%100)-50)/64 + float64(c)/128
+}
+
+// samples encodes frames sample frames of f's layout.
+func samples(f format, frames int) []byte {
+	var order binary.ByteOrder = binary.LittleEndian
+	if f.bigEndian {
+		order = binary.BigEndian
+	}
+	bytesPer := f.BitDepth / 8
+	data := make([]byte, frames*f.Channels*bytesPer)
+	for i := range frames {
+		for c := range f.Channels {
+			v := testSample(i, c)
+			b := data[(i*f.Channels+c)*bytesPer:]
+			switch {
+			case f.float && f.BitDepth == 32:
+				order.PutUint32(b, math.Float32bits(float32(v)))
+			case f.float:
+				order.PutUint64(b, math.Float64bits(v))
+			case f.unsigned:
+				b[0] = uint8(128 + v*128)
+			default:
+				s := uint64(int64(v * float64(int64(1)<<(f.BitDepth-1))))
+				for k := range bytesPer {
+					shift := 8 * k
+					if f.bigEndian {
+						shift = 8 * (bytesPer - 1 - k)
+					}
+					b[k] = byte(s >> shift)
+				}
+			}
+		}
+	}
+	return data
+}
+
+// chunk returns a RIFF or IFF chunk, padded to an even length.
+func chunk(order binary.AppendByteOrder, id string, body []byte) []byte {
+	b := append([]byte(id), order.AppendUint32(nil, uint32(len(body)))...)
+	b = append(b, body...)
+	if len(body)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// wavFile returns a WAV file of frames sample frames with format tag tag.
+func wavFile(tag uint16, f format, frames int) []byte {
+	le := binary.LittleEndian
+	fmtChunk := le.AppendUint16(nil, tag)
+	fmtChunk = le.AppendUint16(fmtChunk, uint16(f.Channels))
+	fmtChunk = le.AppendUint32(fmtChunk, uint32(f.SampleRate))
+	fmtChunk = le.AppendUint32(fmtChunk, uint32(f.SampleRate*f.blockAlign()))
+	fmtChunk = le.AppendUint16(fmtChunk, uint16(f.blockAlign()))
+	fmtChunk = le.AppendUint16(fmtChunk, uint16(f.BitDepth))
+	if tag == 0xfffe {
+		sub := uint16(1)
+		if f.float {
+			sub = 3
+		}
+		fmtChunk = le.AppendUint16(fmtChunk, 22)
+		fmtChunk = le.AppendUint16(fmtChunk, uint16(f.BitDepth))
+		fmtChunk = le.AppendUint32(fmtChunk, 3)
+		fmtChunk = le.AppendUint16(fmtChunk, sub)
+		fmtChunk = append(fmtChunk, "\x00\x00\x00\x00\x10\x00\x80\x00\x00\xaa\x00\x38\x9b\x71"...)
+	}
+	body := []byte("WAVE")
+	body = append(body, chunk(le, "fmt ", fmtChunk)...)
+	body = append(body, chunk(le, "LIST", []byte("INFOISFT\x03\x00\x00\x00jf\x00\x00"))...)
+	body = append(body, chunk(le, "data", samples(f, frames))...)
+	return chunk(le, "RIFF", body)
+}
+
+// aiffFile returns an AIFF file of frames sample frames, or AIFF-C with
+// compression type kind when kind is set.
+func aiffFile(kind string, f format, frames int) []byte {
+	be := binary.BigEndian
+	comm := be.AppendUint16(nil, uint16(f.Channels))
+	comm = be.AppendUint32(comm, uint32(frames))
+	comm = be.AppendUint16(comm, uint16(f.BitDepth))
+	// 8000 Hz as an 80-bit extended float
+	comm = append(comm, 0x40, 0x0b, 0xfa, 0, 0, 0, 0, 0, 0, 0)
+	form := "AIFF"
+	if kind != "" {
+		form = "AIFC"
+		comm = append(comm, kind...)
+		comm = append(comm, 0)
+	}
+	ssnd := append(make([]byte, 8), samples(f, frames)...)
+	body := []byte(form)
+	body = append(body, chunk(be, "COMM", comm)...)
+	body = append(body, chunk(be, "SSND", ssnd)...)
+	return chunk(be, "FORM", body)
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func layout(channels, bits int) format {
+	return format{Info: Info{SampleRate: testRate, Channels: channels, BitDepth: bits}}
+}
+
+// TestProbe verifies each supported layout is recognised with FFmpeg's
+// codec name, and decodes to the samples written.
+func TestProbe(t *testing.T) {
+	float32LE := layout(1, 32)
+	float32LE.float = true
+	float64LE := layout(2, 64)
+	float64LE.float = true
+	unsigned := layout(1, 8)
+	unsigned.unsigned = true
+	bigEndian := layout(2, 16)
+	bigEndian.bigEndian = true
+	bigFloat := layout(1, 32)
+	bigFloat.bigEndian, bigFloat.float = true, true
+
+	tests := []struct {
+		name  string
+		file  []byte
+		codec string
+	}{
+		{"u8", wavFile(1, unsigned, 300), "pcm_u8"},
+		{"s16", wavFile(1, layout(2, 16), 300), "pcm_s16le"},
+		{"s24", wavFile(1, layout(1, 24), 301), "pcm_s24le"},
+		{"s32", wavFile(1, layout(2, 32), 300), "pcm_s32le"},
+		{"f32", wavFile(3, float32LE, 300), "pcm_f32le"},
+		{"f64", wavFile(3, float64LE, 300), "pcm_f64le"},
+		{"extensible", wavFile(0xfffe, layout(2, 24), 300), "pcm_s24le"},
+		{"aiff", aiffFile("", bigEndian, 300), "pcm_s16be"},
+		{"aifc sowt", aiffFile("sowt", layout(1, 16), 300), "pcm_s16le"},
+		{"aifc fl32", aiffFile("fl32", bigFloat, 300), "pcm_f32be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "in", tt.file)
+			info, err := Probe(path)
+			if err != nil {
+				t.Fatalf("Probe() error: %v", err)
+			}
+			if info.Codec != tt.codec || info.SampleRate != testRate || info.Frames < 300 {
+				t.Fatalf("Probe() = %+v, want %s at %d Hz", info, tt.codec, testRate)
+			}
+			if want := time.Duration(info.Frames) * time.Second / testRate; info.Duration != want {
+				t.Errorf("Duration = %v, want %v", info.Duration, want)
+			}
+
+			r, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open() error: %v", err)
+			}
+			defer r.Close()
+			got := make([]float64, info.Frames)
+			if n, err := r.ReadInto(got); n != len(got) || err != nil {
+				t.Fatalf("ReadInto() = %d, %v, want %d", n, err, len(got))
+			}
+			for i, v := range got {
+				want := testSample(i, 0)
+				if info.Channels == 2 {
+					want = (testSample(i, 0) + testSample(i, 1)) * math.Sqrt2 / 2
+				}
+				if math.Abs(v-want) > 1e-6 {
+					t.Fatalf("sample %d = %v, want %v", i, v, want)
+				}
+			}
+		})
+	}
+}
+
+// TestReadInto verifies the end of stream arrives as a partial read then
+// io.EOF, and that Output returns the interleaved samples of each read.
+func TestReadInto(t *testing.T) {
+	r, err := Open(writeFile(t, "in.wav", wavFile(1, layout(2, 16), 5000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := r.EnableOutput(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.EnableOutput(6); err == nil {
+		t.Error("EnableOutput(6) succeeded, want an error")
+	}
+
+	buf := make([]float64, 4500)
+	if n, err := r.ReadInto(buf); n != 4500 || err != nil {
+		t.Fatalf("first ReadInto() = %d, %v", n, err)
+	}
+	out := r.Output()
+	if len(out) != 9000 || out[2*4321+1] != float32(testSample(4321, 1)) {
+		t.Fatalf("Output() has %d samples, want 9000 matching the source", len(out))
+	}
+	if n, err := r.ReadInto(buf); n != 500 || err != nil {
+		t.Fatalf("second ReadInto() = %d, %v, want 500, nil", n, err)
+	}
+	if got := len(r.Output()); got != 1000 {
+		t.Errorf("Output() after the partial read has %d samples, want 1000", got)
+	}
+	if n, err := r.ReadInto(buf); n != 0 || !errors.Is(err, io.EOF) {
+		t.Errorf("ReadInto() at the end = %d, %v, want io.EOF", n, err)
+	}
+}
+
+// TestSeek verifies a seek lands on the exact sample, also after end of
+// stream, and that seeking past the end reads nothing.
+func TestSeek(t *testing.T) {
+	r, err := OpenAt(writeFile(t, "in.wav", wavFile(1, layout(1, 16), 8000)), 250*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	buf := make([]float64, 8000)
+	if n, _ := r.ReadInto(buf); n != 6000 || buf[0] != testSample(2000, 0) {
+		t.Fatalf("ReadInto() after OpenAt = %d samples from %v, want 6000 from sample 2000", n, buf[0])
+	}
+	if err := r.Seek(100 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := r.ReadInto(buf[:1]); n != 1 || buf[0] != testSample(800, 0) {
+		t.Errorf("ReadInto() after Seek = %v, want sample 800", buf[0])
+	}
+	if err := r.Seek(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := r.ReadInto(buf); n != 0 || !errors.Is(err, io.EOF) {
+		t.Errorf("ReadInto() past the end = %d, %v, want io.EOF", n, err)
+	}
+}
+
+// TestTruncated verifies a file shorter than its header claims, as a
+// recorder that stopped leaves it, reads the frames that are there.
+func TestTruncated(t *testing.T) {
+	file := wavFile(1, layout(1, 16), 1000)
+	path := writeFile(t, "in.wav", file[:len(file)-501])
+	info, err := Probe(path)
+	if err != nil || info.Frames != 749 {
+		t.Fatalf("Probe() = %d frames, %v, want 749", info.Frames, err)
+	}
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	buf := make([]float64, 1000)
+	if n, err := r.ReadInto(buf); n != 749 || err != nil {
+		t.Errorf("ReadInto() = %d, %v, want 749", n, err)
+	}
+}
+
+// TestUnsupported verifies files the native decoder cannot read fail as an
+// unsupported format, and a missing file as not found.
+func TestUnsupported(t *testing.T) {
+	adpcm := wavFile(2, layout(1, 16), 10)
+	tests := map[string][]byte{
+		"not audio": []byte("ID3\x04\x00\x00\x00\x00\x00\x00\xff\xfb"),
+		"surround":  wavFile(1, layout(6, 16), 10),
+		"adpcm":     adpcm,
+		"ulaw aifc": aiffFile("ulaw", layout(1, 16), 10),
+		"12-bit":    wavFile(1, layout(1, 12), 10),
+	}
+	for name, data := range tests {
+		_, err := Probe(writeFile(t, "in", data))
+		if !errors.Is(err, failure.ErrUnsupportedFormat) {
+			t.Errorf("%s: Probe() error = %v, want ErrUnsupportedFormat", name, err)
+		}
+	}
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.wav")); !errors.Is(err, failure.ErrInputNotFound) {
+		t.Errorf("Open() of a missing file = %v, want ErrInputNotFound", err)
+	}
+}
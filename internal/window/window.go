@@ -0,0 +1,139 @@
+// Package window shows the frames being encoded in a real video window by
+// piping them, uncompressed, to an ffplay child process.
+package window
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Player is the program frames are piped to. It is looked up on PATH; the
+// static binary carries no display code of its own.
+const Player = "ffplay"
+
+// Window streams RGBA frames to a player process. Frames are handed to a
+// writer goroutine through a one-slot queue and dropped while the player is
+// still busy with the previous one, so a slow window never holds up encoding.
+type Window struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	frames chan []byte   // frames waiting for the writer
+	free   chan []byte   // spare frame buffers
+	done   chan struct{} // closed when the writer exits
+	closed atomic.Bool   // the player went away (window closed by the user)
+	once   sync.Once
+}
+
+// playerArgs returns the ffplay arguments for raw RGBA frames of the given
+// size and rate read from stdin.
+func playerArgs(width, height, fps int) []string {
+	return []string{
+		"-hide_banner", "-loglevel", "error",
+		"-window_title", "Jivefire preview",
+		"-f", "rawvideo",
+		"-pixel_format", "rgba",
+		"-video_size", strconv.Itoa(width) + "x" + strconv.Itoa(height),
+		"-framerate", strconv.Itoa(fps),
+		"-an",
+		"-i", "pipe:0",
+	}
+}
+
+// Available reports whether the player can be found on PATH.
+func Available() error {
+	if _, err := exec.LookPath(Player); err != nil {
+		return fmt.Errorf("%s not found on PATH", Player)
+	}
+	return nil
+}
+
+// Open starts the player for width×height RGBA frames at fps.
+func Open(width, height, fps int) (*Window, error) {
+	if err := Available(); err != nil {
+		return nil, err
+	}
+	return start(exec.Command(Player, playerArgs(width, height, fps)...), width*height*4) //nolint:gosec // fixed program and arguments
+}
+
+// start runs cmd with its stdin as the frame pipe, for frames of frameSize
+// bytes.
+func start(cmd *exec.Cmd, frameSize int) (*Window, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating %s pipe: %w", Player, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", Player, err)
+	}
+
+	w := &Window{
+		cmd:    cmd,
+		stdin:  stdin,
+		frames: make(chan []byte, 1),
+		free:   make(chan []byte, 2),
+		done:   make(chan struct{}),
+	}
+	w.free <- make([]byte, frameSize)
+	w.free <- make([]byte, frameSize)
+
+	go w.write()
+	return w, nil
+}
+
+// write feeds queued frames to the player until the queue closes. After a
+// failed write, normally because the window was closed, frames are recycled
+// unwritten.
+func (w *Window) write() {
+	defer close(w.done)
+	for buf := range w.frames {
+		if !w.closed.Load() {
+			if _, err := w.stdin.Write(buf); err != nil {
+				w.closed.Store(true)
+			}
+		}
+		w.free <- buf
+	}
+}
+
+// WriteFrame queues a copy of pix for display. It never blocks: the frame is
+// dropped if the player has not caught up or has gone away.
+func (w *Window) WriteFrame(pix []byte) {
+	if w.closed.Load() {
+		return
+	}
+	select {
+	case buf := <-w.free:
+		copy(buf, pix)
+		select {
+		case w.frames <- buf:
+		default:
+			w.free <- buf
+		}
+	default:
+	}
+}
+
+// Closed reports whether the player has gone away, so frames are no longer
+// shown.
+func (w *Window) Closed() bool {
+	return w.closed.Load()
+}
+
+// Close stops the player and closes its window. The player is killed rather
+// than left to play out its buffered frames after the encode has finished,
+// which also unblocks a write stuck on a stalled window. Safe to call more
+// than once.
+func (w *Window) Close() {
+	w.once.Do(func() {
+		_ = w.cmd.Process.Kill()
+		close(w.frames)
+		<-w.done
+		_ = w.stdin.Close()
+		_ = w.cmd.Wait()
+	})
+}
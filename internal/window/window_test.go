@@ -0,0 +1,62 @@
+package window
+
+import (
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPlayerArgs(t *testing.T) {
+	args := playerArgs(1280, 720, 30)
+	for _, want := range [][]string{
+		{"-pixel_format", "rgba"},
+		{"-video_size", "1280x720"},
+		{"-framerate", "30"},
+		{"-i", "pipe:0"},
+	} {
+		i := slices.Index(args, want[0])
+		if i < 0 || i+1 >= len(args) || args[i+1] != want[1] {
+			t.Errorf("playerArgs() missing %s %s: %q", want[0], want[1], args)
+		}
+	}
+}
+
+// TestWindowWritesFrames verifies frames reach the player's stdin and Close
+// returns once it has been stopped.
+func TestWindowWritesFrames(t *testing.T) {
+	w, err := start(exec.Command("sh", "-c", "cat >/dev/null"), 16)
+	if err != nil {
+		t.Skipf("sh unavailable: %v", err)
+	}
+	frame := make([]byte, 16)
+	for range 100 {
+		w.WriteFrame(frame)
+	}
+	if w.Closed() {
+		t.Error("Closed() = true while the player is running")
+	}
+	w.Close()
+	w.Close() // a second Close is a no-op
+}
+
+// TestWindowPlayerExits verifies a player that goes away, as when its
+// window is closed, makes WriteFrame a no-op rather than blocking.
+func TestWindowPlayerExits(t *testing.T) {
+	w, err := start(exec.Command("true"), 1<<20)
+	if err != nil {
+		t.Skipf("true unavailable: %v", err)
+	}
+	defer w.Close()
+
+	frame := make([]byte, 1<<20)
+	deadline := time.Now().Add(5 * time.Second)
+	for !w.Closed() && time.Now().Before(deadline) {
+		w.WriteFrame(frame)
+		time.Sleep(time.Millisecond)
+	}
+	if !w.Closed() {
+		t.Fatal("writes to an exited player never failed")
+	}
+	w.WriteFrame(frame)
+}
@@ -0,0 +1,41 @@
+package yuv
+
+// native enables the architecture's vector row converters. Tests and
+// benchmarks turn it off to compare against the plain Go loops.
+var native = true
+
+// RowNV12 converts one row of RGBA pixels to luma in y and, when uv is
+// non-nil, NV12's interleaved Cb/Cr pairs sampled from the even pixels. The
+// row is len(y) pixels wide; rgba holds four bytes per pixel and uv at least
+// len(y) rounded up to even.
+func (m *Matrix) RowNV12(y, uv, rgba []byte) {
+	x := 0
+	if native {
+		x = rowNV12Native(m, y, uv, rgba)
+	}
+	for ; x < len(y); x++ {
+		r, g, b := int32(rgba[x*4]), int32(rgba[x*4+1]), int32(rgba[x*4+2])
+		y[x] = m.Y(r, g, b)
+		if uv != nil && x&1 == 0 {
+			uv[x] = m.Cb(r, g, b)
+			uv[x+1] = m.Cr(r, g, b)
+		}
+	}
+}
+
+// RowYUV is RowNV12 for YUV420P, writing the chroma of the even pixels to
+// separate u and v planes, or none when they are nil.
+func (m *Matrix) RowYUV(y, u, v, rgba []byte) {
+	x := 0
+	if native {
+		x = rowYUVNative(m, y, u, v, rgba)
+	}
+	for ; x < len(y); x++ {
+		r, g, b := int32(rgba[x*4]), int32(rgba[x*4+1]), int32(rgba[x*4+2])
+		y[x] = m.Y(r, g, b)
+		if u != nil && x&1 == 0 {
+			u[x>>1] = m.Cb(r, g, b)
+			v[x>>1] = m.Cr(r, g, b)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package yuv
+
+// neonBlock is the pixels the NEON kernels convert per iteration: one
+// 64-byte de-interleaving load of RGBA.
+const neonBlock = 16
+
+// neonCoefficients is a Matrix laid out for the NEON kernels, which multiply
+// unsigned 16-bit lanes by unsigned 16-bit coefficients. The luma terms are
+// all positive; of the chroma terms the negative ones are stored as
+// magnitudes and subtracted, which wraps to the same 32-bit sums as Matrix.
+type neonCoefficients struct {
+	luma   [8]uint16 // YR, YG, YB
+	chroma [8]uint16 // -CbR, -CbG, CbB, 0, CrR, -CrG, -CrB
+	yBias  uint32
+}
+
+func (m *Matrix) neonCoefficients() neonCoefficients {
+	//nolint:gosec // coefficients and magnitudes are within 0-65535, the bias positive
+	return neonCoefficients{
+		luma:   [8]uint16{uint16(m.YR), uint16(m.YG), uint16(m.YB)},
+		chroma: [8]uint16{uint16(-m.CbR), uint16(-m.CbG), uint16(m.CbB), 0, uint16(m.CrR), uint16(-m.CrG), uint16(-m.CrB)},
+		yBias:  uint32(m.yBias),
+	}
+}
+
+// The NEON kernels convert blocks×neonBlock pixels from rgba. Chroma is
+// sampled from the even pixels, as in Matrix.Cb and Matrix.Cr, and clamped
+// the same way, so the output matches the Go loops bit for bit.
+//
+//go:noescape
+func rowYNEON(c *neonCoefficients, rgba, y *byte, blocks int)
+
+//go:noescape
+func rowNV12NEON(c *neonCoefficients, rgba, y, uv *byte, blocks int)
+
+//go:noescape
+func rowYUVNEON(c *neonCoefficients, rgba, y, u, v *byte, blocks int)
+
+// rowNV12Native converts the whole NEON blocks of the row, returning the
+// pixels done.
+func rowNV12Native(m *Matrix, y, uv, rgba []byte) int {
+	blocks := len(y) / neonBlock
+	if blocks == 0 {
+		return 0
+	}
+	n := blocks * neonBlock
+	_ = rgba[n*4-1]
+	c := m.neonCoefficients()
+	if uv == nil {
+		rowYNEON(&c, &rgba[0], &y[0], blocks)
+		return n
+	}
+	_ = uv[n-1]
+	rowNV12NEON(&c, &rgba[0], &y[0], &uv[0], blocks)
+	return n
+}
+
+// rowYUVNative converts the whole NEON blocks of the row, returning the
+// pixels done.
+func rowYUVNative(m *Matrix, y, u, v, rgba []byte) int {
+	blocks := len(y) / neonBlock
+	if blocks == 0 {
+		return 0
+	}
+	n := blocks * neonBlock
+	_ = rgba[n*4-1]
+	c := m.neonCoefficients()
+	if u == nil {
+		rowYNEON(&c, &rgba[0], &y[0], blocks)
+		return n
+	}
+	_, _ = u[n/2-1], v[n/2-1]
+	rowYUVNEON(&c, &rgba[0], &y[0], &u[0], &v[0], blocks)
+	return n
+}
@@ -0,0 +1,171 @@
+#include "textflag.h"
+
+// NEON RGBA→YCbCr row kernels. Each iteration loads 16 pixels with VLD4,
+// which splits R, G, B and A into V4-V7, and widens R, G and B to 16 bits:
+// V16/V17, V18/V19 and V20/V21, low and high eight pixels. Luma multiplies
+// those by the coefficients in V0 into 32-bit sums, adds the bias in V2 and
+// keeps the top halves, which are below 256. Chroma takes the even pixels'
+// R, G and B (V8-V10), subtracts the negative terms' magnitudes in V1, adds
+// the rounding bias in V3, then shifts and narrows with signed-to-unsigned
+// saturation, clamping as Matrix.Cb and Matrix.Cr do: Cb in V14, Cr in V15.
+//
+// Go's assembler has no mnemonics for the widening multiplies or saturating
+// narrows, so those are WORD-encoded, each with the instruction it encodes.
+
+// LOAD loads the coefficients c from R0 into V0-V3.
+#define LOAD \
+	VLD1    (R0), [V0.H8, V1.H8]; \
+	MOVWU   32(R0), R6; \
+	VDUP    R6, V2.S4; \
+	MOVW    $(257<<15), R6; \
+	VDUP    R6, V3.S4
+
+// WIDEN loads the next 16 pixels from R1 and widens R, G and B.
+#define WIDEN \
+	VLD4.P  64(R1), [V4.B16, V5.B16, V6.B16, V7.B16]; \
+	VUXTL   V4.B8, V16.H8; \
+	VUXTL2  V4.B16, V17.H8; \
+	VUXTL   V5.B8, V18.H8; \
+	VUXTL2  V5.B16, V19.H8; \
+	VUXTL   V6.B8, V20.H8; \
+	VUXTL2  V6.B16, V21.H8
+
+// LUMA computes the 16 luma bytes into V28.
+#define LUMA \
+	WORD    $0x2f40a216; \
+	WORD    $0x2f502256; \
+	WORD    $0x2f602296; \
+	WORD    $0x6f40a217; \
+	WORD    $0x6f502257; \
+	WORD    $0x6f602297; \
+	WORD    $0x2f40a238; \
+	WORD    $0x2f502278; \
+	WORD    $0x2f6022b8; \
+	WORD    $0x6f40a239; \
+	WORD    $0x6f502279; \
+	WORD    $0x6f6022b9; \
+	VADD    V2.S4, V22.S4, V22.S4; \
+	VADD    V2.S4, V23.S4, V23.S4; \
+	VADD    V2.S4, V24.S4, V24.S4; \
+	VADD    V2.S4, V25.S4, V25.S4; \
+	VUZP2   V23.H8, V22.H8, V26.H8; \
+	VUZP2   V25.H8, V24.H8, V27.H8; \
+	VUZP1   V27.B16, V26.B16, V28.B16
+
+// LUMA's WORDs, in order:
+//	umull  v22.4s, v16.4h, v0.h[0]
+//	umlal  v22.4s, v18.4h, v0.h[1]
+//	umlal  v22.4s, v20.4h, v0.h[2]
+//	umull2 v23.4s, v16.8h, v0.h[0]
+//	umlal2 v23.4s, v18.8h, v0.h[1]
+//	umlal2 v23.4s, v20.8h, v0.h[2]
+//	umull  v24.4s, v17.4h, v0.h[0]
+//	umlal  v24.4s, v19.4h, v0.h[1]
+//	umlal  v24.4s, v21.4h, v0.h[2]
+//	umull2 v25.4s, v17.8h, v0.h[0]
+//	umlal2 v25.4s, v19.8h, v0.h[1]
+//	umlal2 v25.4s, v21.8h, v0.h[2]
+
+// CHROMA computes the eight Cb bytes into V14 and Cr bytes into V15.
+#define CHROMA \
+	VUZP1   V17.H8, V16.H8, V8.H8; \
+	VUZP1   V19.H8, V18.H8, V9.H8; \
+	VUZP1   V21.H8, V20.H8, V10.H8; \
+	WORD    $0x2f61a14b; \
+	WORD    $0x2f41610b; \
+	WORD    $0x2f51612b; \
+	WORD    $0x6f61a14c; \
+	WORD    $0x6f41610c; \
+	WORD    $0x6f51612c; \
+	VADD    V3.S4, V11.S4, V11.S4; \
+	VADD    V3.S4, V12.S4, V12.S4; \
+	WORD    $0x2f10856d; \
+	WORD    $0x6f10858d; \
+	WORD    $0x2e2149ae; \
+	WORD    $0x2f41a91d; \
+	WORD    $0x2f51693d; \
+	WORD    $0x2f61695d; \
+	WORD    $0x6f41a91e; \
+	WORD    $0x6f51693e; \
+	WORD    $0x6f61695e; \
+	VADD    V3.S4, V29.S4, V29.S4; \
+	VADD    V3.S4, V30.S4, V30.S4; \
+	WORD    $0x2f1087bf; \
+	WORD    $0x6f1087df; \
+	WORD    $0x2e214bef
+
+// CHROMA's WORDs, in order:
+//	umull    v11.4s, v10.4h, v1.h[2]
+//	umlsl    v11.4s, v8.4h, v1.h[0]
+//	umlsl    v11.4s, v9.4h, v1.h[1]
+//	umull2   v12.4s, v10.8h, v1.h[2]
+//	umlsl2   v12.4s, v8.8h, v1.h[0]
+//	umlsl2   v12.4s, v9.8h, v1.h[1]
+//	sqshrun  v13.4h, v11.4s, #16
+//	sqshrun2 v13.8h, v12.4s, #16
+//	uqxtn    v14.8b, v13.8h
+//	umull    v29.4s, v8.4h, v1.h[4]
+//	umlsl    v29.4s, v9.4h, v1.h[5]
+//	umlsl    v29.4s, v10.4h, v1.h[6]
+//	umull2   v30.4s, v8.8h, v1.h[4]
+//	umlsl2   v30.4s, v9.8h, v1.h[5]
+//	umlsl2   v30.4s, v10.8h, v1.h[6]
+//	sqshrun  v31.4h, v29.4s, #16
+//	sqshrun2 v31.8h, v30.4s, #16
+//	uqxtn    v15.8b, v31.8h
+
+// func rowYNEON(c *neonCoefficients, rgba, y *byte, blocks int)
+TEXT ·rowYNEON(SB), NOSPLIT, $0-32
+	MOVD    c+0(FP), R0
+	MOVD    rgba+8(FP), R1
+	MOVD    y+16(FP), R2
+	MOVD    blocks+24(FP), R5
+	LOAD
+
+yloop:
+	WIDEN
+	LUMA
+	VST1.P  [V28.B16], 16(R2)
+	SUBS    $1, R5, R5
+	BNE     yloop
+	RET
+
+// func rowNV12NEON(c *neonCoefficients, rgba, y, uv *byte, blocks int)
+TEXT ·rowNV12NEON(SB), NOSPLIT, $0-40
+	MOVD    c+0(FP), R0
+	MOVD    rgba+8(FP), R1
+	MOVD    y+16(FP), R2
+	MOVD    uv+24(FP), R3
+	MOVD    blocks+32(FP), R5
+	LOAD
+
+nv12loop:
+	WIDEN
+	LUMA
+	VST1.P  [V28.B16], 16(R2)
+	CHROMA
+	VST2.P  [V14.B8, V15.B8], 16(R3)
+	SUBS    $1, R5, R5
+	BNE     nv12loop
+	RET
+
+// func rowYUVNEON(c *neonCoefficients, rgba, y, u, v *byte, blocks int)
+TEXT ·rowYUVNEON(SB), NOSPLIT, $0-48
+	MOVD    c+0(FP), R0
+	MOVD    rgba+8(FP), R1
+	MOVD    y+16(FP), R2
+	MOVD    u+24(FP), R3
+	MOVD    v+32(FP), R4
+	MOVD    blocks+40(FP), R5
+	LOAD
+
+yuvloop:
+	WIDEN
+	LUMA
+	VST1.P  [V28.B16], 16(R2)
+	CHROMA
+	VST1.P  [V14.B8], 8(R3)
+	VST1.P  [V15.B8], 8(R4)
+	SUBS    $1, R5, R5
+	BNE     yuvloop
+	RET
@@ -0,0 +1,12 @@
+//go:build !arm64
+
+package yuv
+
+// TODO: revisit amd64 with the simd package's AVX2 intrinsics once it leaves
+// GOEXPERIMENT, for potentially 30-50% additional gains over the Go loops.
+
+// rowNV12Native converts no pixels: only arm64 has vector row converters.
+func rowNV12Native(_ *Matrix, _, _, _ []byte) int { return 0 }
+
+// rowYUVNative converts no pixels: only arm64 has vector row converters.
+func rowYUVNative(_ *Matrix, _, _, _, _ []byte) int { return 0 }
@@ -0,0 +1,135 @@
+package yuv
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// matrices are the four colour space and range combinations.
+var matrices = []struct {
+	name string
+	m    Matrix
+}{
+	{"bt601-limited", NewMatrix(BT601, RangeLimited)},
+	{"bt601-full", NewMatrix(BT601, RangeFull)},
+	{"bt709-limited", NewMatrix(BT709, RangeLimited)},
+	{"bt709-full", NewMatrix(BT709, RangeFull)},
+}
+
+// rowPixels returns width random RGBA pixels, led by the colours at the ends
+// of each chroma clamp: the primaries, their complements, black and white.
+func rowPixels(width int) []byte {
+	rng := rand.New(rand.NewPCG(uint64(width), 1))
+	rgba := make([]byte, width*4)
+	for i := range rgba {
+		rgba[i] = byte(rng.UintN(256))
+	}
+	extremes := [][3]byte{{255, 0, 0}, {0, 255, 0}, {0, 0, 255}, {0, 255, 255}, {255, 0, 255}, {255, 255, 0}, {0, 0, 0}, {255, 255, 255}}
+	for i, c := range extremes {
+		if i < width {
+			copy(rgba[i*4:], c[:])
+		}
+	}
+	return rgba
+}
+
+// withoutNative runs fn with the vector row converters off.
+func withoutNative(fn func()) {
+	native = false
+	defer func() { native = true }()
+	fn()
+}
+
+// TestRowConvertersMatchGo verifies the vector row converters, where the
+// architecture has them, give exactly the plain Go loops' output, across
+// widths with and without a partial block at the end, and with luma only.
+func TestRowConvertersMatchGo(t *testing.T) {
+	for _, mat := range matrices {
+		for _, width := range []int{1, 15, 16, 17, 33, 1280} {
+			t.Run(fmt.Sprintf("%s/%d", mat.name, width), func(t *testing.T) {
+				rgba := rowPixels(width)
+				m := mat.m
+				chroma := (width + 1) / 2
+
+				var wantY, wantUV, wantU, wantV, wantLuma []byte
+				withoutNative(func() {
+					wantY, wantUV = make([]byte, width), make([]byte, chroma*2)
+					m.RowNV12(wantY, wantUV, rgba)
+					wantU, wantV = make([]byte, chroma), make([]byte, chroma)
+					m.RowYUV(make([]byte, width), wantU, wantV, rgba)
+					wantLuma = make([]byte, width)
+					m.RowNV12(wantLuma, nil, rgba)
+				})
+
+				y, uv := make([]byte, width), make([]byte, chroma*2)
+				m.RowNV12(y, uv, rgba)
+				if !bytes.Equal(y, wantY) || !bytes.Equal(uv, wantUV) {
+					t.Errorf("RowNV12 differs from the Go loop:\n Y %v\nUV %v\nwant\n Y %v\nUV %v", y, uv, wantY, wantUV)
+				}
+				y, u, v := make([]byte, width), make([]byte, chroma), make([]byte, chroma)
+				m.RowYUV(y, u, v, rgba)
+				if !bytes.Equal(y, wantY) || !bytes.Equal(u, wantU) || !bytes.Equal(v, wantV) {
+					t.Errorf("RowYUV differs from the Go loop")
+				}
+				luma := make([]byte, width)
+				m.RowYUV(luma, nil, nil, rgba)
+				if !bytes.Equal(luma, wantLuma) {
+					t.Errorf("luma-only RowYUV differs from the Go loop")
+				}
+			})
+		}
+	}
+}
+
+// TestRowNV12MatchesMatrix pins the Go loop to the per-pixel Matrix methods.
+func TestRowNV12MatchesMatrix(t *testing.T) {
+	const width = 37
+	rgba := rowPixels(width)
+	m := NewMatrix(BT709, RangeLimited)
+	y, uv := make([]byte, width), make([]byte, width+1)
+	m.RowNV12(y, uv, rgba)
+	for x := range width {
+		r, g, b := int32(rgba[x*4]), int32(rgba[x*4+1]), int32(rgba[x*4+2])
+		if y[x] != m.Y(r, g, b) {
+			t.Fatalf("Y[%d] = %d, want %d", x, y[x], m.Y(r, g, b))
+		}
+		if x%2 == 0 && (uv[x] != m.Cb(r, g, b) || uv[x+1] != m.Cr(r, g, b)) {
+			t.Fatalf("UV[%d] = %d,%d, want %d,%d", x, uv[x], uv[x+1], m.Cb(r, g, b), m.Cr(r, g, b))
+		}
+	}
+}
+
+// BenchmarkRowNV12 converts a 720p frame's worth of rows on one core,
+// through the plain Go loop and the architecture's vector converter (the
+// same loop where there is none).
+func BenchmarkRowNV12(b *testing.B) {
+	const width, height = 1280, 720
+	rgba := rowPixels(width)
+	y, uv := make([]byte, width), make([]byte, width)
+	m := NewMatrix(BT709, RangeLimited)
+	frame := func() {
+		for row := range height {
+			if row%2 == 0 {
+				m.RowNV12(y, uv, rgba)
+			} else {
+				m.RowNV12(y, nil, rgba)
+			}
+		}
+	}
+	b.Run("go", func(b *testing.B) {
+		withoutNative(func() {
+			b.SetBytes(width * height * 4)
+			for b.Loop() {
+				frame()
+			}
+		})
+	})
+	b.Run("native", func(b *testing.B) {
+		b.SetBytes(width * height * 4)
+		for b.Loop() {
+			frame()
+		}
+	})
+}
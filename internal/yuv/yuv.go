@@ -1,9 +1,12 @@
-// Package yuv holds the shared RGB→YCbCr conversion primitives used by both the
-// encoder hot path and the standalone bench-yuv tool.
+// Package yuv holds the shared RGB→YCbCr conversion primitives used by the
+// encoder hot path.
 package yuv
 
 import (
+	"fmt"
+	"math"
 	"runtime"
+	"strings"
 	"sync"
 )
 
@@ -59,6 +62,135 @@ func RGBToCr(r, g, b int32) uint8 {
 	return uint8(cr) //nolint:gosec // value is clamped by branch above
 }
 
+// ColorSpace selects the RGB→YCbCr matrix and the colour tags written to the
+// stream.
+type ColorSpace string
+
+const (
+	BT601 ColorSpace = "bt601" // SD matrix, as Go's image/color uses
+	BT709 ColorSpace = "bt709" // HD matrix, what YouTube and most players expect
+)
+
+// ParseColorSpace validates a --color-space value. "auto" returns the empty
+// ColorSpace, leaving the choice to the encoder.
+func ParseColorSpace(s string) (ColorSpace, error) {
+	switch cs := ColorSpace(strings.ToLower(s)); cs {
+	case BT601, BT709:
+		return cs, nil
+	case "auto":
+		return "", nil
+	}
+	return "", fmt.Errorf("unknown colour space %q (must be auto, bt601 or bt709)", s)
+}
+
+// ColorRange selects whether luma and chroma use the full 0-255 code range or
+// the limited (TV) range of 16-235 for luma and 16-240 for chroma.
+type ColorRange string
+
+const (
+	RangeLimited ColorRange = "limited" // TV range, the default for H.264 delivery
+	RangeFull    ColorRange = "full"    // PC/JPEG range
+)
+
+// ParseColorRange validates a --color-range value.
+func ParseColorRange(s string) (ColorRange, error) {
+	switch cr := ColorRange(strings.ToLower(s)); cr {
+	case RangeLimited, RangeFull:
+		return cr, nil
+	}
+	return "", fmt.Errorf("unknown colour range %q (must be limited or full)", s)
+}
+
+// Matrix holds fixed-point RGB→YCbCr coefficients (scaled by 65536) for one
+// colour space and range. The package-level RGBTo* functions are the BT.601
+// full-range case with the coefficients folded into constants; the encoder
+// uses a Matrix so the output can match what the stream is tagged as.
+type Matrix struct {
+	YR, YG, YB    int32
+	CbR, CbG, CbB int32
+	CrR, CrG, CrB int32
+	yBias         int32 // luma offset plus rounding, pre-scaled
+}
+
+// NewMatrix returns the coefficients for the given colour space and range.
+// BT.601 full range reuses Go's image/color constants exactly, so it matches
+// RGBToY, RGBToCb and RGBToCr bit for bit.
+func NewMatrix(cs ColorSpace, cr ColorRange) Matrix {
+	if cs != BT709 && cr == RangeFull {
+		return Matrix{
+			YR: YR, YG: YG, YB: YB,
+			CbR: CbR, CbG: CbG, CbB: CbB,
+			CrR: CrR, CrG: CrG, CrB: CrB,
+			yBias: 1 << 15,
+		}
+	}
+
+	kr, kb := 0.299, 0.114
+	if cs == BT709 {
+		kr, kb = 0.2126, 0.0722
+	}
+	// Limited range squeezes luma into 219 codes above 16 and chroma into 224
+	// codes around 128.
+	yScale, cScale, yOffset := 1.0, 1.0, 0.0
+	if cr != RangeFull {
+		yScale, cScale, yOffset = 219.0/255, 224.0/255, 16
+	}
+
+	fixed := func(v float64) int32 { return int32(math.Round(v * 65536)) }
+	m := Matrix{
+		YR: fixed(kr * yScale),
+		YB: fixed(kb * yScale),
+		// Chroma extremes sit on pure blue and pure red at exactly ±0.5.
+		CbB: fixed(0.5 * cScale),
+		CbR: fixed(-0.5 * kr / (1 - kb) * cScale),
+		CrR: fixed(0.5 * cScale),
+		CrB: fixed(-0.5 * kb / (1 - kr) * cScale),
+	}
+	// Derive the middle terms so white lands exactly on peak luma and greys
+	// carry no chroma.
+	m.YG = fixed(yScale) - m.YR - m.YB
+	m.CbG = -m.CbR - m.CbB
+	m.CrG = -m.CrR - m.CrB
+	m.yBias = fixed(yOffset) + 1<<15
+	return m
+}
+
+// Y converts RGB to luma. Limited range needs no clamp: the coefficients sum
+// to at most 235 for 8-bit input.
+//
+//go:inline
+func (m *Matrix) Y(r, g, b int32) uint8 {
+	return uint8((m.YR*r + m.YG*g + m.YB*b + m.yBias) >> 16) //nolint:gosec // result is within 0-255
+}
+
+// Cb converts RGB to blue-difference chroma with the same branchless clamp as
+// RGBToCb.
+//
+//go:inline
+func (m *Matrix) Cb(r, g, b int32) uint8 {
+	cb := m.CbR*r + m.CbG*g + m.CbB*b + 257<<15
+	if uint32(cb)&0xff000000 == 0 { //nolint:gosec // intentional bit manipulation
+		cb >>= 16
+	} else {
+		cb = ^(cb >> 31)
+	}
+	return uint8(cb) //nolint:gosec // value is clamped by branch above
+}
+
+// Cr converts RGB to red-difference chroma with the same branchless clamp as
+// RGBToCr.
+//
+//go:inline
+func (m *Matrix) Cr(r, g, b int32) uint8 {
+	cr := m.CrR*r + m.CrG*g + m.CrB*b + 257<<15
+	if uint32(cr)&0xff000000 == 0 { //nolint:gosec // intentional bit manipulation
+		cr >>= 16
+	} else {
+		cr = ^(cr >> 31)
+	}
+	return uint8(cr) //nolint:gosec // value is clamped by branch above
+}
+
 // rowRange is a precomputed row partition reused across every frame.
 type rowRange struct {
 	startY, endY int
@@ -156,6 +288,24 @@ func (p *RowPool) Run(fn func(startY, endY int)) {
 	wg.Wait()
 }
 
+// RunRows is Run over rows [startY, endY) only, split evenly across the
+// workers (or fewer, when there are fewer rows than workers). It is for
+// frames where only part of the image changed.
+func (p *RowPool) RunRows(startY, endY int, fn func(startY, endY int)) {
+	rows := endY - startY
+	if rows <= 0 {
+		return
+	}
+	workers := min(len(p.ranges), rows)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := range workers {
+		r := rowRange{startY: startY + w*rows/workers, endY: startY + (w+1)*rows/workers}
+		p.jobs <- rowJob{r: r, fn: fn, wg: &wg}
+	}
+	wg.Wait()
+}
+
 // Close stops the pool's worker goroutines. The pool must not be used after.
 func (p *RowPool) Close() {
 	close(p.jobs)
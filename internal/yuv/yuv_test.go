@@ -2,6 +2,8 @@ package yuv
 
 import (
 	"image/color"
+	"math"
+	"sync"
 	"testing"
 )
 
@@ -94,3 +96,130 @@ func TestRGBToYCbCr_ChromaClampBoundaries(t *testing.T) {
 		}
 	}
 }
+
+// TestNewMatrix_BT601FullMatchesConstants pins the BT.601 full-range matrix to
+// the package-level functions, so selecting it reproduces the original output.
+func TestNewMatrix_BT601FullMatchesConstants(t *testing.T) {
+	m := NewMatrix(BT601, RangeFull)
+	for r := int32(0); r <= 255; r += 15 {
+		for g := int32(0); g <= 255; g += 15 {
+			for b := int32(0); b <= 255; b += 15 {
+				if m.Y(r, g, b) != RGBToY(r, g, b) || m.Cb(r, g, b) != RGBToCb(r, g, b) || m.Cr(r, g, b) != RGBToCr(r, g, b) {
+					t.Fatalf("Matrix(bt601, full) differs from RGBTo* at (%d,%d,%d)", r, g, b)
+				}
+			}
+		}
+	}
+}
+
+// TestNewMatrix_AgainstReference checks every colour space and range against
+// the floating-point definitions, and that black, white and the primaries land
+// on the code values a decoder expects.
+func TestNewMatrix_AgainstReference(t *testing.T) {
+	cases := []struct {
+		cs     ColorSpace
+		cr     ColorRange
+		kr, kb float64
+	}{
+		{BT601, RangeLimited, 0.299, 0.114},
+		{BT601, RangeFull, 0.299, 0.114},
+		{BT709, RangeLimited, 0.2126, 0.0722},
+		{BT709, RangeFull, 0.2126, 0.0722},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.cs)+"-"+string(c.cr), func(t *testing.T) {
+			m := NewMatrix(c.cs, c.cr)
+			yScale, cScale, yOffset := 1.0, 1.0, 0.0
+			yMin, yMax, cMax := uint8(0), uint8(255), uint8(255)
+			if c.cr == RangeLimited {
+				yScale, cScale, yOffset = 219.0/255, 224.0/255, 16
+				yMin, yMax, cMax = 16, 235, 240
+			}
+
+			for r := 0; r <= 255; r += 51 {
+				for g := 0; g <= 255; g += 51 {
+					for b := 0; b <= 255; b += 51 {
+						fr, fg, fb := float64(r), float64(g), float64(b)
+						y := c.kr*fr + (1-c.kr-c.kb)*fg + c.kb*fb
+						wantY := uint8(math.Round(yOffset + y*yScale))
+						wantCb := uint8(math.Min(255, math.Round(128+(fb-y)/(2*(1-c.kb))*cScale)))
+						wantCr := uint8(math.Min(255, math.Round(128+(fr-y)/(2*(1-c.kr))*cScale)))
+
+						ri, gi, bi := int32(r), int32(g), int32(b)
+						if !diffWithin(m.Y(ri, gi, bi), wantY) || !diffWithin(m.Cb(ri, gi, bi), wantCb) || !diffWithin(m.Cr(ri, gi, bi), wantCr) {
+							t.Errorf("(%d,%d,%d) = %d,%d,%d, want %d,%d,%d (±%d)", r, g, b,
+								m.Y(ri, gi, bi), m.Cb(ri, gi, bi), m.Cr(ri, gi, bi), wantY, wantCb, wantCr, tolerance)
+						}
+					}
+				}
+			}
+
+			if got := m.Y(0, 0, 0); got != yMin {
+				t.Errorf("Y(black) = %d, want %d", got, yMin)
+			}
+			if got := m.Y(255, 255, 255); got != yMax {
+				t.Errorf("Y(white) = %d, want %d", got, yMax)
+			}
+			if cb, cr := m.Cb(200, 200, 200), m.Cr(200, 200, 200); cb != 128 || cr != 128 {
+				t.Errorf("grey chroma = %d,%d, want 128,128", cb, cr)
+			}
+			if got := m.Cb(0, 0, 255); got != cMax {
+				t.Errorf("Cb(blue) = %d, want %d", got, cMax)
+			}
+			if got := m.Cr(255, 0, 0); got != cMax {
+				t.Errorf("Cr(red) = %d, want %d", got, cMax)
+			}
+		})
+	}
+}
+
+// TestParseColorSpaceAndRange verifies the flag values parse case-blind,
+// "auto" leaves the colour space to the encoder, and unknown values fail.
+func TestParseColorSpaceAndRange(t *testing.T) {
+	if got, err := ParseColorSpace("BT709"); err != nil || got != BT709 {
+		t.Errorf("ParseColorSpace(BT709) = %q, %v; want bt709", got, err)
+	}
+	if got, err := ParseColorSpace("auto"); err != nil || got != "" {
+		t.Errorf("ParseColorSpace(auto) = %q, %v; want empty", got, err)
+	}
+	if _, err := ParseColorSpace("rec2020"); err == nil {
+		t.Error("ParseColorSpace(rec2020) succeeded, want error")
+	}
+	if got, err := ParseColorRange("Full"); err != nil || got != RangeFull {
+		t.Errorf("ParseColorRange(Full) = %q, %v; want full", got, err)
+	}
+	if _, err := ParseColorRange("tv"); err == nil {
+		t.Error("ParseColorRange(tv) succeeded, want error")
+	}
+}
+
+// RunRows must visit every row of the span exactly once and nothing outside
+// it, however the span compares with the worker count.
+func TestRowPoolRunRows(t *testing.T) {
+	const height = 720
+	pool := NewRowPool(height)
+	defer pool.Close()
+
+	for _, span := range [][2]int{{0, height}, {101, 619}, {359, 361}, {5, 6}, {400, 400}} {
+		var mu sync.Mutex
+		visits := make([]int, height)
+		pool.RunRows(span[0], span[1], func(startY, endY int) {
+			mu.Lock()
+			defer mu.Unlock()
+			for y := startY; y < endY; y++ {
+				visits[y]++
+			}
+		})
+		for y, n := range visits {
+			want := 0
+			if y >= span[0] && y < span[1] {
+				want = 1
+			}
+			if n != want {
+				t.Errorf("RunRows(%d, %d): row %d visited %d times, want %d", span[0], span[1], y, n, want)
+				break
+			}
+		}
+	}
+}